@@ -151,6 +151,10 @@ Ensure commands are defined in a "_tool.cue" file.
 	cmd.Flags().SetInterspersed(false)
 
 	addInjectionFlags(cmd.Flags(), true)
+	cmd.Flags().Bool(string(flagDryrun), false,
+		"print the tasks in the order in which they would run, without running them")
+	cmd.Flags().StringArray(string(flagConcurrency), nil,
+		"limit the number of tasks running at the same time to N, or to N per kind of task with kind=N")
 
 	return cmd
 }
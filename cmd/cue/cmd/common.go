@@ -29,7 +29,9 @@ import (
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/cuecontext"
 	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/interpreter/wasm"
 	"cuelang.org/go/cue/load"
 	"cuelang.org/go/cue/parser"
 	"cuelang.org/go/cue/token"
@@ -48,6 +50,7 @@ var requestedVersion = os.Getenv("CUE_SYNTAX_OVERRIDE")
 
 var defaultConfig = config{
 	loadCfg: &load.Config{
+		Registry: newRegistry(),
 		ParseFile: func(name string, src interface{}) (*ast.File, error) {
 			version := syntaxVersion
 			if requestedVersion != "" {
@@ -143,6 +146,7 @@ type buildPlan struct {
 	imported []*ast.File
 
 	expressions []ast.Expr // only evaluate these expressions within results
+	queries     []*query   // only output the values selected by these queries
 	schema      ast.Expr   // selects schema in instance for orphaned values
 
 	// orphan placement flags.
@@ -179,12 +183,15 @@ func (b *buildPlan) instances() iterator {
 		b.instance = nil
 	}
 	if len(b.expressions) > 0 {
-		return &expressionIter{
+		i = &expressionIter{
 			iter: i,
 			expr: b.expressions,
 			i:    len(b.expressions),
 		}
 	}
+	if len(b.queries) > 0 {
+		i = &queryIter{iter: i, queries: b.queries}
+	}
 	return i
 }
 
@@ -354,18 +361,41 @@ func (i *expressionIter) value() cue.Value {
 	if len(i.expr) == 0 {
 		return i.iter.value()
 	}
-	v := i.iter.value()
-	path := ""
-	if inst := i.iter.instance(); inst != nil {
-		path = inst.ID()
+	return i.iter.value().EvalExpr(i.expr[i.i])
+}
+
+// queryIter iterates over the values selected by a list of queries within
+// the values of another iterator.
+type queryIter struct {
+	iter    iterator
+	queries []*query
+	values  []cue.Value
+}
+
+func (i *queryIter) err() error { return i.iter.err() }
+func (i *queryIter) close()     { i.iter.close() }
+func (i *queryIter) id() string { return i.iter.id() }
+
+func (i *queryIter) scan() bool {
+	if len(i.values) > 0 {
+		i.values = i.values[1:]
+	}
+	for len(i.values) == 0 {
+		if !i.iter.scan() {
+			return false
+		}
+		v := i.iter.value()
+		for _, q := range i.queries {
+			i.values = append(i.values, q.apply(v)...)
+		}
 	}
-	return v.Context().BuildExpr(i.expr[i.i],
-		cue.Scope(v),
-		cue.InferBuiltins(true),
-		cue.ImportPath(path),
-	)
+	return true
 }
 
+func (i *queryIter) file() *ast.File         { return nil }
+func (i *queryIter) instance() *cue.Instance { return nil }
+func (i *queryIter) value() cue.Value        { return i.values[0] }
+
 type config struct {
 	outMode filetypes.Mode
 
@@ -458,7 +488,7 @@ func (p *buildPlan) getDecoders(b *build.Instance) (schemas, values []*decoderIn
 		}
 		switch f.Encoding {
 		case build.Protobuf, build.YAML, build.JSON, build.JSONL,
-			build.Text, build.Binary:
+			build.XML, build.HCL, build.CSV, build.TSV, build.Text, build.Binary:
 			if f.Interpretation == build.ProtobufJSON {
 				// Need a schema.
 				values = append(values, &decoderInfo{f, nil})
@@ -658,9 +688,14 @@ func parseArgs(cmd *Command, args []string, cfg *config) (p *buildPlan, err erro
 		}
 	}
 
-	if len(p.expressions) > 1 {
+	if len(p.expressions) > 1 || len(p.queries) > 1 {
 		p.encConfig.Stream = true
 	}
+	for _, q := range p.queries {
+		if q.hasWildcard() {
+			p.encConfig.Stream = true
+		}
+	}
 	return p, nil
 }
 
@@ -692,6 +727,13 @@ func (b *buildPlan) parseFlags() (err error) {
 		}
 		b.expressions = append(b.expressions, expr)
 	}
+	for _, s := range flagQuery.StringArray(b.cmd) {
+		q, err := parseQuery(s)
+		if err != nil {
+			return err
+		}
+		b.queries = append(b.queries, q)
+	}
 	if s := flagSchema.String(b.cmd); s != "" {
 		b.schema, err = parser.ParseExpr("--schema", s)
 		if err != nil {
@@ -703,23 +745,55 @@ func (b *buildPlan) parseFlags() (err error) {
 		b.cfg.fileFilter = s
 	}
 	b.encConfig = &encoding.Config{
-		Force:     flagForce.Bool(b.cmd),
-		Mode:      b.cfg.outMode,
-		Stdin:     b.cmd.InOrStdin(),
-		Stdout:    b.cmd.OutOrStdout(),
-		ProtoPath: flagProtoPath.StringArray(b.cmd),
-		AllErrors: flagAllErrors.Bool(b.cmd),
-		PkgName:   flagPackage.String(b.cmd),
-		Strict:    flagStrict.Bool(b.cmd),
+		Force:      flagForce.Bool(b.cmd),
+		Mode:       b.cfg.outMode,
+		Stdin:      b.cmd.InOrStdin(),
+		Stdout:     b.cmd.OutOrStdout(),
+		ProtoPath:  flagProtoPath.StringArray(b.cmd),
+		AllErrors:  flagAllErrors.Bool(b.cmd),
+		PkgName:    flagPackage.String(b.cmd),
+		Strict:     flagStrict.Bool(b.cmd),
+		SortFields: flagSortFields.Bool(b.cmd),
 	}
 	return nil
 }
 
+// newRuntime returns the runtime for building the instances of cmd, which
+// records a profile if requested. It implements @extern("wasm") functions
+// only if the wasm experiment is enabled.
+func newRuntime(cmd *Command) *cue.Runtime {
+	var opts []cuecontext.Option
+	if hasExperiment("wasm") {
+		opts = append(opts, cuecontext.WithInterpreter(wasm.New()))
+	}
+	if cmd.profile != nil {
+		opts = append(opts, cuecontext.WithProfile(cmd.profile))
+	}
+	return (*cue.Runtime)(cuecontext.New(opts...))
+}
+
+// hasExperiment reports whether name is listed in the comma-separated
+// CUE_EXPERIMENT environment variable.
+func hasExperiment(name string) bool {
+	for _, s := range strings.Split(os.Getenv("CUE_EXPERIMENT"), ",") {
+		if strings.TrimSpace(s) == name {
+			return true
+		}
+	}
+	return false
+}
+
 func buildInstances(cmd *Command, binst []*build.Instance) []*cue.Instance {
 	// TODO:
 	// If there are no files and User is true, then use those?
 	// Always use all files in user mode?
-	instances := cue.Build(binst)
+	var instances []*cue.Instance
+	r := newRuntime(cmd)
+	for _, b := range binst {
+		inst, err := r.Build(b)
+		exitIfErr(cmd, inst, err, true)
+		instances = append(instances, inst)
+	}
 	for _, inst := range instances {
 		// TODO: consider merging errors of multiple files, but ensure
 		// duplicates are removed.
@@ -740,11 +814,14 @@ func buildInstances(cmd *Command, binst []*build.Instance) []*cue.Instance {
 }
 
 func buildToolInstances(cmd *Command, binst []*build.Instance) ([]*cue.Instance, error) {
-	instances := cue.Build(binst)
-	for _, inst := range instances {
-		if inst.Err != nil {
-			return nil, inst.Err
+	var instances []*cue.Instance
+	r := newRuntime(cmd)
+	for _, b := range binst {
+		inst, err := r.Build(b)
+		if err != nil {
+			return nil, err
 		}
+		instances = append(instances, inst)
 	}
 
 	// TODO check errors after the fact in case of ignore.
@@ -759,7 +836,8 @@ func buildToolInstances(cmd *Command, binst []*build.Instance) ([]*cue.Instance,
 func buildTools(cmd *Command, args []string) (*cue.Instance, error) {
 
 	cfg := &load.Config{
-		Tools: true,
+		Tools:    true,
+		Registry: newRegistry(),
 	}
 	f := cmd.cmd.Flags()
 	if err := setTags(f, cfg); err != nil {
@@ -19,9 +19,11 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -29,13 +31,17 @@ import (
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
 	itask "cuelang.org/go/internal/task"
 	"cuelang.org/go/internal/value"
 	_ "cuelang.org/go/pkg/tool/cli" // Register tasks
+	_ "cuelang.org/go/pkg/tool/docker"
 	_ "cuelang.org/go/pkg/tool/exec"
 	_ "cuelang.org/go/pkg/tool/file"
 	_ "cuelang.org/go/pkg/tool/http"
+	_ "cuelang.org/go/pkg/tool/kube"
 	_ "cuelang.org/go/pkg/tool/os"
+	_ "cuelang.org/go/pkg/tool/storage"
 	"cuelang.org/go/tools/flow"
 )
 
@@ -128,15 +134,74 @@ func doTasks(cmd *Command, typ, command string, root *cue.Instance) error {
 		InferTasks:     true,
 		IgnoreConcrete: true,
 	}
+	limits, _ := cmd.cmd.Flags().GetStringArray(string(flagConcurrency))
+	if err := parseConcurrency(cfg, limits); err != nil {
+		return err
+	}
 
 	c := flow.New(cfg, root, newTaskFunc(cmd))
 
+	if dryRun, _ := cmd.cmd.Flags().GetBool(string(flagDryrun)); dryRun {
+		err := printPlan(cmd, c)
+		exitIfErr(cmd, root, err, true)
+		return err
+	}
+
 	err := c.Run(context.Background())
 	exitIfErr(cmd, root, err, true)
 
 	return err
 }
 
+// parseConcurrency sets the concurrency limits of cfg from the values of the
+// --concurrency flag. A value is either a number, which limits the number of
+// tasks overall, or of the form kind=N, which limits the number of tasks of a
+// kind, such as tool/http.Do=4.
+func parseConcurrency(cfg *flow.Config, values []string) error {
+	for _, s := range values {
+		kind, num := "", s
+		if i := strings.LastIndexByte(s, '='); i >= 0 {
+			kind, num = s[:i], s[i+1:]
+		}
+		n, err := strconv.Atoi(num)
+		if err != nil || n < 1 || kind == "" && num != s {
+			return errors.Newf(token.NoPos,
+				"invalid concurrency limit %q: must be N or kind=N, where N is a positive integer", s)
+		}
+		if kind == "" {
+			cfg.MaxConcurrency = n
+			continue
+		}
+		if cfg.KindConcurrency == nil {
+			cfg.KindConcurrency = map[string]int{}
+		}
+		cfg.KindConcurrency[kind] = n
+	}
+	return nil
+}
+
+// printPlan prints the tasks of c in the order in which they would be run,
+// along with their dependencies.
+func printPlan(cmd *Command, c *flow.Controller) error {
+	plan, err := c.Plan()
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	for i, t := range plan {
+		fmt.Fprintf(w, "%d. %v", i+1, t.Path())
+		var deps []string
+		for _, d := range t.Dependencies() {
+			deps = append(deps, d.Path().String())
+		}
+		if len(deps) > 0 {
+			fmt.Fprintf(w, " (after %s)", strings.Join(deps, ", "))
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
 // func (r *customRunner) tagReference(t *task, ref cue.Value) error {
 // 	inst, path := ref.Reference()
 // 	if len(path) == 0 {
@@ -196,6 +261,10 @@ func newTaskFunc(cmd *Command) flow.TaskFunc {
 		}
 		rf := itask.Lookup(kind)
 		if rf == nil {
+			// Tasks registered from Go by programs embedding the cue command.
+			if f := flow.LookupTask(kind); f != nil {
+				return f(v)
+			}
 			return nil, errors.Newf(v.Pos(), "runner of kind %q not found", kind)
 		}
 
@@ -218,12 +287,22 @@ func newTaskFunc(cmd *Command) flow.TaskFunc {
 				Stderr:  cmd.OutOrStderr(),
 				Obj:     t.Value(),
 			}
-			value, err := runner.Run(c)
+			r, err := newRedactor(t.Value())
 			if err != nil {
 				return err
 			}
+			if r != nil {
+				stdout, stderr := r.writer(c.Stdout), r.writer(c.Stderr)
+				defer stderr.Flush()
+				defer stdout.Flush()
+				c.Stdout, c.Stderr = stdout, stderr
+			}
+			value, err := runner.Run(c)
+			if err != nil {
+				return r.error(err)
+			}
 			if value != nil {
-				_ = t.Fill(value)
+				_ = t.Fill(r.value(value))
 			}
 			return nil
 		}), nil
@@ -0,0 +1,138 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/diff"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/filetypes"
+)
+
+// newDiffCmd creates a new diff command
+func newDiffCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <x> <y>",
+		Short: "report the differences between two configurations",
+		Long: `diff evaluates two configurations and prints the paths at which
+they differ.
+
+Each argument may be a CUE package or a single file of any of the
+supported encodings. Each difference is printed on a separate line,
+prefixed with a + for values only in y, a - for values only in x, and
+a ~ for values that changed.
+
+By default, values are compared as schema. Use the --concrete flag to
+compare values as data, ignoring definitions, optional fields, and
+non-concrete values.
+
+Examples:
+
+  $ cat <<EOF > x.cue
+  a: 1
+  b: c: "foo"
+  EOF
+
+  $ cat <<EOF > y.json
+  {"b": {"c": "bar"}, "d": true}
+  EOF
+
+  $ cue diff x.cue y.json
+  - a: 1
+  ~ b.c: "foo" -> "bar"
+  + d: true
+`,
+		Args: cobra.ExactArgs(2),
+		RunE: mkRunE(c, runDiff),
+	}
+
+	cmd.Flags().BoolP(string(flagConcrete), "c", false,
+		"compare values as data")
+
+	return cmd
+}
+
+func runDiff(cmd *Command, args []string) error {
+	x, err := loadDiffValue(cmd, args[0])
+	exitOnErr(cmd, err, true)
+
+	y, err := loadDiffValue(cmd, args[1])
+	exitOnErr(cmd, err, true)
+
+	var opts []diff.Option
+	if flagConcrete.Bool(cmd) {
+		opts = append(opts, diff.Concrete(true))
+	}
+
+	w := cmd.OutOrStdout()
+	for _, e := range diff.Diff(x, y, opts...) {
+		switch e.Kind {
+		case diff.Added:
+			fmt.Fprintf(w, "+ %s: %s\n", e.Path, formatDiffValue(e.Y))
+		case diff.Removed:
+			fmt.Fprintf(w, "- %s: %s\n", e.Path, formatDiffValue(e.X))
+		case diff.Changed:
+			fmt.Fprintf(w, "~ %s: %s -> %s\n",
+				e.Path, formatDiffValue(e.X), formatDiffValue(e.Y))
+		}
+	}
+	return nil
+}
+
+// loadDiffValue loads the single configuration identified by arg.
+func loadDiffValue(cmd *Command, arg string) (cue.Value, error) {
+	b, err := parseArgs(cmd, []string{arg}, &config{outMode: filetypes.Eval})
+	if err != nil {
+		return cue.Value{}, err
+	}
+	iter := b.instances()
+	defer iter.close()
+
+	if !iter.scan() {
+		if err := iter.err(); err != nil {
+			return cue.Value{}, err
+		}
+		return cue.Value{}, errors.Newf(token.NoPos, "no value found for %s", arg)
+	}
+	v := iter.value()
+	if iter.scan() {
+		return cue.Value{}, errors.Newf(token.NoPos,
+			"%s defines more than one value", arg)
+	}
+	if err := iter.err(); err != nil {
+		return cue.Value{}, err
+	}
+	return v, v.Err()
+}
+
+// formatDiffValue formats v on a single line.
+func formatDiffValue(v cue.Value) string {
+	s := fmt.Sprint(v)
+	if !strings.Contains(s, "\n") {
+		return s
+	}
+	b, err := v.MarshalJSON()
+	if err == nil {
+		return string(b)
+	}
+	fields := strings.Fields(s)
+	return strings.Join(fields, " ")
+}
@@ -21,6 +21,7 @@ import (
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/cuecontext"
 	"cuelang.org/go/cue/format"
 	"cuelang.org/go/internal"
 	"cuelang.org/go/internal/encoding"
@@ -39,6 +40,11 @@ Printing is skipped if validation fails.
 The --expression flag is used to evaluate an expression within the
 configuration file, instead of the entire configuration file itself.
 
+The --profile flag prints, for each path, how often and for how long
+values were evaluated and how many combinations of disjuncts were
+evaluated, to help find the parts of a configuration that are slow
+to evaluate. The statistics are printed to stderr after the output.
+
 Examples:
 
   $ cat <<EOF > foo.cue
@@ -73,6 +79,9 @@ Examples:
 	cmd.Flags().BoolP(string(flagAll), "a", false,
 		"show optional and hidden fields")
 
+	cmd.Flags().Bool(string(flagProfile), false,
+		"print evaluation statistics to stderr")
+
 	// TODO: Option to include comments in output.
 	return cmd
 }
@@ -82,9 +91,14 @@ const (
 	flagHidden     flagName = "show-hidden"
 	flagOptional   flagName = "show-optional"
 	flagAttributes flagName = "show-attributes"
+	flagProfile    flagName = "profile"
 )
 
 func runEval(cmd *Command, args []string) error {
+	if flagProfile.Bool(cmd) {
+		cmd.profile = &cuecontext.Profile{}
+	}
+
 	b, err := parseArgs(cmd, args, &config{outMode: filetypes.Eval})
 	exitOnErr(cmd, err, true)
 
@@ -171,5 +185,9 @@ func runEval(cmd *Command, args []string) error {
 	err = e.Close()
 	exitOnErr(cmd, err, true)
 
+	if cmd.profile != nil {
+		_, err := cmd.profile.WriteTo(cmd.OutOrStderr())
+		exitOnErr(cmd, err, true)
+	}
 	return nil
 }
@@ -75,6 +75,19 @@ ancestor directories, up to the module root, belonging to the same package.
 If the package is not explicitly defined by the '-p' flag, it must be uniquely
 defined by the files in the current directory.
 
+Fields are emitted in the order in which they are declared. With --sort-fields,
+fields are sorted by name instead, so that the output does not depend on how a
+configuration is split across files.
+
+The --query flag selects values to export by path. A query is a CUE path, such
+as a.b[0]."c-d", in which a label * selects all regular fields of a struct
+and an index [*] selects all elements of a list. The selected values are
+exported as a stream of values. For example,
+
+	cue export -q 'deployment.*.spec.containers[*].image'
+
+prints the images of all containers of all deployments.
+
 
 Formats
 The following formats are recognized:
@@ -97,7 +110,9 @@ yaml    output as YAML
 	addInjectionFlags(cmd.Flags(), false)
 
 	cmd.Flags().Bool(string(flagEscape), false, "use HTML escaping")
+	cmd.Flags().Bool(string(flagSortFields), false, "sort fields by name instead of declaration order")
 	cmd.Flags().StringArrayP(string(flagExpression), "e", nil, "export this expression only")
+	cmd.Flags().StringArrayP(string(flagQuery), "q", nil, "export the values selected by this query only")
 
 	return cmd
 }
@@ -35,8 +35,13 @@ const (
 	flagInjectVars flagName = "inject-vars"
 
 	flagExpression  flagName = "expression"
+	flagQuery       flagName = "query"
 	flagSchema      flagName = "schema"
 	flagEscape      flagName = "escape"
+	flagEmbedded    flagName = "embedded"
+	flagNDJSON      flagName = "ndjson"
+	flagConcurrency flagName = "concurrency"
+	flagSortFields  flagName = "sort-fields"
 	flagGlob        flagName = "name"
 	flagRecursive   flagName = "recursive"
 	flagMerge       flagName = "merge"
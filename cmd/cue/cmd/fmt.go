@@ -15,6 +15,9 @@
 package cmd
 
 import (
+	"io/ioutil"
+	"path/filepath"
+
 	"github.com/spf13/cobra"
 
 	"cuelang.org/go/cue/ast"
@@ -32,8 +35,42 @@ func newFmtCmd(c *Command) *cobra.Command {
 		Use:   "fmt [-s] [inputs]",
 		Short: "formats CUE configuration files",
 		Long: `Fmt formats the given files or the files for the given packages in place
+
+With the --embedded flag, Markdown and YAML files given as arguments are
+searched for embedded CUE, which is formatted in place, leaving all other
+content untouched. Embedded CUE is the contents of a Markdown code block
+with info string cue, such as
+
+	` + "```" + `cue
+	a: 1
+	` + "```" + `
+
+or of a literal YAML block scalar whose key ends in .cue or whose header is
+followed by a # cue comment, such as
+
+	config.cue: |
+	  a: 1
+	schema: | # cue
+	  #A: int
+
+Snippets that are not valid CUE are left untouched.
 `,
 		RunE: mkRunE(c, func(cmd *Command, args []string) error {
+			if flagEmbedded.Bool(cmd) {
+				var rest []string
+				for _, arg := range args {
+					if !hasEmbeddedCUE(arg) {
+						rest = append(rest, arg)
+						continue
+					}
+					exitOnErr(cmd, formatEmbedded(cmd, arg), false)
+				}
+				if len(rest) == 0 && len(args) > 0 {
+					return nil
+				}
+				args = rest
+			}
+
 			plan, err := newBuildPlan(cmd, args, &config{loadCfg: &load.Config{
 				Tests:       true,
 				Tools:       true,
@@ -93,5 +130,36 @@ func newFmtCmd(c *Command) *cobra.Command {
 			return nil
 		}),
 	}
+
+	cmd.Flags().Bool(string(flagEmbedded), false,
+		"format CUE embedded in Markdown and YAML files")
+
 	return cmd
 }
+
+// hasEmbeddedCUE reports whether file is a file in which fmt --embedded
+// formats embedded CUE.
+func hasEmbeddedCUE(file string) bool {
+	switch filepath.Ext(file) {
+	case ".md", ".markdown", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// formatEmbedded formats the CUE embedded in file in place.
+func formatEmbedded(cmd *Command, file string) error {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var opts []format.Option
+	if flagSimplify.Bool(cmd) {
+		opts = append(opts, format.Simplify())
+	}
+	out := format.Embedded(b, opts...)
+	if string(out) == string(b) {
+		return nil
+	}
+	return ioutil.WriteFile(file, out, 0644)
+}
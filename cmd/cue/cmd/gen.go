@@ -0,0 +1,51 @@
+// Copyright 2021 The CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newGenCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gen <language> [packages]",
+		Short: "generate code for another language from CUE packages",
+		Long: `Gen generates code for another language from the definitions of
+CUE packages.
+
+Gen requires an additional language field to determine for which
+language code should be generated. The generated code is written to
+the directory of the respective CUE package. The specifics on how
+definitions are converted vary per language and are documented in the
+respective subcommands.
+`,
+		RunE: mkRunE(c, func(cmd *Command, args []string) error {
+			stderr := cmd.Stderr()
+			if len(args) == 0 {
+				fmt.Fprintln(stderr, "gen must be run as one of its subcommands")
+			} else {
+				fmt.Fprintf(stderr, "gen must be run as one of its subcommands: unknown subcommand %q\n", args[0])
+			}
+			fmt.Fprintln(stderr, "Run 'cue help gen' for known subcommands.")
+			os.Exit(1) // TODO: get rid of this
+			return nil
+		}),
+	}
+	cmd.AddCommand(newGenGoCmd(c))
+	return cmd
+}
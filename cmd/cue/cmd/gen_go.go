@@ -0,0 +1,587 @@
+// Copyright 2021 The CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	goformat "go/format"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/value"
+)
+
+func newGenGoCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "go [packages]",
+		Short: "generate Go types from CUE definitions",
+		Long: `go converts CUE definitions into Go types
+
+The command "cue gen go" is the reverse of "cue get go": it converts the
+definitions of CUE packages to Go types, allowing CUE to be the source of
+truth for types used in Go. The generated types for a package are written
+to a file named cue_types_gen.go in the directory of the package.
+
+The output depends only on the CUE definitions and is stable, making it
+suitable for committing alongside the CUE files.
+
+
+Rules of Converting CUE definitions to Go
+
+Each definition #Name of a package is converted as follows:
+
+	- a struct translates to a Go struct type. Each regular field translates
+	  to a Go field with a json tag with the name of the CUE field. The
+	  json tag of an optional field has the omitempty option. The Go name
+	  of a field is the name given in its @go attribute, as generated by
+	  "cue get go", or its CUE name converted to an exported Go identifier
+	  otherwise.
+
+	- a disjunction of concrete strings or numbers translates to a named
+	  type with a typed constant for each of its values. For instance,
+
+	    #Mode: "fast" | "slow"
+
+	  translates to
+
+	    type Mode string
+
+	    const (
+	        ModeFast Mode = "fast"
+	        ModeSlow Mode = "slow"
+	    )
+
+	- a concrete string, number, or boolean translates to a constant.
+
+	- any other value translates to a Go type definition of its type.
+
+The types of values are converted as follows:
+
+	- a reference to a definition of the same package translates to the
+	  corresponding Go type.
+
+	- the CUE types int, float, number, string, bytes, and bool, and the
+	  predeclared sized types like int32 and float64, translate to the
+	  corresponding Go types. The type time.Time translates to time.Time.
+
+	- a disjunction with null translates to a pointer.
+
+	- a list translates to a slice of its element type.
+
+	- a struct with only a pattern constraint translates to a map with
+	  string keys. Other structs translate to a Go struct type named after
+	  the enclosing type and field.
+
+	- any other value translates to interface{}.
+
+Doc comments of definitions and fields are copied to the generated code.
+`,
+		RunE: mkRunE(c, genGo),
+	}
+
+	cmd.Flags().StringP(string(flagPackage), "p", "", "package name for generated Go files")
+
+	return cmd
+}
+
+// goGenFile is the name of the file to which the Go types of a package are
+// written.
+const goGenFile = "cue_types_gen.go"
+
+func genGo(cmd *Command, args []string) error {
+	binst := loadFromArgs(cmd, args, nil)
+	if binst == nil {
+		return nil
+	}
+	instances := buildInstances(cmd, binst)
+
+	for i, inst := range instances {
+		pkg := flagPackage.String(cmd)
+		if pkg == "" {
+			pkg = binst[i].PkgName
+		}
+		if pkg == "" || pkg == "_" {
+			return fmt.Errorf("no package name for %s; use the --%s flag", binst[i].Dir, flagPackage)
+		}
+		b, err := generateGo(inst.Value(), pkg)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(binst[i].Dir, goGenFile)
+		if err := ioutil.WriteFile(dst, b, 0666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// goBuiltinTypes maps the string representation of CUE types to Go types.
+var goBuiltinTypes = map[string]string{
+	"int8":        "int8",
+	"int16":       "int16",
+	"int32":       "int32",
+	"int64":       "int64",
+	"uint":        "uint",
+	"uint8":       "uint8",
+	"uint16":      "uint16",
+	"uint32":      "uint32",
+	"uint64":      "uint64",
+	"float32":     "float32",
+	"float64":     "float64",
+	"time.Time":   "time.Time",
+	"time.Time()": "time.Time",
+}
+
+// goKindTypes maps CUE kinds to Go types.
+var goKindTypes = map[cue.Kind]string{
+	cue.BoolKind:   "bool",
+	cue.IntKind:    "int",
+	cue.FloatKind:  "float64",
+	cue.NumberKind: "float64",
+	cue.StringKind: "string",
+	cue.BytesKind:  "[]byte",
+}
+
+// A goGenerator converts the definitions of a CUE package to Go.
+type goGenerator struct {
+	root *adt.Vertex
+	errs errors.Error
+
+	// types maps the names of definitions that translate to Go types to
+	// their Go names.
+	types map[string]string
+
+	// names holds the declared Go identifiers.
+	names map[string]bool
+
+	imports map[string]bool
+
+	// pending holds the nested struct types that still need to be declared.
+	pending []goStruct
+
+	w *bytes.Buffer
+}
+
+type goStruct struct {
+	name string
+	v    cue.Value
+}
+
+// generateGo returns the Go source for the definitions of v.
+func generateGo(v cue.Value, pkg string) ([]byte, error) {
+	_, root := value.ToInternal(v)
+	g := &goGenerator{
+		root:    root,
+		types:   map[string]string{},
+		names:   map[string]bool{},
+		imports: map[string]bool{},
+		w:       &bytes.Buffer{},
+	}
+
+	type def struct {
+		name string
+		v    cue.Value
+	}
+	var defs []def
+	iter, err := v.Fields(cue.Definitions(true))
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		label := iter.Label()
+		if !iter.Selector().IsDefinition() || !strings.HasPrefix(label, "#") {
+			continue
+		}
+		name := g.newName(goIdent(label[1:]))
+		defs = append(defs, def{name, iter.Value()})
+		if !isGoConst(iter.Value()) {
+			g.types[label] = name
+		}
+	}
+
+	for _, d := range defs {
+		g.decl(d.name, d.v)
+		for len(g.pending) > 0 {
+			s := g.pending[0]
+			g.pending = g.pending[1:]
+			fmt.Fprintln(g.w)
+			fmt.Fprintf(g.w, "type %s %s\n", s.name, g.structType(s.name, s.v))
+		}
+	}
+	if g.errs != nil {
+		return nil, g.errs
+	}
+
+	w := &bytes.Buffer{}
+	fmt.Fprintln(w, "// Code generated by cue gen go. DO NOT EDIT.")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "package %s\n", pkg)
+	if len(g.imports) > 0 {
+		var imports []string
+		for path := range g.imports {
+			imports = append(imports, strconv.Quote(path))
+		}
+		sort.Strings(imports)
+		if len(imports) == 1 {
+			fmt.Fprintf(w, "\nimport %s\n", imports[0])
+		} else {
+			fmt.Fprintf(w, "\nimport (\n%s\n)\n", strings.Join(imports, "\n"))
+		}
+	}
+	w.Write(g.w.Bytes())
+
+	b, err := goformat.Source(w.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("gen go: invalid Go code generated: %v", err)
+	}
+	return b, nil
+}
+
+func (g *goGenerator) addErrf(v cue.Value, format string, args ...interface{}) {
+	err := errors.Newf(v.Pos(), "gen go: "+format, args...)
+	g.errs = errors.Append(g.errs, err)
+}
+
+// newName returns a Go identifier based on name that is not yet declared.
+func (g *goGenerator) newName(name string) string {
+	s := name
+	for i := 1; g.names[s]; i++ {
+		s = name + strconv.Itoa(i)
+	}
+	g.names[s] = true
+	return s
+}
+
+// isGoConst reports whether v translates to a Go constant.
+func isGoConst(v cue.Value) bool {
+	switch v.Kind() {
+	case cue.BoolKind, cue.IntKind, cue.FloatKind, cue.StringKind:
+		return true
+	}
+	return false
+}
+
+// decl writes the declaration for the definition v with the given Go name.
+func (g *goGenerator) decl(name string, v cue.Value) {
+	fmt.Fprintln(g.w)
+	writeGoDoc(g.w, v)
+
+	if isGoConst(v) {
+		fmt.Fprintf(g.w, "const %s = %s\n", name, g.literal(v))
+		return
+	}
+
+	if values, kind := enumValues(v); len(values) > 0 {
+		fmt.Fprintf(g.w, "type %s %s\n\n", name, goKindTypes[kind])
+		fmt.Fprintln(g.w, "const (")
+		names := map[string]bool{}
+		for i, x := range values {
+			c := g.enumName(name, x, i, names)
+			fmt.Fprintf(g.w, "%s %s = %s\n", c, name, g.literal(x))
+		}
+		fmt.Fprintln(g.w, ")")
+		return
+	}
+
+	if v.IncompleteKind() == cue.StructKind && g.refName(v) == "" && !isGoMap(v) {
+		fmt.Fprintf(g.w, "type %s %s\n", name, g.structType(name, v))
+		return
+	}
+	fmt.Fprintf(g.w, "type %s %s\n", name, g.goType(name, v))
+}
+
+// enumValues returns the values of v if v is a disjunction of concrete
+// values of a single kind that can be represented as Go constants.
+func enumValues(v cue.Value) (values []cue.Value, kind cue.Kind) {
+	op, a := v.Expr()
+	if op == cue.NoOp && len(a) == 1 {
+		op, a = a[0].Expr()
+	}
+	if op != cue.OrOp || len(a) < 2 {
+		return nil, cue.BottomKind
+	}
+	for _, x := range a {
+		if !isGoConst(x) || x.Kind() == cue.BoolKind {
+			return nil, cue.BottomKind
+		}
+		switch {
+		case kind == cue.BottomKind:
+			kind = x.Kind()
+		case kind != x.Kind():
+			return nil, cue.BottomKind
+		}
+	}
+	return a, kind
+}
+
+// enumName returns the name of the constant for the value x of the enum type
+// with the given name. The index i is used if the value is not a valid
+// identifier.
+func (g *goGenerator) enumName(typ string, x cue.Value, i int, used map[string]bool) string {
+	suffix := ""
+	switch x.Kind() {
+	case cue.StringKind:
+		s, _ := x.String()
+		suffix = goIdent(s)
+	case cue.IntKind:
+		suffix = strings.Replace(fmt.Sprint(x), "-", "Neg", 1)
+	}
+	if suffix == "" || used[suffix] {
+		suffix = strconv.Itoa(i)
+	}
+	used[suffix] = true
+	return g.newName(typ + suffix)
+}
+
+// literal returns the Go literal for the concrete value v.
+func (g *goGenerator) literal(v cue.Value) string {
+	switch v.Kind() {
+	case cue.StringKind:
+		s, _ := v.String()
+		return strconv.Quote(s)
+	case cue.BoolKind:
+		b, _ := v.Bool()
+		return strconv.FormatBool(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// refName returns the Go name of the type referred to by v, or "" if v does
+// not refer to a definition of the package that translates to a Go type.
+func (g *goGenerator) refName(v cue.Value) string {
+	root, path := v.ReferencePath()
+	if !root.Exists() {
+		return ""
+	}
+	if _, r := value.ToInternal(root); r != g.root {
+		return ""
+	}
+	sels := path.Selectors()
+	if len(sels) != 1 {
+		return ""
+	}
+	return g.types[sels[0].String()]
+}
+
+// isGoMap reports whether v is a struct that only has a pattern constraint
+// or is open without any fields.
+func isGoMap(v cue.Value) bool {
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return false
+	}
+	if iter.Next() {
+		return false
+	}
+	return v.LookupPath(cue.MakePath(cue.AnyString)).Exists() || v.Allows(cue.AnyString)
+}
+
+// goType returns the Go type for v. The name is used to derive the names of
+// the Go types of nested structs.
+func (g *goGenerator) goType(name string, v cue.Value) string {
+	if t := g.refName(v); t != "" {
+		return t
+	}
+	if t := g.builtinType(v); t != "" {
+		return t
+	}
+
+	switch op, a := v.Expr(); op {
+	case cue.NoOp:
+		// A value with a default is represented by its value without the
+		// default.
+		if len(a) == 1 {
+			root, _ := a[0].ReferencePath()
+			if op, _ := a[0].Expr(); root.Exists() || op != cue.NoOp {
+				return g.goType(name, a[0])
+			}
+		}
+
+	case cue.AndOp:
+		// A sized type or reference constrained further.
+		for _, x := range a {
+			if t := g.refName(x); t != "" {
+				return t
+			}
+			if t := g.builtinType(x); t != "" {
+				return t
+			}
+		}
+
+	case cue.OrOp:
+		var other []cue.Value
+		nullable := false
+		for _, x := range a {
+			if x.Kind() == cue.NullKind {
+				nullable = true
+				continue
+			}
+			other = append(other, x)
+		}
+		if len(other) == 1 {
+			t := g.goType(name, other[0])
+			if nullable {
+				t = goPointer(t)
+			}
+			return t
+		}
+	}
+
+	k := v.IncompleteKind()
+	nullable := k&cue.NullKind != 0 && k != cue.NullKind
+	k &^= cue.NullKind
+
+	t := ""
+	switch k {
+	case cue.ListKind:
+		t = "[]interface{}"
+		if elem := v.LookupPath(cue.MakePath(cue.AnyIndex)); elem.Exists() {
+			t = "[]" + g.goType(name+"Elem", elem)
+		}
+
+	case cue.StructKind:
+		if !isGoMap(v) {
+			t = g.newName(name)
+			g.pending = append(g.pending, goStruct{t, v})
+			break
+		}
+		t = "map[string]interface{}"
+		if x := v.LookupPath(cue.MakePath(cue.AnyString)); x.Exists() {
+			t = "map[string]" + g.goType(name+"Value", x)
+		}
+
+	default:
+		var ok bool
+		if t, ok = goKindTypes[k]; !ok {
+			t = "interface{}"
+		}
+	}
+	if nullable {
+		t = goPointer(t)
+	}
+	return t
+}
+
+// builtinType returns the Go type for the predeclared CUE type v, or "" if v
+// is not such a type.
+func (g *goGenerator) builtinType(v cue.Value) string {
+	t := goBuiltinTypes[fmt.Sprint(v)]
+	if strings.HasPrefix(t, "time.") {
+		g.imports["time"] = true
+	}
+	return t
+}
+
+// goPointer returns a pointer type for t, or t itself if t can already
+// represent a null value.
+func goPointer(t string) string {
+	for _, prefix := range []string{"[]", "map[", "interface{}", "*"} {
+		if strings.HasPrefix(t, prefix) {
+			return t
+		}
+	}
+	return "*" + t
+}
+
+// structType returns the Go struct type for the fields of v.
+func (g *goGenerator) structType(name string, v cue.Value) string {
+	w := &bytes.Buffer{}
+	fmt.Fprintln(w, "struct {")
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		g.addErrf(v, "%v", err)
+		return "struct{}"
+	}
+	fields := map[string]bool{}
+	for i := 0; iter.Next(); i++ {
+		label := iter.Label()
+		x := iter.Value()
+
+		field := goIdent(label)
+		if a := x.Attribute("go"); a.Err() == nil {
+			if s, _ := a.String(0); s != "" {
+				field = s
+			}
+		}
+		if field == "" || fields[field] {
+			g.addErrf(x, "cannot derive unique Go field name for field %q of %s", label, name)
+			continue
+		}
+		fields[field] = true
+
+		tag := label
+		if iter.IsOptional() {
+			tag += ",omitempty"
+		}
+
+		if i > 0 && len(x.Doc()) > 0 {
+			fmt.Fprintln(w)
+		}
+		writeGoDoc(w, x)
+		typ := g.goType(name+field, x)
+		fmt.Fprintf(w, "%s %s `json:%s`\n", field, typ, strconv.Quote(tag))
+	}
+	fmt.Fprint(w, "}")
+	return w.String()
+}
+
+func writeGoDoc(w *bytes.Buffer, v cue.Value) {
+	for i, d := range v.Doc() {
+		if i > 0 {
+			fmt.Fprintln(w, "//")
+		}
+		for _, line := range strings.Split(strings.TrimSpace(d.Text()), "\n") {
+			if line == "" {
+				fmt.Fprintln(w, "//")
+				continue
+			}
+			fmt.Fprintln(w, "// "+line)
+		}
+	}
+}
+
+// goIdent converts s to an exported Go identifier by removing any characters
+// that are not letters or digits and capitalizing the letter following them.
+// It returns "" if s does not contain any letters or digits.
+func goIdent(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	id := b.String()
+	if id != "" && unicode.IsDigit([]rune(id)[0]) {
+		id = "X" + id
+	}
+	return id
+}
@@ -120,6 +120,50 @@ Go structs are converted to cue structs adhering to the following conventions:
 	  The @go attribute is added if the field name or type definition differs
 	  between the generated CUE and the original Go.
 
+	- a cue tag is interpreted as a CUE expression constraining the field.
+
+	- the rules of a validate tag, as used by the go-playground validator,
+	  are translated to constraints where possible. For instance, the field
+
+	    Name string ` + "`json:\"name\" validate:\"required,min=1,alphanum\"`" + `
+
+	  translates to
+
+	    name: string & strings.MinRunes(1) & =~"^[a-zA-Z0-9]+$"
+
+	  Rules following a dive rule are not translated.
+
+	- a yaml tag with a name that differs from the field name is recorded
+	  in a @yaml attribute.
+
+Generic Go types are converted as follows:
+
+	- a generic struct type translates to a definition with a definition
+	  field for each type parameter, constrained by the types permitted by
+	  the type parameter's constraint. For instance, the Go type
+
+	    type Pair[K comparable, V int | string] struct {
+	        Key   K
+	        Value V
+	    }
+
+	  translates to
+
+	    #Pair: {
+	        _K=#K: _
+	        _V=#V: int | string
+	        Key:   _K
+	        Value: _V
+	    }
+
+	  An instantiation of such a type, like Pair[string, int], translates to
+	  the unification of the definition with its type arguments:
+
+	    #Pair & {#K: string, #V: int}
+
+	- other generic types, like a generic slice type, are not declared, but
+	  are expanded at each instantiation.
+
 
 Native CUE Constraints
 
@@ -682,6 +726,18 @@ func (e *extractor) reportDecl(x *ast.GenDecl) (a []cueast.Decl) {
 				mapNamed = true
 			}
 
+			if named, ok := typ.(*types.Named); ok && named.TypeParams().Len() > 0 {
+				if f := e.makeGeneric(named, x.Doc); f != nil {
+					a = append(a, f)
+				}
+				continue
+			}
+			if iface, ok := typ.Underlying().(*types.Interface); ok && !iface.IsMethodSet() {
+				// A constraint interface can only be used for type parameters.
+				a = append(a, e.def(x.Doc, name, e.makeConstraint(typ), true))
+				continue
+			}
+
 			switch tn, ok := e.pkg.TypesInfo.Defs[v.Name].(*types.TypeName); {
 			case ok:
 				if altType := e.altType(tn.Type()); altType != nil {
@@ -710,7 +766,7 @@ func (e *extractor) reportDecl(x *ast.GenDecl) (a []cueast.Decl) {
 
 			if len(enums) > 0 && ast.IsExported(name) {
 				enumName := "#enum" + name
-				cueast.AddComment(a[len(a)-1], internal.NewComment(false, enumName))
+				cueast.AttachComment(a[len(a)-1], internal.NewComment(false, enumName), cueast.LineComment)
 
 				// Constants are mapped as definitions.
 				var exprs []cueast.Expr
@@ -791,7 +847,7 @@ func (e *extractor) reportDecl(x *ast.GenDecl) (a []cueast.Decl) {
 						b.Value = val
 					}
 					if b.Value != val {
-						cv.AddComment(internal.NewComment(false, val))
+						cueast.AttachComment(cv, internal.NewComment(false, val), cueast.LineComment)
 					}
 				}
 
@@ -842,7 +898,7 @@ func (e *extractor) altType(typ types.Type) cueast.Expr {
 func addDoc(g *ast.CommentGroup, x cueast.Node) bool {
 	doc := makeDoc(g, true)
 	if doc != nil {
-		x.AddComment(doc)
+		cueast.AttachComment(x, doc, cueast.DocComment)
 		return true
 	}
 	return false
@@ -1002,6 +1058,99 @@ func (e *extractor) makeField(name string, kind cuetoken.Token, expr types.Type,
 	return f, string(b)
 }
 
+// makeGeneric converts the declaration of a generic type. Only struct types
+// are declared: each type parameter is represented by a definition within
+// the struct, which is set at the use sites of the type. Other generic types
+// are expanded at their use sites.
+func (e *extractor) makeGeneric(typ *types.Named, doc *ast.CommentGroup) *cueast.Field {
+	name := typ.Obj().Name()
+	x, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		e.logf("    Dropped declaration of generic type %v; expanded at use sites", name)
+		return nil
+	}
+	if !supportedType(nil, typ) {
+		e.logf("    Dropped declaration %v of unsupported type %v", name, typ)
+		return nil
+	}
+
+	st := &cueast.StructLit{
+		Lbrace: cuetoken.Blank.Pos(),
+		Rbrace: cuetoken.Newline.Pos(),
+	}
+	params := typ.TypeParams()
+	for i := 0; i < params.Len(); i++ {
+		p := params.At(i)
+		st.Elts = append(st.Elts, &cueast.Field{
+			Label: &cueast.Alias{
+				Ident: e.typeParamRef(p),
+				Expr:  e.ident(p.Obj().Name(), true),
+			},
+			Value: e.makeConstraint(p.Constraint()),
+		})
+	}
+	e.addFields(x, st)
+
+	return e.def(doc, name, st, true)
+}
+
+// typeParamRef returns a reference to the type parameter p from within the
+// generic type declaring it.
+func (e *extractor) typeParamRef(p *types.TypeParam) *cueast.Ident {
+	return cueast.NewIdent("_" + p.Obj().Name())
+}
+
+// makeConstraint converts the type set of a type parameter constraint to a
+// disjunction of the permitted types. It returns top if the type set is not
+// restricted to a list of supported types.
+func (e *extractor) makeConstraint(t types.Type) cueast.Expr {
+	terms := typeSet(t)
+	if len(terms) == 0 {
+		return e.ident("_", false)
+	}
+	var exprs []cueast.Expr
+	for _, t := range terms {
+		if !supportedType(nil, t) {
+			return e.ident("_", false)
+		}
+		exprs = append(exprs, e.makeType(t))
+	}
+	return cueast.NewBinExpr(cuetoken.OR, exprs...)
+}
+
+// typeSet returns the types permitted by the constraint t, or nil if t permits
+// any type with the given methods.
+func typeSet(t types.Type) []types.Type {
+	iface, ok := t.Underlying().(*types.Interface)
+	if !ok {
+		return []types.Type{t}
+	}
+	var a []types.Type
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		switch x := iface.EmbeddedType(i).(type) {
+		case *types.Union:
+			for j := 0; j < x.Len(); j++ {
+				terms := typeSet(x.Term(j).Type())
+				if terms == nil {
+					return nil
+				}
+				a = append(a, terms...)
+			}
+		default:
+			terms := typeSet(x)
+			if terms == nil {
+				continue
+			}
+			if a != nil {
+				// The intersection of type sets is not supported.
+				return nil
+			}
+			a = terms
+		}
+	}
+	return a
+}
+
 func (e *extractor) makeType(expr types.Type) (result cueast.Expr) {
 	if x, ok := expr.(*types.Named); ok {
 		obj := x.Obj()
@@ -1057,10 +1206,16 @@ func (e *extractor) makeType(expr types.Type) (result cueast.Expr) {
 			result = cueast.NewSel(p, "#"+obj.Name())
 			e.usedPkg(pkg.Path())
 		}
+		if args := x.TypeArgs(); args.Len() > 0 {
+			return e.instantiate(x, result)
+		}
 		return
 	}
 
 	switch x := expr.(type) {
+	case *types.TypeParam:
+		return e.typeParamRef(x)
+
 	case *types.Pointer:
 		return &cueast.BinaryExpr{
 			X:  cueast.NewNull(),
@@ -1131,6 +1286,31 @@ func (e *extractor) makeType(expr types.Type) (result cueast.Expr) {
 	}
 }
 
+// instantiate converts an instantiation of the generic type x, where ref is
+// the reference to the generic type. The type parameters of a generic struct
+// are set by unifying its definition with the type arguments. Other generic
+// types are expanded.
+func (e *extractor) instantiate(x *types.Named, ref cueast.Expr) cueast.Expr {
+	if _, ok := x.Origin().Underlying().(*types.Struct); !ok {
+		return e.makeType(x.Underlying())
+	}
+	params := x.Origin().TypeParams()
+	args := x.TypeArgs()
+	st := &cueast.StructLit{
+		Lbrace: cuetoken.Blank.Pos(),
+		Rbrace: cuetoken.Blank.Pos(),
+	}
+	for i := 0; i < args.Len(); i++ {
+		f := &cueast.Field{
+			Label: e.ident(params.At(i).Obj().Name(), true),
+			Value: e.makeType(args.At(i)),
+		}
+		cueast.SetRelPos(f, cuetoken.Blank)
+		st.Elts = append(st.Elts, f)
+	}
+	return cueast.NewBinExpr(cuetoken.AND, ref, st)
+}
+
 func (e *extractor) addAttr(f *cueast.Field, tag, body string) {
 	s := fmt.Sprintf("@%s(%s)", tag, body)
 	f.Attrs = append(f.Attrs, &cueast.Attribute{Text: s})
@@ -1141,14 +1321,16 @@ func (e *extractor) addFields(x *types.Struct, st *cueast.StructLit) {
 		st.Elts = append(st.Elts, x)
 	}
 
-	s := e.orig[x]
-	docs := []*ast.CommentGroup{}
-	for _, f := range s.Fields.List {
-		if len(f.Names) == 0 {
-			docs = append(docs, f.Doc)
-		} else {
-			for range f.Names {
+	docs := make([]*ast.CommentGroup, x.NumFields())
+	if s := e.orig[x]; s != nil {
+		docs = docs[:0]
+		for _, f := range s.Fields.List {
+			if len(f.Names) == 0 {
 				docs = append(docs, f.Doc)
+			} else {
+				for range f.Names {
+					docs = append(docs, f.Doc)
+				}
 			}
 		}
 	}
@@ -1203,14 +1385,6 @@ func (e *extractor) addFields(x *types.Struct, st *cueast.StructLit) {
 		field, cueType := e.makeField(name, kind, f.Type(), docs[i], count > 0)
 		add(field)
 
-		if s := reflect.StructTag(tag).Get("cue"); s != "" {
-			expr, err := parser.ParseExpr("get go", s)
-			if err != nil {
-				e.logf("error parsing struct tag %q:", s, err)
-			}
-			field.Value = cueast.NewBinExpr(cuetoken.AND, field.Value, expr)
-		}
-
 		// Add field tag to convert back to Go.
 		typeName := f.Type().String()
 		// simplify type names:
@@ -1238,50 +1412,11 @@ func (e *extractor) addFields(x *types.Struct, st *cueast.StructLit) {
 			e.addAttr(field, "go", buf.String())
 		}
 
-		// Carry over protobuf field tags with modifications.
-		// TODO: consider trashing the protobuf tag, as the Go versions are
-		// lossy and will not allow for an accurate translation in some cases.
 		tags := reflect.StructTag(tag)
-		if t := tags.Get("protobuf"); t != "" {
-			split := strings.Split(t, ",")
-			k := 0
-			for _, s := range split {
-				if strings.HasPrefix(s, "name=") && s[len("name="):] == name {
-					continue
-				}
-				split[k] = s
-				k++
+		for _, t := range tagInterpreters {
+			if s := tags.Get(t.key); s != "" {
+				t.interpret(e, field, f, s, tags)
 			}
-			split = split[:k]
-
-			// Put tag first, as type could potentially be elided and is
-			// "more optional".
-			if len(split) >= 2 {
-				split[0], split[1] = split[1], split[0]
-			}
-
-			// Interpret as map?
-			if len(split) > 2 && split[1] == "bytes" {
-				tk := tags.Get("protobuf_key")
-				tv := tags.Get("protobuf_val")
-				if tk != "" && tv != "" {
-					tk = strings.SplitN(tk, ",", 2)[0]
-					tv = strings.SplitN(tv, ",", 2)[0]
-					split[1] = fmt.Sprintf("map[%s]%s", tk, tv)
-				}
-			}
-
-			e.addAttr(field, "protobuf", strings.Join(split, ","))
-		}
-
-		// Carry over XML tags.
-		if t := reflect.StructTag(tag).Get("xml"); t != "" {
-			e.addAttr(field, "xml", t)
-		}
-
-		// Carry over TOML tags.
-		if t := reflect.StructTag(tag).Get("toml"); t != "" {
-			e.addAttr(field, "toml", t)
 		}
 
 		// TODO: should we in general carry over any unknown tag verbatim?
@@ -0,0 +1,308 @@
+// Copyright 2021 The CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	cueast "cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/parser"
+	cuetoken "cuelang.org/go/cue/token"
+)
+
+// A tagInterpreter converts the struct tag with the given key of a Go field
+// f to constraints or attributes of the corresponding CUE field.
+type tagInterpreter struct {
+	key       string
+	interpret func(e *extractor, field *cueast.Field, f *types.Var, value string, tags reflect.StructTag)
+}
+
+// tagInterpreters lists the interpreters of struct tags in the order in which
+// they are applied. Tags for which there is no interpreter are dropped.
+var tagInterpreters = []tagInterpreter{
+	{"cue", (*extractor).cueTag},
+	{"validate", (*extractor).validateTag},
+	{"yaml", (*extractor).yamlTag},
+	{"protobuf", (*extractor).protobufTag},
+	{"xml", attrTag("xml")},
+	{"toml", attrTag("toml")},
+}
+
+// cueTag adds the CUE expression of a cue tag as a constraint.
+func (e *extractor) cueTag(field *cueast.Field, f *types.Var, s string, tags reflect.StructTag) {
+	expr, err := parser.ParseExpr("get go", s)
+	if err != nil {
+		e.logf("error parsing struct tag %q: %v", s, err)
+		return
+	}
+	field.Value = cueast.NewBinExpr(cuetoken.AND, field.Value, expr)
+}
+
+// attrTag returns an interpreter that carries over a tag verbatim as an
+// attribute.
+func attrTag(key string) func(e *extractor, field *cueast.Field, f *types.Var, s string, tags reflect.StructTag) {
+	return func(e *extractor, field *cueast.Field, f *types.Var, s string, tags reflect.StructTag) {
+		e.addAttr(field, key, s)
+	}
+}
+
+// yamlTag records the name of a field in YAML if it differs from the label
+// of the field, which is determined by the json tag.
+func (e *extractor) yamlTag(field *cueast.Field, f *types.Var, s string, tags reflect.StructTag) {
+	name := strings.SplitN(s, ",", 2)[0]
+	if label, _, _ := cueast.LabelName(field.Label); name == "" || name == "-" || name == label {
+		return
+	}
+	e.addAttr(field, "yaml", name)
+}
+
+// protobufTag carries over protobuf field tags with modifications.
+//
+// TODO: consider trashing the protobuf tag, as the Go versions are
+// lossy and will not allow for an accurate translation in some cases.
+func (e *extractor) protobufTag(field *cueast.Field, f *types.Var, t string, tags reflect.StructTag) {
+	name, _, _ := cueast.LabelName(field.Label)
+	split := strings.Split(t, ",")
+	k := 0
+	for _, s := range split {
+		if strings.HasPrefix(s, "name=") && s[len("name="):] == name {
+			continue
+		}
+		split[k] = s
+		k++
+	}
+	split = split[:k]
+
+	// Put tag first, as type could potentially be elided and is
+	// "more optional".
+	if len(split) >= 2 {
+		split[0], split[1] = split[1], split[0]
+	}
+
+	// Interpret as map?
+	if len(split) > 2 && split[1] == "bytes" {
+		tk := tags.Get("protobuf_key")
+		tv := tags.Get("protobuf_val")
+		if tk != "" && tv != "" {
+			tk = strings.SplitN(tk, ",", 2)[0]
+			tv = strings.SplitN(tv, ",", 2)[0]
+			split[1] = fmt.Sprintf("map[%s]%s", tk, tv)
+		}
+	}
+
+	e.addAttr(field, "protobuf", strings.Join(split, ","))
+}
+
+// validateTag converts the rules of a validate tag, as used by
+// github.com/go-playground/validator, to constraints. Rules that have no
+// equivalent in CUE are dropped.
+func (e *extractor) validateTag(field *cueast.Field, f *types.Var, s string, tags reflect.StructTag) {
+	typ := f.Type()
+	for {
+		p, ok := typ.(*types.Pointer)
+		if !ok {
+			break
+		}
+		typ = p.Elem()
+	}
+
+	var exprs []cueast.Expr
+rules:
+	for _, rule := range strings.Split(s, ",") {
+		key, arg := rule, ""
+		if p := strings.IndexByte(rule, '='); p >= 0 {
+			key, arg = rule[:p], rule[p+1:]
+		}
+		switch key {
+		case "", "-":
+			continue
+		case "dive":
+			// Subsequent rules apply to the elements.
+			// TODO: support rules for elements.
+			break rules
+		case "required":
+			field.Optional = cuetoken.NoPos
+			continue
+		case "omitempty":
+			field.Optional = cuetoken.Blank.Pos()
+			continue
+		}
+		x := validateRule(typ, key, arg)
+		if x == nil {
+			e.logf("    Dropped unsupported validate rule %q for field %v", rule, f.Name())
+			continue
+		}
+		exprs = append(exprs, x)
+	}
+	if len(exprs) == 0 {
+		return
+	}
+
+	// A pointer may still be null.
+	if b, ok := field.Value.(*cueast.BinaryExpr); ok && b.Op == cuetoken.OR && isNull(b.X) {
+		b.Y = cueast.NewBinExpr(cuetoken.AND, append([]cueast.Expr{b.Y}, exprs...)...)
+		return
+	}
+	field.Value = cueast.NewBinExpr(cuetoken.AND, append([]cueast.Expr{field.Value}, exprs...)...)
+}
+
+func isNull(x cueast.Expr) bool {
+	lit, ok := x.(*cueast.BasicLit)
+	return ok && lit.Kind == cuetoken.NULL
+}
+
+// validatePatterns maps validate rules for strings to regular expressions.
+var validatePatterns = map[string]string{
+	"alpha":       `^[a-zA-Z]+$`,
+	"alphanum":    `^[a-zA-Z0-9]+$`,
+	"numeric":     `^[-+]?[0-9]+(?:\.[0-9]+)?$`,
+	"hexadecimal": `^(0[xX])?[0-9a-fA-F]+$`,
+	"lowercase":   `^[^A-Z]*$`,
+	"uppercase":   `^[^a-z]*$`,
+	"uuid":        `^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`,
+}
+
+// validateRule converts a single validate rule for a value of type typ, or
+// returns nil if it is not supported. Like for the validator, the size rules
+// min, max, len, gt, gte, lt, and lte constrain the value of numbers and the
+// length of other values.
+func validateRule(typ types.Type, key, arg string) cueast.Expr {
+	var size func(op cuetoken.Token, n cueast.Expr) cueast.Expr
+	var literal func(s string) cueast.Expr
+	switch x := typ.Underlying().(type) {
+	case *types.Basic:
+		switch info := x.Info(); {
+		case info&types.IsNumeric != 0:
+			literal = numLit
+			size = func(op cuetoken.Token, n cueast.Expr) cueast.Expr {
+				if op == cuetoken.EQL {
+					return n
+				}
+				return &cueast.UnaryExpr{Op: op, X: n}
+			}
+		case info&types.IsString != 0:
+			literal = func(s string) cueast.Expr { return cueast.NewString(s) }
+			size = lengthConstraint("strings", "MinRunes", "MaxRunes")
+		}
+	case *types.Slice:
+		if x.Elem().String() != "byte" {
+			size = lengthConstraint("list", "MinItems", "MaxItems")
+		}
+	case *types.Array:
+		if x.Elem().String() != "byte" {
+			size = lengthConstraint("list", "MinItems", "MaxItems")
+		}
+	case *types.Map:
+		size = lengthConstraint("struct", "MinFields", "MaxFields")
+	}
+
+	switch key {
+	case "min", "max", "len", "gt", "gte", "lt", "lte":
+		n := numLit(arg)
+		if size == nil || n == nil {
+			return nil
+		}
+		switch key {
+		case "min", "gte":
+			return size(cuetoken.GEQ, n)
+		case "max", "lte":
+			return size(cuetoken.LEQ, n)
+		case "len":
+			return size(cuetoken.EQL, n)
+		case "gt":
+			return size(cuetoken.GTR, n)
+		case "lt":
+			return size(cuetoken.LSS, n)
+		}
+
+	case "eq", "ne":
+		if literal == nil {
+			return nil
+		}
+		x := literal(arg)
+		if x == nil || key == "eq" {
+			return x
+		}
+		return &cueast.UnaryExpr{Op: cuetoken.NEQ, X: x}
+
+	case "oneof":
+		if literal == nil {
+			return nil
+		}
+		var a []cueast.Expr
+		for _, s := range strings.Fields(arg) {
+			x := literal(s)
+			if x == nil {
+				return nil
+			}
+			a = append(a, x)
+		}
+		if len(a) == 0 {
+			return nil
+		}
+		return cueast.NewBinExpr(cuetoken.OR, a...)
+
+	default:
+		if p, ok := validatePatterns[key]; ok && arg == "" {
+			if b, ok := typ.Underlying().(*types.Basic); ok && b.Info()&types.IsString != 0 {
+				return &cueast.UnaryExpr{Op: cuetoken.MAT, X: cueast.NewString(p)}
+			}
+		}
+	}
+	return nil
+}
+
+// lengthConstraint returns a function converting a size rule to a call of
+// the builtins min and max of package pkg.
+func lengthConstraint(pkg, min, max string) func(op cuetoken.Token, n cueast.Expr) cueast.Expr {
+	call := func(name string, n cueast.Expr) cueast.Expr {
+		ref := cueast.NewIdent(pkg)
+		ref.Node = cueast.NewImport(nil, pkg)
+		return cueast.NewCall(cueast.NewSel(ref, name), n)
+	}
+	return func(op cuetoken.Token, n cueast.Expr) cueast.Expr {
+		lit := n.(*cueast.BasicLit)
+		i, err := strconv.Atoi(lit.Value)
+		if err != nil {
+			return nil
+		}
+		switch op {
+		case cuetoken.GTR:
+			return call(min, cueast.NewLit(cuetoken.INT, strconv.Itoa(i+1)))
+		case cuetoken.LSS:
+			return call(max, cueast.NewLit(cuetoken.INT, strconv.Itoa(i-1)))
+		case cuetoken.GEQ:
+			return call(min, n)
+		case cuetoken.LEQ:
+			return call(max, n)
+		}
+		return cueast.NewBinExpr(cuetoken.AND, call(min, n), call(max, n))
+	}
+}
+
+// numLit returns the literal for the number s, or nil if s is not a number.
+func numLit(s string) cueast.Expr {
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return cueast.NewLit(cuetoken.INT, s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return cueast.NewLit(cuetoken.FLOAT, s)
+	}
+	return nil
+}
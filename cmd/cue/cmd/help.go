@@ -182,9 +182,20 @@ var filetypeHelp = &cobra.Command{
     cue         .cue            CUE source files.
     json        .json           JSON files.
     yaml        .yaml/.yml      YAML files.
+    yamlstream                  YAML stream; on output, each element of a
+                                list is written as a separate document.
     jsonl       .jsonl/.ldjson  Line-separated JSON values.
+    xml         .xml            XML files.
+    hcl         .hcl/.tf        HCL files, such as Terraform configurations.
+    csv         .csv            Comma-separated values with a header row.
+                                Columns of numbers or booleans are typed,
+                                unless the infer=false tag is given.
+    tsv         .tsv            Like csv, but with tab-separated values.
     jsonschema                  JSON Schema.
     openapi                     OpenAPI schema.
+    crd                         Kubernetes CustomResourceDefinitions for
+                                definitions with a @crd attribute
+                                (output only).
 	pb                          Use Protobuf mappings (e.g. json+pb)
     textproto    .textproto     Text-based protocol buffers.
     proto        .proto         Protocol Buffer definitions.
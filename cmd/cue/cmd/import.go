@@ -54,6 +54,11 @@ the following modes:
    Mode       Extensions
    json       Look for JSON files (.json, .jsonl, .ldjson).
    yaml       Look for YAML files (.yaml .yml).
+   xml        Look for XML files (.xml).
+   hcl        Look for HCL files, such as Terraform
+              configurations (.hcl, .tf).
+   csv        Look for CSV files (.csv).
+   tsv        Look for TSV files (.tsv).
    text       Look for text files (.txt).
    binary     Look for files with extensions specified by --ext
               and interpret them as binary.
@@ -290,6 +295,14 @@ func runImport(cmd *Command, args []string) (err error) {
 			c.fileFilter = `\.(json|jsonl|ldjson)$`
 		case "yaml":
 			c.fileFilter = `\.(yaml|yml)$`
+		case "xml":
+			c.fileFilter = `\.xml$`
+		case "hcl":
+			c.fileFilter = `\.(hcl|tf)$`
+		case "csv":
+			c.fileFilter = `\.csv$`
+		case "tsv":
+			c.fileFilter = `\.tsv$`
 		case "text":
 			c.fileFilter = `\.txt$`
 		case "binary":
@@ -0,0 +1,42 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"cuelang.org/go/lsp"
+)
+
+func newLSPCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lsp",
+		Short: "run the CUE language server",
+		Long: `Lsp runs a server for the Language Server Protocol (LSP) that
+communicates with an editor over stdin and stdout.
+
+The server reports syntax and evaluation errors for the open files,
+and supports going to the definition of references, hover
+information showing the documentation and value of fields, and
+completion of field names, package members, and builtin import paths.
+Files are evaluated as part of the package in their directory.
+`,
+		Args: cobra.NoArgs,
+		RunE: mkRunE(c, func(cmd *Command, args []string) error {
+			return lsp.Serve(cmd.InOrStdin(), cmd.OutOrStdout())
+		}),
+	}
+	return cmd
+}
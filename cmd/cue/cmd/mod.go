@@ -23,13 +23,27 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"cuelang.org/go/cue/load"
 )
 
 func newModCmd(c *Command) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "mod <cmd> [arguments]",
 		Short: "module maintenance",
-		Long: `
+		Long: `Mod provides commands for the maintenance of modules.
+
+The dependencies of a module are listed in the deps field of its
+cue.mod/module.cue file, which maps module paths to versions:
+
+	module: "example.com/foo"
+	deps: "example.com/bar": v: "v1.2.0"
+
+These modules are fetched from the registry set in the CUE_REGISTRY
+environment variable. A registry of the form oci:<host> refers to an
+OCI registry; any other value is the URL of a server providing the
+contents of a module at <url>/<module path>/@v/<version>.zip.
+The checksums of the modules are kept in cue.mod/cue.sum.
 `,
 		RunE: mkRunE(c, func(cmd *Command, args []string) error {
 			stderr := cmd.Stderr()
@@ -45,9 +59,33 @@ func newModCmd(c *Command) *cobra.Command {
 	}
 
 	cmd.AddCommand(newModInitCmd(c))
+	cmd.AddCommand(newModGetCmd(c))
+	cmd.AddCommand(newModTidyCmd(c))
+	cmd.AddCommand(newModVendorCmd(c))
 	return cmd
 }
 
+// newRegistry returns the registry set in CUE_REGISTRY or nil if it is not
+// set.
+func newRegistry() load.Registry {
+	r := os.Getenv("CUE_REGISTRY")
+	switch {
+	case r == "":
+		return nil
+	case strings.HasPrefix(r, "oci:"):
+		return load.NewOCIRegistry(strings.TrimPrefix(r, "oci:"))
+	}
+	return load.NewHTTPRegistry(r)
+}
+
+func modConfig() (*load.Config, error) {
+	r := newRegistry()
+	if r == nil {
+		return nil, fmt.Errorf("no registry configured; set CUE_REGISTRY")
+	}
+	return &load.Config{Registry: r}, nil
+}
+
 func newModInitCmd(c *Command) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "init [module]",
@@ -173,3 +211,92 @@ func backport(mod, cwd string) error {
 
 	return nil
 }
+
+func newModGetCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get <package>@<version>...",
+		Short: "add or upgrade dependencies",
+		Long: `Get adds the modules providing the given packages at the given
+versions to the dependencies of the current module, replacing other
+versions of these modules, and records their checksums in cue.mod/cue.sum.
+
+The module providing a package is the module with the longest path
+that is a prefix of the import path of the package and that is
+available at the given version in the registry.
+`,
+		RunE: mkRunE(c, runModGet),
+	}
+	return cmd
+}
+
+func runModGet(cmd *Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no packages specified")
+	}
+	cfg, err := modConfig()
+	if err != nil {
+		return err
+	}
+	for _, arg := range args {
+		p := strings.LastIndexByte(arg, '@')
+		if p < 0 {
+			return fmt.Errorf("missing version in %q; use <package>@<version>", arg)
+		}
+		m, err := load.Get(cfg, arg[:p], arg[p+1:])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.Stderr(), "added %s\n", m)
+	}
+	return nil
+}
+
+func newModTidyCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tidy",
+		Short: "remove unused dependencies",
+		Long: `Tidy removes the modules from the dependencies of the current module
+that provide none of the packages imported by the module, directly or
+indirectly, and updates cue.mod/cue.sum to contain exactly the checksums
+of the remaining modules.
+`,
+		RunE: mkRunE(c, runModTidy),
+	}
+	return cmd
+}
+
+func runModTidy(cmd *Command, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("tidy takes no arguments")
+	}
+	cfg, err := modConfig()
+	if err != nil {
+		return err
+	}
+	_, err = load.Tidy(cfg)
+	return err
+}
+
+func newModVendorCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vendor",
+		Short: "make copies of dependencies",
+		Long: `Vendor copies the contents of the dependencies of the current module
+to cue.mod/pkg, after which the module can be used without a registry.
+Existing copies of these modules in cue.mod/pkg are replaced.
+`,
+		RunE: mkRunE(c, runModVendor),
+	}
+	return cmd
+}
+
+func runModVendor(cmd *Command, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("vendor takes no arguments")
+	}
+	cfg, err := modConfig()
+	if err != nil {
+		return err
+	}
+	return load.Vendor(cfg)
+}
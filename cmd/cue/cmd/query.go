@@ -0,0 +1,179 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+// A query selects values within a configuration. It is a CUE path in which
+// a selector may be replaced by a wildcard: a label * selects all regular
+// fields of a struct and an index [*] selects all elements of a list.
+//
+// Examples:
+//
+//	spec.replicas
+//	deployment.*.spec.containers[*].image
+//	#Defs."my-field"[0]
+type query struct {
+	steps []queryStep
+}
+
+// A queryStep is either a single selector or a wildcard.
+type queryStep struct {
+	sel cue.Selector
+
+	anyField bool // *
+	anyElem  bool // [*]
+}
+
+// parseQuery parses a query from s.
+func parseQuery(s string) (*query, error) {
+	q := &query{}
+	errf := func(format string, args ...interface{}) (*query, error) {
+		args = append([]interface{}{s}, args...)
+		return nil, errors.Newf(token.NoPos, "invalid query %q: "+format, args...)
+	}
+
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '[':
+			n := strings.IndexByte(s[i:], ']')
+			if n < 0 {
+				return errf("missing ']'")
+			}
+			elem := s[i+1 : i+n]
+			i += n + 1
+
+			switch {
+			case elem == "*":
+				q.steps = append(q.steps, queryStep{anyElem: true})
+			case strings.HasPrefix(elem, `"`):
+				str, err := strconv.Unquote(elem)
+				if err != nil {
+					return errf("invalid string %s", elem)
+				}
+				q.steps = append(q.steps, queryStep{sel: cue.Str(str)})
+			default:
+				x, err := strconv.Atoi(elem)
+				if err != nil || x < 0 {
+					return errf("invalid index [%s]", elem)
+				}
+				q.steps = append(q.steps, queryStep{sel: cue.Index(x)})
+			}
+
+		case s[i] == '.' && i > 0:
+			i++
+			if i == len(s) || s[i] == '.' || s[i] == '[' {
+				return errf("missing label after '.'")
+			}
+
+		default:
+			if i > 0 && s[i-1] != '.' {
+				return errf("missing '.' before %s", s[i:])
+			}
+			n := labelEnd(s[i:])
+			if n == 0 {
+				return errf("missing label")
+			}
+			label := s[i : i+n]
+			i += n
+			if label == "*" {
+				q.steps = append(q.steps, queryStep{anyField: true})
+				break
+			}
+			p := cue.ParsePath(label)
+			if err := p.Err(); err != nil || len(p.Selectors()) != 1 {
+				return errf("invalid label %s", label)
+			}
+			q.steps = append(q.steps, queryStep{sel: p.Selectors()[0]})
+		}
+	}
+	return q, nil
+}
+
+// labelEnd returns the length of the label at the start of s, which ends at
+// the first '.' or '[' outside of a string literal.
+func labelEnd(s string) int {
+	inString := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case !inString && (c == '.' || c == '['):
+			return i
+		}
+	}
+	return len(s)
+}
+
+// hasWildcard reports whether q may select more than one value.
+func (q *query) hasWildcard() bool {
+	for _, s := range q.steps {
+		if s.anyField || s.anyElem {
+			return true
+		}
+	}
+	return false
+}
+
+// apply returns the values selected by q within v, in order. Values that do
+// not have a path matched by a wildcard are skipped. Otherwise, a selector
+// that does not exist results in an error value.
+func (q *query) apply(v cue.Value) []cue.Value {
+	values := []cue.Value{v}
+	expanded := false
+	for _, s := range q.steps {
+		var next []cue.Value
+		for _, v := range values {
+			switch {
+			case s.anyField:
+				if v.IncompleteKind() != cue.StructKind {
+					continue
+				}
+				iter, _ := v.Fields()
+				for iter.Next() {
+					next = append(next, iter.Value())
+				}
+
+			case s.anyElem:
+				if v.IncompleteKind() != cue.ListKind {
+					continue
+				}
+				iter, _ := v.List()
+				for iter.Next() {
+					next = append(next, iter.Value())
+				}
+
+			default:
+				w := v.LookupPath(cue.MakePath(s.sel))
+				if expanded && !w.Exists() {
+					continue
+				}
+				next = append(next, w)
+			}
+		}
+		values = next
+		expanded = expanded || s.anyField || s.anyElem
+	}
+	return values
+}
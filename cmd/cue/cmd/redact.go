@@ -0,0 +1,161 @@
+// Copyright 2021 The CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+)
+
+// mask replaces secrets in the output of tasks.
+const mask = "***"
+
+// A redactor replaces the secrets listed in the $secrets field of a task in
+// the output of the task.
+type redactor struct {
+	r *strings.Replacer
+}
+
+// newRedactor returns a redactor for the secrets of the task v, or nil if v
+// does not define any.
+//
+// The output written by a task is redacted line by line. To redact secrets
+// spanning multiple lines, such as keys, each of their lines is treated as a
+// separate secret.
+func newRedactor(v cue.Value) (*redactor, error) {
+	s := v.LookupPath(cue.MakePath(cue.Str("$secrets")))
+	if !s.Exists() {
+		return nil, nil
+	}
+	var secrets []string
+	for iter, _ := s.List(); iter.Next(); {
+		str, err := iter.Value().String()
+		if err != nil {
+			return nil, errors.Wrapf(err, iter.Value().Pos(), "invalid secret")
+		}
+		for _, line := range strings.Split(str, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				secrets = append(secrets, line)
+			}
+		}
+	}
+	if len(secrets) == 0 {
+		return nil, nil
+	}
+	// Replace longer secrets first, so that a secret containing another one
+	// is masked as a whole.
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+	var oldnew []string
+	for _, s := range secrets {
+		oldnew = append(oldnew, s, mask)
+	}
+	return &redactor{r: strings.NewReplacer(oldnew...)}, nil
+}
+
+// String returns s with all secrets masked.
+func (r *redactor) String(s string) string {
+	return r.r.Replace(s)
+}
+
+// value returns the result x of a task with all secrets in string and bytes
+// values masked. It returns x as is if r is nil.
+func (r *redactor) value(x interface{}) interface{} {
+	if r == nil {
+		return x
+	}
+	switch x := x.(type) {
+	case string:
+		return r.String(x)
+	case []byte:
+		return []byte(r.String(string(x)))
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, v := range x {
+			m[k] = r.value(v)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(x))
+		for i, v := range x {
+			a[i] = r.value(v)
+		}
+		return a
+	case []string:
+		a := make([]string, len(x))
+		for i, v := range x {
+			a[i] = r.String(v)
+		}
+		return a
+	}
+	return x
+}
+
+// error returns err with all secrets in its message masked. It returns err
+// as is if r is nil.
+func (r *redactor) error(err error) error {
+	if r == nil {
+		return err
+	}
+	msg := err.Error()
+	if s := r.String(msg); s != msg {
+		return errors.New(s)
+	}
+	return err
+}
+
+// writer returns a Writer that writes the output written to it to w with all
+// secrets masked. Output is written line by line. Flush must be called to
+// write the last incomplete line.
+func (r *redactor) writer(w io.Writer) *redactWriter {
+	return &redactWriter{r: r, w: w}
+}
+
+type redactWriter struct {
+	r   *redactor
+	w   io.Writer
+	buf []byte // incomplete last line
+}
+
+func (w *redactWriter) Write(b []byte) (n int, err error) {
+	w.buf = append(w.buf, b...)
+	i := bytes.LastIndexByte(w.buf, '\n')
+	if i < 0 {
+		return len(b), nil
+	}
+	lines := w.r.String(string(w.buf[:i+1]))
+	w.buf = append(w.buf[:0], w.buf[i+1:]...)
+	if _, err := io.WriteString(w.w, lines); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Flush writes the incomplete last line, if any.
+func (w *redactWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	s := w.r.String(string(w.buf))
+	w.buf = w.buf[:0]
+	_, err := io.WriteString(w.w, s)
+	return err
+}
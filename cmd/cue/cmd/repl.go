@@ -0,0 +1,212 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal"
+	cueruntime "cuelang.org/go/internal/core/runtime"
+	"cuelang.org/go/internal/filetypes"
+)
+
+func newReplCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repl",
+		Short: "evaluate expressions interactively",
+		Long: `Repl loads a CUE package and evaluates the expressions entered
+on each line in the scope of this package. Expressions may refer to
+fields of the package, including definitions and hidden fields,
+and to builtin packages by their name.
+
+As for other commands, the package in the current directory is loaded
+in absence of arguments.
+
+Enter a line of the form ":complete x.y" to list the possible
+completions of the last reference on the line. Lines are read as is,
+without line editing, so pressing the tab key does not complete
+references. The following commands are recognized:
+
+	:complete <expr>   list completions of the last reference in expr
+	:help              show this help
+	:quit              exit the REPL
+
+The REPL also exits at the end of the input.
+`,
+		RunE: mkRunE(c, runRepl),
+	}
+
+	addInjectionFlags(cmd.Flags(), false)
+
+	return cmd
+}
+
+func runRepl(cmd *Command, args []string) error {
+	b, err := parseArgs(cmd, args, &config{outMode: filetypes.Eval})
+	exitOnErr(cmd, err, true)
+
+	iter := b.instances()
+	defer iter.close()
+	if !iter.scan() {
+		exitOnErr(cmd, iter.err(), true)
+		return errors.Newf(token.NoPos, "no package to evaluate")
+	}
+	v := iter.value()
+	exitOnErr(cmd, v.Err(), false)
+
+	r := &repl{cmd: cmd, v: v, w: cmd.OutOrStdout()}
+	return r.run(cmd.InOrStdin())
+}
+
+type repl struct {
+	cmd *Command
+	v   cue.Value
+	w   io.Writer
+}
+
+const replPrompt = "cue> "
+
+func (r *repl) run(in io.Reader) error {
+	s := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(r.w, replPrompt)
+		if !s.Scan() {
+			fmt.Fprintln(r.w)
+			return s.Err()
+		}
+		line := strings.TrimSpace(s.Text())
+		switch {
+		case line == "":
+		case line == ":quit" || line == ":q":
+			return nil
+		case line == ":help" || line == ":h":
+			fmt.Fprint(r.w, r.cmd.Long)
+		case strings.HasPrefix(line, ":complete"):
+			r.complete(strings.TrimSpace(strings.TrimPrefix(line, ":complete")))
+		case strings.HasPrefix(line, ":"):
+			fmt.Fprintf(r.w, "unknown command %q; type :help for help\n", line)
+		default:
+			r.eval(line)
+		}
+	}
+}
+
+// eval evaluates the expression src and prints the result.
+func (r *repl) eval(src string) {
+	expr, err := parser.ParseExpr("<repl>", src)
+	if err != nil {
+		r.printErr(err)
+		return
+	}
+	v := r.v.EvalExpr(expr)
+	if err := v.Err(); err != nil {
+		r.printErr(err)
+		return
+	}
+	n := v.Syntax(cue.Final(), cue.Definitions(true))
+	b, err := format.Node(internal.ToFile(n))
+	if err != nil {
+		r.printErr(err)
+		return
+	}
+	fmt.Fprintf(r.w, "%s", b)
+}
+
+// printErr prints err. Unlike for other commands, errors do not affect the
+// exit code, as they only relate to the input of a single line.
+func (r *repl) printErr(err error) {
+	cwd, _ := os.Getwd()
+	errors.Print(r.cmd.OutOrStderr(), err, &errors.Config{
+		Cwd:     cwd,
+		ToSlash: inTest,
+	})
+}
+
+// refRe matches the reference at the end of a line, such as a.b.c or a.b.
+var refRe = regexp.MustCompile(`(?:([\w#$]+(?:\.[\w#$]+)*)\.)?([\w#$]*)$`)
+
+// complete prints the completions of the reference at the end of line.
+func (r *repl) complete(line string) {
+	for _, c := range r.completions(line) {
+		fmt.Fprintln(r.w, c)
+	}
+}
+
+// completions returns the possible completions of the reference at the end of
+// line, sorted by name.
+func (r *repl) completions(line string) []string {
+	m := refRe.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	base, prefix := m[1], m[2]
+
+	var names []string
+	if base == "" {
+		names = fieldNames(r.v)
+		r := cueruntime.SharedRuntime
+		for _, p := range r.BuiltinPackages() {
+			if name := path.Base(p); r.BuiltinPackagePath(name) == p {
+				names = append(names, name)
+			}
+		}
+	} else {
+		expr, err := parser.ParseExpr("<repl>", base)
+		if err != nil {
+			return nil
+		}
+		names = fieldNames(r.v.EvalExpr(expr))
+		base += "."
+	}
+
+	var a []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) && !seen[name] {
+			seen[name] = true
+			a = append(a, base+name)
+		}
+	}
+	sort.Strings(a)
+	return a
+}
+
+// fieldNames returns the labels of the regular fields, definitions, and hidden
+// fields of v.
+func fieldNames(v cue.Value) []string {
+	iter, err := v.Fields(cue.Definitions(true), cue.Hidden(true))
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for iter.Next() {
+		names = append(names, iter.Selector().String())
+	}
+	return names
+}
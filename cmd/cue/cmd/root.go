@@ -22,6 +22,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"cuelang.org/go/cue/cuecontext"
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/token"
 )
@@ -91,12 +92,16 @@ For more information on writing CUE configuration files see cuelang.org.`,
 		newCompletionCmd(c),
 		newEvalCmd(c),
 		newDefCmd(c),
+		newDiffCmd(c),
 		newExportCmd(c),
 		newFixCmd(c),
 		newFmtCmd(c),
+		newGenCmd(c),
 		newGetCmd(c),
 		newImportCmd(c),
+		newLSPCmd(c),
 		newModCmd(c),
+		newReplCmd(c),
 		newTrimCmd(c),
 		newVersionCmd(c),
 		newVetCmd(c),
@@ -154,6 +159,10 @@ type Command struct {
 	// Subcommands
 	cmd *cobra.Command
 
+	// profile, if not nil, records evaluation statistics for the instances
+	// built by the command.
+	profile *cuecontext.Profile
+
 	hasErr bool
 }
 
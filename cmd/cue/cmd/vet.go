@@ -20,6 +20,7 @@ import (
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/encoding/json"
 )
 
 const vetDoc = `vet validates CUE and other data files
@@ -59,6 +60,21 @@ Examples:
   cue vet translations/*.yaml foo.cue -d '#Translation'
 
 If more than one expression is given, all must match all values.
+
+
+Checking a stream of JSON values
+
+With the --ndjson flag, vet reads a stream of JSON values, such as
+newline-delimited JSON (NDJSON), from stdin and checks each of them
+against the loaded CUE files or the expression given by -d. Values are
+checked one at a time, so that streams of any length can be checked with
+constant memory. Errors are reported for each failing value, together with
+its position in the stream, starting at 1.
+
+Examples:
+
+  # Check a log of events against a CUE definition:
+  cue vet --ndjson schema.cue -d '#Event' < events.ndjson
 `
 
 func newVetCmd(c *Command) *cobra.Command {
@@ -75,6 +91,9 @@ func newVetCmd(c *Command) *cobra.Command {
 	cmd.Flags().BoolP(string(flagConcrete), "c", false,
 		"require the evaluation to be concrete")
 
+	cmd.Flags().Bool(string(flagNDJSON), false,
+		"check a stream of JSON values read from stdin")
+
 	return cmd
 }
 
@@ -92,6 +111,11 @@ func doVet(cmd *Command, args []string) error {
 	})
 	exitOnErr(cmd, err, true)
 
+	if flagNDJSON.Bool(cmd) {
+		vetStream(cmd, b)
+		return nil
+	}
+
 	// Go into a special vet mode if the user explicitly specified non-cue
 	// files on the command line.
 	// TODO: unify these two modes.
@@ -156,3 +180,29 @@ func vetFiles(cmd *Command, b *buildPlan) {
 	}
 	exitOnErr(cmd, iter.err(), false)
 }
+
+// vetStream checks each of the JSON values read from stdin against the schema
+// defined by the loaded instance.
+func vetStream(cmd *Command, b *buildPlan) {
+	if len(b.orphaned) > 0 {
+		exitOnErr(cmd, errors.New("cannot combine --ndjson with data files"), true)
+	}
+
+	iter := b.instances()
+	defer iter.close()
+	if !iter.scan() {
+		exitOnErr(cmd, iter.err(), true)
+		exitOnErr(cmd, errors.New("no schema to check values against"), true)
+	}
+	schema := iter.value()
+	if b.schema != nil {
+		schema = schema.EvalExpr(b.schema)
+	}
+	exitOnErr(cmd, schema.Err(), true)
+
+	d := json.NewStreamDecoder(schema.Context(), "stdin", cmd.InOrStdin())
+	for d.Next() {
+		exitOnErr(cmd, d.Validate(schema), false)
+	}
+	exitOnErr(cmd, d.Err(), true)
+}
@@ -21,6 +21,7 @@ import (
 	"reflect"
 
 	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
 )
 
 // A Cursor describes a node encountered during Apply.
@@ -30,7 +31,8 @@ import (
 // The methods Replace, Delete, InsertBefore, and InsertAfter
 // can be used to change the AST without disrupting Apply.
 // Delete, InsertBefore, and InsertAfter are only defined for modifying
-// a StructLit and will panic in any other context.
+// the declarations of a StructLit or File, the elements of a ListLit, or the
+// arguments of a CallExpr, and will panic in any other context.
 type Cursor interface {
 	// Node returns the current Node.
 	Node() ast.Node
@@ -54,17 +56,19 @@ type Cursor interface {
 	// with it.
 	Replace(n ast.Node)
 
-	// Delete deletes the current Node from its containing struct.
-	// If the current Node is not part of a struct, Delete panics.
+	// Delete deletes the current Node from its containing struct or list.
+	// If the current Node is not part of a struct or list, Delete panics.
 	Delete()
 
-	// InsertAfter inserts n after the current Node in its containing struct.
-	// If the current Node is not part of a struct, InsertAfter panics.
+	// InsertAfter inserts n after the current Node in its containing struct
+	// or list. If the current Node is not part of a struct or list,
+	// InsertAfter panics.
 	// Unless n is wrapped by ApplyRecursively, Apply does not walk n.
 	InsertAfter(n ast.Node)
 
-	// InsertBefore inserts n before the current Node in its containing struct.
-	// If the current Node is not part of a struct, InsertBefore panics.
+	// InsertBefore inserts n before the current Node in its containing struct
+	// or list. If the current Node is not part of a struct or list,
+	// InsertBefore panics.
 	// Unless n is wrapped by ApplyRecursively, Apply does not walk n.
 	InsertBefore(n ast.Node)
 
@@ -90,6 +94,7 @@ type info struct {
 
 type cursor struct {
 	file     *info
+	applier  *applier
 	parent   Cursor
 	node     ast.Node
 	typ      interface{} // the type of the node
@@ -98,12 +103,16 @@ type cursor struct {
 }
 
 func newCursor(parent Cursor, n ast.Node, typ interface{}) *cursor {
-	return &cursor{
+	c := &cursor{
 		parent: parent,
 		typ:    typ,
 		node:   n,
 		index:  -1,
 	}
+	if parent != nil {
+		c.applier = parent.self().applier
+	}
+	return c
 }
 
 func fileInfo(c Cursor) (info *info) {
@@ -158,6 +167,7 @@ func (c *cursor) Replace(n ast.Node) {
 	} else {
 		c.replaced = true
 	}
+	c.applier.remove(c.node)
 	c.node = n
 }
 
@@ -185,8 +195,17 @@ func (c *cursor) Delete()                 { panic("unsupported") }
 // Children are traversed in the order in which they appear in the
 // respective node's struct definition.
 //
+// If node is a File that was modified, the identifiers in the file are
+// resolved again: references to nodes that were removed from the file are
+// cleared and, like for all unresolved identifiers, including those of
+// inserted nodes, looked up anew.
+//
 func Apply(node ast.Node, before, after func(Cursor) bool) ast.Node {
-	apply(&applier{before: before, after: after}, nil, &node)
+	a := &applier{before: before, after: after}
+	apply(a, nil, &node)
+	if f, ok := node.(*ast.File); ok && a.modified {
+		a.resolve(f)
+	}
 	return node
 }
 
@@ -213,6 +232,67 @@ func applyExprList(v applyVisitor, parent Cursor, ptr interface{}, list []ast.Ex
 	}
 }
 
+type exprsCursor struct {
+	*cursor
+	exprs, after, process []ast.Expr
+	delete                bool
+}
+
+func (c *exprsCursor) InsertAfter(n ast.Node) {
+	c.applier.insert()
+	if r, ok := n.(recursive); ok {
+		n = r.Node
+		c.process = append(c.process, n.(ast.Expr))
+	}
+	c.after = append(c.after, n.(ast.Expr))
+}
+
+func (c *exprsCursor) InsertBefore(n ast.Node) {
+	c.applier.insert()
+	if r, ok := n.(recursive); ok {
+		n = r.Node
+		c.process = append(c.process, n.(ast.Expr))
+	}
+	c.exprs = append(c.exprs, n.(ast.Expr))
+}
+
+func (c *exprsCursor) Delete() {
+	c.applier.remove(c.node)
+	c.delete = true
+}
+
+// applyExprs is like applyExprList, but allows elements to be inserted and
+// deleted. It returns the resulting list.
+func applyExprs(v applyVisitor, parent Cursor, list []ast.Expr) []ast.Expr {
+	c := &exprsCursor{
+		cursor: newCursor(parent, nil, nil),
+		exprs:  make([]ast.Expr, 0, len(list)),
+	}
+	for i, x := range list {
+		c.index = i
+		c.node = x
+		c.typ = &list[i]
+		applyCursor(v, c)
+		if !c.delete {
+			c.exprs = append(c.exprs, c.node.(ast.Expr))
+		}
+		c.delete = false
+		for i := 0; i < len(c.process); i++ {
+			x := c.process[i]
+			c.node = x
+			c.typ = &c.process[i]
+			applyCursor(v, c)
+			if c.delete {
+				panic("cannot delete a node that was added with InsertBefore or InsertAfter")
+			}
+		}
+		c.exprs = append(c.exprs, c.after...)
+		c.after = c.after[:0]
+		c.process = c.process[:0]
+	}
+	return c.exprs
+}
+
 type declsCursor struct {
 	*cursor
 	decls, after, process []ast.Decl
@@ -220,6 +300,7 @@ type declsCursor struct {
 }
 
 func (c *declsCursor) InsertAfter(n ast.Node) {
+	c.applier.insert()
 	if r, ok := n.(recursive); ok {
 		n = r.Node
 		c.process = append(c.process, n.(ast.Decl))
@@ -228,6 +309,7 @@ func (c *declsCursor) InsertAfter(n ast.Node) {
 }
 
 func (c *declsCursor) InsertBefore(n ast.Node) {
+	c.applier.insert()
 	if r, ok := n.(recursive); ok {
 		n = r.Node
 		c.process = append(c.process, n.(ast.Decl))
@@ -235,7 +317,10 @@ func (c *declsCursor) InsertBefore(n ast.Node) {
 	c.decls = append(c.decls, n.(ast.Decl))
 }
 
-func (c *declsCursor) Delete() { c.delete = true }
+func (c *declsCursor) Delete() {
+	c.applier.remove(c.node)
+	c.delete = true
+}
 
 func applyDeclList(v applyVisitor, parent Cursor, list []ast.Decl) []ast.Decl {
 	c := &declsCursor{
@@ -292,6 +377,9 @@ func apply(v applyVisitor, parent Cursor, nodePtr interface{}) {
 	n := res.Interface()
 	node := n.(ast.Node)
 	c := newCursor(parent, node, nodePtr)
+	if c.applier == nil {
+		c.applier, _ = v.(*applier)
+	}
 	applyCursor(v, c)
 	if node != c.node {
 		res.Set(reflect.ValueOf(c.node))
@@ -354,7 +442,7 @@ func applyCursor(v applyVisitor, c Cursor) {
 		applyExprList(v, c, &n, n.Elts)
 
 	case *ast.ListLit:
-		applyExprList(v, c, &n, n.Elts)
+		n.Elts = applyExprs(v, c, n.Elts)
 
 	case *ast.Ellipsis:
 		if n.Type != nil {
@@ -383,7 +471,7 @@ func applyCursor(v applyVisitor, c Cursor) {
 
 	case *ast.CallExpr:
 		apply(v, c, &n.Fun)
-		applyExprList(v, c, &n, n.Args)
+		n.Args = applyExprs(v, c, n.Args)
 
 	case *ast.UnaryExpr:
 		apply(v, c, &n.X)
@@ -455,6 +543,52 @@ type applier struct {
 
 	commentStack []commentFrame
 	current      commentFrame
+
+	modified bool
+	removed  map[ast.Node]bool
+}
+
+// insert records that a node was inserted.
+func (f *applier) insert() {
+	if f != nil {
+		f.modified = true
+	}
+}
+
+// remove records that n and its descendants were removed.
+func (f *applier) remove(n ast.Node) {
+	if f == nil {
+		return
+	}
+	f.modified = true
+	if f.removed == nil {
+		f.removed = map[ast.Node]bool{}
+	}
+	ast.Walk(n, func(n ast.Node) bool {
+		f.removed[n] = true
+		return true
+	}, nil)
+}
+
+// resolve clears the references to nodes removed from f and resolves the
+// identifiers of f again.
+func (f *applier) resolve(file *ast.File) {
+	present := map[ast.Node]bool{}
+	ast.Walk(file, func(n ast.Node) bool {
+		present[n] = true
+		return true
+	}, nil)
+	ast.Walk(file, func(n ast.Node) bool {
+		if x, ok := n.(*ast.Ident); ok && x.Node != nil {
+			if f.removed[x.Node] && !present[x.Node] {
+				x.Node = nil
+				x.Scope = nil
+			}
+		}
+		return true
+	}, nil)
+	file.Unresolved = nil
+	Resolve(file, func(token.Pos, string, ...interface{}) {})
 }
 
 type commentFrame struct {
@@ -320,6 +320,41 @@ a: list6c6973
 			}
 			return true
 		},
+	}, {
+		name: "list delete",
+		in: `
+a: [1, 2, 3]
+b: f(1, 2)
+`,
+		out: `
+a: [1, 3]
+b: f(1)
+`,
+		before: func(c astutil.Cursor) bool {
+			if x, ok := c.Node().(*ast.BasicLit); ok && x.Value == "2" {
+				c.Delete()
+			}
+			return true
+		},
+	}, {
+		name: "list insert",
+		in: `
+a: [1, 2]
+`,
+		out: `
+a: [0, 1, 2, 3]
+`,
+		before: func(c astutil.Cursor) bool {
+			if x, ok := c.Node().(*ast.BasicLit); ok {
+				switch x.Value {
+				case "1":
+					c.InsertBefore(ast.NewLit(token.INT, "0"))
+				case "2":
+					c.InsertAfter(ast.NewLit(token.INT, "3"))
+				}
+			}
+			return true
+		},
 	}}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -338,3 +373,39 @@ a: list6c6973
 		})
 	}
 }
+
+func TestApplyResolve(t *testing.T) {
+	f, err := parser.ParseFile("resolve", `
+a: 1
+b: a
+c: d
+`)
+	require.NoError(t, err)
+
+	var ref, d *ast.Ident
+	astutil.Apply(f, func(c astutil.Cursor) bool {
+		x, ok := c.Node().(*ast.Field)
+		if !ok {
+			return true
+		}
+		switch name, _, _ := ast.LabelName(x.Label); name {
+		case "a":
+			c.Delete()
+		case "b":
+			ref = x.Value.(*ast.Ident)
+			c.InsertAfter(&ast.Field{
+				Label: ast.NewIdent("d"),
+				Value: ast.NewLit(token.INT, "2"),
+			})
+		case "c":
+			d = x.Value.(*ast.Ident)
+		}
+		return true
+	}, nil)
+
+	assert.Nil(t, ref.Node, "reference to deleted field")
+	assert.Nil(t, ref.Scope, "reference to deleted field")
+	require.NotNil(t, d.Node, "reference to inserted field")
+	assert.Equal(t, "2", d.Node.(*ast.BasicLit).Value)
+	assert.Equal(t, []*ast.Ident{ref}, f.Unresolved)
+}
@@ -14,6 +14,8 @@
 
 package ast
 
+import "cuelang.org/go/cue/token"
+
 // Comments returns all comments associated with a given node.
 func Comments(n Node) []*CommentGroup {
 	c := n.commentInfo()
@@ -44,3 +46,40 @@ func SetComments(n Node, cgs []*CommentGroup) {
 	}
 	c.SetComments(cgs)
 }
+
+// A CommentPosition indicates where a comment group attached to a node is
+// placed. Values between DocComment and LineComment place the comment group
+// before the token with that index, where the tokens of a node are counted as
+// documented for CommentGroup.Position.
+type CommentPosition int8
+
+const (
+	// DocComment places a comment group on the lines before the node.
+	DocComment CommentPosition = 0
+
+	// LineComment places a comment group after the last token of the node,
+	// on the same line.
+	LineComment CommentPosition = 127
+)
+
+// AttachComment attaches cg to n at the given position. Unlike AddComment,
+// it overrides the Doc, Line, and Position fields of cg, as well as any
+// relative positions of its comments that were retained from a source file,
+// so that printing n places the comment group deterministically, regardless
+// of where it came from. Comment groups attached at the same position are
+// printed in the order in which they were attached.
+//
+// AttachComment has no effect if n does not support comments.
+func AttachComment(n Node, cg *CommentGroup, pos CommentPosition) {
+	c := n.commentInfo()
+	if c == nil || cg == nil {
+		return
+	}
+	cg.Doc = pos == DocComment
+	cg.Line = pos == LineComment
+	cg.Position = int8(pos)
+	for _, x := range cg.List {
+		x.Slash = x.Slash.WithRel(token.NoRelPos)
+	}
+	c.AddComment(cg)
+}
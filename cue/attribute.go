@@ -26,20 +26,36 @@ import (
 // Attribute returns the attribute data for the given key.
 // The returned attribute will return an error for any of its methods if there
 // is no attribute for the requested key.
+//
+// Attribute only considers field attributes. It is equivalent to
+// LookupAttribute(key, FieldAttr).
 func (v Value) Attribute(key string) Attribute {
-	// look up the attributes
+	return v.LookupAttribute(key, FieldAttr)
+}
+
+// LookupAttribute returns the first attribute for the given key of any of the
+// kinds in mask. Field attributes are considered before declaration
+// attributes. Use the Kind method of the result to determine where the
+// attribute was declared. As with Attribute, the returned attribute will return
+// an error for any of its methods if there is no such attribute.
+//
+// For instance, for
+//
+//     #D: {
+//         @go(D)
+//     } @protobuf(1)
+//
+// LookupAttribute("go", DeclAttr) returns @go(D) and
+// LookupAttribute("protobuf", FieldAttr) returns @protobuf(1) for #D.
+func (v Value) LookupAttribute(key string, mask AttrKind) Attribute {
 	if v.v == nil {
 		return nonExistAttr(key)
 	}
-	// look up the attributes
-	for _, a := range export.ExtractFieldAttrs(v.v) {
-		k, _ := a.Split()
-		if key != k {
-			continue
+	for _, a := range v.Attributes(mask) {
+		if a.Name() == key {
+			return a
 		}
-		return newAttr(internal.FieldAttr, a)
 	}
-
 	return nonExistAttr(key)
 }
 
@@ -170,6 +186,14 @@ func (a *Attribute) String(pos int) (string, error) {
 	return a.attr.String(pos)
 }
 
+// StringQuoted reports the value of the string literal at the given position,
+// with escape sequences interpreted, or an error if the attribute is invalid,
+// the position does not exist, or the value at the given position is not a
+// quoted string. Unlike String, it does not accept unquoted text.
+func (a *Attribute) StringQuoted(pos int) (string, error) {
+	return a.attr.StringQuoted(pos)
+}
+
 // Int reports the integer at the given position or an error if the attribute is
 // invalid, the position does not exist, or the value at the given position is
 // not an integer.
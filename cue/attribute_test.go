@@ -16,6 +16,7 @@ package cue
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 
 	"cuelang.org/go/cue/errors"
@@ -72,6 +73,120 @@ func TestAttributes(t *testing.T) {
 	}
 }
 
+func TestLookupAttribute(t *testing.T) {
+	const config = `
+	#D: {
+		@go(D)
+		x: int @go(X)
+	} @protobuf(1)
+	a: #D @go(A)
+	#E: int @go(E)
+	`
+
+	testCases := []struct {
+		path string
+		key  string
+		mask AttrKind
+		out  string
+		kind AttrKind
+	}{{
+		path: "#D",
+		key:  "go",
+		mask: DeclAttr,
+		out:  "@go(D)",
+		kind: DeclAttr,
+	}, {
+		path: "#D",
+		key:  "go",
+		mask: FieldAttr,
+		out:  "",
+	}, {
+		path: "#D",
+		key:  "protobuf",
+		mask: ValueAttr,
+		out:  "@protobuf(1)",
+		kind: FieldAttr,
+	}, {
+		path: "a",
+		key:  "go",
+		mask: ValueAttr,
+		out:  "@go(A)",
+		kind: FieldAttr,
+	}, {
+		path: "a",
+		key:  "go",
+		mask: DeclAttr,
+		out:  "@go(D)",
+		kind: DeclAttr,
+	}, {
+		path: "#E",
+		key:  "go",
+		mask: FieldAttr,
+		out:  "@go(E)",
+		kind: FieldAttr,
+	}, {
+		path: "#D.x",
+		key:  "go",
+		mask: ValueAttr,
+		out:  "@go(X)",
+		kind: FieldAttr,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.path+"/"+tc.key, func(t *testing.T) {
+			v := getInstance(t, config).Value().LookupPath(ParsePath(tc.path))
+			a := v.LookupAttribute(tc.key, tc.mask)
+			if tc.out == "" {
+				if a.Err() == nil {
+					t.Errorf("got %v; want no attribute", a)
+				}
+				return
+			}
+			if got := fmt.Sprint(a); got != tc.out {
+				t.Errorf("got %v; want %v", got, tc.out)
+			}
+			if got := a.Kind(); got != tc.kind {
+				t.Errorf("kind: got %v; want %v", got, tc.kind)
+			}
+		})
+	}
+}
+
+func TestFieldAttributes(t *testing.T) {
+	const config = `
+	a: 0 @go(A) @protobuf(1,int64)
+	b?: string @xml(b,attr)
+	c: 2
+	#D: {} @go(D)
+	`
+	want := []string{
+		"a: [@go(A) @protobuf(1,int64)]",
+		"b: [@xml(b,attr)]",
+		"c: []",
+		"#D: [@go(D)]",
+	}
+
+	v := getInstance(t, config).Value()
+
+	var got []string
+	iter, _ := v.Fields(Optional(true), Definitions(true))
+	for iter.Next() {
+		got = append(got, fmt.Sprintf("%v: %v", iter.Selector(), iter.Attributes()))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Iterator: got %q; want %q", got, want)
+	}
+
+	got = got[:0]
+	st, _ := v.Struct()
+	for i := 0; i < st.Len(); i++ {
+		f := st.Field(i)
+		got = append(got, fmt.Sprintf("%v: %v", f.Selector, f.Attributes()))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FieldInfo: got %q; want %q", got, want)
+	}
+}
+
 func TestAttributeErr(t *testing.T) {
 	const config = `
 	a: {
@@ -165,6 +280,50 @@ func TestAttributeString(t *testing.T) {
 	}
 }
 
+func TestAttributeStringQuoted(t *testing.T) {
+	const config = `
+	a: {
+		a: 0 @doc("multi\nline", "tab\there", #"raw\n"#, plain, c="x")
+	}
+	`
+	testCases := []struct {
+		pos int
+		str string
+		err error
+	}{{
+		pos: 0,
+		str: "multi\nline",
+	}, {
+		pos: 1,
+		str: "tab\there",
+	}, {
+		pos: 2,
+		str: `raw\n`,
+	}, {
+		pos: 3,
+		err: errors.New("field is not a quoted string"),
+	}, {
+		pos: 4,
+		err: errors.New("field is not a quoted string"),
+	}, {
+		pos: 5,
+		err: errors.New("field does not exist"),
+	}}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprint(tc.pos), func(t *testing.T) {
+			v := getInstance(t, config).Value().Lookup("a", "a")
+			a := v.Attribute("doc")
+			got, err := a.StringQuoted(tc.pos)
+			if !cmpError(err, tc.err) {
+				t.Errorf("err: got %v; want %v", err, tc.err)
+			}
+			if got != tc.str {
+				t.Errorf("str: got %q; want %q", got, tc.str)
+			}
+		})
+	}
+}
+
 func TestAttributeInt(t *testing.T) {
 	const config = `
 	a: {
@@ -37,6 +37,10 @@ const (
 	JSON        Encoding = "json"
 	YAML        Encoding = "yaml"
 	JSONL       Encoding = "jsonl"
+	XML         Encoding = "xml"
+	HCL         Encoding = "hcl"
+	CSV         Encoding = "csv"
+	TSV         Encoding = "tsv"
 	Text        Encoding = "text"
 	Binary      Encoding = "binary"
 	Protobuf    Encoding = "proto"
@@ -70,6 +74,10 @@ const (
 	JSONSchema   Interpretation = "jsonschema"
 	OpenAPI      Interpretation = "openapi"
 	ProtobufJSON Interpretation = "pb"
+
+	// CRD interprets definitions as Kubernetes CustomResourceDefinitions.
+	// It is only supported for output.
+	CRD Interpretation = "crd"
 )
 
 // A Form specifies the form in which a program should be represented.
@@ -0,0 +1,163 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// MarshalJSONSorted returns the canonical JSON encoding of v as defined by
+// the JSON Canonicalization Scheme (RFC 8785): object keys are sorted, numbers
+// are formatted in their shortest form, and no insignificant whitespace is
+// emitted. Unlike MarshalJSON, the output does not depend on the order in
+// which fields are declared, which makes it suitable for signing.
+//
+// As RFC 8785 represents numbers as IEEE 754 double precision values, numbers
+// that cannot be represented as such lose precision.
+func (v Value) MarshalJSONSorted() ([]byte, error) {
+	b, err := v.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var x interface{}
+	if err := d.Decode(&x); err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := writeCanonical(buf, x); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeCanonical(w *bytes.Buffer, x interface{}) error {
+	switch x := x.(type) {
+	case nil:
+		w.WriteString("null")
+
+	case bool:
+		w.WriteString(strconv.FormatBool(x))
+
+	case string:
+		writeCanonicalString(w, x)
+
+	case json.Number:
+		f, err := x.Float64()
+		if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+			return fmt.Errorf("cannot represent number %s in canonical JSON", x)
+		}
+		w.WriteString(canonicalNumber(f))
+
+	case []interface{}:
+		w.WriteByte('[')
+		for i, e := range x {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			if err := writeCanonical(w, e); err != nil {
+				return err
+			}
+		}
+		w.WriteByte(']')
+
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		// Keys are sorted by their UTF-16 code units.
+		sort.Slice(keys, func(i, j int) bool {
+			a, b := utf16.Encode([]rune(keys[i])), utf16.Encode([]rune(keys[j]))
+			for k := 0; k < len(a) && k < len(b); k++ {
+				if a[k] != b[k] {
+					return a[k] < b[k]
+				}
+			}
+			return len(a) < len(b)
+		})
+		w.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeCanonicalString(w, k)
+			w.WriteByte(':')
+			if err := writeCanonical(w, x[k]); err != nil {
+				return err
+			}
+		}
+		w.WriteByte('}')
+
+	default:
+		return fmt.Errorf("unexpected JSON value of type %T", x)
+	}
+	return nil
+}
+
+// writeCanonicalString writes s as a JSON string, only escaping the
+// characters that must be escaped.
+func writeCanonicalString(w *bytes.Buffer, s string) {
+	w.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			w.WriteString(`\"`)
+		case '\\':
+			w.WriteString(`\\`)
+		case '\b':
+			w.WriteString(`\b`)
+		case '\f':
+			w.WriteString(`\f`)
+		case '\n':
+			w.WriteString(`\n`)
+		case '\r':
+			w.WriteString(`\r`)
+		case '\t':
+			w.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(w, `\u%04x`, r)
+			} else {
+				w.WriteRune(r)
+			}
+		}
+	}
+	w.WriteByte('"')
+}
+
+// canonicalNumber formats f as ECMAScript's Number.prototype.toString does.
+func canonicalNumber(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+	abs := math.Abs(f)
+	if abs < 1e21 && abs >= 1e-6 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	// Remove leading zeros from the exponent, as in 1e-07.
+	i := strings.IndexByte(s, 'e')
+	mant, exp := s[:i+2], strings.TrimLeft(s[i+2:], "0")
+	return mant + exp
+}
@@ -0,0 +1,54 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+import (
+	"testing"
+)
+
+func TestMarshalJSONSorted(t *testing.T) {
+	testCases := []struct {
+		value string
+		out   string
+		err   string
+	}{{
+		value: `{b: 1, a: 2, c: {z: true, y: null}}`,
+		out:   `{"a":2,"b":1,"c":{"y":null,"z":true}}`,
+	}, {
+		value: `{"€": 1, "\r": 2, "1": 3, "ö": 4, "😀": 5, "€\u0000": 6}`,
+		out:   `{"\r":2,"1":3,"ö":4,"€":1,"€\u0000":6,"😀":5}`,
+	}, {
+		value: `[1.0, 1.5e2, 0.0000001, 1e21, 1e20, -0.0, 333333333.33333329]`,
+		out:   `[1,150,1e-7,1e+21,100000000000000000000,0,333333333.3333333]`,
+	}, {
+		value: `"<tag> & \"q\" \\ \t \u001f"`,
+		out:   `"<tag> & \"q\" \\ \t \u001f"`,
+	}, {
+		value: `{a: *1 | int, b: [...int]}`,
+		out:   `{"a":1,"b":[]}`,
+	}, {
+		value: `{a: int}`,
+		err:   "a: cannot convert incomplete value",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			b, err := getInstance(t, tc.value).Value().MarshalJSONSorted()
+			checkErr(t, err, tc.err, "MarshalJSONSorted")
+			if got := string(b); got != tc.out {
+				t.Errorf("got %s; want %s", got, tc.out)
+			}
+		})
+	}
+}
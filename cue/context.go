@@ -252,6 +252,7 @@ type EncodeOption func(*encodeOptions)
 
 type encodeOptions struct {
 	nilIsTop bool
+	omitZero bool
 }
 
 func (o *encodeOptions) process(option []EncodeOption) {
@@ -262,11 +263,19 @@ func (o *encodeOptions) process(option []EncodeOption) {
 
 // NilIsAny indicates whether a nil value is interpreted as null or _.
 //
-// The default is to interpret nil as _.
+// The default is to interpret nil as null.
 func NilIsAny(isAny bool) EncodeOption {
 	return func(o *encodeOptions) { o.nilIsTop = isAny }
 }
 
+// OmitZero indicates whether struct fields with a zero value are omitted, as
+// if all fields were tagged with omitempty.
+//
+// The default is to only omit fields that are explicitly tagged as such.
+func OmitZero(omit bool) EncodeOption {
+	return func(o *encodeOptions) { o.omitZero = omit }
+}
+
 // Encode converts a Go value to a CUE value.
 //
 // The returned Value will represent an error, accessible through Err, if any
@@ -305,7 +314,8 @@ func NilIsAny(isAny bool) EncodeOption {
 //
 // The "omitempty" option specifies that the field should be omitted from the
 // encoding if the field has an empty value, defined as false, 0, a nil pointer,
-// a nil interface value, and any empty array, slice, map, or string.
+// a nil interface value, and any empty array, slice, map, or string. The
+// OmitZero EncodeOption can be used to apply this to all fields.
 //
 // See the documentation for Go's json.Marshal for more details on the field
 // tags and their meaning.
@@ -361,7 +371,10 @@ func (c *Context) Encode(x interface{}, option ...EncodeOption) Value {
 
 	ctx := c.ctx()
 	// TODO: is true the right default?
-	expr := convert.GoValueToValue(ctx, x, options.nilIsTop)
+	expr := convert.GoValueToValueWith(ctx, x, convert.Options{
+		NilIsTop: options.nilIsTop,
+		OmitZero: options.omitZero,
+	})
 	n := &adt.Vertex{}
 	n.AddConjunct(adt.MakeRootConjunct(nil, expr))
 	n.Finalize(ctx)
@@ -80,3 +80,68 @@ func TestNewList(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeOptions(t *testing.T) {
+	ctx := cuecontext.New()
+
+	type nested struct {
+		B string `json:"b"`
+	}
+	type config struct {
+		A int         `json:"a"`
+		S string      `json:"s,omitempty"`
+		P *nested     `json:"p"`
+		I interface{} `json:"i"`
+		N nested      `json:"n"`
+	}
+	x := config{N: nested{B: "b"}}
+
+	testCases := []struct {
+		desc string
+		opts []cue.EncodeOption
+		out  string
+	}{{
+		desc: "default",
+		out: `{
+	a: 0
+	n: {
+		b: "b"
+	}
+}`,
+	}, {
+		desc: "nil is any",
+		opts: []cue.EncodeOption{cue.NilIsAny(true)},
+		out: `{
+	a: 0
+	p: _
+	i: _
+	n: {
+		b: "b"
+	}
+}`,
+	}, {
+		desc: "omit zero",
+		opts: []cue.EncodeOption{cue.OmitZero(true)},
+		out: `{
+	n: {
+		b: "b"
+	}
+}`,
+	}, {
+		desc: "omit zero and nil is any",
+		opts: []cue.EncodeOption{cue.OmitZero(true), cue.NilIsAny(true)},
+		out: `{
+	n: {
+		b: "b"
+	}
+}`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := fmt.Sprint(ctx.Encode(x, tc.opts...))
+			if got != tc.out {
+				t.Errorf(" got: %v\nwant: %v", got, tc.out)
+			}
+		})
+	}
+}
@@ -24,8 +24,30 @@ import (
 // Option controls a build context.
 type Option interface{ buildOption() }
 
+type option func(r *runtime.Runtime)
+
+func (option) buildOption() {}
+
 // New creates a new Context.
 func New(options ...Option) *cue.Context {
 	r := runtime.New()
+	for _, o := range options {
+		if f, ok := o.(option); ok {
+			f(r)
+		}
+	}
 	return (*cue.Context)(r)
 }
+
+// An ExternInterpreter implements the fields marked with an @extern attribute
+// in files that select it with a file-level attribute @extern(kind). See
+// package cuelang.org/go/cue/interpreter/wasm for an example.
+type ExternInterpreter = runtime.Interpreter
+
+// WithInterpreter adds i to the interpreters of the created Context, so that
+// @extern fields of files selecting the kind of i are implemented by i.
+func WithInterpreter(i ExternInterpreter) Option {
+	return option(func(r *runtime.Runtime) {
+		r.SetInterpreter(i)
+	})
+}
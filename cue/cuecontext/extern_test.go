@@ -0,0 +1,98 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cuecontext
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/core/runtime"
+)
+
+// echo is an interpreter that implements each @extern field with the string
+// given as the first argument of its attribute.
+type echo struct{}
+
+func (echo) Kind() string { return "echo" }
+
+func (echo) NewCompiler(b *build.Instance) (runtime.Compiler, errors.Error) {
+	return echo{}, nil
+}
+
+func (echo) Compile(name string, pos token.Pos, a *internal.Attr) (adt.Expr, errors.Error) {
+	s, err := a.String(0)
+	if err != nil {
+		return nil, errors.Promote(err, "echo")
+	}
+	return &adt.String{Str: name + "=" + s}, nil
+}
+
+func TestInterpreter(t *testing.T) {
+	const src = `
+@extern("echo")
+
+package p
+
+a: _ @extern("x")
+b: c: string @extern("y")
+d: 1
+`
+	testCases := []struct {
+		name string
+		opts []Option
+		out  string
+		err  string
+	}{{
+		name: "installed",
+		opts: []Option{WithInterpreter(echo{})},
+		out: `{
+	a: "a=x"
+	b: {
+		c: "c=y"
+	}
+	d: 1
+}`,
+	}, {
+		name: "not installed",
+		err:  `no interpreter defined for "echo"`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inst := build.NewContext().NewInstance("", nil)
+			if err := inst.AddFile("in.cue", src); err != nil {
+				t.Fatal(err)
+			}
+			v := New(tc.opts...).BuildInstance(inst)
+			if err := v.Err(); err != nil {
+				if tc.err == "" || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got error %v; want %q", err, tc.err)
+				}
+				return
+			}
+			if tc.err != "" {
+				t.Fatalf("got no error; want %q", tc.err)
+			}
+			if got := fmt.Sprint(v); got != tc.out {
+				t.Errorf("got %s; want %s", got, tc.out)
+			}
+		})
+	}
+}
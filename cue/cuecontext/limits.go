@@ -0,0 +1,42 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cuecontext
+
+import (
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/core/runtime"
+)
+
+// Limits bounds the resources used for evaluating values, protecting
+// against configurations that take excessive time or memory to evaluate.
+// The limits apply to each evaluation separately: for instance, each call
+// to Validate or Err on a Value may use the maximum number of steps.
+//
+// A zero value for a field means there is no limit.
+type Limits = adt.Limits
+
+// A LimitError reports that an evaluation was aborted because it exceeded
+// one of its Limits. The errors of values that exceeded a limit include a
+// LimitError, which can be retrieved with errors.As.
+type LimitError = adt.LimitError
+
+// WithLimits sets the limits for evaluating the values of the created
+// Context. A value whose evaluation exceeds one of the limits results in an
+// error.
+func WithLimits(l Limits) Option {
+	return option(func(r *runtime.Runtime) {
+		r.SetLimits(&l)
+	})
+}
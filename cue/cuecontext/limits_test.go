@@ -0,0 +1,101 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cuecontext
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/errors"
+)
+
+func TestLimits(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	const disjuncts = `
+		a: (1 | 2 | 3 | 4) & (1 | 2 | 3 | 4) & (1 | 2 | 3 | 4)
+		`
+	fields := &strings.Builder{}
+	for i := 0; i < 100; i++ {
+		fields.WriteString("x" + strings.Repeat("y", i) + ": {a: 1}\n")
+	}
+	const nested = `a: b: c: d: e: f: 1`
+
+	testCases := []struct {
+		name   string
+		limits Limits
+		in     string
+		limit  string // exceeded limit, if any
+	}{{
+		name:   "disjuncts",
+		limits: Limits{MaxDisjuncts: 10},
+		in:     disjuncts,
+		limit:  "MaxDisjuncts",
+	}, {
+		name:   "disjuncts within limit",
+		limits: Limits{MaxDisjuncts: 200},
+		in:     disjuncts,
+	}, {
+		name:   "steps",
+		limits: Limits{MaxSteps: 50},
+		in:     fields.String(),
+		limit:  "MaxSteps",
+	}, {
+		name:   "steps within limit",
+		limits: Limits{MaxSteps: 500},
+		in:     fields.String(),
+	}, {
+		name:   "depth",
+		limits: Limits{MaxDepth: 4},
+		in:     nested,
+		limit:  "MaxDepth",
+	}, {
+		name:   "depth within limit",
+		limits: Limits{MaxDepth: 10},
+		in:     nested,
+	}, {
+		name:   "context",
+		limits: Limits{Context: canceled},
+		in:     nested,
+		limit:  "Context",
+	}, {
+		name:   "context not done",
+		limits: Limits{Context: context.Background()},
+		in:     nested,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := New(WithLimits(tc.limits)).CompileString(tc.in)
+			err := v.Validate()
+
+			var lerr *LimitError
+			switch {
+			case tc.limit == "":
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			case !errors.As(err, &lerr):
+				t.Fatalf("got error %v; want limit error", err)
+			case lerr.Limit != tc.limit:
+				t.Errorf("got limit %s; want %s", lerr.Limit, tc.limit)
+			}
+			if tc.limit == "Context" && !errors.Is(err, context.Canceled) {
+				t.Errorf("got error %v; want context.Canceled", err)
+			}
+		})
+	}
+}
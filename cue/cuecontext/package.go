@@ -0,0 +1,161 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cuecontext
+
+import (
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/core/convert"
+	"cuelang.org/go/internal/core/eval"
+	"cuelang.org/go/internal/core/runtime"
+	"cuelang.org/go/internal/value"
+)
+
+// A Package is a package of builtin functions implemented in Go, which
+// configurations can import once it is registered with WithPackage.
+type Package struct {
+	// Funcs holds the functions of the package.
+	Funcs []*Func
+}
+
+// A Func is a builtin function implemented in Go.
+//
+// Functions must be pure: the result may only depend on the arguments, as
+// CUE may evaluate a call any number of times, or not at all.
+type Func struct {
+	// Name is the name of the function within its package. It must be a
+	// valid identifier that does not start with _ or #.
+	Name string
+
+	// Params holds the allowed kinds of each of the parameters. The number
+	// of parameters determines the arity of the function. Arguments that do
+	// not match the kinds of their parameter result in an error without
+	// calling Func. Use cue.TopKind to allow any value.
+	Params []cue.Kind
+
+	// Result is the kind of the result of the function.
+	Result cue.Kind
+
+	// Func computes the result of a call with the given arguments, which are
+	// concrete. The result may be any value accepted by Context.Encode. An
+	// error results in an error value that mentions the function.
+	Func func(args []cue.Value) (interface{}, error)
+}
+
+// WithPackage makes the functions of p available to the configurations of
+// the created Context under the given import path. The first element of the
+// import path may not contain a dot, like the paths of the standard library,
+// so that it is not looked up in the module of the configuration.
+//
+// WithPackage panics if the import path is invalid or already used by a
+// builtin package, or if the package has an invalid function.
+func WithPackage(importPath string, p *Package) Option {
+	return option(func(r *runtime.Runtime) {
+		if err := p.check(r, importPath); err != nil {
+			panic(fmt.Sprintf("cuecontext: package %q: %v", importPath, err))
+		}
+		r.RegisterBuiltin(importPath, p.compile(importPath))
+	})
+}
+
+func (p *Package) check(r *runtime.Runtime, importPath string) error {
+	elem := strings.Split(importPath, "/")
+	if importPath == "" || strings.Contains(elem[0], ".") {
+		return fmt.Errorf("first path element may not contain a dot")
+	}
+	for _, e := range elem {
+		if e == "" || e == "." || e == ".." {
+			return fmt.Errorf("invalid import path")
+		}
+	}
+	for _, x := range r.BuiltinPackages() {
+		if x == importPath {
+			return fmt.Errorf("builtin package already exists")
+		}
+	}
+	names := map[string]bool{}
+	for _, f := range p.Funcs {
+		if !ast.IsValidIdent(f.Name) || strings.HasPrefix(f.Name, "_") ||
+			strings.HasPrefix(f.Name, "#") {
+			return fmt.Errorf("invalid function name %q", f.Name)
+		}
+		if names[f.Name] {
+			return fmt.Errorf("duplicate function %s", f.Name)
+		}
+		names[f.Name] = true
+		if f.Func == nil {
+			return fmt.Errorf("function %s has no implementation", f.Name)
+		}
+	}
+	return nil
+}
+
+// compile returns the function that creates the package value of p.
+func (p *Package) compile(importPath string) runtime.PackageFunc {
+	return func(r adt.Runtime) (*adt.Vertex, errors.Error) {
+		ctx := eval.NewContext(r, nil)
+		pkg := ctx.StringLabel(importPath)
+		st := &adt.StructLit{}
+		for _, f := range p.Funcs {
+			st.Decls = append(st.Decls, &adt.Field{
+				Label: ctx.StringLabel(f.Name),
+				Value: f.builtin(pkg),
+			})
+		}
+		v := &adt.Vertex{}
+		v.AddConjunct(adt.MakeRootConjunct(nil, st))
+		v.Finalize(ctx)
+		if err := v.Err(ctx, adt.Finalized); err != nil {
+			return nil, err.Err
+		}
+		return v, nil
+	}
+}
+
+func (f *Func) builtin(pkg adt.Feature) *adt.Builtin {
+	b := &adt.Builtin{
+		Params:  make([]adt.Param, len(f.Params)),
+		Result:  f.Result,
+		Package: pkg,
+		Name:    f.Name,
+	}
+	for i, k := range f.Params {
+		b.Params[i].Value = &adt.BasicType{K: k}
+	}
+	b.Func = func(c *adt.OpContext, args []adt.Value) adt.Expr {
+		a := make([]cue.Value, len(args))
+		for i, x := range args {
+			a[i] = value.Make(c, x)
+			if !a[i].IsConcrete() {
+				return &adt.Bottom{
+					Code: adt.IncompleteError,
+					Err:  c.Newf("non-concrete argument %d", i),
+				}
+			}
+		}
+		ret, err := f.Func(a)
+		if err != nil {
+			return &adt.Bottom{Err: errors.Wrapf(errors.Promote(err, ""), c.Pos(),
+				"error in call to %s.%s", pkg.StringValue(c), f.Name)}
+		}
+		return convert.GoValueToValue(c, ret, true)
+	}
+	return b
+}
@@ -0,0 +1,188 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cuecontext
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+)
+
+var netPkg = &Package{
+	Funcs: []*Func{{
+		Name:   "Contains",
+		Params: []cue.Kind{cue.StringKind, cue.StringKind},
+		Result: cue.BoolKind,
+		Func: func(args []cue.Value) (interface{}, error) {
+			cidr, _ := args[0].String()
+			ip, _ := args[1].String()
+			_, n, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, err
+			}
+			return n.Contains(net.ParseIP(ip)), nil
+		},
+	}, {
+		Name:   "Hosts",
+		Params: []cue.Kind{cue.StringKind},
+		Result: cue.ListKind,
+		Func: func(args []cue.Value) (interface{}, error) {
+			cidr, _ := args[0].String()
+			ip, n, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, err
+			}
+			var a []string
+			for ip := ip.Mask(n.Mask); n.Contains(ip) && len(a) < 4; {
+				a = append(a, ip.String())
+				ip = append(net.IP(nil), ip...)
+				ip[len(ip)-1]++
+			}
+			return a, nil
+		},
+	}},
+}
+
+func TestPackage(t *testing.T) {
+	testCases := []struct {
+		in  string
+		out string
+		err string
+	}{{
+		in: `
+		import "acme/net"
+
+		a: net.Contains("10.0.0.0/8", "10.1.2.3")
+		b: net.Contains("10.0.0.0/8", "192.168.0.1")
+		`,
+		out: `{ a: true b: false }`,
+	}, {
+		in: `
+		import "acme/net"
+
+		a: net.Hosts("10.0.0.0/30")
+		`,
+		out: `{ a: ["10.0.0.0", "10.0.0.1", "10.0.0.2", "10.0.0.3"] }`,
+	}, {
+		in: `
+		import "acme/net"
+
+		a: net.Contains("10.0.0.0", "10.1.2.3")
+		`,
+		err: `error in call to acme/net.Contains: invalid CIDR address: 10.0.0.0`,
+	}, {
+		in: `
+		import "acme/net"
+
+		a: net.Contains("10.0.0.0/8", 1)
+		`,
+		err: `cannot use 1 (type int) as string in argument 2 to "acme/net".Contains`,
+	}, {
+		in: `
+		import "acme/net"
+
+		a: net.Contains("10.0.0.0/8")
+		`,
+		err: `incomplete value "acme/net".Contains("10.0.0.0/8")`,
+	}, {
+		in: `
+		import "acme/net"
+
+		x: string
+		a: net.Contains("10.0.0.0/8", x)
+		`,
+		err: `non-concrete argument 1`,
+	}, {
+		in: `
+		import "strings"
+
+		a: strings.ToUpper("builtins still work")
+		`,
+		out: `{ a: "BUILTINS STILL WORK" }`,
+	}}
+	ctx := New(WithPackage("acme/net", netPkg))
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			v := ctx.CompileString(tc.in)
+			err := v.Validate(cue.Concrete(true))
+			if tc.err != "" {
+				if err == nil || !strings.Contains(errors.Details(err, nil), tc.err) {
+					t.Fatalf("got error %v; want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+			if got := fmt.Sprint(v); strings.Join(strings.Fields(got), " ") != tc.out {
+				t.Errorf("got %s; want %s", got, tc.out)
+			}
+		})
+	}
+
+	// Packages are only available to the Context they were registered with.
+	v := New().CompileString(`
+		import "acme/net"
+
+		a: net.Contains("10.0.0.0/8", "10.1.2.3")
+		`)
+	if v.Err() == nil {
+		t.Error("package available to Context without package")
+	}
+}
+
+func TestPackagePanics(t *testing.T) {
+	f := func(args []cue.Value) (interface{}, error) { return nil, nil }
+	testCases := []struct {
+		path string
+		pkg  *Package
+		err  string
+	}{{
+		path: "acme.com/net",
+		pkg:  &Package{},
+		err:  "first path element may not contain a dot",
+	}, {
+		path: "strings",
+		pkg:  &Package{},
+		err:  "builtin package already exists",
+	}, {
+		path: "acme/net",
+		pkg:  &Package{Funcs: []*Func{{Name: "_f", Func: f}}},
+		err:  `invalid function name "_f"`,
+	}, {
+		path: "acme/net",
+		pkg:  &Package{Funcs: []*Func{{Name: "F", Func: f}, {Name: "F", Func: f}}},
+		err:  "duplicate function F",
+	}, {
+		path: "acme/net",
+		pkg:  &Package{Funcs: []*Func{{Name: "F"}}},
+		err:  "function F has no implementation",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.err, func(t *testing.T) {
+			defer func() {
+				err := recover()
+				if err == nil || !strings.Contains(fmt.Sprint(err), tc.err) {
+					t.Errorf("got panic %v; want %q", err, tc.err)
+				}
+			}()
+			New(WithPackage(tc.path, tc.pkg))
+		})
+	}
+}
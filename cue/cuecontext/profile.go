@@ -0,0 +1,165 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cuecontext
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/core/runtime"
+)
+
+// WithProfile records statistics on the evaluation of all values of the
+// created Context in p.
+func WithProfile(p *Profile) Option {
+	return option(func(r *runtime.Runtime) {
+		r.SetInstrumenter((*profiler)(p))
+	})
+}
+
+// A Profile holds statistics on the evaluation of values, aggregated by the
+// path of the values. It can be used to find the parts of a configuration
+// that are expensive to evaluate, such as combinations of disjunctions that
+// result in many disjuncts being evaluated.
+//
+// The zero value is an empty Profile. A Profile is not safe for concurrent
+// use.
+type Profile struct {
+	entries map[string]*ProfileEntry
+
+	// stack holds the start times of the values being unified and the time
+	// spent on unifying nested values.
+	stack []frame
+}
+
+type frame struct {
+	start  time.Time
+	nested time.Duration
+}
+
+// A ProfileEntry holds the statistics for a single path.
+type ProfileEntry struct {
+	// Path is the path of the values within their package. Values of
+	// different packages with the same path share an entry.
+	Path string
+
+	// Count is the number of times a value at Path was unified.
+	Count int
+
+	// Time is the total time spent unifying values at Path, including the
+	// time spent on unifying other values needed for it, and Self the time
+	// excluding this.
+	Time time.Duration
+	Self time.Duration
+
+	// Expanded is the number of combinations of disjuncts evaluated for
+	// values at Path, and Disjuncts the number of disjuncts that remained.
+	// A large ratio between the two indicates that many disjuncts were
+	// eliminated only after evaluating them.
+	Expanded  int
+	Disjuncts int
+}
+
+// Entries returns the entries of p, sorted by decreasing Self time.
+func (p *Profile) Entries() []ProfileEntry {
+	a := make([]ProfileEntry, 0, len(p.entries))
+	for _, e := range p.entries {
+		a = append(a, *e)
+	}
+	sort.Slice(a, func(i, j int) bool {
+		if a[i].Self != a[j].Self {
+			return a[i].Self > a[j].Self
+		}
+		return a[i].Path < a[j].Path
+	})
+	return a
+}
+
+// WriteTo writes the entries of p as a table to w.
+func (p *Profile) WriteTo(w io.Writer) (n int64, err error) {
+	cw := &countWriter{w: w}
+	tw := tabwriter.NewWriter(cw, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "self\ttotal\tcount\texpanded\tdisjuncts\t\tpath\n")
+	for _, e := range p.Entries() {
+		fmt.Fprintf(tw, "%v\t%v\t%d\t%d\t%d\t\t%s\n",
+			e.Self.Round(time.Microsecond), e.Time.Round(time.Microsecond),
+			e.Count, e.Expanded, e.Disjuncts, e.Path)
+	}
+	err = tw.Flush()
+	return cw.n, err
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+
+func (p *Profile) entry(c *adt.OpContext, v *adt.Vertex) *ProfileEntry {
+	var sels []string
+	for _, f := range v.Path() {
+		sels = append(sels, f.SelectorString(c))
+	}
+	path := strings.Join(sels, ".")
+	if path == "" {
+		path = "<root>"
+	}
+	if p.entries == nil {
+		p.entries = map[string]*ProfileEntry{}
+	}
+	e, ok := p.entries[path]
+	if !ok {
+		e = &ProfileEntry{Path: path}
+		p.entries[path] = e
+	}
+	return e
+}
+
+// A profiler implements adt.Instrumenter for a Profile.
+type profiler Profile
+
+func (x *profiler) Unify(c *adt.OpContext, v *adt.Vertex) (done func()) {
+	p := (*Profile)(x)
+	e := p.entry(c, v)
+	e.Count++
+	p.stack = append(p.stack, frame{start: time.Now()})
+	return func() {
+		n := len(p.stack) - 1
+		f := p.stack[n]
+		p.stack = p.stack[:n]
+		d := time.Since(f.start)
+		e.Time += d
+		e.Self += d - f.nested
+		if n > 0 {
+			p.stack[n-1].nested += d
+		}
+	}
+}
+
+func (x *profiler) Disjuncts(c *adt.OpContext, v *adt.Vertex, expanded, remaining int) {
+	e := (*Profile)(x).entry(c, v)
+	e.Expanded += expanded
+	e.Disjuncts += remaining
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cuecontext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	p := &Profile{}
+	ctx := New(WithProfile(p))
+	v := ctx.CompileString(`
+		a: b: (1 | 2 | 3) & (2 | 3 | 4)
+		c: [1, 2]
+		`)
+	if err := v.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := map[string]ProfileEntry{}
+	for _, e := range p.Entries() {
+		entries[e.Path] = e
+	}
+	for _, path := range []string{"<root>", "a", "a.b", "c", "c.0", "c.1"} {
+		if entries[path].Count == 0 {
+			t.Errorf("no evaluation recorded for %s", path)
+		}
+	}
+	if e := entries["a.b"]; e.Expanded != 12 || e.Disjuncts != 2 {
+		t.Errorf("a.b: got %d expanded and %d remaining disjuncts; want 12 and 2",
+			e.Expanded, e.Disjuncts)
+	}
+	if e := entries["<root>"]; e.Time < entries["a.b"].Time || e.Self > e.Time {
+		t.Errorf("inconsistent times: %+v", e)
+	}
+
+	b := &strings.Builder{}
+	if _, err := p.WriteTo(b); err != nil {
+		t.Fatal(err)
+	}
+	if out := b.String(); !strings.Contains(out, "expanded") || !strings.Contains(out, "a.b\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// Contexts without a profile are not affected.
+	if New().CompileString(`a: 1 | 2`).Err() != nil {
+		t.Error("unexpected error")
+	}
+}
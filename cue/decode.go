@@ -34,6 +34,57 @@ import (
 // constraints specified in the field tags.
 func (v Value) Decode(x interface{}) error {
 	var d decoder
+	return d.run(x, v)
+}
+
+// DecodeWithDefaults is like Decode, but is intended for decoding values that
+// are only partially specified, such as the unification of a schema and
+// partial data. Any value with a default decodes as its default value, and
+// values that are neither concrete nor have a default leave the corresponding
+// Go value untouched instead of resulting in an error.
+func (v Value) DecodeWithDefaults(x interface{}) error {
+	d := decoder{partial: true}
+	return d.run(x, v)
+}
+
+// DecodeEach decodes the elements of the list v one at a time. For each
+// element, it allocates a new value of the type of prototype, decodes the
+// element into it, and calls fn with the index of the element and the decoded
+// value. If prototype is a pointer, fn is passed a pointer to the newly
+// allocated value.
+//
+// DecodeEach stops at the first error reported by decoding or by fn and
+// returns that error. As only one element is decoded at a time, it can be
+// used to process large lists without decoding them as a whole.
+func (v Value) DecodeEach(prototype interface{}, fn func(i int, elem interface{}) error) error {
+	t := reflect.TypeOf(prototype)
+	if t == nil {
+		return errors.Newf(v.Pos(), "cannot decode into nil prototype")
+	}
+	iter, err := v.List()
+	if err != nil {
+		return err
+	}
+	for i := 0; iter.Next(); i++ {
+		var p, elem reflect.Value
+		if t.Kind() == reflect.Ptr {
+			p = reflect.New(t.Elem())
+			elem = p
+		} else {
+			p = reflect.New(t)
+			elem = p.Elem()
+		}
+		if err := iter.Value().Decode(p.Interface()); err != nil {
+			return err
+		}
+		if err := fn(i, elem.Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *decoder) run(x interface{}, v Value) error {
 	w := reflect.ValueOf(x)
 	switch {
 	case !reflect.Indirect(w).CanSet():
@@ -50,6 +101,9 @@ func (v Value) Decode(x interface{}) error {
 
 type decoder struct {
 	errs errors.Error
+
+	// partial indicates that non-concrete values are to be skipped.
+	partial bool
 }
 
 func (d *decoder) addErr(err error) {
@@ -94,6 +148,9 @@ func (d *decoder) decode(x reflect.Value, v Value, isPtr bool) {
 	switch x.Kind() {
 	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
 		// nullable types
+		if !v.IsConcrete() && d.partial {
+			return
+		}
 		if v.Null() == nil || !v.IsConcrete() {
 			d.clear(x)
 			return
@@ -102,7 +159,9 @@ func (d *decoder) decode(x reflect.Value, v Value, isPtr bool) {
 	default:
 		// TODO: allow incomplete values.
 		if !v.IsConcrete() {
-			d.addErr(incompleteError(v))
+			if !d.partial {
+				d.addErr(incompleteError(v))
+			}
 			return
 		}
 	}
@@ -110,7 +169,7 @@ func (d *decoder) decode(x reflect.Value, v Value, isPtr bool) {
 	ij, it, x := indirect(x, v.Null() == nil)
 
 	if ij != nil {
-		b, err := v.marshalJSON()
+		b, err := v.marshalJSON(&options{})
 		d.addErr(err)
 		d.addErr(ij.UnmarshalJSON(b))
 		return
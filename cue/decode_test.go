@@ -15,6 +15,7 @@
 package cue
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
@@ -217,3 +218,95 @@ func TestDecode(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeWithDefaults(t *testing.T) {
+	type config struct {
+		Name  string   `json:"name"`
+		Port  int      `json:"port"`
+		Debug *bool    `json:"debug"`
+		Tags  []string `json:"tags"`
+	}
+	testCases := []struct {
+		value string
+		dst   interface{}
+		want  interface{}
+		err   string
+	}{{
+		value: `{
+			name:  string
+			port:  *8080 | int
+			debug: *false | bool
+			tags:  [...string]
+		}`,
+		dst:  &config{Name: "keep"},
+		want: config{Name: "keep", Port: 8080, Debug: new(bool), Tags: []string{}},
+	}, {
+		value: `{name: "x", port: >1000, tags: [string, "a"]}`,
+		dst:   &config{Port: 1},
+		want:  config{Name: "x", Port: 1, Tags: []string{"", "a"}},
+	}, {
+		value: `{name: 1}`,
+		dst:   &config{},
+		want:  config{},
+		err:   "name: cannot use value 1 (type int) as string",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			err := getInstance(t, tc.value).Value().DecodeWithDefaults(tc.dst)
+			checkFatal(t, err, tc.err, "init")
+
+			got := reflect.ValueOf(tc.dst).Elem().Interface()
+			if !cmp.Equal(got, tc.want) {
+				t.Error(cmp.Diff(got, tc.want))
+			}
+		})
+	}
+}
+
+func TestDecodeEach(t *testing.T) {
+	type record struct {
+		Name string `json:"name"`
+		N    int    `json:"n"`
+	}
+	v := getInstance(t, `
+	a: [{name: "a", n: 1}, {name: "b", n: 2}]
+	b: [1, "x"]
+	`).Value()
+
+	var got []interface{}
+	collect := func(i int, elem interface{}) error {
+		got = append(got, elem)
+		return nil
+	}
+
+	err := v.LookupPath(ParsePath("a")).DecodeEach(record{}, collect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{record{"a", 1}, record{"b", 2}}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+
+	got = nil
+	err = v.LookupPath(ParsePath("a")).DecodeEach(&record{}, collect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []interface{}{&record{"a", 1}, &record{"b", 2}}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+
+	got = nil
+	err = v.LookupPath(ParsePath("b")).DecodeEach(0, collect)
+	checkErr(t, err, "cannot use value \"x\" (type string) as int", "DecodeEach")
+	if want := []interface{}{1}; !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+
+	err = v.LookupPath(ParsePath("a")).DecodeEach(record{}, func(i int, _ interface{}) error {
+		return errors.New("stop")
+	})
+	checkErr(t, err, "stop", "DecodeEach")
+}
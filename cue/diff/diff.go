@@ -0,0 +1,142 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diff computes the differences between two CUE values.
+package diff // import "cuelang.org/go/cue/diff"
+
+import (
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/internal/diff"
+)
+
+// Kind identifies the kind of an edit.
+type Kind uint8
+
+const (
+	// Added indicates that a value only exists in Y.
+	Added Kind = iota + 1
+
+	// Removed indicates that a value only exists in X.
+	Removed
+
+	// Changed indicates that the value at a path differs between X and Y.
+	Changed
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	}
+	return fmt.Sprintf("Kind(%d)", uint8(k))
+}
+
+// An Edit describes a single difference between two values.
+type Edit struct {
+	Kind Kind
+
+	// Path is the location of the edit relative to the compared values.
+	Path cue.Path
+
+	// X and Y are the values at Path in the respective values. X does not
+	// exist for Added edits and Y does not exist for Removed edits.
+	X, Y cue.Value
+}
+
+// An Option configures a diff.
+type Option func(*options)
+
+type options struct {
+	concrete bool
+}
+
+// Concrete compares values as data: default values are taken, only regular
+// fields are compared, and non-concrete values are considered equal.
+func Concrete(concrete bool) Option {
+	return func(o *options) { o.concrete = concrete }
+}
+
+// Diff returns the edits that transform x into y. Structs and lists are
+// compared recursively, so that only the innermost differences are reported.
+// Edits for struct fields follow the order of the fields in x and y. Diff
+// returns nil if x and y are equal.
+func Diff(x, y cue.Value, opts ...Option) []Edit {
+	var o options
+	for _, f := range opts {
+		f(&o)
+	}
+	p := &diff.Profile{Concrete: o.concrete, Regular: o.concrete}
+	k, es := p.Diff(x, y)
+	if k == diff.Identity {
+		return nil
+	}
+	d := differ{}
+	d.script(nil, es)
+	return d.edits
+}
+
+type differ struct {
+	edits []Edit
+}
+
+func (d *differ) add(k Kind, path []cue.Selector, x, y cue.Value) {
+	d.edits = append(d.edits, Edit{
+		Kind: k,
+		Path: cue.MakePath(path...),
+		X:    x,
+		Y:    y,
+	})
+}
+
+// script adds the edits of es, which compares the values at path.
+func (d *differ) script(path []cue.Selector, es *diff.EditScript) {
+	if es.Len() == 0 {
+		d.add(Changed, path, es.X(), es.Y())
+		return
+	}
+	for i := 0; i < es.Len(); i++ {
+		e := es.Edit(i)
+		x, y := es.ValueX(i), es.ValueY(i)
+		switch e.Kind() {
+		case diff.UniqueX:
+			d.add(Removed, appendPath(path, es.X(), x, e.XPos()), x, cue.Value{})
+		case diff.UniqueY:
+			d.add(Added, appendPath(path, es.Y(), y, e.YPos()), cue.Value{}, y)
+		case diff.Modified:
+			p := appendPath(path, es.X(), x, e.XPos())
+			if sub := e.Sub(); sub != nil {
+				d.script(p, sub)
+			} else {
+				d.add(Changed, p, x, y)
+			}
+		}
+	}
+}
+
+// appendPath appends the selector of the element v at position i of parent to
+// path.
+func appendPath(path []cue.Selector, parent, v cue.Value, i int) []cue.Selector {
+	sel := cue.Index(i)
+	if parent.Kind() != cue.ListKind {
+		a := v.Path().Selectors()
+		sel = a[len(a)-1]
+	}
+	return append(path[:len(path):len(path)], sel)
+}
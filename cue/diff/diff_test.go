@@ -0,0 +1,96 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/diff"
+)
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		name string
+		x, y string
+		opts []diff.Option
+		want string
+	}{{
+		name: "identical",
+		x:    `a: 1, b: [1, 2]`,
+		y:    `a: 1, b: [1, 2]`,
+		want: ``,
+	}, {
+		name: "scalars",
+		x:    `1`,
+		y:    `2`,
+		want: `changed : 1 -> 2`,
+	}, {
+		name: "fields",
+		x:    `a: 1, b: {c: "x", d: true}, e: 3`,
+		y:    `a: 1, b: {c: "y", d: true, f: null}, g: 4`,
+		want: `
+changed b.c: "x" -> "y"
+added b.f: <nil> -> null
+removed e: 3 -> <nil>
+added g: <nil> -> 4`,
+	}, {
+		name: "lists",
+		x:    `a: [1, {b: 2}, 3]`,
+		y:    `a: [1, {b: 3}]`,
+		want: `
+changed a[1].b: 2 -> 3
+removed a[2]: 3 -> <nil>`,
+	}, {
+		name: "kinds",
+		x:    `a: 1`,
+		y:    `a: "1"`,
+		want: `changed a: 1 -> "1"`,
+	}, {
+		name: "optional",
+		x:    `a?: int, #D: {b: int}`,
+		y:    `a: int, #D: {b: string}`,
+		want: `
+changed a: int -> int
+changed #D.b: int -> string`,
+	}, {
+		name: "concrete",
+		x:    `a?: int, b: *1 | int, c: int, d: int, #D: 1`,
+		y:    `a: int, b: 1, c: string, d: int, #D: 2`,
+		opts: []diff.Option{diff.Concrete(true)},
+		want: `
+added a: <nil> -> int
+changed c: int -> string`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := cuecontext.New()
+			x := ctx.CompileString(tc.x)
+			y := ctx.CompileString(tc.y)
+
+			var lines []string
+			for _, e := range diff.Diff(x, y, tc.opts...) {
+				lines = append(lines, fmt.Sprintf("%v %v: %v -> %v",
+					e.Kind, e.Path, e.X, e.Y))
+			}
+			got := strings.Join(lines, "\n")
+			if want := strings.TrimSpace(tc.want); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
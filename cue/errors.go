@@ -66,6 +66,22 @@ func (e *valueError) Position() token.Pos {
 	return src.Pos()
 }
 
+// End returns the end of the source of the error if the error is reported at
+// its position.
+func (e *valueError) End() token.Pos {
+	src := e.err.Source()
+	if src == nil || src.Pos() != e.Position() {
+		return token.NoPos
+	}
+	return src.End()
+}
+
+// Incomplete reports whether the error may be resolved by making the value
+// more concrete.
+func (e *valueError) Incomplete() bool {
+	return e.err.IsIncomplete()
+}
+
 func (e *valueError) InputPositions() []token.Pos {
 	if e.err.Err == nil {
 		return nil
@@ -90,6 +106,28 @@ func (e *valueError) Path() (a []string) {
 	return pathToStrings(e.v.Path())
 }
 
+// An incompleteErr marks an error as incomplete. It is used where the
+// error code of the originating Bottom is otherwise lost.
+type incompleteErr struct {
+	err errors.Error
+}
+
+// markIncomplete marks each of the errors in err as incomplete.
+func markIncomplete(err errors.Error) (a errors.Error) {
+	for _, e := range errors.Errors(err) {
+		a = errors.Append(a, &incompleteErr{e})
+	}
+	return a
+}
+
+func (e *incompleteErr) Incomplete() bool             { return true }
+func (e *incompleteErr) Unwrap() error                { return errors.Unwrap(e.err) }
+func (e *incompleteErr) Error() string                { return e.err.Error() }
+func (e *incompleteErr) Position() token.Pos          { return e.err.Position() }
+func (e *incompleteErr) InputPositions() []token.Pos  { return e.err.InputPositions() }
+func (e *incompleteErr) Path() []string               { return e.err.Path() }
+func (e *incompleteErr) Msg() (string, []interface{}) { return e.err.Msg() }
+
 var errNotExists = &adt.Bottom{
 	Code: adt.NotExistError,
 	Err:  errors.Newf(token.NoPos, "undefined value"),
@@ -553,6 +553,85 @@ func Details(err error, cfg *Config) string {
 	return w.String()
 }
 
+// A Severity indicates how serious a Diagnostic is.
+type Severity int
+
+const (
+	// SeverityError indicates an error that needs to be fixed.
+	SeverityError Severity = iota
+
+	// SeverityIncomplete indicates an error that may be resolved by making
+	// the value to which it applies more concrete, for instance by unifying
+	// it with data.
+	SeverityIncomplete
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityIncomplete:
+		return "incomplete"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// A Diagnostic is a structured representation of a single error, suitable
+// for rendering errors in, for instance, editors.
+//
+// An error reports that it is incomplete by implementing an Incomplete method
+// that returns true, and reports the end of the range at its position by
+// implementing an End method.
+type Diagnostic struct {
+	// Severity indicates whether the error is incomplete.
+	Severity Severity
+
+	// Pos is the primary position of the error, if known, or the first of the
+	// other positions otherwise.
+	Pos token.Pos
+
+	// End is the end of the range that starts at Pos, or NoPos if it is not
+	// known.
+	End token.Pos
+
+	// Related holds the other positions that contributed to the error, sorted
+	// by position.
+	Related []token.Pos
+
+	// Path is the path of the value to which the error applies.
+	Path []string
+
+	// Message is the error message, excluding the path.
+	Message string
+}
+
+// Diagnostics returns a Diagnostic for each of the errors in err, after
+// removing duplicates, in the same order as Print.
+func Diagnostics(err error) []Diagnostic {
+	if e, ok := err.(Error); ok {
+		err = Sanitize(e)
+	}
+	var a []Diagnostic
+	for _, e := range Errors(err) {
+		d := Diagnostic{Path: e.Path()}
+		if x, ok := e.(interface{ Incomplete() bool }); ok && x.Incomplete() {
+			d.Severity = SeverityIncomplete
+		}
+		if pos := Positions(e); len(pos) > 0 {
+			d.Pos = pos[0]
+			d.Related = pos[1:]
+		}
+		if x, ok := e.(interface{ End() token.Pos }); ok && d.Pos == e.Position() {
+			d.End = x.End()
+		}
+		w := &strings.Builder{}
+		writeMsg(w, e)
+		d.Message = w.String()
+		a = append(a, d)
+	}
+	return a
+}
+
 // String generates a short message from a given Error.
 func String(err Error) string {
 	w := &strings.Builder{}
@@ -565,7 +644,11 @@ func writeErr(w io.Writer, err Error) {
 		_, _ = io.WriteString(w, path)
 		_, _ = io.WriteString(w, ": ")
 	}
+	writeMsg(w, err)
+}
 
+// writeMsg writes the messages of err and the errors it wraps.
+func writeMsg(w io.Writer, err Error) {
 	for {
 		u := xerrors.Unwrap(err)
 
@@ -190,3 +190,65 @@ func TestPrintError(t *testing.T) {
 		}
 	}
 }
+
+func TestDiagnostics(t *testing.T) {
+	f := token.NewFile("x.cue", 0, 100)
+	f.SetLinesForContent(bytes.Repeat([]byte("123456789\n"), 10))
+	p1 := f.Pos(12, token.NoRelPos)
+	p2 := f.Pos(25, token.NoRelPos)
+	p3 := f.Pos(3, token.NoRelPos)
+
+	e1 := &posError{
+		pos:     p1,
+		inputs:  []token.Pos{p2, p3, p1},
+		Message: NewMessage("conflicting values %v and %v", []interface{}{1, 2}),
+	}
+	e2 := Wrapf(Newf(p2, "inner"), token.NoPos, "outer")
+
+	got := Diagnostics(Append(e1, e2))
+	if len(got) != 2 {
+		t.Fatalf("got %d diagnostics; want 2", len(got))
+	}
+
+	d := got[0]
+	if d.Pos != p1 {
+		t.Errorf("pos: got %v; want %v", d.Pos, p1)
+	}
+	if len(d.Related) != 2 || d.Related[0] != p3 || d.Related[1] != p2 {
+		t.Errorf("related: got %v; want [%v %v]", d.Related, p3, p2)
+	}
+	if want := "conflicting values 1 and 2"; d.Message != want {
+		t.Errorf("message: got %q; want %q", d.Message, want)
+	}
+
+	d = got[1]
+	if d.Pos != p2 {
+		t.Errorf("pos: got %v; want %v", d.Pos, p2)
+	}
+	if len(d.Related) != 0 {
+		t.Errorf("related: got %v; want none", d.Related)
+	}
+	if want := "outer: inner"; d.Message != want {
+		t.Errorf("message: got %q; want %q", d.Message, want)
+	}
+	if d.Severity != SeverityError || d.End.IsValid() {
+		t.Errorf("got severity %v and end %v; want error and no end", d.Severity, d.End)
+	}
+
+	p4 := f.Pos(30, token.NoRelPos)
+	got = Diagnostics(&incompleteError{posError{pos: p2}, p4})
+	if len(got) != 1 {
+		t.Fatalf("got %d diagnostics; want 1", len(got))
+	}
+	if d := got[0]; d.Severity != SeverityIncomplete || d.End != p4 {
+		t.Errorf("got severity %v and end %v; want incomplete and %v", d.Severity, d.End, p4)
+	}
+}
+
+type incompleteError struct {
+	posError
+	end token.Pos
+}
+
+func (e *incompleteError) Incomplete() bool { return true }
+func (e *incompleteError) End() token.Pos   { return e.end }
@@ -0,0 +1,146 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+var (
+	// fenceRe matches the opening line of a Markdown code block with info
+	// string cue, such as ```cue.
+	fenceRe = regexp.MustCompile("^( {0,3})(```+|~~~+)[ \t]*cue(?:[ \t].*)?$")
+
+	// blockRe matches the header of a YAML block scalar holding CUE, which is
+	// a key ending in .cue or a header annotated with a # cue comment, such
+	// as
+	//
+	//	config.cue: |
+	//	schema: |- # cue
+	//
+	blockRe = regexp.MustCompile(`^(\s*)(?:- )?(?:` +
+		`(?:"[^"]*\.cue"|'[^']*\.cue'|[^\s#'"][^#]*\.cue)\s*:\s*\|[-+]?\s*(?:#.*)?` +
+		`|[^#]*:\s*\|[-+]?\s+#\s*cue\s*)$`)
+)
+
+// Embedded formats the CUE snippets embedded in b, which holds Markdown or
+// YAML text, and returns the result. All other content is left untouched.
+//
+// Snippets are either the contents of a Markdown code block with info string
+// cue or the contents of a literal YAML block scalar whose key ends in .cue
+// or whose header is followed by a # cue comment. Snippets that are not valid
+// CUE are left untouched as well. As YAML does not allow tabs in indentation,
+// snippets in YAML block scalars are indented with spaces.
+func Embedded(b []byte, opt ...Option) []byte {
+	lines := strings.SplitAfter(string(b), "\n")
+	buf := &bytes.Buffer{}
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		buf.WriteString(line)
+		i++
+
+		text := strings.TrimRight(line, "\r\n")
+		if m := fenceRe.FindStringSubmatch(text); m != nil {
+			i = formatFenced(buf, lines, i, m[1], m[2], opt)
+		} else if m := blockRe.FindStringSubmatch(text); m != nil {
+			i = formatBlock(buf, lines, i, len(m[1]), opt)
+		}
+	}
+	return buf.Bytes()
+}
+
+// formatFenced writes the formatted contents of the Markdown code block
+// starting at lines[i], opened with the given fence and indentation, and
+// returns the index of the line following the contents.
+func formatFenced(buf *bytes.Buffer, lines []string, i int, indent, fence string, opt []Option) int {
+	start := i
+	for ; i < len(lines); i++ {
+		s := strings.TrimRight(lines[i], " \t\r\n")
+		t := strings.TrimLeft(s, " ")
+		if len(s)-len(t) <= 3 && strings.HasPrefix(t, fence) &&
+			strings.Trim(t, fence[:1]) == "" {
+			break
+		}
+	}
+	if i == len(lines) {
+		// An unterminated code block extends to the end of the document,
+		// which is unlikely to be intended. Leave it as is.
+		buf.WriteString(strings.Join(lines[start:], ""))
+		return i
+	}
+	writeSnippet(buf, lines[start:i], indent, opt)
+	return i
+}
+
+// formatBlock writes the formatted contents of the YAML block scalar starting
+// at lines[i], where n is the indentation of its key, and returns the index
+// of the line following the contents.
+func formatBlock(buf *bytes.Buffer, lines []string, i, n int, opt []Option) int {
+	start := i
+	indent := ""
+	for ; i < len(lines); i++ {
+		s := strings.TrimRight(lines[i], "\r\n")
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		t := strings.TrimLeft(s, " ")
+		if len(s)-len(t) <= n || indent != "" && !strings.HasPrefix(s, indent) {
+			break
+		}
+		if indent == "" {
+			indent = s[:len(s)-len(t)]
+		}
+	}
+	// Trailing empty lines belong to the enclosing document.
+	for i > start && strings.TrimSpace(lines[i-1]) == "" {
+		i--
+	}
+	opt = append([]Option{UseSpaces(2), TabIndent(false)}, opt...)
+	writeSnippet(buf, lines[start:i], indent, opt)
+	return i
+}
+
+// writeSnippet writes lines formatted as CUE, removing indent from the lines
+// before and adding it back after formatting. The lines are written as is if
+// they are not valid CUE.
+func writeSnippet(buf *bytes.Buffer, lines []string, indent string, opt []Option) {
+	src := &strings.Builder{}
+	for _, s := range lines {
+		src.WriteString(strings.TrimPrefix(strings.TrimRight(s, "\r\n"), indent))
+		src.WriteByte('\n')
+	}
+	if strings.TrimSpace(src.String()) == "" {
+		buf.WriteString(strings.Join(lines, ""))
+		return
+	}
+	b, err := Source([]byte(src.String()), opt...)
+	if err != nil {
+		buf.WriteString(strings.Join(lines, ""))
+		return
+	}
+	eol := "\n"
+	if strings.HasSuffix(lines[0], "\r\n") {
+		eol = "\r\n"
+	}
+	for _, s := range strings.Split(strings.TrimRight(string(b), "\n"), "\n") {
+		if s != "" {
+			buf.WriteString(indent)
+		}
+		buf.WriteString(s)
+		buf.WriteString(eol)
+	}
+}
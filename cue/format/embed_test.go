@@ -0,0 +1,87 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"testing"
+)
+
+func TestEmbedded(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		out  string
+	}{{
+		name: "markdown",
+		in:   "# Title\n\n```cue\na:   1\nb: {c:2}\n```\n\n```go\na :=  1\n```\n",
+		out:  "# Title\n\n```cue\na: 1\nb: {c: 2}\n```\n\n```go\na :=  1\n```\n",
+	}, {
+		name: "markdown indented fence",
+		in:   "- item\n\n  ~~~~ cue\n  a:   1\n  ~~~~\n",
+		out:  "- item\n\n  ~~~~ cue\n  a: 1\n  ~~~~\n",
+	}, {
+		name: "markdown invalid",
+		in:   "```cue\na: {\n```\n",
+		out:  "```cue\na: {\n```\n",
+	}, {
+		name: "markdown unterminated",
+		in:   "```cue\na:   1\n",
+		out:  "```cue\na:   1\n",
+	}, {
+		name: "markdown empty",
+		in:   "```cue\n\n```\n",
+		out:  "```cue\n\n```\n",
+	}, {
+		name: "yaml key",
+		in: `data:
+  config.cue: |
+    a:   1
+    b: {
+    c: 2
+    }
+
+  other: |
+    a:   1
+kind: ConfigMap
+`,
+		out: `data:
+  config.cue: |
+    a: 1
+    b: {
+      c: 2
+    }
+
+  other: |
+    a:   1
+kind: ConfigMap
+`,
+	}, {
+		name: "yaml comment",
+		in:   "- schema: |- # cue\n    #A: {x:int}\n- x: 1\n",
+		out:  "- schema: |- # cue\n    #A: {x: int}\n- x: 1\n",
+	}, {
+		name: "crlf",
+		in:   "```cue\r\na:   1\r\n```\r\n",
+		out:  "```cue\r\na: 1\r\n```\r\n",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := string(Embedded([]byte(tc.in)))
+			if got != tc.out {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.out)
+			}
+		})
+	}
+}
@@ -25,6 +25,7 @@ package format // import "cuelang.org/go/cue/format"
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
 	"text/tabwriter"
 
@@ -292,7 +293,7 @@ func (f *formatter) before(node ast.Node) bool {
 		if ok && len(s.Elts) <= 1 && f.current.nodeSep != blank && f.onOneLine(node) {
 			f.current.nodeSep = blank
 		}
-		f.current.cg = node.Comments()
+		f.current.cg = sortComments(node.Comments())
 		f.visitComments(f.current.pos)
 		return true
 	}
@@ -308,6 +309,20 @@ func (f *formatter) after(node ast.Node) {
 	f.visitComments(f.current.pos)
 }
 
+// sortComments orders comment groups by position, retaining the order of
+// groups at the same position. The original slice is not modified.
+func sortComments(cgs []*ast.CommentGroup) []*ast.CommentGroup {
+	less := func(a []*ast.CommentGroup) func(i, j int) bool {
+		return func(i, j int) bool { return a[i].Position < a[j].Position }
+	}
+	if sort.SliceIsSorted(cgs, less(cgs)) {
+		return cgs
+	}
+	a := append([]*ast.CommentGroup(nil), cgs...)
+	sort.SliceStable(a, less(a))
+	return a
+}
+
 func (f *formatter) visitComments(until int8) {
 	c := &f.current
 
@@ -325,8 +340,10 @@ func (f *formatter) printComment(cg *ast.CommentGroup) {
 	f.Print(cg)
 
 	printBlank := false
-	if cg.Doc && len(f.output) > 0 {
-		f.Print(newline)
+	if cg.Doc {
+		if len(f.output) > 0 {
+			f.Print(newline)
+		}
 		printBlank = true
 	}
 	for _, c := range cg.List {
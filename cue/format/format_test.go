@@ -266,6 +266,39 @@ func TestNodes(t *testing.T) {
 		version: "foo"
 	}
 }`,
+	}, {
+		name: "attached comments",
+		in: func() ast.Node {
+			a := &ast.Field{Label: ast.NewIdent("a"), Value: ast.NewString("x")}
+			ast.AttachComment(a, internal.NewComment(false, "line a"), ast.LineComment)
+			ast.AttachComment(a, internal.NewComment(false, "doc a"), ast.DocComment)
+
+			// Comment groups taken from a parsed file lose their spacing.
+			f, _ := parser.ParseFile("in", "x: 1 // line b\n", parser.ParseComments)
+			b := &ast.Field{Label: ast.NewIdent("b"), Value: ast.NewIdent("int")}
+			for _, cg := range ast.Comments(f.Decls[0]) {
+				ast.AttachComment(b, cg, ast.DocComment)
+			}
+			return &ast.File{Decls: []ast.Decl{a, b}}
+		}(),
+		out: `// doc a
+a: "x" // line a
+// line b
+b: int
+`,
+	}, {
+		name: "comments in list",
+		in: func() ast.Node {
+			list := ast.NewList(ast.NewLit(token.INT, "1"), ast.NewLit(token.INT, "2"))
+			ast.AttachComment(list.Elts[0], internal.NewComment(false, "one"), ast.LineComment)
+			ast.AttachComment(list.Elts[1], internal.NewComment(true, "two"), ast.DocComment)
+			return &ast.Field{Label: ast.NewIdent("a"), Value: list}
+		}(),
+		out: `a: [
+	1, // one
+	// two
+	2,
+]`,
 	}}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
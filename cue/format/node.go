@@ -185,9 +185,22 @@ func (f *formatter) walkClauseList(list []ast.Clause, ws whiteSpace) {
 	f.after(nil)
 }
 
-func (f *formatter) walkListElems(list []ast.Expr) {
+// walkListElems prints the elements of a list. Elements without a relative
+// position are put on separate lines if any of the elements has comments.
+// It reports whether this is the case.
+func (f *formatter) walkListElems(list []ast.Expr) (multiline bool) {
+	for _, x := range list {
+		if len(ast.Comments(x)) > 0 {
+			multiline = true
+			break
+		}
+	}
+
 	f.before(nil)
 	for _, x := range list {
+		if multiline && !x.Pos().HasRelPos() {
+			f.print(newline)
+		}
 		f.before(x)
 		switch n := x.(type) {
 		case *ast.Comprehension:
@@ -212,6 +225,7 @@ func (f *formatter) walkListElems(list []ast.Expr) {
 		f.after(x)
 	}
 	f.after(nil)
+	return multiline
 }
 
 func (f *formatter) walkArgsList(list []ast.Expr, depth int) {
@@ -661,10 +675,13 @@ func (f *formatter) exprRaw(expr ast.Expr, prec1, depth int) {
 
 	case *ast.ListLit:
 		f.print(x.Lbrack, token.LBRACK, indent)
-		f.walkListElems(x.Elts)
+		multiline := f.walkListElems(x.Elts)
 		f.print(trailcomma, noblank)
 		f.visitComments(f.current.pos)
 		f.matchUnindent()
+		if multiline && !x.Rbrack.HasRelPos() {
+			f.print(newline)
+		}
 		f.print(noblank, x.Rbrack, token.RBRACK)
 
 	case *ast.Ellipsis:
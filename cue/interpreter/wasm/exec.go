@@ -0,0 +1,451 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"cuelang.org/go/internal/core/adt"
+)
+
+// This file contains a straightforward interpreter for WebAssembly functions.
+// Values on the stack are represented as uint64: integers of type i32 are
+// held in the lower 32 bits and floats are held as their IEEE 754 bits.
+
+// maxCallDepth limits the depth of recursive calls.
+const maxCallDepth = 1000
+
+// defaultMaxSteps limits the number of instructions executed by a call, so
+// that calls of functions that do not terminate result in an error.
+const defaultMaxSteps = 1 << 27
+
+// checkInterval is the number of instructions after which the interpreter
+// checks whether the evaluation was canceled.
+const checkInterval = 1 << 12
+
+// A trap is raised for the traps defined by the WebAssembly specification.
+type trap string
+
+// An instance holds the state of an instantiated module.
+type instance struct {
+	m       *module
+	mem     []byte
+	maxMem  uint32 // in pages
+	globals []uint64
+	table   []int64 // function indices, or -1
+	depth   int
+
+	steps    int // number of instructions executed
+	maxSteps int
+	ctx      context.Context
+}
+
+// invoke runs the function with index fn of m on a new instance of m with
+// the given arguments. The call is aborted with a trap if it executes more
+// than defaultMaxSteps instructions, or l.MaxSteps if this is smaller, or if
+// the context of l is done.
+func (m *module) invoke(fn uint32, args []uint64, l *adt.Limits) (results []uint64, err error) {
+	defer func() {
+		switch x := recover().(type) {
+		case nil:
+		case trap:
+			err = fmt.Errorf("trap: %s", string(x))
+		case decodeError:
+			err = fmt.Errorf("invalid module: %s", string(x))
+		default:
+			panic(x)
+		}
+	}()
+	in := &instance{m: m, maxSteps: defaultMaxSteps}
+	if l != nil {
+		if l.MaxSteps > 0 && l.MaxSteps < in.maxSteps {
+			in.maxSteps = l.MaxSteps
+		}
+		in.ctx = l.Context
+	}
+	in.init()
+	return in.call(fn, args), nil
+}
+
+// init initializes the memory, globals, and table of in, and runs the start
+// function of its module, if any.
+func (in *instance) init() {
+	m := in.m
+	if m.hasMemory {
+		if m.memMin > maxPages {
+			panic(trap("memory too large"))
+		}
+		in.mem = make([]byte, m.memMin*pageSize)
+		in.maxMem = m.memMax
+		if in.maxMem > maxPages {
+			in.maxMem = maxPages
+		}
+	}
+	in.globals = make([]uint64, len(m.globals))
+	for i, g := range m.globals {
+		in.globals[i] = g.init
+	}
+	if m.hasTable {
+		in.table = make([]int64, m.tableMin)
+		for i := range in.table {
+			in.table[i] = -1
+		}
+	}
+	for _, e := range m.elements {
+		if uint64(e.offset)+uint64(len(e.funcs)) > uint64(len(in.table)) {
+			panic(trap("out of bounds table access"))
+		}
+		for i, f := range e.funcs {
+			in.table[int(e.offset)+i] = int64(f)
+		}
+	}
+	for _, d := range m.data {
+		if uint64(d.offset)+uint64(len(d.init)) > uint64(len(in.mem)) {
+			panic(trap("out of bounds memory access"))
+		}
+		copy(in.mem[d.offset:], d.init)
+	}
+	if m.start != nil {
+		in.call(*m.start, nil)
+	}
+}
+
+// step accounts for the execution of an instruction.
+func (in *instance) step() {
+	if in.steps++; in.steps > in.maxSteps {
+		panic(trap(fmt.Sprintf("exceeded the maximum of %d instructions", in.maxSteps)))
+	}
+	if in.ctx != nil && in.steps%checkInterval == 0 {
+		select {
+		case <-in.ctx.Done():
+			panic(trap(fmt.Sprintf("evaluation aborted: %v", in.ctx.Err())))
+		default:
+		}
+	}
+}
+
+type label struct {
+	cont   int // offset at which to continue after a branch
+	height int // height of the stack at the start of the block
+	arity  int // number of values passed by a branch
+}
+
+// call runs the function with index fn.
+func (in *instance) call(fn uint32, args []uint64) []uint64 {
+	if in.depth++; in.depth > maxCallDepth {
+		panic(trap("call stack exhausted"))
+	}
+	defer func() { in.depth-- }()
+
+	f := &in.m.funcs[fn]
+	t := &in.m.types[f.typ]
+
+	locals := make([]uint64, len(t.params)+len(f.locals))
+	copy(locals, args)
+
+	s := &stack{}
+
+	body := f.body
+	r := &reader{b: body}
+	labels := []label{{cont: len(body), arity: len(t.results)}}
+
+	branch := func(depth uint32) {
+		l := labels[len(labels)-1-int(depth)]
+		n := copy(s.a[l.height:], s.a[len(s.a)-l.arity:])
+		s.a = s.a[:l.height+n]
+		labels = labels[:len(labels)-1-int(depth)]
+		r.pos = l.cont
+	}
+
+	// addr computes the address of a memory access of size n.
+	addr := func(n uint64) uint64 {
+		r.u32() // alignment
+		offset := uint64(r.u32())
+		a := uint64(s.pop32()) + offset
+		if a+n > uint64(len(in.mem)) {
+			panic(trap("out of bounds memory access"))
+		}
+		return a
+	}
+	load := func(n uint64) []byte {
+		a := addr(n)
+		return in.mem[a : a+n]
+	}
+	store := func(n uint64) {
+		x := s.pop()
+		a := addr(n)
+		b := in.mem[a : a+n]
+		for i := range b {
+			b[i] = byte(x)
+			x >>= 8
+		}
+	}
+
+	for {
+		if len(labels) == 0 || r.done() {
+			return s.a[len(s.a)-len(t.results):]
+		}
+		in.step()
+		pc := r.pos
+		op := r.byte()
+		switch op {
+		case 0x00: // unreachable
+			panic(trap("unreachable"))
+		case 0x01: // nop
+
+		case 0x02: // block
+			params, results := r.blockType(in.m)
+			labels = append(labels, label{
+				cont:   f.ends[pc],
+				height: len(s.a) - params,
+				arity:  results,
+			})
+		case 0x03: // loop
+			params, _ := r.blockType(in.m)
+			labels = append(labels, label{
+				cont:   pc,
+				height: len(s.a) - params,
+				arity:  params,
+			})
+		case 0x04: // if
+			params, results := r.blockType(in.m)
+			if s.pop32() == 0 {
+				if e, ok := f.elses[pc]; ok {
+					r.pos = e
+				} else {
+					r.pos = f.ends[pc]
+					break
+				}
+			}
+			labels = append(labels, label{
+				cont:   f.ends[pc],
+				height: len(s.a) - params,
+				arity:  results,
+			})
+		case 0x05: // else, reached at the end of the then branch
+			r.pos = labels[len(labels)-1].cont
+			labels = labels[:len(labels)-1]
+		case 0x0b: // end
+			labels = labels[:len(labels)-1]
+
+		case 0x0c: // br
+			branch(r.u32())
+		case 0x0d: // br_if
+			l := r.u32()
+			if s.pop32() != 0 {
+				branch(l)
+			}
+		case 0x0e: // br_table
+			targets := make([]uint32, r.u32())
+			for i := range targets {
+				targets[i] = r.u32()
+			}
+			l := r.u32()
+			if i := s.pop32(); int64(i) < int64(len(targets)) {
+				l = targets[i]
+			}
+			branch(l)
+		case 0x0f: // return
+			branch(uint32(len(labels) - 1))
+
+		case 0x10: // call
+			in.callWith(r.u32(), s)
+		case 0x11: // call_indirect
+			typ := &in.m.types[r.u32()]
+			r.u32() // table
+			i := s.pop32()
+			if int64(i) >= int64(len(in.table)) {
+				panic(trap("undefined element"))
+			}
+			fn := in.table[i]
+			if fn < 0 {
+				panic(trap("uninitialized element"))
+			}
+			if !typ.equal(&in.m.types[in.m.funcs[fn].typ]) {
+				panic(trap("indirect call type mismatch"))
+			}
+			in.callWith(uint32(fn), s)
+
+		case 0x1a: // drop
+			s.pop()
+		case 0x1b, 0x1c: // select
+			if op == 0x1c {
+				r.valTypes()
+			}
+			c, y, x := s.pop32(), s.pop(), s.pop()
+			if c != 0 {
+				s.push(x)
+			} else {
+				s.push(y)
+			}
+
+		case 0x20: // local.get
+			s.push(locals[r.u32()])
+		case 0x21: // local.set
+			locals[r.u32()] = s.pop()
+		case 0x22: // local.tee
+			locals[r.u32()] = s.a[len(s.a)-1]
+		case 0x23: // global.get
+			s.push(in.globals[r.u32()])
+		case 0x24: // global.set
+			in.globals[r.u32()] = s.pop()
+
+		case 0x28: // i32.load
+			s.push32(binary.LittleEndian.Uint32(load(4)))
+		case 0x29: // i64.load
+			s.push(binary.LittleEndian.Uint64(load(8)))
+		case 0x2a: // f32.load
+			s.push32(binary.LittleEndian.Uint32(load(4)))
+		case 0x2b: // f64.load
+			s.push(binary.LittleEndian.Uint64(load(8)))
+		case 0x2c: // i32.load8_s
+			s.push32(uint32(int8(load(1)[0])))
+		case 0x2d: // i32.load8_u
+			s.push32(uint32(load(1)[0]))
+		case 0x2e: // i32.load16_s
+			s.push32(uint32(int16(binary.LittleEndian.Uint16(load(2)))))
+		case 0x2f: // i32.load16_u
+			s.push32(uint32(binary.LittleEndian.Uint16(load(2))))
+		case 0x30: // i64.load8_s
+			s.push(uint64(int8(load(1)[0])))
+		case 0x31: // i64.load8_u
+			s.push(uint64(load(1)[0]))
+		case 0x32: // i64.load16_s
+			s.push(uint64(int16(binary.LittleEndian.Uint16(load(2)))))
+		case 0x33: // i64.load16_u
+			s.push(uint64(binary.LittleEndian.Uint16(load(2))))
+		case 0x34: // i64.load32_s
+			s.push(uint64(int32(binary.LittleEndian.Uint32(load(4)))))
+		case 0x35: // i64.load32_u
+			s.push(uint64(binary.LittleEndian.Uint32(load(4))))
+		case 0x36, 0x38, 0x3e: // i32.store, f32.store, i64.store32
+			store(4)
+		case 0x37, 0x39: // i64.store, f64.store
+			store(8)
+		case 0x3a, 0x3c: // i32.store8, i64.store8
+			store(1)
+		case 0x3b, 0x3d: // i32.store16, i64.store16
+			store(2)
+		case 0x3f: // memory.size
+			r.byte()
+			s.push32(uint32(len(in.mem) / pageSize))
+		case 0x40: // memory.grow
+			r.byte()
+			n := s.pop32()
+			old := uint32(len(in.mem) / pageSize)
+			if uint64(old)+uint64(n) > uint64(in.maxMem) {
+				s.push32(math.MaxUint32)
+				break
+			}
+			in.mem = append(in.mem, make([]byte, int(n)*pageSize)...)
+			s.push32(old)
+
+		case 0x41: // i32.const
+			s.push32(uint32(r.s32()))
+		case 0x42: // i64.const
+			s.push(uint64(r.s64()))
+		case 0x43: // f32.const
+			s.push32(binary.LittleEndian.Uint32(r.bytes(4)))
+		case 0x44: // f64.const
+			s.push(binary.LittleEndian.Uint64(r.bytes(8)))
+
+		case 0xfc:
+			switch sub := r.u32(); sub {
+			case 0: // i32.trunc_sat_f32_s
+				s.push32(uint32(truncSatS(float64(s.popF32()), 32)))
+			case 1: // i32.trunc_sat_f32_u
+				s.push32(uint32(truncSatU(float64(s.popF32()), 32)))
+			case 2: // i32.trunc_sat_f64_s
+				s.push32(uint32(truncSatS(s.popF64(), 32)))
+			case 3: // i32.trunc_sat_f64_u
+				s.push32(uint32(truncSatU(s.popF64(), 32)))
+			case 4: // i64.trunc_sat_f32_s
+				s.push(uint64(truncSatS(float64(s.popF32()), 64)))
+			case 5: // i64.trunc_sat_f32_u
+				s.push(truncSatU(float64(s.popF32()), 64))
+			case 6: // i64.trunc_sat_f64_s
+				s.push(uint64(truncSatS(s.popF64(), 64)))
+			case 7: // i64.trunc_sat_f64_u
+				s.push(truncSatU(s.popF64(), 64))
+			case 10: // memory.copy
+				r.byte()
+				r.byte()
+				n, src, dst := uint64(s.pop32()), uint64(s.pop32()), uint64(s.pop32())
+				if src+n > uint64(len(in.mem)) || dst+n > uint64(len(in.mem)) {
+					panic(trap("out of bounds memory access"))
+				}
+				copy(in.mem[dst:dst+n], in.mem[src:src+n])
+			case 11: // memory.fill
+				r.byte()
+				n, val, dst := uint64(s.pop32()), byte(s.pop32()), uint64(s.pop32())
+				if dst+n > uint64(len(in.mem)) {
+					panic(trap("out of bounds memory access"))
+				}
+				b := in.mem[dst : dst+n]
+				for i := range b {
+					b[i] = val
+				}
+			default:
+				panic(decodeError(fmt.Sprintf("unsupported instruction 0xfc %d", sub)))
+			}
+
+		default:
+			if op < 0x45 || op > 0xc4 {
+				panic(decodeError(fmt.Sprintf("unsupported instruction 0x%02x", op)))
+			}
+			s.numeric(op)
+		}
+	}
+}
+
+// callWith calls the function with index fn with arguments taken from the
+// stack, and pushes the results on the stack.
+func (in *instance) callWith(fn uint32, s *stack) {
+	t := &in.m.types[in.m.funcs[fn].typ]
+	n := len(s.a) - len(t.params)
+	args := append([]uint64(nil), s.a[n:]...)
+	s.a = append(s.a[:n], in.call(fn, args)...)
+}
+
+// A stack is the operand stack of a function call.
+type stack struct {
+	a []uint64
+}
+
+func (s *stack) push(x uint64) { s.a = append(s.a, x) }
+
+func (s *stack) pop() uint64 {
+	x := s.a[len(s.a)-1]
+	s.a = s.a[:len(s.a)-1]
+	return x
+}
+
+func (s *stack) push32(x uint32)   { s.push(uint64(x)) }
+func (s *stack) pop32() uint32     { return uint32(s.pop()) }
+func (s *stack) pushF32(x float32) { s.push(uint64(math.Float32bits(x))) }
+func (s *stack) popF32() float32   { return math.Float32frombits(uint32(s.pop())) }
+func (s *stack) pushF64(x float64) { s.push(math.Float64bits(x)) }
+func (s *stack) popF64() float64   { return math.Float64frombits(s.pop()) }
+
+func (s *stack) pushBool(b bool) {
+	if b {
+		s.push(1)
+	} else {
+		s.push(0)
+	}
+}
@@ -0,0 +1,482 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// This file contains the decoder for the binary format of WebAssembly
+// modules. See https://webassembly.github.io/spec/core/binary/.
+
+type valType byte
+
+const (
+	i32 valType = 0x7f
+	i64 valType = 0x7e
+	f32 valType = 0x7d
+	f64 valType = 0x7c
+)
+
+func (t valType) String() string {
+	switch t {
+	case i32:
+		return "i32"
+	case i64:
+		return "i64"
+	case f32:
+		return "f32"
+	case f64:
+		return "f64"
+	}
+	return fmt.Sprintf("type(0x%x)", byte(t))
+}
+
+type funcType struct {
+	params  []valType
+	results []valType
+}
+
+func (t *funcType) equal(u *funcType) bool {
+	return bytes.Equal(types(t.params), types(u.params)) &&
+		bytes.Equal(types(t.results), types(u.results))
+}
+
+func (t *funcType) String() string {
+	var b strings.Builder
+	b.WriteString("func(")
+	for i, p := range t.params {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(p.String())
+	}
+	b.WriteString(")")
+	switch len(t.results) {
+	case 0:
+	case 1:
+		b.WriteString(": " + t.results[0].String())
+	default:
+		b.WriteString(": (")
+		for i, r := range t.results {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(r.String())
+		}
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+func types(a []valType) []byte {
+	b := make([]byte, len(a))
+	for i, t := range a {
+		b[i] = byte(t)
+	}
+	return b
+}
+
+type function struct {
+	typ    uint32
+	locals []valType
+	body   []byte
+
+	// ends maps the offset of each block, loop, and if instruction to the
+	// offset following its end instruction, and elses maps the offset of if
+	// instructions to the offset following their else instruction. Both are
+	// computed by validate.
+	ends  map[int]int
+	elses map[int]int
+}
+
+type global struct {
+	typ     valType
+	mutable bool
+	init    uint64
+}
+
+type segment struct {
+	offset uint32
+	init   []byte
+}
+
+type elements struct {
+	offset uint32
+	funcs  []uint32
+}
+
+type module struct {
+	types   []funcType
+	funcs   []function
+	globals []global
+	exports map[string]uint32 // exported functions
+
+	hasMemory bool
+	memMin    uint32
+	memMax    uint32
+
+	hasTable bool
+	tableMin uint32
+	elements []elements
+
+	data  []segment
+	start *uint32
+}
+
+const pageSize = 1 << 16
+
+// maxPages limits the memory of an instance to 256MiB.
+const maxPages = 4096
+
+// maxTableSize limits the size of the table of an instance.
+const maxTableSize = 1 << 16
+
+// maxLocals limits the number of local variables of a function.
+const maxLocals = 50000
+
+// A decodeError is raised by the decoder for malformed modules.
+type decodeError string
+
+// decode decodes the WebAssembly module in binary format b.
+func decode(b []byte) (m *module, err error) {
+	defer func() {
+		switch x := recover().(type) {
+		case nil:
+		case decodeError:
+			err = fmt.Errorf("invalid module: %s", string(x))
+		default:
+			panic(x)
+		}
+	}()
+
+	r := &reader{b: b}
+	if !bytes.HasPrefix(b, []byte("\x00asm\x01\x00\x00\x00")) {
+		panic(decodeError("not a WebAssembly binary of version 1"))
+	}
+	r.pos = 8
+
+	m = &module{exports: map[string]uint32{}}
+	var funcTypes []uint32
+	for !r.done() {
+		id := r.byte()
+		size := r.u32()
+		s := &reader{b: r.bytes(size)}
+		switch id {
+		case 0: // custom
+		case 1: // type
+			m.types = make([]funcType, s.count())
+			for i := range m.types {
+				if s.byte() != 0x60 {
+					panic(decodeError("malformed function type"))
+				}
+				m.types[i].params = s.valTypes()
+				m.types[i].results = s.valTypes()
+			}
+		case 2: // import
+			if s.u32() > 0 {
+				panic(decodeError("imports are not supported"))
+			}
+		case 3: // function
+			funcTypes = make([]uint32, s.count())
+			for i := range funcTypes {
+				funcTypes[i] = s.u32()
+			}
+		case 4: // table
+			n := s.u32()
+			if n > 1 {
+				panic(decodeError("multiple tables are not supported"))
+			}
+			if n == 1 {
+				if s.byte() != 0x70 {
+					panic(decodeError("only tables of funcref are supported"))
+				}
+				m.hasTable = true
+				m.tableMin, _ = s.limits()
+			}
+		case 5: // memory
+			n := s.u32()
+			if n > 1 {
+				panic(decodeError("multiple memories are not supported"))
+			}
+			if n == 1 {
+				m.hasMemory = true
+				m.memMin, m.memMax = s.limits()
+			}
+		case 6: // global
+			m.globals = make([]global, s.count())
+			for i := range m.globals {
+				g := &m.globals[i]
+				g.typ = s.valType()
+				g.mutable = s.byte() == 1
+				g.init = s.constExpr(m.globals[:i], g.typ)
+			}
+		case 7: // export
+			for n := s.u32(); n > 0; n-- {
+				name := s.name()
+				kind := s.byte()
+				index := s.u32()
+				if kind == 0 {
+					m.exports[name] = index
+				}
+			}
+		case 8: // start
+			start := s.u32()
+			m.start = &start
+		case 9: // element
+			for n := s.u32(); n > 0; n-- {
+				if s.u32() != 0 {
+					panic(decodeError("only active element segments of table 0 are supported"))
+				}
+				e := elements{offset: uint32(s.constExpr(m.globals, i32))}
+				e.funcs = make([]uint32, s.count())
+				for i := range e.funcs {
+					e.funcs[i] = s.u32()
+				}
+				m.elements = append(m.elements, e)
+			}
+		case 10: // code
+			if int(s.u32()) != len(funcTypes) {
+				panic(decodeError("function and code section have inconsistent lengths"))
+			}
+			m.funcs = make([]function, len(funcTypes))
+			for i := range m.funcs {
+				f := &m.funcs[i]
+				f.typ = funcTypes[i]
+				if int(f.typ) >= len(m.types) {
+					panic(decodeError("invalid function type index"))
+				}
+				c := &reader{b: s.bytes(s.u32())}
+				for n := c.u32(); n > 0; n-- {
+					count := c.u32()
+					t := c.valType()
+					if uint64(len(f.locals))+uint64(count) > maxLocals {
+						panic(decodeError("too many locals"))
+					}
+					for ; count > 0; count-- {
+						f.locals = append(f.locals, t)
+					}
+				}
+				f.body = c.b[c.pos:]
+			}
+		case 11: // data
+			for n := s.u32(); n > 0; n-- {
+				var d segment
+				switch s.u32() {
+				case 0:
+				case 1: // passive; only used by memory.init
+					s.bytes(s.u32())
+					continue
+				case 2:
+					if s.u32() != 0 {
+						panic(decodeError("invalid memory index"))
+					}
+				default:
+					panic(decodeError("malformed data segment"))
+				}
+				d.offset = uint32(s.constExpr(m.globals, i32))
+				d.init = s.bytes(s.u32())
+				m.data = append(m.data, d)
+			}
+		case 12: // data count
+		default:
+			panic(decodeError(fmt.Sprintf("unsupported section %d", id)))
+		}
+	}
+	m.validate()
+	return m, nil
+}
+
+// A reader reads the values of the binary format of WebAssembly.
+type reader struct {
+	b   []byte
+	pos int
+}
+
+func (r *reader) done() bool { return r.pos >= len(r.b) }
+
+func (r *reader) byte() byte {
+	if r.done() {
+		panic(decodeError("unexpected end of input"))
+	}
+	c := r.b[r.pos]
+	r.pos++
+	return c
+}
+
+func (r *reader) bytes(n uint32) []byte {
+	if uint64(r.pos)+uint64(n) > uint64(len(r.b)) {
+		panic(decodeError("unexpected end of input"))
+	}
+	b := r.b[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b
+}
+
+func (r *reader) u32() uint32 {
+	x := r.uleb(32)
+	return uint32(x)
+}
+
+func (r *reader) s32() int32 {
+	return int32(r.sleb(32))
+}
+
+func (r *reader) s64() int64 {
+	return r.sleb(64)
+}
+
+func (r *reader) uleb(bits uint) uint64 {
+	var x uint64
+	for shift := uint(0); ; shift += 7 {
+		if shift >= bits+7 {
+			panic(decodeError("integer too large"))
+		}
+		c := r.byte()
+		x |= uint64(c&0x7f) << shift
+		if c&0x80 == 0 {
+			return x
+		}
+	}
+}
+
+func (r *reader) sleb(bits uint) int64 {
+	var x int64
+	var shift uint
+	for {
+		if shift >= bits+7 {
+			panic(decodeError("integer too large"))
+		}
+		c := r.byte()
+		x |= int64(c&0x7f) << shift
+		shift += 7
+		if c&0x80 == 0 {
+			if shift < 64 && c&0x40 != 0 {
+				x |= -1 << shift
+			}
+			return x
+		}
+	}
+}
+
+func (r *reader) name() string {
+	return string(r.bytes(r.u32()))
+}
+
+// count reads the length of a vector. As each element takes at least one
+// byte, the length cannot exceed the number of remaining bytes.
+func (r *reader) count() uint32 {
+	n := r.u32()
+	if uint64(n) > uint64(len(r.b)-r.pos) {
+		panic(decodeError("unexpected end of input"))
+	}
+	return n
+}
+
+func (r *reader) valType() valType {
+	switch t := valType(r.byte()); t {
+	case i32, i64, f32, f64:
+		return t
+	default:
+		panic(decodeError(fmt.Sprintf("unsupported value type 0x%x", byte(t))))
+	}
+}
+
+func (r *reader) valTypes() []valType {
+	a := make([]valType, r.count())
+	for i := range a {
+		a[i] = r.valType()
+	}
+	return a
+}
+
+func (r *reader) limits() (min, max uint32) {
+	switch r.byte() {
+	case 0:
+		return r.u32(), math.MaxUint32
+	case 1:
+		return r.u32(), r.u32()
+	}
+	panic(decodeError("malformed limits"))
+}
+
+// blockType reads a block type and reports the number of parameters and
+// results of the block.
+func (r *reader) blockType(m *module) (params, results int) {
+	p, res := r.blockTypes(m)
+	return len(p), len(res)
+}
+
+// singleTypes holds the result types of blocks with a single result.
+var singleTypes = map[valType][]valType{
+	i32: {i32},
+	i64: {i64},
+	f32: {f32},
+	f64: {f64},
+}
+
+// blockTypes reads a block type and reports the types of the parameters and
+// results of the block.
+func (r *reader) blockTypes(m *module) (params, results []valType) {
+	switch t := valType(r.byte()); t {
+	case 0x40:
+		return nil, nil
+	case i32, i64, f32, f64:
+		return nil, singleTypes[t]
+	}
+	r.pos--
+	x := r.sleb(33)
+	if x < 0 || x >= int64(len(m.types)) {
+		panic(decodeError("invalid block type"))
+	}
+	t := &m.types[x]
+	return t.params, t.results
+}
+
+// constExpr reads a constant expression of type t, as used for the initial
+// values of globals and the offsets of segments.
+func (r *reader) constExpr(globals []global, t valType) uint64 {
+	var x uint64
+	var typ valType
+	switch r.byte() {
+	case 0x41:
+		x, typ = uint64(uint32(r.s32())), i32
+	case 0x42:
+		x, typ = uint64(r.s64()), i64
+	case 0x43:
+		x, typ = uint64(binary.LittleEndian.Uint32(r.bytes(4))), f32
+	case 0x44:
+		x, typ = binary.LittleEndian.Uint64(r.bytes(8)), f64
+	case 0x23:
+		i := r.u32()
+		if int(i) >= len(globals) {
+			panic(decodeError("invalid global index"))
+		}
+		x, typ = globals[i].init, globals[i].typ
+	default:
+		panic(decodeError("unsupported constant expression"))
+	}
+	if typ != t {
+		panic(decodeError(fmt.Sprintf("constant expression has type %s, want %s", typ, t)))
+	}
+	if r.byte() != 0x0b {
+		panic(decodeError("malformed constant expression"))
+	}
+	return x
+}
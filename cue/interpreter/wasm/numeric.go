@@ -0,0 +1,422 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"math"
+	"math/bits"
+)
+
+// numeric executes the numeric instruction op, in the range 0x45 to 0xc4.
+func (s *stack) numeric(op byte) {
+	switch {
+	case op == 0x45: // i32.eqz
+		s.pushBool(s.pop32() == 0)
+	case op <= 0x4f:
+		y, x := s.pop32(), s.pop32()
+		s.pushBool(compare32(op, x, y))
+	case op == 0x50: // i64.eqz
+		s.pushBool(s.pop() == 0)
+	case op <= 0x5a:
+		y, x := s.pop(), s.pop()
+		s.pushBool(compare64(op, x, y))
+	case op <= 0x60:
+		y, x := s.popF32(), s.popF32()
+		s.pushBool(compareFloat(op-0x5b, float64(x), float64(y)))
+	case op <= 0x66:
+		y, x := s.popF64(), s.popF64()
+		s.pushBool(compareFloat(op-0x61, x, y))
+
+	case op == 0x67: // i32.clz
+		s.push32(uint32(bits.LeadingZeros32(s.pop32())))
+	case op == 0x68: // i32.ctz
+		s.push32(uint32(bits.TrailingZeros32(s.pop32())))
+	case op == 0x69: // i32.popcnt
+		s.push32(uint32(bits.OnesCount32(s.pop32())))
+	case op <= 0x78:
+		y, x := s.pop32(), s.pop32()
+		s.push32(binary32(op, x, y))
+	case op == 0x79: // i64.clz
+		s.push(uint64(bits.LeadingZeros64(s.pop())))
+	case op == 0x7a: // i64.ctz
+		s.push(uint64(bits.TrailingZeros64(s.pop())))
+	case op == 0x7b: // i64.popcnt
+		s.push(uint64(bits.OnesCount64(s.pop())))
+	case op <= 0x8a:
+		y, x := s.pop(), s.pop()
+		s.push(binary64(op, x, y))
+
+	case op <= 0x91:
+		s.pushF32(float32(unaryFloat(op-0x8b, float64(s.popF32()))))
+	case op <= 0x98:
+		y, x := s.popF32(), s.popF32()
+		var z float32
+		switch op {
+		case 0x92:
+			z = x + y
+		case 0x93:
+			z = x - y
+		case 0x94:
+			z = x * y
+		case 0x95:
+			z = x / y
+		default:
+			z = float32(binaryFloat(op-0x96, float64(x), float64(y)))
+		}
+		s.pushF32(z)
+	case op <= 0x9f:
+		s.pushF64(unaryFloat(op-0x99, s.popF64()))
+	case op <= 0xa6:
+		y, x := s.popF64(), s.popF64()
+		var z float64
+		switch op {
+		case 0xa0:
+			z = x + y
+		case 0xa1:
+			z = x - y
+		case 0xa2:
+			z = x * y
+		case 0xa3:
+			z = x / y
+		default:
+			z = binaryFloat(op-0xa4, x, y)
+		}
+		s.pushF64(z)
+
+	default:
+		s.convert(op)
+	}
+}
+
+func compare32(op byte, x, y uint32) bool {
+	switch op {
+	case 0x46:
+		return x == y
+	case 0x47:
+		return x != y
+	case 0x48:
+		return int32(x) < int32(y)
+	case 0x49:
+		return x < y
+	case 0x4a:
+		return int32(x) > int32(y)
+	case 0x4b:
+		return x > y
+	case 0x4c:
+		return int32(x) <= int32(y)
+	case 0x4d:
+		return x <= y
+	case 0x4e:
+		return int32(x) >= int32(y)
+	default: // 0x4f
+		return x >= y
+	}
+}
+
+func compare64(op byte, x, y uint64) bool {
+	switch op {
+	case 0x51:
+		return x == y
+	case 0x52:
+		return x != y
+	case 0x53:
+		return int64(x) < int64(y)
+	case 0x54:
+		return x < y
+	case 0x55:
+		return int64(x) > int64(y)
+	case 0x56:
+		return x > y
+	case 0x57:
+		return int64(x) <= int64(y)
+	case 0x58:
+		return x <= y
+	case 0x59:
+		return int64(x) >= int64(y)
+	default: // 0x5a
+		return x >= y
+	}
+}
+
+// compareFloat implements eq, ne, lt, gt, le, and ge for both float types.
+func compareFloat(op byte, x, y float64) bool {
+	switch op {
+	case 0:
+		return x == y
+	case 1:
+		return x != y
+	case 2:
+		return x < y
+	case 3:
+		return x > y
+	case 4:
+		return x <= y
+	default:
+		return x >= y
+	}
+}
+
+func binary32(op byte, x, y uint32) uint32 {
+	switch op {
+	case 0x6a:
+		return x + y
+	case 0x6b:
+		return x - y
+	case 0x6c:
+		return x * y
+	case 0x6d:
+		if y == 0 {
+			panic(trap("integer divide by zero"))
+		}
+		if int32(x) == math.MinInt32 && int32(y) == -1 {
+			panic(trap("integer overflow"))
+		}
+		return uint32(int32(x) / int32(y))
+	case 0x6e:
+		if y == 0 {
+			panic(trap("integer divide by zero"))
+		}
+		return x / y
+	case 0x6f:
+		if y == 0 {
+			panic(trap("integer divide by zero"))
+		}
+		if int32(y) == -1 {
+			return 0
+		}
+		return uint32(int32(x) % int32(y))
+	case 0x70:
+		if y == 0 {
+			panic(trap("integer divide by zero"))
+		}
+		return x % y
+	case 0x71:
+		return x & y
+	case 0x72:
+		return x | y
+	case 0x73:
+		return x ^ y
+	case 0x74:
+		return x << (y & 31)
+	case 0x75:
+		return uint32(int32(x) >> (y & 31))
+	case 0x76:
+		return x >> (y & 31)
+	case 0x77:
+		return bits.RotateLeft32(x, int(y&31))
+	default: // 0x78
+		return bits.RotateLeft32(x, -int(y&31))
+	}
+}
+
+func binary64(op byte, x, y uint64) uint64 {
+	switch op {
+	case 0x7c:
+		return x + y
+	case 0x7d:
+		return x - y
+	case 0x7e:
+		return x * y
+	case 0x7f:
+		if y == 0 {
+			panic(trap("integer divide by zero"))
+		}
+		if int64(x) == math.MinInt64 && int64(y) == -1 {
+			panic(trap("integer overflow"))
+		}
+		return uint64(int64(x) / int64(y))
+	case 0x80:
+		if y == 0 {
+			panic(trap("integer divide by zero"))
+		}
+		return x / y
+	case 0x81:
+		if y == 0 {
+			panic(trap("integer divide by zero"))
+		}
+		if int64(y) == -1 {
+			return 0
+		}
+		return uint64(int64(x) % int64(y))
+	case 0x82:
+		if y == 0 {
+			panic(trap("integer divide by zero"))
+		}
+		return x % y
+	case 0x83:
+		return x & y
+	case 0x84:
+		return x | y
+	case 0x85:
+		return x ^ y
+	case 0x86:
+		return x << (y & 63)
+	case 0x87:
+		return uint64(int64(x) >> (y & 63))
+	case 0x88:
+		return x >> (y & 63)
+	case 0x89:
+		return bits.RotateLeft64(x, int(y&63))
+	default: // 0x8a
+		return bits.RotateLeft64(x, -int(y&63))
+	}
+}
+
+// unaryFloat implements abs, neg, ceil, floor, trunc, nearest, and sqrt for
+// both float types. These are exact for float32 values computed as float64,
+// except for sqrt, which rounds correctly when converted back to float32.
+func unaryFloat(op byte, x float64) float64 {
+	switch op {
+	case 0:
+		return math.Abs(x)
+	case 1:
+		return -x
+	case 2:
+		return math.Ceil(x)
+	case 3:
+		return math.Floor(x)
+	case 4:
+		return math.Trunc(x)
+	case 5:
+		return math.RoundToEven(x)
+	default:
+		return math.Sqrt(x)
+	}
+}
+
+// binaryFloat implements min, max, and copysign for both float types.
+func binaryFloat(op byte, x, y float64) float64 {
+	switch op {
+	case 0:
+		return math.Min(x, y)
+	case 1:
+		return math.Max(x, y)
+	default:
+		return math.Copysign(x, y)
+	}
+}
+
+// convert executes the conversion instruction op, in the range 0xa7 to 0xc4.
+func (s *stack) convert(op byte) {
+	switch op {
+	case 0xa7: // i32.wrap_i64
+		s.push32(uint32(s.pop()))
+	case 0xa8: // i32.trunc_f32_s
+		s.push32(uint32(truncS(float64(s.popF32()), 32)))
+	case 0xa9: // i32.trunc_f32_u
+		s.push32(uint32(truncU(float64(s.popF32()), 32)))
+	case 0xaa: // i32.trunc_f64_s
+		s.push32(uint32(truncS(s.popF64(), 32)))
+	case 0xab: // i32.trunc_f64_u
+		s.push32(uint32(truncU(s.popF64(), 32)))
+	case 0xac: // i64.extend_i32_s
+		s.push(uint64(int32(s.pop32())))
+	case 0xad: // i64.extend_i32_u
+		s.push(uint64(s.pop32()))
+	case 0xae: // i64.trunc_f32_s
+		s.push(uint64(truncS(float64(s.popF32()), 64)))
+	case 0xaf: // i64.trunc_f32_u
+		s.push(truncU(float64(s.popF32()), 64))
+	case 0xb0: // i64.trunc_f64_s
+		s.push(uint64(truncS(s.popF64(), 64)))
+	case 0xb1: // i64.trunc_f64_u
+		s.push(truncU(s.popF64(), 64))
+	case 0xb2: // f32.convert_i32_s
+		s.pushF32(float32(int32(s.pop32())))
+	case 0xb3: // f32.convert_i32_u
+		s.pushF32(float32(s.pop32()))
+	case 0xb4: // f32.convert_i64_s
+		s.pushF32(float32(int64(s.pop())))
+	case 0xb5: // f32.convert_i64_u
+		s.pushF32(float32(s.pop()))
+	case 0xb6: // f32.demote_f64
+		s.pushF32(float32(s.popF64()))
+	case 0xb7: // f64.convert_i32_s
+		s.pushF64(float64(int32(s.pop32())))
+	case 0xb8: // f64.convert_i32_u
+		s.pushF64(float64(s.pop32()))
+	case 0xb9: // f64.convert_i64_s
+		s.pushF64(float64(int64(s.pop())))
+	case 0xba: // f64.convert_i64_u
+		s.pushF64(float64(s.pop()))
+	case 0xbb: // f64.promote_f32
+		s.pushF64(float64(s.popF32()))
+	case 0xbc, 0xbe: // i32.reinterpret_f32, f32.reinterpret_i32
+		s.push32(s.pop32())
+	case 0xbd, 0xbf: // i64.reinterpret_f64, f64.reinterpret_i64
+	case 0xc0: // i32.extend8_s
+		s.push32(uint32(int8(s.pop32())))
+	case 0xc1: // i32.extend16_s
+		s.push32(uint32(int16(s.pop32())))
+	case 0xc2: // i64.extend8_s
+		s.push(uint64(int8(s.pop())))
+	case 0xc3: // i64.extend16_s
+		s.push(uint64(int16(s.pop())))
+	case 0xc4: // i64.extend32_s
+		s.push(uint64(int32(s.pop())))
+	}
+}
+
+// truncS truncates x to a signed integer of the given size, trapping if the
+// result cannot be represented.
+func truncS(x float64, size int) int64 {
+	if math.IsNaN(x) {
+		panic(trap("invalid conversion to integer"))
+	}
+	limit := math.Ldexp(1, size-1)
+	if math.Trunc(x) < -limit || x >= limit {
+		panic(trap("integer overflow"))
+	}
+	return int64(x)
+}
+
+// truncU truncates x to an unsigned integer of the given size, trapping if
+// the result cannot be represented.
+func truncU(x float64, size int) uint64 {
+	if math.IsNaN(x) {
+		panic(trap("invalid conversion to integer"))
+	}
+	if x <= -1 || x >= math.Ldexp(1, size) {
+		panic(trap("integer overflow"))
+	}
+	return uint64(x)
+}
+
+// truncSatS truncates x to a signed integer of the given size, saturating if
+// the result cannot be represented.
+func truncSatS(x float64, size int) int64 {
+	limit := math.Ldexp(1, size-1)
+	switch {
+	case math.IsNaN(x):
+		return 0
+	case x <= -limit:
+		return -1 << (size - 1)
+	case x >= limit:
+		return 1<<(size-1) - 1
+	}
+	return int64(x)
+}
+
+// truncSatU truncates x to an unsigned integer of the given size, saturating
+// if the result cannot be represented.
+func truncSatU(x float64, size int) uint64 {
+	switch {
+	case math.IsNaN(x), x <= 0:
+		return 0
+	case x >= math.Ldexp(1, size):
+		return 1<<size - 1
+	}
+	return uint64(x)
+}
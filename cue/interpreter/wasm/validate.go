@@ -0,0 +1,481 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import "fmt"
+
+// This file contains the validation of modules, which guarantees that the
+// interpreter only encounters well-typed instructions with valid indices.
+// It follows the validation algorithm of the specification; see
+// https://webassembly.github.io/spec/core/appendix/algorithm.html.
+
+// unknown is the type of operands popped in unreachable code, which matches
+// any type.
+const unknown valType = 0
+
+// validate raises a decodeError if m is not a valid module. It computes the
+// offsets of the end and else instructions of the functions of m.
+func (m *module) validate() {
+	for name, fn := range m.exports {
+		if int(fn) >= len(m.funcs) {
+			panic(decodeError(fmt.Sprintf("invalid function index for export %q", name)))
+		}
+	}
+	if m.start != nil {
+		if int(*m.start) >= len(m.funcs) {
+			panic(decodeError("invalid start function index"))
+		}
+		if t := &m.types[m.funcs[*m.start].typ]; len(t.params) > 0 || len(t.results) > 0 {
+			panic(decodeError("start function must not have parameters or results"))
+		}
+	}
+	if m.tableMin > maxTableSize {
+		panic(decodeError("table too large"))
+	}
+	if len(m.elements) > 0 && !m.hasTable {
+		panic(decodeError("element segment without table"))
+	}
+	for _, e := range m.elements {
+		for _, fn := range e.funcs {
+			if int(fn) >= len(m.funcs) {
+				panic(decodeError("invalid function index in element segment"))
+			}
+		}
+	}
+	if len(m.data) > 0 && !m.hasMemory {
+		panic(decodeError("data segment without memory"))
+	}
+	for i := range m.funcs {
+		m.validateFunc(&m.funcs[i])
+	}
+}
+
+// A ctrlFrame is an entry of the control stack of the validator.
+type ctrlFrame struct {
+	op          byte // block, loop, if, or else
+	pc          int  // offset of the instruction
+	start, end  []valType
+	height      int
+	unreachable bool
+}
+
+// labelTypes returns the types of the values passed by a branch to f.
+func (f *ctrlFrame) labelTypes() []valType {
+	if f.op == 0x03 { // loop
+		return f.start
+	}
+	return f.end
+}
+
+type validator struct {
+	m     *module
+	opds  []valType
+	ctrls []ctrlFrame
+}
+
+func (v *validator) push(t valType) { v.opds = append(v.opds, t) }
+
+func (v *validator) pushAll(a []valType) {
+	for _, t := range a {
+		v.push(t)
+	}
+}
+
+func (v *validator) pop() valType {
+	f := &v.ctrls[len(v.ctrls)-1]
+	if len(v.opds) == f.height {
+		if f.unreachable {
+			return unknown
+		}
+		panic(decodeError("type mismatch: missing operand"))
+	}
+	t := v.opds[len(v.opds)-1]
+	v.opds = v.opds[:len(v.opds)-1]
+	return t
+}
+
+func (v *validator) popType(want valType) valType {
+	got := v.pop()
+	if got == unknown {
+		return want
+	}
+	if want != unknown && got != want {
+		panic(decodeError(fmt.Sprintf("type mismatch: got %s, want %s", got, want)))
+	}
+	return got
+}
+
+// popAll pops operands of the given types and returns their types.
+func (v *validator) popAll(a []valType) []valType {
+	popped := make([]valType, len(a))
+	for i := len(a) - 1; i >= 0; i-- {
+		popped[i] = v.popType(a[i])
+	}
+	return popped
+}
+
+func (v *validator) pushCtrl(op byte, pc int, start, end []valType) {
+	v.ctrls = append(v.ctrls, ctrlFrame{
+		op:     op,
+		pc:     pc,
+		start:  start,
+		end:    end,
+		height: len(v.opds),
+	})
+	v.pushAll(start)
+}
+
+func (v *validator) popCtrl() ctrlFrame {
+	f := v.ctrls[len(v.ctrls)-1]
+	v.popAll(f.end)
+	if len(v.opds) != f.height {
+		panic(decodeError("type mismatch: too many operands at end of block"))
+	}
+	v.ctrls = v.ctrls[:len(v.ctrls)-1]
+	return f
+}
+
+// label returns the control frame targeted by a branch to the given depth.
+func (v *validator) label(depth uint32) *ctrlFrame {
+	if uint64(depth) >= uint64(len(v.ctrls)) {
+		panic(decodeError("invalid branch depth"))
+	}
+	return &v.ctrls[len(v.ctrls)-1-int(depth)]
+}
+
+// unreachable marks the rest of the current block as unreachable.
+func (v *validator) unreachable() {
+	f := &v.ctrls[len(v.ctrls)-1]
+	v.opds = v.opds[:f.height]
+	f.unreachable = true
+}
+
+// memArg reads the immediates of a memory access of the given size.
+func (v *validator) memArg(r *reader, size uint32) {
+	v.memory()
+	if align := r.u32(); align >= 32 || 1<<align > size {
+		panic(decodeError("alignment must not be larger than natural"))
+	}
+	r.u32() // offset
+}
+
+func (v *validator) memory() {
+	if !v.m.hasMemory {
+		panic(decodeError("memory instruction without memory"))
+	}
+}
+
+// zero reads a reserved byte, which must be zero.
+func zero(r *reader) {
+	if r.byte() != 0 {
+		panic(decodeError("reserved byte must be zero"))
+	}
+}
+
+// memOps holds the value type and size of the load and store instructions,
+// indexed by their opcode minus 0x28.
+var memOps = [...]struct {
+	typ  valType
+	size uint32
+}{
+	{i32, 4}, {i64, 8}, {f32, 4}, {f64, 8}, // loads
+	{i32, 1}, {i32, 1}, {i32, 2}, {i32, 2},
+	{i64, 1}, {i64, 1}, {i64, 2}, {i64, 2}, {i64, 4}, {i64, 4},
+	{i32, 4}, {i64, 8}, {f32, 4}, {f64, 8}, // stores
+	{i32, 1}, {i32, 2}, {i64, 1}, {i64, 2}, {i64, 4},
+}
+
+// conversions holds the operand and result types of the conversion
+// instructions, indexed by their opcode minus 0xa7.
+var conversions = [...][2]valType{
+	{i64, i32}, {f32, i32}, {f32, i32}, {f64, i32}, {f64, i32},
+	{i32, i64}, {i32, i64}, {f32, i64}, {f32, i64}, {f64, i64}, {f64, i64},
+	{i32, f32}, {i32, f32}, {i64, f32}, {i64, f32}, {f64, f32},
+	{i32, f64}, {i32, f64}, {i64, f64}, {i64, f64}, {f32, f64},
+	{f32, i32}, {f64, i64}, {i32, f32}, {i64, f64},
+}
+
+// numericType returns the operand types and the result type of the numeric
+// instruction op, in the range 0x45 to 0xc4.
+func numericType(op byte) (params []valType, result valType) {
+	unary := func(t valType) []valType { return []valType{t} }
+	binary := func(t valType) []valType { return []valType{t, t} }
+	switch {
+	case op == 0x45: // i32.eqz
+		return unary(i32), i32
+	case op <= 0x4f:
+		return binary(i32), i32
+	case op == 0x50: // i64.eqz
+		return unary(i64), i32
+	case op <= 0x5a:
+		return binary(i64), i32
+	case op <= 0x60:
+		return binary(f32), i32
+	case op <= 0x66:
+		return binary(f64), i32
+	case op <= 0x69:
+		return unary(i32), i32
+	case op <= 0x78:
+		return binary(i32), i32
+	case op <= 0x7b:
+		return unary(i64), i64
+	case op <= 0x8a:
+		return binary(i64), i64
+	case op <= 0x91:
+		return unary(f32), f32
+	case op <= 0x98:
+		return binary(f32), f32
+	case op <= 0x9f:
+		return unary(f64), f64
+	case op <= 0xa6:
+		return binary(f64), f64
+	case op <= 0xbf:
+		c := conversions[op-0xa7]
+		return unary(c[0]), c[1]
+	case op <= 0xc1: // i32.extend8_s, i32.extend16_s
+		return unary(i32), i32
+	default: // i64.extend8_s, i64.extend16_s, i64.extend32_s
+		return unary(i64), i64
+	}
+}
+
+// validateFunc validates the body of f and computes the offsets of its end
+// and else instructions.
+func (m *module) validateFunc(f *function) {
+	t := &m.types[f.typ]
+	locals := append(append([]valType(nil), t.params...), f.locals...)
+	localType := func(i uint32) valType {
+		if uint64(i) >= uint64(len(locals)) {
+			panic(decodeError("invalid local index"))
+		}
+		return locals[i]
+	}
+	globalAt := func(i uint32) *global {
+		if uint64(i) >= uint64(len(m.globals)) {
+			panic(decodeError("invalid global index"))
+		}
+		return &m.globals[i]
+	}
+	callType := func(i uint32) *funcType {
+		if uint64(i) >= uint64(len(m.funcs)) {
+			panic(decodeError("invalid function index"))
+		}
+		return &m.types[m.funcs[i].typ]
+	}
+
+	f.ends = map[int]int{}
+	f.elses = map[int]int{}
+	v := &validator{m: m}
+	v.pushCtrl(0x02, -1, nil, t.results)
+	r := &reader{b: f.body}
+	for {
+		pc := r.pos
+		switch op := r.byte(); {
+		case op == 0x00: // unreachable
+			v.unreachable()
+		case op == 0x01: // nop
+
+		case op == 0x02, op == 0x03: // block, loop
+			in, out := r.blockTypes(m)
+			v.popAll(in)
+			v.pushCtrl(op, pc, in, out)
+		case op == 0x04: // if
+			in, out := r.blockTypes(m)
+			v.popType(i32)
+			v.popAll(in)
+			v.pushCtrl(op, pc, in, out)
+		case op == 0x05: // else
+			c := v.popCtrl()
+			if c.op != 0x04 {
+				panic(decodeError("else without if"))
+			}
+			f.elses[c.pc] = r.pos
+			v.pushCtrl(0x05, c.pc, c.start, c.end)
+		case op == 0x0b: // end
+			c := v.popCtrl()
+			if c.op == 0x04 && !equalTypes(c.start, c.end) {
+				panic(decodeError("type mismatch: if without else must not change the operand types"))
+			}
+			v.pushAll(c.end)
+			if len(v.ctrls) == 0 {
+				if !r.done() {
+					panic(decodeError("instructions after end of function"))
+				}
+				return
+			}
+			f.ends[c.pc] = r.pos
+
+		case op == 0x0c: // br
+			v.popAll(v.label(r.u32()).labelTypes())
+			v.unreachable()
+		case op == 0x0d: // br_if
+			l := v.label(r.u32())
+			v.popType(i32)
+			v.pushAll(v.popAll(l.labelTypes()))
+		case op == 0x0e: // br_table
+			targets := make([]uint32, r.count())
+			for i := range targets {
+				targets[i] = r.u32()
+			}
+			def := v.label(r.u32()).labelTypes()
+			v.popType(i32)
+			for _, d := range targets {
+				lt := v.label(d).labelTypes()
+				if len(lt) != len(def) {
+					panic(decodeError("type mismatch: br_table targets of different arity"))
+				}
+				v.pushAll(v.popAll(lt))
+			}
+			v.popAll(def)
+			v.unreachable()
+		case op == 0x0f: // return
+			v.popAll(t.results)
+			v.unreachable()
+
+		case op == 0x10: // call
+			ft := callType(r.u32())
+			v.popAll(ft.params)
+			v.pushAll(ft.results)
+		case op == 0x11: // call_indirect
+			i := r.u32()
+			if uint64(i) >= uint64(len(m.types)) {
+				panic(decodeError("invalid type index"))
+			}
+			if r.u32() != 0 || !m.hasTable {
+				panic(decodeError("call_indirect without table"))
+			}
+			v.popType(i32)
+			v.popAll(m.types[i].params)
+			v.pushAll(m.types[i].results)
+
+		case op == 0x1a: // drop
+			v.pop()
+		case op == 0x1b: // select
+			v.popType(i32)
+			t1, t2 := v.pop(), v.pop()
+			if t1 != t2 && t1 != unknown && t2 != unknown {
+				panic(decodeError(fmt.Sprintf("type mismatch: select of %s and %s", t2, t1)))
+			}
+			if t1 == unknown {
+				t1 = t2
+			}
+			v.push(t1)
+		case op == 0x1c: // select t*
+			ts := r.valTypes()
+			if len(ts) != 1 {
+				panic(decodeError("select must have exactly one type"))
+			}
+			v.popType(i32)
+			v.popType(ts[0])
+			v.popType(ts[0])
+			v.push(ts[0])
+
+		case op == 0x20: // local.get
+			v.push(localType(r.u32()))
+		case op == 0x21: // local.set
+			v.popType(localType(r.u32()))
+		case op == 0x22: // local.tee
+			lt := localType(r.u32())
+			v.popType(lt)
+			v.push(lt)
+		case op == 0x23: // global.get
+			v.push(globalAt(r.u32()).typ)
+		case op == 0x24: // global.set
+			g := globalAt(r.u32())
+			if !g.mutable {
+				panic(decodeError("global is immutable"))
+			}
+			v.popType(g.typ)
+
+		case 0x28 <= op && op <= 0x35: // loads
+			mo := memOps[op-0x28]
+			v.memArg(r, mo.size)
+			v.popType(i32)
+			v.push(mo.typ)
+		case 0x36 <= op && op <= 0x3e: // stores
+			mo := memOps[op-0x28]
+			v.memArg(r, mo.size)
+			v.popType(mo.typ)
+			v.popType(i32)
+		case op == 0x3f: // memory.size
+			v.memory()
+			zero(r)
+			v.push(i32)
+		case op == 0x40: // memory.grow
+			v.memory()
+			zero(r)
+			v.popType(i32)
+			v.push(i32)
+
+		case op == 0x41: // i32.const
+			r.s32()
+			v.push(i32)
+		case op == 0x42: // i64.const
+			r.s64()
+			v.push(i64)
+		case op == 0x43: // f32.const
+			r.bytes(4)
+			v.push(f32)
+		case op == 0x44: // f64.const
+			r.bytes(8)
+			v.push(f64)
+
+		case 0x45 <= op && op <= 0xc4:
+			params, result := numericType(op)
+			v.popAll(params)
+			v.push(result)
+
+		case op == 0xfc:
+			switch sub := r.u32(); {
+			case sub <= 7: // saturating truncation
+				from, to := f32, i32
+				if sub&2 != 0 {
+					from = f64
+				}
+				if sub >= 4 {
+					to = i64
+				}
+				v.popType(from)
+				v.push(to)
+			case sub == 10: // memory.copy
+				v.memory()
+				zero(r)
+				zero(r)
+				v.popAll([]valType{i32, i32, i32})
+			case sub == 11: // memory.fill
+				v.memory()
+				zero(r)
+				v.popAll([]valType{i32, i32, i32})
+			default:
+				panic(decodeError(fmt.Sprintf("unsupported instruction 0xfc %d", sub)))
+			}
+
+		default:
+			panic(decodeError(fmt.Sprintf("unsupported instruction 0x%02x", op)))
+		}
+	}
+}
+
+func equalTypes(a, b []valType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
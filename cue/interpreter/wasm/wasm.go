@@ -0,0 +1,318 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wasm implements CUE functions with functions exported by
+// WebAssembly modules.
+//
+// A file uses WebAssembly functions by selecting this interpreter with the
+// file attribute @extern("wasm"). Fields of the file that have an @extern
+// attribute then become functions implemented by the module named by the
+// attribute:
+//
+//	@extern("wasm")
+//
+//	package p
+//
+//	add: _ @extern("math.wasm", name=add, sig="func(int64, int64): int64")
+//
+//	three: add(1, 2)
+//
+// The module file is relative to the directory of the package. The name
+// argument selects the exported function, and defaults to the name of the
+// field without a leading # or _, so that functions can be declared as
+// definitions or hidden fields, which are not exported. The sig argument
+// declares the signature of the function, which must match the type of the
+// exported function. The supported types are int32,
+// int64, uint32, and uint64, which are passed as WebAssembly integers, float32
+// and float64, and bool, which is passed as an i32 that is either 0 or 1.
+//
+// Modules must be self-contained: imports are not supported. Modules are
+// validated when they are loaded. Each call is run on a new instance of the
+// module, so that functions cannot keep state between calls and remain pure,
+// as CUE requires.
+//
+// A call fails if it executes more than about 134 million instructions, or
+// more than the MaxSteps of the evaluation limits of the Context if these are
+// set and smaller. The Context of the evaluation limits, if any, aborts calls
+// as well.
+//
+// The interpreter is experimental and is not installed by default. Programs
+// install it with cuecontext.WithInterpreter:
+//
+//	ctx := cuecontext.New(cuecontext.WithInterpreter(wasm.New()))
+//
+// The cue command installs it only if the comma-separated list of experiments
+// in the CUE_EXPERIMENT environment variable includes wasm.
+package wasm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/core/runtime"
+)
+
+// New returns an interpreter for the "wasm" @extern kind.
+func New() cuecontext.ExternInterpreter {
+	return &interpreter{}
+}
+
+type interpreter struct{}
+
+func (i *interpreter) Kind() string {
+	return "wasm"
+}
+
+func (i *interpreter) NewCompiler(b *build.Instance) (runtime.Compiler, errors.Error) {
+	return &compiler{dir: b.Dir, modules: map[string]*module{}}, nil
+}
+
+type compiler struct {
+	dir     string
+	modules map[string]*module
+}
+
+func (c *compiler) Compile(name string, pos token.Pos, a *internal.Attr) (adt.Expr, errors.Error) {
+	file, err := a.String(0)
+	if err != nil || file == "" {
+		return nil, errors.Newf(pos, "@extern: missing module file")
+	}
+	export := strings.TrimLeft(name, "_#")
+	if s, ok, _ := a.Lookup(1, "name"); ok {
+		export = s
+	}
+	s, ok, _ := a.Lookup(1, "sig")
+	if !ok {
+		return nil, errors.Newf(pos, "@extern: missing signature for %s", name)
+	}
+	params, result, err := parseSignature(s)
+	if err != nil {
+		return nil, errors.Newf(pos, "@extern: invalid signature %q: %v", s, err)
+	}
+
+	m, err := c.load(file)
+	if err != nil {
+		return nil, errors.Newf(pos, "@extern: cannot load %s: %v", file, err)
+	}
+	fn, ok := m.exports[export]
+	if !ok || int(fn) >= len(m.funcs) {
+		return nil, errors.Newf(pos, "@extern: %s does not export function %s", file, export)
+	}
+	if err := checkSignature(&m.types[m.funcs[fn].typ], params, result); err != nil {
+		return nil, errors.Newf(pos, "@extern: signature of %s does not match function %s of %s: %v",
+			name, export, file, err)
+	}
+
+	b := &adt.Builtin{
+		Params: make([]adt.Param, len(params)),
+		Result: result.kind,
+		Name:   name,
+		Func: func(c *adt.OpContext, args []adt.Value) adt.Expr {
+			in := make([]uint64, len(args))
+			for i, a := range args {
+				x, err := params[i].toWasm(a)
+				if err != nil {
+					return c.NewErrf("argument %d: %v", i+1, err)
+				}
+				in[i] = x
+			}
+			out, err := m.invoke(fn, in, c.Limits())
+			if err != nil {
+				return c.NewErrf("%v", err)
+			}
+			return result.toCUE(c, out[0])
+		},
+	}
+	for i, p := range params {
+		b.Params[i].Value = &adt.BasicType{K: p.kind}
+	}
+	return b, nil
+}
+
+// load loads and decodes the module in file, which is relative to the
+// directory of the instance.
+func (c *compiler) load(file string) (*module, error) {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(c.dir, file)
+	}
+	if m, ok := c.modules[file]; ok {
+		return m, nil
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	m, err := decode(b)
+	if err != nil {
+		return nil, err
+	}
+	c.modules[file] = m
+	return m, nil
+}
+
+// A sigType is a type of the signature of an @extern function.
+type sigType struct {
+	name string
+	wasm valType
+	kind adt.Kind
+}
+
+var sigTypes = map[string]sigType{
+	"int32":   {"int32", i32, adt.IntKind},
+	"int64":   {"int64", i64, adt.IntKind},
+	"uint32":  {"uint32", i32, adt.IntKind},
+	"uint64":  {"uint64", i64, adt.IntKind},
+	"float32": {"float32", f32, adt.NumKind},
+	"float64": {"float64", f64, adt.NumKind},
+	"bool":    {"bool", i32, adt.BoolKind},
+}
+
+// parseSignature parses a signature of the form func(T1, T2, ...): R.
+func parseSignature(s string) (params []sigType, result sigType, err error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "func(") {
+		return nil, result, fmt.Errorf("must be of the form func(T, ...): R")
+	}
+	s = s[len("func("):]
+	end := strings.IndexByte(s, ')')
+	if end < 0 {
+		return nil, result, fmt.Errorf("missing )")
+	}
+	if args := strings.TrimSpace(s[:end]); args != "" {
+		for _, a := range strings.Split(args, ",") {
+			t, ok := sigTypes[strings.TrimSpace(a)]
+			if !ok {
+				return nil, result, fmt.Errorf("unsupported type %q", strings.TrimSpace(a))
+			}
+			params = append(params, t)
+		}
+	}
+	s = strings.TrimSpace(s[end+1:])
+	if !strings.HasPrefix(s, ":") {
+		return nil, result, fmt.Errorf("missing result type")
+	}
+	s = strings.TrimSpace(s[1:])
+	result, ok := sigTypes[s]
+	if !ok {
+		return nil, result, fmt.Errorf("unsupported type %q", s)
+	}
+	return params, result, nil
+}
+
+// checkSignature reports whether the type of a WebAssembly function
+// corresponds to the declared signature.
+func checkSignature(t *funcType, params []sigType, result sigType) error {
+	want := &funcType{results: []valType{result.wasm}}
+	for _, p := range params {
+		want.params = append(want.params, p.wasm)
+	}
+	if !t.equal(want) {
+		return fmt.Errorf("function has type %s, want %s", t, want)
+	}
+	return nil
+}
+
+// toWasm converts an argument to its WebAssembly representation.
+func (t sigType) toWasm(v adt.Value) (uint64, error) {
+	switch x := v.(type) {
+	case *adt.Bool:
+		if x.B {
+			return 1, nil
+		}
+		return 0, nil
+
+	case *adt.Num:
+		switch t.wasm {
+		case f32, f64:
+			f, err := x.X.Float64()
+			if err != nil {
+				return 0, err
+			}
+			if t.wasm == f32 {
+				return uint64(math.Float32bits(float32(f))), nil
+			}
+			return math.Float64bits(f), nil
+		}
+		s := x.X.Text('f')
+		switch t.name {
+		case "int32":
+			i, err := strconv.ParseInt(s, 10, 32)
+			return uint64(uint32(i)), rangeError(t, s, err)
+		case "int64":
+			i, err := strconv.ParseInt(s, 10, 64)
+			return uint64(i), rangeError(t, s, err)
+		case "uint32":
+			i, err := strconv.ParseUint(s, 10, 32)
+			return i, rangeError(t, s, err)
+		default:
+			i, err := strconv.ParseUint(s, 10, 64)
+			return i, rangeError(t, s, err)
+		}
+	}
+	return 0, fmt.Errorf("unsupported value %v", v)
+}
+
+func rangeError(t sigType, s string, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s does not fit in %s", s, t.name)
+	}
+	return nil
+}
+
+// toCUE converts a result to its CUE value.
+func (t sigType) toCUE(c *adt.OpContext, x uint64) adt.Expr {
+	var s string
+	switch t.name {
+	case "bool":
+		return &adt.Bool{B: uint32(x) != 0}
+	case "int32":
+		return c.NewInt64(int64(int32(x)))
+	case "int64":
+		return c.NewInt64(int64(x))
+	case "uint32":
+		return c.NewInt64(int64(uint32(x)))
+	case "uint64":
+		s = strconv.FormatUint(x, 10)
+	case "float32":
+		f := math.Float32frombits(uint32(x))
+		if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+			return c.NewErrf("result %v cannot be represented as a number", f)
+		}
+		s = strconv.FormatFloat(float64(f), 'g', -1, 32)
+	case "float64":
+		f := math.Float64frombits(x)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return c.NewErrf("result %v cannot be represented as a number", f)
+		}
+		s = strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	n := &adt.Num{K: t.kind}
+	if t.kind != adt.IntKind {
+		n.K = adt.FloatKind
+	}
+	if _, _, err := n.X.SetString(s); err != nil {
+		return c.NewErrf("invalid result %s: %v", s, err)
+	}
+	return n
+}
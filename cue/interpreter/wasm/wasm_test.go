@@ -0,0 +1,454 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/interpreter/wasm"
+	"cuelang.org/go/cue/load"
+)
+
+const (
+	i32 = 0x7f
+	i64 = 0x7e
+	f64 = 0x7c
+)
+
+// A fn is a function of a test module.
+type fn struct {
+	name    string
+	params  []byte
+	results []byte
+	locals  []byte // one local per entry
+	body    []byte // without the final end
+}
+
+// assemble returns the binary format of a module with the given functions,
+// one page of memory, and the given data at offset 0.
+func assemble(funcs []fn, data string) []byte {
+	var types, decls, exports, code []byte
+	for i, f := range funcs {
+		types = append(types, 0x60)
+		types = append(types, vec(f.params)...)
+		types = append(types, vec(f.results)...)
+		decls = append(decls, uleb(uint64(i))...)
+		exports = append(exports, vec([]byte(f.name))...)
+		exports = append(exports, 0x00)
+		exports = append(exports, uleb(uint64(i))...)
+		var body []byte
+		body = append(body, uleb(uint64(len(f.locals)))...)
+		for _, t := range f.locals {
+			body = append(body, 1, t)
+		}
+		body = append(body, f.body...)
+		body = append(body, 0x0b)
+		code = append(code, vec(body)...)
+	}
+	n := uleb(uint64(len(funcs)))
+	b := []byte("\x00asm\x01\x00\x00\x00")
+	b = append(b, section(1, n, types)...)
+	b = append(b, section(3, n, decls)...)
+	b = append(b, section(5, []byte{1}, []byte{0, 1})...)
+	b = append(b, section(7, n, exports)...)
+	b = append(b, section(10, n, code)...)
+	if data != "" {
+		seg := []byte{0, 0x41, 0, 0x0b}
+		seg = append(seg, vec([]byte(data))...)
+		b = append(b, section(11, []byte{1}, seg)...)
+	}
+	return b
+}
+
+func section(id byte, n, contents []byte) []byte {
+	b := append(n, contents...)
+	return append(append([]byte{id}, uleb(uint64(len(b)))...), b...)
+}
+
+func vec(b []byte) []byte {
+	return append(uleb(uint64(len(b))), b...)
+}
+
+func uleb(x uint64) []byte {
+	var b []byte
+	for {
+		c := byte(x & 0x7f)
+		x >>= 7
+		if x != 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+		if x == 0 {
+			return b
+		}
+	}
+}
+
+func cat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+var (
+	get = func(i byte) []byte { return []byte{0x20, i} }
+	set = func(i byte) []byte { return []byte{0x21, i} }
+	c32 = func(x byte) []byte { return []byte{0x41, x} }
+	c64 = func(x byte) []byte { return []byte{0x42, x} }
+)
+
+var testFuncs = []fn{{
+	name:    "add",
+	params:  []byte{i64, i64},
+	results: []byte{i64},
+	body:    cat(get(0), get(1), []byte{0x7c}),
+}, {
+	// fact(n) = n <= 1 ? 1 : n * fact(n-1)
+	name:    "fact",
+	params:  []byte{i64},
+	results: []byte{i64},
+	body: cat(
+		get(0), c64(1), []byte{0x57}, // i64.le_s
+		[]byte{0x04, i64}, c64(1),
+		[]byte{0x05}, get(0), get(0), c64(1), []byte{0x7d}, // i64.sub
+		[]byte{0x10, 1, 0x7e}, // call fact, i64.mul
+		[]byte{0x0b},
+	),
+}, {
+	// sum(n) = n + (n-1) + ... + 1, using a loop.
+	name:    "sum",
+	params:  []byte{i32},
+	results: []byte{i32},
+	locals:  []byte{i32},
+	body: cat(
+		[]byte{0x02, 0x40, 0x03, 0x40}, // block loop
+		get(0), []byte{0x45, 0x0d, 1},  // i32.eqz br_if 1
+		get(1), get(0), []byte{0x6a}, set(1),
+		get(0), c32(1), []byte{0x6b}, set(0),
+		[]byte{0x0c, 0, 0x0b, 0x0b}, // br 0 end end
+		get(1),
+	),
+}, {
+	// roundtrip stores its argument in memory and loads it back.
+	name:    "roundtrip",
+	params:  []byte{i32},
+	results: []byte{i32},
+	body: cat(
+		c32(16), get(0), []byte{0x36, 2, 0},
+		c32(16), []byte{0x28, 2, 0},
+	),
+}, {
+	// byteAt loads a byte of the data segment.
+	name:    "byteAt",
+	params:  []byte{i32},
+	results: []byte{i32},
+	body:    cat(get(0), []byte{0x2d, 0, 0}),
+}, {
+	name:    "div",
+	params:  []byte{i32, i32},
+	results: []byte{i32},
+	body:    cat(get(0), get(1), []byte{0x6d}),
+}, {
+	name:    "sqrt",
+	params:  []byte{f64},
+	results: []byte{f64},
+	body:    cat(get(0), []byte{0x9f}),
+}, {
+	name:    "even",
+	params:  []byte{i64},
+	results: []byte{i32},
+	body:    cat(get(0), c64(2), []byte{0x82, 0x50}),
+}, {
+	name:    "forever",
+	params:  []byte{i32},
+	results: []byte{i32},
+	body:    cat(get(0), []byte{0x10, 8}),
+}, {
+	// choose maps 0 to 10, 1 to 20, and everything else to 30.
+	name:    "choose",
+	params:  []byte{i32},
+	results: []byte{i32},
+	body: cat(
+		[]byte{0x02, 0x40, 0x02, 0x40, 0x02, 0x40},
+		get(0), []byte{0x0e, 2, 0, 1, 2},
+		[]byte{0x0b}, c32(10), []byte{0x0f},
+		[]byte{0x0b}, c32(20), []byte{0x0f},
+		[]byte{0x0b}, c32(30),
+	),
+}, {
+	name:    "grow",
+	params:  []byte{i32},
+	results: []byte{i32},
+	body:    cat(get(0), []byte{0x40, 0, 0x1a, 0x3f, 0}),
+}, {
+	// spin loops forever.
+	name:    "spin",
+	params:  []byte{i32},
+	results: []byte{i32},
+	body:    cat([]byte{0x03, 0x40, 0x0c, 0, 0x0b}, get(0)),
+}}
+
+func writeModule(t *testing.T, dir, name string, funcs []fn, data string) {
+	t.Helper()
+	b := assemble(funcs, data)
+	if err := ioutil.WriteFile(filepath.Join(dir, name), b, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// build builds src in a directory with the module test.wasm and, if bad is
+// not nil, the module bad.wasm with the functions of bad.
+func build(t *testing.T, ctx *cue.Context, src string, bad ...fn) cue.Value {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeModule(t, dir, "test.wasm", testFuncs, "hello")
+	if bad != nil {
+		writeModule(t, dir, "bad.wasm", bad, "")
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "x.cue"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	insts := load.Instances([]string{"."}, &load.Config{Dir: dir})
+	if err := insts[0].Err; err != nil {
+		t.Fatal(err)
+	}
+	return ctx.BuildInstance(insts[0])
+}
+
+func TestFunctions(t *testing.T) {
+	const decls = `
+@extern("wasm")
+
+package x
+
+add:       _ @extern("test.wasm", sig="func(int64, int64): int64")
+fact:      _ @extern("test.wasm", sig="func(int64): int64")
+sum:       _ @extern("test.wasm", sig="func(uint32): uint32")
+roundtrip: _ @extern("test.wasm", sig="func(int32): int32")
+byteAt:    _ @extern("test.wasm", sig="func(uint32): uint32")
+div:       _ @extern("test.wasm", sig="func(int32, int32): int32")
+sqrt:      _ @extern("test.wasm", sig="func(float64): float64")
+#isEven:   _ @extern("test.wasm", name=even, sig="func(int64): bool")
+forever:   _ @extern("test.wasm", sig="func(int32): int32")
+choose:    _ @extern("test.wasm", sig="func(int32): int32")
+grow:      _ @extern("test.wasm", sig="func(uint32): uint32")
+spin:      _ @extern("test.wasm", sig="func(int32): int32")
+`
+	testCases := []struct {
+		expr    string
+		limits  cuecontext.Limits
+		timeout time.Duration
+		out     string
+		err     string
+	}{{
+		expr: "add(1, 2)",
+		out:  "3",
+	}, {
+		expr: "add(9223372036854775807, 1)",
+		out:  "-9223372036854775808",
+	}, {
+		expr: "fact(20)",
+		out:  "2432902008176640000",
+	}, {
+		expr: "sum(100)",
+		out:  "5050",
+	}, {
+		expr: "roundtrip(-7)",
+		out:  "-7",
+	}, {
+		expr: "byteAt(1)",
+		out:  "101", // 'e'
+	}, {
+		expr: "byteAt(65536)",
+		err:  "trap: out of bounds memory access",
+	}, {
+		expr: "div(7, -2)",
+		out:  "-3",
+	}, {
+		expr: "div(1, 0)",
+		err:  "trap: integer divide by zero",
+	}, {
+		expr: "div(1, 4294967296)",
+		err:  "argument 2: 4294967296 does not fit in int32",
+	}, {
+		expr: "sqrt(2)",
+		out:  fmt.Sprint(math.Sqrt(2)),
+	}, {
+		expr: "sqrt(-1)",
+		err:  "result NaN cannot be represented as a number",
+	}, {
+		expr: "#isEven(10)",
+		out:  "true",
+	}, {
+		expr: "#isEven(7)",
+		out:  "false",
+	}, {
+		expr: "forever(1)",
+		err:  "trap: call stack exhausted",
+	}, {
+		expr: "[choose(0), choose(1), choose(2), choose(100)]",
+		out:  "[10, 20, 30, 30]",
+	}, {
+		expr: "grow(2)",
+		out:  "3",
+	}, {
+		expr: "add(1, 2.5)",
+		err:  "cannot use 2.5 (type float) as int",
+	}, {
+		expr:   "spin(1)",
+		limits: cuecontext.Limits{MaxSteps: 10000},
+		err:    "trap: exceeded the maximum of 10000 instructions",
+	}, {
+		expr:    "spin(1)",
+		timeout: 100 * time.Millisecond,
+		err:     "trap: evaluation aborted: context deadline exceeded",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			limits := tc.limits
+			if tc.timeout != 0 {
+				c, cancel := context.WithTimeout(context.Background(), tc.timeout)
+				defer cancel()
+				limits.Context = c
+			}
+			ctx := cuecontext.New(
+				cuecontext.WithInterpreter(wasm.New()),
+				cuecontext.WithLimits(limits))
+			v := build(t, ctx, decls+"out: "+tc.expr).LookupPath(cue.ParsePath("out"))
+			if tc.err != "" {
+				err := v.Validate()
+				if err == nil || !strings.Contains(errors.Details(err, nil), tc.err) {
+					t.Fatalf("got error %v; want %q", err, tc.err)
+				}
+				return
+			}
+			if err := v.Err(); err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+			if got := fmt.Sprint(v); got != tc.out {
+				t.Errorf("got %s; want %s", got, tc.out)
+			}
+		})
+	}
+}
+
+const badSrc = `@extern("wasm")
+package x
+f: _ @extern("bad.wasm", sig="func(): int32")`
+
+func TestErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		ctx  *cue.Context
+		src  string
+		bad  []fn
+		err  string
+	}{{
+		name: "no interpreter",
+		ctx:  cuecontext.New(),
+		src: `@extern("wasm")
+package x
+add: _ @extern("test.wasm", sig="func(int64, int64): int64")`,
+		err: `no interpreter defined for "wasm"`,
+	}, {
+		name: "signature mismatch",
+		src: `@extern("wasm")
+package x
+add: _ @extern("test.wasm", sig="func(int32, int64): int64")`,
+		err: "signature of add does not match function add of test.wasm: function has type func(i64, i64): i64, want func(i32, i64): i64",
+	}, {
+		name: "unknown type",
+		src: `@extern("wasm")
+package x
+add: _ @extern("test.wasm", sig="func(int, int64): int64")`,
+		err: `unsupported type "int"`,
+	}, {
+		name: "missing export",
+		src: `@extern("wasm")
+package x
+sub: _ @extern("test.wasm", sig="func(int64, int64): int64")`,
+		err: "test.wasm does not export function sub",
+	}, {
+		name: "missing module",
+		src: `@extern("wasm")
+package x
+add: _ @extern("other.wasm", sig="func(int64, int64): int64")`,
+		err: "cannot load other.wasm",
+	}, {
+		name: "missing operand",
+		src:  badSrc,
+		bad:  []fn{{name: "f", results: []byte{i32}, body: []byte{0x6a}}},
+		err:  "cannot load bad.wasm: invalid module: type mismatch: missing operand",
+	}, {
+		name: "wrong result type",
+		src:  badSrc,
+		bad:  []fn{{name: "f", results: []byte{i32}, body: c64(1)}},
+		err:  "type mismatch: got i64, want i32",
+	}, {
+		name: "remaining operands",
+		src:  badSrc,
+		bad:  []fn{{name: "f", results: []byte{i32}, body: cat(c32(1), c32(2))}},
+		err:  "type mismatch: too many operands at end of block",
+	}, {
+		name: "invalid local",
+		src:  badSrc,
+		bad:  []fn{{name: "f", results: []byte{i32}, body: get(3)}},
+		err:  "invalid local index",
+	}, {
+		name: "invalid branch",
+		src:  badSrc,
+		bad:  []fn{{name: "f", results: []byte{i32}, body: []byte{0x0c, 2}}},
+		err:  "invalid branch depth",
+	}, {
+		name: "invalid call",
+		src:  badSrc,
+		bad:  []fn{{name: "f", results: []byte{i32}, body: []byte{0x10, 7}}},
+		err:  "invalid function index",
+	}, {
+		name: "unsupported instruction",
+		src:  badSrc,
+		bad:  []fn{{name: "f", results: []byte{i32}, body: []byte{0x06}}},
+		err:  "unsupported instruction 0x06",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := tc.ctx
+			if ctx == nil {
+				ctx = cuecontext.New(cuecontext.WithInterpreter(wasm.New()))
+			}
+			err := build(t, ctx, tc.src, tc.bad...).Err()
+			if err == nil || !strings.Contains(errors.Details(err, nil), tc.err) {
+				t.Fatalf("got error %v; want %q", err, tc.err)
+			}
+		})
+	}
+}
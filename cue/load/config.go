@@ -16,6 +16,7 @@ package load
 
 import (
 	"io"
+	"io/fs"
 	"os"
 	pathpkg "path"
 	"path/filepath"
@@ -150,7 +151,8 @@ type Config struct {
 
 	// Dir is the directory in which to run the build system's query tool
 	// that provides information about the packages.
-	// If Dir is empty, the tool is run in the current directory.
+	// If Dir is empty, the tool is run in the current directory, or in the
+	// root of FS if it is set.
 	Dir string
 
 	// Tags defines boolean tags or key-value pairs to select files to build
@@ -274,6 +276,35 @@ type Config struct {
 	// the corresponding build.File will be associated with the full buffer.
 	Stdin io.Reader
 
+	// FS, if non-nil, provides the files to load instead of the host file
+	// system, like an embed.FS, zip.Reader, or fstest.MapFS. The root of FS
+	// corresponds to the absolute path "/", so absolute paths in Dir,
+	// ModuleRoot, and Overlay refer to files within FS. Files in Overlay
+	// take precedence over files in FS.
+	//
+	// Modules fetched from Registry are still read from the module cache.
+	FS fs.FS
+
+	// Registry is used to fetch the modules listed in the deps field of the
+	// module file, which maps module paths to versions:
+	//
+	//     module: "example.com/foo"
+	//     deps: "example.com/bar": v: "v1.2.0"
+	//
+	// Imports of packages within these modules are resolved to a copy of the
+	// module in the module cache, located in the directory CUE_CACHE_DIR or
+	// in the user's cache directory otherwise. The checksum of each module
+	// must be listed in the cue.mod/cue.sum file of the main module. Modules
+	// required by these modules must be listed in the deps field as well.
+	//
+	// If Registry is nil, these imports are resolved to the cue.mod
+	// directory, like the imports of other external packages.
+	Registry Registry
+
+	// deps maps the paths of the modules listed in the module file to
+	// their versions.
+	deps map[string]string
+
 	fileSystem
 
 	loadFunc build.LoadFunc
@@ -470,6 +501,14 @@ func (c *Config) absDirFromImportPath(pos token.Pos, p importPath) (absDir, name
 
 	default:
 		absDir = filepath.Join(GenPath(c.ModuleRoot), sub)
+
+		if d, ok := c.dependencyFor(string(p)); ok && c.Registry != nil {
+			dir, ferr := c.moduleDir(pos, d)
+			if ferr != nil {
+				return absDir, name, errors.Append(err, ferr)
+			}
+			absDir = filepath.Join(dir, filepath.FromSlash(string(p[len(d.Path):])))
+		}
 	}
 
 	return absDir, name, err
@@ -490,13 +529,18 @@ func (c Config) complete() (cfg *Config, err error) {
 	// (perhaps it is the stub to use in that case) should say "+build !cue1.x".
 	c.releaseTags = []string{"cue0.1"}
 
-	if c.Dir == "" {
+	switch {
+	case c.FS != nil:
+		c.Dir = filepath.Join(string(filepath.Separator), c.Dir)
+	case c.Dir == "":
 		c.Dir, err = os.Getwd()
 		if err != nil {
 			return nil, err
 		}
-	} else if c.Dir, err = filepath.Abs(c.Dir); err != nil {
-		return nil, err
+	default:
+		if c.Dir, err = filepath.Abs(c.Dir); err != nil {
+			return nil, err
+		}
 	}
 
 	// TODO: we could populate this already with absolute file paths,
@@ -519,6 +563,7 @@ func (c Config) complete() (cfg *Config, err error) {
 	c.loader = &loader{
 		cfg:       &c,
 		buildTags: make(map[string]bool),
+		modules:   make(map[ModuleVersion]string),
 	}
 
 	// TODO: also make this work if run from outside the module?
@@ -566,6 +611,26 @@ func (c Config) complete() (cfg *Config, err error) {
 			}
 			c.Module = name
 		}
+
+		if deps := v.Lookup(ctx.StringLabel("deps")); deps != nil {
+			c.deps = map[string]string{}
+			for _, a := range deps.Arcs {
+				version := a.Lookup(ctx.StringLabel("v"))
+				if version == nil {
+					return &c, errors.Newf(token.NoPos,
+						"invalid cue.mod file: no version for dependency %s",
+						a.Label.StringValue(ctx))
+				}
+				path := a.Label.StringValue(ctx)
+				c.deps[path] = ctx.StringValue(version.Value())
+				if err := ctx.Err(); err != nil {
+					return &c, err.Err
+				}
+				if err := checkModuleVersion(path, c.deps[path]); err != nil {
+					return &c, errors.Newf(token.NoPos, "invalid cue.mod file: %v", err)
+				}
+			}
+		}
 	}
 
 	c.loadFunc = c.loader.loadFunc()
@@ -17,6 +17,7 @@ package load
 import (
 	"bytes"
 	"io"
+	iofs "io/fs"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -48,6 +49,11 @@ func (f *overlayFile) Sys() interface{}   { return nil }
 type fileSystem struct {
 	overlayDirs map[string]map[string]*overlayFile
 	cwd         string
+
+	// fsys, if non-nil, is used instead of the host file system, except
+	// for the directories in hostDirs.
+	fsys     iofs.FS
+	hostDirs []string
 }
 
 func (fs *fileSystem) getDir(dir string, create bool) map[string]*overlayFile {
@@ -62,6 +68,7 @@ func (fs *fileSystem) getDir(dir string, create bool) map[string]*overlayFile {
 
 func (fs *fileSystem) init(c *Config) error {
 	fs.cwd = c.Dir
+	fs.fsys = c.FS
 
 	overlay := c.Overlay
 	fs.overlayDirs = map[string]map[string]*overlayFile{}
@@ -127,13 +134,13 @@ func (fs *fileSystem) isDir(path string) bool {
 	if fs.getDir(path, false) != nil {
 		return true
 	}
-	fi, err := os.Stat(path)
+	fi, err := fs.hostStat(path)
 	return err == nil && fi.IsDir()
 }
 
 func (fs *fileSystem) hasSubdir(root, dir string) (rel string, ok bool) {
 	// Try using paths we received.
-	if rel, ok = hasSubdir(root, dir); ok {
+	if rel, ok = hasSubdir(root, dir); ok || fs.fsys != nil {
 		return
 	}
 
@@ -168,7 +175,7 @@ func hasSubdir(root, dir string) (rel string, ok bool) {
 func (fs *fileSystem) readDir(path string) ([]os.FileInfo, errors.Error) {
 	path = fs.makeAbs(path)
 	m := fs.getDir(path, false)
-	items, err := ioutil.ReadDir(path)
+	items, err := fs.hostReadDir(path)
 	if err != nil {
 		if !os.IsNotExist(err) || m == nil {
 			return nil, errors.Wrapf(err, token.NoPos, "readDir")
@@ -207,7 +214,7 @@ func (fs *fileSystem) stat(path string) (os.FileInfo, errors.Error) {
 	if fi := fs.getOverlay(path); fi != nil {
 		return fi, nil
 	}
-	fi, err := os.Stat(path)
+	fi, err := fs.hostStat(path)
 	if err != nil {
 		return nil, errors.Wrapf(err, token.NoPos, "stat")
 	}
@@ -219,7 +226,7 @@ func (fs *fileSystem) lstat(path string) (os.FileInfo, errors.Error) {
 	if fi := fs.getOverlay(path); fi != nil {
 		return fi, nil
 	}
-	fi, err := os.Lstat(path)
+	fi, err := fs.hostLstat(path)
 	if err != nil {
 		return nil, errors.Wrapf(err, token.NoPos, "stat")
 	}
@@ -232,13 +239,94 @@ func (fs *fileSystem) openFile(path string) (io.ReadCloser, errors.Error) {
 		return ioutil.NopCloser(bytes.NewReader(fi.contents)), nil
 	}
 
-	f, err := os.Open(path)
+	f, err := fs.hostOpen(path)
 	if err != nil {
 		return nil, errors.Wrapf(err, token.NoPos, "load")
 	}
 	return f, nil
 }
 
+// fsName reports the name in fs.fsys of the file with the given absolute
+// path, or false if the file is to be read from the host file system.
+func (fs *fileSystem) fsName(path string) (name string, ok bool) {
+	if fs.fsys == nil {
+		return "", false
+	}
+	for _, dir := range fs.hostDirs {
+		if path == dir {
+			return "", false
+		}
+		if _, ok := hasSubdir(dir, path); ok {
+			return "", false
+		}
+	}
+	path = path[len(filepath.VolumeName(path)):]
+	name = strings.Trim(filepath.ToSlash(path), "/")
+	if name == "" {
+		name = "."
+	}
+	return name, true
+}
+
+// readFS returns the contents of the file with the given path if it is to be
+// read from fs.fsys.
+func (fs *fileSystem) readFS(path string) (b []byte, ok bool, err errors.Error) {
+	if path == "-" {
+		return nil, false, nil
+	}
+	name, ok := fs.fsName(fs.makeAbs(path))
+	if !ok {
+		return nil, false, nil
+	}
+	b, rerr := iofs.ReadFile(fs.fsys, name)
+	if rerr != nil {
+		return nil, true, errors.Wrapf(rerr, token.NoPos, "load")
+	}
+	return b, true, nil
+}
+
+func (fs *fileSystem) hostStat(path string) (os.FileInfo, error) {
+	if name, ok := fs.fsName(path); ok {
+		return iofs.Stat(fs.fsys, name)
+	}
+	return os.Stat(path)
+}
+
+func (fs *fileSystem) hostLstat(path string) (os.FileInfo, error) {
+	if name, ok := fs.fsName(path); ok {
+		// An fs.FS does not expose symbolic links.
+		return iofs.Stat(fs.fsys, name)
+	}
+	return os.Lstat(path)
+}
+
+func (fs *fileSystem) hostReadDir(path string) ([]os.FileInfo, error) {
+	name, ok := fs.fsName(path)
+	if !ok {
+		return ioutil.ReadDir(path)
+	}
+	entries, err := iofs.ReadDir(fs.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]os.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		fi, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, fi)
+	}
+	return items, nil
+}
+
+func (fs *fileSystem) hostOpen(path string) (io.ReadCloser, error) {
+	if name, ok := fs.fsName(path); ok {
+		return fs.fsys.Open(name)
+	}
+	return os.Open(path)
+}
+
 var skipDir = errors.Newf(token.NoPos, "skip directory")
 
 type walkFunc func(path string, info os.FileInfo, err errors.Error) errors.Error
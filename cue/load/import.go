@@ -92,7 +92,12 @@ func (l *loader) importPkg(pos token.Pos, p *build.Instance) []*build.Instance {
 		return []*build.Instance{p}
 	}
 
-	if !strings.HasPrefix(p.Dir, cfg.ModuleRoot) {
+	root := cfg.ModuleRoot
+	if dir, ok := l.fetchedModuleFor(p.Dir); ok {
+		root = dir
+	}
+
+	if !strings.HasPrefix(p.Dir, root) {
 		err := errors.Newf(token.NoPos, "module root not defined", p.DisplayPath)
 		return retErr(err)
 	}
@@ -113,7 +118,7 @@ func (l *loader) importPkg(pos token.Pos, p *build.Instance) []*build.Instance {
 		fp.ignoreOther = true
 	}
 
-	if !strings.HasPrefix(p.Dir, cfg.ModuleRoot) {
+	if !strings.HasPrefix(p.Dir, root) {
 		panic("")
 	}
 
@@ -137,7 +142,7 @@ func (l *loader) importPkg(pos token.Pos, p *build.Instance) []*build.Instance {
 			}
 		}
 	} else {
-		dirs = append(dirs, [2]string{cfg.ModuleRoot, p.Dir})
+		dirs = append(dirs, [2]string{root, p.Dir})
 	}
 
 	found := false
@@ -139,6 +139,9 @@ type loader struct {
 	tags         []*tag // tags found in files
 	buildTags    map[string]bool
 	replacements map[ast.Node]ast.Node
+
+	// modules maps the fetched dependencies to their directories.
+	modules map[ModuleVersion]string
 }
 
 func (l *loader) abs(filename string) string {
@@ -21,6 +21,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"text/template"
 	"unicode"
 
@@ -401,3 +402,70 @@ func TestOverlays(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cue.mod/module.cue": {Data: []byte(`module: "acme.com"`)},
+		"dir/top.cue": {Data: []byte(`
+			package top
+
+			import "acme.com/dir/b:foo"
+
+			msg: "Hello"
+			a:   foo.a
+		`)},
+		"dir/b/foo.cue": {Data: []byte(`
+			package foo
+
+			a: <= 5
+		`)},
+		"dir/b/bar.cue": {Data: []byte(`
+			package foo
+
+			a: >= 5
+		`)},
+	}
+	testCases := []struct {
+		dir  string
+		args []string
+		want []string
+	}{{
+		args: []string{"./dir/..."},
+		want: []string{`{msg:"Hello"a:5}`, `{a:5}`},
+	}, {
+		dir:  "dir",
+		want: []string{`{msg:"Hello"a:5}`},
+	}, {
+		dir:  "/dir/b",
+		args: []string{"../b"},
+		want: []string{`{a:5}`},
+	}}
+	rmSpace := func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}
+	for _, tc := range testCases {
+		c := &Config{FS: fsys, Dir: tc.dir}
+		insts := cue.Build(Instances(tc.args, c))
+		if len(insts) != len(tc.want) {
+			t.Errorf("%s: got %d instances; want %d", tc.dir, len(insts), len(tc.want))
+			continue
+		}
+		for i, inst := range insts {
+			if inst.Err != nil {
+				t.Error(inst.Err)
+				continue
+			}
+			b, err := format.Node(inst.Value().Syntax(cue.Final()))
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			if got := string(bytes.Map(rmSpace, b)); got != tc.want[i] {
+				t.Errorf("%s: got %s; want %s", inst.Dir, got, tc.want[i])
+			}
+		}
+	}
+}
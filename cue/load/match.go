@@ -48,6 +48,12 @@ func matchFile(cfg *Config, file *build.File, returnImports, allFiles bool, allT
 		} else {
 			file.Source = fi.contents
 		}
+	} else if b, ok, err := cfg.fileSystem.readFS(file.Filename); ok {
+		// Files in an fs.FS cannot be read by name later on.
+		if err != nil {
+			return false, nil, err
+		}
+		file.Source = b
 	}
 
 	if file.Encoding != build.CUE {
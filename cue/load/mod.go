@@ -0,0 +1,345 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
+)
+
+// Dependencies reports the modules listed in the deps field of the module
+// file of the main module, sorted by path.
+func Dependencies(c *Config) ([]ModuleVersion, error) {
+	cfg, err := c.complete()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.dependencies(), nil
+}
+
+func (c *Config) dependencies() []ModuleVersion {
+	a := []ModuleVersion{}
+	for path, version := range c.deps {
+		a = append(a, ModuleVersion{path, version})
+	}
+	sort.Slice(a, func(i, j int) bool { return a[i].Path < a[j].Path })
+	return a
+}
+
+// Get adds the module providing the package with the given import path at
+// the given version to the dependencies of the main module, replacing any
+// other version of this module. The module path is the longest prefix of the
+// import path for which the registry of c has a module at this version. Its
+// checksum is added to the sum file.
+//
+// Get fails if the sum file already lists a different checksum for the module.
+func Get(c *Config, importPath, version string) (ModuleVersion, error) {
+	cfg, err := c.mainModule()
+	if err != nil {
+		return ModuleVersion{}, err
+	}
+	if err := checkModuleVersion(importPath, version); err != nil {
+		return ModuleVersion{}, err
+	}
+
+	sums, err := cfg.readSums()
+	if err != nil {
+		return ModuleVersion{}, err
+	}
+
+	var m ModuleVersion
+	var b []byte
+	var firstErr error
+	for p := importPath; ; p = path.Dir(p) {
+		m = ModuleVersion{p, version}
+		if b, err = cfg.download(m, sums[m]); err == nil {
+			break
+		}
+		if _, ok := sums[m]; ok {
+			// The sum file identifies this module, so its contents must match.
+			return ModuleVersion{}, fmt.Errorf("cannot fetch %s: %v", m, err)
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if !strings.Contains(p, "/") {
+			return ModuleVersion{}, fmt.Errorf("cannot fetch %s@%s: %v", importPath, version, firstErr)
+		}
+	}
+
+	deps := map[string]string{m.Path: m.Version}
+	for p, v := range cfg.deps {
+		if p != m.Path {
+			deps[p] = v
+		}
+	}
+	sums[m] = "sha256:" + sha256Hex(b)
+
+	if err := cfg.writeSums(deps, sums); err != nil {
+		return ModuleVersion{}, err
+	}
+	if err := cfg.writeDeps(deps); err != nil {
+		return ModuleVersion{}, err
+	}
+	return m, nil
+}
+
+// Tidy removes the modules from the dependencies of the main module that
+// provide none of the packages imported by the main module, directly or
+// indirectly, and updates the sum file to list exactly the checksums of the
+// remaining modules. It returns the remaining modules.
+//
+// Tidy fails if a checksum listed in the sum file does not match the module
+// provided by the registry.
+func Tidy(c *Config) ([]ModuleVersion, error) {
+	cfg, err := c.mainModule()
+	if err != nil {
+		return nil, err
+	}
+
+	sums, err := cfg.readSums()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range cfg.dependencies() {
+		b, err := cfg.download(d, sums[d])
+		if err != nil {
+			return nil, fmt.Errorf("cannot fetch module %s: %v", d, err)
+		}
+		sums[d] = "sha256:" + sha256Hex(b)
+	}
+	if err := cfg.writeSums(cfg.deps, sums); err != nil {
+		return nil, err
+	}
+
+	lc := *c
+	lc.Dir = cfg.ModuleRoot
+	lc.Package = "*"
+	lc.Tests = true
+	lc.Tools = true
+	used := map[string]string{}
+	visited := map[string]bool{}
+	var visit func(inst *build.Instance) error
+	visit = func(inst *build.Instance) error {
+		if inst.Err != nil {
+			return inst.Err
+		}
+		for _, path := range inst.ImportPaths {
+			if visited[path] {
+				continue
+			}
+			visited[path] = true
+			if d, ok := cfg.dependencyFor(path); ok {
+				used[d.Path] = d.Version
+			}
+			pos := token.NoPos
+			if a := inst.ImportPos[path]; len(a) > 0 {
+				pos = a[0]
+			}
+			if imp := cfg.loadFunc(pos, path); imp != nil {
+				if err := visit(imp); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	for _, inst := range Instances([]string{"./..."}, &lc) {
+		if err := visit(inst); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.writeSums(used, sums); err != nil {
+		return nil, err
+	}
+	if err := cfg.writeDeps(used); err != nil {
+		return nil, err
+	}
+	cfg.deps = used
+	return cfg.dependencies(), nil
+}
+
+// Vendor copies the contents of the modules listed as dependencies of the
+// main module to the directory cue.mod/pkg/<module path>. With these copies
+// in place, the main module can be loaded without a registry.
+func Vendor(c *Config) error {
+	cfg, err := c.mainModule()
+	if err != nil {
+		return err
+	}
+	for _, d := range cfg.dependencies() {
+		dir, err := cfg.moduleDir(token.NoPos, d)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(cfg.ModuleRoot, modDir, "pkg", filepath.FromSlash(d.Path))
+		if err := os.RemoveAll(dst); err != nil {
+			return err
+		}
+		if err := copyDir(dst, dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mainModule completes c and checks that it defines a module with a cue.mod
+// directory.
+func (c *Config) mainModule() (*Config, error) {
+	cfg, err := c.complete()
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(filepath.Join(cfg.ModuleRoot, modDir))
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("no cue.mod directory found in %s or its parents", cfg.Dir)
+	}
+	return cfg, nil
+}
+
+func (c *Config) sumFile() string {
+	return filepath.Join(c.ModuleRoot, modDir, sumFile)
+}
+
+// readSums reads the checksums of the sum file of the main module. Each line
+// of a sum file consists of a module path, version, and checksum, separated
+// by spaces.
+func (c *Config) readSums() (map[ModuleVersion]string, error) {
+	filename := c.sumFile()
+	sums := map[ModuleVersion]string{}
+	if _, err := c.fileSystem.stat(filename); err != nil {
+		return sums, nil
+	}
+	f, ferr := c.fileSystem.openFile(filename)
+	if ferr != nil {
+		return nil, ferr
+	}
+	defer f.Close()
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	for i, line := range strings.Split(string(b), "\n") {
+		fields := strings.Fields(line)
+		switch len(fields) {
+		case 0:
+		case 3:
+			sums[ModuleVersion{fields[0], fields[1]}] = fields[2]
+		default:
+			return nil, fmt.Errorf("%s:%d: malformed line", filename, i+1)
+		}
+	}
+	return sums, nil
+}
+
+// writeSums writes the checksums of the modules in deps to the sum file of the
+// main module.
+func (c *Config) writeSums(deps map[string]string, sums map[ModuleVersion]string) error {
+	var buf bytes.Buffer
+	for _, d := range (&Config{deps: deps}).dependencies() {
+		if sum, ok := sums[d]; ok {
+			fmt.Fprintf(&buf, "%s %s %s\n", d.Path, d.Version, sum)
+		}
+	}
+	filename := c.sumFile()
+	if buf.Len() == 0 {
+		if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return ioutil.WriteFile(filename, buf.Bytes(), 0666)
+}
+
+// writeDeps sets the deps field of the module file of the main module to
+// deps, leaving the other declarations intact.
+func (c *Config) writeDeps(deps map[string]string) error {
+	filename := filepath.Join(c.ModuleRoot, modDir, configFile)
+	src, err := ioutil.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	f, err := parser.ParseFile(filename, src, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	k := 0
+	for _, d := range f.Decls {
+		if field, ok := d.(*ast.Field); ok {
+			if name, _, _ := ast.LabelName(field.Label); name == "deps" {
+				continue
+			}
+		}
+		f.Decls[k] = d
+		k++
+	}
+	f.Decls = f.Decls[:k]
+
+	if len(deps) > 0 {
+		s := &ast.StructLit{Lbrace: token.Blank.Pos(), Rbrace: token.Newline.Pos()}
+		for _, d := range (&Config{deps: deps}).dependencies() {
+			v := ast.NewStruct(ast.NewIdent("v"), ast.NewString(d.Version))
+			v.Lbrace = token.NoPos
+			field := &ast.Field{Label: ast.NewString(d.Path), Value: v}
+			ast.SetRelPos(field, token.Newline)
+			s.Elts = append(s.Elts, field)
+		}
+		field := &ast.Field{Label: ast.NewIdent("deps"), Value: s}
+		ast.SetRelPos(field, token.NewSection)
+		f.Decls = append(f.Decls, field)
+	}
+
+	b, err := format.Node(f)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, b, 0666)
+}
+
+// copyDir copies the files in the directory tree src to dst.
+func copyDir(dst, src string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0777)
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, b, 0666)
+	})
+}
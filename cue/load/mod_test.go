@@ -0,0 +1,171 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+)
+
+func TestModCommands(t *testing.T) {
+	modules := map[string][]byte{
+		"/example.com/bar/@v/v1.0.0.zip": makeZip(t, map[string]string{
+			"sub/sub.cue": "package sub\n\nimport \"example.com/qux\"\n\ny: qux.v",
+		}),
+		"/example.com/qux/@v/v0.2.0.zip": makeZip(t, map[string]string{
+			"qux.cue": "package qux\n\nv: 2",
+		}),
+		"/example.com/baz/@v/v0.1.0.zip": makeZip(t, map[string]string{
+			"baz.cue": "package baz\n\nw: 3",
+		}),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, ok := modules[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(b)
+	}))
+	defer srv.Close()
+
+	tmp, err := ioutil.TempDir("", "cue-mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	defer os.Setenv("CUE_CACHE_DIR", os.Getenv("CUE_CACHE_DIR"))
+	os.Setenv("CUE_CACHE_DIR", filepath.Join(tmp, "cache"))
+
+	dir := filepath.Join(tmp, "foo")
+	writeFiles(t, dir, map[string]string{
+		"cue.mod/module.cue": "// The foo module.\n\nmodule: \"example.com/foo\"\n",
+		"foo.cue":            "package foo\n\nimport \"example.com/bar/sub\"\n\nz: sub.y\n",
+	})
+	cfg := &Config{Dir: dir, Registry: NewHTTPRegistry(srv.URL)}
+
+	checkFile := func(name, want string) {
+		t.Helper()
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(b); got != want {
+			t.Errorf("%s:\ngot:\n%s\nwant:\n%s", name, got, want)
+		}
+	}
+	checkDeps := func(got []ModuleVersion, want ...ModuleVersion) {
+		t.Helper()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v; want %v", got, want)
+		}
+	}
+	bar := ModuleVersion{"example.com/bar", "v1.0.0"}
+	baz := ModuleVersion{"example.com/baz", "v0.1.0"}
+	qux := ModuleVersion{"example.com/qux", "v0.2.0"}
+
+	m, err := Get(cfg, "example.com/bar/sub", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDeps([]ModuleVersion{m}, bar)
+
+	if _, err := Get(cfg, "example.com/quux", "v1.0.0"); err == nil {
+		t.Error("expected error for unknown module")
+	}
+
+	m, err = Get(cfg, "example.com/qux", "v0.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDeps([]ModuleVersion{m}, qux)
+
+	m, err = Get(cfg, "example.com/baz", "v0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDeps([]ModuleVersion{m}, baz)
+
+	deps, err := Dependencies(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDeps(deps, bar, baz, qux)
+	checkFile("cue.mod/module.cue", `// The foo module.
+
+module: "example.com/foo"
+
+deps: {
+	"example.com/bar": v: "v1.0.0"
+	"example.com/baz": v: "v0.1.0"
+	"example.com/qux": v: "v0.2.0"
+}
+`)
+	checkFile("cue.mod/cue.sum", ""+
+		"example.com/bar v1.0.0 sha256:"+sha256Hex(modules["/example.com/bar/@v/v1.0.0.zip"])+"\n"+
+		"example.com/baz v0.1.0 sha256:"+sha256Hex(modules["/example.com/baz/@v/v0.1.0.zip"])+"\n"+
+		"example.com/qux v0.2.0 sha256:"+sha256Hex(modules["/example.com/qux/@v/v0.2.0.zip"])+"\n")
+
+	deps, err = Tidy(cfg)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	checkDeps(deps, bar, qux)
+	checkFile("cue.mod/module.cue", `// The foo module.
+
+module: "example.com/foo"
+
+deps: {
+	"example.com/bar": v: "v1.0.0"
+	"example.com/qux": v: "v0.2.0"
+}
+`)
+	checkFile("cue.mod/cue.sum", ""+
+		"example.com/bar v1.0.0 sha256:"+sha256Hex(modules["/example.com/bar/@v/v1.0.0.zip"])+"\n"+
+		"example.com/qux v0.2.0 sha256:"+sha256Hex(modules["/example.com/qux/@v/v0.2.0.zip"])+"\n")
+
+	if err := Vendor(cfg); err != nil {
+		t.Fatal(err)
+	}
+	checkFile("cue.mod/pkg/example.com/qux/qux.cue", "package qux\n\nv: 2")
+
+	insts := Instances(nil, &Config{Dir: dir})
+	if err := insts[0].Err; err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	v := cue.Build(insts)[0].Value()
+	if z, err := v.LookupPath(cue.ParsePath("z")).Int64(); err != nil || z != 2 {
+		t.Errorf("loading vendored module: got %d, %v; want 2", z, err)
+	}
+
+	// Get does not replace an existing checksum.
+	tampered := "example.com/bar v1.0.0 sha256:0000\n"
+	writeFiles(t, dir, map[string]string{"cue.mod/cue.sum": tampered})
+	_, err = Get(cfg, "example.com/bar/sub", "v1.0.0")
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("got error %v; want checksum mismatch", err)
+	}
+	checkFile("cue.mod/cue.sum", tampered)
+}
@@ -0,0 +1,444 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// sumFile is the name of the file within the cue.mod directory that holds the
+// checksums of the modules listed as dependencies.
+const sumFile = "cue.sum"
+
+// A Registry provides the contents of versioned modules.
+type Registry interface {
+	// Fetch returns the contents of the module with the given path and
+	// version as a zip archive. The files of the module are at the root of
+	// the archive.
+	Fetch(path, version string) ([]byte, error)
+}
+
+// NewHTTPRegistry returns a Registry that fetches modules from an HTTPS
+// server. The contents of module path at version are retrieved from
+//
+//     url/path/@v/version.zip
+//
+func NewHTTPRegistry(url string) Registry {
+	return &httpRegistry{url: strings.TrimSuffix(url, "/")}
+}
+
+type httpRegistry struct {
+	url string
+}
+
+func (r *httpRegistry) Fetch(path, version string) ([]byte, error) {
+	return get(fmt.Sprintf("%s/%s/@v/%s.zip", r.url, path, version), "")
+}
+
+// NewOCIRegistry returns a Registry that fetches modules from an OCI
+// registry at host, which may include a scheme. The default scheme is https.
+// A module is stored in the repository named after its path and tagged with
+// its version. The first layer of its manifest holds the zip archive of the
+// module.
+//
+// Only anonymous access is supported.
+func NewOCIRegistry(host string) Registry {
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	return &ociRegistry{url: strings.TrimSuffix(host, "/")}
+}
+
+type ociRegistry struct {
+	url string
+}
+
+const ociManifest = "application/vnd.oci.image.manifest.v1+json"
+
+func (r *ociRegistry) Fetch(path, version string) ([]byte, error) {
+	repo := strings.ToLower(path)
+	b, err := get(fmt.Sprintf("%s/v2/%s/manifests/%s", r.url, repo, version), ociManifest)
+	if err != nil {
+		return nil, err
+	}
+	var m struct {
+		Layers []struct {
+			Digest string `json:"digest"`
+		} `json:"layers"`
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest for %s@%s: %v", path, version, err)
+	}
+	if len(m.Layers) == 0 {
+		return nil, fmt.Errorf("manifest for %s@%s has no layers", path, version)
+	}
+	digest := m.Layers[0].Digest
+	b, err = get(fmt.Sprintf("%s/v2/%s/blobs/%s", r.url, repo, digest), "")
+	if err != nil {
+		return nil, err
+	}
+	if got := "sha256:" + sha256Hex(b); got != digest {
+		return nil, fmt.Errorf("digest mismatch for %s@%s: got %s, want %s", path, version, got, digest)
+	}
+	return b, nil
+}
+
+func get(url, accept string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// A ModuleVersion identifies a version of a module.
+type ModuleVersion struct {
+	Path    string
+	Version string
+}
+
+func (m ModuleVersion) String() string { return m.Path + "@" + m.Version }
+
+// dependencyFor returns the dependency that provides the package with import
+// path p.
+func (c *Config) dependencyFor(p string) (d ModuleVersion, ok bool) {
+	for path, version := range c.deps {
+		if (p == path || strings.HasPrefix(p, path+"/")) && len(path) > len(d.Path) {
+			d = ModuleVersion{path, version}
+		}
+	}
+	return d, d.Path != ""
+}
+
+// cacheDir returns the directory in which fetched modules are stored.
+func cacheDir() (string, error) {
+	if dir := os.Getenv("CUE_CACHE_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine cache directory; set CUE_CACHE_DIR: %v", err)
+	}
+	return filepath.Join(dir, "cue"), nil
+}
+
+// moduleDir returns the directory holding the contents of the dependency d,
+// fetching it from the registry if it is not in the cache.
+func (c *Config) moduleDir(pos token.Pos, d ModuleVersion) (string, errors.Error) {
+	if dir, ok := c.loader.modules[d]; ok {
+		return dir, nil
+	}
+	dir, err := c.fetchModule(d)
+	if err != nil {
+		return "", errors.Newf(pos, "cannot fetch module %s: %v", d, err)
+	}
+	c.loader.modules[d] = dir
+	c.fileSystem.hostDirs = append(c.fileSystem.hostDirs, dir)
+	return dir, nil
+}
+
+func (c *Config) fetchModule(d ModuleVersion) (string, error) {
+	sums, err := c.readSums()
+	if err != nil {
+		return "", err
+	}
+	sum, ok := sums[d]
+	if !ok {
+		return "", fmt.Errorf("missing checksum in %s; run 'cue mod tidy' to add it",
+			c.sumFile())
+	}
+	b, err := c.download(d, sum)
+	if err != nil {
+		return "", err
+	}
+
+	root, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return "", err
+	}
+	if err := checkZip(r); err != nil {
+		return "", err
+	}
+	dir := filepath.Join(root, "mod", "extract", filepath.FromSlash(d.Path)+"@"+d.Version)
+	if _, err := os.Stat(dir); err != nil {
+		if err := extractZip(dir, r); err != nil {
+			return "", err
+		}
+	}
+	// The directory may have been modified since it was extracted, or may
+	// have been extracted by another process.
+	if err := verifyDir(dir, r); err != nil {
+		return "", fmt.Errorf("%v; remove %s to extract %s again", err, dir, d)
+	}
+	return dir, nil
+}
+
+// download returns the zip archive of the module d from the cache, fetching
+// it from the registry if needed. If sum is not empty, the archive must have
+// this checksum. Archives fetched from the registry are added to the cache
+// only after they have been verified.
+func (c *Config) download(d ModuleVersion, sum string) ([]byte, error) {
+	if err := checkModuleVersion(d.Path, d.Version); err != nil {
+		return nil, err
+	}
+	root, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	zipFile := filepath.Join(root, "mod", "download", filepath.FromSlash(d.Path), "@v", d.Version+".zip")
+
+	b, err := ioutil.ReadFile(zipFile)
+	if err == nil {
+		return b, verifySum(d, b, sum)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if c.Registry == nil {
+		return nil, fmt.Errorf("no registry configured")
+	}
+	b, err = c.Registry.Fetch(d.Path, d.Version)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySum(d, b, sum); err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(zipFile, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// verifySum checks the contents b of the module d against the checksum sum,
+// if it is not empty.
+func verifySum(d ModuleVersion, b []byte, sum string) error {
+	if got := "sha256:" + sha256Hex(b); sum != "" && got != sum {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", d, got, sum)
+	}
+	return nil
+}
+
+// checkModuleVersion reports whether path and version can be used to locate
+// a module. Every element of path must be a non-empty name other than . and
+// .. that contains no backslash, and version must be a semantic version of
+// the form vMAJOR.MINOR.PATCH, optionally followed by a pre-release suffix.
+func checkModuleVersion(path, version string) error {
+	for _, e := range strings.Split(path, "/") {
+		if e == "" || e == "." || e == ".." || strings.Contains(e, "\\") {
+			return fmt.Errorf("invalid module path %q", path)
+		}
+	}
+	if !semver.IsValid(version) || semver.Canonical(version) != version {
+		return fmt.Errorf("invalid version %q for module %s: must be of the form vMAJOR.MINOR.PATCH",
+			version, path)
+	}
+	return nil
+}
+
+func writeFileAtomic(filename string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// checkZip reports whether the names of the files in r are valid file paths
+// that are safe to extract on any operating system. Names may not be
+// absolute, contain .. elements, backslashes, or drive letters, or name the
+// same file more than once.
+func checkZip(r *zip.Reader) error {
+	seen := map[string]bool{}
+	for _, f := range r.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		if err := module.CheckFilePath(f.Name); err != nil {
+			return fmt.Errorf("invalid file name %q in module archive: %v", f.Name, err)
+		}
+		key := strings.ToLower(f.Name)
+		if seen[key] {
+			return fmt.Errorf("duplicate file name %q in module archive", f.Name)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// extractZip extracts the files of r, which must have been checked with
+// checkZip, into dir. The archive is first extracted into a temporary
+// directory so that dir is either complete or absent. If dir was created
+// concurrently, it is left as is.
+func extractZip(dir string, r *zip.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0777); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempDir(filepath.Dir(dir), filepath.Base(dir)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, f := range r.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		dst := filepath.Join(tmp, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+			return err
+		}
+		if err := extractFile(dst, f); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		if _, serr := os.Stat(dir); serr == nil {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// verifyDir reports whether dir holds exactly the files of r.
+func verifyDir(dir string, r *zip.Reader) error {
+	want := map[string]*zip.File{}
+	for _, f := range r.File {
+		if !strings.HasSuffix(f.Name, "/") {
+			want[filepath.FromSlash(f.Name)] = f
+		}
+	}
+	mismatch := func(name string) error {
+		return fmt.Errorf("%s does not match the module archive",
+			filepath.Join(dir, name))
+	}
+	n := 0
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		f := want[name]
+		if f == nil || !info.Mode().IsRegular() {
+			return mismatch(name)
+		}
+		n++
+		got, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		b, err := ioutil.ReadAll(rc)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, b) {
+			return mismatch(name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if n != len(want) {
+		return fmt.Errorf("%s is missing files of the module archive", dir)
+	}
+	return nil
+}
+
+func extractFile(dst string, f *zip.File) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0444)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// fetchedModuleFor reports the directory of the fetched module containing
+// dir, if any.
+func (l *loader) fetchedModuleFor(dir string) (string, bool) {
+	for _, root := range l.modules {
+		if dir == root || strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			return root, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,324 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+)
+
+func makeZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, contents := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(contents))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, contents := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(contents), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	module := makeZip(t, map[string]string{
+		"cue.mod/module.cue": `module: "example.com/bar"`,
+		"bar.cue":            "package bar\n\nx: 1",
+		"sub/sub.cue":        "package sub\n\ny: 2",
+	})
+	sum := "sha256:" + sha256Hex(module)
+
+	blob := "sha256:" + sha256Hex(module)
+	testCases := []struct {
+		name    string
+		handler http.HandlerFunc
+		sum     string
+		err     string
+		newReg  func(url string) Registry
+	}{{
+		name: "http",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/example.com/bar/@v/v1.0.0.zip" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(module)
+		},
+		sum:    sum,
+		newReg: NewHTTPRegistry,
+	}, {
+		name: "oci",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v2/example.com/bar/manifests/v1.0.0":
+				fmt.Fprintf(w, `{"layers": [{"mediaType": "application/zip", "digest": %q}]}`, blob)
+			case "/v2/example.com/bar/blobs/" + blob:
+				w.Write(module)
+			default:
+				http.NotFound(w, r)
+			}
+		},
+		sum:    sum,
+		newReg: NewOCIRegistry,
+	}, {
+		name: "checksum mismatch",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Write(module)
+		},
+		sum:    "sha256:0000",
+		err:    "checksum mismatch",
+		newReg: NewHTTPRegistry,
+	}, {
+		name: "missing checksum",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Write(module)
+		},
+		err:    "missing checksum",
+		newReg: NewHTTPRegistry,
+	}, {
+		name:    "not found",
+		handler: http.NotFound,
+		sum:     sum,
+		err:     "404 Not Found",
+		newReg:  NewHTTPRegistry,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmp, err := ioutil.TempDir("", "cue-registry")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmp)
+
+			defer os.Setenv("CUE_CACHE_DIR", os.Getenv("CUE_CACHE_DIR"))
+			os.Setenv("CUE_CACHE_DIR", filepath.Join(tmp, "cache"))
+
+			dir := filepath.Join(tmp, "foo")
+			files := map[string]string{
+				"cue.mod/module.cue": `
+module: "example.com/foo"
+deps: "example.com/bar": v: "v1.0.0"
+`,
+				"foo.cue": `
+package foo
+
+import (
+	"example.com/bar"
+	"example.com/bar/sub"
+)
+
+z: bar.x + sub.y
+`,
+			}
+			if tc.sum != "" {
+				files["cue.mod/cue.sum"] = "example.com/bar v1.0.0 " + tc.sum + "\n"
+			}
+			writeFiles(t, dir, files)
+
+			srv := httptest.NewServer(tc.handler)
+			defer srv.Close()
+
+			load := func() (int64, error) {
+				insts := Instances(nil, &Config{
+					Dir:      dir,
+					Registry: tc.newReg(srv.URL),
+				})
+				if err := insts[0].Err; err != nil {
+					return 0, err
+				}
+				v := cue.Build(insts)[0].Value()
+				if err := v.Err(); err != nil {
+					return 0, err
+				}
+				return v.LookupPath(cue.ParsePath("z")).Int64()
+			}
+
+			z, err := load()
+			if tc.err != "" {
+				if err == nil || !strings.Contains(errors.Details(err, nil), tc.err) {
+					t.Fatalf("got error %v; want %q", err, tc.err)
+				}
+				// Modules are only cached after they have been verified.
+				if _, err := os.Stat(filepath.Join(tmp, "cache", "mod", "download")); err == nil {
+					t.Error("unverified module added to cache")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+			if z != 3 {
+				t.Errorf("got %d; want 3", z)
+			}
+
+			// The module is now loaded from the cache.
+			srv.Close()
+			if z, err = load(); err != nil || z != 3 {
+				t.Errorf("loading from cache: got %d, %v; want 3", z, err)
+			}
+		})
+	}
+}
+
+func TestInvalidDependency(t *testing.T) {
+	testCases := []struct {
+		deps string
+		err  string
+	}{{
+		deps: `"example.com/bar": v: "../../../../tmp/x"`,
+		err:  `invalid version "../../../../tmp/x" for module example.com/bar`,
+	}, {
+		deps: `"example.com/bar": v: "v1.0"`,
+		err:  `invalid version "v1.0" for module example.com/bar`,
+	}, {
+		deps: `"example.com/../../bar": v: "v1.0.0"`,
+		err:  `invalid module path "example.com/../../bar"`,
+	}, {
+		deps: `"example.com//bar": v: "v1.0.0"`,
+		err:  `invalid module path "example.com//bar"`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.err, func(t *testing.T) {
+			tmp, err := ioutil.TempDir("", "cue-registry")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmp)
+
+			writeFiles(t, tmp, map[string]string{
+				"cue.mod/module.cue": "module: \"example.com/foo\"\ndeps: " + tc.deps,
+				"foo.cue":            "package foo",
+			})
+			insts := Instances(nil, &Config{Dir: tmp})
+			err = insts[0].Err
+			if err == nil || !strings.Contains(errors.Details(err, nil), tc.err) {
+				t.Fatalf("got error %v; want %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestCheckZip(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  string
+	}{
+		{name: "a/b.cue"},
+		{name: "../a.cue", err: "invalid file name"},
+		{name: "/a.cue", err: "invalid file name"},
+		{name: `a\..\..\b.cue`, err: "invalid file name"},
+		{name: "c:/a.cue", err: "invalid file name"},
+		{name: "C:a.cue", err: "invalid file name"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := makeZip(t, map[string]string{tc.name: ""})
+			r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = checkZip(r)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("got error %v; want %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestExtractZip(t *testing.T) {
+	b := makeZip(t, map[string]string{
+		"a.cue":     "package a",
+		"sub/b.cue": "package b",
+	})
+	r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp, err := ioutil.TempDir("", "cue-registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	dir := filepath.Join(tmp, "m@v1.0.0")
+
+	if err := extractZip(dir, r); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyDir(dir, r); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another extraction that loses the race leaves dir as is.
+	if err := extractZip(dir, r); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyDir(dir, r); err != nil {
+		t.Fatal(err)
+	}
+
+	// Modified and added files are detected.
+	file := filepath.Join(dir, "sub", "b.cue")
+	os.Chmod(file, 0666)
+	if err := ioutil.WriteFile(file, []byte("package c"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyDir(dir, r); err == nil {
+		t.Error("modified file not detected")
+	}
+	if err := ioutil.WriteFile(file, []byte("package b"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dir, map[string]string{"c.cue": "package c"})
+	if err := verifyDir(dir, r); err == nil {
+		t.Error("added file not detected")
+	}
+	os.Remove(filepath.Join(dir, "c.cue"))
+	os.Remove(file)
+	if err := verifyDir(dir, r); err == nil {
+		t.Error("removed file not detected")
+	}
+}
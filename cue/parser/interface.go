@@ -69,6 +69,17 @@ var (
 	allowPartial        = func(p *parser) {
 		p.mode |= partialMode
 	}
+
+	// Recover causes the parser to recover from errors at declaration
+	// boundaries. A declaration that cannot be parsed is skipped up to the
+	// next comma or newline at the same nesting level and is represented
+	// by a BadDecl in the resulting File. Parsing does not stop after too
+	// many errors; all errors are reported. This is useful for tools, such
+	// as editors, that need to work with incomplete or incorrect sources.
+	Recover    Option = recoverOpt
+	recoverOpt        = func(p *parser) {
+		p.mode |= recoverMode | allErrorsMode
+	}
 )
 
 // FromVersion specifies until which legacy version the parser should provide
@@ -121,6 +132,7 @@ const (
 	traceMode             // print a trace of parsed productions
 	declarationErrorsMode // report declaration errors
 	allErrorsMode         // report all errors (not just the first 10 on different lines)
+	recoverMode           // recover from errors at declaration boundaries
 )
 
 // ParseFile parses the source code of a single CUE source file and returns
@@ -55,8 +55,10 @@ type parser struct {
 	// (used to limit the number of calls to syncXXX functions
 	// w/o making scanning progress - avoids potential endless
 	// loops across multiple parser functions during error recovery)
-	syncPos token.Pos // last synchronization position
-	syncCnt int       // number of calls to syncXXX without progress
+	syncPos token.Pos   // last synchronization position
+	syncCnt int         // number of calls to syncXXX without progress
+	errCnt  int         // number of errors reported, including discarded ones
+	lastTok token.Token // previous token
 
 	// Non-syntactic parser control
 	exprLev int // < 0: in control clause, >= 0: in expression
@@ -71,13 +73,16 @@ func (p *parser) init(filename string, src []byte, mode []Option) {
 	for _, f := range mode {
 		f(p)
 	}
-	p.file = token.NewFile(filename, p.offset, len(src))
+	if p.file == nil {
+		p.file = token.NewFile(filename, p.offset, len(src))
+	}
 
 	var m scanner.Mode
 	if p.mode&parseCommentsMode != 0 {
 		m = scanner.ScanComments
 	}
 	eh := func(pos token.Pos, msg string, args []interface{}) {
+		p.errCnt++
 		p.errors = errors.Append(p.errors, errors.Newf(pos, msg, args...))
 	}
 	p.scanner.Init(p.file, src, eh, m)
@@ -347,6 +352,7 @@ func (p *parser) next() {
 	}
 	p.leadComment = nil
 	prev := p.pos
+	p.lastTok = p.tok
 	p.next0()
 	p.comments.pos++
 
@@ -408,6 +414,7 @@ func (p *parser) assertV0(pos token.Pos, minor, patch int, name string) {
 func (p *parser) errf(pos token.Pos, msg string, args ...interface{}) {
 	// ePos := p.file.Position(pos)
 	ePos := pos
+	p.errCnt++
 
 	// If AllErrors is not set, discard errors reported on the same line
 	// as the last recorded error and stop parsing if there are more than
@@ -450,11 +457,26 @@ func (p *parser) expect(tok token.Token) token.Pos {
 	pos := p.pos
 	if p.tok != tok {
 		p.errorExpected(pos, "'"+tok.String()+"'")
+		pos = p.missingPos(len(tok.String()))
 	}
 	p.next() // make progress
 	return pos
 }
 
+// missingPos returns the position for a token of length n that is missing at
+// the current position. At the end of the file, the position is moved back so
+// that the end of the token lies within the file.
+func (p *parser) missingPos(n int) token.Pos {
+	off := p.file.Offset(p.pos)
+	if max := p.file.Size() - n; off > max {
+		off = max
+		if off < 0 {
+			off = 0
+		}
+	}
+	return p.file.Pos(off, p.pos.RelPos())
+}
+
 // expectClosing is like expect but provides a better error message
 // for the common case of a missing comma before a newline.
 func (p *parser) expectClosing(tok token.Token, context string) token.Pos {
@@ -560,6 +582,7 @@ func (p *parser) parseIdent() *ast.Ident {
 		name = p.lit
 		p.next()
 	} else {
+		pos = p.missingPos(len(name))
 		p.expect(token.IDENT) // use expect() error handling
 	}
 	ident := &ast.Ident{NamePos: pos, Name: name}
@@ -727,6 +750,41 @@ func (p *parser) consumeDeclComma() {
 	}
 }
 
+// recoverDecl is called after an error occurred while parsing the declaration
+// d starting at pos. If the parser did not reach the end of d, it skips the
+// remaining tokens up to the next comma at the same nesting level, or the
+// closing brace of the enclosing struct, and returns a BadDecl spanning the
+// declaration.
+func (p *parser) recoverDecl(pos token.Pos, d ast.Decl) ast.Decl {
+	if p.lastTok == token.COMMA || p.tok == token.RBRACE || p.tok == token.EOF {
+		return d
+	}
+	depth := 0
+	for p.tok != token.EOF {
+		switch p.tok {
+		case token.LBRACE, token.LBRACK, token.LPAREN:
+			depth++
+		case token.RBRACE:
+			if depth == 0 {
+				return &ast.BadDecl{From: pos, To: p.pos}
+			}
+			depth--
+		case token.RBRACK, token.RPAREN:
+			if depth > 0 {
+				depth--
+			}
+		case token.COMMA:
+			if depth == 0 {
+				to := p.pos
+				p.next()
+				return &ast.BadDecl{From: pos, To: to}
+			}
+		}
+		p.next()
+	}
+	return &ast.BadDecl{From: pos, To: p.pos}
+}
+
 func (p *parser) parseFieldList() (list []ast.Decl) {
 	if p.trace {
 		defer un(trace(p, "FieldList"))
@@ -749,7 +807,12 @@ func (p *parser) parseFieldList() (list []ast.Decl) {
 			p.consumeDeclComma()
 
 		default:
-			list = append(list, p.parseField())
+			pos, n := p.pos, p.errCnt
+			d := p.parseField()
+			if p.mode&recoverMode != 0 && p.errCnt > n {
+				d = p.recoverDecl(pos, d)
+			}
+			list = append(list, d)
 		}
 
 		// TODO: handle next comma here, after disallowing non-colon separator
@@ -1364,8 +1427,8 @@ L:
 				}
 				fallthrough
 			default:
-				pos := p.pos
-				p.errorExpected(pos, "selector")
+				p.errorExpected(p.pos, "selector")
+				pos := p.missingPos(1)
 				p.next() // make progress
 				x = &ast.SelectorExpr{X: x, Sel: &ast.Ident{NamePos: pos, Name: "_"}}
 			}
@@ -1476,11 +1539,15 @@ func (p *parser) parseInterpolation() (expr ast.Expr) {
 		exprs = append(exprs, p.parseRHS())
 
 		cc = p.openComments()
-		if p.tok != token.RPAREN {
+		missing := p.tok != token.RPAREN
+		if missing {
 			p.errf(p.pos, "expected ')' for string interpolation")
 		}
 		lit = p.scanner.ResumeInterpolation()
 		pos = p.pos
+		if missing {
+			pos = p.missingPos(len(lit))
+		}
 		p.next()
 		last = &ast.BasicLit{
 			ValuePos: pos,
@@ -1659,6 +1726,16 @@ func (p *parser) parseFile() *ast.File {
 			// rest of package decls
 			// TODO: loop and allow multiple expressions.
 			decls = append(decls, p.parseFieldList()...)
+			for p.mode&recoverMode != 0 && p.tok == token.RBRACE {
+				// Skip unmatched closing braces and continue.
+				p.errf(p.pos, "unexpected '}'")
+				decls = append(decls, &ast.BadDecl{From: p.pos, To: p.pos})
+				p.next()
+				if p.tok == token.COMMA {
+					p.next()
+				}
+				decls = append(decls, p.parseFieldList()...)
+			}
 			p.expect(token.EOF)
 		}
 	}
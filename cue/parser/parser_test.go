@@ -20,6 +20,8 @@ import (
 	"testing"
 
 	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
 )
 
 func TestParse(t *testing.T) {
@@ -703,6 +705,74 @@ func TestStrict(t *testing.T) {
 	}
 }
 
+func TestRecover(t *testing.T) {
+	testCases := []struct {
+		desc string
+		in   string
+		out  string
+		errs int
+	}{{
+		desc: "bad declaration",
+		in: `
+			a: 1
+			b: 3 4 x
+			c: 2
+			`,
+		out:  "a: 1, <*ast.BadDecl>, c: 2",
+		errs: 1,
+	}, {
+		desc: "nested struct",
+		in: `
+			a: {
+				b: [1 2] 3
+				c: 2
+			}
+			d: 3
+			`,
+		out:  "a: {<*ast.BadDecl>, c: 2}, d: 3",
+		errs: 1,
+	}, {
+		desc: "multiple errors",
+		in: `
+			a: 1 2
+			b: 3 4
+			c: 5 6
+			d: 7
+			`,
+		out:  "<*ast.BadDecl>, <*ast.BadDecl>, <*ast.BadDecl>, d: 7",
+		errs: 3,
+	}, {
+		desc: "unmatched brace",
+		in: `
+			a: 1
+			}
+			b: 2
+			`,
+		out:  "a: 1, <*ast.BadDecl>, b: 2",
+		errs: 1,
+	}, {
+		desc: "unclosed struct",
+		in: `
+			a: {
+				b: 1 2
+				c: 3
+			`,
+		out:  "a: {<*ast.BadDecl>, c: 3}",
+		errs: 2,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f, err := ParseFile("input", tc.in, Recover)
+			if got := debugStr(f); got != tc.out {
+				t.Errorf("\ngot  %q;\nwant %q", got, tc.out)
+			}
+			if got := len(errors.Errors(err)); got != tc.errs {
+				t.Errorf("got %d errors; want %d:\n%v", got, tc.errs, errors.Details(err, nil))
+			}
+		})
+	}
+}
+
 func TestParseExpr(t *testing.T) {
 	// just kicking the tires:
 	// a valid arithmetic expression
@@ -847,6 +917,37 @@ func TestIncompleteSelection(t *testing.T) {
 	}
 }
 
+// TestIncompletePositions verifies that the positions of nodes synthesized
+// for missing tokens at the end of a file lie within the file.
+func TestIncompletePositions(t *testing.T) {
+	testCases := []string{
+		"a: strings.",
+		"a: strings.\n",
+		"a: [",
+		"a: {",
+		"a: (",
+		"a: b[",
+		"a: foo(",
+		"x: [1,",
+		"package",
+		`a: "\(`,
+		"a: [for x in",
+	}
+	for _, src := range testCases {
+		t.Run(src, func(t *testing.T) {
+			f, _ := ParseFile("input", src, AllErrors)
+			ast.Walk(f, func(n ast.Node) bool {
+				for _, p := range []token.Pos{n.Pos(), n.End()} {
+					if p.File() != nil && p.Offset() > len(src) {
+						t.Errorf("%T: offset %d past end of file", n, p.Offset())
+					}
+				}
+				return true
+			}, nil)
+		})
+	}
+}
+
 // For debugging, do not delete.
 func TestX(t *testing.T) {
 	t.Skip()
@@ -0,0 +1,308 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/ast/astutil"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+// A TextEdit replaces the bytes in the range [Start, End) of a source with
+// NewText.
+type TextEdit struct {
+	Start, End int // byte offsets in the source before any of the edits
+	NewText    string
+}
+
+// Reparse returns the File that results from parsing src, the source from
+// which old was parsed, with the given edits applied. The edits must not
+// overlap. The options should be those used to parse old.
+//
+// Top-level declarations of old that are separated from all edits by a
+// newline are reused rather than parsed again. Only the source between them
+// is parsed. If this results in an error, the whole source is parsed.
+//
+// Reparse updates the token.File of old to the new source, and adjusts the
+// positions of reused declarations accordingly. Old should not be used after
+// the call.
+//
+// Reparse requires old to be parsed by this package and to contain at least
+// one token or comment.
+func Reparse(old *ast.File, src []byte, edits []TextEdit, mode ...Option) (*ast.File, error) {
+	tf := fileOf(old)
+	if tf == nil {
+		return nil, errors.Newf(token.NoPos,
+			"reparse: no source information for file %q", old.Filename)
+	}
+	if tf.Size() != len(src) {
+		return nil, errors.Newf(token.NoPos,
+			"reparse: source of file %q does not match its size", old.Filename)
+	}
+	if len(edits) == 0 {
+		return old, nil
+	}
+
+	edits = append([]TextEdit(nil), edits...)
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].Start < edits[j].Start
+	})
+	var buf bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		if e.Start < last || e.End < e.Start || e.End > len(src) {
+			return nil, errors.Newf(token.NoPos,
+				"reparse: invalid or overlapping edit [%d, %d)", e.Start, e.End)
+		}
+		buf.Write(src[last:e.Start])
+		buf.WriteString(e.NewText)
+		last = e.End
+	}
+	buf.Write(src[last:])
+	newSrc := buf.Bytes()
+	delta := len(newSrc) - len(src)
+	first, end := edits[0].Start, edits[len(edits)-1].End
+
+	// Determine the declarations that can be reused: lo declarations at the
+	// start and the declarations from hi onwards.
+	decls := old.Decls
+	lo := 0
+	for lo < len(decls) {
+		_, stop := extent(decls[lo])
+		if stop > first || !bytes.Contains(src[stop:first], []byte("\n")) ||
+			hasBadNode(decls[lo]) {
+			break
+		}
+		lo++
+	}
+	hi := len(decls)
+	for hi > lo {
+		start, _ := extent(decls[hi-1])
+		if start < end || !bytes.Contains(src[end:start], []byte("\n")) ||
+			hasBadNode(decls[hi-1]) {
+			break
+		}
+		hi--
+	}
+	// Preamble declarations must be followed by the source that followed
+	// them before.
+	if n := len(old.Preamble()); hi < n {
+		hi = n
+		if lo > hi {
+			lo = hi
+		}
+	}
+
+	// Parse only the source between the reused declarations, with the
+	// other parts blanked out so that offsets and lines remain the same.
+	regionStart, regionEnd := 0, len(newSrc)
+	if lo > 0 {
+		_, regionStart = extent(decls[lo-1])
+	}
+	if hi < len(decls) {
+		start, _ := extent(decls[hi])
+		regionEnd = start + delta
+	}
+
+	// Select the file comments that are not in the parsed source.
+	var comments []*ast.CommentGroup
+	var shifted []*ast.CommentGroup
+	for _, cg := range old.Comments() {
+		switch start, stop := extent(cg); {
+		case stop <= regionStart:
+			comments = append(comments, cg)
+		case start >= regionEnd-delta:
+			shifted = append(shifted, cg)
+		}
+	}
+
+	region := make([]byte, len(newSrc))
+	for i, c := range newSrc {
+		if (i < regionStart || i >= regionEnd) && c != '\n' {
+			c = ' '
+		}
+		region[i] = c
+	}
+
+	f, err := parseInFile(tf, old.Filename, region, mode)
+	if err == nil && lo > 0 && misplacedPreamble(f, lo > len(old.Preamble())) {
+		err = errors.Newf(token.NoPos, "misplaced package or import")
+	}
+	if err != nil {
+		// Fall back to parsing the whole file.
+		f, err := parseInFile(tf, old.Filename, newSrc, mode)
+		return f, errors.Sanitize(errors.Append(err, resolve(f)))
+	}
+	tf.SetContent(newSrc)
+
+	suffix := decls[hi:]
+	for _, d := range suffix {
+		shiftPos(d, tf, delta)
+	}
+
+	for _, cg := range shifted {
+		shiftPos(cg, tf, delta)
+	}
+	comments = append(comments, f.Comments()...)
+	comments = append(comments, shifted...)
+	f.SetComments(comments)
+
+	a := make([]ast.Decl, 0, lo+len(f.Decls)+len(suffix))
+	a = append(a, decls[:lo]...)
+	a = append(a, f.Decls...)
+	a = append(a, suffix...)
+	f.Decls = a
+	f.Imports = nil
+	for _, d := range f.Decls {
+		if x, ok := d.(*ast.ImportDecl); ok {
+			f.Imports = append(f.Imports, x.Specs...)
+		}
+	}
+
+	for _, d := range decls[:lo] {
+		clearResolved(d)
+	}
+	for _, d := range suffix {
+		clearResolved(d)
+	}
+	return f, errors.Sanitize(resolve(f))
+}
+
+// parseInFile parses src using the token.File tf.
+func parseInFile(tf *token.File, filename string, src []byte, mode []Option) (f *ast.File, err errors.Error) {
+	var p parser
+	defer func() {
+		if p.panicking {
+			_ = recover()
+		}
+		if f == nil {
+			f = &ast.File{}
+		}
+		f.Filename = filename
+		err = p.errors
+	}()
+
+	tf.SetContent(src)
+	p.file = tf
+	p.init(filename, src, mode)
+	f = p.parseFile()
+	return f, p.errors
+}
+
+// resolve resolves the identifiers of f and returns the errors found.
+func resolve(f *ast.File) (errs errors.Error) {
+	f.Unresolved = nil
+	astutil.Resolve(f, func(pos token.Pos, msg string, args ...interface{}) {
+		errs = errors.Append(errs, errors.Newf(pos, msg, args...))
+	})
+	return errs
+}
+
+// fileOf returns the token.File of the first position found in f.
+func fileOf(f *ast.File) (tf *token.File) {
+	ast.Walk(f, func(n ast.Node) bool {
+		if tf == nil {
+			tf = n.Pos().File()
+		}
+		return tf == nil
+	}, nil)
+	return tf
+}
+
+// extent returns the range of offsets spanned by n and its comments.
+func extent(n ast.Node) (start, end int) {
+	start, end = n.Pos().Offset(), n.End().Offset()
+	for _, cg := range ast.Comments(n) {
+		if s := cg.Pos().Offset(); s < start {
+			start = s
+		}
+		if e := cg.End().Offset(); e > end {
+			end = e
+		}
+	}
+	return start, end
+}
+
+func hasBadNode(n ast.Node) (found bool) {
+	ast.Walk(n, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.BadDecl, *ast.BadExpr:
+			found = true
+		}
+		return !found
+	}, nil)
+	return found
+}
+
+// misplacedPreamble reports whether f, parsed from source that follows
+// other declarations, has a package clause or, if these other declarations
+// are not all part of the preamble, imports. Such declarations are only
+// allowed at the start of a file.
+func misplacedPreamble(f *ast.File, afterDecls bool) bool {
+	for _, d := range f.Decls {
+		switch d.(type) {
+		case *ast.Package:
+			return true
+		case *ast.ImportDecl:
+			if afterDecls {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func clearResolved(n ast.Node) {
+	ast.Walk(n, func(n ast.Node) bool {
+		if x, ok := n.(*ast.Ident); ok {
+			x.Node = nil
+			x.Scope = nil
+		}
+		return true
+	}, nil)
+}
+
+var posType = reflect.TypeOf(token.NoPos)
+
+// shiftPos adds delta to all positions of tf within n.
+func shiftPos(n ast.Node, tf *token.File, delta int) {
+	if delta == 0 {
+		return
+	}
+	// Comments may be attached to more than one node.
+	seen := map[ast.Node]bool{}
+	ast.Walk(n, func(n ast.Node) bool {
+		if seen[n] {
+			return false
+		}
+		seen[n] = true
+		v := reflect.ValueOf(n).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Field(i)
+			if f.Type() != posType || !f.CanSet() {
+				continue
+			}
+			if p := f.Interface().(token.Pos); p.File() == tf {
+				f.Set(reflect.ValueOf(p.Add(delta)))
+			}
+		}
+		return true
+	}, nil)
+}
@@ -0,0 +1,203 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+)
+
+func TestReparse(t *testing.T) {
+	const src = `// A file.
+
+package foo
+
+import "strings"
+
+// Doc for a.
+a: 1 // a
+b: strings.ToUpper(c)
+c: "foo"
+
+d: {
+	e: a
+	f: [1, 2]
+}
+g: d.e
+`
+	// at returns the offset of the n-th occurrence of s in src.
+	at := func(s string, n int) int {
+		i := -1
+		for ; n >= 0; n-- {
+			i += 1 + strings.Index(src[i+1:], s)
+		}
+		return i
+	}
+	testCases := []struct {
+		desc   string
+		edits  []TextEdit
+		reused []string
+	}{{
+		desc:   "change value",
+		edits:  []TextEdit{{at("\"foo\"", 0), at("\"foo\"", 0) + 5, `"barbaz"`}},
+		reused: []string{"package", "import", "a", "b", "d", "g"},
+	}, {
+		desc:   "delete field",
+		edits:  []TextEdit{{at("c:", 0), at("\nd:", 0), ""}},
+		reused: []string{"package", "import", "a", "b", "d", "g"},
+	}, {
+		desc:   "insert field",
+		edits:  []TextEdit{{at("g:", 0), at("g:", 0), "h: 3\n"}},
+		reused: []string{"package", "import", "a", "b", "c", "d"},
+	}, {
+		desc:   "edit nested",
+		edits:  []TextEdit{{at("2]", 0), at("2]", 0) + 1, "2, 3"}},
+		reused: []string{"package", "import", "a", "b", "c", "g"},
+	}, {
+		desc:   "edit doc comment",
+		edits:  []TextEdit{{at("Doc", 0), at("Doc", 0) + 3, "Documentation"}},
+		reused: []string{"package", "import", "b", "c", "d", "g"},
+	}, {
+		desc: "multiple edits",
+		edits: []TextEdit{
+			{at("g:", 0), at("g:", 0) + 1, "gg"},
+			{at("1 //", 0), at("1 //", 0) + 1, "100"},
+		},
+		reused: []string{"package", "import"},
+	}, {
+		desc:   "edit import",
+		edits:  []TextEdit{{at(`"strings"`, 0), at(`"strings"`, 0), "\"list\"\nimport "}},
+		reused: []string{"package", "a", "b", "c", "d", "g"},
+	}, {
+		desc:   "unbalanced",
+		edits:  []TextEdit{{at("c:", 0), at("c:", 0), "x: {\n"}},
+		reused: []string{},
+	}, {
+		desc:   "joined lines",
+		edits:  []TextEdit{{at("\nc:", 0), at("\nc:", 0) + 1, " "}},
+		reused: []string{},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			old, err := ParseFile("test.cue", src, ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decls := map[ast.Decl]string{}
+			for _, d := range old.Decls {
+				decls[d] = declName(d)
+			}
+
+			f, err := Reparse(old, []byte(src), tc.edits, ParseComments)
+
+			// Apply the edits from last to first.
+			edits := append([]TextEdit(nil), tc.edits...)
+			sort.Slice(edits, func(i, j int) bool { return edits[i].Start > edits[j].Start })
+			newSrc := src
+			for _, e := range edits {
+				newSrc = newSrc[:e.Start] + e.NewText + newSrc[e.End:]
+			}
+			want, wantErr := ParseFile("test.cue", newSrc, ParseComments)
+
+			if got, want := errStr(err), errStr(wantErr); got != want {
+				t.Errorf("errors:\ngot  %s\nwant %s", got, want)
+			}
+			if got, want := dump(f), dump(want); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+			if got := f.Pos().File().Size(); got != len(newSrc) {
+				t.Errorf("size: got %d; want %d", got, len(newSrc))
+			}
+
+			reused := []string{}
+			for _, d := range f.Decls {
+				if name, ok := decls[d]; ok {
+					reused = append(reused, name)
+				}
+			}
+			if got, want := fmt.Sprint(reused), fmt.Sprint(tc.reused); got != want {
+				t.Errorf("reused: got %s; want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestReparseNoSource(t *testing.T) {
+	_, err := Reparse(&ast.File{}, nil, []TextEdit{{0, 0, "a: 1"}})
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestReparseWrongSource(t *testing.T) {
+	old, err := ParseFile("test.cue", "a: 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Reparse(old, []byte("a: 10\n"), []TextEdit{{0, 0, "b: 2\n"}})
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
+func declName(d ast.Decl) string {
+	switch x := d.(type) {
+	case *ast.Package:
+		return "package"
+	case *ast.ImportDecl:
+		return "import"
+	case *ast.Field:
+		name, _, _ := ast.LabelName(x.Label)
+		return name
+	}
+	return fmt.Sprintf("%T", d)
+}
+
+func errStr(err error) string {
+	if err == nil {
+		return ""
+	}
+	return errors.Details(err, nil)
+}
+
+// dump returns a string representation of f that includes the positions and
+// resolved references of its nodes.
+func dump(f *ast.File) string {
+	var b strings.Builder
+	fmt.Fprintln(&b, debugStr(f))
+	ast.Walk(f, func(n ast.Node) bool {
+		fmt.Fprintf(&b, "%T %v-%v", n, n.Pos(), n.End())
+		if x, ok := n.(*ast.Ident); ok {
+			fmt.Fprintf(&b, " %s", x.Name)
+			if x.Node != nil {
+				fmt.Fprintf(&b, " -> %T %v", x.Node, x.Node.Pos())
+			}
+		}
+		fmt.Fprintln(&b)
+		return true
+	}, nil)
+	for _, x := range f.Imports {
+		fmt.Fprintf(&b, "import %v\n", x.Pos())
+	}
+	for _, x := range f.Unresolved {
+		fmt.Fprintf(&b, "unresolved %s %v\n", x.Name, x.Pos())
+	}
+	return b.String()
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+// A PatchKind identifies the kind of a patch Operation.
+type PatchKind int
+
+const (
+	// PatchSet indicates that the value at a path is to be set, replacing
+	// any existing value.
+	PatchSet PatchKind = iota
+
+	// PatchDelete indicates that the field at a path is to be removed.
+	PatchDelete
+)
+
+func (k PatchKind) String() string {
+	switch k {
+	case PatchSet:
+		return "set"
+	case PatchDelete:
+		return "delete"
+	}
+	return "unknown"
+}
+
+// An Operation is a single step in transforming one value into another.
+type Operation struct {
+	Op PatchKind
+
+	// Path is the path of the affected value, relative to the root of the
+	// values passed to Patch.
+	Path Path
+
+	// Value is the new value for PatchSet operations. It is the value at Path
+	// in the original value for PatchDelete operations.
+	Value Value
+}
+
+// Patch returns the operations needed to transform from into to.
+//
+// Only regular fields are considered. Structs are compared field by field.
+// Lists of equal length are compared element by element; lists of different
+// lengths are replaced as a whole. Other values are replaced if they are not
+// equal. Operations are reported in the order of the fields in from, followed
+// by operations for fields that only exist in to.
+func Patch(from, to Value) ([]Operation, error) {
+	if err := from.Err(); err != nil {
+		return nil, err
+	}
+	if err := to.Err(); err != nil {
+		return nil, err
+	}
+	var p patcher
+	if err := p.patch(nil, from, to); err != nil {
+		return nil, err
+	}
+	return p.ops, nil
+}
+
+type patcher struct {
+	ops []Operation
+}
+
+func (p *patcher) add(op PatchKind, path []Selector, v Value) {
+	p.ops = append(p.ops, Operation{
+		Op:    op,
+		Path:  MakePath(append([]Selector{}, path...)...),
+		Value: v,
+	})
+}
+
+func (p *patcher) patch(path []Selector, from, to Value) error {
+	from, _ = from.Default()
+	to, _ = to.Default()
+
+	switch k := from.Kind(); {
+	case k != to.Kind():
+
+	case k == StructKind:
+		return p.patchStruct(path, from, to)
+
+	case k == ListKind:
+		if from.Len().Equals(to.Len()) {
+			return p.patchList(path, from, to)
+		}
+
+	case from.Equals(to):
+		return nil
+	}
+
+	p.add(PatchSet, path, to)
+	return nil
+}
+
+func (p *patcher) patchStruct(path []Selector, from, to Value) error {
+	iter, err := from.Fields()
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for iter.Next() {
+		sel := iter.Selector()
+		seen[sel.String()] = true
+		sub := append(path, sel)
+
+		w := to.LookupPath(MakePath(sel))
+		if !w.Exists() {
+			p.add(PatchDelete, sub, iter.Value())
+			continue
+		}
+		if err := p.patch(sub, iter.Value(), w); err != nil {
+			return err
+		}
+	}
+
+	iter, err = to.Fields()
+	if err != nil {
+		return err
+	}
+	for iter.Next() {
+		sel := iter.Selector()
+		if !seen[sel.String()] {
+			p.add(PatchSet, append(path, sel), iter.Value())
+		}
+	}
+	return nil
+}
+
+func (p *patcher) patchList(path []Selector, from, to Value) error {
+	a, err := from.List()
+	if err != nil {
+		return err
+	}
+	b, err := to.List()
+	if err != nil {
+		return err
+	}
+	for i := 0; a.Next() && b.Next(); i++ {
+		if err := p.patch(append(path, Index(i)), a.Value(), b.Value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPatch(t *testing.T) {
+	testCases := []struct {
+		from string
+		to   string
+		want []string
+	}{{
+		from: `{a: 1, b: "x"}`,
+		to:   `{a: 1, b: "x"}`,
+	}, {
+		from: `{a: 1, b: {c: 2, d: 3}, e: "x"}`,
+		to:   `{a: 1, b: {c: 3}, f: true}`,
+		want: []string{
+			"set b.c: 3",
+			"delete b.d: 3",
+			"delete e: \"x\"",
+			"set f: true",
+		},
+	}, {
+		from: `{l: [1, 2, {a: 1}]}`,
+		to:   `{l: [1, 3, {a: 2}]}`,
+		want: []string{
+			"set l[1]: 3",
+			"set l[2].a: 2",
+		},
+	}, {
+		from: `{l: [1, 2]}`,
+		to:   `{l: [1, 2, 3]}`,
+		want: []string{"set l: [1, 2, 3]"},
+	}, {
+		from: `{a: {b: 1}}`,
+		to:   `{a: 1}`,
+		want: []string{"set a: 1"},
+	}, {
+		from: `{a: *1 | int}`,
+		to:   `{a: 1}`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.from+" -> "+tc.to, func(t *testing.T) {
+			from := getInstance(t, tc.from).Value()
+			to := getInstance(t, tc.to).Value()
+
+			ops, err := Patch(from, to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, op := range ops {
+				got = append(got, fmt.Sprintf("%v %v: %v", op.Op, op.Path, op.Value))
+			}
+			if !cmp.Equal(got, tc.want) {
+				t.Error(cmp.Diff(got, tc.want))
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ func TestPaths(t *testing.T) {
 		c: "#Foo": 7
 		map: [string]: int
 		list: [...int]
+		"a.b": 8
 	`)
 	testCases := []struct {
 		path Path
@@ -124,6 +125,14 @@ func TestPaths(t *testing.T) {
 		path: MakePath(Str("list"), AnyIndex),
 		out:  "int",
 		str:  "list.[_]",
+	}, {
+		path: MakePath(Str("a.b")),
+		out:  "8",
+		str:  `"a.b"`,
+	}, {
+		path: ParsePath(`"a.b"`),
+		out:  "8",
+		str:  `"a.b"`,
 	}}
 
 	v := inst.Value()
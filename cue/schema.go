@@ -0,0 +1,321 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+import (
+	"fmt"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/astinternal"
+	"cuelang.org/go/internal/core/adt"
+)
+
+// InferSchema derives a schema from the concrete value v. Each concrete scalar
+// is replaced with the type of its kind, so that, for instance, the value
+//
+//     {name: "x", port: 8000}
+//
+// results in the schema
+//
+//     {name: string, port: int}
+//
+// Structs retain their regular fields. A list whose elements all have the same
+// kind is mapped to an open list of the (disjunction of) inferred element
+// types, while other lists retain their structure.
+//
+// InferSchema returns an error if v or any of its regular fields is not
+// concrete.
+func (v Value) InferSchema() (Value, error) {
+	expr, err := inferSchema(v)
+	if err != nil {
+		b := &adt.Bottom{Err: errors.Promote(err, "infer schema")}
+		return newErrValue(v, b), err
+	}
+	w := v.Context().BuildExpr(expr)
+	return w, w.Err()
+}
+
+func inferSchema(v Value) (ast.Expr, error) {
+	v, _ = v.Default()
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	switch k := v.Kind(); k {
+	case NullKind:
+		return ast.NewNull(), nil
+
+	case BoolKind, IntKind, FloatKind, StringKind, BytesKind:
+		return ast.NewIdent(k.String()), nil
+
+	case StructKind:
+		iter, err := v.Fields()
+		if err != nil {
+			return nil, err
+		}
+		s := ast.NewStruct()
+		for iter.Next() {
+			x, err := inferSchema(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			s.Elts = append(s.Elts, &ast.Field{
+				Label: newLabel(iter.Label()),
+				Value: x,
+			})
+		}
+		return s, nil
+
+	case ListKind:
+		iter, err := v.List()
+		if err != nil {
+			return nil, err
+		}
+		var (
+			elems   []ast.Expr
+			unique  []ast.Expr
+			seen    = map[string]bool{}
+			kind    = BottomKind
+			allSame = true
+		)
+		for i := 0; iter.Next(); i++ {
+			w := iter.Value()
+			x, err := inferSchema(w)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, x)
+
+			w, _ = w.Default()
+			if i == 0 {
+				kind = w.Kind()
+			} else if w.Kind() != kind {
+				allSame = false
+			}
+
+			if key := astinternal.DebugStr(x); !seen[key] {
+				seen[key] = true
+				unique = append(unique, x)
+			}
+		}
+		switch {
+		case len(elems) == 0:
+			return &ast.ListLit{Elts: []ast.Expr{&ast.Ellipsis{}}}, nil
+		case allSame:
+			return &ast.ListLit{Elts: []ast.Expr{
+				&ast.Ellipsis{Type: ast.NewBinExpr(token.OR, unique...)},
+			}}, nil
+		default:
+			return ast.NewList(elems...), nil
+		}
+
+	default:
+		return nil, errors.Newf(v.Pos(),
+			"cannot infer schema from incomplete value %v", v)
+	}
+}
+
+// newLabel returns an identifier for name if it is a valid regular identifier
+// or a quoted string otherwise.
+func newLabel(name string) ast.Label {
+	if ast.IsValidIdent(name) && !isHiddenOrDefinition(name) {
+		return ast.NewIdent(name)
+	}
+	return ast.NewString(name)
+}
+
+// IsSchema reports whether v is a pure schema, that is, whether none of the
+// values of v and its regular fields and list elements are concrete scalars.
+// Values that are only constrained, as in int or >=1, or have a default, as in
+// *1 | int, are not concrete. Definitions, optional fields, and pattern
+// constraints are not considered. An empty struct or list is a schema.
+//
+// Unlike IsConcrete, which only considers the top-level value, IsSchema
+// considers the entire tree.
+func (v Value) IsSchema() bool {
+	if v.v == nil {
+		return false
+	}
+	switch v.IncompleteKind() {
+	case StructKind:
+		for iter, _ := v.Fields(); iter.Next(); {
+			if !iter.Value().IsSchema() {
+				return false
+			}
+		}
+		return true
+
+	case ListKind:
+		for iter, _ := v.List(); iter.Next(); {
+			if !iter.Value().IsSchema() {
+				return false
+			}
+		}
+		return true
+	}
+	return !v.IsConcrete()
+}
+
+// RequiredFields reports the paths, relative to v, of the regular fields of v
+// that still need to be specified to make v concrete: fields that are not
+// optional, have no default, and are not concrete. It descends into structs,
+// but not into lists.
+func (v Value) RequiredFields() []Path {
+	var a []Path
+	requiredFields(&a, nil, v)
+	return a
+}
+
+func requiredFields(a *[]Path, path []Selector, v Value) {
+	for iter, _ := v.Fields(); iter.Next(); {
+		sub := append(path, iter.Selector())
+		w, _ := iter.Value().Default()
+		switch {
+		case w.IncompleteKind() == StructKind:
+			requiredFields(a, sub, w)
+		case !w.IsConcrete():
+			*a = append(*a, MakePath(append([]Selector{}, sub...)...))
+		}
+	}
+}
+
+// A PatternConstraint describes a constraint of the form [Pattern]: Value,
+// which applies Value to all fields of a struct whose label matches Pattern.
+type PatternConstraint struct {
+	Pattern Value
+	Value   Value
+}
+
+// PatternConstraints reports the pattern constraints that apply to the fields
+// of v, in the order in which they were declared. References to the label of
+// a field, as in [Name=string]: {name: Name}, are not resolved to any specific
+// label and may thus be incomplete.
+//
+// Use Constraint to obtain the constraints that apply to a specific label.
+func (v Value) PatternConstraints() []PatternConstraint {
+	if v.v == nil {
+		return nil
+	}
+	ctx := v.ctx()
+	seen := map[*adt.BulkOptionalField]bool{}
+	var a []PatternConstraint
+	for _, s := range v.v.Structs {
+		for _, b := range s.Bulk {
+			if seen[b] {
+				continue
+			}
+			seen[b] = true
+
+			mk := func(x adt.Expr) Value {
+				n := &adt.Vertex{}
+				n.AddConjunct(adt.MakeRootConjunct(s.Env, x))
+				n.Finalize(ctx)
+				return makeValue(v.idx, n, nil)
+			}
+			a = append(a, PatternConstraint{
+				Pattern: mk(b.Filter),
+				Value:   mk(b.Value),
+			})
+		}
+	}
+	return a
+}
+
+// A NamingConflict reports a field whose name may be confused with that of
+// another field in the same struct.
+type NamingConflict struct {
+	// Path is the path of the offending field.
+	Path Path
+
+	// Message describes the conflict.
+	Message string
+}
+
+// NamingConflicts reports fields in v that may be confused with other fields in
+// the same struct. This includes regular fields named like a definition minus
+// the '#', as in Foo and #Foo, and hidden fields named like a regular field
+// minus the '_', as in _x and x.
+//
+// NamingConflicts descends into all values of v, including definitions and
+// hidden and optional fields.
+func (v Value) NamingConflicts() []NamingConflict {
+	var a []NamingConflict
+	namingConflicts(&a, v)
+	return a
+}
+
+func namingConflicts(a *[]NamingConflict, v Value) {
+	v, _ = v.Default()
+
+	switch v.IncompleteKind() {
+	case StructKind:
+		iter, err := v.Fields(All())
+		if err != nil {
+			return
+		}
+
+		type entry struct {
+			sel Selector
+			v   Value
+		}
+		var fields []entry
+		defs := map[string]bool{}
+		regular := map[string]bool{}
+		for iter.Next() {
+			sel := iter.Selector()
+			fields = append(fields, entry{sel, iter.Value()})
+			switch name := sel.String(); {
+			case sel.PkgPath() != "":
+			case sel.IsDefinition():
+				defs[name[1:]] = true
+			default:
+				regular[name] = true
+			}
+		}
+
+		for _, f := range fields {
+			name := f.sel.String()
+			switch {
+			case f.sel.PkgPath() != "":
+				if x := name[1:]; regular[x] {
+					*a = append(*a, NamingConflict{
+						Path: f.v.Path(),
+						Message: fmt.Sprintf(
+							"hidden field %s may be confused with field %s",
+							name, x),
+					})
+				}
+
+			case !f.sel.IsDefinition():
+				if defs[name] {
+					*a = append(*a, NamingConflict{
+						Path: f.v.Path(),
+						Message: fmt.Sprintf(
+							"field %s may be confused with definition #%s",
+							name, name),
+					})
+				}
+			}
+			namingConflicts(a, f.v)
+		}
+
+	case ListKind:
+		for iter, _ := v.List(); iter.Next(); {
+			namingConflicts(a, iter.Value())
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"cuelang.org/go/cue/format"
+)
+
+func TestInferSchema(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  string
+		err   string
+	}{{
+		value: `{name: "x", port: 8000}`,
+		want:  "{\n\tname: string\n\tport: int\n}",
+	}, {
+		value: `{a: null, b: true, c: 1.5, d: 'x', "e-f": *"x" | string}`,
+		want:  "{\n\ta:     null\n\tb:     bool\n\tc:     float\n\td:     bytes\n\t\"e-f\": string\n}",
+	}, {
+		value: `[1, 2, 3]`,
+		want:  "[...int]",
+	}, {
+		value: `[]`,
+		want:  "[...]",
+	}, {
+		value: `[1, "a"]`,
+		want:  "[int, string]",
+	}, {
+		value: `[{a: 1}, {a: 2}, {b: "x"}]`,
+		want:  "[...{\n\ta: int\n} | {\n\tb: string\n}]",
+	}, {
+		value: `{a: int}`,
+		err:   "cannot infer schema from incomplete value int",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			v := getInstance(t, "a: "+tc.value).Lookup("a")
+
+			w, err := v.InferSchema()
+			checkErr(t, err, tc.err, "InferSchema")
+			if tc.err != "" {
+				return
+			}
+
+			b, err := format.Node(w.Syntax())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsSchema(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  bool
+	}{
+		{`int`, true},
+		{`>=1 & <10`, true},
+		{`*1 | int`, true},
+		{`1`, false},
+		{`null`, false},
+		{`{}`, true},
+		{`[]`, true},
+		{`{a: int, b: [...string], c?: 1, #d: 2, [=~"^x"]: 3}`, true},
+		{`{a: int, b: {c: string, d: [int, 1]}}`, false},
+		{`{a: "x", b: 2}`, false},
+		{`{a: int, b: "x"}`, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			v := getInstance(t, "a: "+tc.value).Lookup("a")
+			if got := v.IsSchema(); got != tc.want {
+				t.Errorf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRequiredFields(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  []string
+	}{{
+		value: `{a: 1, b: "x"}`,
+	}, {
+		value: `{
+			name:   string
+			port:   *8080 | int
+			debug?: bool
+			#def:   int
+			tags: [...string]
+			db: {
+				host: string
+				user: *"root" | string
+				pass: =~"^.{8,}$"
+			}
+		}`,
+		want: []string{"name", "db.host", "db.pass"},
+	}, {
+		value: `#S & {name: "x"}
+		#S: {name: string, id: int}`,
+		want: []string{"id"},
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			v := getInstance(t, "a: "+tc.value).Lookup("a")
+
+			var got []string
+			for _, p := range v.RequiredFields() {
+				got = append(got, p.String())
+			}
+			if !cmp.Equal(got, tc.want) {
+				t.Error(cmp.Diff(got, tc.want))
+			}
+		})
+	}
+}
+
+func TestPatternConstraints(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  []string
+	}{{
+		value: `{a: int}`,
+	}, {
+		value: `{[!~"^[.]"]: #job, #job: {name: string}}`,
+		want:  []string{"!~\"^[.]\": {\n\tname: string\n}"},
+	}, {
+		value: `close({[=~"^a"]: int, [=~"b$"]: <10, a: 1})`,
+		want:  []string{`=~"^a": int`, `=~"b$": <10`},
+	}, {
+		value: `{[string]: bool} & {[int]: string}`,
+		want:  []string{`string: bool`, `int: string`},
+	}, {
+		value: `{[Name=string]: {name: Name, x: 1}}`,
+		want:  []string{"string: {\n\tname: string\n\tx:    1\n}"},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			v := getInstance(t, "a: "+tc.value).Lookup("a")
+
+			var got []string
+			for _, c := range v.PatternConstraints() {
+				got = append(got, fmt.Sprintf("%v: %v", c.Pattern, c.Value))
+			}
+			if !cmp.Equal(got, tc.want) {
+				t.Error(cmp.Diff(got, tc.want))
+			}
+		})
+	}
+}
+
+func TestNamingConflicts(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  []string
+	}{{
+		value: `{Foo: 1, #Foo: int}`,
+		want:  []string{"Foo: field Foo may be confused with definition #Foo"},
+	}, {
+		value: `{x: 1, _x: 2}`,
+		want:  []string{"_x: hidden field _x may be confused with field x"},
+	}, {
+		value: `{#Def: {a: b: {B: 1, #B: int}}, l: [{_y: 1, y: 2}]}`,
+		want: []string{
+			"#Def.a.b.B: field B may be confused with definition #B",
+			"l[0]._y: hidden field _y may be confused with field y",
+		},
+	}, {
+		value: `{Foo: 1, #Bar: int, _baz: 2, "#Foo": 3}`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			v := getInstance(t, tc.value).Value()
+
+			var got []string
+			for _, c := range v.NamingConflicts() {
+				got = append(got, c.Path.String()+": "+c.Message)
+			}
+			if !cmp.Equal(got, tc.want) {
+				t.Error(cmp.Diff(got, tc.want))
+			}
+		})
+	}
+}
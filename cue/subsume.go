@@ -0,0 +1,129 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+import (
+	"cuelang.org/go/cue/errors"
+)
+
+// A SubsumeViolation describes a location at which a value is not an instance
+// of another value.
+type SubsumeViolation struct {
+	// Path is the location of the violation relative to the compared values.
+	Path Path
+
+	// Err describes the violation.
+	Err errors.Error
+
+	// Subsuming and Subsumed are the values at Path in the subsuming and the
+	// subsumed value, respectively. One of them does not exist if the field
+	// is only defined in the other value.
+	Subsuming Value
+	Subsumed  Value
+}
+
+// SubsumeReport is like Subsume, but reports each location at which w is not
+// an instance of v, instead of a single error. It returns nil if w is an
+// instance of v.
+//
+// Violations are reported at the deepest path at which they can be attributed.
+// This allows, for instance, API compatibility checkers to report all
+// incompatibilities between two versions of a schema at once.
+func (v Value) SubsumeReport(w Value, opts ...Option) []SubsumeViolation {
+	r := subsumeReporter{opts: opts}
+	r.report(nil, v, w)
+	return r.violations
+}
+
+type subsumeReporter struct {
+	opts       []Option
+	violations []SubsumeViolation
+}
+
+func (r *subsumeReporter) add(path []Selector, v, w Value, err errors.Error) {
+	r.violations = append(r.violations, SubsumeViolation{
+		Path:      MakePath(path...),
+		Err:       err,
+		Subsuming: v,
+		Subsumed:  w,
+	})
+}
+
+func (r *subsumeReporter) report(path []Selector, v, w Value) {
+	err := v.Subsume(w, r.opts...)
+	if err == nil {
+		return
+	}
+	n := len(r.violations)
+
+	vk, wk := v.IncompleteKind(), w.IncompleteKind()
+	switch {
+	case vk == StructKind && wk == StructKind:
+		r.reportFields(path, v, w)
+
+	case vk == ListKind && wk == ListKind:
+		vi, _ := v.List()
+		wi, _ := w.List()
+		for i := 0; vi.Next() && wi.Next(); i++ {
+			r.report(appendSubsumePath(path, Index(i)), vi.Value(), wi.Value())
+		}
+	}
+
+	if len(r.violations) == n {
+		// The violation could not be attributed to a more specific path.
+		r.add(path, v, w, errors.Promote(err, ""))
+	}
+}
+
+func (r *subsumeReporter) reportFields(path []Selector, v, w Value) {
+	fieldOpts := []Option{Optional(true), Definitions(true)}
+
+	iter, _ := v.Fields(fieldOpts...)
+	for iter.Next() {
+		sel := iter.Selector()
+		p := appendSubsumePath(path, sel)
+		wf := lookupField(w, sel)
+		if !wf.Exists() {
+			if !iter.IsOptional() {
+				r.add(p, iter.Value(), wf, errors.Newf(iter.Value().Pos(),
+					"field %v is required but not defined in subsumed value", sel))
+			}
+			continue
+		}
+		r.report(p, iter.Value(), wf)
+	}
+
+	iter, _ = w.Fields(fieldOpts...)
+	for iter.Next() {
+		sel := iter.Selector()
+		if lookupField(v, sel).Exists() || v.Allows(sel) {
+			continue
+		}
+		r.add(appendSubsumePath(path, sel), Value{}, iter.Value(), errors.Newf(
+			iter.Value().Pos(), "field %v not allowed by subsuming value", sel))
+	}
+}
+
+// lookupField looks up the regular or optional field sel in v.
+func lookupField(v Value, sel Selector) Value {
+	if f := v.LookupPath(MakePath(sel)); f.Exists() {
+		return f
+	}
+	return v.LookupPath(MakePath(sel.Optional()))
+}
+
+func appendSubsumePath(path []Selector, sel Selector) []Selector {
+	return append(path[:len(path):len(path)], sel)
+}
@@ -239,6 +239,18 @@ func (f *File) Size() int {
 	return int(f.size)
 }
 
+// SetContent resets file f for the source content. The size of f is set to
+// the length of content and the line offsets are computed from it; //line
+// information is discarded. Positions of f recorded before the call refer to
+// the same offsets in the new content. The content itself is not retained.
+func (f *File) SetContent(content []byte) {
+	f.SetLinesForContent(content)
+	f.mutex.Lock()
+	f.size = index(len(content))
+	f.infos = nil
+	f.mutex.Unlock()
+}
+
 // LineCount returns the number of lines in file f.
 func (f *File) LineCount() int {
 	f.mutex.RLock()
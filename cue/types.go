@@ -15,6 +15,7 @@
 package cue
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -27,6 +28,7 @@ import (
 
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/ast/astutil"
+	"cuelang.org/go/cue/build"
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/token"
 	"cuelang.org/go/internal"
@@ -144,25 +146,30 @@ func (o *hiddenStructValue) Lookup(key string) Value {
 
 // MarshalJSON returns a valid JSON encoding or reports an error if any of the
 // fields is invalid.
-func (o *structValue) marshalJSON() (b []byte, err errors.Error) {
+func (o *structValue) marshalJSON(opts *options) (b []byte, err errors.Error) {
 	b = append(b, '{')
 	n := o.Len()
+	first := true
 	for i := 0; i < n; i++ {
 		k, v := o.At(i)
+		if opts.omitEmpty && isEmpty(v) {
+			continue
+		}
+		if !first {
+			b = append(b, ',')
+		}
+		first = false
 		s, err := json.Marshal(k)
 		if err != nil {
 			return nil, unwrapJSONError(err)
 		}
 		b = append(b, s...)
 		b = append(b, ':')
-		bb, err := json.Marshal(v)
+		bb, err := v.marshalJSON(opts)
 		if err != nil {
 			return nil, unwrapJSONError(err)
 		}
 		b = append(b, bb...)
-		if i < n-1 {
-			b = append(b, ',')
-		}
 	}
 	b = append(b, '}')
 	return b, nil
@@ -283,6 +290,12 @@ func (i *Iterator) IsOptional() bool {
 	return i.isOpt
 }
 
+// Attributes reports the field attributes of the current field, such as
+// @go(Name) or @protobuf(1), in the order in which they are defined.
+func (i *Iterator) Attributes() []Attribute {
+	return i.cur.Attributes(FieldAttr)
+}
+
 // IsDefinition reports if a field is a definition.
 //
 // Deprecated: use i.Selector().IsDefinition()
@@ -290,13 +303,38 @@ func (i *hiddenIterator) IsDefinition() bool {
 	return i.f.IsDef()
 }
 
+// isEmpty reports whether v is the zero value for its kind.
+func isEmpty(v Value) bool {
+	v, _ = v.Default()
+	switch v.Kind() {
+	case NullKind:
+		return true
+	case BoolKind:
+		b, _ := v.Bool()
+		return !b
+	case IntKind, FloatKind, NumberKind:
+		n, err := v.getNum(adt.NumKind)
+		return err == nil && n.X.IsZero()
+	case StringKind, BytesKind:
+		b, _ := v.Bytes()
+		return len(b) == 0
+	case ListKind:
+		iter, _ := v.List()
+		return !iter.Next()
+	case StructKind:
+		obj, err := v.structValData(v.ctx())
+		return err == nil && obj.Len() == 0
+	}
+	return false
+}
+
 // marshalJSON iterates over the list and generates JSON output. HasNext
 // will return false after this operation.
-func marshalList(l *Iterator) (b []byte, err errors.Error) {
+func marshalList(l *Iterator, opts *options) (b []byte, err errors.Error) {
 	b = append(b, '[')
 	if l.Next() {
 		for i := 0; ; i++ {
-			x, err := json.Marshal(l.Value())
+			x, err := l.Value().marshalJSON(opts)
 			if err != nil {
 				return nil, unwrapJSONError(err)
 			}
@@ -804,6 +842,28 @@ func (v Value) Default() (Value, bool) {
 	// 	return Value{v.idx, x}, isDefault
 }
 
+// SelectDefaults returns v with defaults selected throughout the tree. Unlike
+// with Final, structs and open lists remain open, so that the result can still
+// be unified with values that add fields or elements.
+//
+// The result only retains the regular fields of v. Non-concrete values without
+// a default are retained as is.
+func (v Value) SelectDefaults() Value {
+	if v.v == nil {
+		return v
+	}
+	if err := v.Err(); err != nil {
+		return v
+	}
+	n := &adt.Vertex{
+		Parent: v.v.Parent,
+		Label:  v.v.Label,
+	}
+	n.AddConjunct(adt.MakeRootConjunct(nil, dataExpr(v.v, true)))
+	n.Finalize(v.ctx())
+	return makeValue(v.idx, n, v.parent_)
+}
+
 // TODO: this should go: record preexpanded disjunctions in Vertex.
 func hasDisjunction(expr adt.Expr) bool {
 	switch x := expr.(type) {
@@ -902,14 +962,21 @@ func (v Value) IncompleteKind() Kind {
 
 // MarshalJSON marshalls this value into valid JSON.
 func (v Value) MarshalJSON() (b []byte, err error) {
-	b, err = v.marshalJSON()
+	return v.MarshalJSONWith()
+}
+
+// MarshalJSONWith is like MarshalJSON, but allows the output to be tuned with
+// options. Currently only OmitEmpty and SortFields are supported.
+func (v Value) MarshalJSONWith(opts ...Option) (b []byte, err error) {
+	o := getOptions(opts)
+	b, err = v.marshalJSON(&o)
 	if err != nil {
 		return nil, unwrapJSONError(err)
 	}
 	return b, nil
 }
 
-func (v Value) marshalJSON() (b []byte, err error) {
+func (v Value) marshalJSON(opts *options) (b []byte, err error) {
 	v, _ = v.Default()
 	if v.v == nil {
 		return json.Marshal(nil)
@@ -940,13 +1007,13 @@ func (v Value) marshalJSON() (b []byte, err error) {
 		return json.Marshal(x.(*adt.Bytes).B)
 	case adt.ListKind:
 		i, _ := v.List()
-		return marshalList(&i)
+		return marshalList(&i, opts)
 	case adt.StructKind:
-		obj, err := v.structValData(ctx)
+		obj, err := v.structValJSON(ctx, opts)
 		if err != nil {
 			return nil, toMarshalErr(v, err)
 		}
-		return obj.marshalJSON()
+		return obj.marshalJSON(opts)
 	case adt.BottomKind:
 		return nil, toMarshalErr(v, x.(*adt.Bottom))
 	default:
@@ -954,6 +1021,90 @@ func (v Value) marshalJSON() (b []byte, err error) {
 	}
 }
 
+// EncodeJSON writes the JSON encoding of v to w. It is like MarshalJSONWith,
+// but writes the output incrementally instead of building the complete
+// encoding in memory first.
+//
+// If an error occurs, part of the encoding may already have been written to w.
+func (v Value) EncodeJSON(w io.Writer, opts ...Option) error {
+	o := getOptions(opts)
+	e := &jsonEncoder{w: bufio.NewWriter(w), opts: &o}
+	if err := e.encode(v); err != nil {
+		return unwrapJSONError(err)
+	}
+	return e.w.Flush()
+}
+
+type jsonEncoder struct {
+	w    *bufio.Writer
+	opts *options
+}
+
+func (e *jsonEncoder) encode(v Value) error {
+	v, _ = v.Default()
+	if v.v == nil {
+		return e.encodeValue(v)
+	}
+	ctx := newContext(v.idx)
+	x := v.eval(ctx)
+	if !adt.IsConcrete(x) {
+		return e.encodeValue(v)
+	}
+
+	switch x.Kind() {
+	case adt.ListKind:
+		iter, _ := v.List()
+		e.w.WriteByte('[')
+		for i := 0; iter.Next(); i++ {
+			if i > 0 {
+				e.w.WriteByte(',')
+			}
+			if err := e.encode(iter.Value()); err != nil {
+				return err
+			}
+		}
+		return e.w.WriteByte(']')
+
+	case adt.StructKind:
+		obj, err := v.structValJSON(ctx, e.opts)
+		if err != nil {
+			return toMarshalErr(v, err)
+		}
+		e.w.WriteByte('{')
+		first := true
+		for i := 0; i < obj.Len(); i++ {
+			k, v := obj.At(i)
+			if e.opts.omitEmpty && isEmpty(v) {
+				continue
+			}
+			if !first {
+				e.w.WriteByte(',')
+			}
+			first = false
+			b, err := json.Marshal(k)
+			if err != nil {
+				return err
+			}
+			e.w.Write(b)
+			e.w.WriteByte(':')
+			if err := e.encode(v); err != nil {
+				return err
+			}
+		}
+		return e.w.WriteByte('}')
+	}
+	return e.encodeValue(v)
+}
+
+func (e *jsonEncoder) encodeValue(v Value) error {
+	b, err := v.marshalJSON(e.opts)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(b)
+	return err
+}
+
 // Syntax converts the possibly partially evaluated value into syntax. This
 // can use used to print the value with package format.
 func (v Value) Syntax(opts ...Option) ast.Node {
@@ -974,6 +1125,14 @@ func (v Value) Syntax(opts ...Option) ast.Node {
 		ShowHidden:      !o.omitHidden && !o.concrete,
 		ShowAttributes:  !o.omitAttrs,
 		ShowDocs:        o.docs,
+		KindComments:    o.kindComments,
+
+		PreserveLabelStyle: o.preserveLabels,
+		SortFields:         o.sortFields,
+	}
+	if o.hasResolveBuiltins {
+		p.ResolveBuiltins = o.resolveBuiltins
+		p.KeepBuiltins = !o.resolveBuiltins
 	}
 
 	pkgID := v.instance().ID()
@@ -1025,6 +1184,11 @@ You could file a bug with the above information at:
 		}
 	}
 
+	if o.selfContained {
+		addPackageClause(f, v.pkgName())
+		return f
+	}
+
 outer:
 	for _, d := range f.Decls {
 		switch d.(type) {
@@ -1046,6 +1210,39 @@ outer:
 	}
 }
 
+// buildInstance reports the instance of the package in which v is defined, if
+// any.
+func (v Value) buildInstance() *build.Instance {
+	root := v.v
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	return v.idx.GetInstanceFromNode(root)
+}
+
+// pkgName reports the name of the package in which v is defined, if any.
+func (v Value) pkgName() string {
+	if p := v.buildInstance(); p != nil {
+		return p.PkgName
+	}
+	return ""
+}
+
+// addPackageClause adds a package clause for package name to f if f does not
+// have one already.
+func addPackageClause(f *ast.File, name string) {
+	if name == "" || name == "_" {
+		return
+	}
+	for _, d := range f.Decls {
+		if _, ok := d.(*ast.Package); ok {
+			return
+		}
+	}
+	pkg := &ast.Package{Name: ast.NewIdent(name)}
+	f.Decls = append([]ast.Decl{pkg}, f.Decls...)
+}
+
 // Doc returns all documentation comments associated with the field from which
 // the current value originates.
 func (v Value) Doc() []*ast.CommentGroup {
@@ -1258,6 +1455,29 @@ func (v Value) List() (Iterator, error) {
 	return Iterator{idx: v.idx, ctx: ctx, val: v, arcs: arcs}, nil
 }
 
+// ElementKinds reports the union of the kinds of the elements of the list v,
+// and whether all elements have the same kind. Non-concrete elements are
+// included with the kinds they may still assume, so [string, 4] results in
+// StringKind|IntKind. It reports an error if v is not a list.
+//
+// The union of an empty list is BottomKind.
+func (v Value) ElementKinds() (union Kind, allSame bool, err error) {
+	iter, err := v.List()
+	if err != nil {
+		return BottomKind, false, err
+	}
+	allSame = true
+	for i := 0; iter.Next(); i++ {
+		w, _ := iter.Value().Default()
+		k := w.IncompleteKind()
+		if i > 0 && k != union {
+			allSame = false
+		}
+		union |= k
+	}
+	return union, allSame, nil
+}
+
 // Null reports an error if v is not null.
 func (v Value) Null() error {
 	v, _ = v.Default()
@@ -1333,6 +1553,17 @@ func (v Value) structValData(ctx *adt.OpContext) (structValue, *adt.Bottom) {
 	})
 }
 
+// structValJSON returns the fields of v to include when marshaling with the
+// given options.
+func (v Value) structValJSON(ctx *adt.OpContext, o *options) (structValue, *adt.Bottom) {
+	return v.structValOpts(ctx, options{
+		omitHidden:      true,
+		omitDefinitions: true,
+		omitOptional:    true,
+		sortFields:      o.sortFields,
+	})
+}
+
 func (v Value) structValFull(ctx *adt.OpContext) (structValue, *adt.Bottom) {
 	return v.structValOpts(ctx, options{allowScalar: true})
 }
@@ -1378,6 +1609,9 @@ func (v Value) structValOpts(ctx *adt.OpContext, o options) (s structValue, err
 		k++
 	}
 	features = features[:k]
+	if o.sortFields {
+		export.SortFeatures(ctx, features)
+	}
 	return structValue{ctx, v, obj, features}, nil
 }
 
@@ -1422,6 +1656,12 @@ type FieldInfo struct {
 	IsHidden     bool
 }
 
+// Attributes reports the field attributes of the field, such as @go(Name) or
+// @protobuf(1), in the order in which they are defined.
+func (f FieldInfo) Attributes() []Attribute {
+	return f.Value.Attributes(FieldAttr)
+}
+
 func (s *hiddenStruct) Len() int {
 	return s.structValue.Len()
 }
@@ -1587,17 +1827,27 @@ func (v hiddenValue) LookupField(name string) (FieldInfo, error) {
 	return f, err
 }
 
-// TODO: expose this API?
+// EvalExpr evaluates an expression within the scope of v. Identifiers in expr
+// resolve to the fields of v, including definitions and hidden fields of the
+// package of v, or, if v has no such field, to the fields of the structs
+// enclosing v. For instance, if v is a struct with field a, the expression
+// a.b[x] + 1 refers to field a of v and x is looked up in v and its parents.
 //
-// // EvalExpr evaluates an expression within the scope of v, which must be
-// // a struct.
-// //
-// // Expressions may refer to builtin packages if they can be uniquely identified.
-// func (v Value) EvalExpr(expr ast.Expr) Value {
-// 	ctx := v.ctx()
-// 	result := evalExpr(ctx, v.eval(ctx), expr)
-// 	return newValueRoot(ctx, result)
-// }
+// Expressions may refer to builtin packages if they can be uniquely identified.
+func (v Value) EvalExpr(expr ast.Expr) Value {
+	if v.v == nil {
+		return newErrValue(v, mkErr(v.idx, nil, 0, "undefined value"))
+	}
+	path := ""
+	if p := v.buildInstance(); p != nil {
+		path = p.ID()
+	}
+	return v.Context().BuildExpr(expr,
+		Scope(v),
+		InferBuiltins(true),
+		ImportPath(path),
+	)
+}
 
 // Fill creates a new value by unifying v with the value of x at the given path.
 //
@@ -1719,7 +1969,8 @@ func (v Value) FillPath(p Path, x interface{}) Value {
 // The returned function returns the value that would be unified with field
 // given its name.
 //
-// Deprecated: use LookupPath in combination with using optional selectors.
+// Deprecated: use Constraint, or LookupPath in combination with using optional
+// selectors.
 func (v hiddenValue) Template() func(label string) Value {
 	if v.v == nil {
 		return nil
@@ -1735,6 +1986,53 @@ func (v hiddenValue) Template() func(label string) Value {
 	}
 }
 
+// Constraint returns the constraint that would apply to a new regular field
+// with the given label, as defined by the optional fields and pattern
+// constraints of v. It reports false if no such constraint applies, which
+// includes the case where v does not allow the field.
+//
+// Unlike LookupPath with an optional selector, Constraint does not take into
+// account any existing field with the given label.
+func (v Value) Constraint(label string) (Value, bool) {
+	if v.v == nil {
+		return Value{}, false
+	}
+	ctx := v.ctx()
+	x := &adt.Vertex{
+		Parent: v.v,
+		Label:  v.idx.StrLabel(label),
+	}
+	v.v.MatchAndInsert(ctx, x)
+	if len(x.Conjuncts) == 0 {
+		return Value{}, false
+	}
+	x.Finalize(ctx)
+	return makeValue(v.idx, x, linkParent(v.parent_, v.v, x)), true
+}
+
+// Matches reports whether the string s satisfies the constraints of v, for
+// instance a regular expression bound like =~"[a-z]+". It returns an error if
+// v is erroneous or does not allow strings.
+func (v Value) Matches(s string) (bool, error) {
+	if v.v == nil {
+		return false, v.toErr(errNotExists)
+	}
+	if err := v.Err(); err != nil {
+		return false, err
+	}
+	if k := v.IncompleteKind(); k&StringKind == 0 {
+		return false, v.toErr(mkErr(v.idx, v.v,
+			"cannot match string against value of type %s", k))
+	}
+
+	ctx := v.ctx()
+	n := &adt.Vertex{}
+	addConjuncts(n, v.v)
+	n.AddConjunct(adt.MakeRootConjunct(nil, &adt.String{Str: s}))
+	n.Finalize(ctx)
+	return n.Err(ctx, adt.Finalized) == nil, nil
+}
+
 // Subsume reports nil when w is an instance of v or an error otherwise.
 //
 // Without options, the entire value is considered for assumption, which means
@@ -1876,6 +2174,171 @@ func (v Value) UnifyAccept(w Value, accept Value) Value {
 	return makeValue(v.idx, n, v.parent_)
 }
 
+// UnifyPartial unifies v and w field by field. Unlike Unify, a conflict in a
+// field does not invalidate the entire result: fields that unify without error
+// are retained, while conflicting fields are omitted from the result and
+// reported in conflicts.
+//
+// Only regular fields of structs are unified separately; all other values,
+// including lists, are either unified as a whole or reported as a conflict.
+// The result is not closed, even if v or w is.
+func (v Value) UnifyPartial(w Value) (result Value, conflicts []Path) {
+	result = unifyPartial(&conflicts, nil, v, w)
+	return result, conflicts
+}
+
+func unifyPartial(conflicts *[]Path, path []Selector, v, w Value) Value {
+	u := v.Unify(w)
+	if u.Validate() == nil {
+		return u
+	}
+	if v.IncompleteKind() != StructKind || w.IncompleteKind() != StructKind ||
+		v.Err() != nil || w.Err() != nil {
+		p := MakePath(append([]Selector{}, path...)...)
+		*conflicts = append(*conflicts, p)
+		return u
+	}
+
+	s := &adt.StructLit{}
+	add := func(sel Selector, x Value) {
+		s.Decls = append(s.Decls, &adt.Field{
+			Label: sel.sel.feature(v.idx),
+			Value: x.v,
+		})
+	}
+
+	seen := map[string]bool{}
+	for iter, _ := v.Fields(); iter.Next(); {
+		sel := iter.Selector()
+		seen[sel.String()] = true
+
+		x := w.LookupPath(MakePath(sel))
+		if !x.Exists() {
+			add(sel, iter.Value())
+			continue
+		}
+		x = unifyPartial(conflicts, append(path, sel), iter.Value(), x)
+		if x.Validate() == nil {
+			add(sel, x)
+		}
+	}
+	for iter, _ := w.Fields(); iter.Next(); {
+		if sel := iter.Selector(); !seen[sel.String()] {
+			add(sel, iter.Value())
+		}
+	}
+
+	n := &adt.Vertex{}
+	n.AddConjunct(adt.MakeRootConjunct(nil, s))
+	n.Finalize(v.ctx())
+	return makeValue(v.idx, n, v.parent_)
+}
+
+// Layered unifies the given layers, in order, on top of schema, selects
+// defaults, closes the result and checks that it is concrete. It reports the
+// first error encountered, if any.
+//
+// Layered is intended for configuration systems where values are defined in
+// order of increasing precedence, for instance defaults < file < environment <
+// flags. Unlike a sequence of calls to Unify, Layered evaluates the combined
+// value only once.
+//
+// All values must be obtained from the same Context.
+func Layered(schema Value, layers ...Value) (Value, error) {
+	if schema.v == nil {
+		return schema, errors.Newf(token.NoPos, "undefined schema")
+	}
+
+	n := &adt.Vertex{}
+	all := append([]Value{schema}, layers...)
+	for _, w := range all {
+		if w.v != nil {
+			addConjuncts(n, w.v)
+		}
+	}
+
+	ctx := schema.ctx()
+	n.Finalize(ctx)
+
+	n.Parent = schema.v.Parent
+	n.Label = schema.v.Label
+
+	v := makeValue(schema.idx, n, schema.parent_)
+	for _, w := range all {
+		if w.v == nil {
+			continue
+		}
+		n.Closed = n.Closed || w.v.Closed
+		if err := allowed(ctx, w.v, n); err != nil {
+			v = newErrValue(v, err)
+			return v, v.Err()
+		}
+	}
+
+	if err := v.Validate(Concrete(true)); err != nil {
+		return v, errors.Errors(err)[0]
+	}
+
+	d := &adt.Vertex{
+		Parent: n.Parent,
+		Label:  n.Label,
+	}
+	d.AddConjunct(adt.MakeRootConjunct(nil, dataExpr(n, false)))
+	d.Finalize(ctx)
+	closeData(d)
+
+	return makeValue(schema.idx, d, schema.parent_), nil
+}
+
+// dataExpr returns an expression for the regular fields of an evaluated
+// vertex, selecting defaults along the way. Lists are kept open if they were
+// open in v and open is true.
+func dataExpr(v *adt.Vertex, open bool) adt.Expr {
+	// Default closes lists, so only use it to resolve disjunctions.
+	if _, ok := v.BaseValue.(*adt.ListMarker); !ok {
+		v = v.Default()
+	}
+	switch x := v.BaseValue.(type) {
+	case *adt.StructMarker:
+		s := &adt.StructLit{}
+		for _, a := range v.Arcs {
+			if a.Label.IsRegular() {
+				s.Decls = append(s.Decls, &adt.Field{
+					Label: a.Label,
+					Value: dataExpr(a, open),
+				})
+			}
+		}
+		return s
+
+	case *adt.ListMarker:
+		l := &adt.ListLit{}
+		for _, a := range v.Elems() {
+			l.Elems = append(l.Elems, dataExpr(a, open))
+		}
+		if open && !v.IsClosedList() {
+			l.Elems = append(l.Elems, &adt.Ellipsis{})
+		}
+		return l
+
+	case adt.Value:
+		return x
+
+	default:
+		return v.Value()
+	}
+}
+
+// closeData recursively closes all structs in v.
+func closeData(v *adt.Vertex) {
+	if v.Kind() == adt.StructKind {
+		v.Closed = true
+	}
+	for _, a := range v.Arcs {
+		closeData(a)
+	}
+}
+
 // Equals reports whether two values are equal, ignoring optional fields.
 // The result is undefined for incomplete values.
 func (v Value) Equals(other Value) bool {
@@ -2008,6 +2471,14 @@ type options struct {
 	docs              bool
 	disallowCycles    bool // implied by concrete
 	allowScalar       bool
+	omitEmpty         bool
+	kindComments      bool
+	sortFields        bool
+	selfContained     bool
+
+	hasResolveBuiltins bool
+	resolveBuiltins    bool
+	preserveLabels     bool
 }
 
 // An Option defines modes of evaluation.
@@ -2113,6 +2584,66 @@ func Attributes(include bool) Option {
 	return func(p *options) { p.omitAttrs = !include }
 }
 
+// KindComments annotates fields with non-concrete values with a comment
+// listing the kinds the value may assume, for instance // int|string. It is
+// only used by Syntax and has no effect in combination with Final or
+// Concrete.
+func KindComments() Option {
+	return func(p *options) { p.kindComments = true }
+}
+
+// ResolveBuiltins indicates whether Syntax should replace calls to builtin
+// functions with their result. By default, such calls are retained unless
+// Final or Concrete is used. In either case, only calls whose arguments do not
+// refer to other values are affected, and calls are only replaced if their
+// result is concrete.
+func ResolveBuiltins(resolve bool) Option {
+	return func(p *options) {
+		p.hasResolveBuiltins = true
+		p.resolveBuiltins = resolve
+	}
+}
+
+// PreserveLabelStyle indicates that Syntax should retain the quotes of labels
+// that were quoted in the source, such as "foo" in "foo": 1, rather than
+// quoting labels only where needed. It only applies to regular fields.
+func PreserveLabelStyle() Option {
+	return func(p *options) { p.preserveLabels = true }
+}
+
+// OmitEmpty indicates that regular fields with an empty value should be
+// omitted when marshaling, akin to the omitempty tag of encoding/json. Values
+// are empty if they are null, false, 0, the empty string or bytes, an empty
+// list, or a struct without regular fields.
+//
+// Optional fields are never included, regardless of this option.
+func OmitEmpty() Option {
+	return func(p *options) { p.omitEmpty = true }
+}
+
+// SortFields indicates that the fields of structs should be ordered by label
+// rather than in the order in which they were declared. Regular fields come
+// first, followed by definitions and hidden fields, each sorted by name. The
+// resulting order is stable across evaluations and file layouts, which makes
+// it suitable for output that is reviewed as a diff.
+//
+// It applies to Syntax, Fields, and the JSON encoding methods that accept
+// options.
+func SortFields() Option {
+	return func(p *options) { p.sortFields = true }
+}
+
+// SelfContained indicates that Syntax should always return an *ast.File that
+// can be compiled as is. The file includes the package clause of the instance
+// from which the value originates, if any, and import declarations for all
+// packages, including builtin packages, referred to by the result.
+//
+// References to values outside of v are retained as is. Use Final or Concrete
+// to resolve these.
+func SelfContained() Option {
+	return func(p *options) { p.selfContained = true }
+}
+
 func getOptions(opts []Option) (o options) {
 	o.updateOptions(opts)
 	return
@@ -2139,25 +2670,88 @@ func (v Value) Validate(opts ...Option) error {
 
 	b := validate.Validate(v.ctx(), v.v, cfg)
 	if b != nil {
+		if b.IsIncomplete() {
+			return markIncomplete(b.Err)
+		}
 		return b.Err
 	}
 	return nil
 }
 
+// A FieldError associates an error with the path of the field that caused it.
+type FieldError struct {
+	// Path is the path of the field, relative to the validated value.
+	Path Path
+
+	Err error
+}
+
+// ValidateData unifies data with the schema v and reports, for each regular
+// field of the result, the error that occurred for that field, if any. This
+// includes type mismatches, violated bounds and patterns, disallowed fields,
+// and fields required by v that are not concrete.
+//
+// Errors are reported for the deepest field at which they occur, in the order
+// of the fields of the result. ValidateData returns nil if the result is
+// valid and concrete.
+func (v Value) ValidateData(data Value) []FieldError {
+	var a []FieldError
+	validateData(&a, nil, v.Unify(data))
+	return a
+}
+
+func validateData(a *[]FieldError, path []Selector, v Value) {
+	if v.v == nil {
+		return
+	}
+	n := v.v.Default()
+
+	b, isErr := n.BaseValue.(*adt.Bottom)
+	switch {
+	case isErr && !b.ChildError:
+		*a = append(*a, FieldError{
+			Path: MakePath(append([]Selector{}, path...)...),
+			Err:  v.Err(),
+		})
+
+	case isErr, n.IsList(), n.Kind() == adt.StructKind:
+		for _, arc := range n.Arcs {
+			if !arc.Label.IsRegular() {
+				continue
+			}
+			w := makeValue(v.idx, arc, linkParent(v.parent_, n, arc))
+			sel := featureToSel(arc.Label, v.idx)
+			validateData(a, append(path, sel), w)
+		}
+
+	default:
+		if err := v.Validate(Concrete(true)); err != nil {
+			*a = append(*a, FieldError{
+				Path: MakePath(append([]Selector{}, path...)...),
+				Err:  err,
+			})
+		}
+	}
+}
+
 // Walk descends into all values of v, calling f. If f returns false, Walk
-// will not descent further. It only visits values that are part of the data
-// model, so this excludes optional fields, hidden fields, and definitions.
-func (v Value) Walk(before func(Value) bool, after func(Value)) {
-	ctx := v.ctx()
+// will not descent further. By default, it only visits values that are part
+// of the data model, so this excludes optional fields, hidden fields, and
+// definitions.
+//
+// The options determine which fields of structs are visited, as for Fields.
+// For instance, use Optional(true) and Definitions(true) to traverse schema.
+// The attributes and doc comments of visited fields are available through
+// the Attributes and Doc methods of the values passed to before and after.
+func (v Value) Walk(before func(Value) bool, after func(Value), opts ...Option) {
 	switch v.Kind() {
 	case StructKind:
 		if before != nil && !before(v) {
 			return
 		}
-		obj, _ := v.structValData(ctx)
-		for i := 0; i < obj.Len(); i++ {
-			_, v := obj.At(i)
-			v.Walk(before, after)
+		iter, _ := v.Fields(opts...)
+		for iter.Next() {
+			iter.Value().Walk(before, after, opts...)
 		}
 	case ListKind:
 		if before != nil && !before(v) {
@@ -2165,7 +2759,7 @@ func (v Value) Walk(before func(Value) bool, after func(Value)) {
 		}
 		list, _ := v.List()
 		for list.Next() {
-			list.Value().Walk(before, after)
+			list.Value().Walk(before, after, opts...)
 		}
 	default:
 		if before != nil {
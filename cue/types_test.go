@@ -28,6 +28,9 @@ import (
 	"github.com/google/go-cmp/cmp"
 
 	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/parser"
 	"cuelang.org/go/internal/astinternal"
 	"cuelang.org/go/internal/core/adt"
 	"cuelang.org/go/internal/core/debug"
@@ -567,6 +570,33 @@ func TestError(t *testing.T) {
 	}
 }
 
+func TestErrorDiagnostics(t *testing.T) {
+	testCases := []struct {
+		value    string
+		severity errors.Severity
+	}{{
+		value:    `a: int, b: a + 1`,
+		severity: errors.SeverityIncomplete,
+	}, {
+		value:    `a: 1 & 2`,
+		severity: errors.SeverityError,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			err := getInstance(t, tc.value).Value().Validate(Concrete(true))
+			diags := errors.Diagnostics(err)
+			if len(diags) == 0 {
+				t.Fatal("got no diagnostics")
+			}
+			for _, d := range diags {
+				if d.Severity != tc.severity {
+					t.Errorf("%s: got severity %v; want %v", d.Message, d.Severity, tc.severity)
+				}
+			}
+		})
+	}
+}
+
 func TestNull(t *testing.T) {
 	testCases := []struct {
 		value string
@@ -668,6 +698,51 @@ func TestList(t *testing.T) {
 	}
 }
 
+func TestElementKinds(t *testing.T) {
+	testCases := []struct {
+		value   string
+		union   Kind
+		allSame bool
+		err     string
+	}{{
+		value:   `[]`,
+		union:   BottomKind,
+		allSame: true,
+	}, {
+		value:   `[1, 2, 3]`,
+		union:   IntKind,
+		allSame: true,
+	}, {
+		value: `[string, 4]`,
+		union: StringKind | IntKind,
+	}, {
+		value: `[1, 2.0, "a"]`,
+		union: IntKind | FloatKind | StringKind,
+	}, {
+		value:   `[*1 | "a", 2, ...string]`,
+		union:   IntKind,
+		allSame: true,
+	}, {
+		value: `{a: 1}`,
+		union: BottomKind,
+		err:   "cannot use value",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			v := getInstance(t, "a: "+tc.value).Lookup("a")
+
+			union, allSame, err := v.ElementKinds()
+			checkErr(t, err, tc.err, "ElementKinds")
+			if union != tc.union {
+				t.Errorf("union: got %v; want %v", union, tc.union)
+			}
+			if allSame != tc.allSame {
+				t.Errorf("allSame: got %v; want %v", allSame, tc.allSame)
+			}
+		})
+	}
+}
+
 func TestFields(t *testing.T) {
 	testCases := []struct {
 		value string
@@ -1177,6 +1252,21 @@ func TestFillPath(t *testing.T) {
 		x:    1,
 		path: MakePath(Str("b").Optional()),
 		out:  `{b: 1}`,
+	}, {
+		in:   `a: [{b: [1, int]}]`,
+		x:    2,
+		path: ParsePath("a[0].b[1]"),
+		out:  `a: [{b: [1, 2]}]`,
+	}, {
+		in: `
+		#D: a: [_, {b: int}]
+		x: #D & {a: [1, _]}
+		`,
+		x:    2,
+		path: ParsePath("#D.a[1].b"),
+		out: `
+		x: {a: [1, {b: 2}]}
+		`,
 	}}
 
 	for _, tc := range testCases {
@@ -1688,6 +1778,97 @@ func TestTemplate(t *testing.T) {
 	}
 }
 
+func TestConstraint(t *testing.T) {
+	testCases := []struct {
+		value string
+		label string
+		want  string
+		ok    bool
+	}{{
+		value: `[Name=string]: {name: Name}`,
+		label: "foo",
+		want:  "{\n\tname: \"foo\"\n}",
+		ok:    true,
+	}, {
+		value: `[=~"^a"]: int, [=~"b$"]: <10`,
+		label: "ab",
+		want:  `<10 & int`,
+		ok:    true,
+	}, {
+		value: `foo?: string, bar: int`,
+		label: "foo",
+		want:  `string`,
+		ok:    true,
+	}, {
+		value: `foo?: string, bar: int`,
+		label: "bar",
+	}, {
+		value: `[=~"^a"]: int`,
+		label: "b",
+	}, {
+		value: `close({[=~"^a"]: int})`,
+		label: "b",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			v := getInstance(t, "a: {"+tc.value+"}").Lookup("a")
+
+			c, ok := v.Constraint(tc.label)
+			if ok != tc.ok {
+				t.Fatalf("ok: got %v; want %v", ok, tc.ok)
+			}
+			if !ok {
+				return
+			}
+			if got := fmt.Sprint(c); got != tc.want {
+				t.Errorf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	testCases := []struct {
+		value string
+		input string
+		want  bool
+		err   string
+	}{{
+		value: `=~"^[a-z]+$"`,
+		input: "abc",
+		want:  true,
+	}, {
+		value: `=~"^[a-z]+$"`,
+		input: "aBc",
+	}, {
+		value: `=~"^a" & !~"z$"`,
+		input: "abz",
+	}, {
+		value: `string & strings.MinRunes(3)`,
+		input: "ab",
+	}, {
+		value: `*"x" | =~"^[0-9]+$"`,
+		input: "123",
+		want:  true,
+	}, {
+		value: `int`,
+		input: "1",
+		err:   "cannot match string against value of type int",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			v := getInstance(t, `import "strings"
+a: `+tc.value).Lookup("a")
+
+			got, err := v.Matches(tc.input)
+			checkErr(t, err, tc.err, "Matches")
+			if got != tc.want {
+				t.Errorf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestElem(t *testing.T) {
 	testCases := []struct {
 		value string
@@ -1867,6 +2048,67 @@ func TestSubsume(t *testing.T) {
 	}
 }
 
+func TestSubsumeReport(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  []string
+	}{{
+		value: `
+		a: {x: int, y: string}
+		b: {x: 1, y: "foo"}
+		`,
+		want: nil,
+	}, {
+		value: `
+		a: {x: int, y: string, z: {p: bool, q: >0}}
+		b: {x: "1", y: "foo", z: {p: 1, q: -1}}
+		`,
+		want: []string{"x", "z.p", "z.q"},
+	}, {
+		value: `
+		a: {x: int, y: string, o?: int}
+		b: {x: 1}
+		`,
+		want: []string{"y"},
+	}, {
+		value: `
+		a: close({x: int})
+		b: {x: 1, y: 2}
+		`,
+		want: []string{"y"},
+	}, {
+		value: `
+		a: [int, {s: string}]
+		b: [1, {s: 2}]
+		`,
+		want: []string{"[1].s"},
+	}, {
+		value: `
+		a: int
+		b: string
+		`,
+		want: []string{""},
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			v := getInstance(t, tc.value).Value()
+			a := v.LookupPath(ParsePath("a"))
+			b := v.LookupPath(ParsePath("b"))
+
+			var got []string
+			for _, x := range a.SubsumeReport(b, Final()) {
+				if x.Err == nil {
+					t.Errorf("%v: missing error", x.Path)
+				}
+				got = append(got, x.Path.String())
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestSubsumes(t *testing.T) {
 	a := []string{"a"}
 	b := []string{"b"}
@@ -1983,6 +2225,144 @@ func TestUnify(t *testing.T) {
 	}
 }
 
+func TestUnifyPartial(t *testing.T) {
+	testCases := []struct {
+		v         string
+		w         string
+		want      string
+		conflicts []string
+	}{{
+		v:    `{a: 1, b: int}`,
+		w:    `{b: 2, c: 3}`,
+		want: "{\n\ta: 1\n\tb: 2\n\tc: 3\n}",
+	}, {
+		v:         `{a: 1, b: 2, c: {d: "x", e: 1}, l: [1]}`,
+		w:         `{a: 1, b: 3, c: {d: "y", e: int}, l: [2]}`,
+		want:      "{\n\ta: 1\n\tc: {\n\t\te: 1\n\t}\n}",
+		conflicts: []string{"b", "c.d", "l"},
+	}, {
+		v:         `{a: 1, b: {c: 1}}`,
+		w:         `{a: 1, b: "x", d: true}`,
+		want:      "{\n\ta: 1\n\td: true\n}",
+		conflicts: []string{"b"},
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			v := getInstance(t, tc.v).Value()
+			w := getInstance(t, tc.w).Value()
+
+			u, conflicts := v.UnifyPartial(w)
+
+			b, err := format.Node(u.Syntax(Final()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+
+			var got []string
+			for _, p := range conflicts {
+				got = append(got, p.String())
+			}
+			if !cmp.Equal(got, tc.conflicts) {
+				t.Error(cmp.Diff(got, tc.conflicts))
+			}
+		})
+	}
+}
+
+func TestSelectDefaults(t *testing.T) {
+	inst := getInstance(t, `
+	#D: {
+		a: *1 | int
+		b: {c: *"x" | string, d: int}
+		l: *[1] | [...int]
+		m: [*2 | int, ...int]
+	}
+	x: #D
+	`)
+	v := inst.Lookup("x").SelectDefaults()
+	if err := v.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	w := getInstance(t, `{b: {e: true}, m: [2, 3], n: "y"}`).Value()
+	u := v.Unify(w)
+	if err := u.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := format.Node(u.Syntax(Final()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+	a: 1
+	b: {
+		c: "x"
+		e: true
+		d: int
+	}
+	l: [1]
+	m: [2, 3]
+	n: "y"
+}`
+	if got := string(b); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLayered(t *testing.T) {
+	const schema = `
+	port:  *8080 | int
+	host:  string
+	debug: *false | bool
+	tags: [...string]
+	`
+	testCases := []struct {
+		layers []string
+		json   string
+		err    string
+	}{{
+		layers: []string{`host: "localhost"`, `tags: []`},
+		json:   `{"port":8080,"host":"localhost","debug":false,"tags":[]}`,
+	}, {
+		layers: []string{`host: "a", tags: ["x"]`, `port: 80`, `debug: true`},
+		json:   `{"port":80,"host":"a","debug":true,"tags":["x"]}`,
+	}, {
+		layers: []string{`tags: []`},
+		err:    "host: incomplete value string",
+	}, {
+		layers: []string{`host: "a", tags: []`, `port: "80"`},
+		err:    "port: 2 errors in empty disjunction",
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			var r Runtime
+			var layers []Value
+			for _, l := range tc.layers {
+				layers = append(layers, compileT(t, &r, l).Value())
+			}
+			v, err := Layered(compileT(t, &r, schema).Value(), layers...)
+			checkFatal(t, err, tc.err, "Layered")
+
+			b, err := v.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tc.json {
+				t.Errorf("\n got %v;\nwant %v", got, tc.json)
+			}
+
+			w := v.Unify(compileT(t, &r, `extra: 1`).Value())
+			if w.Err() == nil {
+				t.Error("expected result to be closed")
+			}
+		})
+	}
+}
+
 func TestEquals(t *testing.T) {
 	testCases := []struct {
 		a, b string
@@ -2054,6 +2434,57 @@ func TestEquals(t *testing.T) {
 }
 
 // TODO: options: disallow cycles.
+func TestValidateData(t *testing.T) {
+	const schema = `
+	#Schema: close({
+		name:  =~"^[a-z]+$"
+		port:  int & >0 & <65536
+		tags?: [...string]
+		sub?: {
+			kind: "a" | "b"
+		}
+	})
+	`
+	testCases := []struct {
+		data string
+		want []string
+	}{{
+		data: `{name: "foo", port: 80}`,
+	}, {
+		data: `{name: "Foo", port: 80, tags: ["a", 1]}`,
+		want: []string{
+			`name: #Schema.name: invalid value "Foo" (out of bound =~"^[a-z]+$")`,
+			`tags[1]: #Schema.tags.1: conflicting values 1 and string (mismatched types int and string)`,
+		},
+	}, {
+		data: `{name: "foo", port: 0, sub: {kind: "c"}}`,
+		want: []string{
+			`port: #Schema.port: invalid value 0 (out of bound >0)`,
+			`sub.kind: #Schema.sub.kind: 2 errors in empty disjunction: (and 2 more errors)`,
+		},
+	}, {
+		data: `{name: "foo", extra: 1}`,
+		want: []string{
+			`port: #Schema.port: incomplete value >0 & <65536 & int`,
+			`extra: #Schema: field not allowed: extra`,
+		},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.data, func(t *testing.T) {
+			s := getInstance(t, schema).LookupDef("#Schema")
+			d := getInstance(t, tc.data).Value()
+
+			var got []string
+			for _, e := range s.ValidateData(d) {
+				got = append(got, fmt.Sprintf("%v: %v", e.Path, e.Err))
+			}
+			if !cmp.Equal(got, tc.want) {
+				t.Error(cmp.Diff(got, tc.want))
+			}
+		})
+	}
+}
+
 func TestValidate(t *testing.T) {
 	testCases := []struct {
 		desc string
@@ -2651,9 +3082,442 @@ func TestMarshalJSON(t *testing.T) {
 	}
 }
 
+func TestMarshalJSONOmitEmpty(t *testing.T) {
+	testCases := []struct {
+		value string
+		json  string
+	}{{
+		value: `{a: 0, b: 0.0, c: "", d: '', e: false, f: null, g: [], h: {}}`,
+		json:  `{}`,
+	}, {
+		value: `{a: 1, b: "x", c: true, d: [0], e: {f: 0, g: 1}}`,
+		json:  `{"a":1,"b":"x","c":true,"d":[0],"e":{"g":1}}`,
+	}, {
+		value: `{a: *0 | int, b?: 1, c: {d: ""}}`,
+		json:  `{"c":{}}`,
+	}, {
+		value: `[{}, 0, ""]`,
+		json:  `[{},0,""]`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			inst := getInstance(t, "a: "+tc.value)
+			b, err := inst.Lookup("a").MarshalJSONWith(OmitEmpty())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := string(b); got != tc.json {
+				t.Errorf("\n got %v;\nwant %v", got, tc.json)
+			}
+		})
+	}
+}
+
+func TestEncodeJSON(t *testing.T) {
+	testCases := []struct {
+		value string
+		opts  []Option
+		err   string
+	}{{
+		value: `{a: 1, b: "<x>", c: [1, [2, 3], {d: null}], e: 'bytes', f: 1.5}`,
+	}, {
+		value: `{a: *1 | int, b: [...int], c: [...{d: *"x" | string}] & [{}, {}]}`,
+	}, {
+		value: `[]`,
+	}, {
+		value: `{a: 0, b: {c: ""}, d: [0]}`,
+		opts:  []Option{OmitEmpty()},
+	}, {
+		value: `{a: 1, b: [1, int]}`,
+		err:   "cue: marshal error: b.1: cannot convert incomplete value \"int\" to JSON",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			v := getInstance(t, tc.value).Value()
+			want, wantErr := v.MarshalJSONWith(tc.opts...)
+
+			var buf bytes.Buffer
+			err := v.EncodeJSON(&buf, tc.opts...)
+			checkErr(t, err, tc.err, "EncodeJSON")
+			if wantErr != nil {
+				if err == nil || err.Error() != wantErr.Error() {
+					t.Errorf("got error %v; want %v", err, wantErr)
+				}
+				return
+			}
+			if got := buf.String(); got != string(want) {
+				t.Errorf("\n got %v;\nwant %v", got, string(want))
+			}
+		})
+	}
+}
+
+func TestKindComments(t *testing.T) {
+	inst := getInstance(t, `
+	a: int | string
+	b: >=1
+	c: *"x" | string
+	d: 1
+	e: {f: bool, g: true}
+	l: [...int]
+	`)
+	b, err := format.Node(inst.Value().Syntax(KindComments()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+	a: int | string // int|string
+	b: >=1          // number
+	c: *"x" | string
+	d: 1
+	e: {
+		f: bool // bool
+		g: true
+	}
+	l: [...int]
+}`
+	if got := string(b); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestResolveBuiltins(t *testing.T) {
+	inst := getInstance(t, `
+	import "strings"
+
+	a: strings.ToUpper("x")
+	b: strings.MinRunes(3)
+	c: len("abc")
+	d: string
+	e: strings.ToUpper(d)
+	`)
+	testCases := []struct {
+		name string
+		opts []Option
+		want string
+	}{{
+		name: "default",
+		want: `import "strings"
+
+a: strings.ToUpper("x")
+b: strings.MinRunes(3)
+c: len("abc")
+d: string
+e: strings.ToUpper(d)`,
+	}, {
+		name: "resolve",
+		opts: []Option{ResolveBuiltins(true)},
+		want: `import "strings"
+
+a: "X"
+b: strings.MinRunes(3)
+c: 3
+d: string
+e: strings.ToUpper(d)`,
+	}, {
+		name: "final",
+		opts: []Option{Final()},
+		want: `import "strings"
+
+a: "X"
+b: strings.MinRunes(3)
+c: 3
+d: string
+e: strings.ToUpper(d)`,
+	}, {
+		name: "final keep",
+		opts: []Option{Final(), ResolveBuiltins(false)},
+		want: `import "strings"
+
+a: strings.ToUpper("x")
+b: strings.MinRunes(3)
+c: len("abc")
+d: string
+e: strings.ToUpper(d)`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := format.Node(inst.Value().Syntax(tc.opts...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(string(b)); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPreserveLabelStyle(t *testing.T) {
+	inst := getInstance(t, `
+	"a": 1
+	b:   2
+	"c-d": {"e": int, f?: string}
+	#g: {"h": true}
+	`)
+	testCases := []struct {
+		name string
+		opts []Option
+		want string
+	}{{
+		name: "default",
+		want: `{
+	a: 1
+	b: 2
+	"c-d": {
+		e:  int
+		f?: string
+	}
+	#g: {
+		h: true
+	}
+}`,
+	}, {
+		name: "preserve",
+		opts: []Option{PreserveLabelStyle()},
+		want: `{
+	"a": 1
+	b:   2
+	"c-d": {
+		"e": int
+		f?:  string
+	}
+	#g: {
+		"h": true
+	}
+}`,
+	}, {
+		name: "final",
+		opts: []Option{Final(), PreserveLabelStyle()},
+		want: `{
+	"a": 1
+	b:   2
+	"c-d": {
+		"e": int
+	}
+}`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := format.Node(inst.Value().Syntax(tc.opts...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSortFields(t *testing.T) {
+	inst := getInstance(t, `
+	b: 2
+	#d: {y: 1, x: 2}
+	a: {
+		for k, v in {q: 1, p: 2} {"\(k)": v}
+		c: 3
+	}
+	_h: 1
+	#c: 1
+	`)
+	testCases := []struct {
+		name string
+		opts []Option
+		want string
+	}{{
+		name: "default",
+		want: `{
+	b: 2
+	#d: {
+		y: 1
+		x: 2
+	}
+	a: {
+		for k, v in {
+			q: 1
+			p: 2
+		} {
+			"\(k)": v
+		}
+		c: 3
+	}
+	_h: 1
+	#c: 1
+}`,
+	}, {
+		name: "sorted",
+		opts: []Option{SortFields()},
+		want: `{
+	a: {
+		for k, v in {
+			p: 2
+			q: 1
+		} {
+			"\(k)": v
+		}
+		c: 3
+	}
+	b:  2
+	#c: 1
+	#d: {
+		x: 2
+		y: 1
+	}
+	_h: 1
+}`,
+	}, {
+		name: "final",
+		opts: []Option{Final(), SortFields()},
+		want: `{
+	a: {
+		c: 3
+		p: 2
+		q: 1
+	}
+	b: 2
+}`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := format.Node(inst.Value().Syntax(tc.opts...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+
+	b, err := inst.Value().MarshalJSONWith(SortFields())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), `{"a":{"c":3,"p":2,"q":1},"b":2}`; got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+
+	iter, _ := inst.Value().Fields(SortFields(), Definitions(true))
+	var labels []string
+	for iter.Next() {
+		labels = append(labels, iter.Selector().String())
+	}
+	if got, want := strings.Join(labels, " "), "a b #c #d"; got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
+
+func TestSelfContained(t *testing.T) {
+	inst := getInstance(t, `
+	package foo
+
+	import "strings"
+
+	a: {
+		x: strings.ToUpper(y)
+		y: string
+	}
+	b: "x"
+	`)
+	testCases := []struct {
+		path string
+		opts []Option
+		want string
+	}{{
+		path: "a",
+		want: `package foo
+
+import "strings"
+
+x: strings.ToUpper(y)
+y: string
+`,
+	}, {
+		path: "b",
+		opts: []Option{Final()},
+		want: `package foo
+
+"x"
+`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			v := inst.Value().LookupPath(ParsePath(tc.path))
+			n := v.Syntax(append(tc.opts, SelfContained())...)
+			if _, ok := n.(*ast.File); !ok {
+				t.Fatalf("got %T; want *ast.File", n)
+			}
+			b, err := format.Node(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+
+			var r Runtime
+			if _, err := r.Compile("out.cue", b); err != nil {
+				t.Errorf("cannot compile output: %v", err)
+			}
+		})
+	}
+}
+
+func TestEvalExpr(t *testing.T) {
+	inst := getInstance(t, `
+	a: b: [1, 2, 3]
+	x: 1
+	_h: 10
+	s: {
+		x: 2
+		t: {}
+	}
+	`)
+	testCases := []struct {
+		path string
+		expr string
+		want string
+	}{{
+		expr: "a.b[x] + 1",
+		want: "3",
+	}, {
+		expr: "_h + x",
+		want: "11",
+	}, {
+		// x resolves to s.x, the innermost field named x.
+		path: "s.t",
+		expr: "a.b[x]",
+		want: "3",
+	}, {
+		expr: "strings.ToUpper(\"a\")",
+		want: `"A"`,
+	}, {
+		expr: "y",
+		want: "_|_ // test:1:1: reference \"y\" not found",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			v := inst.Value()
+			if tc.path != "" {
+				v = v.LookupPath(ParsePath(tc.path))
+			}
+			expr, err := parser.ParseExpr("test", tc.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprint(v.EvalExpr(expr)); got != tc.want {
+				t.Errorf("got %s; want %s", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestWalk(t *testing.T) {
 	testCases := []struct {
 		value string
+		opts  []Option
 		out   string
 	}{{
 		value: `""`,
@@ -2713,6 +3577,17 @@ func TestWalk(t *testing.T) {
 	}, {
 		value: `{a: 2, b: 3, c: ["A", "B"]}`,
 		out:   `{a:2,b:3,c:["A","B"]}`,
+	}, {
+		value: `{a: 1, b?: 2, #D: {c: int}, _h: 3}`,
+		out:   `{a:1}`,
+	}, {
+		value: `{a: 1, b?: 2, #D: {c: int}, _h: 3}`,
+		opts:  []Option{Optional(true), Definitions(true), Hidden(true)},
+		out:   `{a:1,b:2,#D:{c:int},_h:3}`,
+	}, {
+		value: `{a: [{b?: 1}], #D: {c?: string}}`,
+		opts:  []Option{Optional(true), Definitions(true)},
+		out:   `{a:[{b:1}],#D:{c:string}}`,
 	}}
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("%d/%v", i, tc.value), func(t *testing.T) {
@@ -2753,7 +3628,7 @@ func TestWalk(t *testing.T) {
 					stripComma()
 					buf = append(buf, "],"...)
 				}
-			})
+			}, tc.opts...)
 			stripComma()
 			if got := string(buf); got != tc.out {
 				t.Errorf("\n got %v;\nwant %v", got, tc.out)
@@ -0,0 +1,271 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cuego
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/internal/value"
+)
+
+// An Adapter defines the CUE representation of values of a Go type.
+type Adapter struct {
+	// Schema is the CUE schema for values of the type. It is used in place of
+	// the schema that would otherwise be derived from the type. If empty, the
+	// derived schema is used.
+	Schema string
+
+	// ToCUE converts a value of the type to a Go value that is converted to
+	// CUE in its place. It is used by Validate and Complete.
+	ToCUE func(x interface{}) (interface{}, error)
+
+	// FromCUE converts a CUE value to a value of the type. It is used by
+	// Complete. If nil, the CUE value is decoded into the type as usual.
+	FromCUE func(v cue.Value) (interface{}, error)
+}
+
+var (
+	toCUE   = map[reflect.Type]func(x interface{}) (interface{}, error){}
+	fromCUE = map[reflect.Type]func(v cue.Value) (interface{}, error){}
+)
+
+// RegisterAdapter registers a to define the CUE representation of the type of
+// x for Validate, Complete, and Encode in all contexts.
+//
+// RegisterAdapter must be called before the type of x, or any type containing
+// it, is used with cuego. It is typically called from an init function in the
+// package defining the type.
+func RegisterAdapter(x interface{}, a Adapter) error {
+	t := reflect.TypeOf(x)
+	if t == nil {
+		return fmt.Errorf("cuego: cannot register adapter for nil")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if _, ok := toCUE[t]; ok {
+		return fmt.Errorf("cuego: adapter for %v already registered", t)
+	}
+	if _, ok := fromCUE[t]; ok {
+		return fmt.Errorf("cuego: adapter for %v already registered", t)
+	}
+
+	if a.Schema != "" {
+		expr, err := parser.ParseExpr(fmt.Sprintf("<%v>", t), a.Schema)
+		if err != nil {
+			return err
+		}
+		if err := value.SetTypeSchema(runtime, x, expr); err != nil {
+			return err
+		}
+	}
+	if a.ToCUE != nil {
+		toCUE[t] = a.ToCUE
+	}
+	if a.FromCUE != nil {
+		fromCUE[t] = a.FromCUE
+	}
+	return nil
+}
+
+// decode decodes v into x, which must be a pointer, using the registered
+// adapters for values of adapted types.
+func decode(v cue.Value, x interface{}) error {
+	rv := reflect.ValueOf(x)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return v.Decode(x) // Let Decode report the error.
+	}
+	return decodeValue(v, rv.Elem(), map[reflect.Type]bool{})
+}
+
+func decodeValue(v cue.Value, rv reflect.Value, seen map[reflect.Type]bool) error {
+	t := rv.Type()
+
+	mutex.Lock()
+	f := fromCUE[t]
+	mutex.Unlock()
+
+	if f != nil {
+		y, err := f(v)
+		if err != nil {
+			return err
+		}
+		yv := reflect.ValueOf(y)
+		if !yv.IsValid() {
+			rv.Set(reflect.Zero(t))
+			return nil
+		}
+		if !yv.Type().AssignableTo(t) {
+			return fmt.Errorf("cuego: adapter for %v returned value of type %v", t, yv.Type())
+		}
+		rv.Set(yv)
+		return nil
+	}
+
+	if !hasAdapted(t, seen) {
+		return v.Decode(rv.Addr().Interface())
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		if v.Null() == nil {
+			rv.Set(reflect.Zero(t))
+			return nil
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.New(t.Elem()))
+		}
+		return decodeValue(v, rv.Elem(), seen)
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" {
+				continue
+			}
+			name, ok := fieldName(sf)
+			if !ok {
+				continue
+			}
+			if name == "" {
+				// Embedded struct of which the fields are inlined.
+				if err := decodeValue(v, rv.Field(i), seen); err != nil {
+					return err
+				}
+				continue
+			}
+			w := v.LookupPath(cue.MakePath(cue.Str(name)))
+			if !w.Exists() {
+				continue
+			}
+			if err := decodeValue(w, rv.Field(i), seen); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Slice:
+		if v.Null() == nil {
+			rv.Set(reflect.Zero(t))
+			return nil
+		}
+		iter, err := v.List()
+		if err != nil {
+			return err
+		}
+		s := reflect.MakeSlice(t, 0, 0)
+		for iter.Next() {
+			e := reflect.New(t.Elem()).Elem()
+			if err := decodeValue(iter.Value(), e, seen); err != nil {
+				return err
+			}
+			s = reflect.Append(s, e)
+		}
+		rv.Set(s)
+		return nil
+
+	case reflect.Map:
+		if v.Null() == nil {
+			rv.Set(reflect.Zero(t))
+			return nil
+		}
+		iter, err := v.Fields()
+		if err != nil {
+			return err
+		}
+		m := reflect.MakeMap(t)
+		for iter.Next() {
+			e := reflect.New(t.Elem()).Elem()
+			if err := decodeValue(iter.Value(), e, seen); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(iter.Label()).Convert(t.Key()), e)
+		}
+		rv.Set(m)
+		return nil
+	}
+
+	return v.Decode(rv.Addr().Interface())
+}
+
+// hasAdapted reports whether values of type t may contain values of a type
+// for which a FromCUE adapter is registered.
+func hasAdapted(t reflect.Type, seen map[reflect.Type]bool) bool {
+	mutex.Lock()
+	n := len(fromCUE)
+	_, ok := fromCUE[t]
+	mutex.Unlock()
+
+	switch {
+	case n == 0:
+		return false
+	case ok:
+		return true
+	}
+
+	if done, ok := seen[t]; ok {
+		return done
+	}
+	seen[t] = false // Break cycles.
+
+	var found bool
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		found = hasAdapted(t.Elem(), seen)
+
+	case reflect.Map:
+		found = t.Key().Kind() == reflect.String && hasAdapted(t.Elem(), seen)
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField() && !found; i++ {
+			sf := t.Field(i)
+			if _, ok := fieldName(sf); ok && sf.PkgPath == "" {
+				found = hasAdapted(sf.Type, seen)
+			}
+		}
+	}
+	seen[t] = found
+	return found
+}
+
+// fieldName reports the name of the CUE field corresponding to the given Go
+// field, or "" if the fields of an embedded struct are inlined. It reports
+// false if the field is omitted. It mirrors the naming used for conversion.
+func fieldName(sf reflect.StructField) (name string, ok bool) {
+	if tag, _ := sf.Tag.Lookup("json"); tag == "-" {
+		return "", false
+	}
+	name = sf.Name
+	if sf.Anonymous {
+		name = ""
+	}
+	for _, s := range []string{"json", "yaml", "protobuf"} {
+		if tag, ok := sf.Tag.Lookup(s); ok {
+			if p := strings.IndexByte(tag, ','); p >= 0 {
+				tag = tag[:p]
+			}
+			if tag != "" {
+				name = tag
+				break
+			}
+		}
+	}
+	return name, name != "-"
+}
@@ -20,8 +20,12 @@ import (
 	"sync"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/format"
 	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/internal"
+	"cuelang.org/go/internal/core/convert"
 	"cuelang.org/go/internal/value"
 )
 
@@ -61,6 +65,17 @@ func Complete(x interface{}) error {
 	return DefaultContext.Complete(x)
 }
 
+// Encode returns the CUE source of the schema for the type of x. The schema
+// includes the constraints defined through field tags and Constrain.
+func Encode(x interface{}) (string, error) {
+	return DefaultContext.Encode(x)
+}
+
+// EncodeExpr is like Encode, but returns the schema as a CUE expression.
+func EncodeExpr(x interface{}) (ast.Expr, error) {
+	return DefaultContext.EncodeExpr(x)
+}
+
 // A Context holds type constraints that are only applied within a given
 // context.
 // Global constraints that are defined at the time a constraint is
@@ -73,7 +88,7 @@ type Context struct {
 // the type of x.
 //
 // Constraints for x can be defined as field tags or through the Register
-// function.
+// function. The errors reported are of type *FieldError.
 func (c *Context) Validate(x interface{}) error {
 	a := c.load(x)
 	v, err := fromGoValue(x, false)
@@ -82,7 +97,7 @@ func (c *Context) Validate(x interface{}) error {
 	}
 	v = a.Unify(v)
 	if err := v.Validate(); err != nil {
-		return err
+		return fieldErrors(reflect.TypeOf(x), err)
 	}
 	// TODO: validate all values are concrete. (original value subsumes result?)
 	return nil
@@ -106,9 +121,35 @@ func (c *Context) Complete(x interface{}) error {
 	}
 	v = a.Unify(v)
 	if err := v.Validate(cue.Concrete(true)); err != nil {
-		return err
+		return fieldErrors(reflect.TypeOf(x), err)
+	}
+	return decode(v, x)
+}
+
+// Encode returns the CUE source of the schema for the type of x. The schema
+// includes the constraints defined through field tags and Constrain.
+func (c *Context) Encode(x interface{}) (string, error) {
+	expr, err := c.EncodeExpr(x)
+	if err != nil {
+		return "", err
 	}
-	return v.Decode(x)
+	b, err := format.Node(expr)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// EncodeExpr is like Encode, but returns the schema as a CUE expression.
+func (c *Context) EncodeExpr(x interface{}) (ast.Expr, error) {
+	v := c.load(x)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	return internal.ToExpr(v.Syntax()), nil
 }
 
 func (c *Context) load(x interface{}) cue.Value {
@@ -177,7 +218,10 @@ func fromGoValue(x interface{}, nilIsNull bool) (v cue.Value, err error) {
 	// Instance) here as any previously unrecognized field can never match an
 	// existing one and can only be merged.
 	mutex.Lock()
-	v = value.FromGoValue(runtime, x, nilIsNull)
+	v = value.FromGoValueWith(runtime, x, convert.Options{
+		NilIsTop: nilIsNull,
+		Adapters: toCUE,
+	})
 	mutex.Unlock()
 	if err := v.Err(); err != nil {
 		return v, err
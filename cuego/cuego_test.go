@@ -16,7 +16,11 @@ package cuego
 
 import (
 	"reflect"
+	"strings"
 	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
 )
 
 type Sum struct {
@@ -203,3 +207,121 @@ func TestUpdate(t *testing.T) {
 		})
 	}
 }
+
+// temperature has no exported fields and is represented in CUE as a number
+// through an adapter.
+type temperature struct{ celsius float64 }
+
+type reading struct {
+	Temp  temperature
+	Max   *temperature  `cue:"Temp" json:",omitempty"`
+	Trend []temperature `cue:"[Temp]" json:",omitempty"`
+}
+
+func init() {
+	err := RegisterAdapter(temperature{}, Adapter{
+		Schema: "number & >=-273.15",
+		ToCUE: func(x interface{}) (interface{}, error) {
+			return x.(temperature).celsius, nil
+		},
+		FromCUE: func(v cue.Value) (interface{}, error) {
+			f, err := v.Float64()
+			return temperature{f}, err
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestAdapter(t *testing.T) {
+	ctx := &Context{}
+
+	if err := ctx.Validate(&reading{Temp: temperature{20}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := ctx.Validate(&reading{Temp: temperature{-300}})
+	checkErr(t, err, "out of bound")
+
+	r := &reading{Temp: temperature{21.5}}
+	if err := ctx.Complete(r); err != nil {
+		t.Fatal(err)
+	}
+	want := &reading{
+		Temp:  temperature{21.5},
+		Max:   &temperature{21.5},
+		Trend: []temperature{{21.5}},
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("Complete: got %#v; want %#v", r, want)
+	}
+
+	got, err := Encode(temperature{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ">=-273.15"; got != want {
+		t.Errorf("Encode: got %q; want %q", got, want)
+	}
+
+	err = RegisterAdapter(temperature{}, Adapter{})
+	checkErr(t, err, "already registered")
+}
+
+type address struct {
+	Zip string `json:"zip" cue:"=~\"^[0-9]{5}$\""`
+}
+
+type person struct {
+	Name      string             `json:"name" cue:"!=\"\""`
+	Addresses []address          `json:"addresses"`
+	Contacts  map[string]address `json:"contacts"`
+}
+
+func TestFieldError(t *testing.T) {
+	testCases := []struct {
+		value interface{}
+		index []int
+		path  []string
+	}{{
+		value: person{},
+		index: []int{0},
+		path:  []string{"name"},
+	}, {
+		value: person{
+			Name:      "x",
+			Addresses: []address{{"12345"}, {"1"}},
+		},
+		index: []int{1, 1, 0},
+		path:  []string{"addresses", "1", "zip"},
+	}, {
+		value: person{
+			Name:     "x",
+			Contacts: map[string]address{"home": {"1"}},
+		},
+		index: []int{2, -1, 0},
+		path:  []string{"contacts", "home", "zip"},
+	}}
+	for _, tc := range testCases {
+		t.Run(strings.Join(tc.path, "."), func(t *testing.T) {
+			err := Validate(tc.value)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, e := range errors.Errors(err) {
+				fe, ok := e.(*FieldError)
+				if !ok {
+					t.Fatalf("got error of type %T; want *FieldError", e)
+				}
+				if !reflect.DeepEqual(fe.JSONPath, tc.path) {
+					continue
+				}
+				if !reflect.DeepEqual(fe.Index, tc.index) {
+					t.Errorf("Index: got %v; want %v", fe.Index, tc.index)
+				}
+				return
+			}
+			t.Errorf("no error for path %v: %v", tc.path, err)
+		})
+	}
+}
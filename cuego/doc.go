@@ -55,6 +55,27 @@
 // value and there is a JSON field tag with the omitempty flag.
 // A Complete will implicitly validate a struct.
 //
+//
+// Encoding Schemas
+//
+// The constraints associated with a Go type, including those defined in field
+// tags, can be published as CUE using Encode:
+//
+//   src, err := cuego.Encode(Sum{})
+//
+//
+// Custom Types
+//
+// The CUE representation of a Go type that does not map naturally to CUE can
+// be defined with RegisterAdapter. An Adapter specifies the schema for the
+// type and how to convert its values to and from CUE:
+//
+//   cuego.RegisterAdapter(Celsius{}, cuego.Adapter{
+//       Schema:  "number & >=-273.15",
+//       ToCUE:   func(x interface{}) (interface{}, error) { ... },
+//       FromCUE: func(v cue.Value) (interface{}, error) { ... },
+//   })
+//
 package cuego // import "cuelang.org/go/cuego"
 
 // The first goal of this packages is to get the semantics right. After that,
@@ -0,0 +1,131 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cuego
+
+import (
+	"reflect"
+	"strconv"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+// A FieldError is a validation error that is associated with a value within
+// a Go value. Use errors.Errors to obtain the individual errors reported by
+// Validate and Complete.
+type FieldError struct {
+	// Err is the underlying CUE error.
+	Err errors.Error
+
+	// Index is the sequence of indices leading to the Go value that failed
+	// validation: the field index for structs, as used by
+	// reflect.Value.FieldByIndex, the element index for slices and arrays,
+	// and -1 for map entries. It is nil if the error could not be associated
+	// with a Go value.
+	Index []int
+
+	// JSONPath is the path to the failing value in the JSON encoding of the
+	// Go value. Its elements are the names of the fields, as determined by
+	// field tags, the element indices, and the map keys.
+	JSONPath []string
+}
+
+func (e *FieldError) Error() string                            { return e.Err.Error() }
+func (e *FieldError) Position() token.Pos                      { return e.Err.Position() }
+func (e *FieldError) InputPositions() []token.Pos              { return e.Err.InputPositions() }
+func (e *FieldError) Path() []string                           { return e.Err.Path() }
+func (e *FieldError) Msg() (format string, args []interface{}) { return e.Err.Msg() }
+func (e *FieldError) Unwrap() error                            { return e.Err }
+
+// fieldErrors converts the errors in err, which resulted from validating a
+// value of type t, to FieldErrors.
+func fieldErrors(t reflect.Type, err error) error {
+	var errs errors.Error
+	for _, e := range errors.Errors(err) {
+		path := e.Path()
+		errs = errors.Append(errs, &FieldError{
+			Err:      e,
+			Index:    fieldIndex(t, path),
+			JSONPath: path,
+		})
+	}
+	return errs
+}
+
+// fieldIndex reports the index sequence for the value at the given path
+// within values of type t, or nil if the path does not correspond to a Go
+// value.
+func fieldIndex(t reflect.Type, path []string) []int {
+	index := []int{}
+	for _, sel := range path {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			x, ft := structField(t, sel)
+			if x == nil {
+				return nil
+			}
+			index = append(index, x...)
+			t = ft
+
+		case reflect.Slice, reflect.Array:
+			i, err := strconv.Atoi(sel)
+			if err != nil {
+				return nil
+			}
+			index = append(index, i)
+			t = t.Elem()
+
+		case reflect.Map:
+			index = append(index, -1)
+			t = t.Elem()
+
+		default:
+			return nil
+		}
+	}
+	return index
+}
+
+// structField reports the index sequence and type of the field with the
+// given name within struct type t, including fields of embedded structs that
+// are inlined.
+func structField(t reflect.Type, name string) ([]int, reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		switch s, ok := fieldName(sf); {
+		case !ok:
+		case s == name:
+			return []int{i}, sf.Type
+		case s == "":
+			et := sf.Type
+			for et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+			if et.Kind() != reflect.Struct {
+				break
+			}
+			if x, ft := structField(et, name); x != nil {
+				return append([]int{i}, x...), ft
+			}
+		}
+	}
+	return nil, nil
+}
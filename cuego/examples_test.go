@@ -116,3 +116,27 @@ func errMsg(err error) string {
 	}
 	return s
 }
+
+func ExampleEncode() {
+	type Config struct {
+		Name    string `cue:"=~\"^[a-z]+$\""`
+		Port    int    `cue:">=1024 & <65536"`
+		Verbose bool   `json:",omitempty"`
+		Tags    []string
+	}
+
+	s, err := cuego.Encode(Config{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(s)
+
+	//Output:
+	// {
+	// 	Name:     =~"^[a-z]+$"
+	// 	Port:     uint & >=1024 & <65536
+	// 	Verbose?: bool
+	// 	Tags?:    *null | [...string]
+	// }
+}
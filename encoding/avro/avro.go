@@ -0,0 +1,85 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package avro converts Apache Avro schemas to CUE definitions and back.
+//
+// The named types of an Avro schema, records, enums, and fixed types, map to
+// CUE definitions. Named types that are declared inline are converted to
+// top-level definitions as well. The types map as follows:
+//
+//   Avro                      CUE
+//   null                      null
+//   boolean                   bool
+//   int                       int32
+//   long                      int64
+//   float                     float32
+//   double                    float64
+//   bytes                     bytes
+//   string                    string
+//   record                    closed struct
+//   enum                      disjunction of strings
+//   array                     [...T]
+//   map                       {[string]: T}
+//   union                     disjunction
+//   fixed                     bytes @avro(fixed,size=N)
+//
+// Defaults of record fields are marked as the default of the field type. The
+// namespace of a named type is recorded in an @avro attribute of its
+// definition.
+//
+// The logical types map to constraints:
+//
+//   date                      time.Format("2006-01-02")
+//   time-millis, time-micros  time.Format("15:04:05")
+//   timestamp-millis,
+//   timestamp-micros          time.Time
+//   uuid                      a string matching the UUID format
+//
+// Other logical types map to their underlying type. When converting back to
+// Avro, times and timestamps use millisecond precision.
+//
+// API Status: DRAFT: API may change without notice.
+package avro
+
+// Avro primitive types.
+const (
+	avroNull    = "null"
+	avroBoolean = "boolean"
+	avroInt     = "int"
+	avroLong    = "long"
+	avroFloat   = "float"
+	avroDouble  = "double"
+	avroBytes   = "bytes"
+	avroString  = "string"
+)
+
+// primitives maps Avro primitive types to the corresponding CUE identifiers.
+var primitives = map[string]string{
+	avroNull:    "null",
+	avroBoolean: "bool",
+	avroInt:     "int32",
+	avroLong:    "int64",
+	avroFloat:   "float32",
+	avroDouble:  "float64",
+	avroBytes:   "bytes",
+	avroString:  "string",
+}
+
+// uuidPattern matches the textual representation of a UUID.
+const uuidPattern = "^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$"
+
+const (
+	dateLayout = "2006-01-02"
+	timeLayout = "15:04:05"
+)
@@ -0,0 +1,403 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package avro
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+)
+
+func TestExtract(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		out  string
+	}{{
+		name: "record",
+		in: `{
+	"type": "record",
+	"name": "User",
+	"namespace": "com.example",
+	"doc": "A user of the system.",
+	"fields": [
+		{"name": "name", "type": "string", "doc": "The full name."},
+		{"name": "age", "type": "int"},
+		{"name": "score", "type": "double", "default": 1.5},
+		{"name": "email", "type": ["null", "string"], "default": null},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "attrs", "type": {"type": "map", "values": "long"}},
+		{"name": "_id", "type": "bytes", "default": "ÿ"}
+	]
+}`,
+		out: `// A user of the system.
+#User: {
+	// The full name.
+	name:  string
+	age:   int32
+	score: *1.5 | float64
+	email: *null | string
+	tags: [...string]
+	attrs: {
+		[string]: int64
+	}
+	"_id": *'\xff' | bytes
+} @avro(namespace=com.example)`,
+	}, {
+		name: "named types",
+		in: `{
+	"type": "record",
+	"name": "Node",
+	"fields": [
+		{"name": "kind", "type": {
+			"type": "enum",
+			"name": "Kind",
+			"symbols": ["LEAF", "BRANCH"],
+			"default": "LEAF"
+		}},
+		{"name": "hash", "type": {"type": "fixed", "name": "MD5", "size": 16}},
+		{"name": "other", "type": "Kind", "default": "BRANCH"},
+		{"name": "children", "type": {"type": "array", "items": "Node"}}
+	]
+}`,
+		out: `#Node: {
+	kind:  #Kind
+	hash:  #MD5
+	other: *"BRANCH" | #Kind
+	children: [...#Node]
+}
+#Kind: *"LEAF" | "BRANCH"
+#MD5:  bytes @avro(fixed,size=16)`,
+	}, {
+		name: "logical types",
+		in: `[{
+	"type": "record",
+	"name": "Event",
+	"fields": [
+		{"name": "id", "type": {"type": "string", "logicalType": "uuid"}},
+		{"name": "at", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "day", "type": {"type": "int", "logicalType": "date"}},
+		{"name": "clock", "type": {"type": "int", "logicalType": "time-millis"}},
+		{"name": "amount", "type": {
+			"type": "bytes",
+			"logicalType": "decimal",
+			"precision": 4,
+			"scale": 2
+		}}
+	]
+}]`,
+		out: `import "time"
+
+#Event: {
+	id:     =~"^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$"
+	at:     time.Time
+	day:    time.Format("2006-01-02")
+	clock:  time.Format("15:04:05")
+	amount: bytes
+}`,
+	}, {
+		name: "namespaces",
+		in: `[
+	{"type": "fixed", "name": "a.b.Hash", "size": 4},
+	{"type": "error", "name": "Failure", "namespace": "a.b", "fields": [
+		{"name": "hash", "type": "Hash"}
+	]}
+]`,
+		out: `#Hash: bytes @avro(fixed,size=4,namespace=a.b)
+#Failure: {
+	hash: #Hash
+} @avro(error,namespace=a.b)`,
+	}, {
+		name: "undefined",
+		in:   `{"type": "record", "name": "A", "fields": [{"name": "b", "type": "B"}]}`,
+		out:  `error: avro: undefined type "B"`,
+	}, {
+		name: "primitive",
+		in:   `"string"`,
+		out:  `error: avro: top-level schema must be a named type`,
+	}, {
+		name: "duplicate",
+		in: `[
+	{"type": "enum", "name": "A", "symbols": ["X"]},
+	{"type": "enum", "name": "A", "symbols": ["Y"]}
+]`,
+		out: `error: avro: duplicate definition of type "A"`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := Extract(tc.name+".avsc", tc.in)
+			var got string
+			if err != nil {
+				got = "error: " + err.Error()
+			} else {
+				b, err := format.Node(f)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = strings.TrimSpace(string(b))
+			}
+			if strings.HasPrefix(tc.out, "error: ") {
+				if !strings.HasPrefix(got, tc.out) {
+					t.Errorf("got %v; want %v", got, tc.out)
+				}
+				return
+			}
+			if diff := cmp.Diff(got, tc.out); diff != "" {
+				t.Errorf("%s\n(-got +want)\n%s", got, diff)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		out  string
+		err  string
+	}{{
+		name: "record",
+		in: `
+		// A user of the system.
+		#Schema: {
+			// The full name.
+			name:   string
+			age:    int32
+			score:  *1.5 | float64
+			email?: string
+			nick:   string | *null
+			tags: [...string]
+			attrs: [string]: int64
+			id: *'\xff' | bytes
+		} @avro(namespace=com.example)
+		`,
+		out: `
+		{
+			"type": "record",
+			"name": "Schema",
+			"namespace": "com.example",
+			"doc": "A user of the system.",
+			"fields": [
+				{
+					"name": "name",
+					"doc": "The full name.",
+					"type": "string"
+				},
+				{
+					"name": "age",
+					"type": "int"
+				},
+				{
+					"name": "score",
+					"type": "double",
+					"default": 1.5
+				},
+				{
+					"name": "email",
+					"type": [
+						"null",
+						"string"
+					],
+					"default": null
+				},
+				{
+					"name": "nick",
+					"type": [
+						"null",
+						"string"
+					],
+					"default": null
+				},
+				{
+					"name": "tags",
+					"type": {
+						"type": "array",
+						"items": "string"
+					}
+				},
+				{
+					"name": "attrs",
+					"type": {
+						"type": "map",
+						"values": "long"
+					}
+				},
+				{
+					"name": "id",
+					"type": "bytes",
+					"default": "ÿ"
+				}
+			]
+		}`,
+	}, {
+		name: "named types",
+		in: `
+		#Kind: *"LEAF" | "BRANCH"
+		#MD5:  bytes @avro(fixed,size=16)
+
+		#Schema: {
+			kind:  #Kind
+			hash:  #MD5
+			other: *"BRANCH" | #Kind
+			next:  #Schema | null
+			inline: x: int
+		}
+		`,
+		out: `
+		{
+			"type": "record",
+			"name": "Schema",
+			"fields": [
+				{
+					"name": "kind",
+					"type": {
+						"type": "enum",
+						"name": "Kind",
+						"symbols": [
+							"LEAF",
+							"BRANCH"
+						],
+						"default": "LEAF"
+					},
+					"default": "LEAF"
+				},
+				{
+					"name": "hash",
+					"type": {
+						"type": "fixed",
+						"name": "MD5",
+						"size": 16
+					}
+				},
+				{
+					"name": "other",
+					"type": "Kind",
+					"default": "BRANCH"
+				},
+				{
+					"name": "next",
+					"type": [
+						"Schema",
+						"null"
+					]
+				},
+				{
+					"name": "inline",
+					"type": {
+						"type": "record",
+						"name": "Inline",
+						"fields": [
+							{
+								"name": "x",
+								"type": "long"
+							}
+						]
+					}
+				}
+			]
+		}`,
+	}, {
+		name: "logical types",
+		in: `
+		import "time"
+
+		#Schema: {
+			id:    =~"^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$"
+			at:    time.Time
+			day:   time.Format("2006-01-02")
+			clock: time.Format("15:04:05")
+		}
+		`,
+		out: `
+		{
+			"type": "record",
+			"name": "Schema",
+			"fields": [
+				{
+					"name": "id",
+					"type": {
+						"type": "string",
+						"logicalType": "uuid"
+					}
+				},
+				{
+					"name": "at",
+					"type": {
+						"type": "long",
+						"logicalType": "timestamp-millis"
+					}
+				},
+				{
+					"name": "day",
+					"type": {
+						"type": "int",
+						"logicalType": "date"
+					}
+				},
+				{
+					"name": "clock",
+					"type": {
+						"type": "int",
+						"logicalType": "time-millis"
+					}
+				}
+			]
+		}`,
+	}, {
+		name: "unsupported",
+		in: `
+		#Schema: a: _
+		`,
+		err: "unsupported type _",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := cuecontext.New().CompileString(tc.in)
+			if err := v.Err(); err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+
+			b, err := Generate(v.LookupPath(cue.ParsePath("#Schema")))
+			if err != nil {
+				got := errors.Details(err, nil)
+				if tc.err == "" || !strings.Contains(got, tc.err) {
+					t.Fatalf("unexpected error: %v", got)
+				}
+				return
+			}
+			if tc.err != "" {
+				t.Fatalf("unexpected success; want error %q", tc.err)
+			}
+
+			var out bytes.Buffer
+			if err := stdjson.Indent(&out, b, "\t\t", "\t"); err != nil {
+				t.Fatal(err)
+			}
+			got := strings.TrimSpace(out.String())
+			want := strings.TrimSpace(tc.out)
+			if diff := cmp.Diff(got, want); diff != "" {
+				t.Errorf("%s\n(-got +want)\n%s", got, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,552 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package avro
+
+import (
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/ast/astutil"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/encoding/json"
+	"cuelang.org/go/internal/source"
+)
+
+// Extract converts the Avro schema of the given file to CUE definitions.
+//
+// The top-level schema must be a named type or a list of named types.
+func Extract(filename string, src interface{}) (*ast.File, error) {
+	b, err := source.Read(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := json.Extract(filename, b)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &extractor{
+		names: map[string]string{},
+		defs:  map[string]bool{},
+		fixed: map[string]bool{},
+	}
+
+	schemas := []ast.Expr{expr}
+	if list, ok := expr.(*ast.ListLit); ok {
+		schemas = list.Elts
+	}
+	for _, x := range schemas {
+		if !e.isNamed(x) {
+			e.errf(x, "top-level schema must be a named type")
+			continue
+		}
+		e.typ(x, "")
+	}
+	if e.errs != nil {
+		return nil, e.errs
+	}
+
+	f := &ast.File{Filename: filename, Decls: e.decls}
+	if err := astutil.Sanitize(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+type extractor struct {
+	decls []ast.Decl
+	errs  errors.Error
+
+	names map[string]string // full name to definition name
+	defs  map[string]bool   // definition names in use
+	fixed map[string]bool   // full names of fixed types
+}
+
+func (e *extractor) errf(n ast.Node, format string, args ...interface{}) {
+	err := errors.Newf(n.Pos(), "avro: "+format, args...)
+	e.errs = errors.Append(e.errs, err)
+}
+
+// A schema holds the attributes of an Avro schema declared as a JSON object.
+type schema struct {
+	node   ast.Expr
+	fields map[string]ast.Expr
+}
+
+func (s *schema) lookup(name string) ast.Expr {
+	return s.fields[name]
+}
+
+func (e *extractor) schema(x *ast.StructLit) *schema {
+	s := &schema{node: x, fields: map[string]ast.Expr{}}
+	for _, d := range x.Elts {
+		f, ok := d.(*ast.Field)
+		if !ok {
+			continue
+		}
+		name, _, err := ast.LabelName(f.Label)
+		if err != nil {
+			e.errf(f.Label, "invalid attribute name")
+			continue
+		}
+		s.fields[name] = f.Value
+	}
+	return s
+}
+
+// str reports the string value of x, if x is a string literal.
+func (e *extractor) str(x ast.Expr) (string, bool) {
+	lit, ok := x.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := literal.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	return s, true
+}
+
+// attr returns the string value of the named attribute, if it exists.
+func (e *extractor) attr(s *schema, name string) string {
+	x := s.lookup(name)
+	if x == nil {
+		return ""
+	}
+	str, ok := e.str(x)
+	if !ok {
+		e.errf(x, "%s must be a string", name)
+	}
+	return str
+}
+
+func (e *extractor) isNamed(x ast.Expr) bool {
+	st, ok := x.(*ast.StructLit)
+	if !ok {
+		return false
+	}
+	switch e.attr(e.schema(st), "type") {
+	case "record", "error", "enum", "fixed":
+		return true
+	}
+	return false
+}
+
+// fullName returns the full name of a named type, which is the name if it
+// contains a dot, or the name qualified by the given namespace otherwise.
+func fullName(name, ns string) string {
+	if strings.Contains(name, ".") || ns == "" {
+		return name
+	}
+	return ns + "." + name
+}
+
+// splitName splits a full name into its namespace and short name.
+func splitName(name string) (ns, short string) {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// resolve returns the full name of the named type referred to by name.
+func (e *extractor) resolve(name, ns string) (full string, ok bool) {
+	if full = fullName(name, ns); e.names[full] != "" {
+		return full, true
+	}
+	return name, e.names[name] != ""
+}
+
+// typ converts an Avro schema to a CUE expression. Named types are added as
+// top-level definitions, for which typ returns a reference. The namespace ns
+// is used to resolve relative names.
+func (e *extractor) typ(x ast.Expr, ns string) ast.Expr {
+	switch x := x.(type) {
+	case *ast.BasicLit:
+		name, ok := e.str(x)
+		if !ok {
+			break
+		}
+		if p, ok := primitives[name]; ok {
+			return ast.NewIdent(p)
+		}
+		full, ok := e.resolve(name, ns)
+		if !ok {
+			e.errf(x, "undefined type %q", name)
+			return nil
+		}
+		return ast.NewIdent(e.names[full])
+
+	case *ast.ListLit:
+		var a []ast.Expr
+		for _, elt := range x.Elts {
+			if _, ok := elt.(*ast.ListLit); ok {
+				e.errf(elt, "unions may not immediately contain other unions")
+				continue
+			}
+			if t := e.typ(elt, ns); t != nil {
+				a = append(a, t)
+			}
+		}
+		if len(a) == 0 {
+			e.errf(x, "empty union")
+			return nil
+		}
+		return ast.NewBinExpr(token.OR, a...)
+
+	case *ast.StructLit:
+		return e.complexType(e.schema(x), ns)
+	}
+	e.errf(x, "invalid schema")
+	return nil
+}
+
+func (e *extractor) complexType(s *schema, ns string) ast.Expr {
+	t := s.lookup("type")
+	if t == nil {
+		e.errf(s.node, "missing type")
+		return nil
+	}
+	name, ok := e.str(t)
+	if !ok {
+		// The type is itself a schema, as in {"type": {"type": "array", ...}}.
+		return e.typ(t, ns)
+	}
+
+	switch name {
+	case "record", "error", "enum", "fixed":
+		return e.named(s, name, ns)
+
+	case "array":
+		items := s.lookup("items")
+		if items == nil {
+			e.errf(s.node, "array is missing items")
+			return nil
+		}
+		elem := e.typ(items, ns)
+		if elem == nil {
+			return nil
+		}
+		return ast.NewList(&ast.Ellipsis{Type: elem})
+
+	case "map":
+		values := s.lookup("values")
+		if values == nil {
+			e.errf(s.node, "map is missing values")
+			return nil
+		}
+		elem := e.typ(values, ns)
+		if elem == nil {
+			return nil
+		}
+		return ast.NewStruct(&ast.Field{
+			Label: ast.NewList(ast.NewIdent("string")),
+			Value: elem,
+		})
+	}
+
+	if logical := e.attr(s, "logicalType"); logical != "" {
+		if expr := e.logicalType(logical, name); expr != nil {
+			return expr
+		}
+	}
+	return e.typ(t, ns)
+}
+
+// logicalType returns the CUE constraint for the given logical type, or nil
+// if the logical type should map to its underlying type.
+func (e *extractor) logicalType(logical, underlying string) ast.Expr {
+	switch {
+	case logical == "date" && underlying == avroInt:
+		return e.timeFormat(dateLayout)
+
+	case logical == "time-millis" && underlying == avroInt,
+		logical == "time-micros" && underlying == avroLong:
+		return e.timeFormat(timeLayout)
+
+	case underlying == avroLong && (logical == "timestamp-millis" ||
+		logical == "timestamp-micros" ||
+		logical == "local-timestamp-millis" ||
+		logical == "local-timestamp-micros"):
+		return ast.NewSel(e.addImport("time"), "Time")
+
+	case logical == "uuid" && underlying == avroString:
+		return &ast.UnaryExpr{
+			Op: token.MAT,
+			X:  ast.NewString(uuidPattern),
+		}
+	}
+	return nil
+}
+
+func (e *extractor) timeFormat(layout string) ast.Expr {
+	return ast.NewCall(ast.NewSel(e.addImport("time"), "Format"), ast.NewString(layout))
+}
+
+func (e *extractor) addImport(pkg string) *ast.Ident {
+	spec := ast.NewImport(nil, pkg)
+	ident := ast.NewIdent(pkg)
+	ident.Node = spec
+	return ident
+}
+
+// named adds a definition for the named type of the given kind and returns a
+// reference to it.
+func (e *extractor) named(s *schema, kind, ns string) ast.Expr {
+	name := e.attr(s, "name")
+	if name == "" {
+		e.errf(s.node, "%s is missing a name", kind)
+		return nil
+	}
+	if x := e.attr(s, "namespace"); x != "" && !strings.Contains(name, ".") {
+		ns = x
+	}
+	full := fullName(name, ns)
+	ns, short := splitName(full)
+
+	if _, ok := e.names[full]; ok {
+		e.errf(s.node, "duplicate definition of type %q", full)
+		return nil
+	}
+	def := "#" + short
+	if e.defs[def] {
+		e.errf(s.node, "type %q conflicts with another type named %q", full, short)
+		return nil
+	}
+	e.names[full] = def
+	e.defs[def] = true
+
+	f := &ast.Field{Label: ast.NewIdent(def)}
+	// Add the definition before converting its contents so that definitions
+	// of nested named types follow the type in which they are declared.
+	e.decls = append(e.decls, f)
+
+	var args []string
+	switch kind {
+	case "record", "error":
+		f.Value = e.record(s, ns)
+		if kind == "error" {
+			args = append(args, "error")
+		}
+
+	case "enum":
+		f.Value = e.enum(s)
+
+	case "fixed":
+		f.Value = ast.NewIdent("bytes")
+		size := s.lookup("size")
+		lit, ok := size.(*ast.BasicLit)
+		if !ok || lit.Kind != token.INT {
+			e.errf(s.node, "fixed type %q must have an integer size", full)
+			return nil
+		}
+		args = append(args, "fixed", "size="+lit.Value)
+		e.fixed[full] = true
+	}
+	if ns != "" {
+		args = append(args, "namespace="+ns)
+	}
+	if len(args) > 0 {
+		f.Attrs = []*ast.Attribute{{
+			Text: fmt.Sprintf("@avro(%s)", strings.Join(args, ",")),
+		}}
+	}
+	addDoc(f, e.attr(s, "doc"))
+
+	return ast.NewIdent(def)
+}
+
+func (e *extractor) record(s *schema, ns string) ast.Expr {
+	st := &ast.StructLit{}
+	list, ok := s.lookup("fields").(*ast.ListLit)
+	if !ok {
+		e.errf(s.node, "record is missing fields")
+		return st
+	}
+	for _, x := range list.Elts {
+		fs, ok := x.(*ast.StructLit)
+		if !ok {
+			e.errf(x, "invalid record field")
+			continue
+		}
+		fd := e.schema(fs)
+		name := e.attr(fd, "name")
+		if name == "" {
+			e.errf(x, "record field is missing a name")
+			continue
+		}
+		t := fd.lookup("type")
+		if t == nil {
+			e.errf(x, "record field %q is missing a type", name)
+			continue
+		}
+		expr := e.typ(t, ns)
+		if expr == nil {
+			continue
+		}
+		if d := fd.lookup("default"); d != nil {
+			expr = e.addDefault(expr, t, d, ns)
+		}
+		f := &ast.Field{Label: label(name), Value: expr}
+		addDoc(f, e.attr(fd, "doc"))
+		st.Elts = append(st.Elts, f)
+	}
+	return st
+}
+
+func (e *extractor) enum(s *schema) ast.Expr {
+	list, ok := s.lookup("symbols").(*ast.ListLit)
+	if !ok || len(list.Elts) == 0 {
+		e.errf(s.node, "enum must have symbols")
+		return ast.NewIdent("string")
+	}
+	def := e.attr(s, "default")
+	var a []ast.Expr
+	for _, x := range list.Elts {
+		sym, ok := e.str(x)
+		if !ok {
+			e.errf(x, "enum symbols must be strings")
+			continue
+		}
+		var expr ast.Expr = ast.NewString(sym)
+		if sym == def {
+			expr = &ast.UnaryExpr{Op: token.MUL, X: expr}
+		}
+		a = append(a, expr)
+	}
+	return ast.NewBinExpr(token.OR, a...)
+}
+
+// addDefault marks the default value d of a record field of type expr. The
+// default of a union corresponds to its first type, which is marked as the
+// default if d is null.
+func (e *extractor) addDefault(expr, t, d ast.Expr, ns string) ast.Expr {
+	if lit, ok := d.(*ast.BasicLit); ok && lit.Kind == token.NULL {
+		if b, ok := expr.(*ast.BinaryExpr); ok && b.Op == token.OR {
+			first := &b.X
+			for x, ok := (*first).(*ast.BinaryExpr); ok && x.Op == token.OR; x, ok = (*first).(*ast.BinaryExpr) {
+				first = &x.X
+			}
+			if id, ok := (*first).(*ast.Ident); ok && id.Name == "null" {
+				*first = &ast.UnaryExpr{Op: token.MUL, X: id}
+				return expr
+			}
+		}
+	}
+	v := e.value(d, e.isBytes(t, ns))
+	if v == nil {
+		return expr
+	}
+	return ast.NewBinExpr(token.OR, &ast.UnaryExpr{Op: token.MUL, X: v}, expr)
+}
+
+// isBytes reports whether a default value for the schema t, or for the first
+// type of t if t is a union, is encoded as bytes.
+func (e *extractor) isBytes(t ast.Expr, ns string) bool {
+	switch x := t.(type) {
+	case *ast.ListLit:
+		return len(x.Elts) > 0 && e.isBytes(x.Elts[0], ns)
+
+	case *ast.StructLit:
+		s := e.schema(x)
+		switch e.attr(s, "type") {
+		case "fixed":
+			return true
+		case "record", "error", "enum", "array", "map":
+			return false
+		}
+		return s.lookup("type") != nil && e.isBytes(s.lookup("type"), ns)
+	}
+	name, _ := e.str(t)
+	if name == avroBytes {
+		return true
+	}
+	full, ok := e.resolve(name, ns)
+	return ok && e.fixed[full]
+}
+
+// value converts the JSON value of a default to a CUE expression. Defaults
+// of bytes types are encoded as strings with code points 0-255, which map to
+// the corresponding bytes.
+func (e *extractor) value(x ast.Expr, isBytes bool) ast.Expr {
+	switch x := x.(type) {
+	case *ast.BasicLit:
+		if x.Kind == token.STRING && isBytes {
+			s, _ := e.str(x)
+			b := make([]byte, 0, len(s))
+			for _, r := range s {
+				if r > 0xff {
+					e.errf(x, "invalid bytes default %s", x.Value)
+					return nil
+				}
+				b = append(b, byte(r))
+			}
+			return ast.NewLit(token.STRING, literal.Bytes.Quote(string(b)))
+		}
+		return &ast.BasicLit{Kind: x.Kind, Value: x.Value}
+
+	case *ast.UnaryExpr:
+		return &ast.UnaryExpr{Op: x.Op, X: e.value(x.X, false)}
+
+	case *ast.ListLit:
+		var a []ast.Expr
+		for _, elt := range x.Elts {
+			a = append(a, e.value(elt, false))
+		}
+		return ast.NewList(a...)
+
+	case *ast.StructLit:
+		var a []interface{}
+		for _, d := range x.Elts {
+			f, ok := d.(*ast.Field)
+			if !ok {
+				continue
+			}
+			name, _, _ := ast.LabelName(f.Label)
+			a = append(a, &ast.Field{Label: label(name), Value: e.value(f.Value, false)})
+		}
+		return ast.NewStruct(a...)
+	}
+	e.errf(x, "invalid default value")
+	return nil
+}
+
+// label returns a label for the given Avro name. Names that would be hidden
+// or definitions in CUE are quoted.
+func label(name string) ast.Label {
+	if ast.IsValidIdent(name) && !strings.HasPrefix(name, "_") &&
+		!strings.HasPrefix(name, "#") {
+		return ast.NewIdent(name)
+	}
+	return ast.NewString(name)
+}
+
+func addDoc(n ast.Node, doc string) {
+	if doc == "" {
+		return
+	}
+	cg := &ast.CommentGroup{Doc: true}
+	for _, line := range strings.Split(strings.TrimSpace(doc), "\n") {
+		cg.List = append(cg.List, &ast.Comment{Text: "// " + strings.TrimRightFunc(line, isSpace)})
+	}
+	ast.AddComment(n, cg)
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\r'
+}
@@ -0,0 +1,401 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package avro
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/encoding/json"
+	"cuelang.org/go/internal/value"
+)
+
+// Generate converts v, which is typically a definition, to an Avro schema.
+// The name of the schema is the last label of the path of v.
+//
+// Structs map to records, disjunctions of strings to enums, and other
+// disjunctions to unions. Definitions referred to from v are converted to
+// named types, which are declared at their first use. Optional fields map to
+// a union with null that defaults to null. Constraints that cannot be
+// expressed in Avro are ignored.
+func Generate(v cue.Value) ([]byte, error) {
+	sels := v.Path().Selectors()
+	if len(sels) == 0 {
+		return nil, errors.Newf(v.Pos(), "avro: cannot determine name of schema")
+	}
+	name := strings.TrimPrefix(sels[len(sels)-1].String(), "#")
+
+	g := &generator{
+		declared: map[*adt.Vertex]string{},
+		names:    map[string]bool{},
+	}
+	x := g.named(v, name)
+	if g.errs != nil {
+		return nil, g.errs
+	}
+	return json.Encode(x)
+}
+
+type generator struct {
+	errs errors.Error
+
+	declared map[*adt.Vertex]string // full names of declared types
+	names    map[string]bool        // full names in use
+
+	// ns is the namespace of the enclosing named type. Named types without
+	// a namespace of their own inherit it.
+	ns string
+}
+
+func (g *generator) addErrf(v cue.Value, format string, args ...interface{}) {
+	err := errors.Newf(v.Pos(), "avro: "+format, args...)
+	g.errs = errors.Append(g.errs, err)
+}
+
+// named converts v to a named type with the given name if v is a struct, an
+// enum, or a fixed type. It returns the name of the type if it was already
+// declared.
+func (g *generator) named(v cue.Value, name string) ast.Expr {
+	_, n := value.ToInternal(v)
+	if full, ok := g.declared[n]; ok {
+		if ns, short := splitName(full); ns == g.ns {
+			return ast.NewString(short)
+		}
+		return ast.NewString(full)
+	}
+
+	attr := v.Attribute("avro")
+	kind := "record"
+	if ok, _ := attr.Flag(0, "error"); ok {
+		kind = "error"
+	}
+	symbols := g.symbols(v)
+	isFixed, _ := attr.Flag(0, "fixed")
+	switch {
+	case isFixed:
+		kind = "fixed"
+	case symbols != nil:
+		kind = "enum"
+	case v.IncompleteKind() != cue.StructKind || g.isMap(v):
+		return g.typ(v, name)
+	}
+
+	ns, _, _ := attr.Lookup(0, "namespace")
+	if ns == "" {
+		ns = g.ns
+	}
+	full := fullName(name, ns)
+	for i := 2; g.names[full]; i++ {
+		full = fullName(name+strconv.Itoa(i), ns)
+	}
+	_, name = splitName(full)
+	g.declared[n] = full
+	g.names[full] = true
+
+	s := &ast.StructLit{}
+	s.Elts = append(s.Elts, field("type", ast.NewString(kind)))
+	s.Elts = append(s.Elts, field("name", ast.NewString(name)))
+	if ns != g.ns {
+		s.Elts = append(s.Elts, field("namespace", ast.NewString(ns)))
+	}
+	if doc := docString(v); doc != "" {
+		s.Elts = append(s.Elts, field("doc", ast.NewString(doc)))
+	}
+
+	switch kind {
+	case "fixed":
+		size, _, _ := attr.Lookup(0, "size")
+		n, err := strconv.Atoi(size)
+		if err != nil {
+			g.addErrf(v, "invalid size %q for fixed type %s", size, full)
+		}
+		s.Elts = append(s.Elts, field("size", ast.NewLit(token.INT, strconv.Itoa(n))))
+
+	case "enum":
+		s.Elts = append(s.Elts, field("symbols", ast.NewList(symbols...)))
+		if d, ok := v.Default(); ok && d.IsConcrete() {
+			s.Elts = append(s.Elts, field("default", g.value(d)))
+		}
+
+	default:
+		saved := g.ns
+		g.ns = ns
+		s.Elts = append(s.Elts, field("fields", g.fields(v)))
+		g.ns = saved
+	}
+	return s
+}
+
+// fields converts the fields of a struct to the fields of a record.
+func (g *generator) fields(v cue.Value) ast.Expr {
+	list := ast.NewList()
+	for i, _ := v.Fields(cue.Optional(true)); i.Next(); {
+		x := i.Value()
+		f := &ast.StructLit{}
+		f.Elts = append(f.Elts, field("name", ast.NewString(i.Label())))
+		if doc := docString(x); doc != "" {
+			f.Elts = append(f.Elts, field("doc", ast.NewString(doc)))
+		}
+
+		t := g.typ(x, exportedName(i.Label()))
+		var d ast.Expr
+		switch def, ok := x.Default(); {
+		case i.IsOptional():
+			t = prependNull(t)
+			d = ast.NewNull()
+		case ok && def.IsConcrete() && !isEmptyList(def):
+			d = g.value(def)
+		}
+		f.Elts = append(f.Elts, field("type", t))
+		if d != nil {
+			f.Elts = append(f.Elts, field("default", d))
+		}
+		list.Elts = append(list.Elts, f)
+	}
+	return list
+}
+
+// types maps the string representation of CUE types to the corresponding
+// Avro schemas.
+var types = map[string][2]string{
+	"int32":   {avroInt},
+	"int64":   {avroLong},
+	"float32": {avroFloat},
+	"float64": {avroDouble},
+
+	"time.Time":                 {avroLong, "timestamp-millis"},
+	"time.Time()":               {avroLong, "timestamp-millis"},
+	`time.Format("2006-01-02")`: {avroInt, "date"},
+	`time.Format("15:04:05")`:   {avroInt, "time-millis"},
+
+	"=~" + literal.String.Quote(uuidPattern): {avroString, "uuid"},
+}
+
+// typ converts v to an Avro schema. The name is used for named types that are
+// not declared as a definition.
+func (g *generator) typ(v cue.Value, name string) ast.Expr {
+	if t, ok := types[fmt.Sprint(v)]; ok {
+		if t[1] == "" {
+			return ast.NewString(t[0])
+		}
+		return ast.NewStruct(
+			field("type", ast.NewString(t[0])),
+			field("logicalType", ast.NewString(t[1])))
+	}
+
+	if root, p := v.ReferencePath(); root.Exists() {
+		sels := p.Selectors()
+		if n := len(sels); n > 0 && sels[n-1].IsDefinition() {
+			name = strings.TrimPrefix(sels[n-1].String(), "#")
+			return g.named(cue.Dereference(v), name)
+		}
+	}
+
+	switch op, a := v.Expr(); op {
+	case cue.OrOp:
+		if g.symbols(v) != nil {
+			return g.named(v, name)
+		}
+		return g.union(v, a, name)
+
+	case cue.NoOp:
+		// A value with a default is represented by its value without the
+		// default.
+		if len(a) == 1 {
+			root, _ := a[0].ReferencePath()
+			if op, _ := a[0].Expr(); root.Exists() || op != cue.NoOp {
+				return g.typ(a[0], name)
+			}
+		}
+	}
+
+	switch k := v.IncompleteKind(); k {
+	case cue.NullKind:
+		return ast.NewString(avroNull)
+	case cue.BoolKind:
+		return ast.NewString(avroBoolean)
+	case cue.IntKind:
+		return ast.NewString(avroLong)
+	case cue.FloatKind, cue.NumberKind:
+		return ast.NewString(avroDouble)
+	case cue.StringKind:
+		return ast.NewString(avroString)
+	case cue.BytesKind:
+		return ast.NewString(avroBytes)
+
+	case cue.ListKind:
+		elem := v.LookupPath(cue.MakePath(cue.AnyIndex))
+		if !elem.Exists() {
+			g.addErrf(v, "unsupported closed list at %v", v.Path())
+			return ast.NewString(avroNull)
+		}
+		return ast.NewStruct(
+			field("type", ast.NewString("array")),
+			field("items", g.typ(elem, name+"Item")))
+
+	case cue.StructKind:
+		if g.isMap(v) {
+			elem := v.LookupPath(cue.MakePath(cue.AnyString))
+			return ast.NewStruct(
+				field("type", ast.NewString("map")),
+				field("values", g.typ(elem, name+"Value")))
+		}
+		return g.named(v, name)
+
+	default:
+		g.addErrf(v, "unsupported type %v at %v", k, v.Path())
+		return ast.NewString(avroNull)
+	}
+}
+
+// union converts the disjuncts a of v to a union. The type of the default of
+// v, if any, is the first type of the union, as Avro requires the default of
+// a union to be of its first type.
+func (g *generator) union(v cue.Value, a []cue.Value, name string) ast.Expr {
+	d, hasDefault := v.Default()
+	hasDefault = hasDefault && d.IsConcrete()
+
+	list := ast.NewList()
+	seen := map[string]bool{}
+	for _, x := range a {
+		t := g.typ(x, name)
+		b, err := json.Encode(t)
+		if err != nil {
+			g.addErrf(x, "%v", err)
+			continue
+		}
+		key := string(b)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if hasDefault && x.Unify(d).Err() == nil {
+			hasDefault = false
+			list.Elts = append([]ast.Expr{t}, list.Elts...)
+			continue
+		}
+		list.Elts = append(list.Elts, t)
+	}
+	return list
+}
+
+// symbols returns the symbols of v if v is a disjunction of strings that are
+// valid enum symbols, or nil otherwise.
+func (g *generator) symbols(v cue.Value) []ast.Expr {
+	op, a := v.Expr()
+	if op == cue.NoOp && len(a) == 1 {
+		op, a = a[0].Expr()
+	}
+	if op != cue.OrOp {
+		return nil
+	}
+	var symbols []ast.Expr
+	for _, x := range a {
+		s, err := x.String()
+		if err != nil || !isSymbol(s) {
+			return nil
+		}
+		symbols = append(symbols, ast.NewString(s))
+	}
+	return symbols
+}
+
+// isMap reports whether v is a struct with only a pattern constraint for all
+// fields.
+func (g *generator) isMap(v cue.Value) bool {
+	if !v.LookupPath(cue.MakePath(cue.AnyString)).Exists() {
+		return false
+	}
+	i, _ := v.Fields(cue.Optional(true))
+	return !i.Next()
+}
+
+// value converts a concrete value to the JSON encoding of an Avro default.
+// Bytes are encoded as strings with the code points 0-255.
+func (g *generator) value(v cue.Value) ast.Expr {
+	v, _ = v.Default()
+	if b, err := v.Bytes(); err == nil && v.Kind() == cue.BytesKind {
+		var sb strings.Builder
+		for _, c := range b {
+			sb.WriteRune(rune(c))
+		}
+		return ast.NewString(sb.String())
+	}
+	return v.Syntax(cue.Final()).(ast.Expr)
+}
+
+// prependNull makes t nullable, with null as the first type.
+func prependNull(t ast.Expr) ast.Expr {
+	null := ast.NewString(avroNull)
+	list, ok := t.(*ast.ListLit)
+	if !ok {
+		return ast.NewList(null, t)
+	}
+	elts := []ast.Expr{null}
+	for _, x := range list.Elts {
+		if lit, ok := x.(*ast.BasicLit); !ok || lit.Value != null.Value {
+			elts = append(elts, x)
+		}
+	}
+	list.Elts = elts
+	return list
+}
+
+func field(key string, x ast.Expr) *ast.Field {
+	return &ast.Field{Label: ast.NewString(key), Value: x}
+}
+
+// isSymbol reports whether s is a valid Avro name.
+func isSymbol(s string) bool {
+	for i, r := range s {
+		if r != '_' && !isLetter(r) && (i == 0 || !isDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isLetter(r rune) bool { return r < utf8.RuneSelf && unicode.IsLetter(r) }
+func isDigit(r rune) bool  { return '0' <= r && r <= '9' }
+
+// exportedName returns the name of a named type derived from a field name.
+func exportedName(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
+
+func docString(v cue.Value) string {
+	var doc []string
+	for _, d := range v.Doc() {
+		doc = append(doc, d.Text())
+	}
+	return strings.TrimSpace(strings.Join(doc, "\n\n"))
+}
+
+// isEmptyList reports whether v is the empty list, which is the default of
+// open lists.
+func isEmptyList(v cue.Value) bool {
+	n, err := v.Len().Int64()
+	return v.Kind() == cue.ListKind && err == nil && n == 0
+}
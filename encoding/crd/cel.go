@@ -0,0 +1,251 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crd
+
+import (
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/cue/token"
+)
+
+// rule returns the x-kubernetes-validations rule for the hidden field x of
+// the struct v, or nil if x is not a boolean constraint.
+func (g *generator) rule(v, x cue.Value) ast.Expr {
+	expr, ok := x.Source().(ast.Expr)
+	if f, isField := x.Source().(*ast.Field); isField {
+		expr, ok = f.Value, true
+	}
+	if !ok || !isBoolExpr(expr) {
+		return nil
+	}
+	for {
+		if b, ok := expr.(*ast.BinaryExpr); ok && b.Op == token.AND {
+			expr = b.X
+			if isTrue(b.X) {
+				expr = b.Y
+			}
+			continue
+		}
+		if p, ok := expr.(*ast.ParenExpr); ok {
+			expr = p.X
+			continue
+		}
+		break
+	}
+	c := &celConverter{self: v}
+	var b strings.Builder
+	if !c.expr(&b, expr) {
+		g.addErrf(x, "cannot convert %s to a validation rule: unsupported %s",
+			x.Path(), c.unsupported)
+		return nil
+	}
+	rule := newStruct("rule", ast.NewString(b.String()))
+	if doc := docString(x); doc != "" {
+		rule.Elts = append(rule.Elts, newField("message", ast.NewString(doc)))
+	}
+	return rule
+}
+
+// isBoolExpr reports whether x is an expression that evaluates to a boolean.
+// An expression that is unified with true, as in
+//
+//    _check: true & (a < b)
+//
+// is a boolean expression as well.
+func isBoolExpr(x ast.Expr) bool {
+	switch x := x.(type) {
+	case *ast.ParenExpr:
+		return isBoolExpr(x.X)
+	case *ast.UnaryExpr:
+		return x.Op == token.NOT
+	case *ast.BinaryExpr:
+		switch x.Op {
+		case token.LAND, token.LOR, token.EQL, token.NEQ,
+			token.LSS, token.LEQ, token.GTR, token.GEQ,
+			token.MAT, token.NMAT:
+			return true
+		case token.AND:
+			return isTrue(x.X) && isBoolExpr(x.Y) || isTrue(x.Y) && isBoolExpr(x.X)
+		}
+	}
+	return false
+}
+
+func isTrue(x ast.Expr) bool {
+	lit, ok := x.(*ast.BasicLit)
+	return ok && lit.Kind == token.TRUE
+}
+
+// celFuncs maps CUE builtins to CEL functions, which are called as methods of
+// their first argument.
+var celFuncs = map[string]string{
+	"strings.HasPrefix": "startsWith",
+	"strings.HasSuffix": "endsWith",
+	"strings.Contains":  "contains",
+}
+
+// A celConverter converts CUE expressions to CEL. References to fields of
+// self are converted to field selections of the CEL variable self.
+type celConverter struct {
+	self        cue.Value
+	unsupported string
+}
+
+func (c *celConverter) fail(n ast.Node) bool {
+	if c.unsupported == "" {
+		b, _ := format.Node(n)
+		c.unsupported = string(b)
+	}
+	return false
+}
+
+func (c *celConverter) expr(b *strings.Builder, x ast.Expr) bool {
+	switch x := x.(type) {
+	case *ast.ParenExpr:
+		b.WriteString("(")
+		ok := c.expr(b, x.X)
+		b.WriteString(")")
+		return ok
+
+	case *ast.UnaryExpr:
+		switch x.Op {
+		case token.NOT, token.SUB:
+			b.WriteString(x.Op.String())
+			return c.expr(b, x.X)
+		}
+
+	case *ast.BinaryExpr:
+		switch x.Op {
+		case token.AND:
+			if isTrue(x.X) {
+				return c.expr(b, x.Y)
+			}
+			return c.expr(b, x.X)
+
+		case token.MAT, token.NMAT:
+			if x.Op == token.NMAT {
+				b.WriteString("!")
+			}
+			if !c.expr(b, x.X) {
+				return false
+			}
+			b.WriteString(".matches(")
+			ok := c.expr(b, x.Y)
+			b.WriteString(")")
+			return ok
+
+		case token.LAND, token.LOR, token.EQL, token.NEQ,
+			token.LSS, token.LEQ, token.GTR, token.GEQ,
+			token.ADD, token.SUB, token.MUL, token.QUO:
+			if !c.expr(b, x.X) {
+				return false
+			}
+			b.WriteString(" " + x.Op.String() + " ")
+			return c.expr(b, x.Y)
+		}
+
+	case *ast.BasicLit:
+		switch x.Kind {
+		case token.STRING:
+			s, err := literal.Unquote(x.Value)
+			if err != nil || strings.HasPrefix(x.Value, "'") {
+				break
+			}
+			b.WriteString(strconv.Quote(s))
+			return true
+		default:
+			b.WriteString(x.Value)
+			return true
+		}
+
+	case *ast.Ident:
+		if !c.self.LookupPath(cue.MakePath(cue.Str(x.Name))).Exists() ||
+			strings.HasPrefix(x.Name, "_") || strings.HasPrefix(x.Name, "#") {
+			break
+		}
+		b.WriteString("self")
+		c.field(b, x.Name)
+		return true
+
+	case *ast.SelectorExpr:
+		name, _, err := ast.LabelName(x.Sel)
+		if err != nil {
+			break
+		}
+		if !c.expr(b, x.X) {
+			return false
+		}
+		c.field(b, name)
+		return true
+
+	case *ast.IndexExpr:
+		if !c.expr(b, x.X) {
+			return false
+		}
+		b.WriteString("[")
+		ok := c.expr(b, x.Index)
+		b.WriteString("]")
+		return ok
+
+	case *ast.CallExpr:
+		return c.call(b, x)
+	}
+	return c.fail(x)
+}
+
+func (c *celConverter) field(b *strings.Builder, name string) {
+	if ast.IsValidIdent(name) && !strings.Contains(name, "$") {
+		b.WriteString("." + name)
+		return
+	}
+	b.WriteString("[" + strconv.Quote(name) + "]")
+}
+
+func (c *celConverter) call(b *strings.Builder, x *ast.CallExpr) bool {
+	switch fun := funcName(x.Fun); {
+	case fun == "len" && len(x.Args) == 1:
+		b.WriteString("size(")
+		ok := c.expr(b, x.Args[0])
+		b.WriteString(")")
+		return ok
+
+	case celFuncs[fun] != "" && len(x.Args) == 2:
+		if !c.expr(b, x.Args[0]) {
+			return false
+		}
+		b.WriteString("." + celFuncs[fun] + "(")
+		ok := c.expr(b, x.Args[1])
+		b.WriteString(")")
+		return ok
+	}
+	return c.fail(x)
+}
+
+// funcName returns the qualified name of a called function.
+func funcName(n ast.Node) string {
+	switch x := n.(type) {
+	case *ast.Ident:
+		return x.Name
+	case *ast.SelectorExpr:
+		name, _, _ := ast.LabelName(x.Sel)
+		return funcName(x.X) + "." + name
+	}
+	return ""
+}
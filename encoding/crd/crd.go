@@ -0,0 +1,276 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package crd generates Kubernetes CustomResourceDefinitions from CUE
+// definitions.
+//
+// The schema of a definition is converted to a structural OpenAPI schema, as
+// required by Kubernetes: references are expanded, each node has a type, and
+// fields without a default are required. Open structs preserve unknown
+// fields, and a disjunction of an integer and a string maps to
+// x-kubernetes-int-or-string.
+//
+// Hidden fields whose value is a boolean expression over the fields of a
+// struct are converted to CEL rules in the x-kubernetes-validations of that
+// struct. For instance,
+//
+//   #Range: {
+//       min: int
+//       max: int
+//
+//       // min must not exceed max
+//       _check: min <= max
+//   }
+//
+// results in the rule "self.min <= self.max" with the message taken from the
+// comment of the field.
+//
+// The metadata of a resource is specified in a @crd attribute of the
+// definition, or in a Config:
+//
+//   #MyApp: {
+//       spec: replicas: int
+//   } @crd(group=example.com,version=v1alpha1,scope=Namespaced)
+//
+// API Status: DRAFT: API may change without notice.
+package crd
+
+import (
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/ast/astutil"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+// A Config defines the metadata of the generated resources. The arguments of a
+// @crd attribute of a definition take precedence over the corresponding fields.
+type Config struct {
+	// Group is the API group of the resource, such as example.com. It
+	// defaults to the group of the apiVersion field of the definition, if
+	// this field is a concrete string, and is required otherwise.
+	Group string
+
+	// Version is the API version of the resource. It defaults to the version
+	// of the apiVersion field of the definition, or v1.
+	Version string
+
+	// Kind is the kind of the resource. It defaults to the kind field of the
+	// definition, if this field is a concrete string, or to the name of the
+	// definition otherwise.
+	Kind string
+
+	// Plural is the plural name of the resource. It defaults to the lower case
+	// kind followed by an s.
+	Plural string
+
+	// Singular is the singular name of the resource. It defaults to the lower
+	// case kind.
+	Singular string
+
+	// Scope is either Namespaced, the default, or Cluster.
+	Scope string
+}
+
+// Generate returns a CustomResourceDefinition for the definition v.
+func Generate(v cue.Value, c *Config) (*ast.File, error) {
+	x, err := generate(v, c)
+	if err != nil {
+		return nil, err
+	}
+	return astutil.ToFile(x)
+}
+
+// GenerateAll returns a CustomResourceDefinition for each definition of v that
+// has a @crd attribute. A single CustomResourceDefinition is returned as is;
+// multiple ones are returned as a List.
+func GenerateAll(v cue.Value, c *Config) (*ast.File, error) {
+	iter, err := v.Fields(cue.Definitions(true))
+	if err != nil {
+		return nil, err
+	}
+	var items []ast.Expr
+	var errs errors.Error
+	for iter.Next() {
+		if !iter.Selector().IsDefinition() {
+			continue
+		}
+		def := iter.Value()
+		if a := def.Attribute("crd"); a.Err() != nil {
+			continue
+		}
+		x, err := generate(def, c)
+		if err != nil {
+			errs = errors.Append(errs, errors.Promote(err, "crd"))
+			continue
+		}
+		items = append(items, x)
+	}
+	switch {
+	case errs != nil:
+		return nil, errs
+	case len(items) == 0:
+		return nil, errors.Newf(v.Pos(), "crd: no definitions with a @crd attribute")
+	case len(items) == 1:
+		return astutil.ToFile(items[0])
+	}
+	return astutil.ToFile(newStruct(
+		"apiVersion", ast.NewString("v1"),
+		"kind", ast.NewString("List"),
+		"items", ast.NewList(items...),
+	))
+}
+
+// names holds the metadata of a resource.
+type names struct {
+	Config
+	listKind string
+}
+
+func (c *Config) names(v cue.Value) (*names, error) {
+	n := &names{}
+	if c != nil {
+		n.Config = *c
+	}
+
+	a := v.Attribute("crd")
+	for _, arg := range []struct {
+		key string
+		dst *string
+	}{
+		{"group", &n.Group},
+		{"version", &n.Version},
+		{"kind", &n.Kind},
+		{"plural", &n.Plural},
+		{"singular", &n.Singular},
+		{"scope", &n.Scope},
+	} {
+		if s, ok, _ := a.Lookup(0, arg.key); ok {
+			*arg.dst = s
+		}
+	}
+
+	// The apiVersion and kind of a resource may be specified by the definition
+	// itself.
+	if s, err := v.LookupPath(cue.MakePath(cue.Str("apiVersion"))).String(); err == nil {
+		if i := strings.LastIndexByte(s, '/'); i >= 0 {
+			if n.Group == "" {
+				n.Group = s[:i]
+			}
+			if n.Version == "" {
+				n.Version = s[i+1:]
+			}
+		}
+	}
+	if s, err := v.LookupPath(cue.MakePath(cue.Str("kind"))).String(); err == nil && n.Kind == "" {
+		n.Kind = s
+	}
+	if n.Kind == "" {
+		sels := v.Path().Selectors()
+		if len(sels) == 0 {
+			return nil, errors.Newf(v.Pos(), "crd: no kind specified")
+		}
+		n.Kind = strings.TrimPrefix(sels[len(sels)-1].String(), "#")
+	}
+	if n.Group == "" {
+		return nil, errors.Newf(v.Pos(), "crd: no group specified for kind %s", n.Kind)
+	}
+	if n.Version == "" {
+		n.Version = "v1"
+	}
+	if n.Singular == "" {
+		n.Singular = strings.ToLower(n.Kind)
+	}
+	if n.Plural == "" {
+		n.Plural = strings.ToLower(n.Kind) + "s"
+	}
+	switch n.Scope {
+	case "":
+		n.Scope = "Namespaced"
+	case "Namespaced", "Cluster":
+	default:
+		return nil, errors.Newf(v.Pos(), "crd: invalid scope %q", n.Scope)
+	}
+	n.listKind = n.Kind + "List"
+	return n, nil
+}
+
+func generate(v cue.Value, c *Config) (ast.Expr, error) {
+	n, err := c.names(v)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{}
+	schema := g.resource(v)
+	if g.errs != nil {
+		return nil, g.errs
+	}
+
+	version := newStruct(
+		"name", ast.NewString(n.Version),
+		"served", ast.NewBool(true),
+		"storage", ast.NewBool(true),
+		"schema", newStruct("openAPIV3Schema", schema),
+	)
+	if v.LookupPath(cue.MakePath(cue.Str("status").Optional())).Exists() {
+		version.Elts = append(version.Elts,
+			newField("subresources", newStruct("status", newStruct())))
+	}
+
+	return newStruct(
+		"apiVersion", ast.NewString("apiextensions.k8s.io/v1"),
+		"kind", ast.NewString("CustomResourceDefinition"),
+		"metadata", newStruct(
+			"name", ast.NewString(n.Plural+"."+n.Group),
+		),
+		"spec", newStruct(
+			"group", ast.NewString(n.Group),
+			"names", newStruct(
+				"kind", ast.NewString(n.Kind),
+				"listKind", ast.NewString(n.listKind),
+				"plural", ast.NewString(n.Plural),
+				"singular", ast.NewString(n.Singular),
+			),
+			"scope", ast.NewString(n.Scope),
+			"versions", ast.NewList(version),
+		),
+	), nil
+}
+
+// label returns the label for a schema keyword.
+func label(key string) ast.Label {
+	if ast.IsValidIdent(key) && !strings.HasPrefix(key, "#") &&
+		!strings.HasPrefix(key, "_") {
+		return ast.NewIdent(key)
+	}
+	return ast.NewString(key)
+}
+
+func newField(key string, x ast.Expr) *ast.Field {
+	f := &ast.Field{Label: label(key), Value: x}
+	ast.SetRelPos(f, token.Newline)
+	return f
+}
+
+// newStruct returns a struct with the given keys and values.
+func newStruct(kv ...interface{}) *ast.StructLit {
+	s := &ast.StructLit{}
+	for i := 0; i < len(kv); i += 2 {
+		s.Elts = append(s.Elts, newField(kv[i].(string), kv[i+1].(ast.Expr)))
+	}
+	return s
+}
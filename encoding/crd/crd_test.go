@@ -0,0 +1,294 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+)
+
+func TestGenerate(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		cfg  *Config
+		out  string
+		err  string
+	}{{
+		name: "schema",
+		cfg:  &Config{Group: "example.com"},
+		in: `
+		import "strings"
+
+		#Range: {
+			min: int
+			max: int
+
+			// min must not exceed max
+			_check: min <= max
+		}
+
+		#Schema: {
+			spec: {
+				replicas: *1 | int32 & >=0
+				port?:    int & >0 & <65536
+				mode:     "fast" | "slow"
+				labels: [string]: string
+				extra: {...}
+				any:   _
+				size:  int | string
+				note:  string | null
+				range: #Range
+				items: [...{name: string}]
+
+				_valid: true & (strings.HasPrefix(mode, "f") || len(labels) > 0)
+			}
+		}
+		`,
+		out: `
+		apiVersion: "apiextensions.k8s.io/v1"
+		kind:       "CustomResourceDefinition"
+		metadata:
+			name: "schemas.example.com"
+		spec: {
+			group: "example.com"
+			names: {
+				kind:     "Schema"
+				listKind: "SchemaList"
+				plural:   "schemas"
+				singular: "schema"
+			}
+			scope: "Namespaced"
+			versions: [{
+				name:    "v1"
+				served:  true
+				storage: true
+				schema:
+					openAPIV3Schema: {
+						type: "object"
+						required: ["spec"]
+						properties: {
+							apiVersion:
+								type: "string"
+							kind:
+								type: "string"
+							metadata:
+								type: "object"
+							spec: {
+								type: "object"
+								required: ["mode", "labels", "extra", "any", "size", "note", "range"]
+								properties: {
+									replicas: {
+										type:    "integer"
+										format:  "int32"
+										minimum: 0
+										default: 1
+									}
+									port: {
+										type:             "integer"
+										minimum:          0
+										exclusiveMinimum: true
+										maximum:          65536
+										exclusiveMaximum: true
+									}
+									mode: {
+										type: "string"
+										enum: ["fast", "slow"]
+									}
+									labels: {
+										type: "object"
+										additionalProperties:
+											type: "string"
+									}
+									extra: {
+										type:                                   "object"
+										"x-kubernetes-preserve-unknown-fields": true
+									}
+									any:
+										"x-kubernetes-preserve-unknown-fields": true
+									size:
+										"x-kubernetes-int-or-string": true
+									note: {
+										type:     "string"
+										nullable: true
+									}
+									range: {
+										type: "object"
+										required: ["min", "max"]
+										properties: {
+											min:
+												type: "integer"
+											max:
+												type: "integer"
+										}
+										"x-kubernetes-validations": [{
+											rule:    "self.min <= self.max"
+											message: "min must not exceed max"
+										}]
+									}
+									items: {
+										type: "array"
+										items: {
+											type: "object"
+											required: ["name"]
+											properties:
+												name:
+													type: "string"
+										}
+									}
+								}
+								"x-kubernetes-validations": [{
+									rule: "self.mode.startsWith(\"f\") || size(self.labels) > 0"
+								}]
+							}
+						}
+					}
+			}]
+		}`,
+	}, {
+		name: "names",
+		cfg:  &Config{Group: "ignored.com", Scope: "Cluster"},
+		in: `
+		// A Schema is a resource.
+		#Schema: {
+			apiVersion: "example.com/v1beta1"
+			kind:       "Thing"
+			status?: ready: bool
+		} @crd(group=example.com,plural=stuff)
+		`,
+		out: `
+		apiVersion: "apiextensions.k8s.io/v1"
+		kind:       "CustomResourceDefinition"
+		metadata:
+			name: "stuff.example.com"
+		spec: {
+			group: "example.com"
+			names: {
+				kind:     "Thing"
+				listKind: "ThingList"
+				plural:   "stuff"
+				singular: "thing"
+			}
+			scope: "Cluster"
+			versions: [{
+				name:    "v1beta1"
+				served:  true
+				storage: true
+				schema:
+					openAPIV3Schema: {
+						description: "A Schema is a resource."
+						type:        "object"
+						properties: {
+							apiVersion:
+								type: "string"
+							kind:
+								type: "string"
+							metadata:
+								type: "object"
+							status: {
+								type: "object"
+								required: ["ready"]
+								properties:
+									ready:
+										type: "boolean"
+							}
+						}
+					}
+				subresources:
+					status: {}
+			}]
+		}`,
+	}, {
+		name: "no group",
+		in:   `#Schema: spec: int`,
+		err:  "crd: no group specified for kind Schema",
+	}, {
+		name: "cycle",
+		cfg:  &Config{Group: "example.com"},
+		in:   `#Schema: spec: next?: #Schema`,
+		err:  "cycle in reference at #Schema",
+	}, {
+		name: "unsupported rule",
+		cfg:  &Config{Group: "example.com"},
+		in: `
+		#Schema: spec: {
+			a: [...int]
+			_check: a[0] > 0 && [ for x in a {x}] != []
+		}
+		`,
+		err: "cannot convert #Schema.spec._check to a validation rule",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := cuecontext.New().CompileString(tc.in)
+			if err := v.Err(); err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+
+			f, err := Generate(v.LookupPath(cue.ParsePath("#Schema")), tc.cfg)
+			if err != nil {
+				got := errors.Details(err, nil)
+				if tc.err == "" || !strings.Contains(got, tc.err) {
+					t.Fatalf("unexpected error: %v", got)
+				}
+				return
+			}
+			if tc.err != "" {
+				t.Fatalf("unexpected success; want error %q", tc.err)
+			}
+
+			b, err := format.Node(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := strings.TrimSpace(string(b))
+			want := strings.TrimSpace(strings.Replace(tc.out, "\n\t\t", "\n", -1))
+			if diff := cmp.Diff(got, want); diff != "" {
+				t.Errorf("%s\n(-got +want)\n%s", got, diff)
+			}
+		})
+	}
+}
+
+func TestGenerateAll(t *testing.T) {
+	v := cuecontext.New().CompileString(`
+	#A: {spec: int} @crd(group=example.com)
+	#B: {spec: string} @crd(group=example.com,version=v2)
+	#C: {spec: bool}
+	`)
+	f, err := GenerateAll(v, nil)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	got, err := cuecontext.New().BuildFile(f).LookupPath(cue.ParsePath("items")).List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for got.Next() {
+		s, _ := got.Value().LookupPath(cue.ParsePath("metadata.name")).String()
+		names = append(names, s)
+	}
+	if diff := cmp.Diff(names, []string{"as.example.com", "bs.example.com"}); diff != "" {
+		t.Error(diff)
+	}
+}
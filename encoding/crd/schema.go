@@ -0,0 +1,374 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crd
+
+import (
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/value"
+)
+
+type generator struct {
+	errs errors.Error
+
+	// expanding holds the references that are currently being expanded, to
+	// detect cycles, which cannot be represented in a structural schema.
+	expanding []*adt.Vertex
+}
+
+func (g *generator) addErrf(v cue.Value, format string, args ...interface{}) {
+	err := errors.Newf(v.Pos(), "crd: "+format, args...)
+	g.errs = errors.Append(g.errs, err)
+}
+
+// resource returns the schema of the resource defined by v.
+func (g *generator) resource(v cue.Value) *ast.StructLit {
+	s := &schema{}
+	if doc := docString(v); doc != "" {
+		s.set("description", ast.NewString(doc))
+	}
+	s.set("type", ast.NewString("object"))
+
+	props := []interface{}{
+		"apiVersion", newStruct("type", ast.NewString("string")),
+		"kind", newStruct("type", ast.NewString("string")),
+		"metadata", newStruct("type", ast.NewString("object")),
+	}
+	g.expand(v, func(v cue.Value) {
+		g.object(s, v, props...)
+	})
+	return s.finish()
+}
+
+// A schema collects the keywords of a schema.
+type schema struct {
+	elts []ast.Decl
+}
+
+func (s *schema) set(key string, x ast.Expr) {
+	for _, d := range s.elts {
+		if f := d.(*ast.Field); keyword(f) == key {
+			// A later constraint of the same kind overrides an earlier one.
+			// Conjunctions of bounds are already simplified by CUE.
+			f.Value = x
+			return
+		}
+	}
+	s.elts = append(s.elts, newField(key, x))
+}
+
+func (s *schema) finish() *ast.StructLit {
+	return &ast.StructLit{Elts: s.elts}
+}
+
+func keyword(f *ast.Field) string {
+	name, _, _ := ast.LabelName(f.Label)
+	return name
+}
+
+// expand calls f with the value referred to by v, reporting an error if
+// this value is already being expanded.
+func (g *generator) expand(v cue.Value, f func(v cue.Value)) {
+	v = cue.Dereference(v)
+	_, n := value.ToInternal(v)
+	for _, x := range g.expanding {
+		if x == n {
+			g.addErrf(v, "cycle in reference at %v: recursive types cannot be represented in a structural schema", v.Path())
+			return
+		}
+	}
+	g.expanding = append(g.expanding, n)
+	f(v)
+	g.expanding = g.expanding[:len(g.expanding)-1]
+}
+
+// schema converts v to a structural schema.
+func (g *generator) schema(v cue.Value) *ast.StructLit {
+	s := &schema{}
+	if doc := docString(v); doc != "" {
+		s.set("description", ast.NewString(doc))
+	}
+
+	k := v.IncompleteKind()
+	if k == cue.TopKind {
+		s.set("x-kubernetes-preserve-unknown-fields", ast.NewBool(true))
+		return s.finish()
+	}
+	nullable := k&cue.NullKind != 0 && k != cue.NullKind
+	k &^= cue.NullKind
+	switch k {
+	case cue.IntKind | cue.StringKind:
+		s.set("x-kubernetes-int-or-string", ast.NewBool(true))
+
+	default:
+		t, ok := typeNames[k]
+		if !ok {
+			g.addErrf(v, "unsupported type %v at %v: a structural schema requires a single type", k, v.Path())
+			return s.finish()
+		}
+		s.set("type", ast.NewString(t))
+		if k == cue.BytesKind {
+			s.set("format", ast.NewString("byte"))
+		}
+	}
+	if nullable {
+		s.set("nullable", ast.NewBool(true))
+	}
+
+	g.conjunct(s, v)
+
+	if d, ok := v.Default(); ok && !isEmptyList(d) &&
+		d.Validate(cue.Concrete(true)) == nil {
+		s.set("default", decodeValue(d))
+	}
+
+	return s.finish()
+}
+
+var typeNames = map[cue.Kind]string{
+	cue.BoolKind:   "boolean",
+	cue.IntKind:    "integer",
+	cue.FloatKind:  "number",
+	cue.NumberKind: "number",
+	cue.StringKind: "string",
+	cue.BytesKind:  "string",
+	cue.ListKind:   "array",
+	cue.StructKind: "object",
+}
+
+// formats maps the string representation of CUE types to the OpenAPI format
+// they correspond to. The constraints of these types are not converted.
+var formats = map[string]string{
+	"int32":       "int32",
+	"int64":       "int64",
+	"float32":     "float",
+	"float64":     "double",
+	"time.Time":   "date-time",
+	"time.Time()": "date-time",
+
+	`time.Format("2006-01-02")`: "date",
+}
+
+// conjunct adds the constraints of v.
+func (g *generator) conjunct(s *schema, v cue.Value) {
+	if f, ok := formats[fmt.Sprint(v)]; ok {
+		s.set("format", ast.NewString(f))
+		return
+	}
+	if root, _ := v.ReferencePath(); root.Exists() {
+		g.expand(v, func(v cue.Value) { g.conjunct(s, v) })
+		return
+	}
+
+	switch op, a := v.Expr(); op {
+	case cue.AndOp:
+		for _, x := range a {
+			g.conjunct(s, x)
+		}
+
+	case cue.OrOp:
+		g.disjunction(s, v, a)
+
+	case cue.LessThanOp, cue.LessThanEqualOp,
+		cue.GreaterThanOp, cue.GreaterThanEqualOp:
+		g.bound(s, op, a[0])
+
+	case cue.RegexMatchOp:
+		if str, err := a[0].String(); err == nil {
+			s.set("pattern", ast.NewString(str))
+		}
+
+	case cue.CallOp:
+		if key, ok := builtins[fmt.Sprint(a[0])]; ok && len(a) == 2 {
+			s.set(key, decodeValue(a[1]))
+		}
+
+	case cue.NoOp:
+		// A value with a default is represented by its value without the
+		// default.
+		if len(a) == 1 {
+			root, _ := a[0].ReferencePath()
+			if op, _ := a[0].Expr(); root.Exists() || op != cue.NoOp {
+				g.conjunct(s, a[0])
+				return
+			}
+			v = a[0]
+		}
+		switch v.IncompleteKind() {
+		case cue.StructKind:
+			g.object(s, v)
+		case cue.ListKind:
+			g.array(s, v)
+		default:
+			if v.IsConcrete() && v.Kind() != cue.NullKind {
+				s.set("enum", ast.NewList(decodeValue(v)))
+			}
+		}
+	}
+}
+
+// builtins maps the builtins with a single argument to the corresponding
+// keyword.
+var builtins = map[string]string{
+	"strings.MinRunes": "minLength",
+	"strings.MaxRunes": "maxLength",
+	"list.MinItems":    "minItems",
+	"list.MaxItems":    "maxItems",
+	"struct.MinFields": "minProperties",
+	"struct.MaxFields": "maxProperties",
+	"math.MultipleOf":  "multipleOf",
+}
+
+func (g *generator) bound(s *schema, op cue.Op, x cue.Value) {
+	if x.Kind()&cue.NumberKind == 0 {
+		return
+	}
+	n := decodeValue(x)
+	switch op {
+	case cue.LessThanOp:
+		s.set("maximum", n)
+		s.set("exclusiveMaximum", ast.NewBool(true))
+	case cue.LessThanEqualOp:
+		s.set("maximum", n)
+	case cue.GreaterThanOp:
+		s.set("minimum", n)
+		s.set("exclusiveMinimum", ast.NewBool(true))
+	case cue.GreaterThanEqualOp:
+		s.set("minimum", n)
+	}
+}
+
+// disjunction adds the constraints of the disjuncts a of v. Concrete values
+// map to an enum. A structural schema cannot represent other disjunctions,
+// except for a disjunction with null, which maps to a nullable schema.
+func (g *generator) disjunction(s *schema, v cue.Value, a []cue.Value) {
+	var enum []ast.Expr
+	var other []cue.Value
+	for _, x := range a {
+		switch {
+		case x.Kind() == cue.NullKind:
+		case x.IsConcrete() && x.Kind() != cue.StructKind && x.Kind() != cue.ListKind:
+			enum = append(enum, decodeValue(x))
+		default:
+			other = append(other, x)
+		}
+	}
+	switch {
+	case len(other) == 1:
+		if len(enum) == 0 {
+			g.conjunct(s, other[0])
+		}
+	case len(other) > 1:
+		if v.IncompleteKind()&^cue.NullKind == cue.StructKind {
+			s.set("x-kubernetes-preserve-unknown-fields", ast.NewBool(true))
+		}
+	case len(enum) > 0:
+		s.set("enum", ast.NewList(enum...))
+	}
+}
+
+// object adds the fields of v. The props are the schemas of predeclared
+// properties, given as pairs of names and schemas, which take precedence
+// over fields of v with the same name.
+func (g *generator) object(s *schema, v cue.Value, props ...interface{}) {
+	predeclared := map[string]bool{}
+	var elts []ast.Decl
+	for i := 0; i < len(props); i += 2 {
+		name := props[i].(string)
+		predeclared[name] = true
+		elts = append(elts, newField(name, props[i+1].(ast.Expr)))
+	}
+
+	var required []ast.Expr
+	var rules []ast.Expr
+	iter, _ := v.Fields(cue.Optional(true), cue.Hidden(true))
+	for iter.Next() {
+		x := iter.Value()
+		name := iter.Label()
+		switch sel := iter.Selector(); {
+		case sel.IsDefinition():
+			continue
+		case strings.HasPrefix(name, "_"):
+			if strings.HasPrefix(name, "_#") {
+				continue
+			}
+			if r := g.rule(v, x); r != nil {
+				rules = append(rules, r)
+			}
+			continue
+		}
+		if predeclared[name] {
+			continue
+		}
+		elts = append(elts, newField(name, g.schema(x)))
+		if _, hasDefault := x.Default(); !iter.IsOptional() && !hasDefault {
+			required = append(required, ast.NewString(name))
+		}
+	}
+
+	if len(required) > 0 {
+		s.set("required", ast.NewList(required...))
+	}
+	if len(elts) > 0 {
+		s.set("properties", &ast.StructLit{Elts: elts})
+	}
+	switch x := v.LookupPath(cue.MakePath(cue.AnyString)); {
+	case x.Exists() && x.IncompleteKind() != cue.TopKind:
+		// A structural schema does not allow both properties and
+		// additionalProperties.
+		if len(elts) == 0 {
+			s.set("additionalProperties", g.schema(x))
+		}
+	case v.Allows(cue.AnyString):
+		s.set("x-kubernetes-preserve-unknown-fields", ast.NewBool(true))
+	}
+	if len(rules) > 0 {
+		s.set("x-kubernetes-validations", ast.NewList(rules...))
+	}
+}
+
+func (g *generator) array(s *schema, v cue.Value) {
+	x := v.LookupPath(cue.MakePath(cue.AnyIndex))
+	if !x.Exists() {
+		g.addErrf(v, "unsupported closed list at %v", v.Path())
+		return
+	}
+	s.set("items", g.schema(x))
+}
+
+func docString(v cue.Value) string {
+	var doc []string
+	for _, d := range v.Doc() {
+		doc = append(doc, d.Text())
+	}
+	return strings.TrimSpace(strings.Join(doc, "\n\n"))
+}
+
+// isEmptyList reports whether v is the empty list, which is the default of
+// open lists.
+func isEmptyList(v cue.Value) bool {
+	n, err := v.Len().Int64()
+	return v.Kind() == cue.ListKind && err == nil && n == 0
+}
+
+func decodeValue(v cue.Value) ast.Expr {
+	v, _ = v.Default()
+	return v.Syntax(cue.Final()).(ast.Expr)
+}
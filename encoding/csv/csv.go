@@ -0,0 +1,227 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package csv converts CSV and TSV tables to CUE.
+//
+// A table maps to a list with a struct for each record. The first record of
+// the table is the header row, which determines the field names. For example,
+//
+//   cidr,zone,reserved
+//   10.0.0.0/24,us-east-1a,true
+//
+// maps to
+//
+//   [{
+//       cidr:     "10.0.0.0/24"
+//       zone:     "us-east-1a"
+//       reserved: true
+//   }]
+//
+// if types are inferred, or to a list where all values are strings otherwise.
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"regexp"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/source"
+)
+
+// Config configures the conversion of a table to CUE.
+type Config struct {
+	// Comma is the field delimiter. It defaults to ','. Use '\t' for TSV.
+	Comma rune
+
+	// Comment, if not 0, is a character that starts a comment line.
+	Comment rune
+
+	// InferTypes converts the values of columns that consist solely of
+	// numbers or solely of booleans to the corresponding CUE type. Empty
+	// values in such columns are omitted. By default, all values are strings.
+	//
+	// Only numbers in canonical decimal form are recognized, so that columns
+	// with values like zip codes with leading zeros are retained as strings.
+	InferTypes bool
+}
+
+// Extract parses the table of the given file to a CUE list of structs.
+func Extract(filename string, src interface{}, cfg *Config) (ast.Expr, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	b, err := source.Read(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	tf := token.NewFile(filename, -1, len(b))
+	tf.SetLinesForContent(b)
+
+	r := csv.NewReader(bytes.NewReader(b))
+	if cfg.Comma != 0 {
+		r.Comma = cfg.Comma
+	}
+	r.Comment = cfg.Comment
+
+	d := &decoder{file: tf, src: b}
+
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, errors.Newf(tf.Pos(0, token.NoRelPos), "csv: missing header row")
+	}
+	if err != nil {
+		return nil, d.wrap(err)
+	}
+	labels := make([]ast.Label, len(header))
+	seen := map[string]bool{}
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, errors.Newf(tf.Pos(0, token.NoRelPos),
+				"csv: empty name for column %d in header row", i+1)
+		}
+		if seen[name] {
+			return nil, errors.Newf(tf.Pos(0, token.NoRelPos),
+				"csv: duplicate column %q in header row", name)
+		}
+		seen[name] = true
+		labels[i] = label(name)
+	}
+
+	var records [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, d.wrap(err)
+		}
+		records = append(records, record)
+	}
+
+	kinds := make([]kind, len(header))
+	if cfg.InferTypes {
+		for i := range kinds {
+			kinds[i] = inferKind(records, i)
+		}
+	}
+
+	list := &ast.ListLit{}
+	for _, record := range records {
+		s := &ast.StructLit{}
+		for i, v := range record {
+			if v == "" && kinds[i] != stringKind {
+				continue
+			}
+			f := &ast.Field{Label: labels[i], Value: value(kinds[i], v)}
+			ast.SetRelPos(f, token.Newline)
+			s.Elts = append(s.Elts, f)
+		}
+		list.Elts = append(list.Elts, s)
+	}
+	return list, nil
+}
+
+type decoder struct {
+	file *token.File
+	src  []byte
+}
+
+// A kind is the type of the values of a column.
+type kind int
+
+const (
+	stringKind kind = iota
+	boolKind
+	numberKind
+)
+
+var numberRE = regexp.MustCompile(
+	`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// inferKind determines the kind of column i. A column is of kind bool or
+// number if all its non-empty values are of that kind.
+func inferKind(records [][]string, i int) kind {
+	k := stringKind
+	for _, r := range records {
+		v := r[i]
+		var vk kind
+		switch {
+		case v == "":
+			continue
+		case v == "true", v == "false":
+			vk = boolKind
+		case numberRE.MatchString(v):
+			vk = numberKind
+		default:
+			return stringKind
+		}
+		if k != stringKind && k != vk {
+			return stringKind
+		}
+		k = vk
+	}
+	return k
+}
+
+func value(k kind, s string) ast.Expr {
+	switch k {
+	case boolKind:
+		return ast.NewBool(s == "true")
+	case numberKind:
+		if strings.ContainsAny(s, ".eE") {
+			return ast.NewLit(token.FLOAT, s)
+		}
+		return ast.NewLit(token.INT, s)
+	}
+	return ast.NewString(s)
+}
+
+// wrap converts an error reported by the CSV reader to a CUE error.
+func (d *decoder) wrap(err error) error {
+	pos := token.NoPos
+	msg := err.Error()
+	if pe, ok := err.(*csv.ParseError); ok {
+		pos = d.file.Pos(d.lineOffset(pe.Line), token.NoRelPos)
+		msg = pe.Err.Error()
+	}
+	return errors.Newf(pos, "csv: %s", msg)
+}
+
+// lineOffset returns the offset of the start of the given 1-based line.
+func (d *decoder) lineOffset(line int) int {
+	offset := 0
+	for ; line > 1; line-- {
+		i := bytes.IndexByte(d.src[offset:], '\n')
+		if i < 0 {
+			break
+		}
+		offset += i + 1
+	}
+	return offset
+}
+
+func label(name string) ast.Label {
+	if ast.IsValidIdent(name) && !strings.HasPrefix(name, "_") &&
+		!strings.HasPrefix(name, "#") {
+		return ast.NewIdent(name)
+	}
+	return ast.NewString(name)
+}
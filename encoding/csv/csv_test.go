@@ -0,0 +1,129 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csv
+
+import (
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/format"
+)
+
+func TestExtract(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		cfg  *Config
+		out  string
+	}{{
+		name: "strings",
+		in: `cidr,zone,reserved
+10.0.0.0/24,us-east-1a,true
+10.0.1.0/24,"us-east-1b",false
+`,
+		out: `[{
+	cidr:     "10.0.0.0/24"
+	zone:     "us-east-1a"
+	reserved: "true"
+}, {
+	cidr:     "10.0.1.0/24"
+	zone:     "us-east-1b"
+	reserved: "false"
+}]`,
+	}, {
+		name: "infer",
+		in: `name,price,count,zip,available,note
+apple,1.25,3,02134,true,
+pear,-2e3,0,10001,false,"a, b"
+plum,,7,10002,,
+`,
+		cfg: &Config{InferTypes: true},
+		out: `[{
+	name:      "apple"
+	price:     1.25
+	count:     3
+	zip:       "02134"
+	available: true
+	note:      ""
+}, {
+	name:      "pear"
+	price:     -2e3
+	count:     0
+	zip:       "10001"
+	available: false
+	note:      "a, b"
+}, {
+	name:  "plum"
+	count: 7
+	zip:   "10002"
+	note:  ""
+}]`,
+	}, {
+		name: "tsv",
+		in:   "first name\t_id\nJohn\t1\n",
+		cfg:  &Config{Comma: '\t'},
+		out: `[{
+	"first name": "John"
+	"_id":        "1"
+}]`,
+	}, {
+		name: "comments",
+		in:   "# comment\na\n1\n",
+		cfg:  &Config{Comment: '#'},
+		out: `[{
+	a: "1"
+}]`,
+	}, {
+		name: "header only",
+		in:   "a,b\n",
+		out:  `[]`,
+	}, {
+		name: "empty",
+		in:   "",
+		out:  `error: csv: missing header row`,
+	}, {
+		name: "duplicate column",
+		in:   "a,a\n1,2\n",
+		out:  `error: csv: duplicate column "a" in header row`,
+	}, {
+		name: "field count",
+		in:   "a,b\n1,2\n3\n",
+		out:  `error: csv: wrong number of fields`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := Extract(tc.name, tc.in, tc.cfg)
+			var got string
+			if err != nil {
+				got = "error: " + err.Error()
+			} else {
+				b, err := format.Node(expr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = string(b)
+			}
+			if strings.HasPrefix(tc.out, "error: ") {
+				if !strings.HasPrefix(got, tc.out) {
+					t.Errorf("got %v; want %v", got, tc.out)
+				}
+				return
+			}
+			if got != tc.out {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.out)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hcl
+
+// This file implements the evaluation of operations of which all operands are
+// literals. HCL converts strings to numbers and booleans where needed, but
+// such conversions are not applied here: operations on operands of
+// unexpected types are not evaluated.
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/apd/v2"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal"
+)
+
+// constant returns the value of the literal x as a *apd.Decimal, string,
+// bool, or nil for null. It reports false if x is not a literal, or is a
+// string that contains interpolation or directive sequences.
+func constant(x ast.Expr) (v interface{}, ok bool) {
+	lit, ok := x.(*ast.BasicLit)
+	if !ok {
+		return nil, false
+	}
+	switch lit.Kind {
+	case token.INT, token.FLOAT:
+		d, _, err := apd.NewFromString(lit.Value)
+		return d, err == nil
+	case token.STRING:
+		s, err := literal.Unquote(lit.Value)
+		if err != nil || strings.Contains(s, "${") || strings.Contains(s, "%{") {
+			return nil, false
+		}
+		return s, true
+	case token.TRUE, token.FALSE:
+		return lit.Kind == token.TRUE, true
+	case token.NULL:
+		return nil, true
+	}
+	return nil, false
+}
+
+// unaryOp returns the value of op applied to x, or nil if it cannot be
+// evaluated.
+func unaryOp(op string, x ast.Expr) ast.Expr {
+	v, ok := constant(x)
+	if !ok {
+		return nil
+	}
+	switch v := v.(type) {
+	case *apd.Decimal:
+		if op == "-" {
+			d := &apd.Decimal{}
+			d.Neg(v)
+			return number(d)
+		}
+	case bool:
+		if op == "!" {
+			return ast.NewBool(!v)
+		}
+	}
+	return nil
+}
+
+// binaryOp returns the value of op applied to x and y, or nil if it cannot be
+// evaluated.
+func binaryOp(op string, x, y ast.Expr) ast.Expr {
+	a, ok := constant(x)
+	if !ok {
+		return nil
+	}
+	b, ok := constant(y)
+	if !ok {
+		return nil
+	}
+	switch op {
+	case "==":
+		return ast.NewBool(equal(a, b))
+	case "!=":
+		return ast.NewBool(!equal(a, b))
+	}
+
+	switch a := a.(type) {
+	case bool:
+		b, ok := b.(bool)
+		if !ok {
+			return nil
+		}
+		switch op {
+		case "&&":
+			return ast.NewBool(a && b)
+		case "||":
+			return ast.NewBool(a || b)
+		}
+
+	case *apd.Decimal:
+		b, ok := b.(*apd.Decimal)
+		if !ok {
+			return nil
+		}
+		switch op {
+		case "<":
+			return ast.NewBool(a.Cmp(b) < 0)
+		case "<=":
+			return ast.NewBool(a.Cmp(b) <= 0)
+		case ">":
+			return ast.NewBool(a.Cmp(b) > 0)
+		case ">=":
+			return ast.NewBool(a.Cmp(b) >= 0)
+		}
+		if b.IsZero() && (op == "/" || op == "%") {
+			return nil
+		}
+		d := &apd.Decimal{}
+		var err error
+		switch op {
+		case "+":
+			_, err = internal.BaseContext.Add(d, a, b)
+		case "-":
+			_, err = internal.BaseContext.Sub(d, a, b)
+		case "*":
+			_, err = internal.BaseContext.Mul(d, a, b)
+		case "/":
+			_, err = internal.BaseContext.Quo(d, a, b)
+		case "%":
+			_, err = internal.BaseContext.Rem(d, a, b)
+		default:
+			return nil
+		}
+		if err != nil {
+			return nil
+		}
+		return number(d)
+	}
+	return nil
+}
+
+// equal reports whether the constants a and b are equal. As in HCL, values of
+// different types are never equal.
+func equal(a, b interface{}) bool {
+	switch a := a.(type) {
+	case *apd.Decimal:
+		b, ok := b.(*apd.Decimal)
+		return ok && a.Cmp(b) == 0
+	case nil:
+		return b == nil
+	}
+	return a == b
+}
+
+// number returns the literal for d. HCL does not distinguish integers from
+// floating-point numbers, so whole numbers are represented as integers.
+func number(d *apd.Decimal) ast.Expr {
+	d.Reduce(d)
+	if d.IsZero() {
+		d.Negative = false
+	}
+	if d.Exponent >= 0 {
+		return ast.NewLit(token.INT, d.Text('f'))
+	}
+	return ast.NewLit(token.FLOAT, d.Text('f'))
+}
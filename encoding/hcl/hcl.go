@@ -0,0 +1,195 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hcl converts HCL2 configurations, such as Terraform files, to CUE.
+//
+// The mapping follows the HCL JSON syntax:
+//
+//   - An attribute maps to a regular field.
+//   - A block maps to a field named after the block type. Each block label
+//     adds a level of nesting, and the block body maps to a struct.
+//   - Blocks with the same type and labels map to a list of structs.
+//
+// Literals, templates, tuples, and objects are converted to their CUE
+// equivalents. Templates retain interpolation and directive sequences
+// verbatim. Operations and conditionals of which all operands are literals
+// are evaluated, so that count = 2 * 3 maps to count: 6. Any other
+// expression, such as a reference, function call, or for expression, or an
+// operation involving one, cannot be resolved statically and maps to a string
+// holding its source wrapped in an interpolation sequence.
+//
+// For example,
+//
+//   resource "aws_instance" "web" {
+//     ami   = "ami-a1b2c3d4"
+//     count = var.instances
+//     tags  = { tier = 1 + 1 }
+//   }
+//
+// maps to
+//
+//   resource: aws_instance: web: {
+//       ami:   "ami-a1b2c3d4"
+//       count: "${var.instances}"
+//       tags: tier: 2
+//   }
+//
+package hcl
+
+import (
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/source"
+)
+
+// Extract parses the HCL file of the given name to a CUE file.
+func Extract(filename string, src interface{}) (f *ast.File, err error) {
+	b, err := source.Read(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	tf := token.NewFile(filename, -1, len(b))
+	tf.SetLinesForContent(b)
+
+	p := &parser{file: tf, src: b}
+	defer func() {
+		if e := recover(); e != nil {
+			b, ok := e.(bailout)
+			if !ok {
+				panic(e)
+			}
+			f, err = nil, b.err
+		}
+	}()
+	body := p.parseBody(false)
+	return &ast.File{Filename: filename, Decls: p.decls(body)}, nil
+}
+
+// decls converts the items of a body to CUE fields.
+func (p *parser) decls(b *body) []ast.Decl {
+	var decls []ast.Decl
+	attrs := map[string]bool{}
+	groups := map[string]*blockGroup{}
+	var order []*blockGroup
+
+	for _, it := range b.items {
+		pos := p.pos(it.offset)
+		if attrs[it.name] || (it.block == nil && groups[it.name] != nil) {
+			p.errf(it.offset, "duplicate attribute or block %q", it.name)
+		}
+		if it.block == nil {
+			attrs[it.name] = true
+			f := &ast.Field{Label: label(it.name), Value: it.value}
+			ast.SetPos(f, pos.WithRel(token.Newline))
+			decls = append(decls, f)
+			continue
+		}
+
+		g := groups[it.name]
+		if g == nil {
+			g = &blockGroup{field: &ast.Field{Label: label(it.name)}}
+			ast.SetPos(g.field, pos.WithRel(token.Newline))
+			groups[it.name] = g
+			order = append(order, g)
+			decls = append(decls, g.field)
+		}
+		s := &ast.StructLit{
+			Lbrace: pos,
+			Elts:   p.decls(it.block),
+			Rbrace: pos.WithRel(token.Newline),
+		}
+		if !g.add(it.labels, s) {
+			p.errf(it.offset,
+				"blocks of type %q have different numbers of labels", it.name)
+		}
+	}
+
+	for _, g := range order {
+		g.field.Value = g.expr()
+	}
+	return decls
+}
+
+// A blockGroup collects the bodies of the blocks of a single type, nested by
+// their labels.
+type blockGroup struct {
+	field  *ast.Field
+	labels []string
+	sub    map[string]*blockGroup
+	bodies []ast.Expr
+}
+
+// add adds the body of a block with the given remaining labels. It reports
+// false if the number of labels is inconsistent with earlier blocks.
+func (g *blockGroup) add(labels []string, body ast.Expr) bool {
+	if len(labels) == 0 {
+		g.bodies = append(g.bodies, body)
+		return len(g.labels) == 0
+	}
+	if len(g.bodies) > 0 {
+		return false
+	}
+	name := labels[0]
+	c := g.sub[name]
+	if c == nil {
+		if g.sub == nil {
+			g.sub = map[string]*blockGroup{}
+		}
+		c = &blockGroup{}
+		g.sub[name] = c
+		g.labels = append(g.labels, name)
+	}
+	return c.add(labels[1:], body)
+}
+
+func (g *blockGroup) expr() ast.Expr {
+	if len(g.labels) == 0 {
+		if len(g.bodies) == 1 {
+			return g.bodies[0]
+		}
+		return ast.NewList(g.bodies...)
+	}
+	s := &ast.StructLit{}
+	for _, name := range g.labels {
+		f := &ast.Field{Label: label(name), Value: g.sub[name].expr()}
+		if len(g.labels) > 1 {
+			ast.SetRelPos(f, token.Newline)
+		}
+		s.Elts = append(s.Elts, f)
+	}
+	return s
+}
+
+func label(name string) ast.Label {
+	if ast.IsValidIdent(name) && !strings.HasPrefix(name, "_") &&
+		!strings.HasPrefix(name, "#") {
+		return ast.NewIdent(name)
+	}
+	return ast.NewString(name)
+}
+
+type bailout struct {
+	err errors.Error
+}
+
+func (p *parser) pos(offset int) token.Pos {
+	return p.file.Pos(offset, token.NoRelPos)
+}
+
+func (p *parser) errf(offset int, format string, args ...interface{}) {
+	panic(bailout{errors.Newf(p.pos(offset), "hcl: "+format, args...)})
+}
@@ -0,0 +1,206 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hcl
+
+import (
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/format"
+)
+
+func TestExtract(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		out  string
+	}{{
+		name: "literals",
+		in: `
+a = 1
+b = -2.5e3
+c = "foo\tbar"
+d = true
+e = null
+f = [1, "two", false]
+g = { x = 1, "y-z": 2 }
+`,
+		out: `a: 1
+b: -2.5e3
+c: "foo\tbar"
+d: true
+e: null
+f: [1, "two", false]
+g: {
+	x:     1
+	"y-z": 2
+}`,
+	}, {
+		name: "blocks",
+		in: `
+# Comment
+terraform {
+  required_version = ">= 1.0"
+}
+
+resource "aws_instance" "web" {
+  ami = "ami-a1b2c3d4"
+
+  ebs_block_device {
+    device_name = "/dev/sda1"
+  }
+  ebs_block_device {
+    device_name = "/dev/sdb1"
+  }
+}
+
+resource "aws_instance" "db-1" { ami = "ami-e5f6" }
+`,
+		out: `terraform: {
+	required_version: ">= 1.0"
+}
+resource: aws_instance: {
+	web: {
+		ami: "ami-a1b2c3d4"
+		ebs_block_device: [{
+			device_name: "/dev/sda1"
+		}, {
+			device_name: "/dev/sdb1"
+		}]
+	}
+	"db-1": {
+		ami: "ami-e5f6"
+	}
+}`,
+	}, {
+		name: "expressions",
+		in: `
+a = var.instances
+b = "${var.name}-web"
+c = length(var.zones) > 1 ? "multi" : "single"
+d = [var.a, 2, [for z in var.zones : upper(z)]]
+e = {
+  name = local.name
+  (var.key) = 1
+}
+f = aws_instance.web[*].id
+g = "$${literal}"
+`,
+		out: `a: "${var.instances}"
+b: "${var.name}-web"
+c: "${length(var.zones) > 1 ? \"multi\" : \"single\"}"
+d: ["${var.a}", 2, "${[for z in var.zones : upper(z)]}"]
+e: "${{\n  name = local.name\n  (var.key) = 1\n}}"
+f: "${aws_instance.web[*].id}"
+g: "$${literal}"`,
+	}, {
+		name: "operations",
+		in: `
+a = 2 + 3 * 4
+b = (2 + 3) * 4
+c = 7 / 2
+d = 7 % 3
+e = -(1 - 3)
+f = 1.5 * 2
+g = 2 > 1 && !false
+h = "a" == "a"
+i = 1 == "1"
+j = null != null
+k = 1 < 2 ? "less" : "more"
+l = false ? 1 : [1 + 1]
+m = 1 / 0
+n = var.a + 1
+o = "${var.a}" == "x"
+p = true ? var.a : 1
+`,
+		out: `a: 14
+b: 20
+c: 3.5
+d: 1
+e: 2
+f: 3
+g: true
+h: true
+i: false
+j: false
+k: "less"
+l: [2]
+m: "${1 / 0}"
+n: "${var.a + 1}"
+o: "${\"${var.a}\" == \"x\"}"
+p: "${true ? var.a : 1}"`,
+	}, {
+		name: "heredoc",
+		in: `
+policy = <<EOF
+{
+  "Version": "2012-10-17"
+}
+EOF
+script = <<-EOT
+    echo hello
+      echo world
+    EOT
+`,
+		out: `policy: "{\n  \"Version\": \"2012-10-17\"\n}\n"
+script: "echo hello\n  echo world\n"`,
+	}, {
+		name: "duplicate",
+		in: `
+a = 1
+a = 2
+`,
+		out: `error: hcl: duplicate attribute or block "a"`,
+	}, {
+		name: "labels",
+		in: `
+a "x" {}
+a {}
+`,
+		out: `error: hcl: blocks of type "a" have different numbers of labels`,
+	}, {
+		name: "unterminated",
+		in:   `a = "foo`,
+		out:  `error: hcl: string literal not terminated`,
+	}, {
+		name: "missing newline",
+		in:   `a = 1 b = 2`,
+		out:  `error: hcl: expected newline, found 'b'`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := Extract(tc.name, tc.in)
+			var got string
+			if err != nil {
+				got = "error: " + err.Error()
+			} else {
+				b, err := format.Node(f)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = strings.TrimSpace(string(b))
+			}
+			if strings.HasPrefix(tc.out, "error: ") {
+				if !strings.HasPrefix(got, tc.out) {
+					t.Errorf("got %v; want %v", got, tc.out)
+				}
+				return
+			}
+			if got != tc.out {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.out)
+			}
+		})
+	}
+}
@@ -0,0 +1,752 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hcl
+
+// This file implements a parser for the HCL2 native syntax. Expressions are
+// only analyzed to the extent needed to determine their extent and whether
+// they can be converted to a CUE value statically.
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
+)
+
+type parser struct {
+	file *token.File
+	src  []byte
+	off  int
+
+	// nest is the bracket nesting level of the current expression. Newlines
+	// are insignificant within parentheses and brackets.
+	nest int
+}
+
+// A body is a sequence of attributes and blocks.
+type body struct {
+	items []*item
+}
+
+// An item is an attribute or a block.
+type item struct {
+	offset int
+	name   string
+	value  ast.Expr // attributes only
+
+	labels []string // blocks only
+	block  *body    // blocks only
+}
+
+// An expr describes a parsed expression.
+type expr struct {
+	// x is the CUE value of the expression, or nil if it cannot be determined
+	// statically.
+	x ast.Expr
+
+	start, end int
+}
+
+// value returns the CUE value for e. Expressions that cannot be evaluated
+// statically are represented as interpolation sequences.
+func (p *parser) value(e expr) ast.Expr {
+	if e.x != nil {
+		return e.x
+	}
+	s := ast.NewString("${" + string(p.src[e.start:e.end]) + "}")
+	ast.SetPos(s, p.pos(e.start))
+	return s
+}
+
+func (p *parser) parseBody(closing bool) *body {
+	b := &body{}
+	for {
+		p.skip(true)
+		switch {
+		case p.off >= len(p.src):
+			if closing {
+				p.errf(p.off, "unexpected EOF, expected '}'")
+			}
+			return b
+		case p.peek() == '}':
+			if !closing {
+				p.errf(p.off, "unexpected '}'")
+			}
+			p.off++
+			return b
+		}
+		b.items = append(b.items, p.parseItem())
+	}
+}
+
+func (p *parser) parseItem() *item {
+	it := &item{offset: p.off, name: p.ident()}
+	if it.name == "" {
+		p.errf(p.off, "expected attribute or block, found %s", p.found())
+	}
+	p.skip(false)
+	if p.peek() == '=' && !p.hasPrefix("==") {
+		p.off++
+		it.value = p.value(p.expr())
+		p.endItem()
+		return it
+	}
+	for {
+		p.skip(false)
+		switch c := p.peek(); {
+		case c == '{':
+			p.off++
+			it.block = p.parseBody(true)
+			p.endItem()
+			return it
+		case c == '"':
+			start := p.off
+			s := p.template()
+			if strings.Contains(string(p.src[start:p.off]), "${") {
+				p.errf(start, "block labels may not contain interpolations")
+			}
+			it.labels = append(it.labels, s)
+		case isIdentStart(p.peekRune()):
+			it.labels = append(it.labels, p.ident())
+		default:
+			p.errf(p.off, "expected '=', '{', or block label, found %s", p.found())
+		}
+	}
+}
+
+// endItem checks that an attribute or block is terminated by a newline. The
+// closing brace of a single-line block also terminates an attribute.
+func (p *parser) endItem() {
+	p.skip(false)
+	if p.off < len(p.src) && p.peek() != '\n' && p.peek() != '}' {
+		p.errf(p.off, "expected newline, found %s", p.found())
+	}
+}
+
+var binaryOps = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{">=", "<=", ">", "<"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) expr() expr {
+	x := p.binary(0)
+	p.space()
+	if p.peek() != '?' {
+		return x
+	}
+	p.off++
+	y := p.expr()
+	p.space()
+	p.expect(':')
+	z := p.expr()
+	e := expr{start: x.start, end: z.end}
+	if c, ok := constant(x.x); ok {
+		switch c {
+		case true:
+			e.x = y.x
+		case false:
+			e.x = z.x
+		}
+	}
+	return e
+}
+
+func (p *parser) binary(prec int) expr {
+	if prec == len(binaryOps) {
+		return p.unary()
+	}
+	x := p.binary(prec + 1)
+	for {
+		p.space()
+		op := ""
+		for _, s := range binaryOps[prec] {
+			if p.hasPrefix(s) {
+				op = s
+				break
+			}
+		}
+		if op == "" {
+			return x
+		}
+		p.off += len(op)
+		y := p.binary(prec + 1)
+		x = expr{x: binaryOp(op, x.x, y.x), start: x.start, end: y.end}
+		if x.x != nil {
+			ast.SetPos(x.x, p.pos(x.start))
+		}
+	}
+}
+
+func (p *parser) unary() expr {
+	p.space()
+	start := p.off
+	switch p.peek() {
+	case '-':
+		p.off++
+		x := p.unary()
+		if lit, ok := x.x.(*ast.BasicLit); ok && !strings.HasPrefix(lit.Value, "-") &&
+			(lit.Kind == token.INT || lit.Kind == token.FLOAT) {
+			// Retain the form of negative number literals.
+			x.x = ast.NewLit(lit.Kind, "-"+lit.Value)
+		} else {
+			x.x = unaryOp("-", x.x)
+		}
+		if x.x != nil {
+			ast.SetPos(x.x, p.pos(start))
+		}
+		x.start = start
+		return x
+	case '!':
+		p.off++
+		x := p.unary()
+		x.x = unaryOp("!", x.x)
+		if x.x != nil {
+			ast.SetPos(x.x, p.pos(start))
+		}
+		x.start = start
+		return x
+	}
+	return p.postfix()
+}
+
+// postfix parses a primary expression followed by any attribute accesses,
+// index operations, and splats.
+func (p *parser) postfix() expr {
+	x := p.primary()
+	for {
+		p.space()
+		switch {
+		case p.hasPrefix(".*"):
+			p.off += 2
+		case p.peek() == '.':
+			p.off++
+			p.space()
+			if isDigit(p.peek()) {
+				p.digits()
+			} else if p.ident() == "" {
+				p.errf(p.off, "expected attribute name, found %s", p.found())
+			}
+		case p.peek() == '[':
+			p.off++
+			p.nest++
+			p.space()
+			if p.peek() == '*' {
+				p.off++
+			} else {
+				p.expr()
+			}
+			p.space()
+			p.expect(']')
+			p.nest--
+		default:
+			return x
+		}
+		x = expr{start: x.start, end: p.off}
+	}
+}
+
+func (p *parser) primary() expr {
+	p.space()
+	start := p.off
+	lit := func(x ast.Expr) expr {
+		ast.SetPos(x, p.pos(start))
+		return expr{x: x, start: start, end: p.off}
+	}
+	switch c := p.peek(); {
+	case c == '"':
+		return lit(ast.NewString(p.template()))
+
+	case p.hasPrefix("<<"):
+		return lit(ast.NewString(p.heredoc()))
+
+	case isDigit(c):
+		return lit(p.number())
+
+	case c == '(':
+		p.off++
+		p.nest++
+		x := p.expr()
+		p.space()
+		p.expect(')')
+		p.nest--
+		return expr{x: x.x, start: start, end: p.off}
+
+	case c == '[':
+		return p.tuple()
+
+	case c == '{':
+		return p.object()
+
+	case isIdentStart(p.peekRune()):
+		switch name := p.ident(); name {
+		case "true", "false":
+			return lit(ast.NewBool(name == "true"))
+		case "null":
+			return lit(ast.NewNull())
+		}
+		end := p.off
+		p.space()
+		if p.peek() != '(' {
+			return expr{start: start, end: end}
+		}
+		// Function call.
+		p.off++
+		p.nest++
+		for {
+			p.space()
+			if p.peek() == ')' {
+				break
+			}
+			p.expr()
+			p.space()
+			if p.hasPrefix("...") {
+				p.off += 3
+				p.space()
+			}
+			if p.peek() != ',' {
+				break
+			}
+			p.off++
+		}
+		p.expect(')')
+		p.nest--
+		return expr{start: start, end: p.off}
+	}
+	p.errf(start, "expected expression, found %s", p.found())
+	return expr{}
+}
+
+func (p *parser) tuple() expr {
+	start := p.off
+	p.off++
+	p.nest++
+	defer func() { p.nest-- }()
+
+	p.space()
+	if p.keyword("for") {
+		p.skipTo(']')
+		return expr{start: start, end: p.off}
+	}
+	list := &ast.ListLit{Lbrack: p.pos(start)}
+	for {
+		p.space()
+		if p.peek() == ']' {
+			break
+		}
+		list.Elts = append(list.Elts, p.value(p.expr()))
+		p.space()
+		if p.peek() != ',' {
+			break
+		}
+		p.off++
+	}
+	list.Rbrack = p.pos(p.off)
+	p.expect(']')
+	return expr{x: list, start: start, end: p.off}
+}
+
+func (p *parser) object() expr {
+	start := p.off
+	p.off++
+	p.skip(true)
+	if p.keyword("for") {
+		p.nest++
+		p.skipTo('}')
+		p.nest--
+		return expr{start: start, end: p.off}
+	}
+
+	// Newlines separate the elements of an object.
+	nest := p.nest
+	p.nest = 0
+	defer func() { p.nest = nest }()
+
+	s := &ast.StructLit{Lbrace: p.pos(start)}
+	static := true
+	for {
+		p.skip(true)
+		if p.peek() == '}' {
+			break
+		}
+		keyStart := p.off
+		key := p.objectKey()
+		p.space()
+		if c := p.peek(); (c != '=' || p.hasPrefix("==")) && c != ':' {
+			p.errf(p.off, "expected '=' or ':', found %s", p.found())
+		}
+		p.off++
+		value := p.value(p.expr())
+		if key == nil {
+			static = false
+		} else {
+			f := &ast.Field{Label: key, Value: value}
+			ast.SetPos(f, p.pos(keyStart).WithRel(token.Newline))
+			s.Elts = append(s.Elts, f)
+		}
+
+		p.space()
+		switch p.peek() {
+		case ',':
+			p.off++
+		case '\n', '}':
+		default:
+			p.errf(p.off, "expected ',', newline, or '}', found %s", p.found())
+		}
+	}
+	s.Rbrace = p.pos(p.off).WithRel(token.Newline)
+	p.off++
+	if !static {
+		return expr{start: start, end: p.off}
+	}
+	return expr{x: s, start: start, end: p.off}
+}
+
+// objectKey parses the key of an object element. It returns nil if the key
+// cannot be determined statically.
+func (p *parser) objectKey() ast.Label {
+	start := p.off
+	if name := p.ident(); name != "" {
+		p.space()
+		if c := p.peek(); c == '=' || c == ':' {
+			return label(name)
+		}
+		p.off = start
+	}
+	if p.peek() == '"' {
+		s := p.template()
+		if !bytes.Contains(p.src[start:p.off], []byte("${")) &&
+			!bytes.Contains(p.src[start:p.off], []byte("%{")) {
+			return label(s)
+		}
+		return nil
+	}
+	p.expr()
+	return nil
+}
+
+// template scans a quoted template and returns its value. Escape sequences
+// are decoded, while interpolation and directive sequences are retained
+// verbatim, as in the HCL JSON syntax.
+func (p *parser) template() string {
+	start := p.off
+	p.off++
+	var buf strings.Builder
+	for {
+		if p.off >= len(p.src) || p.peek() == '\n' {
+			p.errf(start, "string literal not terminated")
+		}
+		switch c := p.peek(); {
+		case c == '"':
+			p.off++
+			return buf.String()
+
+		case c == '\\':
+			p.escape(&buf)
+
+		case p.hasPrefix("$${"), p.hasPrefix("%%{"):
+			buf.Write(p.src[p.off : p.off+3])
+			p.off += 3
+
+		case p.hasPrefix("${"), p.hasPrefix("%{"):
+			s := p.off
+			p.off += 2
+			p.skipTo('}')
+			buf.Write(p.src[s:p.off])
+
+		default:
+			buf.WriteByte(c)
+			p.off++
+		}
+	}
+}
+
+func (p *parser) escape(buf *strings.Builder) {
+	start := p.off
+	p.off++
+	if p.off >= len(p.src) {
+		p.errf(start, "escape sequence not terminated")
+	}
+	c := p.src[p.off]
+	p.off++
+	switch c {
+	case 'n':
+		buf.WriteByte('\n')
+	case 'r':
+		buf.WriteByte('\r')
+	case 't':
+		buf.WriteByte('\t')
+	case '"', '\\':
+		buf.WriteByte(c)
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.off+n > len(p.src) {
+			p.errf(start, "invalid escape sequence")
+		}
+		r, err := strconv.ParseUint(string(p.src[p.off:p.off+n]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			p.errf(start, "invalid escape sequence")
+		}
+		p.off += n
+		buf.WriteRune(rune(r))
+	default:
+		p.errf(start, "invalid escape sequence")
+	}
+}
+
+// heredoc scans a heredoc template and returns its value. The lines of an
+// indented heredoc, introduced with <<-, are stripped of the leading white
+// space they have in common.
+func (p *parser) heredoc() string {
+	start := p.off
+	p.off += 2
+	indented := p.peek() == '-'
+	if indented {
+		p.off++
+	}
+	id := p.ident()
+	if id == "" {
+		p.errf(p.off, "expected heredoc identifier, found %s", p.found())
+	}
+	if p.hasPrefix("\r\n") {
+		p.off++
+	}
+	p.expect('\n')
+
+	var lines []string
+	for {
+		if p.off >= len(p.src) {
+			p.errf(start, "heredoc not terminated; expected %s", id)
+		}
+		end := bytes.IndexByte(p.src[p.off:], '\n')
+		if end < 0 {
+			end = len(p.src) - p.off
+		}
+		line := strings.TrimSuffix(string(p.src[p.off:p.off+end]), "\r")
+		if strings.TrimSpace(line) == id {
+			p.off += strings.Index(string(p.src[p.off:]), id) + len(id)
+			break
+		}
+		lines = append(lines, line)
+		p.off += end + 1
+	}
+
+	if indented {
+		indent := -1
+		for _, l := range lines {
+			if strings.TrimSpace(l) == "" {
+				continue
+			}
+			n := len(l) - len(strings.TrimLeft(l, " \t"))
+			if indent < 0 || n < indent {
+				indent = n
+			}
+		}
+		for i, l := range lines {
+			if len(l) >= indent && indent > 0 {
+				lines[i] = l[indent:]
+			} else if strings.TrimSpace(l) == "" {
+				lines[i] = ""
+			}
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (p *parser) number() ast.Expr {
+	start := p.off
+	kind := token.INT
+	p.digits()
+	if p.peek() == '.' && p.off+1 < len(p.src) && isDigit(p.src[p.off+1]) {
+		kind = token.FLOAT
+		p.off++
+		p.digits()
+	}
+	if c := p.peek(); c == 'e' || c == 'E' {
+		kind = token.FLOAT
+		p.off++
+		if c := p.peek(); c == '+' || c == '-' {
+			p.off++
+		}
+		if !isDigit(p.peek()) {
+			p.errf(start, "invalid number")
+		}
+		p.digits()
+	}
+	s := string(p.src[start:p.off])
+	if kind == token.INT {
+		// CUE does not allow leading zeros in decimal integers.
+		if s = strings.TrimLeft(s, "0"); s == "" {
+			s = "0"
+		}
+	}
+	return ast.NewLit(kind, s)
+}
+
+// skipTo skips past the given closing bracket, which must match an opening
+// bracket that was already consumed, along with any nested brackets and
+// templates.
+func (p *parser) skipTo(close byte) {
+	start := p.off
+	stack := []byte{close}
+	for {
+		p.skip(true)
+		if p.off >= len(p.src) {
+			p.errf(start, "unexpected EOF, expected %q", stack[len(stack)-1])
+		}
+		switch c := p.peek(); c {
+		case '"':
+			p.template()
+			continue
+		case '(':
+			stack = append(stack, ')')
+		case '[':
+			stack = append(stack, ']')
+		case '{':
+			stack = append(stack, '}')
+		case ')', ']', '}':
+			if want := stack[len(stack)-1]; c != want {
+				p.errf(p.off, "unexpected %q, expected %q", c, want)
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				p.off++
+				return
+			}
+		}
+		p.off++
+	}
+}
+
+// skip skips white space and comments. Newlines are only skipped if nl is true.
+func (p *parser) skip(nl bool) {
+	for p.off < len(p.src) {
+		switch c := p.src[p.off]; {
+		case c == ' ', c == '\t', c == '\r':
+			p.off++
+		case c == '\n':
+			if !nl {
+				return
+			}
+			p.off++
+		case c == '#', p.hasPrefix("//"):
+			for p.off < len(p.src) && p.src[p.off] != '\n' {
+				p.off++
+			}
+		case p.hasPrefix("/*"):
+			end := bytes.Index(p.src[p.off+2:], []byte("*/"))
+			if end < 0 {
+				p.errf(p.off, "comment not terminated")
+			}
+			p.off += end + 4
+		default:
+			return
+		}
+	}
+}
+
+// space skips white space and comments within an expression.
+func (p *parser) space() {
+	p.skip(p.nest > 0)
+}
+
+func (p *parser) expect(c byte) {
+	if p.peek() != c || p.off >= len(p.src) {
+		p.errf(p.off, "expected %q, found %s", c, p.found())
+	}
+	p.off++
+}
+
+// keyword reports whether the input continues with the given keyword and
+// consumes it if so.
+func (p *parser) keyword(kw string) bool {
+	start := p.off
+	if p.ident() == kw {
+		return true
+	}
+	p.off = start
+	return false
+}
+
+// ident scans an identifier and returns it, or returns "" if there is none.
+func (p *parser) ident() string {
+	start := p.off
+	for p.off < len(p.src) {
+		r, n := utf8.DecodeRune(p.src[p.off:])
+		if !isIdentStart(r) && (p.off == start || !isIdentPart(r)) {
+			break
+		}
+		p.off += n
+	}
+	return string(p.src[start:p.off])
+}
+
+func (p *parser) digits() {
+	for isDigit(p.peek()) {
+		p.off++
+	}
+}
+
+func (p *parser) peek() byte {
+	if p.off < len(p.src) {
+		return p.src[p.off]
+	}
+	return 0
+}
+
+func (p *parser) peekRune() rune {
+	r, _ := utf8.DecodeRune(p.src[p.off:])
+	return r
+}
+
+func (p *parser) hasPrefix(s string) bool {
+	return bytes.HasPrefix(p.src[p.off:], []byte(s))
+}
+
+// found describes the input at the current position for use in errors.
+func (p *parser) found() string {
+	switch {
+	case p.off >= len(p.src):
+		return "EOF"
+	case p.peek() == '\n':
+		return "newline"
+	}
+	return strconv.QuoteRune(p.peekRune())
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return r == '-' || unicode.IsDigit(r) || isIdentStart(r)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,209 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package json
+
+import (
+	"bytes"
+	gojson "encoding/json"
+	"io"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
+)
+
+// A StreamDecoder decodes a stream of JSON values, such as newline-delimited
+// JSON (NDJSON), one value at a time. Only the current value is retained, so
+// that streams of arbitrary length can be processed with constant memory.
+//
+// Positions of values report the line and column in the whole stream.
+type StreamDecoder struct {
+	ctx   *cue.Context
+	path  string
+	lines *lineReader
+	dec   *gojson.Decoder
+
+	n   int
+	v   cue.Value
+	err error
+}
+
+// NewStreamDecoder returns a StreamDecoder that reads JSON values from r and
+// builds them with ctx. The path is used to associate position information
+// with each value.
+func NewStreamDecoder(ctx *cue.Context, path string, r io.Reader) *StreamDecoder {
+	lines := &lineReader{r: r, line: 1}
+	return &StreamDecoder{
+		ctx:   ctx,
+		path:  path,
+		lines: lines,
+		dec:   gojson.NewDecoder(lines),
+	}
+}
+
+// Next advances to the next value of the stream. It returns false when the
+// input is exhausted or if the next value could not be decoded, in which case
+// Err reports the error.
+func (d *StreamDecoder) Next() bool {
+	if d.err != nil {
+		return false
+	}
+	expr, err := d.extract()
+	if err == io.EOF {
+		d.v = cue.Value{}
+		return false
+	}
+	d.n++
+	if err != nil {
+		d.err = &RecordError{Record: d.n, Err: errors.Promote(err, "invalid JSON")}
+		d.v = cue.Value{}
+		return false
+	}
+	d.v = d.ctx.BuildExpr(expr)
+	return true
+}
+
+// extract parses the next value of the stream. The value is parsed in a
+// token.File that starts at the beginning of the line of the value and maps
+// to its line in the stream, so that its positions are those in the stream.
+func (d *StreamDecoder) extract() (ast.Expr, error) {
+	var raw gojson.RawMessage
+	err := d.dec.Decode(&raw)
+	if err == io.EOF {
+		return nil, err
+	}
+	end := int(d.dec.InputOffset())
+	start := end - len(raw)
+	line, col := d.lines.position(start)
+
+	// Offset 0 of the file is the start of the line.
+	base := start - (col - 1) + 1
+	if err != nil {
+		f := token.NewFile(d.path, base, col)
+		f.AddLineInfo(0, d.path, line)
+		return nil, errors.Wrapf(err, f.Pos(col-1, token.NoRelPos),
+			"invalid JSON for file %q", d.path)
+	}
+	src := append(bytes.Repeat([]byte(" "), col-1), raw...)
+	expr, err := parser.ParseExpr(d.path, src, parser.FileOffset(base))
+	if err != nil {
+		return nil, err
+	}
+	expr.Pos().File().AddLineInfo(0, d.path, line)
+	patchExpr(expr)
+	return expr, nil
+}
+
+// Value returns the current value.
+func (d *StreamDecoder) Value() cue.Value {
+	return d.v
+}
+
+// Record returns the position of the current value in the stream, starting
+// at 1.
+func (d *StreamDecoder) Record() int {
+	return d.n
+}
+
+// Err returns the error that stopped decoding, if any.
+func (d *StreamDecoder) Err() error {
+	return d.err
+}
+
+// Validate reports whether the current value is an instance of schema. The
+// value must be concrete. Each of the reported errors is a *RecordError
+// holding the number of the current value.
+func (d *StreamDecoder) Validate(schema cue.Value, opts ...cue.Option) error {
+	v := d.v.Unify(schema)
+	opts = append([]cue.Option{cue.Concrete(true)}, opts...)
+	err := v.Validate(opts...)
+	if err == nil {
+		return nil
+	}
+	var a errors.Error
+	for _, e := range errors.Errors(err) {
+		a = errors.Append(a, &RecordError{Record: d.n, Err: e})
+	}
+	return a
+}
+
+// A RecordError is an error for a single value of a stream.
+type RecordError struct {
+	// Record is the position of the value in the stream, starting at 1.
+	Record int
+
+	// Err is the error for the value.
+	Err errors.Error
+}
+
+var _ errors.Error = &RecordError{}
+
+// Error implements the error interface.
+func (e *RecordError) Error() string {
+	return errors.String(e)
+}
+
+// Msg reports the record number and the path of the error. The message of
+// the underlying error is available through Unwrap.
+func (e *RecordError) Msg() (format string, args []interface{}) {
+	if p := e.Err.Path(); len(p) > 0 {
+		return "record %d: %s", []interface{}{e.Record, strings.Join(p, ".")}
+	}
+	return "record %d", []interface{}{e.Record}
+}
+
+// Path reports nil, as the path is included in the message.
+func (e *RecordError) Path() []string { return nil }
+
+func (e *RecordError) Position() token.Pos         { return e.Err.Position() }
+func (e *RecordError) InputPositions() []token.Pos { return e.Err.InputPositions() }
+func (e *RecordError) Unwrap() error               { return e.Err }
+
+// A lineReader records the offsets of the newlines read from r, so that the
+// line and column of the values decoded from it can be computed.
+type lineReader struct {
+	r   io.Reader
+	off int   // offset of the next byte read from r
+	nls []int // offsets of the newlines not yet passed by position
+
+	line  int // line at the last position
+	start int // offset of the start of line
+}
+
+func (l *lineReader) Read(b []byte) (int, error) {
+	n, err := l.r.Read(b)
+	for i, c := range b[:n] {
+		if c == '\n' {
+			l.nls = append(l.nls, l.off+i)
+		}
+	}
+	l.off += n
+	return n, err
+}
+
+// position returns the line and column, starting at 1, of the byte at offset
+// off. Offsets must be passed in increasing order.
+func (l *lineReader) position(off int) (line, col int) {
+	i := 0
+	for ; i < len(l.nls) && l.nls[i] < off; i++ {
+		l.line++
+		l.start = l.nls[i] + 1
+	}
+	l.nls = l.nls[i:]
+	return l.line, off - l.start + 1
+}
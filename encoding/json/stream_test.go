@@ -0,0 +1,90 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package json
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+)
+
+func TestStreamDecoder(t *testing.T) {
+	ctx := cuecontext.New()
+	schema := ctx.CompileString(`{name: string, age: >=0}`)
+
+	const input = `{"name": "a", "age": 1}
+{"name": "b", "age": -1}
+{"name": 3, "age": 2}
+{"name": "c", "age": 4}
+{"name": "d",
+`
+	d := NewStreamDecoder(ctx, "stdin", strings.NewReader(input))
+	var got []string
+	n := 0
+	for d.Next() {
+		n++
+		if d.Record() != n {
+			t.Errorf("got record %d; want %d", d.Record(), n)
+		}
+		for _, e := range errors.Errors(d.Validate(schema)) {
+			got = append(got, e.Error())
+		}
+		if got, want := d.Value().Pos().String(), fmt.Sprintf("stdin:%d:1", n); got != want {
+			t.Errorf("got position %s; want %s", got, want)
+		}
+	}
+	if n != 4 {
+		t.Errorf("decoded %d records; want 4", n)
+	}
+	want := []string{
+		"record 2: age: invalid value -1 (out of bound >=0)",
+		"record 3: name: conflicting values 3 and string (mismatched types int and string)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	err := d.Err()
+	if err == nil || !strings.HasPrefix(err.Error(), "record 5: ") {
+		t.Errorf("got error %v; want error for record 5", err)
+	}
+}
+
+func TestStreamDecoderPositions(t *testing.T) {
+	ctx := cuecontext.New()
+	const input = "{\"a\": 1} {\"a\": 2}\n\n  [\n    3\n  ]\n"
+	d := NewStreamDecoder(ctx, "stdin", strings.NewReader(input))
+	var got []string
+	for d.Next() {
+		v := d.Value()
+		if l, err := v.List(); err == nil && l.Next() {
+			v = l.Value()
+		} else {
+			v = v.LookupPath(cue.ParsePath("a"))
+		}
+		got = append(got, v.Pos().String())
+	}
+	if err := d.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := "stdin:1:2 stdin:1:11 stdin:4:5"
+	if strings.Join(got, " ") != want {
+		t.Errorf("got positions %s; want %s", strings.Join(got, " "), want)
+	}
+}
@@ -43,6 +43,37 @@ type decoder struct {
 	cfg   *Config
 	errs  errors.Error
 	numID int // for creating unique numbers: increment on each use
+
+	// external holds the URLs of the JSON Schema referred to by external
+	// references, in order of appearance.
+	external []externalRef
+}
+
+type externalRef struct {
+	url *url.URL // without fragment
+	pos token.Pos
+}
+
+// addExternal records the JSON Schema referred to by u.
+func (d *decoder) addExternal(n cue.Value, u *url.URL) {
+	x := *u
+	x.Fragment = ""
+	for _, e := range d.external {
+		if e.url.String() == x.String() {
+			return
+		}
+	}
+	d.external = append(d.external, externalRef{&x, n.Pos()})
+}
+
+// baseURI returns the URI against which references of the top-level schema
+// are resolved, if Config.ID is an absolute URI.
+func (d *decoder) baseURI() *url.URL {
+	u, err := url.Parse(d.cfg.ID)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return nil
+	}
+	return u
 }
 
 // addImport registers
@@ -100,7 +131,7 @@ func (d *decoder) decode(v cue.Value) *ast.File {
 }
 
 func (d *decoder) schema(ref []ast.Label, v cue.Value) (a []ast.Decl) {
-	root := state{decoder: d}
+	root := state{decoder: d, id: d.baseURI()}
 
 	var name ast.Label
 	inner := len(ref) - 1
@@ -16,7 +16,9 @@ package jsonschema
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -150,6 +152,107 @@ func TestDecode(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+type mapResolver map[string]cue.Value
+
+func (m mapResolver) Resolve(u *url.URL) (cue.Value, error) {
+	v, ok := m[u.String()]
+	if !ok {
+		return cue.Value{}, fmt.Errorf("not found")
+	}
+	return v, nil
+}
+
+func TestExtractFiles(t *testing.T) {
+	a := txtar.Parse([]byte(`
+-- https://example.com/schemas/person.json --
+{
+	"$id": "https://example.com/schemas/person.json",
+	"type": "object",
+	"properties": {
+		"name": { "type": "string" },
+		"address": { "$ref": "address.json" },
+		"phone": { "$ref": "common.json#/$defs/phone" }
+	}
+}
+-- https://example.com/schemas/address.json --
+{
+	"type": "object",
+	"properties": {
+		"city": { "type": "string" },
+		"phone": { "$ref": "common.json#/$defs/phone" }
+	}
+}
+-- https://example.com/schemas/common.json --
+{
+	"$defs": {
+		"phone": { "type": "string", "pattern": "^[0-9]+$" }
+	}
+}
+`))
+
+	r := &cue.Runtime{}
+	m := mapResolver{}
+	for _, f := range a.Files {
+		inst, err := json.Decode(r, f.Name, f.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m[f.Name] = inst.Value()
+	}
+
+	root := m["https://example.com/schemas/person.json"]
+	files, err := ExtractFiles(root, &Config{Resolver: m})
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+
+	var got []string
+	for _, f := range files {
+		b, err := format.Node(f.File, format.Simplify())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("-- %s (%s) --\n%s", f.URL, f.ImportPath, b))
+	}
+
+	want := `-- https://example.com/schemas/person.json (example.com/schemas/person.json:person) --
+import (
+	"example.com/schemas/address.json:address"
+	"example.com/schemas/common.json:common"
+)
+
+@jsonschema(id="https://example.com/schemas/person.json")
+name?:      string
+"address"?: address
+phone?:     common.#phone
+...
+-- https://example.com/schemas/address.json (example.com/schemas/address.json:address) --
+package address
+
+import "example.com/schemas/common.json:common"
+
+city?:  string
+phone?: common.#phone
+...
+-- https://example.com/schemas/common.json (example.com/schemas/common.json:common) --
+package common
+
+_
+
+#phone: =~"^[0-9]+$"
+`
+	if diff := cmp.Diff(strings.Join(got, ""), want); diff != "" {
+		t.Error(diff)
+	}
+
+	m = mapResolver{}
+	_, err = ExtractFiles(root, &Config{Resolver: m})
+	const wantErr = "could not resolve https://example.com/schemas/address.json: not found"
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Errorf("got error %v; want %q", err, wantErr)
+	}
+}
+
 func TestX(t *testing.T) {
 	t.Skip()
 	data := `
@@ -0,0 +1,454 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonschema
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/encoding/json"
+	"cuelang.org/go/internal/value"
+)
+
+// draft is the JSON Schema version used by Generate.
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Generate converts v to a JSON Schema, using the 2020-12 draft.
+//
+// The regular fields of v define the top-level schema. Definitions of v, and
+// definitions nested within those, are included as $defs. References to these
+// definitions are converted to $ref. Other references are expanded.
+//
+// Bounds, regular expressions, defaults, and the builtins of the strings,
+// list, struct, and math packages that have a JSON Schema equivalent are
+// mapped to the corresponding keywords. Closed structs disallow additional
+// properties. Constraints that cannot be expressed in JSON Schema are
+// ignored, so the resulting schema may accept more values than v.
+func Generate(v cue.Value) ([]byte, error) {
+	g := &generator{root: v}
+	_, g.rootNode = value.ToInternal(v)
+
+	s := g.schema(v, true)
+	s.Elts = append([]ast.Decl{
+		field("$schema", ast.NewString(draft)),
+	}, s.Elts...)
+
+	if g.errs != nil {
+		return nil, g.errs
+	}
+	return json.Encode(s)
+}
+
+type generator struct {
+	root     cue.Value
+	rootNode *adt.Vertex
+	errs     errors.Error
+
+	// expanding holds the references that are currently being expanded, to
+	// detect cycles.
+	expanding []*adt.Vertex
+}
+
+func (g *generator) addErrf(v cue.Value, format string, args ...interface{}) {
+	g.errs = errors.Append(g.errs, errors.Newf(v.Pos(), format, args...))
+}
+
+// schema converts v to a schema. If defs is true, the definitions of v are
+// included as $defs.
+func (g *generator) schema(v cue.Value, defs bool) *ast.StructLit {
+	b := &builder{g: g}
+
+	// The documentation of the root is that of its first declaration.
+	if _, n := value.ToInternal(v); n != g.rootNode {
+		if doc := docString(v); doc != "" {
+			b.set("description", ast.NewString(doc))
+		}
+	}
+
+	if ref := g.ref(v); ref != "" {
+		b.set("$ref", ast.NewString(ref))
+		return b.finish()
+	}
+
+	k := v.IncompleteKind()
+	switch types := typeNames(k); {
+	case k == cue.TopKind, len(types) == 0:
+	case len(types) == 1:
+		b.set("type", ast.NewString(types[0]))
+	default:
+		list := ast.NewList()
+		for _, t := range types {
+			list.Elts = append(list.Elts, ast.NewString(t))
+		}
+		b.set("type", list)
+	}
+
+	b.conjunct(v)
+
+	if d, ok := v.Default(); ok && !isEmptyList(d) &&
+		d.Validate(cue.Concrete(true)) == nil {
+		b.set("default", decodeValue(d))
+	}
+
+	if defs {
+		b.definitions(v)
+	}
+
+	return b.finish()
+}
+
+// ref returns the JSON reference for v if v refers to a definition that is
+// included in $defs.
+func (g *generator) ref(v cue.Value) string {
+	root, p := v.ReferencePath()
+	if !root.Exists() {
+		return ""
+	}
+	if _, n := value.ToInternal(root); n != g.rootNode {
+		return ""
+	}
+	sels := p.Selectors()
+	if len(sels) == 0 {
+		return ""
+	}
+	ref := "#"
+	for _, sel := range sels {
+		if !sel.IsDefinition() {
+			return ""
+		}
+		ref += "/$defs/" + escapePointer(strings.TrimPrefix(sel.String(), "#"))
+	}
+	return ref
+}
+
+// expand calls f with the value referred to by v, reporting an error if
+// this value is already being expanded.
+func (g *generator) expand(v cue.Value, f func(v cue.Value)) {
+	v = cue.Dereference(v)
+	_, n := value.ToInternal(v)
+	for _, x := range g.expanding {
+		if x == n {
+			g.addErrf(v, "cycle in reference at %v", v.Path())
+			return
+		}
+	}
+	g.expanding = append(g.expanding, n)
+	f(v)
+	g.expanding = g.expanding[:len(g.expanding)-1]
+}
+
+// A builder collects the keywords of a schema. Keywords that are set more
+// than once are added to an allOf.
+type builder struct {
+	g     *generator
+	elts  []ast.Decl
+	allOf []ast.Expr
+}
+
+func (b *builder) set(key string, x ast.Expr) {
+	for _, d := range b.elts {
+		if keyword(d) == key {
+			b.allOf = append(b.allOf, ast.NewStruct(field(key, x)))
+			return
+		}
+	}
+	b.elts = append(b.elts, field(key, x))
+}
+
+func (b *builder) finish() *ast.StructLit {
+	if len(b.allOf) > 0 {
+		b.set("allOf", ast.NewList(b.allOf...))
+	}
+	sort.SliceStable(b.elts, func(i, j int) bool {
+		return keywordOrder[keyword(b.elts[i])] > keywordOrder[keyword(b.elts[j])]
+	})
+	return &ast.StructLit{Elts: b.elts}
+}
+
+// keywordOrder defines the order of keywords in the output. Keywords that
+// are not listed are written after the listed ones.
+var keywordOrder = map[string]int{
+	"$schema":     20,
+	"$ref":        19,
+	"description": 18,
+	"type":        17,
+	"const":       16,
+	"enum":        15,
+	"required":    14,
+	"properties":  13,
+}
+
+func field(key string, x ast.Expr) *ast.Field {
+	return &ast.Field{Label: ast.NewString(key), Value: x}
+}
+
+func keyword(d ast.Decl) string {
+	name, _, _ := ast.LabelName(d.(*ast.Field).Label)
+	return name
+}
+
+// conjunct adds the constraints of v.
+func (b *builder) conjunct(v cue.Value) {
+	if ref := b.g.ref(v); ref != "" {
+		b.set("$ref", ast.NewString(ref))
+		return
+	}
+	if root, _ := v.ReferencePath(); root.Exists() {
+		b.g.expand(v, b.conjunct)
+		return
+	}
+
+	switch op, a := v.Expr(); op {
+	case cue.AndOp:
+		for _, x := range a {
+			b.conjunct(x)
+		}
+
+	case cue.OrOp:
+		b.disjunction(a)
+
+	case cue.LessThanOp, cue.LessThanEqualOp,
+		cue.GreaterThanOp, cue.GreaterThanEqualOp:
+		b.bound(op, a[0])
+
+	case cue.NotEqualOp:
+		b.set("not", ast.NewStruct(field("const", decodeValue(a[0]))))
+
+	case cue.RegexMatchOp, cue.NotRegexMatchOp:
+		s, err := a[0].String()
+		if err != nil {
+			// Patterns on bytes and non-concrete patterns are not supported.
+			return
+		}
+		if op == cue.RegexMatchOp {
+			b.set("pattern", ast.NewString(s))
+		} else {
+			b.set("not", ast.NewStruct(field("pattern", ast.NewString(s))))
+		}
+
+	case cue.CallOp:
+		b.builtin(a)
+
+	case cue.NoOp:
+		// A value with a default is represented by its value without the
+		// default.
+		if len(a) == 1 {
+			root, _ := a[0].ReferencePath()
+			if op, _ := a[0].Expr(); root.Exists() || op != cue.NoOp {
+				b.conjunct(a[0])
+				return
+			}
+			v = a[0]
+		}
+		b.basic(v)
+	}
+}
+
+func (b *builder) bound(op cue.Op, x cue.Value) {
+	if x.Kind()&cue.NumberKind == 0 {
+		return
+	}
+	n := decodeValue(x)
+	switch op {
+	case cue.LessThanOp:
+		b.set("exclusiveMaximum", n)
+	case cue.LessThanEqualOp:
+		b.set("maximum", n)
+	case cue.GreaterThanOp:
+		b.set("exclusiveMinimum", n)
+	case cue.GreaterThanEqualOp:
+		b.set("minimum", n)
+	}
+}
+
+// builtins maps the builtins with a single argument to the corresponding
+// keyword.
+var builtins = map[string]string{
+	"strings.MinRunes": "minLength",
+	"strings.MaxRunes": "maxLength",
+	"list.MinItems":    "minItems",
+	"list.MaxItems":    "maxItems",
+	"struct.MinFields": "minProperties",
+	"struct.MaxFields": "maxProperties",
+	"math.MultipleOf":  "multipleOf",
+}
+
+func (b *builder) builtin(a []cue.Value) {
+	switch name := fmt.Sprint(a[0]); name {
+	case "list.UniqueItems", "list.UniqueItems()":
+		b.set("uniqueItems", ast.NewBool(true))
+
+	case "time.Time", "time.Time()":
+		b.set("format", ast.NewString("date-time"))
+
+	default:
+		if key, ok := builtins[name]; ok && len(a) == 2 {
+			b.set(key, decodeValue(a[1]))
+		}
+	}
+}
+
+func (b *builder) disjunction(a []cue.Value) {
+	var enum, anyOf []ast.Expr
+	for _, x := range a {
+		if x.IsConcrete() && x.Kind() != cue.StructKind && x.Kind() != cue.ListKind {
+			enum = append(enum, decodeValue(x))
+			continue
+		}
+		anyOf = append(anyOf, b.g.schema(x, false))
+	}
+	switch {
+	case len(anyOf) == 0 && len(enum) == 1:
+		b.set("const", enum[0])
+	case len(anyOf) == 0:
+		b.set("enum", ast.NewList(enum...))
+	default:
+		if len(enum) > 0 {
+			anyOf = append(anyOf, ast.NewStruct(field("enum", ast.NewList(enum...))))
+		}
+		b.set("anyOf", ast.NewList(anyOf...))
+	}
+}
+
+func (b *builder) basic(v cue.Value) {
+	switch v.IncompleteKind() {
+	case cue.StructKind:
+		b.object(v)
+	case cue.ListKind:
+		b.array(v)
+	default:
+		if v.IsConcrete() {
+			b.set("const", decodeValue(v))
+		}
+	}
+}
+
+func (b *builder) object(v cue.Value) {
+	var required []ast.Expr
+	var props []ast.Decl
+	for i, _ := v.Fields(cue.Optional(true)); i.Next(); {
+		props = append(props, field(i.Label(), b.g.schema(i.Value(), false)))
+		if !i.IsOptional() {
+			required = append(required, ast.NewString(i.Label()))
+		}
+	}
+	if len(required) > 0 {
+		b.set("required", ast.NewList(required...))
+	}
+	if len(props) > 0 {
+		b.set("properties", &ast.StructLit{Elts: props})
+	}
+
+	if x := v.LookupPath(cue.MakePath(cue.AnyString)); x.Exists() {
+		if s := b.g.schema(x, false); len(s.Elts) > 0 {
+			b.set("additionalProperties", s)
+		}
+	} else if !v.Allows(cue.AnyString) {
+		b.set("additionalProperties", ast.NewBool(false))
+	}
+}
+
+func (b *builder) array(v cue.Value) {
+	var items []ast.Expr
+	for i, _ := v.List(); i.Next(); {
+		items = append(items, b.g.schema(i.Value(), false))
+	}
+	if len(items) > 0 {
+		b.set("prefixItems", ast.NewList(items...))
+		b.set("minItems", ast.NewLit(token.INT, strconv.Itoa(len(items))))
+	}
+
+	if x := v.LookupPath(cue.MakePath(cue.AnyIndex)); x.Exists() {
+		b.set("items", b.g.schema(x, false))
+	} else if len(items) > 0 {
+		b.set("items", ast.NewBool(false))
+	} else {
+		b.set("maxItems", ast.NewLit(token.INT, "0"))
+	}
+}
+
+// definitions adds the definitions of v as $defs.
+func (b *builder) definitions(v cue.Value) {
+	var defs []ast.Decl
+	for i, _ := v.Fields(cue.Definitions(true)); i.Next(); {
+		if !i.IsDefinition() {
+			continue
+		}
+		name := strings.TrimPrefix(i.Label(), "#")
+		defs = append(defs, field(name, b.g.schema(i.Value(), true)))
+	}
+	if len(defs) > 0 {
+		b.set("$defs", &ast.StructLit{Elts: defs})
+	}
+}
+
+// typeNames returns the JSON Schema types corresponding to k.
+func typeNames(k cue.Kind) (types []string) {
+	if k&cue.FloatKind != 0 {
+		k &^= cue.IntKind // number includes integers
+	}
+	for _, t := range allTypeNames {
+		if k&t.kind != 0 {
+			types = append(types, t.name)
+		}
+	}
+	return types
+}
+
+var allTypeNames = []struct {
+	kind cue.Kind
+	name string
+}{
+	{cue.NullKind, "null"},
+	{cue.BoolKind, "boolean"},
+	{cue.IntKind, "integer"},
+	{cue.FloatKind, "number"},
+	{cue.StringKind | cue.BytesKind, "string"},
+	{cue.ListKind, "array"},
+	{cue.StructKind, "object"},
+}
+
+func docString(v cue.Value) string {
+	var doc []string
+	for _, d := range v.Doc() {
+		doc = append(doc, d.Text())
+	}
+	return strings.TrimSpace(strings.Join(doc, "\n\n"))
+}
+
+// isEmptyList reports whether v is the empty list, which is the default of
+// open lists.
+func isEmptyList(v cue.Value) bool {
+	n, err := v.Len().Int64()
+	return v.Kind() == cue.ListKind && err == nil && n == 0
+}
+
+func decodeValue(v cue.Value) ast.Expr {
+	v, _ = v.Default()
+	return v.Syntax(cue.Final()).(ast.Expr)
+}
+
+// escapePointer escapes a reference token of a JSON pointer.
+func escapePointer(s string) string {
+	s = strings.Replace(s, "~", "~0", -1)
+	return strings.Replace(s, "/", "~1", -1)
+}
@@ -0,0 +1,384 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonschema
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+)
+
+func TestGenerate(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		out  string
+		err  string
+	}{{
+		name: "bounds",
+		in: `
+		a: int & >=0 & <10
+		b?: number & >1 & <=2
+		c: !=3
+		`,
+		out: `
+		{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"required": [
+				"a",
+				"c"
+			],
+			"properties": {
+				"a": {
+					"type": "integer",
+					"minimum": 0,
+					"exclusiveMaximum": 10
+				},
+				"b": {
+					"type": "number",
+					"exclusiveMinimum": 1,
+					"maximum": 2
+				},
+				"c": {
+					"type": "number",
+					"not": {
+						"const": 3
+					}
+				}
+			}
+		}
+		`,
+	}, {
+		name: "strings",
+		in: `
+		import "strings"
+
+		a: strings.MinRunes(1) & strings.MaxRunes(5) & =~"^a" & !~"b$"
+		`,
+		out: `
+		{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"required": [
+				"a"
+			],
+			"properties": {
+				"a": {
+					"type": "string",
+					"minLength": 1,
+					"maxLength": 5,
+					"pattern": "^a",
+					"not": {
+						"pattern": "b$"
+					}
+				}
+			}
+		}
+		`,
+	}, {
+		name: "defaults",
+		in: `
+		kind: *"a" | "b"
+		one:  "x"
+		n:    int | *1
+		opt:  null | string | int
+		port: #Port | *8080
+
+		#Port: int & >0
+		`,
+		out: `
+		{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"required": [
+				"kind",
+				"one",
+				"n",
+				"opt",
+				"port"
+			],
+			"properties": {
+				"kind": {
+					"type": "string",
+					"enum": [
+						"a",
+						"b"
+					],
+					"default": "a"
+				},
+				"one": {
+					"type": "string",
+					"const": "x"
+				},
+				"n": {
+					"type": "integer",
+					"default": 1
+				},
+				"opt": {
+					"type": [
+						"null",
+						"integer",
+						"string"
+					],
+					"anyOf": [
+						{
+							"type": "string"
+						},
+						{
+							"type": "integer"
+						},
+						{
+							"enum": [
+								null
+							]
+						}
+					]
+				},
+				"port": {
+					"$ref": "#/$defs/Port",
+					"type": "integer",
+					"default": 8080
+				}
+			},
+			"$defs": {
+				"Port": {
+					"type": "integer",
+					"exclusiveMinimum": 0
+				}
+			}
+		}
+		`,
+	}, {
+		name: "closedness",
+		in: `
+		#Closed: {a: int}
+		#Open: {a: int, ...}
+		#Map: [string]: int
+		`,
+		out: `
+		{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"$defs": {
+				"Closed": {
+					"type": "object",
+					"required": [
+						"a"
+					],
+					"properties": {
+						"a": {
+							"type": "integer"
+						}
+					},
+					"additionalProperties": false
+				},
+				"Open": {
+					"type": "object",
+					"required": [
+						"a"
+					],
+					"properties": {
+						"a": {
+							"type": "integer"
+						}
+					}
+				},
+				"Map": {
+					"type": "object",
+					"additionalProperties": {
+						"type": "integer"
+					}
+				}
+			}
+		}
+		`,
+	}, {
+		name: "definitions",
+		in: `
+		// A person.
+		#Person: {
+			name: string
+			address?: #Address
+			friends?: [...#Person]
+
+			#Address: {
+				street: string
+				"zip code"?: string
+			}
+		}
+		me: #Person
+		you: me
+		`,
+		out: `
+		{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"required": [
+				"me",
+				"you"
+			],
+			"properties": {
+				"me": {
+					"$ref": "#/$defs/Person"
+				},
+				"you": {
+					"$ref": "#/$defs/Person",
+					"type": "object"
+				}
+			},
+			"$defs": {
+				"Person": {
+					"description": "A person.",
+					"type": "object",
+					"required": [
+						"name"
+					],
+					"properties": {
+						"name": {
+							"type": "string"
+						},
+						"address": {
+							"$ref": "#/$defs/Person/$defs/Address"
+						},
+						"friends": {
+							"type": "array",
+							"items": {
+								"$ref": "#/$defs/Person"
+							}
+						}
+					},
+					"additionalProperties": false,
+					"$defs": {
+						"Address": {
+							"type": "object",
+							"required": [
+								"street"
+							],
+							"properties": {
+								"street": {
+									"type": "string"
+								},
+								"zip code": {
+									"type": "string"
+								}
+							},
+							"additionalProperties": false
+						}
+					}
+				}
+			}
+		}
+		`,
+	}, {
+		name: "lists",
+		in: `
+		import "list"
+
+		a: [...string] & list.MaxItems(3) & list.UniqueItems()
+		b: [int, string]
+		c: [int, ...string]
+		`,
+		out: `
+		{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"required": [
+				"a",
+				"b",
+				"c"
+			],
+			"properties": {
+				"a": {
+					"type": "array",
+					"items": {
+						"type": "string"
+					},
+					"maxItems": 3,
+					"uniqueItems": true
+				},
+				"b": {
+					"type": "array",
+					"prefixItems": [
+						{
+							"type": "integer"
+						},
+						{
+							"type": "string"
+						}
+					],
+					"minItems": 2,
+					"items": false
+				},
+				"c": {
+					"type": "array",
+					"prefixItems": [
+						{
+							"type": "integer"
+						}
+					],
+					"minItems": 1,
+					"items": {
+						"type": "string"
+					}
+				}
+			}
+		}
+		`,
+	}, {
+		name: "cycle",
+		in: `
+		a: {b?: a}
+		`,
+		err: "cycle",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var r cue.Runtime
+			inst, err := r.Compile(tc.name+".cue", tc.in)
+			if err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+
+			b, err := Generate(inst.Value())
+			if err != nil {
+				got := errors.Details(err, nil)
+				if tc.err == "" || !strings.Contains(got, tc.err) {
+					t.Fatalf("unexpected error: %v", got)
+				}
+				return
+			}
+			if tc.err != "" {
+				t.Fatalf("unexpected success; want error %q", tc.err)
+			}
+
+			var out bytes.Buffer
+			if err := stdjson.Indent(&out, b, "\t\t", "\t"); err != nil {
+				t.Fatal(err)
+			}
+			got := strings.TrimSpace(out.String())
+			want := strings.TrimSpace(tc.out)
+			if diff := cmp.Diff(got, want); diff != "" {
+				t.Errorf("%s\n(-got +want)\n%s", got, diff)
+			}
+		})
+	}
+}
@@ -31,8 +31,11 @@
 package jsonschema
 
 import (
+	"net/url"
+
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/token"
 )
 
@@ -50,6 +53,100 @@ func Extract(data cue.InstanceOrValue, cfg *Config) (f *ast.File, err error) {
 	return f, nil
 }
 
+// A File is a CUE file converted from JSON Schema.
+type File struct {
+	// URL is the location of the JSON Schema from which the file was
+	// converted. It is empty if it is not known.
+	URL string
+
+	// ImportPath is the CUE import path by which other files refer to the
+	// package of this file. It is empty if the URL is not known.
+	ImportPath string
+
+	File *ast.File
+}
+
+// ExtractFiles is like Extract, but also converts the JSON Schema referred to
+// by external references, as retrieved by Config.Resolver.
+//
+// External references are resolved relative to the $id of the schema in
+// which they occur or, at the top level, to the ID of the configuration, if
+// it is an absolute URI. Each referred schema is converted into a file with
+// its own package, which is imported by the files referring to it. The first
+// file is the conversion of data. If no Resolver is configured, this is the
+// only file returned.
+func ExtractFiles(data cue.InstanceOrValue, cfg *Config) (files []*File, err error) {
+	v := data.Value()
+	d := &decoder{cfg: cfg}
+
+	root := &File{URL: cfg.ID, File: d.decode(v)}
+	if root.URL == "" {
+		root.URL, _ = v.LookupPath(cue.MakePath(cue.Str("$id"))).String()
+	}
+	if u, err := url.Parse(root.URL); err == nil && u.IsAbs() && u.Host != "" {
+		root.ImportPath, _ = importPath(u)
+	}
+	files = append(files, root)
+
+	var errs errors.Error
+	errs = errors.Append(errs, d.errs)
+
+	if cfg.Resolver == nil {
+		if errs != nil {
+			return nil, errs
+		}
+		return files, nil
+	}
+
+	done := map[string]bool{}
+	if root.ImportPath != "" {
+		done[root.ImportPath] = true
+	}
+
+	for queue := []*decoder{d}; len(queue) > 0; queue = queue[1:] {
+		for _, x := range queue[0].external {
+			path, pkg := importPath(x.url)
+			if done[path] {
+				continue
+			}
+			done[path] = true
+
+			v, err := cfg.Resolver.Resolve(x.url)
+			if err != nil {
+				errs = errors.Append(errs, errors.Newf(x.pos,
+					"could not resolve %s: %v", x.url, err))
+				continue
+			}
+
+			c := *cfg
+			c.ID = x.url.String()
+			c.PkgName = pkg
+			c.Root = ""
+
+			d := &decoder{cfg: &c}
+			f := d.decode(v)
+			errs = errors.Append(errs, d.errs)
+
+			files = append(files, &File{URL: c.ID, ImportPath: path, File: f})
+			queue = append(queue, d)
+		}
+	}
+
+	if errs != nil {
+		return nil, errs
+	}
+	return files, nil
+}
+
+// A Resolver retrieves JSON Schema referred to by external references.
+//
+// A Resolver may, for instance, retrieve schema over the network or map
+// URLs to files on disk.
+type Resolver interface {
+	// Resolve returns the JSON Schema located at u, which has no fragment.
+	Resolve(u *url.URL) (cue.Value, error)
+}
+
 // A Config configures a JSON Schema encoding or decoding.
 type Config struct {
 	PkgName string
@@ -75,6 +172,10 @@ type Config struct {
 	//    {"$defs", foo}         {#foo} or {#, foo}
 	Map func(pos token.Pos, path []string) ([]ast.Label, error)
 
+	// Resolver retrieves the JSON Schema referred to by external references
+	// for ExtractFiles.
+	Resolver Resolver
+
 	// TODO: configurability to make it compatible with OpenAPI, such as
 	// - locations of definitions: #/components/schemas, for instance.
 	// - selection and definition of formats
@@ -167,23 +167,11 @@ func (s *state) makeCUERef(n cue.Value, u *url.URL) ast.Expr {
 				// referenced. We could consider doing an extra pass to record
 				// all '$id's in a file to be able to link to them even if they
 				// are not in scope.
-				p := u.Path
+				importPath, pkg := importPath(u)
+				s.addExternal(n, u)
 
-				base := path.Base(p)
-				if !ast.IsValidIdent(base) {
-					if strings.HasSuffix(base, ".json") {
-						base = base[:len(base)-len(".json")]
-					}
-					if !ast.IsValidIdent(base) {
-						// Find something more clever to do there. For now just
-						// pick "schema" as the package name.
-						base = "schema"
-					}
-					p += ":" + base
-				}
-
-				ident = ast.NewIdent(base)
-				ident.Node = &ast.ImportSpec{Path: ast.NewString(u.Host + p)}
+				ident = ast.NewIdent(pkg)
+				ident.Node = &ast.ImportSpec{Path: ast.NewString(importPath)}
 
 			default:
 				// Just a path, not sure what that means.
@@ -243,6 +231,26 @@ func (s *state) makeCUERef(n cue.Value, u *url.URL) ast.Expr {
 	return s.newSel(ident, n, a)
 }
 
+// importPath returns the CUE import path and package name of the package
+// corresponding to the JSON Schema located at u.
+func importPath(u *url.URL) (importPath, pkg string) {
+	p := u.Path
+
+	base := path.Base(p)
+	if !ast.IsValidIdent(base) {
+		if strings.HasSuffix(base, ".json") {
+			base = base[:len(base)-len(".json")]
+		}
+		if !ast.IsValidIdent(base) {
+			// Find something more clever to do there. For now just
+			// pick "schema" as the package name.
+			base = "schema"
+		}
+		p += ":" + base
+	}
+	return u.Host + p, base
+}
+
 // getNextSelector translates a JSON Reference path into a CUE path by consuming
 // the first path elements and returning the corresponding CUE label.
 func (s *state) getNextSelector(v cue.Value, a []string) (l label, tail []string) {
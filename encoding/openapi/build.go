@@ -41,6 +41,7 @@ type buildContext struct {
 	expandRefs    bool
 	structural    bool
 	exclusiveBool bool
+	jsonSchema    bool // use the JSON Schema 2020-12 dialect of OpenAPI 3.1
 	nameFunc      func(inst *cue.Instance, path []string) string
 	descFunc      func(v cue.Value) string
 	fieldFilter   *regexp.Regexp
@@ -110,6 +111,10 @@ func schemas(g *Generator, inst *cue.Instance) (schemas *ast.StructLit, err erro
 	case "3.0.0":
 		c.exclusiveBool = true
 	case "3.1.0":
+		// Structural schema are a Kubernetes concept based on OpenAPI 3.0.
+		// Expanded references in 3.1 are represented as plain JSON Schema.
+		c.structural = false
+		c.jsonSchema = true
 	default:
 		return nil, errors.Newf(token.NoPos, "unsupported version %s", g.Version)
 	}
@@ -136,7 +141,7 @@ func schemas(g *Generator, inst *cue.Instance) (schemas *ast.StructLit, err erro
 		}
 		// message, enum, or constant.
 		label := i.Label()
-		if c.isInternal(label) {
+		if c.isInternal(label) || isService(i.Value()) {
 			continue
 		}
 		if i.IsDefinition() && strings.HasPrefix(label, "#") {
@@ -305,6 +310,7 @@ var fieldOrder = map[string]int{
 	"description":      31,
 	"type":             30,
 	"format":           29,
+	"contentEncoding":  29,
 	"required":         28,
 	"properties":       27,
 	"minProperties":    26,
@@ -319,6 +325,7 @@ var fieldOrder = map[string]int{
 	"maxLength":        15,
 	"items":            14,
 	"enum":             13,
+	"const":            13,
 	"default":          12,
 }
 
@@ -366,7 +373,11 @@ func (b *builder) value(v cue.Value, f typeFunc) (isRef bool) {
 	if count > 0 { // TODO: implement IsAny.
 		// TODO: perhaps find optimal representation. For now we assume the
 		// representation as is is already optimized for human consumption.
-		if values.IncompleteKind()&cue.StructKind != cue.StructKind && !isRef {
+		// Outside of structural mode, expanded references need to be
+		// evaluated to step into them.
+		expand := b.ctx.expandRefs && !b.ctx.structural
+		isStruct := values.IncompleteKind()&cue.StructKind == cue.StructKind
+		if (!isStruct || expand) && !isRef {
 			values = values.Eval()
 		}
 
@@ -376,7 +387,7 @@ func (b *builder) value(v cue.Value, f typeFunc) (isRef bool) {
 			case isConcrete(v):
 				b.dispatch(f, v)
 				if !b.isNonCore() {
-					b.set("enum", ast.NewList(b.decode(v)))
+					b.setEnum(b.decode(v))
 				}
 			default:
 				a := appendSplit(nil, cue.OrOp, v)
@@ -547,20 +558,23 @@ func (b *builder) disjunction(a []cue.Value, f typeFunc) {
 			b.value(disjuncts[0], f)
 		}
 		if len(enums) > 0 && !b.isNonCore() {
-			b.set("enum", ast.NewList(enums...))
+			if nullable && b.ctx.jsonSchema {
+				enums = append(enums, ast.NewNull())
+			}
+			b.setEnum(enums...)
 		}
 		if nullable {
-			b.setSingle("nullable", ast.NewBool(true), true) // allowed in Structural
+			b.setNullable() // allowed in Structural
 		}
 		return
 	}
 
 	anyOf := []ast.Expr{}
 	if len(enums) > 0 {
-		anyOf = append(anyOf, b.kv("enum", ast.NewList(enums...)))
+		anyOf = append(anyOf, b.enum(enums...))
 	}
 
-	if nullable {
+	if nullable && !b.ctx.jsonSchema {
 		b.setSingle("nullable", ast.NewBool(true), true)
 	}
 
@@ -611,6 +625,17 @@ func (b *builder) disjunction(a []cue.Value, f typeFunc) {
 		anyOf = append(anyOf, t)
 	}
 
+	if nullable && b.ctx.jsonSchema {
+		// The disjuncts need not restrict the type, so null may match more
+		// than one of them.
+		b.nullable = b.typ != ""
+		b.set("anyOf", ast.NewList(
+			b.kv("type", ast.NewString("null")),
+			b.kv("oneOf", ast.NewList(anyOf...)),
+		))
+		return
+	}
+
 	b.set("oneOf", ast.NewList(anyOf...))
 }
 
@@ -646,9 +671,12 @@ func (b *builder) dispatch(f typeFunc, v cue.Value) {
 
 	switch v.IncompleteKind() {
 	case cue.NullKind:
-		// TODO: for JSON schema we would set the type here. For OpenAPI,
-		// it must be nullable.
-		b.setSingle("nullable", ast.NewBool(true), true)
+		// For OpenAPI 3.0, it must be nullable. JSON schema has a null type.
+		if b.ctx.jsonSchema {
+			b.setType("null", "")
+		} else {
+			b.setNullable()
+		}
 
 	case cue.BoolKind:
 		b.setType("boolean", "")
@@ -673,7 +701,14 @@ func (b *builder) dispatch(f typeFunc, v cue.Value) {
 	case cue.BytesKind:
 		// byte		string	byte	base64 	encoded characters
 		// binary	string	binary	any 	sequence of octets
-		b.setType("string", "byte")
+		if b.ctx.jsonSchema {
+			// OpenAPI 3.1 uses contentEncoding instead of a format.
+			b.setType("string", "")
+			b.format = ""
+			b.setSingle("contentEncoding", ast.NewString("base64"), true)
+		} else {
+			b.setType("string", "byte")
+		}
 		b.bytes(v)
 	case cue.StringKind:
 		// date		string			date	   As defined by full-date - RFC3339
@@ -1101,6 +1136,7 @@ type builder struct {
 	current      *oaSchema
 	allOf        []*ast.StructLit
 	deprecated   bool
+	nullable     bool // JSON schema only; OpenAPI 3.0 uses a nullable field
 
 	// Building structural schema
 	core       *builder
@@ -1145,9 +1181,9 @@ func (b *builder) setType(t, format string) {
 
 func setType(t *oaSchema, b *builder) {
 	if b.typ != "" {
-		if b.core == nil || (b.core.typ != b.typ && !b.ctx.structural) {
+		if b.core == nil || (b.core.typ != b.typ && !b.ctx.structural) || b.nullable {
 			if !t.exists("type") {
-				t.Set("type", ast.NewString(b.typ))
+				t.Set("type", typeExpr(b))
 			}
 		}
 	}
@@ -1158,6 +1194,24 @@ func setType(t *oaSchema, b *builder) {
 	}
 }
 
+// typeExpr returns the value of the type field. In JSON schema, a nullable
+// type is represented as a list of types that includes null.
+func typeExpr(b *builder) ast.Expr {
+	if b.nullable && b.typ != "null" {
+		return ast.NewList(ast.NewString(b.typ), ast.NewString("null"))
+	}
+	return ast.NewString(b.typ)
+}
+
+// setNullable marks the schema as allowing null values.
+func (b *builder) setNullable() {
+	if b.ctx.jsonSchema {
+		b.nullable = true
+		return
+	}
+	b.setSingle("nullable", ast.NewBool(true), true)
+}
+
 // setFilter is like set, but allows the key-value pair to be filtered.
 func (b *builder) setFilter(schema, key string, v ast.Expr) {
 	if re := b.ctx.fieldFilter; re != nil && re.MatchString(path.Join(schema, key)) {
@@ -1194,6 +1248,23 @@ func (b *builder) kv(key string, value ast.Expr) *ast.StructLit {
 	return ast.NewStruct(key, value)
 }
 
+// enum returns a schema that allows only the given values. JSON schema uses
+// const for single values.
+func (b *builder) enum(values ...ast.Expr) *ast.StructLit {
+	if b.ctx.jsonSchema && len(values) == 1 {
+		return b.kv("const", values[0])
+	}
+	return b.kv("enum", ast.NewList(values...))
+}
+
+func (b *builder) setEnum(values ...ast.Expr) {
+	if b.ctx.jsonSchema && len(values) == 1 {
+		b.set("const", values[0])
+		return
+	}
+	b.set("enum", ast.NewList(values...))
+}
+
 func (b *builder) setNot(key string, value ast.Expr) {
 	b.add(ast.NewStruct("not", b.kv(key, value)))
 }
@@ -1240,8 +1311,18 @@ func (b *builder) finish() *ast.StructLit {
 	if b.deprecated {
 		t.Set("deprecated", ast.NewBool(true))
 	}
+	// A null type cannot be added to the type of a reference, in which case
+	// null is added as an alternative.
+	nullable := b.nullable && (b.typ == "" || t.exists("$ref"))
+	if nullable {
+		b.nullable = false
+	}
 	setType(t, b)
 	sortSchema((*ast.StructLit)(t))
+	if nullable && len(t.Elts) > 0 {
+		return ast.NewStruct("anyOf", ast.NewList(
+			(*ast.StructLit)(t), ast.NewStruct("type", ast.NewString("null"))))
+	}
 	return (*ast.StructLit)(t)
 }
 
@@ -51,7 +51,12 @@ type Config struct {
 	// in this document.
 	SelfContained bool
 
-	// OpenAPI version to use. Supported as of v3.0.0.
+	// OpenAPI version to use. Supported are 3.0.0, the default, and 3.1.0.
+	//
+	// Version 3.1.0 generates schemas using the JSON Schema 2020-12 dialect:
+	// nullable values are represented by a type list that includes null,
+	// single values use const, and bytes use contentEncoding. Expanded
+	// references are not restricted to structural schema.
 	Version string
 
 	// FieldFilter defines a regular expression of all fields to omit from the
@@ -64,7 +69,8 @@ type Config struct {
 
 	// ExpandReferences replaces references with actual objects when generating
 	// OpenAPI Schema. It is an error for an CUE value to refer to itself
-	// if this option is used.
+	// if this option is used. For version 3.0.0, this generates structural
+	// schema.
 	ExpandReferences bool
 }
 
@@ -84,6 +84,14 @@ func TestParseDefinitions(t *testing.T) {
 		in:     "nums.cue",
 		out:    "nums-v3.1.0.json",
 		config: &openapi.Config{Info: info, Version: "3.1.0"},
+	}, {
+		in:     "nullable.cue",
+		out:    "nullable.json",
+		config: defaultConfig,
+	}, {
+		in:     "nullable.cue",
+		out:    "nullable-v3.1.0.json",
+		config: &openapi.Config{Info: info, Version: "3.1.0"},
 	}, {
 		in:     "builtins.cue",
 		out:    "builtins.json",
@@ -104,6 +112,10 @@ func TestParseDefinitions(t *testing.T) {
 		in:     "openapi.cue",
 		out:    "openapi-norefs.json",
 		config: resolveRefs,
+	}, {
+		in:     "openapi.cue",
+		out:    "openapi-norefs-v3.1.0.json",
+		config: &openapi.Config{Info: info, ExpandReferences: true, Version: "3.1.0"},
 	}, {
 		in:     "embed.cue",
 		out:    "embed.json",
@@ -151,6 +163,14 @@ func TestParseDefinitions(t *testing.T) {
 		in:     "cycle.cue",
 		config: &openapi.Config{Info: info, ExpandReferences: true},
 		err:    "cycle",
+	}, {
+		in:     "cycle.cue",
+		config: &openapi.Config{Info: info, ExpandReferences: true, Version: "3.1.0"},
+		err:    "cycle",
+	}, {
+		in:     "service.cue",
+		out:    "service.json",
+		config: &openapi.Config{Info: info},
 	}}
 	for _, tc := range testCases {
 		t.Run(tc.out, func(t *testing.T) {
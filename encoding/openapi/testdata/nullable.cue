@@ -0,0 +1,18 @@
+#Null: null
+
+#NullableString: string | null
+
+#NullableEnum: "a" | "b" | null
+
+#Const: "foo"
+
+#NullableRef: #Struct | null
+
+#NullableOneOf: null | {a: int} | {b: string}
+
+#Bytes: bytes
+
+#Struct: {
+	a?: int | null
+	b?: *"x" | "y" | null
+}
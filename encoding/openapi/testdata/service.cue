@@ -0,0 +1,15 @@
+// Protobuf services do not describe data types and are omitted.
+#Greeter: {
+	SayHello: {
+		request:  #HelloRequest
+		response: #HelloReply
+	}
+} @protobuf(service)
+
+#HelloRequest: {
+	name?: string @protobuf(1,string)
+}
+
+#HelloReply: {
+	message?: string @protobuf(1,string)
+}
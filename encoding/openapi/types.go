@@ -87,6 +87,14 @@ func getDeprecated(v cue.Value) bool {
 	return r
 }
 
+// isService reports whether v is a service definition converted from
+// protobuf, which does not describe a data type.
+func isService(v cue.Value) bool {
+	a := v.Attribute("protobuf")
+	s, _ := a.String(0)
+	return s == "service"
+}
+
 func simplify(b *builder, t *ast.StructLit) {
 	if b.format == "" {
 		return
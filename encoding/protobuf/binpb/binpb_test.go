@@ -0,0 +1,113 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package binpb_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast/astutil"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/encoding/protobuf/binpb"
+	"cuelang.org/go/internal/cuetest"
+	"cuelang.org/go/internal/cuetxtar"
+)
+
+// TestRoundTrip encodes value.cue, or takes the hex-encoded message of
+// input.hex, and decodes the result using schema.cue. Comments starting with
+// // are allowed in input.hex.
+func TestRoundTrip(t *testing.T) {
+	test := cuetxtar.TxTarTest{
+		Root:   "./testdata",
+		Name:   "binpb",
+		Update: cuetest.UpdateGoldenFiles,
+	}
+
+	r := cue.Runtime{}
+
+	test.Run(t, func(t *cuetxtar.Test) {
+		var schema, value cue.Value
+		var b []byte
+
+		for _, f := range t.Archive.Files {
+			switch f.Name {
+			case "schema.cue", "value.cue":
+				inst, err := r.Compile(f.Name, f.Data)
+				if err != nil {
+					t.WriteErrors(errors.Promote(err, "test"))
+					return
+				}
+				if f.Name == "schema.cue" {
+					schema = inst.Value()
+				} else {
+					value = inst.Value()
+				}
+
+			case "input.hex":
+				var digits []string
+				for _, line := range strings.Split(string(f.Data), "\n") {
+					if i := strings.Index(line, "//"); i >= 0 {
+						line = line[:i]
+					}
+					digits = append(digits, strings.Fields(line)...)
+				}
+				var err error
+				b, err = hex.DecodeString(strings.Join(digits, ""))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		if value.Exists() {
+			v := schema.Unify(value)
+			if err := v.Err(); err != nil {
+				t.WriteErrors(errors.Promote(err, "test"))
+				return
+			}
+
+			var err error
+			b, err = binpb.NewEncoder().Encode(v)
+			if err != nil {
+				t.WriteErrors(errors.Promote(err, "test"))
+				return
+			}
+			_, _ = t.Writer("encode").Write([]byte(hex.Dump(b)))
+		}
+
+		w := t.Writer("decode")
+		x, err := binpb.NewDecoder().Parse(schema, "input.pb", b)
+		if err != nil {
+			errors.Print(w, err, nil)
+			return
+		}
+		f, err := astutil.ToFile(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := format.Node(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write(out)
+
+		if _, err := binpb.NewDecoder().Decode(schema, b); err != nil {
+			t.Errorf("decoded value does not unify with schema: %v", err)
+		}
+	})
+}
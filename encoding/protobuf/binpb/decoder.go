@@ -0,0 +1,502 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package binpb
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/encoding/protobuf/pbinternal"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/value"
+)
+
+// Option defines options for the decoder.
+// There are currently no options.
+type Option func(*options)
+
+type options struct {
+}
+
+// NewDecoder returns a new Decoder
+func NewDecoder(option ...Option) *Decoder {
+	d := &Decoder{}
+	_ = d.m // work around linter bug.
+	return d
+}
+
+// A Decoder caches conversions of cue.Value between calls to its methods.
+type Decoder struct {
+	m map[*adt.Vertex]*mapping
+}
+
+type decoder struct {
+	*Decoder
+
+	// Reset on each call
+	errs errors.Error
+	file *token.File
+}
+
+// Parse decodes the given binary protobuf message and converts it to a CUE
+// expression, using schema as the guideline for conversion using the
+// following rules:
+//
+//   - fields are identified by the field number of their @protobuf attribute
+//   - fields in the message that have no corresponding field in schema are
+//     ignored
+//   - the last value of a non-repeated scalar field wins, whereas multiple
+//     values of a message field are merged
+//
+// Default values are not included in the result. The filename is used for
+// associating position information. The column of a position corresponds to
+// the offset within the message.
+func (d *Decoder) Parse(schema cue.Value, filename string, b []byte) (ast.Expr, error) {
+	dec := decoder{Decoder: d}
+
+	dec.file = token.NewFile(filename, 0, len(b))
+
+	m := dec.parseSchema(schema)
+	if dec.errs != nil {
+		return nil, dec.errs
+	}
+
+	x := dec.decodeMsg(m, b, 0)
+	if dec.errs != nil {
+		return nil, dec.errs
+	}
+
+	return x, nil
+}
+
+// Decode decodes the given binary protobuf message and unifies the result
+// with schema.
+func (d *Decoder) Decode(schema cue.Value, b []byte) (cue.Value, error) {
+	x, err := d.Parse(schema, "", b)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	v := schema.Unify(schema.Context().BuildExpr(x))
+	if err := v.Err(); err != nil {
+		return cue.Value{}, err
+	}
+	return v, nil
+}
+
+type mapping struct {
+	fields []*fieldInfo // ordered by field number
+	byNum  map[int]*fieldInfo
+}
+
+type fieldInfo struct {
+	pbinternal.Info
+	num int
+	typ string // element type for lists and value type for maps
+	msg *mapping
+}
+
+func (d *decoder) addErr(err error) {
+	d.errs = errors.Append(d.errs, errors.Promote(err, "binpb"))
+}
+
+func (d *decoder) addErrf(offset int, format string, args ...interface{}) {
+	err := errors.Newf(d.file.Pos(offset, token.NoRelPos), "binpb: "+format, args...)
+	d.errs = errors.Append(d.errs, err)
+}
+
+// parseSchema walks over a CUE "type" and converts it to an internal data
+// structure that is used for decoding messages. The mappings for fields of
+// message types are computed on demand.
+func (d *decoder) parseSchema(schema cue.Value) *mapping {
+	_, v := value.ToInternal(schema)
+	if v == nil {
+		return nil
+	}
+
+	if d.m == nil {
+		d.m = map[*adt.Vertex]*mapping{}
+	} else if m := d.m[v]; m != nil {
+		return m
+	}
+
+	m := &mapping{byNum: map[int]*fieldInfo{}}
+	d.addFields(m, schema)
+	sort.Slice(m.fields, func(i, j int) bool {
+		return m.fields[i].num < m.fields[j].num
+	})
+
+	d.m[v] = m
+	return m
+}
+
+// addFields adds the fields of schema to m.
+func (d *decoder) addFields(m *mapping, schema cue.Value) {
+	i, err := schema.Fields(cue.Optional(true))
+	if err != nil {
+		// The fields of a oneof are defined in disjunctions embedded in the
+		// schema, which cannot be iterated over before they are resolved.
+		// Collect the fields of all conjuncts and disjuncts instead.
+		switch op, a := schema.Expr(); op {
+		case cue.AndOp, cue.OrOp:
+			for _, v := range a {
+				d.addFields(m, v)
+			}
+			return
+
+		case cue.SelectorOp:
+			_, v := value.ToInternal(schema)
+			if x := cue.Dereference(schema); v != nil {
+				if _, w := value.ToInternal(x); w != v {
+					d.addFields(m, x)
+					return
+				}
+			}
+		}
+		d.addErr(err)
+		return
+	}
+
+	for i.Next() {
+		a := i.Value().Attribute("protobuf")
+		if a.Err() != nil {
+			continue
+		}
+		num, err := a.Int(0)
+		if err != nil {
+			d.addErr(err)
+			continue
+		}
+		if m.byNum[int(num)] != nil {
+			continue
+		}
+
+		info, err := pbinternal.FromIter(i)
+		if err != nil {
+			d.addErr(err)
+			continue
+		}
+
+		f := &fieldInfo{Info: info, num: int(num), typ: typeName(info.Type)}
+		if info.CompositeType == pbinternal.Map {
+			f.typ = mapValueType(info.Type)
+		}
+		m.fields = append(m.fields, f)
+		m.byNum[f.num] = f
+	}
+}
+
+func (d *decoder) decodeMsg(m *mapping, data []byte, offset int) ast.Expr {
+	st := &ast.StructLit{}
+	if m == nil {
+		return st
+	}
+
+	values := map[*fieldInfo][]wireValue{}
+	n, err := decodeFields(data, func(num int, x wireValue) {
+		if f := m.byNum[num]; f != nil {
+			values[f] = append(values[f], x)
+		}
+	})
+	if err != nil {
+		d.addErrf(offset+n, "%v", err)
+		return st
+	}
+
+	for _, f := range m.fields {
+		a := values[f]
+		if len(a) == 0 {
+			continue
+		}
+
+		var value ast.Expr
+
+		switch f.CompositeType {
+		case pbinternal.List:
+			list := &ast.ListLit{}
+			wt := wireType(f.typ, f.ValueType)
+			for _, x := range a {
+				if x.wireType != bytesType || wt == bytesType {
+					if v := d.decodeValue(f, f.typ, x, offset); v != nil {
+						list.Elts = append(list.Elts, v)
+					}
+					continue
+				}
+				// Packed repeated field.
+				for b := x.b; len(b) > 0; {
+					y, n, err := consumeValue(b, wt)
+					if err != nil {
+						d.addErrf(offset+x.offset, "%v", err)
+						break
+					}
+					y.offset = offset + x.offset + len(x.b) - len(b)
+					if v := d.decodeValue(f, f.typ, y, 0); v != nil {
+						list.Elts = append(list.Elts, v)
+					}
+					b = b[n:]
+				}
+			}
+			value = list
+
+		case pbinternal.Map:
+			s := &ast.StructLit{}
+			entries := map[string]*ast.Field{}
+			for _, x := range a {
+				if x.wireType != bytesType {
+					d.addErrf(offset+x.offset, "invalid wire type %d for map %s",
+						x.wireType, f.Name)
+					continue
+				}
+				key, val := d.decodeEntry(f, x, offset)
+				if val == nil {
+					continue
+				}
+				if e := entries[key]; e != nil {
+					e.Value = val
+					continue
+				}
+				e := &ast.Field{Label: label(key), Value: val}
+				entries[key] = e
+				s.Elts = append(s.Elts, e)
+			}
+			value = s
+
+		default:
+			x := a[len(a)-1]
+			if x.wireType == bytesType && len(a) > 1 &&
+				wireType(f.typ, f.ValueType) == bytesType &&
+				f.typ != "string" && f.typ != "bytes" {
+				// Multiple values of a message field are merged, which is
+				// equivalent to decoding their concatenation.
+				x = a[0]
+				for _, y := range a[1:] {
+					x.b = append(x.b[:len(x.b):len(x.b)], y.b...)
+				}
+			}
+			value = d.decodeValue(f, f.typ, x, offset)
+		}
+
+		if value == nil {
+			continue
+		}
+
+		st.Elts = append(st.Elts, &ast.Field{Label: label(f.CUEName), Value: value})
+	}
+
+	return st
+}
+
+// decodeEntry decodes a map entry, which is a message with the key in field
+// 1 and the value in field 2. Missing keys and values have the default value
+// of their type.
+func (d *decoder) decodeEntry(f *fieldInfo, x wireValue, offset int) (key string, val ast.Expr) {
+	offset += x.offset
+	k := wireValue{wireType: wireTypes[f.KeyTypeString]}
+	v := wireValue{wireType: wireType(f.typ, f.ValueType)}
+	n, err := decodeFields(x.b, func(num int, x wireValue) {
+		switch num {
+		case 1:
+			k = x
+		case 2:
+			v = x
+		}
+	})
+	if err != nil {
+		d.addErrf(offset+n, "%v", err)
+		return "", nil
+	}
+
+	if k.wireType != wireTypes[f.KeyTypeString] {
+		d.addErrf(offset+k.offset, "invalid wire type %d for key of map %s",
+			k.wireType, f.Name)
+		return "", nil
+	}
+	switch t := f.KeyTypeString; t {
+	case "string":
+		key = string(k.b)
+	case "bool":
+		key = strconv.FormatBool(k.v != 0)
+	case "uint32", "uint64", "fixed32", "fixed64":
+		key = strconv.FormatUint(k.v, 10)
+	default:
+		key = strconv.FormatInt(signedValue(t, k.v), 10)
+	}
+
+	return key, d.decodeValue(f, f.typ, v, offset)
+}
+
+// decodeValue converts a single value of the given protobuf type to CUE.
+func (d *decoder) decodeValue(f *fieldInfo, typ string, x wireValue, offset int) ast.Expr {
+	offset += x.offset
+	if wt := wireType(typ, f.ValueType); x.wireType != wt {
+		d.addErrf(offset, "invalid wire type %d for field %s of type %s",
+			x.wireType, f.Name, typ)
+		return nil
+	}
+
+	switch typ {
+	case "double":
+		return floatLit(math.Float64frombits(x.v), 64)
+
+	case "float":
+		return floatLit(float64(math.Float32frombits(uint32(x.v))), 32)
+
+	case "int32", "int64", "sint32", "sint64", "sfixed32", "sfixed64":
+		return ast.NewLit(token.INT, strconv.FormatInt(signedValue(typ, x.v), 10))
+
+	case "uint32":
+		return ast.NewLit(token.INT, strconv.FormatUint(uint64(uint32(x.v)), 10))
+
+	case "uint64", "fixed32", "fixed64":
+		return ast.NewLit(token.INT, strconv.FormatUint(x.v, 10))
+
+	case "bool":
+		return ast.NewBool(x.v != 0)
+
+	case "string":
+		if !utf8.Valid(x.b) {
+			d.addErrf(offset, "invalid UTF-8 in string field %s", f.Name)
+			return nil
+		}
+		return ast.NewString(string(x.b))
+
+	case "bytes":
+		return ast.NewLit(token.STRING, literal.Bytes.Quote(string(x.b)))
+
+	case timestampType, durationType:
+		var seconds, nanos int64
+		n, err := decodeFields(x.b, func(num int, x wireValue) {
+			switch num {
+			case 1:
+				seconds = int64(x.v)
+			case 2:
+				nanos = int64(int32(x.v))
+			}
+		})
+		if err != nil {
+			d.addErrf(offset+n, "%v", err)
+			return nil
+		}
+		if typ == durationType {
+			d := time.Duration(seconds)*time.Second + time.Duration(nanos)
+			return ast.NewString(d.String())
+		}
+		t := time.Unix(seconds, nanos).UTC()
+		return ast.NewString(t.Format(time.RFC3339Nano))
+	}
+
+	if w := wrapperTypes[typ]; w != "" {
+		v := wireValue{wireType: wireTypes[w]}
+		n, err := decodeFields(x.b, func(num int, x wireValue) {
+			if num == 1 {
+				v = x
+			}
+		})
+		if err != nil {
+			d.addErrf(offset+n, "%v", err)
+			return nil
+		}
+		return d.decodeValue(f, w, v, offset)
+	}
+
+	if f.ValueType == pbinternal.Message {
+		if f.msg == nil {
+			f.msg = d.parseSchema(f.Value)
+		}
+		return d.decodeMsg(f.msg, x.b, offset)
+	}
+
+	// Enum
+	i := int64(int32(x.v))
+	if f.ValueType == pbinternal.String {
+		s := enumName(f.Value, i)
+		if s == "" {
+			d.addErrf(offset, "unknown value %d for enum field %s", i, f.Name)
+			return nil
+		}
+		return ast.NewString(s)
+	}
+	return ast.NewLit(token.INT, strconv.FormatInt(i, 10))
+}
+
+// wireType returns the wire type for values of the given type, where t is the
+// CUE type of such values.
+func wireType(typ string, t pbinternal.ValueType) int {
+	if wt, ok := wireTypes[typ]; ok {
+		return wt
+	}
+	switch {
+	case typ == timestampType, typ == durationType, wrapperTypes[typ] != "",
+		t == pbinternal.Message:
+		return bytesType
+	}
+	return varintType // enum
+}
+
+// signedValue converts the representation of a signed integer type to its
+// value.
+func signedValue(typ string, v uint64) int64 {
+	switch typ {
+	case "sint32", "sint64":
+		return unzigzag(v)
+	case "int32", "sfixed32":
+		return int64(int32(v))
+	}
+	return int64(v)
+}
+
+func label(name string) ast.Label {
+	if ast.IsValidIdent(name) && !strings.HasPrefix(name, "_") &&
+		!strings.HasPrefix(name, "#") {
+		return ast.NewIdent(name)
+	}
+	return ast.NewString(name)
+}
+
+func floatLit(f float64, bits int) ast.Expr {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		// TODO: include message.
+		return &ast.BottomLit{}
+	}
+	s := strconv.FormatFloat(f, 'g', -1, bits)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return ast.NewLit(token.FLOAT, s)
+}
+
+// enumName returns the name of the enum value i for an enum represented as a
+// disjunction of strings with an #enumValue.
+func enumName(v cue.Value, i int64) string {
+	_, a := cue.Dereference(v).Expr()
+	for _, x := range a {
+		n, err := x.LookupPath(cue.MakePath(cue.Def("#enumValue"))).Int64()
+		if err != nil || n != i {
+			continue
+		}
+		if s, err := x.String(); err == nil {
+			return s
+		}
+	}
+	return ""
+}
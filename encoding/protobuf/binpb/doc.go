@@ -0,0 +1,29 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package binpb converts the protobuf binary wire format to and from CUE.
+//
+// The conversion is driven by the @protobuf attributes of a CUE schema, such
+// as those generated by package protobuf. The first argument of the attribute
+// is the field number and the second is the protobuf type. The types of
+// fields without an attribute cannot be determined and are reported as an
+// error by the encoder and skipped by the decoder.
+//
+// Enums are encoded by their #enumValue if they are represented as strings,
+// or by their integer value otherwise. The well-known types Timestamp,
+// Duration, and the wrapper types are converted to and from their CUE
+// representation. The other well-known types are not supported.
+//
+// API Status: DRAFT: API may change without notice.
+package binpb
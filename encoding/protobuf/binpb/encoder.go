@@ -0,0 +1,369 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package binpb
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/encoding/protobuf/pbinternal"
+)
+
+// Encoder marshals CUE into the protobuf wire format.
+type Encoder struct {
+}
+
+// NewEncoder returns a new encoder, where the given options are default
+// options.
+func NewEncoder(options ...Option) *Encoder {
+	return &Encoder{}
+}
+
+// Encode converts a concrete CUE value to a binary protobuf message.
+//
+// All regular fields of v must have a @protobuf attribute. Fields are written
+// in the order of their field number, and repeated fields of scalar types are
+// packed. Fields with a null value are omitted.
+func (e *Encoder) Encode(v cue.Value, options ...Option) ([]byte, error) {
+	enc := &encoder{}
+
+	b := enc.encodeMsg(v)
+
+	if enc.errs != nil {
+		return nil, enc.errs
+	}
+	return b, nil
+}
+
+type encoder struct {
+	errs errors.Error
+}
+
+func (e *encoder) addErr(err error) {
+	e.errs = errors.Append(e.errs, errors.Promote(err, "binpb"))
+}
+
+func (e *encoder) addErrf(v cue.Value, format string, args ...interface{}) {
+	args = append([]interface{}{v.Path()}, args...)
+	err := errors.Newf(v.Pos(), "binpb: %v: "+format, args...)
+	e.errs = errors.Append(e.errs, err)
+}
+
+type encField struct {
+	num   int
+	value cue.Value
+	info  pbinternal.Info
+}
+
+func (e *encoder) encodeMsg(v cue.Value) []byte {
+	i, err := v.Fields()
+	if err != nil {
+		e.addErr(err)
+		return nil
+	}
+
+	var fields []encField
+	for i.Next() {
+		x := i.Value()
+		if x.Null() == nil {
+			continue
+		}
+		a := x.Attribute("protobuf")
+		if a.Err() != nil {
+			e.addErrf(x, "field has no @protobuf attribute")
+			continue
+		}
+		num, err := a.Int(0)
+		if err != nil {
+			e.addErr(err)
+			continue
+		}
+		info, err := pbinternal.FromIter(i)
+		if err != nil {
+			e.addErr(err)
+			continue
+		}
+		fields = append(fields, encField{int(num), x, info})
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].num < fields[j].num
+	})
+
+	var b []byte
+	for _, f := range fields {
+		b = e.encodeField(b, f)
+	}
+	return b
+}
+
+func (e *encoder) encodeField(b []byte, f encField) []byte {
+	switch f.info.CompositeType {
+	case pbinternal.List:
+		i, err := f.value.List()
+		if err != nil {
+			e.addErr(err)
+			return b
+		}
+		typ := typeName(f.info.Type)
+		var values []wireValue
+		for i.Next() {
+			if x, ok := e.encodeValue(typ, i.Value()); ok {
+				values = append(values, x)
+			}
+		}
+		if len(values) == 0 || values[0].wireType == bytesType {
+			for _, x := range values {
+				b = appendField(b, f.num, x)
+			}
+			return b
+		}
+		var packed []byte
+		for _, x := range values {
+			packed = appendPayload(packed, x)
+		}
+		return appendBytes(b, f.num, packed)
+
+	case pbinternal.Map:
+		i, err := f.value.Fields()
+		if err != nil {
+			e.addErr(err)
+			return b
+		}
+		typ := mapValueType(f.info.Type)
+		for i.Next() {
+			key, ok := e.encodeKey(f.info.KeyTypeString, i.Label(), i.Value())
+			if !ok {
+				continue
+			}
+			value, ok := e.encodeValue(typ, i.Value())
+			if !ok {
+				continue
+			}
+			entry := appendField(nil, 1, key)
+			entry = appendField(entry, 2, value)
+			b = appendBytes(b, f.num, entry)
+		}
+		return b
+
+	default:
+		if x, ok := e.encodeValue(typeName(f.info.Type), f.value); ok {
+			b = appendField(b, f.num, x)
+		}
+		return b
+	}
+}
+
+// encodeKey encodes the label of a map entry as a value of the given type.
+func (e *encoder) encodeKey(typ, key string, v cue.Value) (x wireValue, ok bool) {
+	x.wireType = wireTypes[typ]
+	var err error
+	switch typ {
+	case "string":
+		x.b = []byte(key)
+
+	case "bool":
+		var b bool
+		b, err = strconv.ParseBool(key)
+		if b {
+			x.v = 1
+		}
+
+	case "int32", "int64", "sint32", "sint64", "sfixed32", "sfixed64":
+		var i int64
+		i, err = strconv.ParseInt(key, 10, 64)
+		x.v = signedBits(typ, i)
+
+	case "uint32", "uint64", "fixed32", "fixed64":
+		x.v, err = strconv.ParseUint(key, 10, 64)
+
+	default:
+		e.addErrf(v, "unsupported map key type %s", typ)
+		return x, false
+	}
+	if err != nil {
+		e.addErrf(v, "invalid key %q for map key type %s", key, typ)
+		return x, false
+	}
+	return x, true
+}
+
+// encodeValue encodes a single value of the given protobuf type.
+func (e *encoder) encodeValue(typ string, v cue.Value) (x wireValue, ok bool) {
+	var err error
+	if wt, ok := wireTypes[typ]; ok {
+		x.wireType = wt
+		switch typ {
+		case "double", "float":
+			var f float64
+			f, err = v.Float64()
+			if typ == "float" {
+				x.v = uint64(math.Float32bits(float32(f)))
+			} else {
+				x.v = math.Float64bits(f)
+			}
+
+		case "uint32", "uint64", "fixed32", "fixed64":
+			x.v, err = v.Uint64()
+
+		case "int32", "int64", "sint32", "sint64", "sfixed32", "sfixed64":
+			var i int64
+			i, err = v.Int64()
+			x.v = signedBits(typ, i)
+
+		case "bool":
+			var b bool
+			b, err = v.Bool()
+			if b {
+				x.v = 1
+			}
+
+		case "string":
+			var s string
+			s, err = v.String()
+			x.b = []byte(s)
+
+		case "bytes":
+			x.b, err = v.Bytes()
+		}
+		if err != nil {
+			e.addErr(err)
+			return x, false
+		}
+		return x, true
+	}
+
+	x.wireType = bytesType
+
+	switch {
+	case typ == timestampType:
+		s, err := v.String()
+		if err != nil {
+			e.addErr(err)
+			return x, false
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			e.addErrf(v, "invalid timestamp %q", s)
+			return x, false
+		}
+		x.b = appendSecondsNanos(nil, t.Unix(), int64(t.Nanosecond()))
+		return x, true
+
+	case typ == durationType:
+		s, err := v.String()
+		if err != nil {
+			e.addErr(err)
+			return x, false
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			e.addErrf(v, "invalid duration %q", s)
+			return x, false
+		}
+		x.b = appendSecondsNanos(nil,
+			int64(d/time.Second), int64(d%time.Second))
+		return x, true
+
+	case wrapperTypes[typ] != "":
+		y, ok := e.encodeValue(wrapperTypes[typ], v)
+		if !ok {
+			return x, false
+		}
+		x.b = appendField(nil, 1, y)
+		return x, true
+
+	case strings.HasPrefix(typ, "google.protobuf.") &&
+		typ != "google.protobuf.Empty":
+		e.addErrf(v, "unsupported well-known type %s", typ)
+		return x, false
+	}
+
+	switch v.Kind() {
+	case cue.StructKind:
+		x.b = e.encodeMsg(v)
+		return x, true
+
+	case cue.IntKind:
+		x.wireType = varintType
+		i, err := v.Int64()
+		if err != nil {
+			e.addErr(err)
+			return x, false
+		}
+		x.v = uint64(i)
+		return x, true
+
+	case cue.StringKind:
+		x.wireType = varintType
+		i, err := v.LookupPath(cue.MakePath(cue.Def("#enumValue"))).Int64()
+		if err != nil {
+			s, _ := v.String()
+			e.addErrf(v, "cannot determine value of enum %q", s)
+			return x, false
+		}
+		x.v = uint64(i)
+		return x, true
+	}
+
+	e.addErrf(v, "cannot encode value of kind %v as %s", v.Kind(), typ)
+	return x, false
+}
+
+// signedBits returns the representation of i for a signed integer type.
+func signedBits(typ string, i int64) uint64 {
+	switch typ {
+	case "sint32", "sint64":
+		return zigzag(i)
+	case "sfixed32":
+		return uint64(uint32(i))
+	}
+	return uint64(i)
+}
+
+// appendSecondsNanos appends the fields of a Timestamp or Duration message.
+func appendSecondsNanos(b []byte, seconds, nanos int64) []byte {
+	if seconds != 0 {
+		b = appendField(b, 1, wireValue{wireType: varintType, v: uint64(seconds)})
+	}
+	if nanos != 0 {
+		b = appendField(b, 2, wireValue{wireType: varintType, v: uint64(nanos)})
+	}
+	return b
+}
+
+func appendField(b []byte, num int, x wireValue) []byte {
+	b = appendTag(b, num, x.wireType)
+	return appendPayload(b, x)
+}
+
+func appendPayload(b []byte, x wireValue) []byte {
+	switch x.wireType {
+	case varintType:
+		return appendVarint(b, x.v)
+	case fixed64Type:
+		return appendFixed64(b, x.v)
+	case fixed32Type:
+		return appendFixed32(b, uint32(x.v))
+	default:
+		b = appendVarint(b, uint64(len(x.b)))
+		return append(b, x.b...)
+	}
+}
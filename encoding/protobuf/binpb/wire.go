@@ -0,0 +1,190 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package binpb
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+)
+
+// Wire types.
+const (
+	varintType  = 0
+	fixed64Type = 1
+	bytesType   = 2
+	fixed32Type = 5
+)
+
+// wireTypes maps the scalar protobuf types to their wire type.
+var wireTypes = map[string]int{
+	"double":   fixed64Type,
+	"float":    fixed32Type,
+	"int32":    varintType,
+	"int64":    varintType,
+	"uint32":   varintType,
+	"uint64":   varintType,
+	"sint32":   varintType,
+	"sint64":   varintType,
+	"fixed32":  fixed32Type,
+	"fixed64":  fixed64Type,
+	"sfixed32": fixed32Type,
+	"sfixed64": fixed64Type,
+	"bool":     varintType,
+	"string":   bytesType,
+	"bytes":    bytesType,
+}
+
+// Well-known types with a dedicated CUE representation.
+const (
+	timestampType = "google.protobuf.Timestamp"
+	durationType  = "google.protobuf.Duration"
+)
+
+// wrapperTypes maps the well-known wrapper types to the type of the value
+// they wrap.
+var wrapperTypes = map[string]string{
+	"google.protobuf.DoubleValue": "double",
+	"google.protobuf.FloatValue":  "float",
+	"google.protobuf.Int64Value":  "int64",
+	"google.protobuf.UInt64Value": "uint64",
+	"google.protobuf.Int32Value":  "int32",
+	"google.protobuf.UInt32Value": "uint32",
+	"google.protobuf.BoolValue":   "bool",
+	"google.protobuf.StringValue": "string",
+	"google.protobuf.BytesValue":  "bytes",
+}
+
+// typeName normalizes the type of a @protobuf attribute. Types derived from
+// descriptors are fully qualified and start with a dot.
+func typeName(s string) string {
+	return strings.TrimPrefix(strings.TrimSpace(s), ".")
+}
+
+// mapValueType returns the value type of a map type of the form
+// map[K]V.
+func mapValueType(s string) string {
+	if i := strings.IndexByte(s, ']'); i >= 0 {
+		s = s[i+1:]
+	}
+	return typeName(s)
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+func appendTag(b []byte, num, wireType int) []byte {
+	return appendVarint(b, uint64(num)<<3|uint64(wireType))
+}
+
+func appendBytes(b []byte, num int, data []byte) []byte {
+	b = appendTag(b, num, bytesType)
+	b = appendVarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendFixed32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendFixed64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+// A wireValue is a single encoded value of a field.
+type wireValue struct {
+	wireType int
+	v        uint64 // varint and fixed values
+	b        []byte // length-delimited values
+	offset   int    // offset of the value within the decoded message
+}
+
+var errTruncated = errors.New("unexpected end of input")
+
+// consumeValue decodes a single value of the given wire type.
+func consumeValue(data []byte, wireType int) (x wireValue, n int, err error) {
+	x.wireType = wireType
+	switch wireType {
+	case varintType:
+		x.v, n = binary.Uvarint(data)
+		if n <= 0 {
+			return x, 0, errors.New("malformed varint")
+		}
+
+	case fixed64Type:
+		if len(data) < 8 {
+			return x, 0, errTruncated
+		}
+		x.v, n = binary.LittleEndian.Uint64(data), 8
+
+	case fixed32Type:
+		if len(data) < 4 {
+			return x, 0, errTruncated
+		}
+		x.v, n = uint64(binary.LittleEndian.Uint32(data)), 4
+
+	case bytesType:
+		size, k := binary.Uvarint(data)
+		if k <= 0 || uint64(len(data)-k) < size {
+			return x, 0, errTruncated
+		}
+		x.b, n = data[k:k+int(size)], k+int(size)
+		x.offset = k
+
+	default:
+		return x, 0, errors.New("unsupported wire type")
+	}
+	return x, n, nil
+}
+
+// decodeFields calls f for each field of the given encoded message. If the
+// message is malformed, it returns the offset at which decoding failed.
+func decodeFields(data []byte, f func(num int, x wireValue)) (offset int, err error) {
+	for offset < len(data) {
+		key, n := binary.Uvarint(data[offset:])
+		if n <= 0 {
+			return offset, errors.New("malformed field key")
+		}
+		num := key >> 3
+		if num == 0 || num > math.MaxInt32 {
+			return offset, errors.New("invalid field number")
+		}
+		x, k, err := consumeValue(data[offset+n:], int(key&7))
+		if err != nil {
+			return offset, err
+		}
+		x.offset += offset + n
+		offset += n + k
+
+		f(int(num), x)
+	}
+	return offset, nil
+}
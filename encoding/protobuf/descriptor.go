@@ -0,0 +1,565 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protobuf
+
+// This file converts compiled file descriptors, as defined in
+// google/protobuf/descriptor.proto, to the proto AST used for .proto source
+// files. Descriptors are decoded directly from the wire format, and only the
+// parts relevant for the conversion to CUE are interpreted.
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/proto"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+// ExtractDescriptorSet converts the files of a serialized FileDescriptorSet,
+// as generated by protoc --descriptor_set_out, to CUE. Imports are resolved
+// against the files in the set, which should therefore be generated with
+// --include_imports. The well-known types of google/protobuf need not be
+// included and are mapped to their CUE equivalents.
+//
+// The returned files are laid out as for Extractor.Files.
+func ExtractDescriptorSet(b []byte) ([]*ast.File, error) {
+	e := NewExtractor(&Config{})
+	if err := e.AddDescriptorSet(b); err != nil {
+		return nil, err
+	}
+	return e.Files()
+}
+
+// AddDescriptorSet adds the files of a serialized FileDescriptorSet to be
+// converted into CUE by the builder. The well-known types of google/protobuf
+// in the set are only converted if they are imported and have no builtin
+// CUE equivalent.
+//
+// Source code information, such as comments, and custom options, such as
+// (cue.val), are not available in descriptors and are not converted.
+func (b *Extractor) AddDescriptorSet(data []byte) error {
+	if b.done {
+		err := errors.Newf(token.NoPos,
+			"protobuf: cannot call AddDescriptorSet: Instances was already called")
+		b.errs = errors.Append(b.errs, err)
+		return err
+	}
+	files, err := decodeDescriptorSet(data)
+	if err != nil {
+		b.errs = errors.Append(b.errs, err)
+		return err
+	}
+	for _, f := range files {
+		b.descriptors[f.Filename] = f
+	}
+	for _, f := range files {
+		if isWellKnown(f.Filename) {
+			continue
+		}
+		if _, err := b.parse(f.Filename, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isWellKnown(filename string) bool {
+	switch {
+	case strings.HasPrefix(filename, "google/protobuf/"),
+		filename == "gogoproto/gogo.proto",
+		filename == "cue/cue.proto":
+		return true
+	}
+	return false
+}
+
+// Field numbers and values of google/protobuf/descriptor.proto.
+const (
+	fileSetFile = 1
+
+	fileName        = 1
+	filePackage     = 2
+	fileDependency  = 3
+	fileMessageType = 4
+	fileEnumType    = 5
+	fileService     = 6
+	fileOptions     = 8
+	fileSyntax      = 12
+
+	fileOptionsGoPackage = 11
+
+	messageName       = 1
+	messageField      = 2
+	messageNestedType = 3
+	messageEnumType   = 4
+	messageOptions    = 7
+	messageOneofDecl  = 8
+
+	messageOptionsMapEntry = 7
+
+	fieldName           = 1
+	fieldNumber         = 3
+	fieldLabel          = 4
+	fieldType           = 5
+	fieldTypeName       = 6
+	fieldOneofIndex     = 9
+	fieldProto3Optional = 17
+
+	oneofName = 1
+
+	enumName  = 1
+	enumValue = 2
+
+	enumValueName   = 1
+	enumValueNumber = 2
+
+	serviceName    = 1
+	serviceMethod  = 2
+	serviceOptions = 3
+
+	methodName            = 1
+	methodInputType       = 2
+	methodOutputType      = 3
+	methodOptions         = 4
+	methodClientStreaming = 5
+	methodServerStreaming = 6
+
+	// Options shared by ServiceOptions and MethodOptions.
+	optionsDeprecated = 33
+
+	methodOptionsIdempotencyLevel = 34
+
+	labelRequired = 2
+	labelRepeated = 3
+)
+
+// scalarTypes maps the values of FieldDescriptorProto.Type to the names of
+// the corresponding scalar types. Message, group, and enum types are
+// identified by their type name instead.
+var scalarTypes = map[uint64]string{
+	1:  "double",
+	2:  "float",
+	3:  "int64",
+	4:  "uint64",
+	5:  "int32",
+	6:  "fixed64",
+	7:  "fixed32",
+	8:  "bool",
+	9:  "string",
+	12: "bytes",
+	13: "uint32",
+	15: "sfixed32",
+	16: "sfixed64",
+	17: "sint32",
+	18: "sint64",
+}
+
+func decodeDescriptorSet(data []byte) ([]*proto.Proto, errors.Error) {
+	var files []*proto.Proto
+	err := decodeMessage(data, func(num int, v uint64, b []byte) error {
+		if num != fileSetFile {
+			return nil
+		}
+		f, err := decodeFile(b)
+		files = append(files, f)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Newf(token.NoPos,
+			"protobuf: invalid file descriptor set: %v", err)
+	}
+	return files, nil
+}
+
+func decodeFile(data []byte) (*proto.Proto, error) {
+	f := &proto.Proto{}
+	var pkg, syntax string
+	var imports, defs []proto.Visitee
+	var options []proto.Visitee
+
+	err := decodeMessage(data, func(num int, v uint64, b []byte) error {
+		switch num {
+		case fileName:
+			f.Filename = string(b)
+		case filePackage:
+			pkg = string(b)
+		case fileDependency:
+			imports = append(imports, &proto.Import{Filename: string(b)})
+		case fileMessageType:
+			m, _, err := decodeMessageType(b)
+			defs = append(defs, m)
+			return err
+		case fileEnumType:
+			e, err := decodeEnum(b)
+			defs = append(defs, e)
+			return err
+		case fileService:
+			s, err := decodeService(b)
+			defs = append(defs, s)
+			return err
+		case fileOptions:
+			return decodeMessage(b, func(num int, v uint64, b []byte) error {
+				if num == fileOptionsGoPackage {
+					options = append(options, &proto.Option{
+						Name:     "go_package",
+						Constant: stringLiteral(string(b)),
+					})
+				}
+				return nil
+			})
+		case fileSyntax:
+			syntax = string(b)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if syntax == "" {
+		syntax = "proto2"
+	}
+	f.Elements = append(f.Elements, &proto.Syntax{Value: syntax})
+	if pkg != "" {
+		f.Elements = append(f.Elements, &proto.Package{Name: pkg})
+	}
+	f.Elements = append(f.Elements, options...)
+	f.Elements = append(f.Elements, imports...)
+	f.Elements = append(f.Elements, defs...)
+
+	return f, nil
+}
+
+type descriptorField struct {
+	name       string
+	number     int
+	label      uint64
+	typ        string
+	oneofIndex int // -1 if not part of a oneof
+	optional   bool
+}
+
+// decodeMessageType decodes a DescriptorProto. It reports whether the message
+// is a synthesized map entry type.
+func decodeMessageType(data []byte) (m *proto.Message, mapEntry bool, err error) {
+	m = &proto.Message{}
+	var fields []*descriptorField
+	var oneofs []*proto.Oneof
+	var nested []*proto.Message
+	var enums []*proto.Enum
+	mapEntries := map[string]*proto.Message{}
+
+	err = decodeMessage(data, func(num int, v uint64, b []byte) error {
+		switch num {
+		case messageName:
+			m.Name = string(b)
+		case messageField:
+			f, err := decodeField(b)
+			fields = append(fields, f)
+			return err
+		case messageNestedType:
+			n, isEntry, err := decodeMessageType(b)
+			if err != nil {
+				return err
+			}
+			if isEntry {
+				mapEntries[n.Name] = n
+				return nil
+			}
+			nested = append(nested, n)
+		case messageEnumType:
+			e, err := decodeEnum(b)
+			enums = append(enums, e)
+			return err
+		case messageOneofDecl:
+			o := &proto.Oneof{}
+			oneofs = append(oneofs, o)
+			return decodeMessage(b, func(num int, v uint64, b []byte) error {
+				if num == oneofName {
+					o.Name = string(b)
+				}
+				return nil
+			})
+		case messageOptions:
+			return decodeMessage(b, func(num int, v uint64, b []byte) error {
+				if num == messageOptionsMapEntry {
+					mapEntry = v != 0
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	used := make([]bool, len(oneofs))
+	for _, f := range fields {
+		field := &proto.Field{Name: f.name, Type: f.typ, Sequence: f.number}
+
+		if f.oneofIndex >= 0 && !f.optional {
+			if f.oneofIndex >= len(oneofs) {
+				return nil, false, errors.Newf(token.NoPos,
+					"invalid oneof index %d for field %s", f.oneofIndex, f.name)
+			}
+			o := oneofs[f.oneofIndex]
+			o.Elements = append(o.Elements, &proto.OneOfField{Field: field})
+			if !used[f.oneofIndex] {
+				used[f.oneofIndex] = true
+				m.Elements = append(m.Elements, o)
+			}
+			continue
+		}
+
+		if f.label == labelRepeated {
+			name := f.typ[strings.LastIndexByte(f.typ, '.')+1:]
+			if entry, ok := mapEntries[name]; ok {
+				m.Elements = append(m.Elements, mapField(field, entry))
+				continue
+			}
+		}
+
+		m.Elements = append(m.Elements, &proto.NormalField{
+			Field:    field,
+			Repeated: f.label == labelRepeated,
+			Required: f.label == labelRequired,
+		})
+	}
+	for _, e := range enums {
+		m.Elements = append(m.Elements, e)
+	}
+	for _, n := range nested {
+		m.Elements = append(m.Elements, n)
+	}
+	return m, mapEntry, nil
+}
+
+// mapField converts a repeated field of a map entry type to a map field.
+func mapField(f *proto.Field, entry *proto.Message) *proto.MapField {
+	m := &proto.MapField{Field: f}
+	for _, e := range entry.Elements {
+		if x, ok := e.(*proto.NormalField); ok {
+			switch x.Sequence {
+			case 1:
+				m.KeyType = x.Type
+			case 2:
+				m.Type = x.Type
+			}
+		}
+	}
+	return m
+}
+
+func decodeField(data []byte) (*descriptorField, error) {
+	f := &descriptorField{oneofIndex: -1}
+	var typ uint64
+	var typeName string
+	err := decodeMessage(data, func(num int, v uint64, b []byte) error {
+		switch num {
+		case fieldName:
+			f.name = string(b)
+		case fieldNumber:
+			f.number = int(int32(v))
+		case fieldLabel:
+			f.label = v
+		case fieldType:
+			typ = v
+		case fieldTypeName:
+			typeName = string(b)
+		case fieldOneofIndex:
+			f.oneofIndex = int(int32(v))
+		case fieldProto3Optional:
+			f.optional = v != 0
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if f.typ = scalarTypes[typ]; f.typ == "" {
+		if typeName == "" {
+			return nil, errors.Newf(token.NoPos,
+				"unknown type %d for field %s", typ, f.name)
+		}
+		f.typ = typeName
+	}
+	return f, nil
+}
+
+func decodeEnum(data []byte) (*proto.Enum, error) {
+	e := &proto.Enum{}
+	err := decodeMessage(data, func(num int, v uint64, b []byte) error {
+		switch num {
+		case enumName:
+			e.Name = string(b)
+		case enumValue:
+			f := &proto.EnumField{}
+			e.Elements = append(e.Elements, f)
+			return decodeMessage(b, func(num int, v uint64, b []byte) error {
+				switch num {
+				case enumValueName:
+					f.Name = string(b)
+				case enumValueNumber:
+					f.Integer = int(int32(v))
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return e, err
+}
+
+// idempotencyLevels maps the values of MethodOptions.IdempotencyLevel to
+// their names.
+var idempotencyLevels = map[uint64]string{
+	1: "NO_SIDE_EFFECTS",
+	2: "IDEMPOTENT",
+}
+
+// decodeService decodes a ServiceDescriptorProto. Only the standard options
+// are retained; custom options are encoded as extensions, which cannot be
+// interpreted without their definition.
+func decodeService(data []byte) (*proto.Service, error) {
+	s := &proto.Service{}
+	err := decodeMessage(data, func(num int, v uint64, b []byte) error {
+		switch num {
+		case serviceName:
+			s.Name = string(b)
+		case serviceMethod:
+			m, err := decodeMethod(b)
+			s.Elements = append(s.Elements, m)
+			return err
+		case serviceOptions:
+			return decodeMessage(b, func(num int, v uint64, b []byte) error {
+				if num == optionsDeprecated {
+					s.Elements = append(s.Elements, boolOption("deprecated", v))
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return s, err
+}
+
+func decodeMethod(data []byte) (*proto.RPC, error) {
+	m := &proto.RPC{}
+	err := decodeMessage(data, func(num int, v uint64, b []byte) error {
+		switch num {
+		case methodName:
+			m.Name = string(b)
+		case methodInputType:
+			m.RequestType = string(b)
+		case methodOutputType:
+			m.ReturnsType = string(b)
+		case methodClientStreaming:
+			m.StreamsRequest = v != 0
+		case methodServerStreaming:
+			m.StreamsReturns = v != 0
+		case methodOptions:
+			return decodeMessage(b, func(num int, v uint64, b []byte) error {
+				switch num {
+				case optionsDeprecated:
+					m.Elements = append(m.Elements, boolOption("deprecated", v))
+				case methodOptionsIdempotencyLevel:
+					if level, ok := idempotencyLevels[v]; ok {
+						m.Elements = append(m.Elements, &proto.Option{
+							Name:     "idempotency_level",
+							Constant: proto.Literal{Source: level},
+						})
+					}
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return m, err
+}
+
+func boolOption(name string, v uint64) *proto.Option {
+	return &proto.Option{
+		Name:     name,
+		Constant: proto.Literal{Source: strconv.FormatBool(v != 0)},
+	}
+}
+
+func stringLiteral(s string) proto.Literal {
+	q := strconv.Quote(s)
+	return proto.Literal{Source: q[1 : len(q)-1], IsString: true, QuoteRune: '"'}
+}
+
+// Wire types of the protocol buffer encoding.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// decodeMessage calls f for each field of the given encoded message. Varint
+// values are passed as v and length-delimited values as b. Fixed-size values
+// are skipped.
+func decodeMessage(data []byte, f func(num int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("malformed field key")
+		}
+		data = data[n:]
+
+		num := int(key >> 3)
+		var v uint64
+		var b []byte
+		switch key & 7 {
+		case wireVarint:
+			if v, n = binary.Uvarint(data); n <= 0 {
+				return errors.New("malformed varint")
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return errors.New("unexpected end of data")
+			}
+			data = data[8:]
+			continue
+		case wireBytes:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < size {
+				return errors.New("malformed length-delimited field")
+			}
+			b = data[n : n+int(size)]
+			data = data[n+int(size):]
+		case wireFixed32:
+			if len(data) < 4 {
+				return errors.New("unexpected end of data")
+			}
+			data = data[4:]
+			continue
+		default:
+			return errors.Newf(token.NoPos, "unsupported wire type %d", key&7)
+		}
+		if err := f(num, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
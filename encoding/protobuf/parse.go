@@ -47,6 +47,10 @@ func (s *Extractor) parse(filename string, src interface{}) (p *protoConverter,
 		s.fileCache[filename] = result{p, err}
 	}()
 
+	if d, ok := s.descriptors[filename]; ok && src == nil {
+		return s.convert(filename, d, token.NewFile(filename, 0, 0), true)
+	}
+
 	b, err := source.Read(filename, src)
 	if err != nil {
 		return nil, err
@@ -64,12 +68,19 @@ func (s *Extractor) parse(filename string, src interface{}) (p *protoConverter,
 	tfile := token.NewFile(filename, 0, len(b))
 	tfile.SetLinesForContent(b)
 
+	return s.convert(filename, d, tfile, false)
+}
+
+// convert converts a parsed proto file to CUE. If descriptor is true, d was
+// derived from a compiled file descriptor instead of a .proto source file.
+func (s *Extractor) convert(filename string, d *proto.Proto, tfile *token.File, descriptor bool) (p *protoConverter, err error) {
 	p = &protoConverter{
-		id:       filename,
-		state:    s,
-		tfile:    tfile,
-		imported: map[string]bool{},
-		symbols:  map[string]bool{},
+		id:         filename,
+		state:      s,
+		tfile:      tfile,
+		descriptor: descriptor,
+		imported:   map[string]bool{},
+		symbols:    map[string]bool{},
 	}
 
 	defer func() {
@@ -126,6 +137,16 @@ func (s *Extractor) parse(filename string, src interface{}) (p *protoConverter,
 		}
 	}
 
+	if p.descriptor && p.protoPkg != "" {
+		// Type names in descriptors are fully qualified.
+		top := p.scope[0]
+		p.scope[0] = map[string]mapping{}
+		for name, m := range top {
+			p.scope[0][name] = m
+			p.scope[0][p.protoPkg+"."+name] = m
+		}
+	}
+
 	if name := p.shortName(); name != "" {
 		p.file.Decls = append(p.file.Decls, &ast.Package{Name: ast.NewIdent(name)})
 	}
@@ -154,7 +175,8 @@ type protoConverter struct {
 	state *Extractor
 	tfile *token.File
 
-	proto3 bool
+	proto3     bool
+	descriptor bool // converted from a file descriptor
 
 	id           string
 	protoPkg     string
@@ -302,14 +324,25 @@ func (p *protoConverter) doImport(v *proto.Import) error {
 	}
 
 	filename := ""
-	for _, p := range p.state.paths {
-		name := filepath.Join(p, v.Filename)
-		_, err := os.Stat(name)
-		if err != nil {
-			continue
+	if _, ok := p.state.descriptors[v.Filename]; ok {
+		filename = v.Filename
+	} else {
+		for _, p := range p.state.paths {
+			name := filepath.Join(p, v.Filename)
+			_, err := os.Stat(name)
+			if err != nil {
+				continue
+			}
+			filename = name
+			break
+		}
+	}
+
+	if filename == "" && p.descriptor {
+		// Descriptor sets need not include the well-known types.
+		if !p.mapBuiltinPackage(v.Position, v.Filename, true) {
+			return nil
 		}
-		filename = name
-		break
 	}
 
 	if filename == "" {
@@ -429,7 +462,7 @@ func (p *protoConverter) topElement(v proto.Visitee) {
 		// already handled.
 
 	case *proto.Service:
-		// TODO: handle services.
+		p.service(x)
 
 	case *proto.Extensions, *proto.Reserved:
 		// no need to handle
@@ -477,6 +510,156 @@ func (p *protoConverter) message(v *proto.Message) {
 	}
 }
 
+// service converts a proto service definition to CUE. A service maps to a
+// definition with a field for each of its methods, which holds the request
+// and response types and the options of the method. For example,
+//
+//    service Greeter {
+//      rpc SayHello(HelloRequest) returns (stream HelloReply) {
+//        option (google.api.http) = { get: "/v1/hello" };
+//      }
+//    }
+//
+// maps to
+//
+//    #Greeter: {
+//        SayHello: {
+//            request:         #HelloRequest
+//            response:        #HelloReply
+//            serverStreaming: true
+//            options: "google.api.http": get: "/v1/hello"
+//        }
+//    } @protobuf(service)
+//
+// The streaming fields are only included if a method streams its request or
+// response. Options of the service itself map to attributes.
+func (p *protoConverter) service(v *proto.Service) {
+	defer func(saved []string) { p.path = saved }(p.path)
+	p.path = append(p.path, v.Name)
+
+	s := &ast.StructLit{
+		Lbrace: p.toCUEPos(v.Position),
+		Rbrace: token.Newline.Pos(),
+	}
+
+	ref := p.ref(v.Position)
+	if v.Comment == nil {
+		ref.NamePos = newSection
+	}
+	f := &ast.Field{Label: ref, Value: s}
+	addComments(f, 1, v.Comment, nil)
+	p.addTag(f, "service")
+	p.addDecl(f)
+
+	for i, e := range v.Elements {
+		switch x := e.(type) {
+		case *proto.Comment:
+			s.Elts = append(s.Elts, comment(x, true))
+
+		case *proto.RPC:
+			s.Elts = append(s.Elts, p.rpc(i, x))
+
+		case *proto.Option:
+			opt := fmt.Sprintf("@protobuf(option %s=%s)", x.Name, x.Constant.Source)
+			attr := &ast.Attribute{
+				At:   p.toCUEPos(x.Position),
+				Text: opt,
+			}
+			addComments(attr, i, x.Doc(), x.InlineComment)
+			s.Elts = append(s.Elts, attr)
+		}
+	}
+}
+
+func (p *protoConverter) rpc(i int, x *proto.RPC) *ast.Field {
+	defer func(saved []string) { p.path = saved }(p.path)
+	p.path = append(p.path, x.Name)
+
+	m := &ast.StructLit{
+		Lbrace: p.toCUEPos(x.Position),
+		Rbrace: token.Newline.Pos(),
+	}
+	add := func(name string, value ast.Expr) {
+		f := &ast.Field{Label: ast.NewIdent(name), Value: value}
+		ast.SetRelPos(f, token.Newline)
+		m.Elts = append(m.Elts, f)
+	}
+
+	add("request", p.resolve(x.Position, x.RequestType, nil))
+	add("response", p.resolve(x.Position, x.ReturnsType, nil))
+	if x.StreamsRequest {
+		add("clientStreaming", ast.NewBool(true))
+	}
+	if x.StreamsReturns {
+		add("serverStreaming", ast.NewBool(true))
+	}
+
+	var options []interface{}
+	for _, e := range x.Elements {
+		if o, ok := e.(*proto.Option); ok {
+			name := strings.NewReplacer("(", "", ")", "").Replace(o.Name)
+			options = append(options, ast.NewString(name), p.optionValue(&o.Constant))
+		}
+	}
+	if options != nil {
+		add("options", ast.NewStruct(options...))
+	}
+
+	f := &ast.Field{
+		Label: &ast.Ident{NamePos: p.toCUEPos(x.Position), Name: x.Name},
+		Value: m,
+	}
+	addComments(f, i, x.Comment, x.InlineComment)
+	return f
+}
+
+// optionValue converts the value of an option to CUE. Identifiers, such as
+// enum values, map to strings.
+func (p *protoConverter) optionValue(l *proto.Literal) ast.Expr {
+	switch {
+	case l.Array != nil:
+		list := &ast.ListLit{}
+		for _, x := range l.Array {
+			list.Elts = append(list.Elts, p.optionValue(x))
+		}
+		return list
+
+	case l.OrderedMap != nil || (!l.IsString && l.Source == ""):
+		s := &ast.StructLit{}
+		for _, x := range l.OrderedMap {
+			f := &ast.Field{
+				Label: ast.NewString(x.Name),
+				Value: p.optionValue(x.Literal),
+			}
+			if ast.IsValidIdent(x.Name) && !strings.HasPrefix(x.Name, "_") {
+				f.Label = ast.NewIdent(x.Name)
+			}
+			ast.SetRelPos(f, token.Newline)
+			s.Elts = append(s.Elts, f)
+		}
+		return s
+
+	case l.IsString:
+		str, err := strconv.Unquote(`"` + l.Source + `"`)
+		if err != nil {
+			str = l.Source
+		}
+		return p.stringLit(l.Position, str)
+
+	case l.Source == "true", l.Source == "false":
+		return ast.NewBool(l.Source == "true")
+	}
+
+	var info literal.NumInfo
+	if err := literal.ParseNum(l.Source, &info); err == nil {
+		if info.IsInt() {
+			return ast.NewLit(token.INT, l.Source)
+		}
+		return ast.NewLit(token.FLOAT, l.Source)
+	}
+	return p.stringLit(l.Position, l.Source)
+}
+
 func (p *protoConverter) addDecl(d ast.Decl) {
 	if p.current == nil {
 		p.file.Decls = append(p.file.Decls, d)
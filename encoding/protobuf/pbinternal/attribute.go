@@ -137,7 +137,10 @@ func FromValue(name string, v cue.Value) (info Info, err error) {
 
 	case cue.IntKind:
 		info.ValueType = Int
-		r, _ := utf8.DecodeRuneInString(info.Type)
+		// Enum type names may be qualified, as in "pkg.Enum", or fully
+		// qualified, as in ".pkg.Enum", for types derived from descriptors.
+		name := info.Type[strings.LastIndexByte(info.Type, '.')+1:]
+		r, _ := utf8.DecodeRuneInString(name)
 		info.IsEnum = unicode.In(r, unicode.Upper)
 
 	case cue.FloatKind, cue.NumberKind:
@@ -91,6 +91,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/emicklei/proto"
 	"github.com/mpvl/unique"
 
 	"cuelang.org/go/cue/ast"
@@ -167,8 +168,9 @@ type Extractor struct {
 	pkgName  string
 	enumMode string
 
-	fileCache map[string]result
-	imports   map[string]*build.Instance
+	fileCache   map[string]result
+	imports     map[string]*build.Instance
+	descriptors map[string]*proto.Proto // by file name
 
 	errs errors.Error
 	done bool
@@ -185,14 +187,15 @@ type result struct {
 func NewExtractor(c *Config) *Extractor {
 	cwd, _ := os.Getwd()
 	b := &Extractor{
-		root:      c.Root,
-		cwd:       cwd,
-		paths:     c.Paths,
-		pkgName:   c.PkgName,
-		module:    c.Module,
-		enumMode:  c.EnumMode,
-		fileCache: map[string]result{},
-		imports:   map[string]*build.Instance{},
+		root:        c.Root,
+		cwd:         cwd,
+		paths:       c.Paths,
+		pkgName:     c.PkgName,
+		module:      c.Module,
+		enumMode:    c.EnumMode,
+		fileCache:   map[string]result{},
+		imports:     map[string]*build.Instance{},
+		descriptors: map[string]*proto.Proto{},
 	}
 
 	if b.root == "" {
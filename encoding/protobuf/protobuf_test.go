@@ -37,6 +37,7 @@ func TestExtractDefinitions(t *testing.T) {
 		"mixer/v1/attributes.proto",
 		"mixer/v1/config/client/client_config.proto",
 		"other/trailcomment.proto",
+		"other/service.proto",
 	}
 	for _, file := range testCases {
 		t.Run(file, func(t *testing.T) {
@@ -73,6 +74,48 @@ func TestExtractDefinitions(t *testing.T) {
 	}
 }
 
+// The descriptor set in testdata/descriptor/set.pb is generated with
+//
+//   protoc -I . --include_imports --descriptor_set_out=set.pb example/v1/example.proto
+//
+func TestExtractDescriptorSet(t *testing.T) {
+	b, err := ioutil.ReadFile("testdata/descriptor/set.pb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := ExtractDescriptorSet(b)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+
+	out := &bytes.Buffer{}
+	for _, f := range files {
+		b, err := format.Node(f, format.Simplify())
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(out, "-- %s --\n%s", filepath.ToSlash(f.Filename), b)
+	}
+
+	wantFile := "testdata/descriptor/set.pb.out.txt"
+	if cuetest.UpdateGoldenFiles {
+		_ = ioutil.WriteFile(wantFile, out.Bytes(), 0644)
+		return
+	}
+
+	want, err := ioutil.ReadFile(wantFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc := pretty.Diff(out.String(), string(want)); len(desc) > 0 {
+		t.Errorf("files differ:\n%v", desc)
+	}
+
+	if _, err := ExtractDescriptorSet([]byte{0x0a, 0x05}); err == nil {
+		t.Error("expected error for malformed descriptor set")
+	}
+}
+
 func TestBuild(t *testing.T) {
 	cwd, _ := os.Getwd()
 	root := filepath.Join(cwd, "testdata/istio.io/api")
@@ -21,6 +21,39 @@ import (
 	"time"
 )
 
+// Mixer provides three core features:
+//
+// - *Precondition Checking*. Enables callers to verify a number of preconditions
+// before responding to an incoming request from a service consumer.
+// Preconditions can include whether the service consumer is properly
+// authenticated, is on the service’s whitelist, passes ACL checks, and more.
+//
+// - *Quota Management*. Enables services to allocate and free quota on a number
+// of dimensions, Quotas are used as a relatively simple resource management tool
+// to provide some fairness between service consumers when contending for limited
+// resources. Rate limits are examples of quotas.
+//
+// - *Telemetry Reporting*. Enables services to report logging and monitoring.
+// In the future, it will also enable tracing and billing streams intended for
+// both the service operator as well as for service consumers.
+#Mixer: {
+	// Checks preconditions and allocate quota before performing an operation.
+	// The preconditions enforced depend on the set of supplied attributes and
+	// the active configuration.
+	Check: {
+		request:  #CheckRequest
+		response: #CheckResponse
+	}
+
+	// Reports telemetry, such as logs and metrics.
+	// The reported information depends on the set of supplied attributes and the
+	// active configuration.
+	Report: {
+		request:  #ReportRequest
+		response: #ReportResponse
+	}
+} @protobuf(service)
+
 // Used to get a thumbs-up/thumbs-down before performing an action.
 #CheckRequest: {
 	// parameters for a quota allocation
@@ -0,0 +1,43 @@
+package other
+
+import "struct"
+
+// Greeter greets.
+#Greeter: {
+	@protobuf(option deprecated=false)
+
+	// Sends a greeting.
+	SayHello: {
+		request:  #HelloRequest
+		response: #HelloReply
+		options: {
+			"google.api.http": {
+				post: "/v1/hello"
+				body: "*"
+				additional_bindings: [{
+					get: "/v1/hello/{name}"
+				}]
+			}
+			idempotency_level: "NO_SIDE_EFFECTS"
+			retries:           3
+		}
+	}
+	Chat: {
+		request:         #HelloRequest
+		response:        #HelloReply
+		clientStreaming: true
+		serverStreaming: true
+	} // Streams both ways.
+	Ping: {
+		request:  struct.MaxFields(0)
+		response: struct.MaxFields(0)
+	}
+} @protobuf(service)
+
+#HelloRequest: {
+	name?: string @protobuf(1,string)
+}
+
+#HelloReply: {
+	message?: string @protobuf(1,string)
+}
@@ -0,0 +1,501 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package xml converts XML to and from CUE.
+//
+// An XML document maps to a struct with a single field named after the root
+// element. Elements are mapped as follows:
+//
+//   - An element without attributes or child elements maps to its text
+//     content as a string.
+//   - Any other element maps to a struct. Attributes map to fields with an
+//     @xml(attr) attribute, child elements map to fields named after the
+//     element, and non-whitespace text maps to a field $text with an
+//     @xml(chardata) attribute.
+//   - Child elements with the same name map to a list.
+//
+// For example,
+//
+//   <config version="2">
+//     <server>a</server>
+//     <server>b</server>
+//   </config>
+//
+// maps to
+//
+//   config: {
+//       version: "2" @xml(attr)
+//       server: ["a", "b"]
+//   }
+//
+// Encoding applies the inverse mapping. Additionally, the name of an element
+// or XML attribute can be set with the first argument of the @xml attribute
+// of a field, as in @xml(id,attr).
+package xml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/source"
+)
+
+// NamespaceMode determines how XML namespaces are represented in CUE.
+type NamespaceMode int
+
+const (
+	// LocalNames discards namespace prefixes and namespace declarations.
+	LocalNames NamespaceMode = iota
+
+	// PrefixedNames retains namespace prefixes in names, as in "soap:Body",
+	// and namespace declarations as attributes, as in "xmlns:soap".
+	PrefixedNames
+)
+
+// Config configures the conversion of XML to CUE.
+type Config struct {
+	// Namespaces determines how namespaces are mapped. The default is
+	// LocalNames.
+	Namespaces NamespaceMode
+}
+
+// Extract parses the XML document of the given file to a CUE expression.
+func Extract(filename string, src interface{}, cfg *Config) (ast.Expr, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	b, err := source.Read(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	tf := token.NewFile(filename, -1, len(b))
+	tf.SetLinesForContent(b)
+
+	d := &decoder{
+		cfg:  cfg,
+		file: tf,
+		dec:  xml.NewDecoder(bytes.NewReader(b)),
+	}
+	root, err := d.parse()
+	if err != nil {
+		return nil, err
+	}
+	field, err := d.field(root)
+	if err != nil {
+		return nil, err
+	}
+	return &ast.StructLit{
+		Lbrace: token.NoPos.WithRel(token.Blank),
+		Elts:   []ast.Decl{field},
+		Rbrace: token.NoPos.WithRel(token.Newline),
+	}, nil
+}
+
+type decoder struct {
+	cfg  *Config
+	file *token.File
+	dec  *xml.Decoder
+}
+
+type element struct {
+	name     string
+	pos      token.Pos
+	attrs    []attribute
+	children []*element
+	text     strings.Builder
+	textPos  token.Pos
+}
+
+type attribute struct {
+	name  string
+	value string
+}
+
+func (d *decoder) pos() token.Pos {
+	return d.file.Pos(int(d.dec.InputOffset()), token.NoRelPos)
+}
+
+func (d *decoder) errf(format string, args ...interface{}) error {
+	return errors.Newf(d.pos(), "xml: "+format, args...)
+}
+
+// parse parses the document and returns its root element.
+func (d *decoder) parse() (*element, error) {
+	var root *element
+	var stack []*element
+	for {
+		pos := d.pos()
+		tok, err := d.token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, pos, "xml")
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e := &element{name: d.name(t.Name), pos: pos}
+			for _, a := range t.Attr {
+				if name, ok := d.attrName(a.Name); ok {
+					e.attrs = append(e.attrs, attribute{name, a.Value})
+				}
+			}
+			switch {
+			case len(stack) > 0:
+				p := stack[len(stack)-1]
+				p.children = append(p.children, e)
+			case root != nil:
+				return nil, d.errf("multiple root elements")
+			default:
+				root = e
+			}
+			stack = append(stack, e)
+
+		case xml.EndElement:
+			n := len(stack) - 1
+			if n < 0 || stack[n].name != d.name(t.Name) {
+				return nil, d.errf("unexpected end element </%s>", d.name(t.Name))
+			}
+			stack = stack[:n]
+
+		case xml.CharData:
+			if len(stack) > 0 {
+				e := stack[len(stack)-1]
+				if e.text.Len() == 0 {
+					e.textPos = pos
+				}
+				e.text.Write(t)
+			}
+		}
+	}
+	switch {
+	case root == nil:
+		return nil, d.errf("no root element")
+	case len(stack) > 0:
+		return nil, d.errf("unexpected EOF")
+	}
+	return root, nil
+}
+
+// token returns the next token. Prefixes are only retained if namespaces are
+// represented by their prefix, as Token replaces them with namespace URLs.
+func (d *decoder) token() (xml.Token, error) {
+	if d.cfg.Namespaces == PrefixedNames {
+		return d.dec.RawToken()
+	}
+	return d.dec.Token()
+}
+
+func (d *decoder) name(n xml.Name) string {
+	if d.cfg.Namespaces == PrefixedNames && n.Space != "" {
+		return n.Space + ":" + n.Local
+	}
+	return n.Local
+}
+
+// attrName reports the name for the XML attribute n, or false if the
+// attribute should be dropped.
+func (d *decoder) attrName(n xml.Name) (string, bool) {
+	isDecl := n.Space == "xmlns" || (n.Space == "" && n.Local == "xmlns")
+	if d.cfg.Namespaces == PrefixedNames {
+		return d.name(n), true
+	}
+	return n.Local, !isDecl
+}
+
+func (d *decoder) field(e *element) (*ast.Field, error) {
+	value, err := d.value(e)
+	if err != nil {
+		return nil, err
+	}
+	f := &ast.Field{Label: label(e.name), Value: value}
+	ast.SetPos(f, e.pos.WithRel(token.Newline))
+	return f, nil
+}
+
+func (d *decoder) value(e *element) (ast.Expr, error) {
+	if len(e.attrs) == 0 && len(e.children) == 0 {
+		s := ast.NewString(e.text.String())
+		ast.SetPos(s, e.textPos)
+		return s, nil
+	}
+
+	s := &ast.StructLit{
+		Lbrace: e.pos,
+		Rbrace: e.pos.WithRel(token.Newline),
+	}
+	seen := map[string]bool{}
+	add := func(name string, f *ast.Field) error {
+		if seen[name] {
+			return errors.Newf(e.pos,
+				"xml: element %s has multiple values named %q", e.name, name)
+		}
+		seen[name] = true
+		ast.SetRelPos(f, token.Newline)
+		s.Elts = append(s.Elts, f)
+		return nil
+	}
+
+	for _, a := range e.attrs {
+		f := &ast.Field{
+			Label: label(a.name),
+			Value: ast.NewString(a.value),
+			Attrs: []*ast.Attribute{{Text: "@xml(attr)"}},
+		}
+		if err := add(a.name, f); err != nil {
+			return nil, err
+		}
+	}
+
+	// Group children by name, in order of first appearance.
+	groups := map[string][]*element{}
+	var names []string
+	for _, c := range e.children {
+		if _, ok := groups[c.name]; !ok {
+			names = append(names, c.name)
+		}
+		groups[c.name] = append(groups[c.name], c)
+	}
+	for _, name := range names {
+		g := groups[name]
+		var f *ast.Field
+		if len(g) == 1 {
+			var err error
+			if f, err = d.field(g[0]); err != nil {
+				return nil, err
+			}
+		} else {
+			list := &ast.ListLit{}
+			for _, c := range g {
+				v, err := d.value(c)
+				if err != nil {
+					return nil, err
+				}
+				list.Elts = append(list.Elts, v)
+			}
+			f = &ast.Field{Label: label(name), Value: list}
+			ast.SetPos(f, g[0].pos.WithRel(token.Newline))
+		}
+		if err := add(name, f); err != nil {
+			return nil, err
+		}
+	}
+
+	if text := e.text.String(); strings.TrimSpace(text) != "" {
+		f := &ast.Field{
+			Label: ast.NewIdent(textField),
+			Value: ast.NewString(text),
+			Attrs: []*ast.Attribute{{Text: "@xml(chardata)"}},
+		}
+		if err := add(textField, f); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// textField is the name of the field holding the text of an element that
+// also has attributes or child elements.
+const textField = "$text"
+
+// label returns a label for name, quoting it if it is not a valid identifier
+// for a regular field.
+func label(name string) ast.Label {
+	if ast.IsValidIdent(name) && !strings.HasPrefix(name, "_") &&
+		!strings.HasPrefix(name, "#") {
+		return ast.NewIdent(name)
+	}
+	return ast.NewString(name)
+}
+
+// Encode returns the XML encoding of v, which must be a struct with a single
+// regular field representing the root element.
+func Encode(v cue.Value) ([]byte, error) {
+	if err := v.Validate(cue.Concrete(true)); err != nil {
+		return nil, err
+	}
+	iter, err := v.Fields()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	e := &encoder{enc: xml.NewEncoder(&buf)}
+	e.enc.Indent("", "    ")
+
+	n := 0
+	for iter.Next() {
+		if n++; n > 1 {
+			return nil, errors.Newf(iter.Value().Pos(),
+				"xml: value must have a single root element")
+		}
+		name, _ := fieldInfo(iter.Value(), iter.Label())
+		if err := e.element(name, iter.Value()); err != nil {
+			return nil, err
+		}
+	}
+	if n == 0 {
+		return nil, errors.Newf(v.Pos(), "xml: value must have a root element")
+	}
+	if err := e.enc.Flush(); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+type encoder struct {
+	enc *xml.Encoder
+}
+
+type fieldKind int
+
+const (
+	elementField fieldKind = iota
+	attrField
+	charDataField
+)
+
+// fieldInfo reports the XML name and kind of the field with the given label
+// and value, as determined by its @xml attribute.
+func fieldInfo(v cue.Value, label string) (name string, kind fieldKind) {
+	name = label
+	a := v.Attribute("xml")
+	if a.Err() != nil {
+		return name, elementField
+	}
+	for i := 0; i < a.NumArgs(); i++ {
+		switch key, _ := a.Arg(i); key {
+		case "attr":
+			kind = attrField
+		case "chardata":
+			kind = charDataField
+		case "":
+		default:
+			if i == 0 {
+				name = key
+			}
+		}
+	}
+	return name, kind
+}
+
+func (e *encoder) element(name string, v cue.Value) error {
+	switch v.Kind() {
+	case cue.ListKind:
+		iter, _ := v.List()
+		for iter.Next() {
+			if err := e.element(name, iter.Value()); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case cue.StructKind:
+		return e.structElement(name, v)
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := e.enc.EncodeToken(start); err != nil {
+		return err
+	}
+	if v.Kind() != cue.NullKind {
+		s, err := text(v)
+		if err != nil {
+			return err
+		}
+		if err := e.enc.EncodeToken(xml.CharData(s)); err != nil {
+			return err
+		}
+	}
+	return e.enc.EncodeToken(start.End())
+}
+
+func (e *encoder) structElement(name string, v cue.Value) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+
+	type child struct {
+		name string
+		v    cue.Value
+	}
+	var children []child
+	var chardata []string
+
+	iter, err := v.Fields()
+	if err != nil {
+		return err
+	}
+	for iter.Next() {
+		f := iter.Value()
+		name, kind := fieldInfo(f, iter.Label())
+		switch kind {
+		case attrField:
+			s, err := text(f)
+			if err != nil {
+				return err
+			}
+			start.Attr = append(start.Attr, xml.Attr{
+				Name:  xml.Name{Local: name},
+				Value: s,
+			})
+		case charDataField:
+			s, err := text(f)
+			if err != nil {
+				return err
+			}
+			chardata = append(chardata, s)
+		default:
+			children = append(children, child{name, f})
+		}
+	}
+
+	if err := e.enc.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, s := range chardata {
+		if err := e.enc.EncodeToken(xml.CharData(s)); err != nil {
+			return err
+		}
+	}
+	for _, c := range children {
+		if err := e.element(c.name, c.v); err != nil {
+			return err
+		}
+	}
+	return e.enc.EncodeToken(start.End())
+}
+
+// text returns the text representation of the scalar value v.
+func text(v cue.Value) (string, error) {
+	switch v.Kind() {
+	case cue.StringKind:
+		return v.String()
+	case cue.BytesKind:
+		b, err := v.Bytes()
+		return string(b), err
+	case cue.BoolKind, cue.IntKind, cue.FloatKind, cue.NumberKind:
+		b, err := v.MarshalJSON()
+		return string(b), err
+	case cue.NullKind:
+		return "", nil
+	}
+	return "", errors.Newf(v.Pos(),
+		"xml: cannot encode value of type %s as text", v.Kind())
+}
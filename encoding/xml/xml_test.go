@@ -0,0 +1,216 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xml
+
+import (
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/format"
+)
+
+func TestExtract(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		cfg  *Config
+		out  string
+	}{{
+		name: "text",
+		in:   `<name>foo</name>`,
+		out: `{
+	name: "foo"
+}`,
+	}, {
+		name: "empty",
+		in:   `<name/>`,
+		out: `{
+	name: ""
+}`,
+	}, {
+		name: "nested",
+		in: `<?xml version="1.0"?>
+<config version="2">
+	<!-- comment -->
+	<server port="80">a</server>
+	<server>b</server>
+	<debug>true</debug>
+	<db><host>localhost</host></db>
+</config>`,
+		out: `{
+	config: {
+		version: "2" @xml(attr)
+		server: [{
+			port:  "80" @xml(attr)
+			$text: "a"  @xml(chardata)
+		}, "b"]
+		debug: "true"
+		db: {
+			host: "localhost"
+		}
+	}
+}`,
+	}, {
+		name: "labels",
+		in:   `<a><b-c>1</b-c><_d>2</_d></a>`,
+		out: `{
+	a: {
+		"b-c": "1"
+		"_d":  "2"
+	}
+}`,
+	}, {
+		name: "local names",
+		in: `<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns="urn:x">` +
+			`<soap:Body a="1">x</soap:Body></soap:Envelope>`,
+		out: `{
+	Envelope: {
+		Body: {
+			a:     "1" @xml(attr)
+			$text: "x" @xml(chardata)
+		}
+	}
+}`,
+	}, {
+		name: "prefixed names",
+		in: `<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">` +
+			`<soap:Body>x</soap:Body></soap:Envelope>`,
+		cfg: &Config{Namespaces: PrefixedNames},
+		out: `{
+	"soap:Envelope": {
+		"xmlns:soap": "http://www.w3.org/2003/05/soap-envelope" @xml(attr)
+		"soap:Body":  "x"
+	}
+}`,
+	}, {
+		name: "duplicate",
+		in:   `<a b="1"><b>2</b></a>`,
+		out:  `error: xml: element a has multiple values named "b"`,
+	}, {
+		name: "mismatch",
+		in:   `<a><b></a>`,
+		out:  `error: `,
+	}, {
+		name: "no root",
+		in:   ``,
+		out:  `error: xml: no root element`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := Extract(tc.name, tc.in, tc.cfg)
+			var got string
+			if err != nil {
+				got = "error: " + err.Error()
+			} else {
+				b, err := format.Node(expr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = string(b)
+			}
+			if strings.HasPrefix(tc.out, "error: ") {
+				if !strings.HasPrefix(got, tc.out) {
+					t.Errorf("got %v; want %v", got, tc.out)
+				}
+				return
+			}
+			if got != tc.out {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.out)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		out  string
+	}{{
+		name: "simple",
+		in:   `config: {name: "foo", port: 80, debug: true, empty: null}`,
+		out: `<config>
+    <name>foo</name>
+    <port>80</port>
+    <debug>true</debug>
+    <empty></empty>
+</config>
+`,
+	}, {
+		name: "attributes",
+		in: `config: {
+			version: 2 @xml(attr)
+			"server-list": server: [{
+				port:  80 @xml(attr)
+				$text: "a<b" @xml(chardata)
+			}, "b"]
+			ID: "x" @xml(id,attr)
+			Name: "y" @xml(name)
+		}`,
+		out: `<config version="2" id="x">
+    <server-list>
+        <server port="80">a&lt;b</server>
+        <server>b</server>
+    </server-list>
+    <name>y</name>
+</config>
+`,
+	}, {
+		name: "multiple roots",
+		in:   `a: 1, b: 2`,
+		out:  `error: xml: value must have a single root element`,
+	}, {
+		name: "incomplete",
+		in:   `a: int`,
+		out:  `error: a: incomplete value int`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := cuecontext.New().CompileString(tc.in)
+			b, err := Encode(v)
+			got := string(b)
+			if err != nil {
+				got = "error: " + err.Error()
+			}
+			if got != tc.out {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.out)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	const in = `<config version="2">
+    <server port="80">a</server>
+    <server>b</server>
+    <db>
+        <host>localhost</host>
+    </db>
+</config>
+`
+	expr, err := Extract("in.xml", in, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := cuecontext.New().BuildExpr(expr)
+	b, err := Encode(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != in {
+		t.Errorf("got:\n%s\nwant:\n%s", got, in)
+	}
+}
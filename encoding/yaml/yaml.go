@@ -88,18 +88,32 @@ func Encode(v cue.Value) ([]byte, error) {
 func EncodeStream(iter cue.Iterator) ([]byte, error) {
 	// TODO: return an io.Reader and allow asynchronous processing.
 	buf := &bytes.Buffer{}
+	if err := WriteStream(buf, iter); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteStream writes the values of iter to w as a multi-document YAML stream,
+// where consecutive values are separated with a `---`. To write the elements
+// of a list, pass the iterator returned by Value.List.
+func WriteStream(w io.Writer, iter cue.Iterator) error {
 	for i := 0; iter.Next(); i++ {
 		if i > 0 {
-			buf.WriteString("---\n")
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
 		}
 		n := iter.Value().Syntax(cue.Final())
 		b, err := cueyaml.Encode(n)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
 		}
-		buf.Write(b)
 	}
-	return buf.Bytes(), nil
+	return nil
 }
 
 // Validate validates the YAML and confirms it matches the constraints
@@ -61,8 +61,9 @@ func NewNonExisting(key string) Attr {
 }
 
 type KeyValue struct {
-	data  string
-	equal int // index of equal sign or 0 if non-existing
+	data   string
+	equal  int  // index of equal sign or 0 if non-existing
+	quoted bool // data was parsed from a CUE string literal
 }
 
 func (kv *KeyValue) Text() string { return kv.data }
@@ -100,6 +101,19 @@ func (a *Attr) String(pos int) (string, error) {
 	return a.Fields[pos].Text(), nil
 }
 
+// StringQuoted reports the unescaped value of the CUE string literal at the
+// given position or an error if the attribute is invalid, the position does
+// not exist, or the value at the given position is not a quoted string.
+func (a *Attr) StringQuoted(pos int) (string, error) {
+	if err := a.hasPos(pos); err != nil {
+		return "", err
+	}
+	if !a.Fields[pos].quoted {
+		return "", fmt.Errorf("field is not a quoted string")
+	}
+	return a.Fields[pos].Text(), nil
+}
+
 // Int reports the integer at the given position or an error if the attribute is
 // invalid, the position does not exist, or the value at the given position is
 // not an integer.
@@ -176,7 +190,9 @@ func skipSpace(s string) int {
 func scanAttributeElem(pos token.Pos, s string, a *Attr) (n int, err errors.Error) {
 	// try CUE string
 	kv := KeyValue{}
-	if n, kv.data, err = scanAttributeString(pos, s); n == 0 {
+	n, kv.data, err = scanAttributeString(pos, s)
+	kv.quoted = n > 0
+	if n == 0 {
 		// try key-value pair
 		p := strings.IndexAny(s, ",=") // ) is assumed to be stripped.
 		switch {
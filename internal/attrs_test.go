@@ -28,64 +28,64 @@ func TestAttributeBody(t *testing.T) {
 		err     string
 	}{{
 		in:  "",
-		out: "[{ 0}]",
+		out: "[{ 0 false}]",
 	}, {
 		in:  "bb",
-		out: "[{bb 0}]",
+		out: "[{bb 0 false}]",
 	}, {
 		in:  "a,",
-		out: "[{a 0} { 0}]",
+		out: "[{a 0 false} { 0 false}]",
 	}, {
 		in:  `"a",`,
-		out: "[{a 0} { 0}]",
+		out: "[{a 0 true} { 0 false}]",
 	}, {
 		in:  "a,b",
-		out: "[{a 0} {b 0}]",
+		out: "[{a 0 false} {b 0 false}]",
 	}, {
 		in:  `foo,"bar",#"baz"#`,
-		out: "[{foo 0} {bar 0} {baz 0}]",
+		out: "[{foo 0 false} {bar 0 true} {baz 0 true}]",
 	}, {
 		in:  `foo,bar,baz`,
-		out: "[{foo 0} {bar 0} {baz 0}]",
+		out: "[{foo 0 false} {bar 0 false} {baz 0 false}]",
 	}, {
 		in:  `1,map[int]string`,
-		out: "[{1 0} {map[int]string 0}]",
+		out: "[{1 0 false} {map[int]string 0 false}]",
 	}, {
 		in:  `1,map[int]string`,
-		out: "[{1 0} {map[int]string 0}]",
+		out: "[{1 0 false} {map[int]string 0 false}]",
 	}, {
 		in:  `bar=str`,
-		out: "[{bar=str 3}]",
+		out: "[{bar=str 3 false}]",
 	}, {
 		in:  `bar="str"`,
-		out: "[{bar=str 3}]",
+		out: "[{bar=str 3 false}]",
 	}, {
 		in:  `foo.bar="str"`,
-		out: "[{foo.bar=str 7}]",
+		out: "[{foo.bar=str 7 false}]",
 	}, {
 		in:  `bar=,baz=`,
-		out: "[{bar= 3} {baz= 3}]",
+		out: "[{bar= 3 false} {baz= 3 false}]",
 	}, {
 		in:  `foo=1,bar="str",baz=free form`,
-		out: "[{foo=1 3} {bar=str 3} {baz=free form 3}]",
+		out: "[{foo=1 3 false} {bar=str 3 false} {baz=free form 3 false}]",
 	}, {
 		in:  `foo=1,bar="str",baz=free form  `,
-		out: "[{foo=1 3} {bar=str 3} {baz=free form 3}]",
+		out: "[{foo=1 3 false} {bar=str 3 false} {baz=free form 3 false}]",
 	}, {
 		in:  `foo=1,bar="str"  ,baz="free form  "`,
-		out: "[{foo=1 3} {bar=str 3} {baz=free form   3}]",
+		out: "[{foo=1 3 false} {bar=str 3 false} {baz=free form   3 false}]",
 	}, {
 		in: `"""
 		"""`,
-		out: "[{ 0}]",
+		out: "[{ 0 true}]",
 	}, {
 		in: `#'''
 			\#x20
 			'''#`,
-		out: "[{  0}]",
+		out: "[{  0 true}]",
 	}, {
 		in:  "'' ,b",
-		out: "[{ 0} {b 0}]",
+		out: "[{ 0 true} {b 0 false}]",
 	}, {
 		in:  "' ,b",
 		err: "not terminated",
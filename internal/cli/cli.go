@@ -30,9 +30,14 @@ func ParseValue(pos token.Pos, name, str string, k cue.Kind) (x ast.Expr, errs e
 	if k&cue.NumberKind != 0 {
 		var err error
 		expr, err = parser.ParseExpr(name, str)
-		if err != nil {
+		switch {
+		case err != nil:
 			errs = errors.Wrapf(err, pos,
 				"invalid number for environment variable %s", name)
+		case !isNumber(expr):
+			expr = nil
+			errs = errors.Newf(pos,
+				"invalid number %q for environment variable %s", str, name)
 		}
 	}
 
@@ -75,6 +80,15 @@ func ParseValue(pos token.Pos, name, str string, k cue.Kind) (x ast.Expr, errs e
 	return nil, errs
 }
 
+// isNumber reports whether x is a, possibly signed, number literal.
+func isNumber(x ast.Expr) bool {
+	if u, ok := x.(*ast.UnaryExpr); ok && (u.Op == token.SUB || u.Op == token.ADD) {
+		x = u.X
+	}
+	b, ok := x.(*ast.BasicLit)
+	return ok && (b.Kind == token.INT || b.Kind == token.FLOAT)
+}
+
 var boolValues = map[string]bool{
 	"1":     true,
 	"0":     false,
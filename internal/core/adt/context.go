@@ -138,6 +138,12 @@ func New(v *Vertex, cfg *Config) *OpContext {
 		Format:  cfg.Format,
 		vertex:  v,
 	}
+	if r, ok := cfg.Runtime.(instrumentedRuntime); ok {
+		ctx.instrument = r.Instrumenter()
+	}
+	if r, ok := cfg.Runtime.(limitedRuntime); ok {
+		ctx.limits = r.Limits()
+	}
 	if v != nil {
 		ctx.e = &Environment{Up: nil, Vertex: v}
 	}
@@ -156,6 +162,17 @@ type OpContext struct {
 	stats        Stats
 	freeListNode *nodeContext
 
+	// instrument, if not nil, receives evaluation events.
+	instrument Instrumenter
+
+	// limits, if not nil, bounds the resources used by evaluation. steps
+	// and depth track the number of unifications started and in progress,
+	// and limitErr is set once a limit is exceeded.
+	limits   *Limits
+	steps    int
+	depth    int
+	limitErr *Bottom
+
 	e         *Environment
 	src       ast.Node
 	errs      *Bottom
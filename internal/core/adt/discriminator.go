@@ -0,0 +1,133 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adt
+
+// A discriminator is a regular field of a disjunct with a concrete scalar
+// value, such as the op field in
+//
+//	#Pull: {op: "pull", image: string}
+//	#Scratch: {op: "scratch"}
+//	steps: [...#Pull | #Scratch]
+//
+// A disjunct can only succeed if its discriminators do not conflict with the
+// concrete values of these fields that are already known for the node to
+// which it is added. Disjuncts for which this is not the case can be skipped
+// without evaluating them.
+//
+// Discriminators are determined syntactically: a field is a discriminator if
+// any of its conjuncts is a scalar literal, or if it was already evaluated to
+// a scalar. This never results in disjuncts being skipped that would not
+// fail, as unifying two different scalar values always results in an error.
+type discriminator struct {
+	label Feature
+	value Value
+}
+
+// discriminators returns the discriminators of each of the values of d, or
+// nil if there are none.
+func (n *nodeContext) discriminators(d envDisjunct) (a [][]discriminator) {
+	found := false
+	add := func(x []discriminator) {
+		a = append(a, x)
+		found = found || len(x) > 0
+	}
+	switch {
+	case d.expr != nil:
+		for _, v := range d.expr.Values {
+			add(exprDiscriminators(n.ctx, d.env, v.Val))
+		}
+	case d.value != nil:
+		for _, v := range d.value.Values {
+			add(vertexDiscriminators(v))
+		}
+	}
+	if !found {
+		return nil
+	}
+	return a
+}
+
+func exprDiscriminators(c *OpContext, env *Environment, x Expr) []discriminator {
+	switch x := x.(type) {
+	case *StructLit:
+		var a []discriminator
+		for _, d := range x.Decls {
+			f, ok := d.(*Field)
+			if !ok || !f.Label.IsRegular() {
+				continue
+			}
+			if v, ok := scalarLiteral(f.Value); ok {
+				a = append(a, discriminator{f.Label, v})
+			}
+		}
+		return a
+
+	case Resolver:
+		v, err := c.Resolve(env, x)
+		if err != nil {
+			return nil
+		}
+		return vertexDiscriminators(v)
+	}
+	return nil
+}
+
+func vertexDiscriminators(v *Vertex) (a []discriminator) {
+	for _, arc := range v.Arcs {
+		if !arc.Label.IsRegular() {
+			continue
+		}
+		if x, ok := arcScalar(arc); ok {
+			a = append(a, discriminator{arc.Label, x})
+		}
+	}
+	return a
+}
+
+// conflicts reports whether any of the discriminators conflicts with the
+// value of the corresponding field of v.
+func conflicts(c *OpContext, v *Vertex, discs []discriminator) bool {
+	for _, d := range discs {
+		arc := v.Lookup(d.label)
+		if arc == nil {
+			continue
+		}
+		if x, ok := arcScalar(arc); ok && !Equal(c, x, d.value, 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// arcScalar returns the concrete scalar value that arc must have, if known.
+func arcScalar(arc *Vertex) (Value, bool) {
+	if arc.status == Finalized {
+		return scalarLiteral(arc.BaseValue)
+	}
+	for _, c := range arc.Conjuncts {
+		if v, ok := scalarLiteral(c.Expr()); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func scalarLiteral(x interface{}) (Value, bool) {
+	switch v := x.(type) {
+	case *Null, *Bool, *Num, *String, *Bytes:
+		return v.(Value), true
+	}
+	return nil, false
+}
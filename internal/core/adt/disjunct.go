@@ -131,6 +131,13 @@ func (n *nodeContext) expandDisjuncts(
 		n.node = node
 	}()
 
+	if n.ctx.limits != nil && n.ctx.checkDisjuncts() != nil {
+		if recursive {
+			n.free()
+		}
+		return
+	}
+
 	for n.expandOne() {
 	}
 
@@ -144,6 +151,7 @@ func (n *nodeContext) expandDisjuncts(
 	}
 
 	defaultOffset := len(n.usedDefault)
+	expanded := 0 // number of disjunct combinations evaluated
 
 	switch {
 	default: // len(n.disjunctions) == 0
@@ -216,35 +224,17 @@ func (n *nodeContext) expandDisjuncts(
 				n.ctx.inDisjunct++
 			}
 
-			for _, dn := range a {
-				switch {
-				case d.expr != nil:
-					for _, v := range d.expr.Values {
-						cn := dn.clone()
-						*cn.node = clone(dn.snapshot)
-						cn.node.state = cn
-
-						c := MakeConjunct(d.env, v.Val, d.cloneID)
-						cn.addExprConjunct(c)
-
-						newMode := mode(d.hasDefaults, v.Default)
-
-						cn.expandDisjuncts(state, n, newMode, true, last)
-					}
-
-				case d.value != nil:
-					for i, v := range d.value.Values {
-						cn := dn.clone()
-						*cn.node = clone(dn.snapshot)
-						cn.node.state = cn
-
-						cn.addValueConjunct(d.env, v, d.cloneID)
-
-						newMode := mode(d.hasDefaults, i < d.value.NumDefaults)
-
-						cn.expandDisjuncts(state, n, newMode, true, last)
-					}
-				}
+			// Skip disjuncts that would fail because of a conflicting
+			// discriminator. If none of the others succeed, evaluate all of
+			// them anyway to report the same errors as without skipping.
+			discs := n.discriminators(d)
+			numErrs := len(n.disjunctErrs)
+			k, pruned := n.expandDisjunction(a, d, discs, state, last)
+			expanded += k
+			if pruned && len(n.disjuncts) == 0 && n.ctx.limitErr == nil {
+				n.disjunctErrs = n.disjunctErrs[:numErrs]
+				k, _ = n.expandDisjunction(a, d, nil, state, last)
+				expanded += k
 			}
 
 			if skipNonMonotonicChecks {
@@ -357,6 +347,10 @@ func (n *nodeContext) expandDisjuncts(
 		// }
 	}
 
+	if c := n.ctx; c.instrument != nil && !recursive && expanded > 0 {
+		c.instrument.Disjuncts(c, node, expanded, len(n.disjuncts))
+	}
+
 	// Compare to root, but add to this one.
 	switch p := parent; {
 	case p != n:
@@ -395,6 +389,67 @@ func (n *nodeContext) expandDisjuncts(
 	}
 }
 
+// expandDisjunction evaluates the combinations of the disjuncts in a with
+// the values of d and adds the results to n.disjuncts. If discs is not nil,
+// it holds the discriminators for each value of d, and combinations for which
+// these conflict with the disjunct in a are skipped. It returns the number of
+// combinations evaluated and whether any were skipped.
+func (n *nodeContext) expandDisjunction(
+	a []*nodeContext,
+	d envDisjunct,
+	discs [][]discriminator,
+	state VertexStatus,
+	last bool) (expanded int, pruned bool) {
+
+	for _, dn := range a {
+		switch {
+		case d.expr != nil:
+			for i, v := range d.expr.Values {
+				if n.ctx.limitErr != nil {
+					break
+				}
+				if discs != nil && conflicts(n.ctx, &dn.snapshot, discs[i]) {
+					pruned = true
+					continue
+				}
+				cn := dn.clone()
+				*cn.node = clone(dn.snapshot)
+				cn.node.state = cn
+
+				c := MakeConjunct(d.env, v.Val, d.cloneID)
+				cn.addExprConjunct(c)
+				expanded++
+
+				newMode := mode(d.hasDefaults, v.Default)
+
+				cn.expandDisjuncts(state, n, newMode, true, last)
+			}
+
+		case d.value != nil:
+			for i, v := range d.value.Values {
+				if n.ctx.limitErr != nil {
+					break
+				}
+				if discs != nil && conflicts(n.ctx, &dn.snapshot, discs[i]) {
+					pruned = true
+					continue
+				}
+				cn := dn.clone()
+				*cn.node = clone(dn.snapshot)
+				cn.node.state = cn
+
+				cn.addValueConjunct(d.env, v, d.cloneID)
+				expanded++
+
+				newMode := mode(d.hasDefaults, i < d.value.NumDefaults)
+
+				cn.expandDisjuncts(state, n, newMode, true, last)
+			}
+		}
+	}
+	return expanded, pruned
+}
+
 func (n *nodeContext) makeError() {
 	code := IncompleteError
 
@@ -178,6 +178,18 @@ func (c *OpContext) Unify(v *Vertex, state VertexStatus) {
 		}
 	}
 
+	if s := v.Status(); s == 0 || s == Partial {
+		if c.instrument != nil {
+			defer c.instrument.Unify(c, v)()
+		}
+		if c.limits != nil {
+			defer c.exitLimits(v)
+			if c.enterLimits() != nil {
+				return
+			}
+		}
+	}
+
 	switch v.Status() {
 	case Evaluating:
 		n.insertConjuncts()
@@ -0,0 +1,41 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adt
+
+// An Instrumenter receives events from the evaluator. It can be used to
+// profile or trace evaluation.
+//
+// An Instrumenter is associated with an OpContext through its Runtime: if the
+// Runtime has a method
+//
+//	Instrumenter() Instrumenter
+//
+// that returns a non-nil value, all contexts created for it report to this
+// Instrumenter. Calls for a single OpContext are never concurrent.
+type Instrumenter interface {
+	// Unify is called when the evaluator starts unifying the conjuncts of v.
+	// The returned function is called when it is done. Calls may be nested,
+	// as unifying a vertex may require unifying others.
+	Unify(c *OpContext, v *Vertex) (done func())
+
+	// Disjuncts is called after the disjunctions of v have been expanded,
+	// with the number of combinations of disjuncts that were evaluated and
+	// the number of disjuncts that remained.
+	Disjuncts(c *OpContext, v *Vertex, expanded, remaining int)
+}
+
+type instrumentedRuntime interface {
+	Instrumenter() Instrumenter
+}
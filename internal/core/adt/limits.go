@@ -0,0 +1,140 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adt
+
+import "context"
+
+// Limits bounds the resources used by evaluation. The limits apply to each
+// OpContext separately. A zero value for a field means there is no limit.
+//
+// Limits are associated with an OpContext through its Runtime: if the
+// Runtime has a method
+//
+//	Limits() *Limits
+//
+// that returns a non-nil value, all contexts created for it observe these
+// limits.
+type Limits struct {
+	// MaxSteps is the maximum number of times a vertex may be unified.
+	// Interpreters of @extern functions may also use it to limit the number
+	// of instructions executed by a single call.
+	MaxSteps int
+
+	// MaxDisjuncts is the maximum number of disjuncts that may be evaluated
+	// while expanding disjunctions.
+	MaxDisjuncts int
+
+	// MaxDepth is the maximum number of vertices whose unification may be
+	// in progress at the same time, which is about the depth of nesting of
+	// values and references that need to be evaluated.
+	MaxDepth int
+
+	// Context, if not nil, aborts evaluation when it is done, for instance
+	// because its deadline has passed.
+	Context context.Context
+}
+
+type limitedRuntime interface {
+	Limits() *Limits
+}
+
+// A LimitError reports that an evaluation was aborted because it exceeded
+// one of its Limits. Values that were being evaluated at that time evaluate
+// to this error.
+type LimitError struct {
+	// Limit is the name of the field of Limits that was exceeded.
+	Limit string
+
+	// Max is the value of the exceeded limit, or 0 if the limit is Context.
+	Max int
+
+	// Err is the error of the Context if Limit is Context.
+	Err error
+
+	*ValueError
+}
+
+// Unwrap returns the error of the Context, if any.
+func (e *LimitError) Unwrap() error { return e.Err }
+
+// enterLimits records the start of unifying a vertex and reports the error
+// for exceeding a limit, if any. The caller must call exitLimits afterwards
+// regardless of the result.
+func (c *OpContext) enterLimits() *Bottom {
+	c.steps++
+	c.depth++
+	if c.limitErr != nil {
+		return c.limitErr
+	}
+	l := c.limits
+	switch {
+	case l.MaxSteps > 0 && c.steps > l.MaxSteps:
+		c.exceedLimit("MaxSteps", l.MaxSteps, nil,
+			"evaluation exceeded the maximum of %d steps")
+
+	case l.MaxDepth > 0 && c.depth > l.MaxDepth:
+		c.exceedLimit("MaxDepth", l.MaxDepth, nil,
+			"evaluation exceeded the maximum depth of %d")
+
+	case l.Context != nil:
+		select {
+		case <-l.Context.Done():
+			c.exceedLimit("Context", 0, l.Context.Err(), "evaluation aborted: %v")
+		default:
+		}
+	}
+	return c.limitErr
+}
+
+// exitLimits records the end of unifying v. If a limit was exceeded, v is
+// set to the corresponding error, as its value may be incomplete.
+func (c *OpContext) exitLimits(v *Vertex) {
+	c.depth--
+	if c.limitErr != nil {
+		v.SetValue(c, Finalized, c.limitErr)
+	}
+}
+
+// checkDisjuncts reports the error for exceeding the maximum number of
+// disjuncts, if any.
+func (c *OpContext) checkDisjuncts() *Bottom {
+	if l := c.limits; c.limitErr == nil && l.MaxDisjuncts > 0 &&
+		c.stats.DisjunctCount > l.MaxDisjuncts {
+		c.exceedLimit("MaxDisjuncts", l.MaxDisjuncts, nil,
+			"evaluation exceeded the maximum of %d disjuncts")
+	}
+	return c.limitErr
+}
+
+func (c *OpContext) exceedLimit(limit string, max int, err error, format string) {
+	arg := interface{}(max)
+	if err != nil {
+		arg = err
+	}
+	c.limitErr = &Bottom{
+		Code: EvalError,
+		Err: &LimitError{
+			Limit:      limit,
+			Max:        max,
+			Err:        err,
+			ValueError: c.Newf(format, arg),
+		},
+	}
+}
+
+// Limits returns the limits of evaluation, or nil if there are none.
+func (c *OpContext) Limits() *Limits {
+	return c.limits
+}
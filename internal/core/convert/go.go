@@ -44,7 +44,26 @@ import (
 // optimized.
 
 func GoValueToValue(ctx *adt.OpContext, x interface{}, nilIsTop bool) adt.Value {
-	v := GoValueToExpr(ctx, nilIsTop, x)
+	return GoValueToValueWith(ctx, x, Options{NilIsTop: nilIsTop})
+}
+
+// Options defines how Go values are converted to CUE.
+type Options struct {
+	// NilIsTop converts nil values to top (_) instead of null.
+	NilIsTop bool
+
+	// OmitZero omits struct fields with a zero value, as if all fields were
+	// tagged with omitempty.
+	OmitZero bool
+
+	// Adapters maps Go types to functions that convert values of that type
+	// to another Go value, which is then converted in its place.
+	Adapters map[reflect.Type]func(x interface{}) (interface{}, error)
+}
+
+// GoValueToValueWith converts x to a CUE value using the given options.
+func GoValueToValueWith(ctx *adt.OpContext, x interface{}, o Options) adt.Value {
+	v := goValueToExpr(ctx, o, x)
 	// TODO: return Value
 	return toValue(v)
 }
@@ -207,7 +226,11 @@ func isZero(v reflect.Value) bool {
 }
 
 func GoValueToExpr(ctx *adt.OpContext, nilIsTop bool, x interface{}) adt.Expr {
-	e := convertRec(ctx, nilIsTop, x)
+	return goValueToExpr(ctx, Options{NilIsTop: nilIsTop}, x)
+}
+
+func goValueToExpr(ctx *adt.OpContext, o Options, x interface{}) adt.Expr {
+	e := convertRec(ctx, o, x)
 	if e == nil {
 		return ctx.AddErrf("unsupported Go type (%T)", x)
 	}
@@ -223,7 +246,14 @@ func isNil(x reflect.Value) bool {
 	return false
 }
 
-func convertRec(ctx *adt.OpContext, nilIsTop bool, x interface{}) adt.Value {
+func convertRec(ctx *adt.OpContext, o Options, x interface{}) adt.Value {
+	if f := o.Adapters[reflect.TypeOf(x)]; f != nil {
+		y, err := f(x)
+		if err != nil {
+			return ctx.AddErr(errors.Promote(err, "adapter"))
+		}
+		x = y
+	}
 	if t := (&types.Value{}); types.CastValue(t, x) {
 		// TODO: panic if nto the same runtime.
 		return t.V
@@ -231,7 +261,7 @@ func convertRec(ctx *adt.OpContext, nilIsTop bool, x interface{}) adt.Value {
 	src := ctx.Source()
 	switch v := x.(type) {
 	case nil:
-		if nilIsTop {
+		if o.NilIsTop {
 			ident, _ := ctx.Source().(*ast.Ident)
 			return &adt.Top{Src: ident}
 		}
@@ -364,7 +394,7 @@ func convertRec(ctx *adt.OpContext, nilIsTop bool, x interface{}) adt.Value {
 
 	case reflect.Value:
 		if v.CanInterface() {
-			return convertRec(ctx, nilIsTop, v.Interface())
+			return convertRec(ctx, o, v.Interface())
 		}
 
 	default:
@@ -391,17 +421,17 @@ func convertRec(ctx *adt.OpContext, nilIsTop bool, x interface{}) adt.Value {
 			return toUint(ctx, value.Uint())
 
 		case reflect.Float32, reflect.Float64:
-			return convertRec(ctx, nilIsTop, value.Float())
+			return convertRec(ctx, o, value.Float())
 
 		case reflect.Ptr:
 			if value.IsNil() {
-				if nilIsTop {
+				if o.NilIsTop {
 					ident, _ := ctx.Source().(*ast.Ident)
 					return &adt.Top{Src: ident}
 				}
 				return &adt.Null{Src: ctx.Source()}
 			}
-			return convertRec(ctx, nilIsTop, value.Elem().Interface())
+			return convertRec(ctx, o, value.Elem().Interface())
 
 		case reflect.Struct:
 			obj := &adt.StructLit{Src: src}
@@ -420,16 +450,16 @@ func convertRec(ctx *adt.OpContext, nilIsTop bool, x interface{}) adt.Value {
 					continue
 				}
 				val := value.Field(i)
-				if !nilIsTop && isNil(val) {
+				if !o.NilIsTop && isNil(val) {
 					continue
 				}
 				if tag, _ := sf.Tag.Lookup("json"); tag == "-" {
 					continue
 				}
-				if isOmitEmpty(&sf) && isZero(val) {
+				if (o.OmitZero || isOmitEmpty(&sf)) && isZero(val) {
 					continue
 				}
-				sub := convertRec(ctx, nilIsTop, val.Interface())
+				sub := convertRec(ctx, o, val.Interface())
 				if sub == nil {
 					// mimic behavior of encoding/json: skip fields of unsupported types
 					continue
@@ -496,7 +526,7 @@ func convertRec(ctx *adt.OpContext, nilIsTop bool, x interface{}) adt.Value {
 					// 	continue
 					// }
 
-					sub := convertRec(ctx, nilIsTop, val.Interface())
+					sub := convertRec(ctx, o, val.Interface())
 					// mimic behavior of encoding/json: report error of
 					// unsupported type.
 					if sub == nil {
@@ -529,7 +559,7 @@ func convertRec(ctx *adt.OpContext, nilIsTop bool, x interface{}) adt.Value {
 
 			for i := 0; i < value.Len(); i++ {
 				val := value.Index(i)
-				x := convertRec(ctx, nilIsTop, val.Interface())
+				x := convertRec(ctx, o, val.Interface())
 				if x == nil {
 					return ctx.AddErrf("unsupported Go type (%T)",
 						val.Interface())
@@ -558,6 +588,18 @@ func toUint(ctx *adt.OpContext, x uint64) adt.Value {
 	return n
 }
 
+// SetTypeSchema sets the schema used for the Go type t, overriding the schema
+// that would otherwise be derived from t. It only affects conversions of types
+// that have not been converted before.
+func SetTypeSchema(ctx *adt.OpContext, t reflect.Type, schema ast.Expr) errors.Error {
+	c, err := compile.Expr(nil, ctx, pkgID(), schema)
+	if err != nil {
+		return err
+	}
+	ctx.StoreType(t, schema, c.Expr())
+	return nil
+}
+
 func convertGoType(ctx *adt.OpContext, t reflect.Type) adt.Expr {
 	// TODO: this can be much more efficient.
 	// TODO: synchronize
@@ -374,7 +374,7 @@ func (e *exporter) decl(d adt.Decl) ast.Decl {
 	case *adt.Field:
 		e.setDocs(x)
 		f := &ast.Field{
-			Label: e.stringLabel(x.Label),
+			Label: e.fieldLabel(x.Label, x.Src),
 		}
 
 		frame := e.frame(0)
@@ -391,7 +391,7 @@ func (e *exporter) decl(d adt.Decl) ast.Decl {
 	case *adt.OptionalField:
 		e.setDocs(x)
 		f := &ast.Field{
-			Label:    e.stringLabel(x.Label),
+			Label:    e.fieldLabel(x.Label, x.Src),
 			Optional: token.NoSpace.Pos(),
 		}
 
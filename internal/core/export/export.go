@@ -50,6 +50,33 @@ type Profile struct {
 
 	// ShowErrors treats errors as values and will not percolate errors up.
 	ShowErrors bool
+
+	// KindComments annotates fields with non-concrete values with a line
+	// comment listing the kinds the value may assume.
+	KindComments bool
+
+	// ResolveBuiltins replaces calls to builtin functions with their result
+	// when exporting a definition. Only calls with self-contained arguments
+	// and a concrete result are replaced. Such calls are exported as is by
+	// default.
+	ResolveBuiltins bool
+
+	// KeepBuiltins retains calls to builtin functions, if their arguments are
+	// self-contained, when exporting a value. The result of such calls is
+	// exported by default.
+	KeepBuiltins bool
+
+	// PreserveLabelStyle retains the quotes of regular labels that were
+	// quoted in the source, even if they are valid identifiers.
+	PreserveLabelStyle bool
+
+	// SortFields orders the fields of structs by label rather than by the
+	// order in which they were declared. Regular fields come first, followed
+	// by definitions and hidden fields, each sorted by name. Unlike the
+	// declaration order, this order does not depend on how a configuration is
+	// split across files or the order in which it is evaluated.
+	SortFields bool
+
 	// Use unevaluated conjuncts for these error types
 	// IgnoreRecursive
 
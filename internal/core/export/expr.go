@@ -17,6 +17,7 @@ package export
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/token"
@@ -77,6 +78,21 @@ func (e *exporter) expr(v adt.Expr) (result ast.Expr) {
 	}
 }
 
+// addKindComment adds a line comment to f listing the kinds of the value of
+// arc if it is not concrete.
+func addKindComment(f *ast.Field, arc *adt.Vertex) {
+	if arc.Default().IsConcrete() {
+		return
+	}
+	k := arc.Kind().String()
+	k = strings.TrimSuffix(strings.TrimPrefix(k, "("), ")")
+	ast.AddComment(f, &ast.CommentGroup{
+		Line:     true,
+		Position: 4,
+		List:     []*ast.Comment{{Text: "// " + k}},
+	})
+}
+
 // Piece out values:
 
 // For a struct, piece out conjuncts that are already values. Those can be
@@ -166,6 +182,9 @@ func (x *exporter) mergeValues(label adt.Feature, src *adt.Vertex, a []conjunct,
 		}
 		return m[fields[i]] > m[fields[j]]
 	})
+	if x.cfg.SortFields {
+		SortFeatures(x.ctx, fields)
+	}
 
 	if len(e.fields) == 0 && !e.hasEllipsis {
 		switch len(e.embed) + len(e.conjuncts) {
@@ -209,17 +228,19 @@ func (x *exporter) mergeValues(label adt.Feature, src *adt.Vertex, a []conjunct,
 		field := e.fields[f]
 		c := field.conjuncts
 
-		label := e.stringLabel(f)
-
 		if f.IsDef() {
 			x.inDefinition++
 		}
 
 		a := []adt.Conjunct{}
+		srcs := []ast.Node{}
 		for _, cc := range c {
 			a = append(a, cc.c)
+			srcs = append(srcs, cc.c.Source())
 		}
 
+		label := e.fieldLabel(f, srcs...)
+
 		d := &ast.Field{Label: label}
 
 		top := e.frame(0)
@@ -247,6 +268,9 @@ func (x *exporter) mergeValues(label adt.Feature, src *adt.Vertex, a []conjunct,
 				d.Attrs = extractFieldAttrs(d.Attrs, c)
 			}
 		}
+		if x.cfg.KindComments && field.arc != nil {
+			addKindComment(d, field.arc)
+		}
 		s.Elts = append(s.Elts, d)
 	}
 	if e.hasEllipsis {
@@ -308,6 +332,12 @@ type conjunct struct {
 }
 
 func (e *conjuncts) addExpr(env *adt.Environment, src *adt.Vertex, x adt.Expr, isEmbed bool) {
+	if call, ok := x.(*adt.CallExpr); ok && e.cfg.ResolveBuiltins {
+		if v := e.resolveCall(env, call); v != nil {
+			x = v
+		}
+	}
+
 	switch x := x.(type) {
 	case *adt.StructLit:
 		e.top().upCount++
@@ -412,6 +442,31 @@ func (e *conjuncts) addExpr(env *adt.Environment, src *adt.Vertex, x adt.Expr, i
 	}
 }
 
+// resolveCall evaluates a call to a builtin. It returns nil if the arguments
+// of the call are not self-contained or if the result is not concrete.
+func (e *exporter) resolveCall(env *adt.Environment, x *adt.CallExpr) adt.Value {
+	if !hasSelfContainedArgs(x) {
+		return nil
+	}
+	v, complete := e.ctx.Evaluate(env, x)
+	if !complete || !adt.IsConcrete(v) {
+		return nil
+	}
+	if _, ok := v.(*adt.Bottom); ok {
+		return nil
+	}
+	return v
+}
+
+func hasSelfContainedArgs(x *adt.CallExpr) bool {
+	for _, a := range x.Args {
+		if !isSelfContained(a) {
+			return false
+		}
+	}
+	return true
+}
+
 func isTop(x adt.BaseValue) bool {
 	switch v := x.(type) {
 	case *adt.Top:
@@ -46,3 +46,22 @@ func (e *exporter) stringLabel(f adt.Feature) ast.Label {
 		return ast.NewIdent(e.ctx.IndexToString(int64(x)))
 	}
 }
+
+// fieldLabel is like stringLabel, but retains the quotes of a label that was
+// quoted in the first of the given sources that is a field, if the profile
+// preserves label styles.
+func (e *exporter) fieldLabel(f adt.Feature, srcs ...ast.Node) ast.Label {
+	if e.cfg.PreserveLabelStyle && f.Typ() == adt.StringLabel {
+		for _, src := range srcs {
+			x, ok := src.(*ast.Field)
+			if !ok || x == nil {
+				continue
+			}
+			if lit, ok := x.Label.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				return ast.NewLit(token.STRING, lit.Value)
+			}
+			break
+		}
+	}
+	return e.stringLabel(f)
+}
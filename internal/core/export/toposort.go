@@ -47,6 +47,35 @@ func VertexFeatures(v *adt.Vertex) []adt.Feature {
 	return sortedArcs(sets)
 }
 
+// SortFeatures sorts a in the order used by Profile.SortFields: regular
+// fields first, followed by definitions and hidden fields, each sorted by
+// name. Integer labels are sorted by index before all other labels.
+func SortFeatures(index adt.StringIndexer, a []adt.Feature) {
+	sort.SliceStable(a, func(i, j int) bool {
+		x, y := a[i], a[j]
+		if gx, gy := labelGroup(x), labelGroup(y); gx != gy {
+			return gx < gy
+		}
+		if x.IsInt() {
+			return x.Index() < y.Index()
+		}
+		return x.IdentString(index) < y.IdentString(index)
+	})
+}
+
+func labelGroup(f adt.Feature) int {
+	switch f.Typ() {
+	case adt.IntLabel:
+		return 0
+	case adt.StringLabel:
+		return 1
+	case adt.DefinitionLabel:
+		return 2
+	default:
+		return 3
+	}
+}
+
 // func structFeatures(a []*adt.StructLit) []adt.Feature {
 // 	sets := extractFeatures(a)
 // 	return sortedArcs(sets)
@@ -383,7 +383,11 @@ func (e *exporter) structComposite(v *adt.Vertex, attrs []*ast.Attribute) ast.Ex
 	}
 
 	p := e.cfg
-	for _, label := range VertexFeatures(v) {
+	features := VertexFeatures(v)
+	if p.SortFields {
+		SortFeatures(e.ctx, features)
+	}
+	for _, label := range features {
 		show := false
 		switch label.Typ() {
 		case adt.StringLabel:
@@ -428,6 +432,12 @@ func (e *exporter) structComposite(v *adt.Vertex, attrs []*ast.Attribute) ast.Ex
 			// f.Value = e.expr(arc)
 
 		default:
+			if p.KeepBuiltins {
+				if x := builtinCall(arc); x != nil {
+					f.Value = e.expr(x)
+					break
+				}
+			}
 			f.Value = e.vertex(arc)
 		}
 
@@ -435,6 +445,14 @@ func (e *exporter) structComposite(v *adt.Vertex, attrs []*ast.Attribute) ast.Ex
 			e.inDefinition--
 		}
 
+		if p.PreserveLabelStyle {
+			srcs := []ast.Node{}
+			for _, c := range arc.Conjuncts {
+				srcs = append(srcs, c.Source())
+			}
+			f.Label = e.fieldLabel(label, srcs...)
+		}
+
 		if p.ShowAttributes {
 			f.Attrs = ExtractFieldAttrs(arc)
 		}
@@ -449,3 +467,16 @@ func (e *exporter) structComposite(v *adt.Vertex, attrs []*ast.Attribute) ast.Ex
 
 	return s
 }
+
+// builtinCall returns the call to a builtin function that defines v, if v is
+// defined by a single such call with self-contained arguments.
+func builtinCall(v *adt.Vertex) *adt.CallExpr {
+	if len(v.Conjuncts) != 1 {
+		return nil
+	}
+	x, ok := v.Conjuncts[0].Expr().(*adt.CallExpr)
+	if !ok || !hasSelfContainedArgs(x) {
+		return nil
+	}
+	return x
+}
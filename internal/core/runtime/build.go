@@ -76,6 +76,9 @@ func (x *Runtime) Build(cfg *Config, b *build.Instance) (v *adt.Vertex, errs err
 	}
 	v, err = compile.Files(cc, x, b.ID(), b.Files...)
 	errs = errors.Append(errs, err)
+	if err == nil {
+		errs = errors.Append(errs, x.injectExterns(b, v))
+	}
 
 	if errs != nil {
 		v = adt.ToVertex(&adt.Bottom{Err: errs})
@@ -0,0 +1,171 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal"
+	"cuelang.org/go/internal/core/adt"
+)
+
+// An Interpreter provides the implementation of fields marked with an @extern
+// attribute. A file selects an interpreter with a file-level attribute
+// @extern(kind), where kind is the Kind of the interpreter, after which the
+// fields of the file with an @extern attribute are implemented by the
+// interpreter. For instance,
+//
+//	@extern("wasm")
+//
+//	package p
+//
+//	#add: _ @extern("add.wasm", sig="func(int64, int64): int64")
+type Interpreter interface {
+	// Kind reports the kind of the interpreter as used in file attributes.
+	Kind() string
+
+	// NewCompiler returns a Compiler for the fields of the given instance.
+	NewCompiler(b *build.Instance) (Compiler, errors.Error)
+}
+
+// A Compiler implements the fields of an instance marked with an @extern
+// attribute.
+type Compiler interface {
+	// Compile returns the value of the field with the given name, which is
+	// marked with the @extern attribute a at position pos. The value is
+	// typically a builtin function.
+	Compile(name string, pos token.Pos, a *internal.Attr) (adt.Expr, errors.Error)
+}
+
+// SetInterpreter registers i to implement the fields of files selecting the
+// kind of i.
+func (r *Runtime) SetInterpreter(i Interpreter) {
+	if r.interpreters == nil {
+		r.interpreters = map[string]Interpreter{}
+	}
+	r.interpreters[i.Kind()] = i
+}
+
+// injectExterns replaces the values of fields marked with an @extern attribute
+// in the files of b, which were compiled into v, with their implementation.
+func (r *Runtime) injectExterns(b *build.Instance, v *adt.Vertex) (errs errors.Error) {
+	compilers := map[string]Compiler{}
+	for _, conj := range v.Conjuncts {
+		s, ok := conj.Expr().(*adt.StructLit)
+		if !ok {
+			continue
+		}
+		f, ok := s.Src.(*ast.File)
+		if !ok {
+			continue
+		}
+		a := fileExternAttr(f)
+		if a == nil {
+			continue
+		}
+		attr := internal.ParseAttrBody(a.Pos(), body(a))
+		kind, err := attr.String(0)
+		if err != nil || kind == "" {
+			errs = errors.Append(errs, errors.Newf(a.Pos(),
+				"invalid @extern attribute: missing interpreter kind"))
+			continue
+		}
+		c, ok := compilers[kind]
+		if !ok {
+			i := r.interpreters[kind]
+			if i == nil {
+				errs = errors.Append(errs, errors.Newf(a.Pos(),
+					"no interpreter defined for %q", kind))
+				continue
+			}
+			var err errors.Error
+			if c, err = i.NewCompiler(b); err != nil {
+				errs = errors.Append(errs, err)
+				continue
+			}
+			compilers[kind] = c
+		}
+		errs = errors.Append(errs, injectStruct(c, s))
+	}
+	return errs
+}
+
+// injectStruct replaces the values of the fields of s marked with an @extern
+// attribute, recursively.
+func injectStruct(c Compiler, s *adt.StructLit) (errs errors.Error) {
+	for _, d := range s.Decls {
+		var src *ast.Field
+		var value *adt.Expr
+		switch f := d.(type) {
+		case *adt.Field:
+			src, value = f.Src, &f.Value
+		case *adt.OptionalField:
+			src, value = f.Src, &f.Value
+		default:
+			continue
+		}
+		if x, ok := (*value).(*adt.StructLit); ok {
+			errs = errors.Append(errs, injectStruct(c, x))
+		}
+		a := externAttr(src.Attrs)
+		if a == nil {
+			continue
+		}
+		name, _, _ := ast.LabelName(src.Label)
+		attr := internal.ParseAttrBody(a.Pos(), body(a))
+		if attr.Err != nil {
+			errs = errors.Append(errs, errors.Promote(attr.Err, "invalid @extern attribute"))
+			continue
+		}
+		x, err := c.Compile(name, a.Pos(), &attr)
+		if err != nil {
+			errs = errors.Append(errs, err)
+			continue
+		}
+		*value = x
+	}
+	return errs
+}
+
+// externAttr returns the first @extern attribute of a, or nil if there is
+// none.
+func externAttr(a []*ast.Attribute) *ast.Attribute {
+	for _, x := range a {
+		if k, _ := x.Split(); k == "extern" {
+			return x
+		}
+	}
+	return nil
+}
+
+// fileExternAttr returns the first file-level @extern attribute of f, or nil
+// if there is none.
+func fileExternAttr(f *ast.File) *ast.Attribute {
+	for _, d := range f.Decls {
+		if x, ok := d.(*ast.Attribute); ok {
+			if k, _ := x.Split(); k == "extern" {
+				return x
+			}
+		}
+	}
+	return nil
+}
+
+func body(a *ast.Attribute) string {
+	_, s := a.Split()
+	return s
+}
@@ -16,6 +16,7 @@ package runtime
 
 import (
 	"path"
+	"sort"
 	"sync"
 
 	"cuelang.org/go/cue/build"
@@ -42,14 +43,61 @@ func (x *index) RegisterBuiltin(importPath string, f PackageFunc) {
 	x.builtinShort[base] = importPath
 }
 
+// RegisterBuiltin registers a builtin package that is only available to
+// values created with r, in addition to the builtin packages registered with
+// the package-level RegisterBuiltin.
+func (r *Runtime) RegisterBuiltin(importPath string, f PackageFunc) {
+	if r.builtins == nil {
+		r.builtins = map[string]PackageFunc{}
+		r.builtinShort = map[string]string{}
+		r.builtinLoaded = map[string]*adt.Vertex{}
+	}
+	r.builtins[importPath] = f
+	base := path.Base(importPath)
+	_, shared := r.index.builtinShort[base]
+	if _, ok := r.builtinShort[base]; ok || shared {
+		importPath = "" // Don't allow ambiguous base paths.
+	}
+	r.builtinShort[base] = importPath
+}
+
+// isBuiltin reports whether importPath is the path of a builtin package.
+func (r *Runtime) isBuiltin(importPath string) bool {
+	if r == nil {
+		return false
+	}
+	if _, ok := r.builtins[importPath]; ok {
+		return true
+	}
+	_, ok := r.index.builtinPaths[importPath]
+	return ok
+}
+
 var SharedRuntime = &Runtime{index: sharedIndex}
 
 // BuiltinPackagePath converts a short-form builtin package identifier to its
 // full path or "" if this doesn't exist.
 func (x *Runtime) BuiltinPackagePath(path string) string {
+	if p, ok := x.builtinShort[path]; ok {
+		return p
+	}
 	return x.index.shortBuiltinToPath(path)
 }
 
+// BuiltinPackages returns the import paths of all builtin packages in
+// increasing order.
+func (x *Runtime) BuiltinPackages() []string {
+	a := make([]string, 0, len(x.index.builtinPaths)+len(x.builtins))
+	for p := range x.index.builtinPaths {
+		a = append(a, p)
+	}
+	for p := range x.builtins {
+		a = append(a, p)
+	}
+	sort.Strings(a)
+	return a
+}
+
 // sharedIndex is used for indexing builtins and any other labels common to
 // all instances.
 var sharedIndex = newIndex()
@@ -108,6 +156,26 @@ func (r *Runtime) getNodeFromInstance(key *build.Instance) *adt.Vertex {
 func (r *Runtime) LoadImport(importPath string) (*adt.Vertex, errors.Error) {
 	x := r.index
 
+	if f := r.builtins[importPath]; f != nil {
+		// Builtins of r are not added to importsByPath, as the index is
+		// shared by all runtimes.
+		if p := r.builtinLoaded[importPath]; p != nil {
+			return p, nil
+		}
+		p, err := f(r)
+		if err != nil {
+			return adt.ToVertex(&adt.Bottom{Err: err}), nil
+		}
+		inst := &build.Instance{
+			ImportPath: importPath,
+			PkgName:    path.Base(importPath),
+		}
+		x.imports[p] = inst
+		x.importsByBuild[inst] = p
+		r.builtinLoaded[importPath] = p
+		return p, nil
+	}
+
 	key := x.importsByPath[importPath]
 	if key != nil {
 		return key, nil
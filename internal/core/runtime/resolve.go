@@ -26,8 +26,6 @@ import (
 
 // TODO(resolve): this is also done in compile, do we need both?
 func (r *Runtime) ResolveFiles(p *build.Instance) (errs errors.Error) {
-	idx := r.index
-
 	// Link top-level declarations. As top-level entries get unified, an entry
 	// may be linked to any top-level entry of any of the files.
 	allFields := map[string]ast.Node{}
@@ -47,14 +45,14 @@ func (r *Runtime) ResolveFiles(p *build.Instance) (errs errors.Error) {
 		if p := internal.GetPackageInfo(f); p.IsAnonymous() {
 			continue
 		}
-		err := resolveFile(idx, f, p, allFields)
+		err := resolveFile(r, f, p, allFields)
 		errs = errors.Append(errs, err)
 	}
 	return errs
 }
 
 func resolveFile(
-	idx *index,
+	r *Runtime,
 	f *ast.File,
 	p *build.Instance,
 	allFields map[string]ast.Node,
@@ -83,7 +81,7 @@ func resolveFile(
 		name := path.Base(id)
 		if imp := p.LookupImport(id); imp != nil {
 			name = imp.PkgName
-		} else if _, ok := idx.builtinPaths[id]; !ok {
+		} else if !r.isBuiltin(id) {
 			errs = errors.Append(errs,
 				nodeErrorf(spec, "package %q not found", id))
 			continue
@@ -94,7 +92,7 @@ func resolveFile(
 		if n, ok := fields[name]; ok {
 			errs = errors.Append(errs, nodeErrorf(spec,
 				"%s redeclared as imported package name\n"+
-					"\tprevious declaration at %v", name, lineStr(idx, n)))
+					"\tprevious declaration at %v", name, lineStr(n)))
 			continue
 		}
 		fields[name] = spec
@@ -164,6 +162,6 @@ func resolveFile(
 	return errs
 }
 
-func lineStr(idx *index, n ast.Node) string {
+func lineStr(n ast.Node) string {
 	return n.Pos().String()
 }
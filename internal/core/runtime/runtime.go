@@ -16,6 +16,7 @@ package runtime
 
 import (
 	"cuelang.org/go/cue/build"
+	"cuelang.org/go/internal/core/adt"
 )
 
 // A Runtime maintains data structures for indexing and resuse for evaluation.
@@ -23,6 +24,38 @@ type Runtime struct {
 	index *index
 
 	loaded map[*build.Instance]interface{}
+
+	instrument adt.Instrumenter
+	limits     *adt.Limits
+
+	interpreters map[string]Interpreter
+
+	// builtins holds the builtin packages registered with r only, along with
+	// their short names and loaded values.
+	builtins      map[string]PackageFunc
+	builtinShort  map[string]string
+	builtinLoaded map[string]*adt.Vertex
+}
+
+// SetInstrumenter sets the Instrumenter that receives the evaluation events
+// of all values created with r.
+func (r *Runtime) SetInstrumenter(i adt.Instrumenter) {
+	r.instrument = i
+}
+
+// Instrumenter returns the Instrumenter set for r, or nil if there is none.
+func (r *Runtime) Instrumenter() adt.Instrumenter {
+	return r.instrument
+}
+
+// SetLimits sets the limits for the evaluation of all values created with r.
+func (r *Runtime) SetLimits(l *adt.Limits) {
+	r.limits = l
+}
+
+// Limits returns the limits set for r, or nil if there are none.
+func (r *Runtime) Limits() *adt.Limits {
+	return r.limits
 }
 
 func (r *Runtime) SetBuildData(b *build.Instance, x interface{}) {
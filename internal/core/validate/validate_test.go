@@ -181,6 +181,18 @@ y: conflicting values 4 and 2:
 			}
 			`,
 		out: "incomplete\nx.a: incomplete value 1 | 2",
+	}, {
+		desc: "error at arbitrary depth",
+		in: `
+		a: b: c: d: e: f: g: h: i: j: k: l: m: n: o: p: q: r: s: t: u: v: w: x: 1 & 2
+		`,
+		out: "eval\na.b.c.d.e.f.g.h.i.j.k.l.m.n.o.p.q.r.s.t.u.v.w.x: conflicting values 2 and 1:\n    test:2:75\n    test:2:79",
+	}, {
+		desc: "structural cycle",
+		in: `
+		a: b: a
+		`,
+		out: "structural cycle\na.b: structural cycle",
 	}}
 
 	r := runtime.New()
@@ -22,6 +22,10 @@ import (
 // Profile configures a diff operation.
 type Profile struct {
 	Concrete bool
+
+	// Regular restricts the comparison of structs to regular fields: hidden
+	// fields, definitions, and optional fields are ignored.
+	Regular bool
 }
 
 var (
@@ -77,6 +81,17 @@ func (es *EditScript) Len() int {
 	return len(es.edits)
 }
 
+// X returns the value from which the edits of es transform.
+func (es *EditScript) X() cue.Value { return es.x }
+
+// Y returns the value into which the edits of es transform.
+func (es *EditScript) Y() cue.Value { return es.y }
+
+// Edit returns the edit at step i.
+func (es *EditScript) Edit(i int) Edit {
+	return es.edits[i]
+}
+
 // Label returns a string representation of the label.
 //
 func (es *EditScript) LabelX(i int) string {
@@ -116,28 +131,27 @@ func label(v cue.Value, i int) string {
 
 // ValueX returns the value of X involved at step i.
 func (es *EditScript) ValueX(i int) (v cue.Value) {
-	p := es.edits[i].XPos()
-	if p < 0 {
-		return v
-	}
-	st, err := es.x.Struct()
-	if err != nil {
-		return v
-	}
-	return st.Field(p).Value
+	return element(es.x, es.edits[i].XPos())
 }
 
 // ValueY returns the value of Y involved at step i.
 func (es *EditScript) ValueY(i int) (v cue.Value) {
-	p := es.edits[i].YPos()
-	if p < 0 {
-		return v
+	return element(es.y, es.edits[i].YPos())
+}
+
+// element returns the field or list element of v at position i.
+func element(v cue.Value, i int) (x cue.Value) {
+	if i < 0 {
+		return x
+	}
+	if v.Kind() == cue.ListKind {
+		return v.LookupPath(cue.MakePath(cue.Index(i)))
 	}
-	st, err := es.y.Struct()
+	st, err := v.Struct()
 	if err != nil {
-		return v
+		return x
 	}
-	return st.Field(p).Value
+	return st.Field(i).Value
 }
 
 // Edit represents a single operation within an edit-script.
@@ -152,6 +166,10 @@ func (e Edit) Kind() Kind { return e.kind }
 func (e Edit) XPos() int  { return int(e.xPos - 1) }
 func (e Edit) YPos() int  { return int(e.yPos - 1) }
 
+// Sub returns the edit script for the values of a Modified edit if they are
+// both structs or both lists, or nil otherwise.
+func (e Edit) Sub() *EditScript { return e.sub }
+
 type differ struct {
 	cfg     Profile
 	options []cue.Option
@@ -208,10 +226,14 @@ func (d *differ) diffStruct(x, y cue.Value) (Kind, *EditScript) {
 	xMap := make(map[string]int32, sx.Len())
 	yMap := make(map[string]int32, sy.Len())
 	for i := 0; i < sx.Len(); i++ {
-		xMap[sx.Field(i).Selector] = int32(i + 1)
+		if f := sx.Field(i); !d.skip(f) {
+			xMap[f.Selector] = int32(i + 1)
+		}
 	}
 	for i := 0; i < sy.Len(); i++ {
-		yMap[sy.Field(i).Selector] = int32(i + 1)
+		if f := sy.Field(i); !d.skip(f) {
+			yMap[f.Selector] = int32(i + 1)
+		}
 	}
 
 	edits := []Edit{}
@@ -223,6 +245,9 @@ func (d *differ) diffStruct(x, y cue.Value) (Kind, *EditScript) {
 		// Process zero nodes
 		for ; xi < sx.Len(); xi++ {
 			xf = sx.Field(xi)
+			if d.skip(xf) {
+				continue
+			}
 			yp := yMap[xf.Selector]
 			if yp > 0 {
 				break
@@ -284,6 +309,12 @@ func (d *differ) diffStruct(x, y cue.Value) (Kind, *EditScript) {
 	return Modified, &EditScript{x: x, y: y, edits: edits}
 }
 
+// skip reports whether field f is excluded from the comparison. Skipped fields
+// are not included in the field maps of diffStruct.
+func (d *differ) skip(f cue.FieldInfo) bool {
+	return d.cfg.Regular && (f.IsHidden || f.IsDefinition || f.IsOptional)
+}
+
 // TODO: right now we do a simple element-by-element comparison. Instead,
 // use an algorithm that approximates a minimal Levenshtein distance, like the
 // one in github.com/google/go-cmp/internal/diff.
@@ -25,16 +25,21 @@ import (
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/ast/astutil"
 	"cuelang.org/go/cue/build"
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/format"
 	"cuelang.org/go/cue/token"
+	"cuelang.org/go/encoding/crd"
+	cuejson "cuelang.org/go/encoding/json"
+	"cuelang.org/go/encoding/jsonschema"
 	"cuelang.org/go/encoding/openapi"
 	"cuelang.org/go/encoding/protobuf/jsonpb"
 	"cuelang.org/go/encoding/protobuf/textproto"
+	"cuelang.org/go/encoding/xml"
 	"cuelang.org/go/internal"
 	"cuelang.org/go/internal/filetypes"
-	"cuelang.org/go/pkg/encoding/yaml"
+	"cuelang.org/go/internal/encoding/yaml"
 )
 
 // An Encoder converts CUE to various file formats, including CUE itself.
@@ -77,6 +82,11 @@ func NewEncoder(f *build.File, cfg *Config) (*Encoder, error) {
 		close: close,
 	}
 
+	var valueOpts []cue.Option
+	if cfg.SortFields {
+		valueOpts = append(valueOpts, cue.SortFields())
+	}
+
 	switch f.Interpretation {
 	case "":
 	case build.OpenAPI:
@@ -95,12 +105,23 @@ func NewEncoder(f *build.File, cfg *Config) (*Encoder, error) {
 			return f, jsonpb.NewEncoder(v).RewriteFile(f)
 		}
 
-	// case build.JSONSchema:
-	// 	// TODO: get encoding options
-	// 	cfg := openapi.Config{}
-	// 	i.interpret = func(inst *cue.Instance) (*ast.File, error) {
-	// 		return jsonschmea.Generate(inst, cfg)
-	// 	}
+	case build.CRD:
+		e.interpret = func(v cue.Value) (*ast.File, error) {
+			return crd.GenerateAll(v, nil)
+		}
+
+	case build.JSONSchema:
+		e.interpret = func(v cue.Value) (*ast.File, error) {
+			b, err := jsonschema.Generate(v)
+			if err != nil {
+				return nil, err
+			}
+			expr, err := cuejson.Extract("jsonschema", b)
+			if err != nil {
+				return nil, err
+			}
+			return astutil.ToFile(expr)
+		}
 	default:
 		return nil, fmt.Errorf("unsupported interpretation %q", f.Interpretation)
 	}
@@ -127,6 +148,7 @@ func NewEncoder(f *build.File, cfg *Config) (*Encoder, error) {
 			cue.ResolveReferences(!fi.References),
 			cue.DisallowCycles(!fi.Cycles),
 		)
+		synOpts = append(synOpts, valueOpts...)
 
 		opts := []format.Option{}
 		opts = append(opts, cfg.Format...)
@@ -166,27 +188,58 @@ func NewEncoder(f *build.File, cfg *Config) (*Encoder, error) {
 		d.SetIndent("", "    ")
 		d.SetEscapeHTML(cfg.EscapeHTML)
 		e.encValue = func(v cue.Value) error {
-			err := d.Encode(v)
-			if x, ok := err.(*json.MarshalerError); ok {
-				err = x.Err
+			b, err := v.MarshalJSONWith(valueOpts...)
+			if err != nil {
+				return err
 			}
-			return err
+			return d.Encode(json.RawMessage(b))
 		}
 
 	case build.YAML:
 		e.concrete = true
 		streamed := false
-		e.encValue = func(v cue.Value) error {
+		encode := func(v cue.Value) error {
 			if streamed {
 				fmt.Fprintln(w, "---")
 			}
 			streamed = true
 
-			str, err := yaml.Marshal(v)
+			opts := append([]cue.Option{cue.Final(), cue.Concrete(true)}, valueOpts...)
+			b, err := yaml.Encode(v.Syntax(opts...))
 			if err != nil {
 				return err
 			}
-			_, err = fmt.Fprint(w, str)
+			_, err = w.Write(b)
+			return err
+		}
+		e.encValue = encode
+		if f.Tags["stream"] == "true" {
+			// Write each element of a list as a separate document.
+			e.encValue = func(v cue.Value) error {
+				if v.Kind() != cue.ListKind {
+					return encode(v)
+				}
+				iter, err := v.List()
+				if err != nil {
+					return err
+				}
+				for iter.Next() {
+					if err := encode(iter.Value()); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+		}
+
+	case build.XML:
+		e.concrete = true
+		e.encValue = func(v cue.Value) error {
+			b, err := xml.Encode(v)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(b)
 			return err
 		}
 
@@ -34,12 +34,15 @@ import (
 	"cuelang.org/go/cue/literal"
 	"cuelang.org/go/cue/parser"
 	"cuelang.org/go/cue/token"
+	"cuelang.org/go/encoding/csv"
+	"cuelang.org/go/encoding/hcl"
 	"cuelang.org/go/encoding/json"
 	"cuelang.org/go/encoding/jsonschema"
 	"cuelang.org/go/encoding/openapi"
 	"cuelang.org/go/encoding/protobuf"
 	"cuelang.org/go/encoding/protobuf/jsonpb"
 	"cuelang.org/go/encoding/protobuf/textproto"
+	"cuelang.org/go/encoding/xml"
 	"cuelang.org/go/internal"
 	"cuelang.org/go/internal/filetypes"
 	"cuelang.org/go/internal/third_party/yaml"
@@ -159,6 +162,7 @@ type Config struct {
 	Schema cue.Value // used for schema-based decoding
 
 	EscapeHTML bool
+	SortFields bool // order fields by label instead of declaration order
 	ProtoPath  []string
 	Format     []format.Option
 	ParseFile  func(name string, src interface{}) (*ast.File, error)
@@ -248,6 +252,16 @@ func NewDecoder(f *build.File, cfg *Config) *Decoder {
 		i.err = err
 		i.next = d.Decode
 		i.Next()
+	case build.XML:
+		i.expr, i.err = xml.Extract(path, r, nil)
+	case build.HCL:
+		i.file, i.err = hcl.Extract(path, r)
+	case build.CSV, build.TSV:
+		cfg := &csv.Config{InferTypes: f.Tags["infer"] != "false"}
+		if f.Encoding == build.TSV {
+			cfg.Comma = '\t'
+		}
+		i.expr, i.err = csv.Extract(path, r, cfg)
 	case build.Text:
 		b, err := ioutil.ReadAll(r)
 		i.err = err
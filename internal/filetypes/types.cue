@@ -147,6 +147,11 @@ extensions: {
 	".ndjson":    tags.jsonl
 	".yaml":      tags.yaml
 	".yml":       tags.yaml
+	".xml":       tags.xml
+	".hcl":       tags.hcl
+	".tf":        tags.hcl
+	".csv":       tags.csv
+	".tsv":       tags.tsv
 	".txt":       tags.text
 	".go":        tags.go
 	".proto":     tags.proto
@@ -185,6 +190,16 @@ tags: {
 	jsonl: encoding:     "jsonl"
 	yaml: encoding:      "yaml"
 	proto: encoding:     "proto"
+	xml: encoding:       "xml"
+	hcl: encoding:       "hcl"
+	csv: encoding:       "csv"
+	tsv: encoding:       "tsv"
+
+	// yamlstream writes each element of a list as a separate YAML document.
+	yamlstream: {
+		encoding: "yaml"
+		tags: stream: "true"
+	}
 	textproto: encoding: "textproto"
 	// "binpb":  encodings.binproto
 
@@ -228,6 +243,10 @@ tags: {
 		interpretation: "openapi"
 		encoding:       *"json" | _
 	}
+	crd: {
+		interpretation: "crd"
+		encoding:       *"yaml" | _
+	}
 }
 
 // forms defines schema for all forms. It does not include the form ID.
@@ -304,6 +323,27 @@ encodings: yaml: {
 	stream: false | *true
 }
 
+encodings: xml: {
+	forms.data
+	stream:     false
+	docs:       false
+	attributes: true
+}
+
+encodings: hcl: {
+	forms.data
+	stream: false
+	docs:   false
+}
+
+encodings: csv: {
+	forms.data
+	stream: false
+	docs:   false
+}
+
+encodings: tsv: encodings.csv
+
 encodings: jsonl: {
 	forms.data
 	stream: true
@@ -369,6 +409,11 @@ interpretations: openapi: {
 	encoding: *"json" | _
 }
 
+interpretations: crd: {
+	forms.schema
+	encoding: *"yaml" | _
+}
+
 interpretations: pb: {
 	forms.data
 	stream: true
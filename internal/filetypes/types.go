@@ -41,5 +41,5 @@ func cuegenMake(name string, x interface{}) cue.Value {
 	return v
 }
 
-// Data size: 1713 bytes.
-var cuegenInstanceData = []byte("\x01\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xff\xacX\u074f\u0736\x11\x97\xce.P\x11i\u07da\xb7\x02c\x19\b\u0483\xabE\x1e\x8c\x18\v\x1c\f'\xb6\v\xbf4E\x91>\x05\xc1\x81+\x8dv\xd9H\xa4JR\xc9\x1dr\xfb\xd06M\xfbW{\x8b!\xa9o\xdd\xd9\xd7\xfa^nw~\x9c\xe1\xccp>\xf7W\xa7\u007f\x9f\xc5g\xa7\xffD\xf1\xe9\x1fQ\xf4\xf9\xe9\xef\x0f\xe2\xf8#!\x8d\xe52\u01d7\xdcr\xa2\xc7\x0f\xe2\x87\u007fV\xca\xc6gQ\xfc\xf0O\xdc\x1e\u23e2\xf8\x17\xafE\x85&>\xfd\x1cE\xd1oO\xff:\x8b\xe3_\u007f\xf3m\xdebV\x8a*p\xfe\x1c\u0167\x9f\xa2\xe8\xd3\xd3?\x1f\xc4\xf1/\a\xfaOQ|\x16?\xfc#\xaf\x91\x04=tD\x16E\xd1\u06cf3\xd2$\x8e\xcf\xe28\xb1\xd7\r\x9a,o1~\xfb\xf1o\x1a\x9e\u007f\xc7\xf7\b\xbbVT\x05c\x9b\r\xbc\x00\xba\x1fr\xa55\x9aF\xc9\u0080U\xc0\xe1\x0f\xca\x1f\xca\b\xce\xd8c\xfa\xb7\x85\x1fYB\xd7K^\xe3\x16\u009f\xb1Z\xc8=KP\xe6\xaa\x10r\xdf\x03\x8f_\x05\nK\x84\xb4\xa8\x1b\x8d\x96[\xa1\xe4\xf3-<~3\xa1\xb0\xa4T\xba~\u07b3\x12\xf7k\xa5k\x96X\xbe7\xcf\xdd\xc5\xc97\xfe\xa6o\xb7\xfd\x95GvtF\xbc\u0112\xb7\x95\x05a\xc0\x1e\x10HEh\r\x16P*\r\xc6\x16B\x02\x97\x05}R\xad\xcd\xe0\xeb\x03\x82Ak\x85\xdc\x1b(\xb0AY\x90\x14%\a\xeeZ\x15du\x10\xbc\x05g?|2u\xc0y\xfa\xfb\x14n:m\x8e#\u007f\xbe\x91\xa5\x82\x02K!\xd1\xc0A\xfd\x00\u070b\x15\x06\x9c\x9b\xb0p\n\xf5n\xc1\"\xb8\x98\x18\x9d\xb5\xee\x1bK\nn\xf9\xe0\x95s\xab[\x84\x1b(ye\x90%\x1aK\xd4(s4\xdb%\x98_\xe7\x95\aV8\x9dj\x82<O'vJU,Q\r}\xe7\x95g\xf1\xb4\\Ic5\x17\xd2\x0e\xe7\xbeCl\x82_\xcc6\u0404\xccU\xddTh]X\x04Z\xdd(m;\r<\xcdX\x8d\xbc\xee\x94\xf2\xb4B\xe5f0\xd1\u04f8\xb5Z\xecZ\xeb\rp4\xef^z\x17C\x8fG\x0f\xe7up\x8f\\\x88\xd2\xf9\u0082jPso\x89?\x9d\xb1\u0346X\xbf>\xa0A\xb0X7\x15\xb7h\x80kt\x0f \xe95\xac\x82\x1dB+E)\x90\xde\x05\xb8u\xc1\xa0\x95\xb2\xa0J\xb0\aaHH\xaed)\xf6\xad\xbf!c\xee\x02\xf7^B6\xad\xf5q:D\r}\x1b\xe5\xc5y\x9a\xb7H\x11sI\xf4,\xcbX\x92\x1cY\x92Th\xe1\n.\xfc\xf1\xb1;f\xaf\x96L\xfc2\aI\xd2(\x86\xae\xd8p\xb5\t\xaa\xe4-E-\xa5\x9a\xc9L~\xc0\x9a\ae\x88\x17\xaf,J\xe3C\u009dN\xb3\xbf\x1a%\xd3\xf0m\x96\xc3d\ro\xad\xea\xcd9z\x96k^W\xf7e\xb9\x1f\u01d1\xf2>\xc1+\x8a\xaew:\xdcYp\x8b\xc7/?[\xf3y\xf0\xea\xf9\xaa\xcf\xe7\xe0\xdc\u75df\xbd\xc3\xeb\x94\u03c3\u03cf,Qmc\xbb\xc0\xf1Z={\xfa\xe1\xd5z\xf6\xf4\xbez\xe1\xf7T\t\xfe\xf7p\xbe\xfc\xe2\u01477\xe3\x8b\x17\xef0\xa3\x14\x94\xf6c;\n,\xff/3\x9e~\xfe\xe5\xb3\x0f\x9e\x9aN\xea=\xf3\xb3\xebu\xaf\xba4\x85\x9a7\u01b7\x95!u\xa9\x90\x85\xc2\xe8\xa1FSA\xb4\x82\xea\xe0,\xc3\xd3t\xdco/Y\x92\u0498\xd0\x13\xa9\xf3\x12\x81\r\x85`\xa0\x13\xa1\x03\xaa\x80\xf4@EHU\fLSD\u078a\x84\xe21H#\x02\xebK\xc4\n`\xaf\xec\x14\xb0xe\t\u062b\xc1:\a\xec\x15\x91\x1b\xad\xac\x1a\xeb\xeb\bN\x12^\xd9\x0e\xed%M\xd1\xddH\xe7\x01e\t5\x97\xaf^~\xb5\x052\xc4\xe0\u07de8R\x9au\f=\xd3N\xc8f\a\x9b\r\xec\x84\xe4\xfa\xba\xd9\xf5CC7*\x81\x90\x85\xc8}\u007f\xf2\x0fH\xd1\xc0\xadkr\x1a\x1b\x8d\x06%\r.\xc0\xe9i\xf7\x9a\xd7\x19\xeb\a\xad-<\xbaHS/R\xc2t\u0102\x02-\xeaz4\x91\xe4\xa8-\x17\xb2\x93\x03\xe6\xa0\u06aa\xa0>8\x99K6\x1bx\xad4t\xc3\xec\x13p\xb5\xa2\xe6\u05f3\x93\xc0\xa9'\x9b\\\x8b\x9d\xd7\xcfG\xf0\x13\xf8\xe1 \xf2\x03\bk\xb0*]\x0f\xe5\x92Xs%\xbfGm}\xf3\xe5\xf0\xe5_^\x05\x8e\x8c\u0366\xc3~\xe0s3\xe18h\x03\xbdt\xc3\xe9dx\uc1b0\xd9\u0216\x96J\xf9h\xf6#\xa7\xe7J\xfd\xc5ix\x0ez+\x9f]\xb9\xaak\x1a\xd4*!\u0453\xadZ\xe6\x15\x01.\xa3\xbc\x18\x9f\xcc^z/\x99Rx\xafys\x98\xa0\x8e\xe2\xc1\x82\xef'P\xc1\xf7\x1d`\xf9\f\xb1A\xa0\xab\x17?\xb2q5s\xc5\u0301d\xe5\x02\r\xa6\a\xb8Z\xc5+\u007f\x80Rl\x81\xbb\fu\xb0\v\xfe\x05\xee3\xc8\x1d\xe83dqhH5:\u84a5\xd9\xd1l\xecfv\x14\xf6\x80\x9a\x1c\xdd\xe5BH\x17\xe8D<\x015\xc1Y\xd2\xec\xb6p>\xbd\xc5\xff\xa5]\xa6\xa5l9\\\xa4t?\xdc\xc0\x1a\u38cb\xbbY\x1d9X\xb9j`\xda?\x98\xd3cx4/v\xc1\xe3\u0277r\xed\x17n\f\x06\xd26q\x9bqI\x1f\x99IRqw\u035e\x9c\x1ez#\xb1~\x10\xa9\xdd>\x16\xe4\xd2\xc8\xe6\xf1\x05\xbb\x9b\xe6V.\x9cLj!:\xc7\u0674\x104\x1cx\x1fq\xaaA\xc9\x1bq\x8b\xac\x80\xbe\x87 _\x1f\\\x83\xee\u05fb\u0428\xa9@\xf3\xaa\xf2`\x06o,\x14\n\rHeA\u023cj\v\xf4\u06e5\xd25\xbcy\x991w\xce)\xe4v[\xda\xe2/\xfa\x05\xb7\xaf_N{j\u0517k\xd5\x05z-\x83+\xe0\x06R7\x03\xb9O]u\x99\xad]\xf31k\xba\xbc\xcdg\x97\xe9\xaa8G\xa7K\xe3\xa7\x13\xf8w\xf0\u025c\u0092\xd9J9\x977].\xe7\xe8t\xa5\x9c\xa1G\xaa\xf3\xb2\x9bZ\xc7C\xd4\xc2_\xc1G\x8b\xfb\u05ad\x1a\xe4/\n\xf8\xf0\x00\xde\xd7\xe4u*\xdc\xfe\xbf\xcb\xdd\xd9\nO:/|\xbe\xee\xeb;\xb5\x99\xf9q\xdd\u007f\xeb~\x1b\xec\x99\xf4\x1c\x939\x1bF\xb6=\xba\x18B\xa8\xfb9a\xcc<\xeeK\xb4B\xec\xe7~yt\x11\xda\xd8T\xdbN\xad\xc9\xef\x17\xbd]\xe3\xdf-V\rX\xf5K\xaf\u05d1M\xa7\xea\xbeGvI0X0t\xc8a\t\x9ae\x8bO\x12\xb8\xe9\xdem\xbc\x02tz\x8c'\xffA\xf8\xd0>\xa7\u039d\xa8Ai\xe8%O;\xf2\xaa>\xfd\xc1\xa1\u7b1e\x1bt\x18\xb7\x9aw\x1c\xb5\xaa\xbe\xeb\xee\xe1\u0a25\xcfr\xec\xbd\u0180\x89\xf4[f\x82\xd1\v\xccm\xa1F\u007f\x97\x98q\xcf^\x932\xb4\xbc\x99\xf2\vC\x8fl\xda'\xeeQ\xab\xdd2\xe5\x9b\xe0\xf4\x96yW\xbb\u0541w\xf6\xaf\xf7\xe6Zu\xd6<\x9a\x8e,\x8a\xfe\x1b\x00\x00\xff\xff\x8e\xf7,\xe9\xbe\x16\x00\x00")
+// Data size: 1908 bytes.
+var cuegenInstanceData = []byte("\x01\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xff\xacXQ\x8f\u0736\xf1_\x9d\xfd\a\xfe\x12\xd2>\xe7\xa5\xc0D\x06\x82t\xe1\xea\x90\a\xb7\xc6\x02\x86\xe1\xc4va\xa0m\x8a\"}(\x8c\xe0\xc0\xa5fw\xd9H\xa4Jr\xcf{\xc8\x1d\u06a6i\xbfd\xbfF\x9fs\u0150\"%quw>\xd4\xf6\xc3\xed\u038f3\x9c\x19rf~\u071f\\\xff\xf3$;\xb9\xfe\xd7\"\xbb\xfe\xdbb\xf1\u02ff>\u0232\x8f\x844\x96I\x8e/\x99e$\xce\x1ed\x0f\xff\xa0\x94\xcdN\x16\xd9\xc3\xdf3\xbb\xcb>Zd\xff\xf7Z4h\xb2\xeb\x1f\x16\x8b\xc5\u03ee\xffq\x92e?}\xfb\r\xdfc\xb5\x11M\xaf\xf9\xc3\"\xbb\xfe~\xb1\xf8\xec\xfa\xef\x0f\xb2\xec\xff\a\xf9\xf7\x8b\xec${\xf8;\xd6\"\x19z\xe8\x84\xc5b\xb1\xf8\xf1\xe3\xff\x90#Yv\x92e\xb9\xbd\xe8\xd0T|\x8f\u064f\x1f\xff\xbbc\xfc[\xb6EX\xefES\x17\xc5\xe9)\xbc\x00\xda\x1f\xb8\xd2\x1aM\xa7dm\xc0*`\xf0k\xe5\x17U\x04W\xc5#\xfa\xb3\x82\uf29c\xb6\x97\xac\xc5\x15\xf4\xff\x8c\xd5Bn\x8b\x1c%W\xb5\x90\xdb\b<z\xd5K\x8a\\H\x8b\xba\xd3h\x99\x15J>_\xc1\xa37\x13I\x91o\x94n\x9fGU\xd2~\xadt[\xe4\x96m\xcds\xb7q\xfe\xd6\xef\xf4\xcd*nyU\\\xb9 ^\xe2\x86\xed\x1b\v\u0080\xdd!\x90\x8b\xb07X\xc3Fi0\xb6\x16\x12\x98\xac\xe9\x93\xda\xdb\n\xbe\xde!\x18\xb4V\u022d\x81\x1a;\x945YQr\xd0nU\x8dU\xf1\xa87\xbc\x02\x17?|:M\xc0\xb2\xfcE\t\x97\xc1\x9b\xabQ>\xdf\u020d\x82\x1a7B\xa2\x81\x9dz\a\u031b\x15\x06\\\x9a\xb0v\x0e\u0174`\u0767\x98\x14]\xb4\xee[\x91\xd7\u0332!+K\xab\xf7\b\x97\xb0a\x8d\xc1\"\u05f8A\x8d\x92\xa3Y\x1d\x83\xfc\x827\x1e\x98\xd1t\xae\t:\vZ\xb1V\xaa)r\xd5\xd1w\xd6x\x15/\xe3J\x1a\xab\x99\x90vX\xf7-b\xd7\xe7\u016cz\x99\x90\\\xb5]\x83\xd6]\x8b^\xd6vJ\xdb\xe0\x81\x97\x19\xab\x91\xb5\xc1)/\xab\x15\x8fn\x06\x19\xb3V\x8b\xf5\xde\xfa\x00\x9c\u0327\x97\xce\xc5\xd0\xe1\xd1\xc1y\x1f\xdc!\xd7b\xe3raAu\xa8\u075db\x8d_]\x15\xa7\xa7\xa4\xfa\xf5\x0e\r\x82\u0176k\x98E\x03L\xa3;\x00YcMw~\x8d\xb0\x97b#\xb0\x06\xba/\xd6]\x06\xad\x94\x05\xb5\x01\xbb\x13\x86\x8cp%7b\xbb\xf7;T\x85\xdb\xc0\x9d\x97\x90\xdd\u07baO\xf9pk\xe8\u06e8.\x96%\xdf#\u07583\x92WUU\xe4\xf9U\x91\xe7\rZ8\xc03\xa7<IGrj\xf9$/)H\x96Fw\xe8P\f[\x9b\xde\x15\xbe\xc7\x15,\xa9\xd4Le\xf8\x0e[\xd6;C\xbax\xb0(\x8d\xbf\x12nuY\xfd\xd9(Y\xf6\u07d2\x1a\xa6\xdb\xcf\xf6V\xc5p\xc8D^V\x17\xacm\xee\xabr?\x8d+\xaa\xfb\x1c\x0ft\xbb\xeeL\xb8\x8b\xe0\x86\x8c\x9f}>\x97\xf3>\xab\xcb\u065c\xa7`\x9a\xf3\xb3\xcf\xef\xc8:\xd5s\uf38fC\xed;\x1b.\x8e\xf7\xea\xe9\x93\x0f\xef\xd6\xd3'\xf7\xf5\v\xcfYsgvo\xb9\xceg_\xbc\xf8\xf0a|\xf1\xe2\x8e06B\xb2f\x12G\x8d\x9b\xff)\x8c'\xbf\xfa\xf2\xe9\a/Mg\xf5\x9e\xf5\x19f\u076bP\xa6\u0432\xce\xf8\xb12\x94.5\xb2\xbe1z\xa8\xd3\xd4\x10\xad@S\x15I\x85\x97e\b\x86\xfe\x9f\x15yI4!\ni\xf2\x92\xa0\x18\x1a\xc1 'A\x00\x9ar5\x05\x1aB\x9azP\x9a\"\xf2F\xa4o\x1e\x835\x12\x14\xb1E\xcc\x00\x87\x148x\x85\x1dO\xe4;\xee\xe4v\x13\xc5\x1397\xe7\xd3\xf5\u071c\xbb\xf5\xa9\xdc\xf6\xf2\x83M\xe4x\xb0\x04lU\x94{`\xabH\xdcie\x03\xe2\xc4N@\x88\u0143\rh\xb44E\u05e3\\\rh\x91\xd3P\xfb\xea\xe5W+\xa0\x04\x1a\xfc\xcbc'*\xab\xa0\x10\x95\xd6Bvk8=\x85\xb5\x90L_t\xebHV\x02E\x03!k\xc1\xfd\\\xf4\x17\x87\xa6\x04\xb3n\xb8j\xec4\x1a\x94D\x98\x80A\xa7\xd5V\xb3\xb6*\"\xc1[\xc1'\xcf\xca\u049b\x940\xa5vP\xa3E\u074e\x98\x10Gm\x99\x90\xc1\x0e\x98\x9d\xda75\xacq\u0287NO\xe1\xb5\xd2\x10H\xf4cp\xbd\xb3e\x17\xc9J`\xc4\x05\f\xd7b\xed\xfd\xf3\x93\xed1\xbc\xdb\t\xbe\x03a\r6\x1br\x8d3I\xaa\\\xc9s\u0524\xe8\x88\xee\x97\x7f|\xd5kTE\xc2J#\xd1t\\4\xa6t\u0f14\xa8\xb1\x18b\x91\xa7T\xb1\xdc(\xe5*\xa0\xf4T\xd7k\x95~\xe3\xb2?\x0e:+_\xd5\\\xb5-\x11\xc4FHt\u05c8\xea\xfa\xa8\x9e\tp\x95\xec\u0378\x8f\xbd\xf5h\x99z\xcfV\xb3n7A\x9d\xa4\xf4\r\x92m'P\u0376\x01\xb0S\x93$\xf0\x90\xe3\x11\u07cd\x1a\xd8\n\\\x13u Ey\x84\xf6\xa1\xf7p3\x8b7~\xc1\x05k\x8fq\x12z\xd8]\xfe#\xdcI\xfd\x82\u00cc\xfa!h\xef\xf81H\xbd\u0081\u071c\x1f\x81\xd4\x18\x1chg@\u06c3\xae\xee\xc8E\xcfo\xe1\x9d\x16TI\xc8\xf8\x0e\xb0\xc1\x16\xa5#\x91\f\x1aa,\xddW\x06\x06;\xa6\x99E\xf8\u04cb\xdf\xfe\x06j\xc5\xf7\xb4\xaa\xf2\xe1\a\x9a<\x9f\x84<\x9e{\x1e\tuI\x13\xa7\x8cS/v\x89c\x97\x0328\u07ad\xe9\xe1\xe4\xdeK(\xec\x0e5]\xb6\xd0\x0f\xfa\x96\x01\xc1\xc4cP\x13\xbc\u023b\xf5\n\x96\xd3]\xe8n\x03\x94\xa1\u06d0_\"\xa9\xac\x92\xf6\x87\xcb\xc4=R\x03j&\xb7\xaa:q\x1f\xe5l\x80e\xbc\xb4dntq\xbd\xd9#\x1d/\xbeQk\xabV0\x1b \xbd\xe4n\nn|J\r#\xa5r\xab\x86\x13\"\xd5\x0fb\xb5oE\xc1.qr\x8f\x1f\xa9\x13T\xcel8a\xc9}\x85\x8e;\u0291\xa1a\xc1\xfb\x98S\x1dJ\u0589\x1bl\xf5\xe8\xfb\x18\u2ebe\xc1\b\xd7\xf5\xbc\x01*\xa6h\xc07Y:a\x13\xdf\xe6=\u02e2)\u01da\x86\xa6]k*xc\xa1Vh@*\vB\xf2f_\xa3{\r\x12\fo^V\x05}\xf0\x87K\xfe\xbc\xa5\x9f`\x9e\xc5_'\xe2\x10p\x97\x87X\xd6\xd9\\\x8b\x0e\xff\x96\xa1W\xc3%\x94\x8e\xc0\x92\u01f1E'o\xe6\x94#O_\xde)\xf1\x9c\xbe\xf3St\xfa\xe2\xffl\x02\xff\x1c>M%E\x9e\xfc\x1e0\x81\x8b<\xf9e E\xa7\xbf\a$\xe8\x15\rK\x19\x9e\x1cc\x06|\x94\xaf>GG\xfb\xcdG5\xd8?\x9a\x82\xc1\xe0\xb2\xcf5e\x9d\xa6\x9f\xff\xebZF\xf2\xfb\v\xf9|\x94\xf3\xf9\\\xdf\xeaM\x92\xc7\xf9\xfc\xcd\u7b57\xa6\x83\xdbT.\x86Ql\x9f<\x1b\xaeP\xf8-h\xac<\x1e\ue9aa\xd9v\xa4\x1b\xba0e#\xf5\xb6\xb71\xfd\xf1)\b\xc3F\x93`'\x01\xcc\xe6\xa5\x17\xd2\x13'\u0530\xaf\xaeH4B\x11\u0115#\x9a1\xbc`\x93jY\xba\xd5p\x19\xcem\xfc~\xeb\rM\x9em\x83\xf1\x81\x83L\x93;q\x83\xca\xd0[\x1e\xb3\x8e\x1b\xbd\t;\xde\xe5E4\xb9\u3dd9L\xcd\xf5\xdf\xc74\xe6\x9ez\x8e\xe1\xc4\xfcW\u071cO\xd8\u06ac\xb5\xe8\xec0\x8bo\xdb5\x19\xc1w,\xb5\xaam\xdek\xe1\x88\xea$\xadc\x9e\"&\xf4hb\xfd\x06\xae\x14w\x1dg,\xb0\x94\xdb\u034c\xb9\u031c\x95\x81\n$\u0387\xc5q\xe9U1\x1d}\xf7\x18A\xee\x81O\f`\x05\xd3]\xd2i\x9f\xf80\xc4q\xeb\\\x7fo\xad8\xc4o\u0458L\xed|6\xbd\xe9\xfd\xbb*\x16\x8b\xff\x0e\x00\f\xb7t_\x83\x19\x00\x00")
@@ -17,9 +17,11 @@
 package value
 
 import (
+	"reflect"
 	"strings"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/internal/core/adt"
 	"cuelang.org/go/internal/core/convert"
@@ -75,14 +77,32 @@ func UnifyBuiltin(v cue.Value, kind string) cue.Value {
 }
 
 func FromGoValue(r *cue.Context, x interface{}, nilIsTop bool) cue.Value {
+	return FromGoValueWith(r, x, convert.Options{NilIsTop: nilIsTop})
+}
+
+// FromGoValueWith is like FromGoValue, but allows the conversion to be
+// tuned with options.
+func FromGoValueWith(r *cue.Context, x interface{}, o convert.Options) cue.Value {
 	rt := (*runtime.Runtime)(r)
 	rt.Init()
 	ctx := eval.NewContext(rt, nil)
-	v := convert.GoValueToValue(ctx, x, nilIsTop)
+	v := convert.GoValueToValueWith(ctx, x, o)
 	n := adt.ToVertex(v)
 	return r.Encode(n)
 }
 
+// SetTypeSchema sets the schema used for the Go type of x in r, overriding
+// the schema that would otherwise be derived from the type.
+func SetTypeSchema(r *cue.Context, x interface{}, schema ast.Expr) error {
+	rt := (*runtime.Runtime)(r)
+	rt.Init()
+	ctx := eval.NewContext(rt, nil)
+	if err := convert.SetTypeSchema(ctx, reflect.TypeOf(x), schema); err != nil {
+		return err
+	}
+	return nil
+}
+
 func FromGoType(r *cue.Context, x interface{}) cue.Value {
 	rt := (*runtime.Runtime)(r)
 	rt.Init()
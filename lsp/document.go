@@ -0,0 +1,134 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/parser"
+)
+
+// A document is a file opened by the client.
+type document struct {
+	uri     string
+	path    string
+	version int
+	text    string
+
+	file     *ast.File // parsed with error recovery
+	parseErr error
+
+	// inst and value are the instance and value of the package of the
+	// document as of the last version without syntax errors.
+	inst  *build.Instance
+	value cue.Value
+}
+
+func newDocument(uri string, version int, text string) (*document, error) {
+	path, err := uriToPath(uri)
+	if err != nil {
+		return nil, err
+	}
+	d := &document{uri: uri, path: path}
+	d.update(version, text, nil)
+	return d, nil
+}
+
+var parseOptions = []parser.Option{parser.ParseComments, parser.Recover}
+
+// update sets the text of d to text, which results from applying edits to
+// the old text. If edits is nil, the whole text is parsed.
+func (d *document) update(version int, text string, edits []parser.TextEdit) {
+	var f *ast.File
+	var err error
+	if d.file != nil && edits != nil {
+		f, err = parser.Reparse(d.file, []byte(d.text), edits, parseOptions...)
+	}
+	d.version = version
+	d.text = text
+	if f == nil {
+		f, err = parser.ParseFile(d.path, text, parseOptions...)
+	}
+	d.file = f
+	d.parseErr = err
+}
+
+// offset returns the byte offset in d of pos, or the end of d if pos is
+// past the end.
+func (d *document) offset(pos position) int {
+	return offsetOf(d.text, pos)
+}
+
+// position returns the LSP position of the byte offset off of d.
+func (d *document) position(off int) position {
+	return positionOf(d.text, off)
+}
+
+func offsetOf(text string, pos position) int {
+	off := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[off:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		off += i + 1
+	}
+	for n := 0; n < pos.Character && off < len(text) && text[off] != '\n'; {
+		r, size := utf8.DecodeRuneInString(text[off:])
+		n += len(utf16.Encode([]rune{r}))
+		off += size
+	}
+	return off
+}
+
+func positionOf(text string, off int) position {
+	if off > len(text) {
+		off = len(text)
+	}
+	var p position
+	for _, r := range text[:off] {
+		if r == '\n' {
+			p.Line++
+			p.Character = 0
+			continue
+		}
+		p.Character += len(utf16.Encode([]rune{r}))
+	}
+	return p
+}
+
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URI scheme %q", u.Scheme)
+	}
+	return filepath.FromSlash(u.Path), nil
+}
+
+func pathToURI(path string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
@@ -0,0 +1,334 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/internal/core/runtime"
+)
+
+// A target describes the node at a position in a document.
+type target struct {
+	// fields holds the fields enclosing the position, outermost first, and
+	// path their path.
+	fields []*ast.Field
+	path   []cue.Selector
+
+	// ident is the identifier at the position, if any.
+	ident *ast.Ident
+
+	// ref is the reference that contains ident, if ident is not a label,
+	// and sels the selectors of ref following ident.
+	ref  ast.Expr
+	sels int
+}
+
+// find returns the target for the byte offset off in f.
+func find(f *ast.File, off int) *target {
+	t := &target{}
+	contains := func(n ast.Node) bool {
+		return n.Pos().IsValid() && n.Pos().Offset() <= off && off <= n.End().Offset()
+	}
+	var parents []ast.Node
+	ast.Walk(f, func(n ast.Node) bool {
+		if n != f && !contains(n) {
+			return false
+		}
+		switch x := n.(type) {
+		case *ast.Field:
+			sel, ok := selector(x.Label)
+			if !ok {
+				return false
+			}
+			t.fields = append(t.fields, x)
+			t.path = append(t.path, sel)
+		case *ast.Ident:
+			t.ident = x
+			t.ref, t.sels = reference(x, parents)
+		}
+		parents = append(parents, n)
+		return true
+	}, nil)
+	if t.ident != nil && len(t.fields) > 0 && t.fields[len(t.fields)-1].Label == t.ident {
+		t.ref = nil
+	}
+	return t
+}
+
+// reference returns the selector expression of which x is the root or a
+// selector, given the nodes enclosing x, and the number of selectors
+// following x.
+func reference(x *ast.Ident, parents []ast.Node) (ref ast.Expr, sels int) {
+	ref = x
+	for i := len(parents) - 1; i >= 0; i-- {
+		s, ok := parents[i].(*ast.SelectorExpr)
+		switch {
+		case !ok:
+			return ref, sels
+		case s.X == ref:
+			sels++
+		case ast.Node(s.Sel) != ast.Node(ref):
+			return ref, sels
+		}
+		ref = s
+	}
+	return ref, sels
+}
+
+// selector returns the path selector for a field label.
+func selector(l ast.Label) (cue.Selector, bool) {
+	name, isIdent, err := ast.LabelName(l)
+	if err != nil || name == "" {
+		return cue.Selector{}, false
+	}
+	if !isIdent {
+		return cue.Str(name), true
+	}
+	p := cue.ParsePath(name)
+	if p.Err() != nil || len(p.Selectors()) != 1 {
+		return cue.Selector{}, false
+	}
+	return p.Selectors()[0], true
+}
+
+// chain returns the identifiers of the reference x, such as a.b.c, or nil if
+// x is not of this form.
+func chain(x ast.Expr) []string {
+	switch x := x.(type) {
+	case *ast.Ident:
+		return []string{x.Name}
+	case *ast.SelectorExpr:
+		a := chain(x.X)
+		if a == nil {
+			return nil
+		}
+		name, _, err := ast.LabelName(x.Sel)
+		if err != nil {
+			return nil
+		}
+		return append(a, name)
+	}
+	return nil
+}
+
+// resolve returns the value referred to by the identifiers in names, looked
+// up in the scopes of path from the innermost to the outermost.
+func resolve(v cue.Value, path []cue.Selector, names []string) cue.Value {
+	for i := len(path); i >= 0; i-- {
+		p := append([]cue.Selector{}, path[:i]...)
+		for _, name := range names {
+			sel, ok := selector(ast.NewIdent(name))
+			if !ok {
+				return cue.Value{}
+			}
+			p = append(p, sel)
+		}
+		if w := v.LookupPath(cue.MakePath(p...)); w.Exists() {
+			return w
+		}
+	}
+	return cue.Value{}
+}
+
+// referred returns the value referred to at the target t.
+func (s *server) referred(d *document, t *target) cue.Value {
+	if t.ref == nil || !d.value.Exists() {
+		return cue.Value{}
+	}
+	// If the reference is the value of a field, use the reference as
+	// computed by the evaluator.
+	if n := len(t.fields); n > 0 && t.fields[n-1].Value == t.ref {
+		v := d.value.LookupPath(cue.MakePath(t.path...))
+		if root, p := v.ReferencePath(); root.Exists() {
+			sels := p.Selectors()
+			if len(sels) >= t.sels {
+				sels = sels[:len(sels)-t.sels]
+				if len(sels) > 0 {
+					return root.LookupPath(cue.MakePath(sels...))
+				}
+			}
+		}
+	}
+	names := chain(t.ref)
+	if names == nil {
+		return cue.Value{}
+	}
+	path := t.path
+	if n := len(path); n > 0 && t.fields[n-1].Value.Pos().Offset() > t.ref.Pos().Offset() {
+		path = path[:n-1] // the reference is in the label of the field
+	}
+	return resolve(d.value, path, names[:len(names)-t.sels])
+}
+
+func (s *server) definition(d *document, off int) *location {
+	t := find(d.file, off)
+	if t.ident == nil || t.ref == nil {
+		return nil
+	}
+	if spec, ok := t.ident.Node.(*ast.ImportSpec); ok && t.ident == t.ref {
+		return s.location(spec.Pos())
+	}
+	v := s.referred(d, t)
+	if !v.Exists() {
+		return nil
+	}
+	return s.location(v.Pos())
+}
+
+func (s *server) hover(d *document, off int) *hover {
+	t := find(d.file, off)
+	if t.ident == nil {
+		return nil
+	}
+	var v cue.Value
+	if t.ref == nil {
+		v = d.value.LookupPath(cue.MakePath(t.path...))
+	} else {
+		v = s.referred(d, t)
+	}
+	if !v.Exists() {
+		return nil
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "```cue\n%v\n```\n", v)
+	for _, cg := range v.Doc() {
+		fmt.Fprintf(b, "\n%s", cg.Text())
+	}
+	start := d.position(t.ident.Pos().Offset())
+	end := d.position(t.ident.End().Offset())
+	return &hover{
+		Contents: markupContent{Kind: "markdown", Value: b.String()},
+		Range:    &rangeLSP{start, end},
+	}
+}
+
+var (
+	importRe   = regexp.MustCompile(`^\s*(import\s+)?([\w#]+\s+)?"([^"]*)$`)
+	selectorRe = regexp.MustCompile(`([\w#$]+(?:\.[\w#$]+)*)\.[\w#$]*$`)
+)
+
+func (s *server) complete(d *document, off int) []completionItem {
+	line := d.text[strings.LastIndex(d.text[:off], "\n")+1 : off]
+
+	// Import paths.
+	if m := importRe.FindStringSubmatch(line); m != nil && inImports(d, off, m[1] != "") {
+		items := []completionItem{}
+		for _, p := range runtime.SharedRuntime.BuiltinPackages() {
+			if strings.HasPrefix(p, m[3]) {
+				items = append(items, completionItem{Label: p, Kind: completionModule})
+			}
+		}
+		return items
+	}
+
+	t := find(d.file, off)
+	var path []cue.Selector
+	if n := len(t.path); n > 0 {
+		path = t.path[:n-1]
+		if f := t.fields[n-1]; off > f.Label.End().Offset() {
+			path = t.path // in the value of the field
+		}
+	}
+
+	// Members of packages and structs.
+	if m := selectorRe.FindStringSubmatch(line); m != nil {
+		names := strings.Split(m[1], ".")
+		if v := s.importedPackage(d, names[0]); v.Exists() {
+			return fieldsOf(v.LookupPath(cue.ParsePath(strings.Join(names[1:], "."))))
+		}
+		if !d.value.Exists() {
+			return []completionItem{}
+		}
+		return fieldsOf(resolve(d.value, path, names))
+	}
+
+	// Fields in scope and imported packages.
+	items := []completionItem{}
+	seen := map[string]bool{}
+	add := func(item completionItem) {
+		if !seen[item.Label] {
+			seen[item.Label] = true
+			items = append(items, item)
+		}
+	}
+	if d.value.Exists() {
+		for i := len(path); i >= 0; i-- {
+			for _, item := range fieldsOf(d.value.LookupPath(cue.MakePath(path[:i]...))) {
+				add(item)
+			}
+		}
+	}
+	for _, spec := range d.file.Imports {
+		ip, _ := strconv.Unquote(spec.Path.Value)
+		add(completionItem{Label: importName(spec, ip), Kind: completionModule, Detail: ip})
+	}
+	return items
+}
+
+// inImports reports whether off is at a position in d where an import path
+// may be written.
+func inImports(d *document, off int, keyword bool) bool {
+	if keyword {
+		return true
+	}
+	for _, decl := range d.file.Decls {
+		if x, ok := decl.(*ast.ImportDecl); ok && x.Lparen.IsValid() {
+			if x.Lparen.Offset() < off && (!x.Rparen.IsValid() || off <= x.Rparen.Offset()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func importName(spec *ast.ImportSpec, importPath string) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	if i := strings.IndexByte(importPath, ':'); i >= 0 {
+		return importPath[i+1:]
+	}
+	return path.Base(importPath)
+}
+
+// importedPackage returns the value of the package imported under name in d,
+// or an invalid value if there is no such import.
+func (s *server) importedPackage(d *document, name string) cue.Value {
+	for _, spec := range d.file.Imports {
+		ip, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || importName(spec, ip) != name {
+			continue
+		}
+		if d.inst != nil {
+			for _, imp := range d.inst.Imports {
+				if imp.ImportPath == ip {
+					return d.value.Context().BuildInstance(imp)
+				}
+			}
+		}
+		v := cuecontext.New().CompileString(fmt.Sprintf("import x %q\nv: x", ip))
+		return v.LookupPath(cue.ParsePath("v"))
+	}
+	return cue.Value{}
+}
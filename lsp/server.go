@@ -0,0 +1,355 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lsp implements a server for the Language Server Protocol (LSP) for
+// CUE.
+//
+// The server communicates over a single stream using JSON-RPC 2.0 and
+// supports the following features:
+//
+//   - diagnostics for syntax and evaluation errors of open files,
+//   - go to definition of references,
+//   - hover information showing the documentation and value of fields, and
+//   - completion of field names, package members, and builtin import paths.
+//
+// Files are evaluated as part of their package, with the contents of files
+// opened by the client taking precedence over the contents on disk.
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/load"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/core/adt"
+)
+
+// Serve runs a language server that reads requests and notifications from r
+// and writes responses and notifications to w. It returns when the client
+// sends the exit notification or r is closed.
+func Serve(r io.Reader, w io.Writer) error {
+	s := &server{
+		conn: newConn(r, w),
+		docs: map[string]*document{},
+	}
+	return s.run()
+}
+
+type server struct {
+	conn *conn
+	docs map[string]*document
+
+	shutdown bool
+}
+
+func (s *server) run() error {
+	for {
+		m, err := s.conn.read()
+		if err == io.EOF {
+			return nil
+		}
+		if e, ok := err.(*responseError); ok {
+			if err := s.conn.write(&message{Error: e}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if m.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit without shutdown")
+			}
+			return nil
+		}
+
+		result, rerr := s.handle(m)
+		if m.ID == nil {
+			continue // notification
+		}
+		resp := &message{ID: m.ID, Result: result, Error: rerr}
+		if rerr == nil && result == nil {
+			resp.Result = json.RawMessage("null")
+		}
+		if err := s.conn.write(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) handle(m *message) (result interface{}, err *responseError) {
+	if s.shutdown && m.ID != nil {
+		return nil, &responseError{codeInvalidRequest, "server is shut down"}
+	}
+
+	switch m.Method {
+	case "initialize":
+		r := &initializeResult{}
+		r.ServerInfo.Name = "cue"
+		r.Capabilities = serverCapabilities{
+			TextDocumentSync:   syncIncremental,
+			HoverProvider:      true,
+			DefinitionProvider: true,
+			CompletionProvider: &completionOptions{
+				TriggerCharacters: []string{".", `"`, "/"},
+			},
+		}
+		return r, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := unmarshal(m, &p); err != nil {
+			return nil, err
+		}
+		d, derr := newDocument(p.TextDocument.URI, p.TextDocument.Version, p.TextDocument.Text)
+		if derr != nil {
+			return nil, &responseError{codeInvalidParams, derr.Error()}
+		}
+		s.docs[d.uri] = d
+		s.publishDiagnostics(d)
+
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := unmarshal(m, &p); err != nil {
+			return nil, err
+		}
+		d, err := s.document(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range p.ContentChanges {
+			if c.Range == nil {
+				d.update(p.TextDocument.Version, c.Text, nil)
+				continue
+			}
+			start, end := d.offset(c.Range.Start), d.offset(c.Range.End)
+			text := d.text[:start] + c.Text + d.text[end:]
+			d.update(p.TextDocument.Version, text, []parser.TextEdit{{
+				Start:   start,
+				End:     end,
+				NewText: c.Text,
+			}})
+		}
+		s.publishDiagnostics(d)
+
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err := unmarshal(m, &p); err != nil {
+			return nil, err
+		}
+		if d, ok := s.docs[p.TextDocument.URI]; ok {
+			delete(s.docs, d.uri)
+			s.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{
+				URI:         d.uri,
+				Diagnostics: []diagnostic{},
+			})
+		}
+
+	case "textDocument/definition":
+		var p textDocumentPositionParams
+		if err := unmarshal(m, &p); err != nil {
+			return nil, err
+		}
+		d, err := s.document(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		if loc := s.definition(d, d.offset(p.Position)); loc != nil {
+			return loc, nil
+		}
+
+	case "textDocument/hover":
+		var p textDocumentPositionParams
+		if err := unmarshal(m, &p); err != nil {
+			return nil, err
+		}
+		d, err := s.document(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		if h := s.hover(d, d.offset(p.Position)); h != nil {
+			return h, nil
+		}
+
+	case "textDocument/completion":
+		var p textDocumentPositionParams
+		if err := unmarshal(m, &p); err != nil {
+			return nil, err
+		}
+		d, err := s.document(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return &completionList{Items: s.complete(d, d.offset(p.Position))}, nil
+
+	case "initialized", "$/cancelRequest", "$/setTrace", "workspace/didChangeConfiguration":
+		// ignore
+
+	default:
+		if m.ID != nil {
+			return nil, &responseError{codeMethodNotFound, "method not found: " + m.Method}
+		}
+	}
+	return nil, nil
+}
+
+func unmarshal(m *message, v interface{}) *responseError {
+	if err := json.Unmarshal(m.Params, v); err != nil {
+		return &responseError{codeInvalidParams, err.Error()}
+	}
+	return nil
+}
+
+func (s *server) document(uri string) (*document, *responseError) {
+	d, ok := s.docs[uri]
+	if !ok {
+		return nil, &responseError{codeInvalidParams, "unknown document " + uri}
+	}
+	return d, nil
+}
+
+func (s *server) notify(method string, params interface{}) {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+	_ = s.conn.write(&message{Method: method, Params: b})
+}
+
+// evaluate builds the package of d, using the contents of the open documents
+// for their files, and returns the errors found.
+func (s *server) evaluate(d *document) error {
+	overlay := map[string]load.Source{}
+	for _, doc := range s.docs {
+		overlay[doc.path] = load.FromString(doc.text)
+	}
+	cfg := &load.Config{
+		Dir:     filepath.Dir(d.path),
+		Overlay: overlay,
+		Tests:   strings.HasSuffix(d.path, "_test.cue"),
+		Tools:   strings.HasSuffix(d.path, "_tool.cue"),
+	}
+	args := []string{d.path}
+	if name := d.file.PackageName(); name != "" {
+		args = []string{"."}
+		cfg.Package = name
+	}
+	insts := load.Instances(args, cfg)
+	if len(insts) == 0 {
+		return nil
+	}
+	if err := insts[0].Err; err != nil {
+		return err
+	}
+	v := cuecontext.New().BuildInstance(insts[0])
+	d.inst, d.value = insts[0], v
+	if err := v.Err(); err != nil {
+		return err
+	}
+	return v.Validate()
+}
+
+func (s *server) publishDiagnostics(d *document) {
+	err := d.parseErr
+	if err == nil {
+		err = s.evaluate(d)
+	}
+	diags := []diagnostic{}
+	for _, e := range errors.Diagnostics(err) {
+		r := rangeLSP{}
+		for _, p := range append([]token.Pos{e.Pos}, e.Related...) {
+			if p.IsValid() && filepath.Clean(p.Filename()) == filepath.Clean(d.path) {
+				start := d.position(p.Offset())
+				r = rangeLSP{start, start}
+				if p == e.Pos && e.End.IsValid() {
+					r.End = d.position(e.End.Offset())
+				}
+				break
+			}
+		}
+		msg := e.Message
+		if len(e.Path) > 0 {
+			msg = strings.Join(e.Path, ".") + ": " + msg
+		}
+		severity := severityError
+		if e.Severity == errors.SeverityIncomplete {
+			severity = severityWarning
+		}
+		diags = append(diags, diagnostic{
+			Range:    r,
+			Severity: severity,
+			Source:   "cue",
+			Message:  msg,
+		})
+	}
+	s.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{
+		URI:         d.uri,
+		Diagnostics: diags,
+	})
+}
+
+// location returns the location of pos, or nil if pos is not in a file.
+func (s *server) location(pos token.Pos) *location {
+	filename := pos.Filename()
+	if !pos.IsValid() || filename == "" || pos.File() == nil {
+		return nil
+	}
+	uri := pathToURI(filename)
+	var text string
+	if d, ok := s.docs[uri]; ok {
+		text = d.text
+	} else if b, err := ioutil.ReadFile(filename); err == nil {
+		text = string(b)
+	}
+	start := positionOf(text, pos.Offset())
+	return &location{URI: uri, Range: rangeLSP{start, start}}
+}
+
+// fieldsOf returns the fields, including definitions and optional fields, of v
+// as completion items.
+func fieldsOf(v cue.Value) []completionItem {
+	iter, err := v.Fields(cue.Definitions(true), cue.Optional(true))
+	if err != nil {
+		return nil
+	}
+	var items []completionItem
+	for iter.Next() {
+		item := completionItem{
+			Label:  iter.Selector().String(),
+			Kind:   completionField,
+			Detail: iter.Value().IncompleteKind().String(),
+		}
+		if iter.Value().IncompleteKind() == adt.FuncKind {
+			item.Kind = completionFunction
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
@@ -0,0 +1,295 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A client sends requests to a server running in a goroutine.
+type client struct {
+	t      *testing.T
+	conn   *conn
+	id     int
+	diags  map[string][]diagnostic
+	done   chan error
+	closer io.Closer
+}
+
+func newClient(t *testing.T) *client {
+	cr, sw := io.Pipe()
+	sr, cw := io.Pipe()
+	c := &client{
+		t:      t,
+		conn:   newConn(cr, cw),
+		diags:  map[string][]diagnostic{},
+		done:   make(chan error, 1),
+		closer: cw,
+	}
+	go func() {
+		err := Serve(sr, sw)
+		sw.Close()
+		c.done <- err
+	}()
+	return c
+}
+
+// call sends a request and decodes its result into result.
+func (c *client) call(method string, params, result interface{}) {
+	c.t.Helper()
+	c.id++
+	id := json.RawMessage(strings.Repeat("1", c.id))
+	c.send(&message{ID: &id, Method: method}, params)
+	for {
+		m := c.read()
+		if m.ID == nil {
+			continue
+		}
+		if m.Error != nil {
+			c.t.Fatalf("%s: %v", method, m.Error)
+		}
+		b, _ := json.Marshal(m.Result)
+		if err := json.Unmarshal(b, result); err != nil {
+			c.t.Fatal(err)
+		}
+		return
+	}
+}
+
+// notify sends a notification and waits for the diagnostics of uri, if not
+// empty.
+func (c *client) notify(method string, params interface{}, uri string) {
+	c.t.Helper()
+	c.send(&message{Method: method}, params)
+	if uri == "" {
+		return
+	}
+	for {
+		if m := c.read(); m.Method == "textDocument/publishDiagnostics" {
+			var p publishDiagnosticsParams
+			b, _ := json.Marshal(m.Params)
+			if err := json.Unmarshal(b, &p); err != nil {
+				c.t.Fatal(err)
+			}
+			c.diags[p.URI] = p.Diagnostics
+			if p.URI == uri {
+				return
+			}
+		}
+	}
+}
+
+func (c *client) send(m *message, params interface{}) {
+	c.t.Helper()
+	b, err := json.Marshal(params)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	m.Params = b
+	if err := c.conn.write(m); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *client) read() *message {
+	c.t.Helper()
+	m, err := c.conn.read()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return m
+}
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cue-lsp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"cue.mod/module.cue": `module: "example.com"`,
+		"b.cue": `package foo
+
+// Defaults holds the default settings.
+#Defaults: {
+	replicas: int | *1
+	name:     string
+}
+`,
+	}
+	for name, content := range files {
+		name = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	uri := pathToURI(filepath.Join(dir, "a.cue"))
+	bURI := pathToURI(filepath.Join(dir, "b.cue"))
+
+	const text = `package foo
+
+import "strings"
+
+app: #Defaults & {
+	name: strings.ToUpper("x")
+}
+n: app.replicas
+`
+	c := newClient(t)
+
+	var init initializeResult
+	c.call("initialize", map[string]interface{}{}, &init)
+	if !init.Capabilities.HoverProvider || init.Capabilities.CompletionProvider == nil {
+		t.Errorf("unexpected capabilities %+v", init.Capabilities)
+	}
+	c.notify("initialized", struct{}{}, "")
+
+	item := textDocumentItem{URI: uri, LanguageID: "cue", Version: 1, Text: text}
+	c.notify("textDocument/didOpen", &didOpenParams{TextDocument: item}, uri)
+	if d := c.diags[uri]; len(d) != 0 {
+		t.Errorf("unexpected diagnostics: %+v", d)
+	}
+
+	at := func(line, char int) textDocumentPositionParams {
+		return textDocumentPositionParams{
+			TextDocument: textDocumentIdentifier{URI: uri},
+			Position:     position{line, char},
+		}
+	}
+
+	t.Run("definition", func(t *testing.T) {
+		var loc location
+		c.call("textDocument/definition", at(7, 8), &loc) // app.replicas
+		want := location{URI: bURI, Range: rangeLSP{position{4, 1}, position{4, 1}}}
+		if loc != want {
+			t.Errorf("got %+v; want %+v", loc, want)
+		}
+
+		c.call("textDocument/definition", at(7, 4), &loc) // app
+		want = location{URI: uri, Range: rangeLSP{position{4, 0}, position{4, 0}}}
+		if loc != want {
+			t.Errorf("got %+v; want %+v", loc, want)
+		}
+
+		c.call("textDocument/definition", at(4, 7), &loc) // #Defaults
+		want = location{URI: bURI, Range: rangeLSP{position{3, 0}, position{3, 0}}}
+		if loc != want {
+			t.Errorf("got %+v; want %+v", loc, want)
+		}
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		var h hover
+		c.call("textDocument/hover", at(4, 8), &h) // #Defaults
+		if !strings.Contains(h.Contents.Value, "Defaults holds the default settings.") ||
+			!strings.Contains(h.Contents.Value, "replicas") {
+			t.Errorf("unexpected hover text %q", h.Contents.Value)
+		}
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		check := func(items []completionItem, want ...string) {
+			t.Helper()
+			labels := map[string]bool{}
+			for _, item := range items {
+				labels[item.Label] = true
+			}
+			for _, w := range want {
+				if !labels[w] {
+					t.Errorf("missing completion %q in %v", w, items)
+				}
+			}
+		}
+
+		// Complete the members of a builtin package.
+		var list completionList
+		c.notify("textDocument/didChange", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "version": 2},
+			"contentChanges": []interface{}{map[string]interface{}{
+				"range": rangeLSP{position{7, 15}, position{7, 15}},
+				"text":  "\nm: strings.",
+			}},
+		}, uri)
+		c.call("textDocument/completion", at(8, 11), &list)
+		check(list.Items, "ToUpper", "Split")
+
+		// Complete the fields of a struct.
+		c.notify("textDocument/didChange", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "version": 3},
+			"contentChanges": []interface{}{map[string]interface{}{
+				"range": rangeLSP{position{8, 3}, position{8, 11}},
+				"text":  "app.",
+			}},
+		}, uri)
+		c.call("textDocument/completion", at(8, 7), &list)
+		check(list.Items, "name", "replicas")
+
+		// Complete fields in scope.
+		c.call("textDocument/completion", at(8, 3), &list)
+		check(list.Items, "app", "n", "#Defaults", "strings")
+
+		// Complete builtin import paths.
+		c.notify("textDocument/didChange", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "version": 4},
+			"contentChanges": []interface{}{map[string]interface{}{
+				"range": rangeLSP{position{2, 16}, position{2, 16}},
+				"text":  "\nimport \"encoding/",
+			}},
+		}, uri)
+		c.call("textDocument/completion", at(3, 17), &list)
+		check(list.Items, "encoding/json", "encoding/yaml")
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		c.notify("textDocument/didChange", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "version": 5},
+			"contentChanges": []interface{}{map[string]interface{}{
+				"text": text + "app: replicas: \"two\"\n",
+			}},
+		}, uri)
+		d := c.diags[uri]
+		if len(d) == 0 || !strings.Contains(d[0].Message, "app.replicas") {
+			t.Errorf("unexpected diagnostics: %+v", d)
+		}
+
+		c.notify("textDocument/didChange", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "version": 6},
+			"contentChanges": []interface{}{map[string]interface{}{
+				"text": "a: {\nb: 1 2\n",
+			}},
+		}, uri)
+		if d := c.diags[uri]; len(d) == 0 || d[0].Range.Start.Line != 1 {
+			t.Errorf("unexpected diagnostics: %+v", d)
+		}
+	})
+
+	var result interface{}
+	c.call("shutdown", nil, &result)
+	c.notify("exit", nil, "")
+	if err := <-c.done; err != nil {
+		t.Error(err)
+	}
+	c.closer.Close()
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bcrypt verifies passwords hashed with Provos and Mazières's bcrypt
+// adaptive hashing algorithm.
+//
+// Hashing a password requires a random salt, which makes the result
+// non-deterministic. This package therefore only verifies pre-hashed
+// passwords, which are typically generated by other tools.
+package bcrypt
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// The minimum and maximum cost of a hash.
+const (
+	MinCost = 4
+	MaxCost = 31
+)
+
+// maxVerifyCost is the maximum cost of a hash accepted by Verify. The time
+// needed to verify a hash doubles with each increment of the cost: a hash of
+// cost 16 takes seconds to verify, while one of cost 31 takes days.
+const maxVerifyCost = 16
+
+// Verify reports whether hash is the bcrypt hash of password. It returns an
+// error if hash is not a valid bcrypt hash or if its cost is larger than 16,
+// which would take too long to verify.
+func Verify(hash, password []byte) (bool, error) {
+	cost, err := bcrypt.Cost(hash)
+	if err != nil {
+		return false, err
+	}
+	if cost > maxVerifyCost {
+		return false, fmt.Errorf("cost %d of hash exceeds the maximum of %d", cost, maxVerifyCost)
+	}
+	switch err := bcrypt.CompareHashAndPassword(hash, password); err {
+	case nil:
+		return true, nil
+	case bcrypt.ErrMismatchedHashAndPassword:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// Cost returns the cost with which hash was created.
+func Cost(hash []byte) (int, error) {
+	return bcrypt.Cost(hash)
+}
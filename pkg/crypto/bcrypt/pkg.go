@@ -0,0 +1,52 @@
+// Code generated by go generate. DO NOT EDIT.
+
+//go:generate rm pkg.go
+//go:generate go run ../../gen/gen.go
+
+package bcrypt
+
+import (
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/pkg/internal"
+)
+
+func init() {
+	internal.Register("crypto/bcrypt", pkg)
+}
+
+var _ = adt.TopKind // in case the adt package isn't used
+
+var pkg = &internal.Package{
+	Native: []*internal.Builtin{{
+		Name:  "MinCost",
+		Const: "4",
+	}, {
+		Name:  "MaxCost",
+		Const: "31",
+	}, {
+		Name: "Verify",
+		Params: []internal.Param{
+			{Kind: adt.BytesKind | adt.StringKind},
+			{Kind: adt.BytesKind | adt.StringKind},
+		},
+		Result: adt.BoolKind,
+		Func: func(c *internal.CallCtxt) {
+			hash, password := c.Bytes(0), c.Bytes(1)
+			if c.Do() {
+				c.Ret, c.Err = Verify(hash, password)
+			}
+		},
+	}, {
+		Name: "Cost",
+		Params: []internal.Param{
+			{Kind: adt.BytesKind | adt.StringKind},
+		},
+		Result: adt.IntKind,
+		Func: func(c *internal.CallCtxt) {
+			hash := c.Bytes(0)
+			if c.Do() {
+				c.Ret, c.Err = Cost(hash)
+			}
+		},
+	}},
+}
@@ -54,6 +54,19 @@ func Sign(hashName string, key []byte, data []byte) ([]byte, error) {
 	return mac.Sum(nil), nil
 }
 
+// Verify reports whether mac is the HMAC signature of the data, using the
+// provided key and hash function. The comparison does not leak timing
+// information.
+//
+// Supported hash functions are the same as for Sign.
+func Verify(hashName string, key, data, mac []byte) (bool, error) {
+	expected, err := Sign(hashName, key, data)
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal(mac, expected), nil
+}
+
 func hashFromName(hash string) (func() hash.Hash, error) {
 	switch hash {
 	case MD5:
@@ -55,5 +55,20 @@ var pkg = &internal.Package{
 				c.Ret, c.Err = Sign(hashName, key, data)
 			}
 		},
+	}, {
+		Name: "Verify",
+		Params: []internal.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.BytesKind | adt.StringKind},
+			{Kind: adt.BytesKind | adt.StringKind},
+			{Kind: adt.BytesKind | adt.StringKind},
+		},
+		Result: adt.BoolKind,
+		Func: func(c *internal.CallCtxt) {
+			hashName, key, data, mac := c.String(0), c.Bytes(1), c.Bytes(2), c.Bytes(3)
+			if c.Do() {
+				c.Ret, c.Err = Verify(hashName, key, data, mac)
+			}
+		},
 	}},
 }
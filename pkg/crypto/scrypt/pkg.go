@@ -0,0 +1,55 @@
+// Code generated by go generate. DO NOT EDIT.
+
+//go:generate rm pkg.go
+//go:generate go run ../../gen/gen.go
+
+package scrypt
+
+import (
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/pkg/internal"
+)
+
+func init() {
+	internal.Register("crypto/scrypt", pkg)
+}
+
+var _ = adt.TopKind // in case the adt package isn't used
+
+var pkg = &internal.Package{
+	Native: []*internal.Builtin{{
+		Name: "Key",
+		Params: []internal.Param{
+			{Kind: adt.BytesKind | adt.StringKind},
+			{Kind: adt.BytesKind | adt.StringKind},
+			{Kind: adt.IntKind},
+			{Kind: adt.IntKind},
+			{Kind: adt.IntKind},
+			{Kind: adt.IntKind},
+		},
+		Result: adt.BytesKind | adt.StringKind,
+		Func: func(c *internal.CallCtxt) {
+			password, salt, N, r, p, keyLen := c.Bytes(0), c.Bytes(1), c.Int(2), c.Int(3), c.Int(4), c.Int(5)
+			if c.Do() {
+				c.Ret, c.Err = Key(password, salt, N, r, p, keyLen)
+			}
+		},
+	}, {
+		Name: "Verify",
+		Params: []internal.Param{
+			{Kind: adt.BytesKind | adt.StringKind},
+			{Kind: adt.BytesKind | adt.StringKind},
+			{Kind: adt.BytesKind | adt.StringKind},
+			{Kind: adt.IntKind},
+			{Kind: adt.IntKind},
+			{Kind: adt.IntKind},
+		},
+		Result: adt.BoolKind,
+		Func: func(c *internal.CallCtxt) {
+			key, password, salt, N, r, p := c.Bytes(0), c.Bytes(1), c.Bytes(2), c.Int(3), c.Int(4), c.Int(5)
+			if c.Do() {
+				c.Ret, c.Err = Verify(key, password, salt, N, r, p)
+			}
+		},
+	}},
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scrypt implements the scrypt key derivation function as defined in
+// Colin Percival's paper "Stronger Key Derivation via Sequential Memory-Hard
+// Functions" and RFC 7914.
+package scrypt
+
+import (
+	"crypto/subtle"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// maxMemory limits the memory used by a key derivation, which is about
+// 128 * r * N bytes.
+const maxMemory = 64 << 20
+
+// maxWork limits the time used by a key derivation, which is proportional to
+// 128 * r * N * p.
+const maxWork = 1 << 30
+
+// Key derives a key of length keyLen from the password, salt, and cost
+// parameters.
+//
+// N is a CPU/memory cost parameter, which must be a power of two greater than
+// 1. r and p must satisfy r * p < 2³⁰. To bound the resources used by
+// evaluation, 128 * r * N may not exceed 64 MiB, and 128 * r * N * p may not
+// exceed 1 GiB.
+func Key(password, salt []byte, N, r, p, keyLen int) ([]byte, error) {
+	if err := checkParams(N, r, p, keyLen); err != nil {
+		return nil, err
+	}
+	return scrypt.Key(password, salt, N, r, p, keyLen)
+}
+
+// Verify reports whether key is the key derived from the password, salt, and
+// cost parameters. The length of the derived key is the length of key. The
+// comparison does not leak timing information.
+//
+// The cost parameters are subject to the same limits as for Key.
+func Verify(key, password, salt []byte, N, r, p int) (bool, error) {
+	dk, err := Key(password, salt, N, r, p, len(key))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(key, dk) == 1, nil
+}
+
+// checkParams reports an error if a key derivation with the given parameters
+// would use too many resources. Invalid parameters are reported by scrypt.Key.
+func checkParams(N, r, p, keyLen int) error {
+	if N <= 1 || r <= 0 || p <= 0 {
+		return nil
+	}
+	if r > maxMemory/128 || N > maxMemory/(128*r) {
+		return fmt.Errorf("N=%d and r=%d require more than %d MiB of memory", N, r, maxMemory>>20)
+	}
+	if p > maxMemory/(128*r) || p > maxWork/(128*r*N) {
+		return fmt.Errorf("N=%d, r=%d, and p=%d require too much computation", N, r, p)
+	}
+	if keyLen > maxMemory {
+		return fmt.Errorf("key length %d too large", keyLen)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+
+	"cuelang.org/go/cue"
+)
+
+func parseCIDR(s string) (*net.IPNet, error) {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %q", s)
+	}
+	return n, nil
+}
+
+// ContainsIP reports whether the network cidr, in CIDR notation like
+// "192.0.2.0/24" or "2001:db8::/32", contains ip.
+//
+// The address may be a string or list of bytes.
+func ContainsIP(cidr string, ip cue.Value) (bool, error) {
+	n, err := parseCIDR(cidr)
+	if err != nil {
+		return false, err
+	}
+	ipdata := netGetIP(ip)
+	if ipdata == nil {
+		return false, fmt.Errorf("invalid IP %q", ip)
+	}
+	return n.Contains(ipdata), nil
+}
+
+// Overlaps reports whether the networks a and b, both in CIDR notation,
+// have any address in common. Networks of different address families never
+// overlap.
+func Overlaps(a, b string) (bool, error) {
+	na, err := parseCIDR(a)
+	if err != nil {
+		return false, err
+	}
+	nb, err := parseCIDR(b)
+	if err != nil {
+		return false, err
+	}
+	if len(na.IP) != len(nb.IP) {
+		return false, nil
+	}
+	return na.Contains(nb.IP) || nb.Contains(na.IP), nil
+}
+
+// SubnetOf reports whether the network sub is contained in the network cidr,
+// both in CIDR notation. A network is a subnet of itself.
+func SubnetOf(sub, cidr string) (bool, error) {
+	ns, err := parseCIDR(sub)
+	if err != nil {
+		return false, err
+	}
+	n, err := parseCIDR(cidr)
+	if err != nil {
+		return false, err
+	}
+	subOnes, subBits := ns.Mask.Size()
+	ones, bits := n.Mask.Size()
+	return subBits == bits && subOnes >= ones && n.Contains(ns.IP), nil
+}
+
+// HostsInCIDR returns the number of host addresses in the network cidr, in
+// CIDR notation.
+//
+// For IPv4 networks, the network and broadcast addresses are not counted,
+// except for /31 and /32 networks, which have 2 and 1 hosts, respectively.
+// For IPv6 networks, all addresses are counted.
+func HostsInCIDR(cidr string) (*big.Int, error) {
+	n, err := parseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	ones, bits := n.Mask.Size()
+	hosts := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+	if bits == 8*net.IPv4len && bits-ones > 1 {
+		hosts.Sub(hosts, big.NewInt(2))
+	}
+	return hosts, nil
+}
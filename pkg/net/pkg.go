@@ -18,6 +18,57 @@ var _ = adt.TopKind // in case the adt package isn't used
 
 var pkg = &internal.Package{
 	Native: []*internal.Builtin{{
+		Name: "ContainsIP",
+		Params: []internal.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.TopKind},
+		},
+		Result: adt.BoolKind,
+		Func: func(c *internal.CallCtxt) {
+			cidr, ip := c.String(0), c.Value(1)
+			if c.Do() {
+				c.Ret, c.Err = ContainsIP(cidr, ip)
+			}
+		},
+	}, {
+		Name: "Overlaps",
+		Params: []internal.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.StringKind},
+		},
+		Result: adt.BoolKind,
+		Func: func(c *internal.CallCtxt) {
+			a, b := c.String(0), c.String(1)
+			if c.Do() {
+				c.Ret, c.Err = Overlaps(a, b)
+			}
+		},
+	}, {
+		Name: "SubnetOf",
+		Params: []internal.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.StringKind},
+		},
+		Result: adt.BoolKind,
+		Func: func(c *internal.CallCtxt) {
+			sub, cidr := c.String(0), c.String(1)
+			if c.Do() {
+				c.Ret, c.Err = SubnetOf(sub, cidr)
+			}
+		},
+	}, {
+		Name: "HostsInCIDR",
+		Params: []internal.Param{
+			{Kind: adt.StringKind},
+		},
+		Result: adt.IntKind,
+		Func: func(c *internal.CallCtxt) {
+			cidr := c.String(0)
+			if c.Do() {
+				c.Ret, c.Err = HostsInCIDR(cidr)
+			}
+		},
+	}, {
 		Name: "SplitHostPort",
 		Params: []internal.Param{
 			{Kind: adt.StringKind},
@@ -15,8 +15,10 @@
 package pkg
 
 import (
+	_ "cuelang.org/go/pkg/crypto/bcrypt"
 	_ "cuelang.org/go/pkg/crypto/hmac"
 	_ "cuelang.org/go/pkg/crypto/md5"
+	_ "cuelang.org/go/pkg/crypto/scrypt"
 	_ "cuelang.org/go/pkg/crypto/sha1"
 	_ "cuelang.org/go/pkg/crypto/sha256"
 	_ "cuelang.org/go/pkg/crypto/sha512"
@@ -41,9 +43,12 @@ import (
 	_ "cuelang.org/go/pkg/time"
 	_ "cuelang.org/go/pkg/tool"
 	_ "cuelang.org/go/pkg/tool/cli"
+	_ "cuelang.org/go/pkg/tool/docker"
 	_ "cuelang.org/go/pkg/tool/exec"
 	_ "cuelang.org/go/pkg/tool/file"
 	_ "cuelang.org/go/pkg/tool/http"
+	_ "cuelang.org/go/pkg/tool/kube"
 	_ "cuelang.org/go/pkg/tool/os"
+	_ "cuelang.org/go/pkg/tool/storage"
 	_ "cuelang.org/go/pkg/uuid"
 )
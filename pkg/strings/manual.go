@@ -29,6 +29,7 @@ import (
 	"fmt"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // ByteAt reports the ith byte of the underlying strings or byte.
@@ -118,3 +119,90 @@ func SliceRunes(s string, start, end int) (string, error) {
 	}
 	return string(runes[start:end]), nil
 }
+
+// maxRepeatLen is the maximum length in bytes of a string created by Repeat.
+const maxRepeatLen = 64 << 20
+
+// Repeat returns a new string consisting of count copies of the string s.
+//
+// It returns an error if count is negative or if the result would be larger
+// than 64 MiB.
+func Repeat(s string, count int) (string, error) {
+	if count < 0 {
+		return "", fmt.Errorf("negative count %d", count)
+	}
+	if len(s) > 0 && count > maxRepeatLen/len(s) {
+		return "", fmt.Errorf("result of repeating %d bytes %d times too large", len(s), count)
+	}
+	return strings.Repeat(s, count), nil
+}
+
+// EqualFold reports whether s and t, interpreted as UTF-8 strings,
+// are equal under Unicode case-folding.
+func EqualFold(s, t string) bool {
+	return strings.EqualFold(s, t)
+}
+
+// Cut slices s around the first instance of sep, returning a struct with the
+// text before and after sep and whether sep appears in s. If sep does not
+// appear in s, before is s, after is the empty string, and found is false.
+func Cut(s, sep string) map[string]interface{} {
+	before, after, found := s, "", false
+	if i := strings.Index(s, sep); i >= 0 {
+		before, after, found = s[:i], s[i+len(sep):], true
+	}
+	return map[string]interface{}{
+		"before": before,
+		"after":  after,
+		"found":  found,
+	}
+}
+
+// PadLeft returns s preceded by copies of pad such that the result has at
+// least n runes (Unicode code points). The last copy of pad is truncated as
+// needed. It returns s unchanged if s already has at least n runes.
+//
+// It returns an error if n is negative or pad is empty.
+func PadLeft(s string, n int, pad string) (string, error) {
+	p, err := padding(s, n, pad)
+	if err != nil {
+		return "", err
+	}
+	return p + s, nil
+}
+
+// PadRight returns s followed by copies of pad such that the result has at
+// least n runes (Unicode code points). The last copy of pad is truncated as
+// needed. It returns s unchanged if s already has at least n runes.
+//
+// It returns an error if n is negative or pad is empty.
+func PadRight(s string, n int, pad string) (string, error) {
+	p, err := padding(s, n, pad)
+	if err != nil {
+		return "", err
+	}
+	return s + p, nil
+}
+
+// padding returns the runes of pad needed to extend s to n runes.
+func padding(s string, n int, pad string) (string, error) {
+	if n < 0 {
+		return "", fmt.Errorf("negative length %d", n)
+	}
+	if pad == "" {
+		return "", fmt.Errorf("empty padding")
+	}
+	if n > maxRepeatLen {
+		return "", fmt.Errorf("length %d too large", n)
+	}
+	missing := n - utf8.RuneCountInString(s)
+	if missing <= 0 {
+		return "", nil
+	}
+	p := []rune(pad)
+	b := make([]rune, missing)
+	for i := range b {
+		b[i] = p[i%len(p)]
+	}
+	return string(b), nil
+}
@@ -120,6 +120,73 @@ var pkg = &internal.Package{
 				c.Ret, c.Err = SliceRunes(s, start, end)
 			}
 		},
+	}, {
+		Name: "Repeat",
+		Params: []internal.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.IntKind},
+		},
+		Result: adt.StringKind,
+		Func: func(c *internal.CallCtxt) {
+			s, count := c.String(0), c.Int(1)
+			if c.Do() {
+				c.Ret, c.Err = Repeat(s, count)
+			}
+		},
+	}, {
+		Name: "EqualFold",
+		Params: []internal.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.StringKind},
+		},
+		Result: adt.BoolKind,
+		Func: func(c *internal.CallCtxt) {
+			s, t := c.String(0), c.String(1)
+			if c.Do() {
+				c.Ret = EqualFold(s, t)
+			}
+		},
+	}, {
+		Name: "Cut",
+		Params: []internal.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.StringKind},
+		},
+		Result: adt.StructKind,
+		Func: func(c *internal.CallCtxt) {
+			s, sep := c.String(0), c.String(1)
+			if c.Do() {
+				c.Ret = Cut(s, sep)
+			}
+		},
+	}, {
+		Name: "PadLeft",
+		Params: []internal.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.IntKind},
+			{Kind: adt.StringKind},
+		},
+		Result: adt.StringKind,
+		Func: func(c *internal.CallCtxt) {
+			s, n, pad := c.String(0), c.Int(1), c.String(2)
+			if c.Do() {
+				c.Ret, c.Err = PadLeft(s, n, pad)
+			}
+		},
+	}, {
+		Name: "PadRight",
+		Params: []internal.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.IntKind},
+			{Kind: adt.StringKind},
+		},
+		Result: adt.StringKind,
+		Func: func(c *internal.CallCtxt) {
+			s, n, pad := c.String(0), c.Int(1), c.String(2)
+			if c.Do() {
+				c.Ret, c.Err = PadRight(s, n, pad)
+			}
+		},
 	}, {
 		Name: "Compare",
 		Params: []internal.Param{
@@ -316,19 +383,6 @@ var pkg = &internal.Package{
 				c.Ret = HasSuffix(s, suffix)
 			}
 		},
-	}, {
-		Name: "Repeat",
-		Params: []internal.Param{
-			{Kind: adt.StringKind},
-			{Kind: adt.IntKind},
-		},
-		Result: adt.StringKind,
-		Func: func(c *internal.CallCtxt) {
-			s, count := c.String(0), c.Int(1)
-			if c.Do() {
-				c.Ret = Repeat(s, count)
-			}
-		},
 	}, {
 		Name: "ToUpper",
 		Params: []internal.Param{
@@ -206,5 +206,57 @@ var pkg = &internal.Package{
 				c.Ret = Unix(sec, nsec)
 			}
 		},
+	}, {
+		Name: "Add",
+		Params: []internal.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.StringKind},
+		},
+		Result: adt.StringKind,
+		Func: func(c *internal.CallCtxt) {
+			t, d := c.String(0), c.String(1)
+			if c.Do() {
+				c.Ret, c.Err = Add(t, d)
+			}
+		},
+	}, {
+		Name: "Sub",
+		Params: []internal.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.StringKind},
+		},
+		Result: adt.StringKind,
+		Func: func(c *internal.CallCtxt) {
+			t, u := c.String(0), c.String(1)
+			if c.Do() {
+				c.Ret, c.Err = Sub(t, u)
+			}
+		},
+	}, {
+		Name: "Truncate",
+		Params: []internal.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.StringKind},
+		},
+		Result: adt.StringKind,
+		Func: func(c *internal.CallCtxt) {
+			t, d := c.String(0), c.String(1)
+			if c.Do() {
+				c.Ret, c.Err = Truncate(t, d)
+			}
+		},
+	}, {
+		Name: "Round",
+		Params: []internal.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.StringKind},
+		},
+		Result: adt.StringKind,
+		Func: func(c *internal.CallCtxt) {
+			t, d := c.String(0), c.String(1)
+			if c.Do() {
+				c.Ret, c.Err = Round(t, d)
+			}
+		},
 	}},
 }
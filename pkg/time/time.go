@@ -201,3 +201,91 @@ func Unix(sec int64, nsec int64) string {
 	t := time.Unix(sec, nsec)
 	return t.UTC().Format(time.RFC3339Nano)
 }
+
+// Add returns the time t plus the duration d, where t is an RFC3339 date-time
+// and d is a duration string such as "1h30m". The result uses the same time
+// zone offset as t.
+func Add(t, d string) (string, error) {
+	x, err := parseTime(t)
+	if err != nil {
+		return "", err
+	}
+	y, err := parseDuration(d)
+	if err != nil {
+		return "", err
+	}
+	return x.Add(y).Format(time.RFC3339Nano), nil
+}
+
+// Sub returns the duration t-u between the RFC3339 date-times t and u as a
+// duration string, such as "1h30m0s".
+func Sub(t, u string) (string, error) {
+	x, err := parseTime(t)
+	if err != nil {
+		return "", err
+	}
+	y, err := parseTime(u)
+	if err != nil {
+		return "", err
+	}
+	d := x.Sub(y)
+	if !y.Add(d).Equal(x) {
+		return "", fmt.Errorf("duration between %q and %q out of range", t, u)
+	}
+	return d.String(), nil
+}
+
+// Truncate returns the result of rounding the RFC3339 date-time t down to a
+// multiple of the duration d since the zero time. The duration must be
+// positive. The result uses the same time zone offset as t.
+func Truncate(t, d string) (string, error) {
+	x, y, err := parseTimeAndMultiple(t, d)
+	if err != nil {
+		return "", err
+	}
+	return x.Truncate(y).Format(time.RFC3339Nano), nil
+}
+
+// Round returns the result of rounding the RFC3339 date-time t to the nearest
+// multiple of the duration d since the zero time. Halfway values are rounded
+// up. The duration must be positive. The result uses the same time zone offset
+// as t.
+func Round(t, d string) (string, error) {
+	x, y, err := parseTimeAndMultiple(t, d)
+	if err != nil {
+		return "", err
+	}
+	return x.Round(y).Format(time.RFC3339Nano), nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		// See timeFormat.
+		return t, fmt.Errorf("invalid time %q", s)
+	}
+	return t, nil
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+func parseTimeAndMultiple(t, d string) (time.Time, time.Duration, error) {
+	x, err := parseTime(t)
+	if err != nil {
+		return x, 0, err
+	}
+	y, err := parseDuration(d)
+	if err != nil {
+		return x, 0, err
+	}
+	if y <= 0 {
+		return x, 0, fmt.Errorf("duration %q must be positive", d)
+	}
+	return x, y, nil
+}
@@ -77,6 +77,26 @@
 //     	// $after can be used to specify a task is run after another one, when
 //     	// it does not otherwise refer to an output of that task.
 //     	$after?: Task | [...Task]
+//
+//     	// $retry specifies that a task is run again if it fails.
+//     	$retry?: {
+//     		// attempts is the maximum number of times the task is run.
+//     		attempts: int & >=1
+//
+//     		// backoff is the time to wait before running the task again, in the
+//     		// format accepted by Go's time.ParseDuration. It doubles after each
+//     		// failed attempt.
+//     		backoff: *"1s" | string
+//
+//     		// jitter, when true, randomizes the time waited to be between half
+//     		// and the full backoff. This avoids many tasks retrying in lockstep.
+//     		jitter: *false | bool
+//     	}
+//
+//     	// $secrets lists values, such as tokens, that are replaced with *** in
+//     	// the output of the task, including its results and error messages.
+//     	// Each line of a secret spanning multiple lines is replaced separately.
+//     	$secrets?: [...string]
 //     }
 //
 //     // TODO: consider these options:
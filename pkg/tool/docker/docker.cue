@@ -0,0 +1,77 @@
+// Copyright 2021 The CUE Authors
+// 
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// 
+//     http://www.apache.org/licenses/LICENSE-2.0
+// 
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+// Build builds an image using the docker command line tool.
+Build: {
+	$id: "tool/docker.Build"
+
+	// context is the directory used as the build context.
+	context: *"." | string
+
+	// file is the path of the Dockerfile. The default is the file named
+	// Dockerfile in the build context.
+	file?: string
+
+	// dockerfile, if set, is used as the contents of the Dockerfile instead
+	// of reading it from file. This allows a Dockerfile generated from CUE
+	// to be built directly.
+	dockerfile?: string
+
+	// tags lists the names under which to tag the resulting image, in the
+	// form name:tag.
+	tags: [...string]
+
+	// buildArgs defines the values of build-time variables.
+	buildArgs: [string]: string
+
+	// target selects the build stage to build in a multi-stage Dockerfile.
+	target?: string
+
+	// platform sets the platform of the image, such as "linux/amd64".
+	platform?: string
+
+	// docker is the command used to invoke docker.
+	docker: *"docker" | string
+
+	// imageID is set to the ID of the built image.
+	imageID: string
+}
+
+// Tag creates a tag target that refers to the image source.
+Tag: {
+	$id: "tool/docker.Tag"
+
+	source: string
+	target: string
+
+	// docker is the command used to invoke docker.
+	docker: *"docker" | string
+}
+
+// Push pushes an image to a registry.
+Push: {
+	$id: "tool/docker.Push"
+
+	// image is the name of the image to push, in the form name:tag.
+	image: string
+
+	// docker is the command used to invoke docker.
+	docker: *"docker" | string
+
+	// digest is set to the digest of the pushed image, if reported by the
+	// registry.
+	digest: string
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+//go:generate go run gen.go
+//go:generate gofmt -s -w .
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/internal/task"
+)
+
+func init() {
+	task.Register("tool/docker.Build", newBuildCmd)
+	task.Register("tool/docker.Tag", newTagCmd)
+	task.Register("tool/docker.Push", newPushCmd)
+}
+
+type buildCmd struct{}
+type tagCmd struct{}
+type pushCmd struct{}
+
+func newBuildCmd(v cue.Value) (task.Runner, error) { return &buildCmd{}, nil }
+func newTagCmd(v cue.Value) (task.Runner, error)   { return &tagCmd{}, nil }
+func newPushCmd(v cue.Value) (task.Runner, error)  { return &pushCmd{}, nil }
+
+func (c *buildCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	// The image ID is written to a file, so that the build output can still
+	// be shown to the user.
+	f, err := ioutil.TempFile("", "cue-docker-iid")
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	args, stdin, err := buildArgs(ctx, f.Name())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := run(ctx, stdin, args...); err != nil {
+		return nil, err
+	}
+
+	id, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"imageID": strings.TrimSpace(string(id)),
+	}, nil
+}
+
+// buildArgs returns the arguments to docker for a Build task, along with the
+// input to pass to the command, if any.
+func buildArgs(ctx *task.Context, iidFile string) (args []string, stdin io.Reader, err error) {
+	args = []string{"build", "--iidfile", iidFile}
+
+	if v := ctx.Obj.Lookup("dockerfile"); v.Exists() {
+		str, err := v.String()
+		if err != nil {
+			return nil, nil, err
+		}
+		args = append(args, "--file", "-")
+		stdin = strings.NewReader(str)
+	} else if v := ctx.Obj.Lookup("file"); v.Exists() {
+		args = append(args, "--file", ctx.String("file"))
+	}
+
+	for iter, _ := ctx.Obj.Lookup("tags").List(); iter.Next(); {
+		str, err := iter.Value().String()
+		if err != nil {
+			return nil, nil, err
+		}
+		args = append(args, "--tag", str)
+	}
+
+	var buildArgs []string
+	for iter, _ := ctx.Obj.Lookup("buildArgs").Fields(); iter.Next(); {
+		str, err := iter.Value().String()
+		if err != nil {
+			return nil, nil, err
+		}
+		buildArgs = append(buildArgs, iter.Label()+"="+str)
+	}
+	sort.Strings(buildArgs)
+	for _, a := range buildArgs {
+		args = append(args, "--build-arg", a)
+	}
+
+	for _, name := range []string{"target", "platform"} {
+		if v := ctx.Obj.Lookup(name); v.Exists() {
+			args = append(args, "--"+name, ctx.String(name))
+		}
+	}
+
+	args = append(args, ctx.String("context"))
+	if ctx.Err != nil {
+		return nil, nil, ctx.Err
+	}
+	return args, stdin, nil
+}
+
+func (c *tagCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	source := ctx.String("source")
+	target := ctx.String("target")
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+	_, err = run(ctx, nil, "tag", source, target)
+	return nil, err
+}
+
+func (c *pushCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	image := ctx.String("image")
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+	out, err := run(ctx, nil, "push", image)
+	if err != nil {
+		return nil, err
+	}
+	update := map[string]interface{}{}
+	if digest := parseDigest(out); digest != "" {
+		update["digest"] = digest
+	}
+	return update, nil
+}
+
+var digestRE = regexp.MustCompile(`digest: (sha256:[0-9a-f]{64})`)
+
+// parseDigest returns the digest reported in the output of docker push, or ""
+// if there is none.
+func parseDigest(out []byte) string {
+	m := digestRE.FindSubmatch(out)
+	if m == nil {
+		return ""
+	}
+	return string(m[1])
+}
+
+// run runs docker with the given arguments. The output of the command is
+// written to the output streams of ctx. The output written to stdout is also
+// returned.
+func run(ctx *task.Context, stdin io.Reader, args ...string) ([]byte, error) {
+	bin := ctx.String("docker")
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx.Context, bin, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	if ctx.Stdout != nil {
+		cmd.Stdout = io.MultiWriter(ctx.Stdout, &stdout)
+	}
+	cmd.Stderr = ctx.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("command %q failed: %v",
+			bin+" "+strings.Join(args, " "), err)
+	}
+	return stdout.Bytes(), nil
+}
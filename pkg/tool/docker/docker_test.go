@@ -0,0 +1,119 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/internal/task"
+)
+
+func TestBuildArgs(t *testing.T) {
+	testCases := []struct {
+		val   string
+		args  []string
+		stdin string
+	}{{
+		val:  `context: "."`,
+		args: []string{"build", "--iidfile", "iid", "."},
+	}, {
+		val: `
+		context: "app"
+		file:    "app/Dockerfile.prod"
+		tags: ["app:latest", "app:v1"]
+		buildArgs: {
+			VERSION: "1.0"
+			GOOS:    "linux"
+		}
+		target:   "release"
+		platform: "linux/amd64"
+		`,
+		args: []string{
+			"build", "--iidfile", "iid",
+			"--file", "app/Dockerfile.prod",
+			"--tag", "app:latest",
+			"--tag", "app:v1",
+			"--build-arg", "GOOS=linux",
+			"--build-arg", "VERSION=1.0",
+			"--target", "release",
+			"--platform", "linux/amd64",
+			"app",
+		},
+	}, {
+		val: `
+		context:    "."
+		dockerfile: "FROM scratch\n"
+		`,
+		args:  []string{"build", "--iidfile", "iid", "--file", "-", "."},
+		stdin: "FROM scratch\n",
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			var r cue.Runtime
+			inst, err := r.Compile("test", tc.val)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			args, stdin, err := buildArgs(&task.Context{
+				Context: context.Background(),
+				Obj:     inst.Value(),
+			}, "iid")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(args, tc.args) {
+				t.Error(cmp.Diff(args, tc.args))
+			}
+
+			got := ""
+			if stdin != nil {
+				b, _ := ioutil.ReadAll(stdin)
+				got = string(b)
+			}
+			if got != tc.stdin {
+				t.Errorf("stdin: got %q; want %q", got, tc.stdin)
+			}
+		})
+	}
+}
+
+func TestParseDigest(t *testing.T) {
+	const digest = "sha256:0e3b1c7cb2da5c25e3e6e3a4cc2c8a1b4d54e03cb1c3c05e1d5d3c1b2a4c6e8f"
+
+	testCases := []struct {
+		out  string
+		want string
+	}{{
+		out: `The push refers to repository [docker.io/library/app]
+5f70bf18a086: Pushed
+latest: digest: ` + digest + ` size: 528
+`,
+		want: digest,
+	}, {
+		out:  "Everything up-to-date\n",
+		want: "",
+	}}
+	for _, tc := range testCases {
+		if got := parseDigest([]byte(tc.out)); got != tc.want {
+			t.Errorf("got %q; want %q", got, tc.want)
+		}
+	}
+}
@@ -25,12 +25,49 @@ Run: {
 	// The default is the current working directory.
 	dir?: string
 
+	// timeout, if set, is the maximum duration the command may run, in the
+	// format accepted by Go's time.ParseDuration, such as "1m30s". The
+	// command runs in its own process group, which is killed when the
+	// timeout expires. The timeout is also reported as the deadline of the
+	// task if exportDeadline is set.
+	timeout?: string
+
 	// env defines the environment variables to use for this system.
 	// If the value is a list, the entries mus be of the form key=value,
 	// where the last value takes precendence in the case of multiple
 	// occurrances of the same key.
 	env: [string]: string | [...=~"="]
 
+	// pathPrepend lists directories that are prepended to the PATH of the
+	// command, which is otherwise inherited from env or the current process.
+	// The command itself is also looked up in these directories first.
+	pathPrepend?: [...string]
+
+	// exportDeadline, when true and timeout is set, passes the deadline of
+	// the task to the command in the CUE_TASK_DEADLINE environment variable,
+	// formatted as an RFC3339 timestamp. This allows well-behaved commands to
+	// limit their own running time before they are killed. Deadlines that do
+	// not stem from timeout are not exported.
+	exportDeadline?: bool
+
+	// hashOutput selects the output streams to hash. If it is "stdout" or
+	// true, outputHash is set to the hex-encoded SHA-256 hash of the output
+	// written to stdout. If it is "stderr", errorHash is set to the hash of
+	// the output written to stderr, and if it is "both", both are set. The
+	// hashes are computed as the output is written, so the output need not
+	// be captured to compute them.
+	hashOutput?: bool | "stdout" | "stderr" | "both"
+
+	// prefix, if set, is written before each line of output that is not
+	// captured. If prefix is true, the path of the task within its command is
+	// used, as in "[deploy.build] ". Lines are written as a whole, so that the
+	// output of commands running concurrently is not mixed within a line.
+	prefix?: bool | string
+
+	// tee, when true, writes output that is captured in stdout or stderr to
+	// the stdout or stderr of the current process as well.
+	tee?: bool
+
 	// stdout captures the output from stdout if it is of type bytes or string.
 	// The default value of null indicates it is redirected to the stdout of the
 	// current process.
@@ -44,6 +81,26 @@ Run: {
 	// If it is of typ bytes or string, that input will be used instead.
 	stdin: *null | string | bytes
 
+	// mustSucceed indicates whether a non-zero exit code of the command
+	// results in an error. If it is false, the exit code can be inspected
+	// through exitCode and success instead.
+	mustSucceed: *true | bool
+
+	// resolvedCmd is set to the command line as it was executed, after
+	// splitting cmd, if it is a string, and resolving the path of the
+	// command.
+	resolvedCmd: [...string]
+
+	// exitCode is set to the exit code of the process. It is -1 if the
+	// process was terminated by a signal.
+	exitCode: int
+
+	// outputHash is set to the hash of stdout if hashOutput selects it.
+	outputHash?: string
+
+	// errorHash is set to the hash of stderr if hashOutput selects it.
+	errorHash?: string
+
 	// success is set to true when the process terminates with with a zero exit
 	// code or false otherwise. The user can explicitly specify the value
 	// force a fatal error if the desired success code is not reached.
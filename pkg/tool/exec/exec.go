@@ -18,9 +18,19 @@ package exec
 //go:generate gofmt -s -w .
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"hash"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/errors"
@@ -41,6 +51,18 @@ func newExecCmd(v cue.Value) (task.Runner, error) {
 }
 
 func (c *execCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	timeout, err := parseTimeout(ctx.Obj)
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		tctx, cancel := context.WithTimeout(ctx.Context, timeout)
+		defer cancel()
+		tc := *ctx
+		tc.Context = tctx
+		ctx = &tc
+	}
+
 	cmd, doc, err := mkCommand(ctx)
 	if err != nil {
 		return cue.Value{}, err
@@ -65,33 +87,246 @@ func (c *execCmd) Run(ctx *task.Context) (res interface{}, err error) {
 	} else if cmd.Stdin, err = v.Reader(); err != nil {
 		return nil, errors.Wrapf(err, v.Pos(), "invalid input")
 	}
+	prefix, err := outputPrefix(ctx.Obj)
+	if err != nil {
+		return nil, err
+	}
+	var lines []*lineWriter
+	output := func(w io.Writer) io.Writer {
+		if w == nil || prefix == "" {
+			return w
+		}
+		lw := &lineWriter{w: w, prefix: prefix}
+		lines = append(lines, lw)
+		return lw
+	}
+	tee, _ := ctx.Obj.Lookup("tee").Bool()
+
+	var stdout, stderr bytes.Buffer
 	_, captureOut := stream("stdout")
-	if !captureOut {
-		cmd.Stdout = ctx.Stdout
+	switch {
+	case !captureOut:
+		cmd.Stdout = output(ctx.Stdout)
+	case tee && ctx.Stdout != nil:
+		cmd.Stdout = io.MultiWriter(&stdout, output(ctx.Stdout))
+	default:
+		cmd.Stdout = &stdout
 	}
 	_, captureErr := stream("stderr")
-	if !captureErr {
-		cmd.Stderr = ctx.Stderr
+	switch {
+	case !captureErr:
+		cmd.Stderr = output(ctx.Stderr)
+	case tee && ctx.Stderr != nil:
+		cmd.Stderr = io.MultiWriter(&stderr, output(ctx.Stderr))
+	default:
+		cmd.Stderr = &stderr
 	}
 
-	update := map[string]interface{}{}
-	if captureOut {
-		var stdout []byte
-		stdout, err = cmd.Output()
-		update["stdout"] = string(stdout)
+	update := map[string]interface{}{
+		"resolvedCmd": append([]string{cmd.Path}, cmd.Args[1:]...),
+	}
+	hashOut, hashErr, err := hashStreams(ctx.Obj)
+	if err != nil {
+		return nil, err
+	}
+	// Hash the output as it is written, so that it need not be captured.
+	hOut, hErr := sha256.New(), sha256.New()
+	if hashOut {
+		cmd.Stdout = hashed(cmd.Stdout, hOut)
+	}
+	if hashErr {
+		cmd.Stderr = hashed(cmd.Stderr, hErr)
+	}
+
+	if timeout > 0 {
+		// Run the command in its own process group, so that any processes it
+		// starts are killed along with it on timeout.
+		setProcessGroup(cmd)
+		err = runGroup(ctx.Context, cmd)
 	} else {
 		err = cmd.Run()
 	}
+	for _, w := range lines {
+		if ferr := w.Flush(); err == nil {
+			err = ferr
+		}
+	}
+	if captureOut {
+		update["stdout"] = stdout.String()
+	}
+	if hashOut {
+		update["outputHash"] = hex.EncodeToString(hOut.Sum(nil))
+	}
+	if hashErr {
+		update["errorHash"] = hex.EncodeToString(hErr.Sum(nil))
+	}
 	update["success"] = err == nil
+	if err == nil {
+		update["exitCode"] = 0
+		return update, nil
+	}
+
+	exit := (*exec.ExitError)(nil)
+	if errors.As(err, &exit) {
+		update["exitCode"] = exit.ExitCode()
+		if captureErr {
+			update["stderr"] = stderr.String()
+		}
+	}
+	if timeout > 0 && ctx.Context.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("command %q timed out after %v", doc, timeout)
+	}
+	if exit != nil && !mustSucceed(ctx.Obj) {
+		return update, nil
+	}
+	if exit == nil || !captureErr {
+		update = nil
+	}
+	return update, fmt.Errorf("command %q failed: %v", doc, err)
+}
+
+// hashStreams reports whether the hashOutput field of v selects stdout,
+// stderr, or both to be hashed.
+func hashStreams(v cue.Value) (stdout, stderr bool, err error) {
+	h := v.Lookup("hashOutput")
+	if !h.Exists() {
+		return false, false, nil
+	}
+	if b, err := h.Bool(); err == nil {
+		return b, false, nil
+	}
+	str, err := h.String()
 	if err != nil {
-		if exit := (*exec.ExitError)(nil); errors.As(err, &exit) && captureErr {
-			update["stderr"] = string(exit.Stderr)
-		} else {
-			update = nil
+		return false, false, errors.Wrapf(err, h.Pos(), "invalid hashOutput")
+	}
+	switch str {
+	case "stdout":
+		return true, false, nil
+	case "stderr":
+		return false, true, nil
+	case "both":
+		return true, true, nil
+	}
+	return false, false, errors.Newf(h.Pos(), "invalid hashOutput %q", str)
+}
+
+// hashed returns a writer that writes to both w, if it is not nil, and h.
+func hashed(w io.Writer, h hash.Hash) io.Writer {
+	if w == nil {
+		return h
+	}
+	return io.MultiWriter(w, h)
+}
+
+// outputPrefix reports the prefix for lines of output of the task v, or "" if
+// there is none.
+func outputPrefix(v cue.Value) (string, error) {
+	p := v.Lookup("prefix")
+	if !p.Exists() {
+		return "", nil
+	}
+	if b, err := p.Bool(); err == nil {
+		if !b {
+			return "", nil
 		}
-		err = fmt.Errorf("command %q failed: %v", doc, err)
+		return "[" + taskName(v) + "] ", nil
+	}
+	str, err := p.String()
+	if err != nil {
+		return "", errors.Wrapf(err, p.Pos(), "invalid prefix")
+	}
+	return str, nil
+}
+
+// taskName returns the path of the task v within its command.
+func taskName(v cue.Value) string {
+	sels := v.Path().Selectors()
+	if len(sels) > 2 && sels[0].String() == "command" {
+		sels = sels[2:]
 	}
-	return update, err
+	return cue.MakePath(sels...).String()
+}
+
+// A lineWriter writes each line written to it, preceded by prefix, with a
+// single call to Write of w. Output of several lineWriters that share w is
+// thus interleaved by line only.
+type lineWriter struct {
+	w      io.Writer
+	prefix string
+	buf    []byte // incomplete last line
+}
+
+func (l *lineWriter) Write(b []byte) (n int, err error) {
+	n = len(b)
+	for {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			l.buf = append(l.buf, b...)
+			return n, nil
+		}
+		l.buf = append(l.buf, b[:i+1]...)
+		if err := l.Flush(); err != nil {
+			return 0, err
+		}
+		b = b[i+1:]
+	}
+}
+
+// Flush writes the incomplete last line, if any.
+func (l *lineWriter) Flush() error {
+	if len(l.buf) == 0 {
+		return nil
+	}
+	line := append([]byte(l.prefix), l.buf...)
+	l.buf = l.buf[:0]
+	_, err := l.w.Write(line)
+	return err
+}
+
+// mustSucceed reports whether a non-zero exit code of the command should be
+// reported as an error.
+func mustSucceed(v cue.Value) bool {
+	b, err := v.Lookup("mustSucceed").Bool()
+	return err != nil || b
+}
+
+// runGroup runs cmd and waits for it to complete. If ctx is done before that,
+// the process group of cmd is killed.
+func runGroup(ctx context.Context, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = killProcessGroup(cmd.Process)
+		case <-done:
+		}
+	}()
+	return cmd.Wait()
+}
+
+// parseTimeout reports the duration of the timeout field of v, or 0 if it is
+// not set.
+func parseTimeout(v cue.Value) (time.Duration, error) {
+	t := v.Lookup("timeout")
+	if !t.Exists() {
+		return 0, nil
+	}
+	str, err := t.String()
+	if err != nil {
+		return 0, err
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, errors.Newf(t.Pos(), "invalid timeout %q: %v", str, err)
+	}
+	if d <= 0 {
+		return 0, errors.Newf(t.Pos(), "timeout must be positive, found %v", d)
+	}
+	return d, nil
 }
 
 func mkCommand(ctx *task.Context) (c *exec.Cmd, doc string, err error) {
@@ -135,6 +370,21 @@ func mkCommand(ctx *task.Context) (c *exec.Cmd, doc string, err error) {
 		return nil, "", errors.New("empty command")
 	}
 
+	var dirs []string
+	for iter, _ := ctx.Obj.Lookup("pathPrepend").List(); iter.Next(); {
+		dir, err := iter.Value().String()
+		if err != nil {
+			return nil, "", err
+		}
+		dirs = append(dirs, dir)
+	}
+
+	// Resolve the command with the prepended directories, as exec.Command
+	// only considers the PATH of the current process.
+	if p := lookPath(bin, dirs); p != "" {
+		bin = p
+	}
+
 	cmd := exec.CommandContext(ctx.Context, bin, args...)
 
 	cmd.Dir, _ = ctx.Obj.Lookup("dir").String()
@@ -168,5 +418,78 @@ func mkCommand(ctx *task.Context) (c *exec.Cmd, doc string, err error) {
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", label, str))
 	}
 
+	if len(dirs) > 0 {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = prependPath(cmd.Env, dirs)
+	}
+
+	// Only export the deadline of a timeout set for this task, and not one
+	// inherited from the context in which the task runs.
+	export, _ := ctx.Obj.Lookup("exportDeadline").Bool()
+	if timeout, _ := parseTimeout(ctx.Obj); export && timeout > 0 {
+		if deadline, ok := ctx.Context.Deadline(); ok {
+			if cmd.Env == nil {
+				cmd.Env = os.Environ()
+			}
+			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s",
+				deadlineEnv, deadline.UTC().Format(time.RFC3339)))
+		}
+	}
+
 	return cmd, doc, nil
 }
+
+// deadlineEnv is the environment variable used to pass the deadline of a task
+// to a command if exportDeadline is set.
+const deadlineEnv = "CUE_TASK_DEADLINE"
+
+// lookPath reports the path of the executable bin in the first of dirs that
+// contains it, or "" if there is no such directory or if bin is not a plain
+// command name.
+func lookPath(bin string, dirs []string) string {
+	if strings.ContainsAny(bin, `/\`) {
+		return ""
+	}
+	for _, dir := range dirs {
+		if p, err := exec.LookPath(filepath.Join(dir, bin)); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// prependPath returns env with dirs prepended to the last PATH entry, or with
+// a new PATH entry if there is none.
+func prependPath(env, dirs []string) []string {
+	key, path := "PATH", ""
+	i := len(env) - 1
+	for ; i >= 0; i-- {
+		k := strings.SplitN(env[i], "=", 2)
+		if isPathKey(k[0]) && len(k) == 2 {
+			key, path = k[0], k[1]
+			break
+		}
+	}
+
+	list := append([]string{}, dirs...)
+	if path != "" {
+		list = append(list, path)
+	}
+	entry := key + "=" + strings.Join(list, string(filepath.ListSeparator))
+
+	if i < 0 {
+		return append(env, entry)
+	}
+	env = append([]string{}, env...)
+	env[i] = entry
+	return env
+}
+
+func isPathKey(key string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(key, "PATH")
+	}
+	return key == "PATH"
+}
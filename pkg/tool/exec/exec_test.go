@@ -16,7 +16,15 @@ package exec
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
@@ -69,3 +77,423 @@ func TestEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestExportDeadline(t *testing.T) {
+	deadline := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	testCases := []struct {
+		name string
+		val  string
+		env  []string
+	}{{
+		name: "timeout",
+		val: `
+		cmd: "echo"
+		env: WHO: "World"
+		timeout: "1h"
+		exportDeadline: true
+		`,
+		env: []string{"WHO=World", "CUE_TASK_DEADLINE=2021-03-04T05:06:07Z"},
+	}, {
+		// The deadline of the parent context is not exported.
+		name: "no timeout",
+		val: `
+		cmd: "echo"
+		env: WHO: "World"
+		exportDeadline: true
+		`,
+		env: []string{"WHO=World"},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var r cue.Runtime
+			inst, err := r.Compile("test", tc.val)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithDeadline(context.Background(), deadline)
+			defer cancel()
+
+			cmd, _, err := mkCommand(&task.Context{
+				Context: ctx,
+				Obj:     inst.Value(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !cmp.Equal(cmd.Env, tc.env) {
+				t.Error(cmp.Diff(cmd.Env, tc.env))
+			}
+		})
+	}
+}
+
+func TestPathPrepend(t *testing.T) {
+	sep := string(filepath.ListSeparator)
+	testCases := []struct {
+		val string
+		env []string
+	}{{
+		val: `
+		cmd: "echo"
+		env: ["A=B", "PATH=/usr/bin"]
+		pathPrepend: ["/a", "/b"]
+		`,
+		env: []string{"A=B", "PATH=/a" + sep + "/b" + sep + "/usr/bin"},
+	}, {
+		val: `
+		cmd: "echo"
+		env: A: "B"
+		pathPrepend: ["/a"]
+		`,
+		env: []string{"A=B", "PATH=/a"},
+	}, {
+		val: `
+		cmd: "echo"
+		env: A: "B"
+		pathPrepend: []
+		`,
+		env: []string{"A=B"},
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			var r cue.Runtime
+			inst, err := r.Compile("test", tc.val)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cmd, _, err := mkCommand(&task.Context{
+				Context: context.Background(),
+				Obj:     inst.Value(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !cmp.Equal(cmd.Env, tc.env) {
+				t.Error(cmp.Diff(cmd.Env, tc.env))
+			}
+		})
+	}
+}
+
+func TestResolvedCmd(t *testing.T) {
+	bin, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	var r cue.Runtime
+	inst, err := r.Compile("test", fmt.Sprintf(`
+	cmd:    [%q, "-test.run=^$"]
+	stdout: string
+	`, bin))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := (&execCmd{}).Run(&task.Context{
+		Context: context.Background(),
+		Obj:     inst.Value(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := res.(map[string]interface{})["resolvedCmd"]
+	want := []string{bin, "-test.run=^$"}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
+func TestHashOutput(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip(err)
+	}
+	hash := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+
+	testCases := []struct {
+		hash   string
+		update map[string]interface{}
+	}{{
+		hash:   "true",
+		update: map[string]interface{}{"outputHash": hash("out\n")},
+	}, {
+		hash:   `"stdout"`,
+		update: map[string]interface{}{"outputHash": hash("out\n")},
+	}, {
+		hash:   `"stderr"`,
+		update: map[string]interface{}{"errorHash": hash("err\n")},
+	}, {
+		hash: `"both"`,
+		update: map[string]interface{}{
+			"outputHash": hash("out\n"),
+			"errorHash":  hash("err\n"),
+		},
+	}, {
+		hash:   "false",
+		update: map[string]interface{}{},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.hash, func(t *testing.T) {
+			var r cue.Runtime
+			inst, err := r.Compile("test", fmt.Sprintf(`
+			cmd:        [%q, "-c", "echo out; echo err >&2"]
+			stdout:     string
+			stderr:     string
+			hashOutput: %s
+			`, sh, tc.hash))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := (&execCmd{}).Run(&task.Context{
+				Context: context.Background(),
+				Obj:     inst.Value(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			update := res.(map[string]interface{})
+			if update["stdout"] != "out\n" {
+				t.Errorf("got stdout %q; want %q", update["stdout"], "out\n")
+			}
+			for _, k := range []string{"outputHash", "errorHash"} {
+				if got, want := update[k], tc.update[k]; got != want {
+					t.Errorf("%s: got %v; want %v", k, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	testCases := []struct {
+		val string
+		err string
+	}{{
+		// The sleep runs in a child process of the shell, which is killed
+		// along with it.
+		val: fmt.Sprintf(`
+		cmd:     [%q, "-c", "sleep 10; echo done"]
+		stdout:  string
+		timeout: "100ms"
+		`, sh),
+		err: `command "` + sh + ` -c sleep 10; echo done" timed out after 100ms`,
+	}, {
+		val: fmt.Sprintf(`
+		cmd:     [%q, "-c", "true"]
+		timeout: "10s"
+		`, sh),
+	}, {
+		val: `
+		cmd:     "true"
+		timeout: "soon"
+		`,
+		err: `invalid timeout "soon": time: invalid duration "soon"`,
+	}, {
+		val: `
+		cmd:     "true"
+		timeout: "-1s"
+		`,
+		err: "timeout must be positive, found -1s",
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			var r cue.Runtime
+			inst, err := r.Compile("test", tc.val)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Now()
+			_, err = (&execCmd{}).Run(&task.Context{
+				Context: context.Background(),
+				Obj:     inst.Value(),
+			})
+			if d := time.Since(start); d > 5*time.Second {
+				t.Errorf("command was not killed in time: ran for %v", d)
+			}
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tc.err {
+				t.Errorf("got error %q; want %q", got, tc.err)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	testCases := []struct {
+		val    string
+		update map[string]interface{}
+		err    string
+	}{{
+		val: `
+		cmd:         [SH, "-c", "exit 3"]
+		mustSucceed: false
+		`,
+		update: map[string]interface{}{
+			"resolvedCmd": []string{sh, "-c", "exit 3"},
+			"success":     false,
+			"exitCode":    3,
+		},
+	}, {
+		val: `
+		cmd:         [SH, "-c", "exit 0"]
+		mustSucceed: false
+		`,
+		update: map[string]interface{}{
+			"resolvedCmd": []string{sh, "-c", "exit 0"},
+			"success":     true,
+			"exitCode":    0,
+		},
+	}, {
+		val: `
+		cmd: [SH, "-c", "exit 3"]
+		`,
+		err: `command "` + sh + ` -c exit 3" failed: exit status 3`,
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			var r cue.Runtime
+			inst, err := r.Compile("test", tc.val+fmt.Sprintf("\nSH: %q", sh))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := (&execCmd{}).Run(&task.Context{
+				Context: context.Background(),
+				Obj:     inst.Value(),
+			})
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tc.err {
+				t.Fatalf("got error %q; want %q", got, tc.err)
+			}
+			if err != nil {
+				return
+			}
+			if update := res.(map[string]interface{}); !cmp.Equal(update, tc.update) {
+				t.Error(cmp.Diff(update, tc.update))
+			}
+		})
+	}
+}
+
+func TestPrefixTee(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	const script = `printf 'a\nb\nc'; printf 'd\n' >&2`
+
+	testCases := []struct {
+		val    string
+		stdout string
+		stderr string
+		update string
+	}{{
+		val:    `prefix: "[x] "`,
+		stdout: "[x] a\n[x] b\n[x] c",
+		stderr: "[x] d\n",
+	}, {
+		val:    `prefix: false`,
+		stdout: "a\nb\nc",
+		stderr: "d\n",
+	}, {
+		val: `
+		stdout: string
+		tee:    true
+		prefix: "> "
+		`,
+		stdout: "> a\n> b\n> c",
+		stderr: "> d\n",
+		update: "a\nb\nc",
+	}, {
+		val: `
+		stdout: string
+		prefix: "> "
+		`,
+		stderr: "> d\n",
+		update: "a\nb\nc",
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			var r cue.Runtime
+			inst, err := r.Compile("test", fmt.Sprintf("cmd: [%q, \"-c\", %q]\n", sh, script)+tc.val)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var stdout, stderr strings.Builder
+			res, err := (&execCmd{}).Run(&task.Context{
+				Context: context.Background(),
+				Stdout:  &stdout,
+				Stderr:  &stderr,
+				Obj:     inst.Value(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := stdout.String(); got != tc.stdout {
+				t.Errorf("stdout: got %q; want %q", got, tc.stdout)
+			}
+			if got := stderr.String(); got != tc.stderr {
+				t.Errorf("stderr: got %q; want %q", got, tc.stderr)
+			}
+			if got, _ := res.(map[string]interface{})["stdout"].(string); got != tc.update {
+				t.Errorf("captured: got %q; want %q", got, tc.update)
+			}
+		})
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	var writes []string
+	w := &lineWriter{w: writerFunc(func(p []byte) (int, error) {
+		writes = append(writes, string(p))
+		return len(p), nil
+	}), prefix: "p: "}
+
+	for _, s := range []string{"a", "b\nc\n", "", "d\ne"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"p: ab\n", "p: c\n", "p: d\n", "p: e"}
+	if !cmp.Equal(writes, want) {
+		t.Error(cmp.Diff(writes, want))
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
@@ -20,16 +20,27 @@ var pkg = &internal.Package{
 	Native: []*internal.Builtin{},
 	CUE: `{
 	Run: {
-		$id:  *"tool/exec.Run" | "exec"
-		cmd:  string | [string, ...string]
-		dir?: string
+		$id:      *"tool/exec.Run" | "exec"
+		cmd:      string | [string, ...string]
+		dir?:     string
+		timeout?: string
 		env: {
 			[string]: string | [...=~"="]
 		}
-		stdout:  *null | string | bytes
-		stderr:  *null | string | bytes
-		stdin:   *null | string | bytes
-		success: bool
+		pathPrepend?: [...string]
+		exportDeadline?: bool
+		hashOutput?:     bool | "stdout" | "stderr" | "both"
+		prefix?:         bool | string
+		tee?:            bool
+		stdout:          *null | string | bytes
+		stderr:          *null | string | bytes
+		stdin:           *null | string | bytes
+		mustSucceed:     *true | bool
+		resolvedCmd: [...string]
+		exitCode:    int
+		outputHash?: string
+		errorHash?:  string
+		success:     bool
 	}
 }`,
 }
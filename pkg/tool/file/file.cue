@@ -75,3 +75,53 @@ Glob: {
 	glob: !=""
 	files: [...string]
 }
+
+// Copy copies a file or, recursively, a directory.
+Copy: {
+	$id: "tool/file.Copy"
+
+	// source names the file or directory to copy.
+	//
+	// Relative names are taken relative to the current working directory.
+	// Slashes are converted to the native OS path separator.
+	source: !=""
+
+	// destination names the file or directory to create. Existing files are
+	// overwritten. The permissions of the copied files are those of the
+	// source files.
+	//
+	// Relative names are taken relative to the current working directory.
+	// Slashes are converted to the native OS path separator.
+	destination: !=""
+}
+
+// Mkdir creates a directory at the specified path.
+Mkdir: {
+	$id: "tool/file.Mkdir"
+
+	// path names the directory to create.
+	//
+	// Relative names are taken relative to the current working directory.
+	// Slashes are converted to the native OS path separator.
+	path: !=""
+
+	// createParents defines whether to create the parent directories of path
+	// if they do not exist. In that case, it is not an error if the
+	// directory already exists.
+	createParents: bool | *false
+
+	// permissions defines the permissions to use for the created directories.
+	permissions: int | *0o755
+}
+
+// RemoveAll removes a file or a directory with all its contents. It is not
+// an error if the path does not exist.
+RemoveAll: {
+	$id: "tool/file.RemoveAll"
+
+	// path names the file or directory to remove.
+	//
+	// Relative names are taken relative to the current working directory.
+	// Slashes are converted to the native OS path separator.
+	path: !=""
+}
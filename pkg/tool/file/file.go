@@ -18,6 +18,7 @@ package file
 //go:generate gofmt -s -w .
 
 import (
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -31,17 +32,27 @@ func init() {
 	task.Register("tool/file.Append", newAppendCmd)
 	task.Register("tool/file.Create", newCreateCmd)
 	task.Register("tool/file.Glob", newGlobCmd)
+	task.Register("tool/file.Copy", newCopyCmd)
+	task.Register("tool/file.Mkdir", newMkdirCmd)
+	task.Register("tool/file.RemoveAll", newRemoveAllCmd)
 }
 
 func newReadCmd(v cue.Value) (task.Runner, error)   { return &cmdRead{}, nil }
 func newAppendCmd(v cue.Value) (task.Runner, error) { return &cmdAppend{}, nil }
 func newCreateCmd(v cue.Value) (task.Runner, error) { return &cmdCreate{}, nil }
 func newGlobCmd(v cue.Value) (task.Runner, error)   { return &cmdGlob{}, nil }
+func newCopyCmd(v cue.Value) (task.Runner, error)   { return &cmdCopy{}, nil }
+func newMkdirCmd(v cue.Value) (task.Runner, error)  { return &cmdMkdir{}, nil }
+
+func newRemoveAllCmd(v cue.Value) (task.Runner, error) { return &cmdRemoveAll{}, nil }
 
 type cmdRead struct{}
 type cmdAppend struct{}
 type cmdCreate struct{}
 type cmdGlob struct{}
+type cmdCopy struct{}
+type cmdMkdir struct{}
+type cmdRemoveAll struct{}
 
 func (c *cmdRead) Run(ctx *task.Context) (res interface{}, err error) {
 	filename := ctx.String("filename")
@@ -111,3 +122,81 @@ func (c *cmdGlob) Run(ctx *task.Context) (res interface{}, err error) {
 	files := map[string]interface{}{"files": m}
 	return files, err
 }
+
+func (c *cmdCopy) Run(ctx *task.Context) (res interface{}, err error) {
+	var (
+		src = filepath.FromSlash(ctx.String("source"))
+		dst = filepath.FromSlash(ctx.String("destination"))
+	)
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+
+	return nil, filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch mode := info.Mode(); {
+		case mode.IsDir():
+			return os.MkdirAll(target, mode.Perm())
+		case mode.IsRegular():
+			return copyFile(target, path, mode.Perm())
+		default:
+			return nil // skip symbolic links and other special files
+		}
+	})
+}
+
+// copyFile copies the regular file src to dst, creating dst with the given
+// permissions if it does not exist.
+func copyFile(dst, src string, perm os.FileMode) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (c *cmdMkdir) Run(ctx *task.Context) (res interface{}, err error) {
+	var (
+		path    = filepath.FromSlash(ctx.String("path"))
+		mode    = ctx.Int64("permissions")
+		parents = ctx.Lookup("createParents")
+	)
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+	createParents, err := parents.Bool()
+	if err != nil {
+		return nil, err
+	}
+
+	if createParents {
+		return nil, os.MkdirAll(path, os.FileMode(mode))
+	}
+	return nil, os.Mkdir(path, os.FileMode(mode))
+}
+
+func (c *cmdRemoveAll) Run(ctx *task.Context) (res interface{}, err error) {
+	path := filepath.FromSlash(ctx.String("path"))
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+
+	return nil, os.RemoveAll(path)
+}
@@ -137,3 +137,59 @@ func TestGlob(t *testing.T) {
 		t.Errorf("got %v; want %v", got, want)
 	}
 }
+
+func TestCopyMkdirRemoveAll(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filetest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	base := filepath.ToSlash(dir)
+
+	run := func(r task.Runner, kind, expr string) {
+		t.Helper()
+		v := parse(t, kind, expr)
+		if _, err := r.Run(&task.Context{Obj: v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run(&cmdMkdir{}, "tool/file.Mkdir", fmt.Sprintf(`{
+		path:          "%s/src/sub"
+		createParents: true
+	}`, base))
+	err = ioutil.WriteFile(filepath.Join(dir, "src", "sub", "a.txt"), []byte("a"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := parse(t, "tool/file.Mkdir", fmt.Sprintf(`{path: "%s/x/y"}`, base))
+	if _, err := (&cmdMkdir{}).Run(&task.Context{Obj: v}); err == nil {
+		t.Error("expected error creating directory without parents")
+	}
+
+	run(&cmdCopy{}, "tool/file.Copy", fmt.Sprintf(`{
+		source:      "%[1]s/src"
+		destination: "%[1]s/dst"
+	}`, base))
+	b, err := ioutil.ReadFile(filepath.Join(dir, "dst", "sub", "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), "a"; got != want {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	run(&cmdCopy{}, "tool/file.Copy", fmt.Sprintf(`{
+		source:      "%[1]s/src/sub/a.txt"
+		destination: "%[1]s/b.txt"
+	}`, base))
+	if _, err := os.Stat(filepath.Join(dir, "b.txt")); err != nil {
+		t.Error(err)
+	}
+
+	run(&cmdRemoveAll{}, "tool/file.RemoveAll", fmt.Sprintf(`{path: "%s/src"}`, base))
+	if _, err := os.Stat(filepath.Join(dir, "src")); !os.IsNotExist(err) {
+		t.Errorf("src not removed: %v", err)
+	}
+}
@@ -41,5 +41,20 @@ var pkg = &internal.Package{
 		glob: !=""
 		files: [...string]
 	}
+	Copy: {
+		$id:         "tool/file.Copy"
+		source:      !=""
+		destination: !=""
+	}
+	Mkdir: {
+		$id:           "tool/file.Mkdir"
+		path:          !=""
+		createParents: bool | *false
+		permissions:   int | *493
+	}
+	RemoveAll: {
+		$id:  "tool/file.RemoveAll"
+		path: !=""
+	}
 }`,
 }
@@ -25,8 +25,38 @@ Do: {
 	method: string
 	url:    string // TODO: make url.URL type
 
+	// tls configures the TLS connections of the client.
+	tls: {
+		// caCert, if set, holds the PEM-encoded certificates of the
+		// certificate authorities to trust instead of those of the system.
+		caCert?: bytes | string
+
+		// cert and key, if set, hold the PEM-encoded certificate and key
+		// the client presents to the server.
+		cert?: bytes | string
+		key?:  bytes | string
+
+		// insecureSkipVerify disables the verification of the certificate
+		// of the server. It should only be used for testing.
+		insecureSkipVerify: bool | *false
+	}
+
+	// followRedirects defines whether redirects are followed. If false, the
+	// response of the redirect is returned.
+	followRedirects: bool | *true
+
 	request: {
 		body?: bytes | string
+
+		// form, if set, defines a body of type multipart/form-data with the
+		// given fields. A field is either a string value or a file. The
+		// contents of a file are read from filename if not specified.
+		// It is an error to set both body and form.
+		form?: [string]: string | {
+			filename:  string
+			contents?: bytes | string
+		}
+
 		header: [string]:  string | [...string]
 		trailer: [string]: string | [...string]
 	}
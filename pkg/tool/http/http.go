@@ -19,11 +19,16 @@ package http
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
+	"path/filepath"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/internal/task"
 )
 
@@ -47,13 +52,23 @@ func (c *httpCmd) Run(ctx *task.Context) (res interface{}, err error) {
 		u      = ctx.String("url")
 	)
 	var r io.Reader
+	contentType := ""
 	if obj := ctx.Obj.Lookup("request"); obj.Exists() {
-		if v := obj.Lookup("body"); v.Exists() {
-			r, err = v.Reader()
+		body, form := obj.Lookup("body"), obj.Lookup("form")
+		switch {
+		case body.Exists() && form.Exists():
+			return nil, errors.Newf(form.Pos(), "cannot set both body and form")
+		case body.Exists():
+			r, err = body.Reader()
 			if err != nil {
 				return nil, err
 			}
-		} else {
+		case form.Exists():
+			r, contentType, err = parseForm(form)
+			if err != nil {
+				return nil, err
+			}
+		default:
 			r = bytes.NewReader([]byte(""))
 		}
 		if header, err = parseHeaders(obj, "header"); err != nil {
@@ -73,11 +88,21 @@ func (c *httpCmd) Run(ctx *task.Context) (res interface{}, err error) {
 	}
 	req.Header = header
 	req.Trailer = trailer
+	if contentType != "" {
+		if req.Header == nil {
+			req.Header = http.Header{}
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	client, err := newClient(ctx.Obj)
+	if err != nil {
+		return nil, err
+	}
 
 	// TODO:
 	//  - retry logic
-	//  - TLS certs
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -114,3 +139,124 @@ func parseHeaders(obj cue.Value, label string) (http.Header, error) {
 	}
 	return h, nil
 }
+
+// newClient returns a client configured with the tls and followRedirects
+// fields of v.
+func newClient(v cue.Value) (*http.Client, error) {
+	client := &http.Client{}
+
+	if t := v.Lookup("tls"); t.Exists() {
+		cfg, err := parseTLS(t)
+		if err != nil {
+			return nil, err
+		}
+		if cfg != nil {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = cfg
+			client.Transport = transport
+		}
+	}
+
+	if follow, err := v.Lookup("followRedirects").Bool(); err == nil && !follow {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return client, nil
+}
+
+// parseTLS returns the TLS configuration defined by v, or nil if v does not
+// deviate from the default configuration.
+func parseTLS(v cue.Value) (*tls.Config, error) {
+	var cfg *tls.Config
+	config := func() *tls.Config {
+		if cfg == nil {
+			cfg = &tls.Config{}
+		}
+		return cfg
+	}
+
+	if ca := v.Lookup("caCert"); ca.Exists() {
+		b, err := ca.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, errors.Newf(ca.Pos(), "no valid certificates in caCert")
+		}
+		config().RootCAs = pool
+	}
+
+	cert, key := v.Lookup("cert"), v.Lookup("key")
+	if cert.Exists() || key.Exists() {
+		c, err := cert.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		k, err := key.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		pair, err := tls.X509KeyPair(c, k)
+		if err != nil {
+			return nil, err
+		}
+		config().Certificates = []tls.Certificate{pair}
+	}
+
+	if skip, err := v.Lookup("insecureSkipVerify").Bool(); err == nil && skip {
+		config().InsecureSkipVerify = true
+	}
+	return cfg, nil
+}
+
+// parseForm returns a multipart/form-data body for the fields of v and its
+// content type.
+func parseForm(v cue.Value) (r io.Reader, contentType string, err error) {
+	iter, err := v.Fields()
+	if err != nil {
+		return nil, "", err
+	}
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+	for iter.Next() {
+		name, f := iter.Label(), iter.Value()
+
+		if f.IncompleteKind() == cue.StringKind {
+			s, err := f.String()
+			if err != nil {
+				return nil, "", err
+			}
+			if err := w.WriteField(name, s); err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+
+		filename, err := f.Lookup("filename").String()
+		if err != nil {
+			return nil, "", err
+		}
+		var b []byte
+		if c := f.Lookup("contents"); c.Exists() {
+			b, err = c.Bytes()
+		} else {
+			b, err = ioutil.ReadFile(filepath.FromSlash(filename))
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		part, err := w.CreateFormFile(name, filepath.Base(filepath.FromSlash(filename)))
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(b); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf, w.FormDataContentType(), nil
+}
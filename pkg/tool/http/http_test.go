@@ -15,11 +15,17 @@
 package http
 
 import (
+	"encoding/pem"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/internal/task"
+	"cuelang.org/go/internal/value"
 )
 
 func TestParseHeaders(t *testing.T) {
@@ -93,3 +99,149 @@ func TestParseHeaders(t *testing.T) {
 		})
 	}
 }
+
+func parse(t *testing.T, kind, expr string) cue.Value {
+	t.Helper()
+
+	x, err := parser.ParseExpr("test", expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r cue.Runtime
+	i, err := r.CompileExpr(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value.UnifyBuiltin(i.Value(), kind)
+}
+
+func TestTLS(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "secure")
+	}))
+	defer s.Close()
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw})
+
+	testCases := []struct {
+		tls string
+		out string
+		err bool
+	}{{
+		tls: `{}`,
+		err: true,
+	}, {
+		tls: fmt.Sprintf(`{caCert: %q}`, ca),
+		out: "secure",
+	}, {
+		tls: `{insecureSkipVerify: true}`,
+		out: "secure",
+	}, {
+		tls: `{caCert: "foo"}`,
+		err: true,
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			v := parse(t, "tool/http.Do", fmt.Sprintf(
+				`{method: "GET", url: %q, tls: %s}`, s.URL, tc.tls))
+			got, err := (*httpCmd).Run(nil, &task.Context{Obj: v})
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := got.(map[string]interface{})["response"].(map[string]interface{})["body"]
+			if body != tc.out {
+				t.Errorf("got %q; want %q", body, tc.out)
+			}
+		})
+	}
+}
+
+func TestFollowRedirects(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "new")
+	}))
+	defer s.Close()
+
+	testCases := []struct {
+		follow string
+		code   int
+	}{
+		{follow: "true", code: http.StatusOK},
+		{follow: "false", code: http.StatusFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.follow, func(t *testing.T) {
+			v := parse(t, "tool/http.Do", fmt.Sprintf(
+				`{method: "GET", url: "%s/old", followRedirects: %s}`, s.URL, tc.follow))
+			got, err := (*httpCmd).Run(nil, &task.Context{Obj: v})
+			if err != nil {
+				t.Fatal(err)
+			}
+			code := got.(map[string]interface{})["response"].(map[string]interface{})["statusCode"]
+			if code != tc.code {
+				t.Errorf("got %v; want %v", code, tc.code)
+			}
+		})
+	}
+}
+
+func TestForm(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f, h, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+		b := make([]byte, h.Size)
+		_, _ = f.Read(b)
+		fmt.Fprintf(w, "%s %s %s", r.FormValue("name"), h.Filename, b)
+	}))
+	defer s.Close()
+
+	v := parse(t, "tool/http.Do", fmt.Sprintf(`{
+		method: "POST"
+		url: %q
+		request: form: {
+			name: "foo"
+			file: {
+				filename: "dir/data.txt"
+				contents: "hello"
+			}
+		}
+	}`, s.URL))
+	got, err := (*httpCmd).Run(nil, &task.Context{Obj: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := got.(map[string]interface{})["response"].(map[string]interface{})["body"]
+	if want := "foo data.txt hello"; body != want {
+		t.Errorf("got %q; want %q", body, want)
+	}
+
+	v = parse(t, "tool/http.Do", fmt.Sprintf(`{
+		method: "POST"
+		url: %q
+		request: {
+			body: "x"
+			form: name: "foo"
+		}
+	}`, s.URL))
+	if _, err := (*httpCmd).Run(nil, &task.Context{Obj: v}); err == nil {
+		t.Error("expected error for both body and form")
+	}
+}
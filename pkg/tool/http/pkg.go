@@ -35,8 +35,21 @@ var pkg = &internal.Package{
 		$id:    *"tool/http.Do" | "http"
 		method: string
 		url:    string
+		tls: {
+			caCert?:            bytes | string
+			cert?:              bytes | string
+			key?:               bytes | string
+			insecureSkipVerify: bool | *false
+		}
+		followRedirects: bool | *true
 		request: {
 			body?: bytes | string
+			form?: {
+				[string]: string | {
+					filename:  string
+					contents?: bytes | string
+				}
+			}
 			header: {
 				[string]: string | [...string]
 			}
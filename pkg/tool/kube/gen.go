@@ -0,0 +1,51 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build ignore
+
+package main
+
+// TODO: remove when we have a cuedoc server. Until then,
+// piggyback on pkg.go.dev.
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+const msg = `// Code generated by cue get go. DO NOT EDIT.
+
+// Package kube provides tasks for managing Kubernetes objects in a cluster.
+//
+// The tasks run the kubectl command line tool, which must be installed
+// separately and is looked up on PATH unless the kubectl field names another
+// location. They do not talk to the Kubernetes API directly, so kubectl
+// determines which versions and authentication methods are supported.
+//
+// These are the supported tasks:
+//     %s
+package kube
+`
+
+func main() {
+	f, _ := os.Create("doc.go")
+	defer f.Close()
+	b, _ := ioutil.ReadFile("kube.cue")
+	i := bytes.Index(b, []byte("package kube"))
+	b = b[i+len("package kube")+1:]
+	b = bytes.ReplaceAll(b, []byte("\n"), []byte("\n//     "))
+	fmt.Fprintf(f, msg, string(b))
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 The CUE Authors
+// 
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// 
+//     http://www.apache.org/licenses/LICENSE-2.0
+// 
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The tasks in this package run the kubectl command line tool, which must
+// be installed separately. They do not talk to the Kubernetes API directly.
+package kube
+
+// Apply creates or updates objects in a cluster using the kubectl command
+// line tool.
+//
+// The cluster is selected with kubeconfig and context. By default, kubectl
+// uses the configuration named by the KUBECONFIG environment variable or
+// ~/.kube/config and, when running in a pod, the service account of the pod.
+Apply: {
+	$id: "tool/kube.Apply"
+
+	// objects lists the Kubernetes objects to apply.
+	objects: [...{...}]
+
+	// serverSide selects server-side apply, in which the API server
+	// computes the result and tracks the ownership of fields.
+	serverSide: bool | *false
+
+	// fieldManager is the name used to track the ownership of fields with
+	// server-side apply.
+	fieldManager?: string
+
+	// prune, if set, deletes the objects in the cluster that match selector
+	// but are not listed in objects.
+	prune?: {
+		// selector is a label selector, such as "app=frontend".
+		selector: string
+	}
+
+	// namespace, if set, is used for objects that do not specify one.
+	namespace?: string
+
+	// kubeconfig is the path of the kubeconfig file to use.
+	kubeconfig?: string
+
+	// context is the name of the kubeconfig context to use.
+	context?: string
+
+	// kubectl is the command used to invoke kubectl.
+	kubectl: *"kubectl" | string
+}
+
+// Delete deletes objects from a cluster using the kubectl command line tool.
+Delete: {
+	$id: "tool/kube.Delete"
+
+	// objects lists the Kubernetes objects to delete.
+	objects: [...{...}]
+
+	// ignoreNotFound reports objects that do not exist as deleted instead
+	// of failing.
+	ignoreNotFound: bool | *true
+
+	// namespace, if set, is used for objects that do not specify one.
+	namespace?: string
+
+	// kubeconfig is the path of the kubeconfig file to use.
+	kubeconfig?: string
+
+	// context is the name of the kubeconfig context to use.
+	context?: string
+
+	// kubectl is the command used to invoke kubectl.
+	kubectl: *"kubectl" | string
+}
+
+// Diff compares objects against their state in a cluster using the kubectl
+// command line tool.
+Diff: {
+	$id: "tool/kube.Diff"
+
+	// objects lists the Kubernetes objects to compare.
+	objects: [...{...}]
+
+	// serverSide computes the difference using server-side apply.
+	serverSide: bool | *false
+
+	// namespace, if set, is used for objects that do not specify one.
+	namespace?: string
+
+	// kubeconfig is the path of the kubeconfig file to use.
+	kubeconfig?: string
+
+	// context is the name of the kubeconfig context to use.
+	context?: string
+
+	// kubectl is the command used to invoke kubectl.
+	kubectl: *"kubectl" | string
+
+	// diff is set to the differences in unified diff format.
+	diff: string
+
+	// changed reports whether applying objects would change the cluster.
+	changed: bool
+}
@@ -0,0 +1,223 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+//go:generate go run gen.go
+//go:generate gofmt -s -w .
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/internal/task"
+)
+
+func init() {
+	task.Register("tool/kube.Apply", newApplyCmd)
+	task.Register("tool/kube.Delete", newDeleteCmd)
+	task.Register("tool/kube.Diff", newDiffCmd)
+}
+
+type applyCmd struct{}
+type deleteCmd struct{}
+type diffCmd struct{}
+
+func newApplyCmd(v cue.Value) (task.Runner, error)  { return &applyCmd{}, nil }
+func newDeleteCmd(v cue.Value) (task.Runner, error) { return &deleteCmd{}, nil }
+func newDiffCmd(v cue.Value) (task.Runner, error)   { return &diffCmd{}, nil }
+
+func (c *applyCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	args, err := applyArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, err = run(ctx, args...)
+	return nil, err
+}
+
+func (c *deleteCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	args, err := deleteArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, err = run(ctx, args...)
+	return nil, err
+}
+
+func (c *diffCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	args, err := diffArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The differences are reported in the diff field, rather than written
+	// to stdout.
+	quiet := *ctx
+	quiet.Stdout = nil
+	out, err := run(&quiet, args...)
+	changed := false
+	if e, ok := err.(*exitError); ok && e.code == 1 {
+		// kubectl diff exits with status 1 if there are differences.
+		changed, err = true, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"diff":    string(out),
+		"changed": changed,
+	}, nil
+}
+
+// applyArgs returns the arguments to kubectl for an Apply task.
+func applyArgs(ctx *task.Context) ([]string, error) {
+	args := append([]string{"apply"}, clusterArgs(ctx)...)
+	args = append(args, "--filename", "-")
+
+	if serverSide, err := ctx.Obj.Lookup("serverSide").Bool(); err != nil {
+		return nil, err
+	} else if serverSide {
+		args = append(args, "--server-side")
+	}
+	if v := ctx.Obj.Lookup("fieldManager"); v.Exists() {
+		args = append(args, "--field-manager", ctx.String("fieldManager"))
+	}
+	if v := ctx.Obj.Lookup("prune"); v.Exists() {
+		sel, err := v.Lookup("selector").String()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--prune", "--selector", sel)
+	}
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+	return args, nil
+}
+
+// deleteArgs returns the arguments to kubectl for a Delete task.
+func deleteArgs(ctx *task.Context) ([]string, error) {
+	args := append([]string{"delete"}, clusterArgs(ctx)...)
+	args = append(args, "--filename", "-")
+
+	if ignore, err := ctx.Obj.Lookup("ignoreNotFound").Bool(); err != nil {
+		return nil, err
+	} else if ignore {
+		args = append(args, "--ignore-not-found")
+	}
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+	return args, nil
+}
+
+// diffArgs returns the arguments to kubectl for a Diff task.
+func diffArgs(ctx *task.Context) ([]string, error) {
+	args := append([]string{"diff"}, clusterArgs(ctx)...)
+	args = append(args, "--filename", "-")
+
+	if serverSide, err := ctx.Obj.Lookup("serverSide").Bool(); err != nil {
+		return nil, err
+	} else if serverSide {
+		args = append(args, "--server-side")
+	}
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+	return args, nil
+}
+
+// clusterArgs returns the arguments to kubectl that select the cluster and
+// namespace.
+func clusterArgs(ctx *task.Context) []string {
+	var args []string
+	for _, name := range []string{"kubeconfig", "context", "namespace"} {
+		if v := ctx.Obj.Lookup(name); v.Exists() {
+			args = append(args, "--"+name, ctx.String(name))
+		}
+	}
+	return args
+}
+
+// manifest returns the objects of a task as a JSON-encoded List, which kubectl
+// accepts as a single input.
+func manifest(ctx *task.Context) ([]byte, error) {
+	items := []json.RawMessage{}
+	iter, err := ctx.Obj.Lookup("objects").List()
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		b, err := iter.Value().MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, b)
+	}
+	return json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      items,
+	})
+}
+
+type exitError struct {
+	cmd  string
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("command %q failed: %v", e.cmd, e.err)
+}
+
+// run runs kubectl with the given arguments, passing the objects of the task
+// as input. The output of the command is written to the output streams of
+// ctx. The output written to stdout is also returned.
+func run(ctx *task.Context, args ...string) ([]byte, error) {
+	bin := ctx.String("kubectl")
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+	if _, err := exec.LookPath(bin); err != nil {
+		return nil, fmt.Errorf("kubectl not found: %v; install kubectl or set the kubectl field to its location", err)
+	}
+	stdin, err := manifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx.Context, bin, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	if ctx.Stdout != nil {
+		cmd.Stdout = io.MultiWriter(ctx.Stdout, &stdout)
+	}
+	cmd.Stderr = ctx.Stderr
+
+	if err := cmd.Run(); err != nil {
+		e := &exitError{cmd: bin + " " + strings.Join(args, " "), code: -1, err: err}
+		if x, ok := err.(*exec.ExitError); ok {
+			e.code = x.ExitCode()
+		}
+		return stdout.Bytes(), e
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/internal/task"
+)
+
+func TestArgs(t *testing.T) {
+	testCases := []struct {
+		val  string
+		args func(*task.Context) ([]string, error)
+		want []string
+	}{{
+		val:  `serverSide: false`,
+		args: applyArgs,
+		want: []string{"apply", "--filename", "-"},
+	}, {
+		val: `
+		serverSide:   true
+		fieldManager: "cue"
+		prune: selector: "app=frontend"
+		namespace:  "prod"
+		kubeconfig: "/etc/kube/config"
+		context:    "east"
+		`,
+		args: applyArgs,
+		want: []string{
+			"apply",
+			"--kubeconfig", "/etc/kube/config",
+			"--context", "east",
+			"--namespace", "prod",
+			"--filename", "-",
+			"--server-side",
+			"--field-manager", "cue",
+			"--prune", "--selector", "app=frontend",
+		},
+	}, {
+		val:  `ignoreNotFound: true`,
+		args: deleteArgs,
+		want: []string{"delete", "--filename", "-", "--ignore-not-found"},
+	}, {
+		val:  `ignoreNotFound: false, namespace: "prod"`,
+		args: deleteArgs,
+		want: []string{"delete", "--namespace", "prod", "--filename", "-"},
+	}, {
+		val:  `serverSide: true`,
+		args: diffArgs,
+		want: []string{"diff", "--filename", "-", "--server-side"},
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			var r cue.Runtime
+			inst, err := r.Compile("test", tc.val)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			args, err := tc.args(&task.Context{
+				Context: context.Background(),
+				Obj:     inst.Value(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(args, tc.want) {
+				t.Error(cmp.Diff(args, tc.want))
+			}
+		})
+	}
+}
+
+func TestManifest(t *testing.T) {
+	var r cue.Runtime
+	inst, err := r.Compile("test", `
+	objects: [{
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: name: "a"
+	}, {
+		apiVersion: "v1"
+		kind:       "Service"
+		metadata: name: "b"
+	}]
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := manifest(&task.Context{Obj: inst.Value()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items": []interface{}{
+			map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": "a"},
+			},
+			map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"metadata":   map[string]interface{}{"name": "b"},
+			},
+		},
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
+func TestKubectlNotFound(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir)
+
+	var r cue.Runtime
+	inst, err := r.Compile("test", `
+	kubectl:    "kubectl"
+	serverSide: false
+	objects: [{apiVersion: "v1", kind: "ConfigMap", metadata: name: "a"}]
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = (&applyCmd{}).Run(&task.Context{
+		Context: context.Background(),
+		Obj:     inst.Value(),
+	})
+	if err == nil || !strings.HasPrefix(err.Error(), "kubectl not found: ") {
+		t.Errorf("got error %v; want kubectl not found", err)
+	}
+}
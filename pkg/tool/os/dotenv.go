@@ -0,0 +1,224 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package os
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/task"
+)
+
+type readDotEnvCmd struct{}
+
+func newReadDotEnvCmd(v cue.Value) (task.Runner, error) {
+	return &readDotEnvCmd{}, nil
+}
+
+func (c *readDotEnvCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	filename := ctx.String("filename")
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := parseDotEnv(filename, string(b))
+	if err != nil {
+		return nil, err
+	}
+
+	obj := ctx.Lookup("env")
+	env := map[string]interface{}{}
+	for _, kv := range vars {
+		name, str := kv[0], kv[1]
+		if v := obj.Lookup(name); v.Exists() {
+			if err := validateEntry(name, v); err != nil {
+				return nil, err
+			}
+			env[name], err = fromString(name, str, v)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			env[name] = str
+		}
+	}
+
+	iter, err := obj.Fields()
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		if _, ok := env[iter.Label()]; !ok {
+			env[iter.Label()] = nil
+		}
+	}
+
+	return map[string]interface{}{"env": env}, nil
+}
+
+// parseDotEnv parses the contents of a .env file and returns the variables
+// it defines as name-value pairs, in order of appearance.
+func parseDotEnv(filename, src string) (vars [][2]string, err error) {
+	defined := map[string]string{}
+	lookup := func(name string) string {
+		if s, ok := defined[name]; ok {
+			return s
+		}
+		return getenv(name)
+	}
+
+	for i, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		errorf := func(format string, args ...interface{}) error {
+			return errors.Newf(token.NoPos, "%s:%d: %s",
+				filename, i+1, fmt.Sprintf(format, args...))
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+		p := strings.IndexByte(line, '=')
+		if p < 0 {
+			return nil, errorf("missing '=' in %q", line)
+		}
+		name := strings.TrimSpace(line[:p])
+		if name == "" || strings.ContainsAny(name, " \t$") {
+			return nil, errorf("invalid variable name %q", name)
+		}
+
+		value := strings.TrimSpace(line[p+1:])
+		switch {
+		case value == "":
+
+		case value[0] == '\'':
+			end := strings.IndexByte(value[1:], '\'')
+			if end < 0 {
+				return nil, errorf("missing closing quote for %s", name)
+			}
+			value = value[1 : end+1]
+
+		case value[0] == '"':
+			s, ok := unquoteDouble(value[1:])
+			if !ok {
+				return nil, errorf("missing closing quote for %s", name)
+			}
+			value = os.Expand(s, lookup)
+
+		default:
+			if p := strings.Index(value, " #"); p >= 0 {
+				value = strings.TrimSpace(value[:p])
+			}
+			value = os.Expand(value, lookup)
+		}
+
+		defined[name] = value
+		vars = append(vars, [2]string{name, value})
+	}
+	return vars, nil
+}
+
+// unquoteDouble returns the contents of a double-quoted string up to the
+// closing quote, interpreting the escape sequences \n, \t, \", \\, and \$.
+func unquoteDouble(s string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return b.String(), true
+		case '\\':
+			if i+1 == len(s) {
+				return "", false
+			}
+			i++
+			switch c := s[i]; c {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '$':
+				// Keep the escape so that os.Expand does not expand it.
+				b.WriteString("$$")
+			default:
+				b.WriteByte(c)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", false
+}
+
+// getenv returns the value of the environment variable name for use with
+// os.Expand, which passes the name $ for $$.
+func getenv(name string) string {
+	if name == "$" {
+		return "$"
+	}
+	return os.Getenv(name)
+}
+
+type expandCmd struct{}
+
+func newExpandCmd(v cue.Value) (task.Runner, error) {
+	return &expandCmd{}, nil
+}
+
+func (c *expandCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	text := ctx.String("text")
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+
+	env := map[string]string{}
+	iter, err := ctx.Lookup("env").Fields()
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		name, v := iter.Label(), iter.Value()
+		if err := validateEntry(name, v); err != nil {
+			return nil, err
+		}
+		switch v.Kind() {
+		case cue.NullKind:
+			env[name] = ""
+		case cue.StringKind:
+			env[name], _ = v.String()
+		case cue.BottomKind:
+			return nil, errors.Newf(v.Pos(),
+				"non-concrete value for variable %s", name)
+		default:
+			env[name] = fmt.Sprint(v)
+		}
+	}
+
+	result := os.Expand(text, func(name string) string {
+		if s, ok := env[name]; ok {
+			return s
+		}
+		return getenv(name)
+	})
+	return map[string]interface{}{"result": result}, nil
+}
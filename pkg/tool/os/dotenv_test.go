@@ -0,0 +1,130 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package os
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/task"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	os.Setenv("CUEOSTESTHOME", "/home/cue")
+
+	const src = `
+# comment
+A=1
+export B = two words # comment
+C="${A} and \"$B\"\n"
+D='$A ${B}'
+E=$CUEOSTESTHOME/bin:$$
+F="\$A"
+G=
+`
+	got, err := parseDotEnv(".env", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]string{
+		{"A", "1"},
+		{"B", "two words"},
+		{"C", "1 and \"two words\"\n"},
+		{"D", "$A ${B}"},
+		{"E", "/home/cue/bin:$"},
+		{"F", "$A"},
+		{"G", ""},
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+
+	for _, src := range []string{
+		"A",
+		"A B=1",
+		`A="unterminated`,
+		`A='unterminated`,
+	} {
+		if _, err := parseDotEnv(".env", src); err == nil {
+			t.Errorf("%s: expected error", src)
+		}
+	}
+}
+
+func TestReadDotEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dotenv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, ".env")
+	err = ioutil.WriteFile(filename, []byte("PORT=8080\nHOST=localhost\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := parse(t, "tool/os.ReadDotEnv", `{
+		filename: `+strconv.Quote(filename)+`
+		env: {
+			PORT:    int
+			MISSING: string | null
+		}
+	}`)
+	got, err := (&readDotEnvCmd{}).Run(&task.Context{Obj: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"env": map[string]interface{}{
+		"PORT":    &ast.BasicLit{Kind: token.INT, Value: "8080"},
+		"HOST":    "localhost",
+		"MISSING": nil,
+	}}
+	opts := []cmp.Option{
+		cmpopts.IgnoreFields(ast.BasicLit{}, "ValuePos"),
+		cmpopts.IgnoreUnexported(ast.BasicLit{}),
+	}
+	if !cmp.Equal(got, want, opts...) {
+		t.Error(cmp.Diff(got, want, opts...))
+	}
+}
+
+func TestExpand(t *testing.T) {
+	os.Setenv("CUEOSTESTUSER", "gopher")
+
+	v := parse(t, "tool/os.Expand", `{
+		text: "${CUEOSTESTUSER}@$HOST:$PORT $$ $CUEOSTESTUNSET."
+		env: {
+			HOST: "localhost"
+			PORT: 8080
+		}
+	}`)
+	got, err := (&expandCmd{}).Run(&task.Context{Obj: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"result": "gopher@localhost:8080 $ ."}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
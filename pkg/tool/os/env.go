@@ -31,6 +31,8 @@ import (
 func init() {
 	task.Register("tool/os.Getenv", newGetenvCmd)
 	task.Register("tool/os.Environ", newEnvironCmd)
+	task.Register("tool/os.ReadDotEnv", newReadDotEnvCmd)
+	task.Register("tool/os.Expand", newExpandCmd)
 
 	// TODO:
 	// Tasks:
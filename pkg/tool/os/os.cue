@@ -54,3 +54,42 @@ Environ: {
 Clearenv: {
 	$id: "tool/os.Clearenv"
 }
+
+// ReadDotEnv reads environment variables from a .env file.
+//
+// Each line of the file is of the form NAME=value, optionally preceded by
+// export. Empty lines and lines starting with # are ignored. Values may be
+// enclosed in double or single quotes. References of the form ${NAME} or
+// $NAME in values that are not enclosed in single quotes are expanded with
+// the variables defined earlier in the file or, if not defined in the file,
+// with the environment. Use $$ or, in double quotes, \$ for a literal $.
+//
+// The variables are not set in the environment.
+ReadDotEnv: {
+	$id: "tool/os.ReadDotEnv"
+
+	// filename names the file to read.
+	filename: *".env" | string
+
+	// env holds the variables defined in the file.
+	// Individual entries may be specified ahead of time to enable
+	// validation and parsing. Entries that are not defined in the file
+	// are set to null.
+	env: {[Name]: Value}
+}
+
+// Expand replaces references of the form ${NAME} or $NAME in text with the
+// values of the corresponding variables of env or, if not defined in env,
+// of the environment. Undefined variables are replaced with the empty string
+// and $$ is replaced with $.
+Expand: {
+	$id: "tool/os.Expand"
+
+	text: string
+
+	// env defines variables that take precedence over the environment.
+	env: {[Name]: Value}
+
+	// result holds the expanded text.
+	result: string
+}
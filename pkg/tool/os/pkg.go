@@ -42,5 +42,20 @@ var pkg = &internal.Package{
 	Clearenv: {
 		$id: "tool/os.Clearenv"
 	}
+	ReadDotEnv: {
+		$id:      "tool/os.ReadDotEnv"
+		filename: *".env" | string
+		env: {
+			[Name]: Value
+		}
+	}
+	Expand: {
+		$id:  "tool/os.Expand"
+		text: string
+		env: {
+			[Name]: Value
+		}
+		result: string
+	}
 }`,
 }
@@ -0,0 +1,153 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// gcs implements a backend for Google Cloud Storage using its JSON API.
+type gcs struct {
+	endpoint string
+	token    string
+}
+
+// newGCS returns a Google Cloud Storage backend configured with the given
+// endpoint and the environment, as returned by getenv.
+func newGCS(getenv func(string) string, endpoint string) *gcs {
+	if endpoint == "" {
+		endpoint = "https://storage.googleapis.com"
+	}
+	return &gcs{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		token:    getenv("GOOGLE_OAUTH_ACCESS_TOKEN"),
+	}
+}
+
+func (g *gcs) put(ctx context.Context, bucket, key string, data []byte, contentType string) error {
+	u := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		g.endpoint, url.PathEscape(bucket), url.QueryEscape(key))
+	req, err := http.NewRequest("POST", u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	_, err = g.do(ctx, req)
+	return err
+}
+
+func (g *gcs) get(ctx context.Context, bucket, key string, w io.Writer) error {
+	u := fmt.Sprintf("%s/storage/v1/b/%s/o/%s?alt=media",
+		g.endpoint, url.PathEscape(bucket), url.PathEscape(key))
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := g.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func (g *gcs) list(ctx context.Context, bucket, prefix string) ([]object, error) {
+	var objects []object
+	token := ""
+	for {
+		query := url.Values{"prefix": {prefix}}
+		if token != "" {
+			query.Set("pageToken", token)
+		}
+		u := fmt.Sprintf("%s/storage/v1/b/%s/o?%s",
+			g.endpoint, url.PathEscape(bucket), query.Encode())
+		req, err := http.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		b, err := g.do(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Items []struct {
+				Name string `json:"name"`
+				Size string `json:"size"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(b, &result); err != nil {
+			return nil, fmt.Errorf("invalid response listing %s: %v", bucket, err)
+		}
+		for _, item := range result.Items {
+			// The JSON API encodes 64-bit integers as strings.
+			size, err := strconv.ParseInt(item.Size, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid size %q for %s", item.Size, item.Name)
+			}
+			objects = append(objects, object{key: item.Name, size: size})
+		}
+		if result.NextPageToken == "" {
+			return objects, nil
+		}
+		token = result.NextPageToken
+	}
+}
+
+// do sends req and returns the body of the response.
+func (g *gcs) do(ctx context.Context, req *http.Request) ([]byte, error) {
+	resp, err := g.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return readResponse(resp.Body)
+}
+
+// send sends req. It returns an error for unsuccessful responses.
+func (g *gcs) send(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		var e struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		msg := resp.Status
+		b, err := readResponse(resp.Body)
+		if err == nil && json.Unmarshal(b, &e) == nil && e.Error.Message != "" {
+			msg = fmt.Sprintf("%s: %s", resp.Status, e.Error.Message)
+		}
+		return nil, fmt.Errorf("%s %s: %s", req.Method, req.URL, msg)
+	}
+	return resp, nil
+}
@@ -0,0 +1,289 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3 implements a backend for Amazon S3 and S3-compatible storage services
+// using the REST API of S3, signing requests with AWS Signature Version 4.
+type s3 struct {
+	endpoint string
+	region   string
+
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+
+	now func() time.Time
+}
+
+// newS3 returns an S3 backend configured with the given endpoint and region
+// and the environment, as returned by getenv.
+func newS3(getenv func(string) string, endpoint, region string) *s3 {
+	if region == "" {
+		region = getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	if endpoint == "" {
+		endpoint = getenv("AWS_ENDPOINT_URL")
+	}
+	return &s3{
+		endpoint:        strings.TrimSuffix(endpoint, "/"),
+		region:          region,
+		accessKeyID:     getenv("AWS_ACCESS_KEY_ID"),
+		secretAccessKey: getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken:    getenv("AWS_SESSION_TOKEN"),
+		now:             time.Now,
+	}
+}
+
+func (s *s3) put(ctx context.Context, bucket, key string, data []byte, contentType string) error {
+	req, err := s.newRequest(ctx, "PUT", bucket, key, nil, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	_, err = s.do(req, data)
+	return err
+}
+
+func (s *s3) get(ctx context.Context, bucket, key string, w io.Writer) error {
+	req, err := s.newRequest(ctx, "GET", bucket, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.send(req, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func (s *s3) list(ctx context.Context, bucket, prefix string) ([]object, error) {
+	var objects []object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := s.newRequest(ctx, "GET", bucket, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		b, err := s.do(req, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Contents []struct {
+				Key  string
+				Size int64
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		if err := xml.Unmarshal(b, &result); err != nil {
+			return nil, fmt.Errorf("invalid response listing %s: %v", bucket, err)
+		}
+		for _, c := range result.Contents {
+			objects = append(objects, object{key: c.Key, size: c.Size})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// newRequest returns a request for the given object. Buckets are addressed
+// as part of the host name on AWS and as part of the path for a custom
+// endpoint, which is what most S3-compatible services expect.
+func (s *s3) newRequest(ctx context.Context, method, bucket, key string, query url.Values, body []byte) (*http.Request, error) {
+	var base string
+	path := "/" + key
+	if s.endpoint == "" {
+		base = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, s.region)
+	} else {
+		base = s.endpoint
+		path = "/" + bucket + path
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %v", base, err)
+	}
+	u.Path = path
+	u.RawPath = uriEncode(path, false)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	return req.WithContext(ctx), nil
+}
+
+// do signs and sends req with the given body and returns the body of the
+// response.
+func (s *s3) do(req *http.Request, body []byte) ([]byte, error) {
+	resp, err := s.send(req, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return readResponse(resp.Body)
+}
+
+// send signs and sends req with the given body. It returns an error for
+// unsuccessful responses.
+func (s *s3) send(req *http.Request, body []byte) (*http.Response, error) {
+	sum := sha256.Sum256(body)
+	s.sign(req, hex.EncodeToString(sum[:]))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		var e struct {
+			Code    string
+			Message string
+		}
+		msg := resp.Status
+		b, err := readResponse(resp.Body)
+		if err == nil && xml.Unmarshal(b, &e) == nil && e.Code != "" {
+			msg = fmt.Sprintf("%s: %s: %s", resp.Status, e.Code, e.Message)
+		}
+		return nil, fmt.Errorf("%s %s: %s", req.Method, req.URL, msg)
+	}
+	return resp, nil
+}
+
+// sign adds the headers of AWS Signature Version 4 to req, where payloadHash
+// is the hex-encoded SHA-256 hash of the body. All headers of req are
+// signed. Requests are not signed if there are no credentials.
+func (s *s3) sign(req *http.Request, payloadHash string) {
+	if s.accessKeyID == "" {
+		return
+	}
+	t := s.now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	canonicalHeaders := &strings.Builder{}
+	for _, k := range names {
+		fmt.Fprintf(canonicalHeaders, "%s:%s\n", k, headers[k])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" +
+		hex.EncodeToString(sum[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalQuery returns the query string of q as used for signing, with
+// sorted keys and values encoded as required by S3.
+func canonicalQuery(q url.Values) string {
+	var params []string
+	for k, values := range q {
+		for _, v := range values {
+			params = append(params, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// uriEncode percent-encodes all bytes of s other than unreserved characters
+// and, if encodeSlash is false, slashes.
+func uriEncode(s string, encodeSlash bool) string {
+	b := &strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~',
+			c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 The CUE Authors
+// 
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// 
+//     http://www.apache.org/licenses/LICENSE-2.0
+// 
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+// Objects are identified by URLs of the form s3://bucket/key, for Amazon S3
+// and S3-compatible storage, or gs://bucket/key, for Google Cloud Storage.
+//
+// Credentials are taken from the environment. For S3, these are
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and, optionally,
+// AWS_SESSION_TOKEN. The region defaults to AWS_REGION or AWS_DEFAULT_REGION
+// and the endpoint to AWS_ENDPOINT_URL. For Google Cloud Storage, the OAuth
+// 2.0 access token is taken from GOOGLE_OAUTH_ACCESS_TOKEN, such as the one
+// printed by gcloud auth print-access-token. Requests are sent without
+// credentials if none are set.
+//
+// No other sources of credentials are supported. In particular, the shared
+// configuration and credentials files of the AWS CLI, EC2 instance and
+// container roles, web identity tokens, and Google application default
+// credentials are not consulted. Tokens taken from the environment are not
+// refreshed, so they must remain valid for the duration of the command.
+
+// Put uploads an object.
+Put: {
+	$id: "tool/storage.Put"
+
+	// url identifies the object to write.
+	url: string
+
+	// contents is the data to store.
+	contents: bytes | string
+
+	// contentType is the media type of the object.
+	contentType: *"application/octet-stream" | string
+
+	// endpoint, if set, is the base URL of the storage service, such as
+	// "http://localhost:9000" for a local S3-compatible server.
+	endpoint?: string
+
+	// region is the region of an S3 bucket.
+	region?: string
+}
+
+// Get downloads an object.
+Get: {
+	$id: "tool/storage.Get"
+
+	// url identifies the object to read.
+	url: string
+
+	// file, if set, names a file to which the object is written as it is
+	// downloaded, instead of setting contents. Use it for objects that are
+	// too large to hold in memory. The file is only replaced once the
+	// download completes.
+	file?: string
+
+	// contents is the data of the object, unless file is set. If the
+	// contents are constraint to bytes (the default), the data is returned
+	// as is. If it is constraint to a string, the data is checked to be
+	// valid UTF-8.
+	contents: *bytes | string
+
+	// endpoint, if set, is the base URL of the storage service.
+	endpoint?: string
+
+	// region is the region of an S3 bucket.
+	region?: string
+}
+
+// List lists the objects in a bucket.
+List: {
+	$id: "tool/storage.List"
+
+	// url identifies the bucket and the prefix of the keys to list, as in
+	// s3://bucket/path/.
+	url: string
+
+	// endpoint, if set, is the base URL of the storage service.
+	endpoint?: string
+
+	// region is the region of an S3 bucket.
+	region?: string
+
+	// objects is set to the objects with a key starting with the prefix, in
+	// the order of their keys.
+	objects: [...{
+		key:  string
+		size: int
+	}]
+}
@@ -0,0 +1,199 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+//go:generate go run gen.go
+//go:generate gofmt -s -w .
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/internal/task"
+)
+
+func init() {
+	task.Register("tool/storage.Put", newPutCmd)
+	task.Register("tool/storage.Get", newGetCmd)
+	task.Register("tool/storage.List", newListCmd)
+}
+
+type putCmd struct{}
+type getCmd struct{}
+type listCmd struct{}
+
+func newPutCmd(v cue.Value) (task.Runner, error)  { return &putCmd{}, nil }
+func newGetCmd(v cue.Value) (task.Runner, error)  { return &getCmd{}, nil }
+func newListCmd(v cue.Value) (task.Runner, error) { return &listCmd{}, nil }
+
+// An object describes an object in a bucket.
+type object struct {
+	key  string
+	size int64
+}
+
+// A backend implements the tasks for a storage service.
+type backend interface {
+	put(ctx context.Context, bucket, key string, data []byte, contentType string) error
+	get(ctx context.Context, bucket, key string, w io.Writer) error
+	list(ctx context.Context, bucket, prefix string) ([]object, error)
+}
+
+func (c *putCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	b, bucket, key, err := parse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data := ctx.Bytes("contents")
+	contentType := ctx.String("contentType")
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+	return nil, b.put(ctx.Context, bucket, key, data, contentType)
+}
+
+func (c *getCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	b, bucket, key, err := parse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if v := ctx.Obj.Lookup("file"); v.Exists() {
+		file := ctx.String("file")
+		if ctx.Err != nil {
+			return nil, ctx.Err
+		}
+		return nil, download(ctx.Context, b, bucket, key, file)
+	}
+	buf := &bytes.Buffer{}
+	if err := b.get(ctx.Context, bucket, key, buf); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+	update := map[string]interface{}{"contents": data}
+
+	switch v := ctx.Lookup("contents"); v.IncompleteKind() {
+	case cue.BytesKind:
+		// already set above
+	case cue.StringKind:
+		update["contents"] = string(data)
+	}
+	return update, nil
+}
+
+// download writes the contents of an object to file as it is received. The
+// file is only replaced once the download has completed.
+func download(ctx context.Context, b backend, bucket, key, file string) (err error) {
+	f, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if err = b.get(ctx, bucket, key, f); err != nil {
+		return err
+	}
+	if err = f.Chmod(0644); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), file)
+}
+
+func (c *listCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	b, bucket, prefix, err := parse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := b.list(ctx.Context, bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+	list := []interface{}{}
+	for _, o := range objects {
+		list = append(list, map[string]interface{}{
+			"key":  o.key,
+			"size": o.size,
+		})
+	}
+	return map[string]interface{}{"objects": list}, nil
+}
+
+// parse returns the backend for the url field of a task, along with the
+// bucket and key it identifies.
+func parse(ctx *task.Context) (b backend, bucket, key string, err error) {
+	str := ctx.String("url")
+	var endpoint, region string
+	if v := ctx.Obj.Lookup("endpoint"); v.Exists() {
+		endpoint = ctx.String("endpoint")
+	}
+	if v := ctx.Obj.Lookup("region"); v.Exists() {
+		region = ctx.String("region")
+	}
+	if ctx.Err != nil {
+		return nil, "", "", ctx.Err
+	}
+
+	u, err := url.Parse(str)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if u.Host == "" {
+		return nil, "", "", fmt.Errorf("invalid url %q: missing bucket", str)
+	}
+	bucket = u.Host
+	key = strings.TrimPrefix(u.Path, "/")
+
+	switch u.Scheme {
+	case "s3":
+		b = newS3(os.Getenv, endpoint, region)
+	case "gs":
+		b = newGCS(os.Getenv, endpoint)
+	default:
+		return nil, "", "", fmt.Errorf(
+			"invalid url %q: scheme must be s3 or gs", str)
+	}
+	return b, bucket, key, nil
+}
+
+// maxResponseSize is the maximum size of a response that is read into memory
+// other than the contents of an object, such as a page of a listing.
+const maxResponseSize = 16 << 20
+
+// readResponse returns the contents of r, which may not exceed
+// maxResponseSize bytes.
+func readResponse(r io.Reader) ([]byte, error) {
+	b, err := ioutil.ReadAll(io.LimitReader(r, maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxResponseSize {
+		return nil, fmt.Errorf("response exceeds %d bytes", maxResponseSize)
+	}
+	return b, nil
+}
@@ -29,6 +29,18 @@ var pkg = &internal.Package{
 				c.Ret = Valid(s)
 			}
 		},
+	}, {
+		Name: "FromString",
+		Params: []internal.Param{
+			{Kind: adt.StringKind},
+		},
+		Result: adt.BottomKind,
+		Func: func(c *internal.CallCtxt) {
+			s := c.String(0)
+			if c.Do() {
+				c.Ret = FromString(s)
+			}
+		},
 	}, {
 		Name: "Parse",
 		Params: []internal.Param{
@@ -28,7 +28,8 @@ import (
 var valid = regexp.MustCompile(
 	"^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$")
 
-// Valid can be used to define a valid Valid.
+// Valid can be used to define a valid UUID in its canonical, lowercase form
+// xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx.
 func Valid(s string) error {
 	if !valid.MatchString(string(s)) {
 		return fmt.Errorf("invalid UUID %q", s)
@@ -36,11 +37,23 @@ func Valid(s string) error {
 	return nil
 }
 
+// FromString can be used to define a valid UUID in any of the forms accepted
+// by Parse. Unlike Valid, it also accepts uppercase hexadecimal digits.
+func FromString(s string) error {
+	if _, err := uuid.Parse(s); err != nil {
+		return fmt.Errorf("invalid UUID %q", s)
+	}
+	return nil
+}
+
 // Parse decodes s into a UUID or returns an error. Both the standard UUID forms
 // of xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx and
 // urn:uuid:xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx are decoded as well as the
 // Microsoft encoding {xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx} and the raw hex
 // encoding: xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx.
+//
+// The result is in canonical form. Use Variant and Version to inspect the
+// parsed UUID.
 func Parse(s string) (string, error) {
 	x, err := uuid.Parse(s)
 	return string(x.String()), err
@@ -15,6 +15,10 @@
 // Package fix contains functionality for writing CUE files with legacy
 // syntax to newer ones.
 //
+// Each transformation is a Rule. Users of this package may Register rules of
+// their own, for instance to rename a deprecated field, which are then
+// applied by File and Instances along with the rules of this package.
+//
 // Note: the transformations that are supported in this package will change
 // over time.
 package fix
@@ -33,6 +37,7 @@ type Option func(*options)
 type options struct {
 	simplify   bool
 	deprecated bool
+	only       map[string]bool
 }
 
 // Simplify enables fixes that simplify the code, but are not strictly
@@ -41,15 +46,54 @@ func Simplify() Option {
 	return func(o *options) { o.simplify = true }
 }
 
-// File applies fixes to f and returns it. It alters the original f.
+// File applies the registered rules to f and returns it. It alters the
+// original f.
 func File(f *ast.File, o ...Option) *ast.File {
 	var options options
 	for _, f := range o {
 		f(&options)
 	}
 
-	// Rewrite integer division operations to use builtins.
-	f = astutil.Apply(f, func(c astutil.Cursor) bool {
+	for _, r := range Rules() {
+		if options.only != nil && !options.only[r.Name] {
+			continue
+		}
+		f = r.Fix(f)
+	}
+
+	if options.simplify {
+		f = simplify(f)
+	}
+
+	return f
+}
+
+func init() {
+	Register(Rule{
+		Name: "intdiv",
+		Doc:  "rewrite integer division operators to calls of builtins",
+		Fix:  fixIntDiv,
+	})
+	Register(Rule{
+		Name: "alias",
+		Doc:  "rewrite old-style aliases to let clauses",
+		Fix:  fixAlias,
+	})
+	Register(Rule{
+		Name: "comments",
+		Doc:  "rewrite block comments to line comments",
+		Fix:  fixComments,
+	})
+	Register(Rule{
+		Name: "quoted",
+		Doc:  "rewrite references to quoted identifiers",
+		Fix:  fixQuoted,
+	})
+}
+
+// fixIntDiv rewrites integer division operations to use builtins.
+func fixIntDiv(f *ast.File) *ast.File {
+	return astutil.Apply(f, func(c astutil.Cursor) bool {
 		n := c.Node()
 		switch x := n.(type) {
 		case *ast.BinaryExpr:
@@ -65,8 +109,10 @@ func File(f *ast.File, o ...Option) *ast.File {
 		}
 		return true
 	}, nil).(*ast.File)
+}
 
-	// Rewrite an old-style alias to a let clause.
+// fixAlias rewrites an old-style alias to a let clause.
+func fixAlias(f *ast.File) *ast.File {
 	ast.Walk(f, func(n ast.Node) bool {
 		var decls []ast.Decl
 		switch x := n.(type) {
@@ -88,8 +134,11 @@ func File(f *ast.File, o ...Option) *ast.File {
 		}
 		return true
 	}, nil)
+	return f
+}
 
-	// Rewrite block comments to regular comments.
+// fixComments rewrites block comments to regular comments.
+func fixComments(f *ast.File) *ast.File {
 	ast.Walk(f, func(n ast.Node) bool {
 		switch x := n.(type) {
 		case *ast.CommentGroup:
@@ -121,7 +170,12 @@ func File(f *ast.File, o ...Option) *ast.File {
 		}
 		return true
 	}, nil)
+	return f
+}
 
+// fixQuoted rewrites quoted identifier fields that are referenced, and the
+// references to them.
+func fixQuoted(f *ast.File) *ast.File {
 	// Referred nodes and used identifiers.
 	referred := map[ast.Node]string{}
 	used := map[string]bool{}
@@ -211,44 +265,40 @@ func File(f *ast.File, o ...Option) *ast.File {
 		return true
 	}, nil)
 
-	// TODO: we are probably reintroducing slices. Disable for now.
-	//
-	// Rewrite slice expression.
-	// f = astutil.Apply(f, func(c astutil.Cursor) bool {
-	// 	n := c.Node()
-	// 	getVal := func(n ast.Expr) ast.Expr {
-	// 		if n == nil {
-	// 			return nil
-	// 		}
-	// 		if id, ok := n.(*ast.Ident); ok && id.Name == "_" {
-	// 			return nil
-	// 		}
-	// 		return n
-	// 	}
-	// 	switch x := n.(type) {
-	// 	case *ast.SliceExpr:
-	// 		ast.SetRelPos(x.X, token.NoRelPos)
-
-	// 		lo := getVal(x.Low)
-	// 		hi := getVal(x.High)
-	// 		if lo == nil { // a[:j]
-	// 			lo = mustParseExpr("0")
-	// 			astutil.CopyMeta(lo, x.Low)
-	// 		}
-	// 		if hi == nil { // a[i:]
-	// 			hi = ast.NewCall(ast.NewIdent("len"), x.X)
-	// 			astutil.CopyMeta(lo, x.High)
-	// 		}
-	// 		if pkg := c.Import("list"); pkg != nil {
-	// 			c.Replace(ast.NewCall(ast.NewSel(pkg, "Slice"), x.X, lo, hi))
-	// 		}
-	// 	}
-	// 	return true
-	// }, nil).(*ast.File)
-
-	if options.simplify {
-		f = simplify(f)
-	}
-
 	return f
 }
+
+// TODO: we are probably reintroducing slices. Disable for now.
+//
+// Rewrite slice expression.
+// f = astutil.Apply(f, func(c astutil.Cursor) bool {
+// 	n := c.Node()
+// 	getVal := func(n ast.Expr) ast.Expr {
+// 		if n == nil {
+// 			return nil
+// 		}
+// 		if id, ok := n.(*ast.Ident); ok && id.Name == "_" {
+// 			return nil
+// 		}
+// 		return n
+// 	}
+// 	switch x := n.(type) {
+// 	case *ast.SliceExpr:
+// 		ast.SetRelPos(x.X, token.NoRelPos)
+
+// 		lo := getVal(x.Low)
+// 		hi := getVal(x.High)
+// 		if lo == nil { // a[:j]
+// 			lo = mustParseExpr("0")
+// 			astutil.CopyMeta(lo, x.Low)
+// 		}
+// 		if hi == nil { // a[i:]
+// 			hi = ast.NewCall(ast.NewIdent("len"), x.X)
+// 			astutil.CopyMeta(lo, x.High)
+// 		}
+// 		if pkg := c.Import("list"); pkg != nil {
+// 			c.Replace(ast.NewCall(ast.NewSel(pkg, "Slice"), x.X, lo, hi))
+// 		}
+// 	}
+// 	return true
+// }, nil).(*ast.File)
@@ -0,0 +1,81 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fix
+
+import (
+	"fmt"
+	"sync"
+
+	"cuelang.org/go/cue/ast"
+)
+
+// A Rule rewrites files, for instance to migrate them from a deprecated
+// language construct or to rename a deprecated field.
+type Rule struct {
+	// Name identifies the rule. It must be unique among the registered
+	// rules.
+	Name string
+
+	// Doc is a short description of the rule.
+	Doc string
+
+	// Fix applies the rule to f and returns the result. It may alter f.
+	Fix func(f *ast.File) *ast.File
+}
+
+var (
+	rulesMu sync.Mutex
+	rules   []Rule
+)
+
+// Register adds r to the rules applied by File and Instances. Rules are
+// applied in the order in which they are registered, after the rules of this
+// package. Register panics if a rule with the same name was registered
+// before.
+//
+// Register is typically called from an init function.
+func Register(r Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+
+	if r.Name == "" || r.Fix == nil {
+		panic("fix: rule must have a name and a Fix function")
+	}
+	for _, x := range rules {
+		if x.Name == r.Name {
+			panic(fmt.Sprintf("fix: rule %q registered twice", r.Name))
+		}
+	}
+	rules = append(rules, r)
+}
+
+// Rules returns the registered rules in the order in which they are applied.
+func Rules() []Rule {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+
+	return append([]Rule(nil), rules...)
+}
+
+// Only limits the rules applied by File and Instances to the registered rules
+// with the given names.
+func Only(names ...string) Option {
+	return func(o *options) {
+		o.only = map[string]bool{}
+		for _, name := range names {
+			o.only[name] = true
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fix
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/ast/astutil"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/parser"
+)
+
+func init() {
+	// Rename the deprecated field replica to replicas.
+	Register(Rule{
+		Name: "test-rename",
+		Doc:  "rename replica to replicas",
+		Fix: func(f *ast.File) *ast.File {
+			return astutil.Apply(f, func(c astutil.Cursor) bool {
+				if x, ok := c.Node().(*ast.Field); ok {
+					if id, ok := x.Label.(*ast.Ident); ok && id.Name == "replica" {
+						x.Label = astutil.CopyMeta(ast.NewIdent("replicas"), id).(ast.Label)
+					}
+				}
+				return true
+			}, nil).(*ast.File)
+		},
+	})
+}
+
+func TestRules(t *testing.T) {
+	const in = `a: {
+	replica: 1 div 2
+}
+`
+	testCases := []struct {
+		name string
+		opts []Option
+		out  string
+	}{{
+		name: "all",
+		out: `a: {
+	replicas: __div(1, 2)
+}
+`,
+	}, {
+		name: "only",
+		opts: []Option{Only("test-rename")},
+		out: `a: {
+	replicas: 1 div 2
+}
+`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := parser.ParseFile(tc.name, in, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := format.Node(File(f, tc.opts...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tc.out {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.out)
+			}
+		})
+	}
+
+	names := []string{}
+	for _, r := range Rules() {
+		names = append(names, r.Name)
+	}
+	if n := len(names); n == 0 || names[n-1] != "test-rename" {
+		t.Errorf("test-rename is not the last rule: %v", names)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a rule twice did not panic")
+		}
+	}()
+	Register(Rules()[0])
+}
@@ -17,7 +17,9 @@
 // A Task defines an operational unit in a Workflow and corresponds to a struct
 // in a CUE instance. This package does not define what a Task looks like in a
 // CUE Instance. Instead, the user of this package must supply a TaskFunc that
-// creates a Runner for cue.Values that are deemed to be a Task.
+// creates a Runner for cue.Values that are deemed to be a Task. Alternatively,
+// tasks of kinds registered with RegisterTask can be run using RegisteredTasks
+// as the TaskFunc.
 //
 // Tasks may depend on other tasks. Cyclic dependencies are thereby not allowed.
 // A Task A depends on another Task B if A, directly or indirectly, has a
@@ -68,6 +70,7 @@ package flow
 
 import (
 	"context"
+	"time"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/errors"
@@ -139,6 +142,24 @@ type Config struct {
 	// updated. This includes directly after initialization. The task may be
 	// nil if this call is not the result of a task completing.
 	UpdateFunc func(c *Controller, t *Task) error
+
+	// EventFunc, if set, is called when a task is started and when it
+	// completes. Upon completion, the Value of the task includes any results
+	// it filled in, such as captured output. EventFunc is called from the
+	// goroutine that called Run, so calls are never concurrent.
+	EventFunc func(e Event)
+
+	// MaxConcurrency, if positive, is the maximum number of tasks that run at
+	// the same time. Tasks that are ready to run wait for others to complete
+	// if the limit is reached, where tasks with a lower Index are started
+	// first.
+	MaxConcurrency int
+
+	// KindConcurrency limits the number of tasks of a given kind that run at
+	// the same time, in addition to MaxConcurrency. The kind of a task is the
+	// value of its $id field, such as "tool/http.Do". Limits that are not
+	// positive are ignored.
+	KindConcurrency map[string]int
 }
 
 // A Controller defines a set of Tasks to be executed.
@@ -156,6 +177,11 @@ type Controller struct {
 
 	taskCh chan *Task
 
+	// numRunning and kindRunning count the running tasks, in total and per
+	// kind, to enforce the concurrency limits.
+	numRunning  int
+	kindRunning map[string]int
+
 	opCtx      *adt.OpContext
 	context    context.Context
 	cancelFunc context.CancelFunc
@@ -183,6 +209,51 @@ func (c *Controller) Tasks() []*Task {
 	return c.tasks
 }
 
+// Plan reports the tasks of the workflow in the order in which they would be
+// run if tasks were run one at a time. Each task is listed after all tasks it
+// depends on. Of the tasks that could run next, the one with the lowest Index
+// is listed first.
+//
+// Plan does not run any tasks. As a consequence, tasks that would only be
+// discovered after other tasks have filled in their results are not included.
+// Plan may only be called before Run is called.
+func (c *Controller) Plan() ([]*Task, error) {
+	if c.errs != nil {
+		return nil, c.errs
+	}
+
+	done := make(map[*Task]bool, len(c.tasks))
+	plan := make([]*Task, 0, len(c.tasks))
+	for len(plan) < len(c.tasks) {
+		next := c.nextPlanned(done)
+		if next == nil {
+			// Should not happen, as cycle detection should have caught this.
+			return nil, errors.New("cannot plan tasks: dependency cycle")
+		}
+		done[next] = true
+		plan = append(plan, next)
+	}
+	return plan, nil
+}
+
+// nextPlanned reports the first task that is not done and for which all
+// dependencies are done, or nil if there is no such task.
+func (c *Controller) nextPlanned(done map[*Task]bool) *Task {
+outer:
+	for _, t := range c.tasks {
+		if done[t] {
+			continue
+		}
+		for _, d := range t.depTasks {
+			if !done[d] {
+				continue outer
+			}
+		}
+		return t
+	}
+	return nil
+}
+
 func (c *Controller) cancel() {
 	if c.cancelFunc != nil {
 		c.cancelFunc()
@@ -302,6 +373,11 @@ type Task struct {
 	err         errors.Error
 	state       State
 	depTasks    []*Task
+
+	start    time.Time
+	duration time.Duration
+
+	kind string // value of $id while running
 }
 
 // Context reports the Controller's Context.
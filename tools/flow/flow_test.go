@@ -22,6 +22,9 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/cuecontext"
@@ -118,6 +121,24 @@ func taskFunc(v cue.Value) (flow.Runner, error) {
 			return errors.New("failure")
 		}), nil
 
+	case "flaky":
+		// Fails until it has been run the number of times indicated by
+		// succeedAt.
+		n := int64(0)
+		return flow.RunnerFunc(func(t *flow.Task) error {
+			n++
+			at, err := t.Value().Lookup("succeedAt").Int64()
+			if err != nil {
+				return err
+			}
+			if n < at {
+				t.Fill(map[string]string{"out": "partial"})
+				return fmt.Errorf("failure %d", n)
+			}
+			t.Fill(map[string]int64{"attempts": n})
+			return nil
+		}), nil
+
 	case "abort":
 		return flow.RunnerFunc(func(t *flow.Task) error {
 			return flow.ErrAbort
@@ -188,6 +209,159 @@ func mermaidGraph(c *flow.Controller) string {
 	return w.String()
 }
 
+func TestPlan(t *testing.T) {
+	v := cuecontext.New().CompileString(`
+	root: {
+		a: {$id: "valToOut", val: b.out, out: string}
+		b: {$id: "valToOut", val: "x", out: string}
+		c: {$id: "valToOut", $after: a, val: "y"}
+		d: {$id: "valToOut", val: "z"}
+	}
+	`)
+
+	c := flow.New(&flow.Config{Root: cue.ParsePath("root")}, v, taskFunc)
+	plan, err := c.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, task := range plan {
+		got = append(got, fmt.Sprintf("%v %v", task.Path(), task.State()))
+	}
+	want := []string{
+		"root.b Waiting",
+		"root.a Waiting",
+		"root.c Waiting",
+		"root.d Waiting",
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
+func TestEventFunc(t *testing.T) {
+	v := cuecontext.New().CompileString(`
+	root: {
+		a: {$id: "valToOut", val: "x", out: string}
+		b: {$id: "failure", val: a.out}
+	}
+	`)
+
+	var got []string
+	cfg := &flow.Config{
+		Root: cue.ParsePath("root"),
+		EventFunc: func(e flow.Event) {
+			s := fmt.Sprintf("%v %v", e.Task.Path(), e.State)
+			if e.State == flow.Terminated {
+				out, _ := e.Task.Value().Lookup("out").String()
+				s += fmt.Sprintf(" out=%q err=%v", out, e.Err)
+				if e.Duration <= 0 {
+					t.Errorf("%v: duration not set", e.Task.Path())
+				}
+			}
+			got = append(got, s)
+		},
+	}
+	err := flow.New(cfg, v, taskFunc).Run(context.Background())
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	want := []string{
+		`root.a Running`,
+		`root.a Terminated out="x" err=<nil>`,
+		`root.b Running`,
+		`root.b Terminated out="" err=task failed: failure`,
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	v := cuecontext.New().CompileString(`
+	root: {
+		a: {$id: "track"}
+		b: {$id: "track"}
+		c: {$id: "track"}
+		d: {$id: "track"}
+		e: {$id: "other"}
+		f: {$id: "other"}
+	}
+	`)
+
+	testCases := []struct {
+		name  string
+		max   int
+		kinds map[string]int
+		want  int // maximum number of concurrent track tasks
+	}{{
+		name: "global",
+		max:  2,
+		want: 2,
+	}, {
+		name:  "kind",
+		kinds: map[string]int{"track": 1},
+		want:  1,
+	}, {
+		name:  "both",
+		max:   3,
+		kinds: map[string]int{"track": 2, "other": 1},
+		want:  2,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			running, maxRunning, total := 0, 0, 0
+			track := func(kind string) flow.RunnerFunc {
+				n := 0
+				if kind == "track" {
+					n = 1
+				}
+				return func(task *flow.Task) error {
+					mu.Lock()
+					running += n
+					total++
+					if running > maxRunning {
+						maxRunning = running
+					}
+					mu.Unlock()
+
+					time.Sleep(10 * time.Millisecond)
+
+					mu.Lock()
+					running -= n
+					mu.Unlock()
+					return nil
+				}
+			}
+			taskFunc := func(v cue.Value) (flow.Runner, error) {
+				kind, err := v.Lookup("$id").String()
+				if err != nil {
+					return nil, nil
+				}
+				return track(kind), nil
+			}
+
+			cfg := &flow.Config{
+				Root:            cue.ParsePath("root"),
+				MaxConcurrency:  tc.max,
+				KindConcurrency: tc.kinds,
+			}
+			if err := flow.New(cfg, v, taskFunc).Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if total != 6 {
+				t.Errorf("ran %d tasks; want 6", total)
+			}
+			if maxRunning > tc.want {
+				t.Errorf("got %d concurrent tasks; want at most %d", maxRunning, tc.want)
+			}
+		})
+	}
+}
+
 // DO NOT REMOVE: for testing purposes.
 func TestX(t *testing.T) {
 	in := `
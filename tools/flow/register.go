@@ -0,0 +1,102 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+)
+
+var registry struct {
+	sync.RWMutex
+	funcs map[string]TaskFunc
+}
+
+// reserved lists the prefixes of kinds that are reserved for the tasks
+// provided by CUE.
+var reserved = []string{"tool/", "cmd/cue/"}
+
+// RegisterTask registers f to create the Runners for tasks of the given kind.
+// The kind of a task is the value of its $id field.
+//
+// A kind is of the form path.Name, such as "mycorp/vault.Read", where Name is
+// an identifier naming the task and path identifies the package that defines
+// it. To avoid collisions, path should start with a name controlled by the
+// registrant, such as a domain or organization name. Paths starting with tool/
+// or cmd/cue/ are reserved for the tasks provided by CUE.
+//
+// RegisterTask panics if kind is invalid or already registered. It is
+// intended to be called from an init function.
+func RegisterTask(kind string, f TaskFunc) {
+	if err := checkKind(kind); err != nil {
+		panic(err)
+	}
+	if f == nil {
+		panic(fmt.Sprintf("flow: nil TaskFunc for task kind %q", kind))
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.funcs[kind]; ok {
+		panic(fmt.Sprintf("flow: task kind %q already registered", kind))
+	}
+	if registry.funcs == nil {
+		registry.funcs = map[string]TaskFunc{}
+	}
+	registry.funcs[kind] = f
+}
+
+// LookupTask returns the TaskFunc registered for kind, or nil if there is
+// none.
+func LookupTask(kind string) TaskFunc {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	return registry.funcs[kind]
+}
+
+// RegisteredTasks is a TaskFunc for the kinds registered with RegisterTask.
+// It calls the TaskFunc registered for the kind of v, if any.
+func RegisteredTasks(v cue.Value) (Runner, error) {
+	kind, err := v.LookupPath(cue.MakePath(cue.Str("$id"))).String()
+	if err != nil {
+		return nil, nil
+	}
+	f := LookupTask(kind)
+	if f == nil {
+		return nil, nil
+	}
+	return f(v)
+}
+
+// checkKind reports an error if kind is not a valid kind for a registered
+// task.
+func checkKind(kind string) error {
+	i := strings.LastIndexByte(kind, '.')
+	if i <= 0 || !ast.IsValidIdent(kind[i+1:]) || strings.HasPrefix(kind[i+1:], "#") {
+		return fmt.Errorf("flow: invalid task kind %q: must be of the form path.Name", kind)
+	}
+	for _, p := range reserved {
+		if strings.HasPrefix(kind, p) {
+			return fmt.Errorf("flow: invalid task kind %q: %s is reserved", kind, p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/tools/flow"
+)
+
+func TestRegisterTask(t *testing.T) {
+	var got []string
+	flow.RegisterTask("example.com/test.Greet", func(v cue.Value) (flow.Runner, error) {
+		return flow.RunnerFunc(func(t *flow.Task) error {
+			name, err := t.Value().LookupPath(cue.ParsePath("name")).String()
+			if err != nil {
+				return err
+			}
+			got = append(got, fmt.Sprintf("%v: hello %s", t.Path(), name))
+			return t.Fill(map[string]string{"out": "hello " + name})
+		}), nil
+	})
+
+	v := cuecontext.New().CompileString(`
+	root: {
+		a: {$id: "example.com/test.Greet", name: "world", out: string}
+		b: {$id: "example.com/test.Greet", name: a.out, out: string}
+		c: {$id: "example.com/test.Unknown"}
+	}
+	`)
+	c := flow.New(&flow.Config{Root: cue.ParsePath("root")}, v, flow.RegisteredTasks)
+	if n := len(c.Tasks()); n != 2 {
+		t.Fatalf("got %d tasks; want 2", n)
+	}
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"root.a: hello world",
+		"root.b: hello hello world",
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+
+	if flow.LookupTask("example.com/test.Unknown") != nil {
+		t.Error("found unregistered task kind")
+	}
+}
+
+func TestRegisterTaskPanics(t *testing.T) {
+	nop := func(v cue.Value) (flow.Runner, error) { return nil, nil }
+	flow.RegisterTask("example.com/test.Dup", nop)
+
+	testCases := []struct {
+		kind string
+		want string
+	}{{
+		kind: "example.com/test.Dup",
+		want: `flow: task kind "example.com/test.Dup" already registered`,
+	}, {
+		kind: "Read",
+		want: `flow: invalid task kind "Read": must be of the form path.Name`,
+	}, {
+		kind: "example.com/vault.",
+		want: `flow: invalid task kind "example.com/vault.": must be of the form path.Name`,
+	}, {
+		kind: "tool/exec.Run",
+		want: `flow: invalid task kind "tool/exec.Run": tool/ is reserved`,
+	}, {
+		kind: "cmd/cue/cmd.Test",
+		want: `flow: invalid task kind "cmd/cue/cmd.Test": cmd/cue/ is reserved`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.kind, func(t *testing.T) {
+			defer func() {
+				got := fmt.Sprint(recover())
+				if got != tc.want {
+					t.Errorf("got panic %q; want %q", got, tc.want)
+				}
+			}()
+			flow.RegisterTask(tc.kind, nop)
+		})
+	}
+}
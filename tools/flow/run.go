@@ -26,6 +26,10 @@ package flow
 // future tasks may be long running, as discussed above.
 
 import (
+	"math/rand"
+	"time"
+
+	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/internal/core/adt"
 	"cuelang.org/go/internal/core/eval"
@@ -58,15 +62,23 @@ func (c *Controller) runLoop() {
 			case Ready:
 				running = true
 
-				t.state = Running
 				c.updateTaskValue(t)
+				if !c.acquire(t) {
+					// Wait for a running task to complete.
+					continue
+				}
+				t.state = Running
 
 				t.ctxt = eval.NewContext(value.ToInternal(t.v))
 
+				t.start = time.Now()
+				c.event(Event{Task: t, State: Running})
+
 				go func(t *Task) {
-					if err := t.r.Run(t, nil); err != nil {
+					if err := t.run(); err != nil {
 						t.err = errors.Promote(err, "task failed")
 					}
+					t.duration = time.Since(t.start)
 
 					t.c.taskCh <- t
 				}(t)
@@ -93,6 +105,7 @@ func (c *Controller) runLoop() {
 
 		case t := <-c.taskCh:
 			t.state = Terminated
+			c.release(t)
 
 			switch t.err {
 			case nil:
@@ -103,6 +116,12 @@ func (c *Controller) runLoop() {
 				fallthrough
 
 			default:
+				c.event(Event{
+					Task:     t,
+					State:    Terminated,
+					Duration: t.duration,
+					Err:      t.err,
+				})
 				c.addErr(t.err, "task failure")
 				return
 			}
@@ -116,11 +135,66 @@ func (c *Controller) runLoop() {
 
 			c.updateTaskValue(t)
 
+			c.event(Event{Task: t, State: Terminated, Duration: t.duration})
+
 			c.markReady(t)
 		}
 	}
 }
 
+// acquire reports whether t may start running without exceeding the
+// concurrency limits and, if so, accounts for it as running.
+func (c *Controller) acquire(t *Task) bool {
+	if max := c.cfg.MaxConcurrency; max > 0 && c.numRunning >= max {
+		return false
+	}
+	kind := ""
+	if len(c.cfg.KindConcurrency) > 0 {
+		kind, _ = t.v.LookupPath(cue.MakePath(cue.Str("$id"))).String()
+		if max := c.cfg.KindConcurrency[kind]; max > 0 && c.kindRunning[kind] >= max {
+			return false
+		}
+		if c.kindRunning == nil {
+			c.kindRunning = map[string]int{}
+		}
+		c.kindRunning[kind]++
+	}
+	c.numRunning++
+	t.kind = kind
+	return true
+}
+
+// release accounts for t, which was started by acquire, as no longer running.
+func (c *Controller) release(t *Task) {
+	c.numRunning--
+	if c.kindRunning != nil {
+		c.kindRunning[t.kind]--
+	}
+}
+
+// An Event reports a change in the state of a Task.
+type Event struct {
+	// Task is the task of which the state changed.
+	Task *Task
+
+	// State is the new state of the task: Running when the task is started
+	// and Terminated when it completes.
+	State State
+
+	// Duration is the time it took to run the task. It is only set for
+	// Terminated events.
+	Duration time.Duration
+
+	// Err is the error with which the task failed, if any.
+	Err error
+}
+
+func (c *Controller) event(e Event) {
+	if c.cfg.EventFunc != nil {
+		c.cfg.EventFunc(e)
+	}
+}
+
 func (c *Controller) markReady(t *Task) {
 	for _, x := range c.tasks {
 		if x.state == Waiting && x.isReady() {
@@ -208,3 +282,93 @@ func (c *Controller) updateTaskResults(t *Task) bool {
 
 	return true
 }
+
+// run runs the task, running it again upon failure as specified by its $retry
+// field.
+func (t *Task) run() error {
+	p, err := parseRetry(t.v)
+	if err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		err = t.r.Run(t, nil)
+		if err == nil || err == ErrAbort || attempt >= p.attempts {
+			return err
+		}
+		// Discard results of the failed attempt.
+		t.update = nil
+
+		select {
+		case <-t.c.context.Done():
+			return err
+		case <-time.After(p.delay(attempt)):
+		}
+	}
+}
+
+// A retryPolicy defines how often and when a failed task is run again.
+type retryPolicy struct {
+	attempts int
+	backoff  time.Duration
+	jitter   bool
+}
+
+// maxRetryDelay is the maximum time to wait between two attempts of a task.
+const maxRetryDelay = time.Hour
+
+// delay reports the time to wait before the next attempt after the given
+// number of failed attempts. The delay doubles after each attempt, up to
+// maxRetryDelay.
+func (p retryPolicy) delay(attempt int) time.Duration {
+	d := p.backoff
+	for i := 1; i < attempt && d < maxRetryDelay; i++ {
+		d *= 2
+	}
+	if d > maxRetryDelay {
+		d = maxRetryDelay
+	}
+	if p.jitter && d > 0 {
+		d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	}
+	return d
+}
+
+// parseRetry reports the retry policy defined by the $retry field of the task
+// value v. A task without this field is run only once.
+func parseRetry(v cue.Value) (p retryPolicy, err error) {
+	p.attempts = 1
+	r := v.LookupPath(cue.MakePath(cue.Str("$retry")))
+	if !r.Exists() {
+		return p, nil
+	}
+
+	n, err := r.LookupPath(cue.ParsePath("attempts")).Int64()
+	if err != nil {
+		return p, err
+	}
+	if n < 1 {
+		return p, errors.Newf(r.Pos(), "$retry.attempts must be at least 1, found %d", n)
+	}
+	p.attempts = int(n)
+
+	p.backoff = time.Second
+	if b := r.LookupPath(cue.ParsePath("backoff")); b.Exists() {
+		str, err := b.String()
+		if err != nil {
+			return p, err
+		}
+		if p.backoff, err = time.ParseDuration(str); err != nil {
+			return p, errors.Newf(b.Pos(), "invalid $retry.backoff %q: %v", str, err)
+		}
+		if p.backoff < 0 {
+			return p, errors.Newf(b.Pos(), "$retry.backoff must not be negative, found %q", str)
+		}
+	}
+
+	if j := r.LookupPath(cue.ParsePath("jitter")); j.Exists() {
+		if p.jitter, err = j.Bool(); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
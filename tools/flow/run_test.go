@@ -0,0 +1,43 @@
+// Copyright 2021 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	testCases := []struct {
+		policy  retryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{retryPolicy{backoff: time.Second}, 1, time.Second},
+		{retryPolicy{backoff: time.Second}, 3, 4 * time.Second},
+		{retryPolicy{backoff: time.Second}, 12, 2048 * time.Second},
+		{retryPolicy{backoff: time.Second}, 13, maxRetryDelay},
+		{retryPolicy{backoff: time.Second}, 100, maxRetryDelay},
+		{retryPolicy{backoff: time.Second}, 1000, maxRetryDelay},
+		{retryPolicy{backoff: 2 * time.Hour}, 1, maxRetryDelay},
+		{retryPolicy{}, 100, 0},
+	}
+	for _, tc := range testCases {
+		if got := tc.policy.delay(tc.attempt); got != tc.want {
+			t.Errorf("delay(%d) with backoff %v: got %v; want %v",
+				tc.attempt, tc.policy.backoff, got, tc.want)
+		}
+	}
+}
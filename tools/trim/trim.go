@@ -56,6 +56,8 @@ import (
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/ast/astutil"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/cuecontext"
 	"cuelang.org/go/internal/core/adt"
 	"cuelang.org/go/internal/core/debug"
 	"cuelang.org/go/internal/core/subsume"
@@ -72,24 +74,12 @@ type Config struct {
 // Trimming is done on a best-effort basis and only when the removed field
 // is clearly implied by another field, rather than equal sibling fields.
 func Files(files []*ast.File, inst cue.InstanceOrValue, cfg *Config) error {
-	r, v := value.ToInternal(inst.Value())
-
-	t := &trimmer{
-		Config:  *cfg,
-		ctx:     adt.NewContext(r, v),
-		remove:  map[ast.Node]bool{},
-		exclude: map[ast.Node]bool{},
-		debug:   Debug,
-		w:       os.Stderr,
-	}
-
-	d, _, _, pickedDefault := t.addDominators(nil, v, false)
-	t.findSubordinates(d, v, pickedDefault)
+	t := newTrimmer(inst.Value(), cfg)
 
 	// Remove subordinate values from files.
 	for _, f := range files {
 		astutil.Apply(f, func(c astutil.Cursor) bool {
-			if f, ok := c.Node().(*ast.Field); ok && t.remove[f.Value] && !t.exclude[f.Value] {
+			if f, ok := c.Node().(*ast.Field); ok && t.removed(f) {
 				c.Delete()
 			}
 			return true
@@ -102,6 +92,70 @@ func Files(files []*ast.File, inst cue.InstanceOrValue, cfg *Config) error {
 	return nil
 }
 
+// A FileEdit lists the fields that Trim removes from a single file.
+type FileEdit struct {
+	// Filename is the name of the file.
+	Filename string
+
+	// Removed holds the fields to remove, in the order in which they appear
+	// in the file. Fields nested within removed fields are not included.
+	Removed []*ast.Field
+}
+
+// Trim reports the fields in the files of inst that can be implied from other
+// fields, as can be derived from the evaluated instance, without modifying
+// the files. An edit is returned for each file from which fields can be
+// removed, in the order of the files of inst.
+//
+// The edits can be applied with Apply. Alternatively, the positions of the
+// removed fields can be used to apply the edits to the source text.
+func Trim(inst *build.Instance, cfg *Config) ([]FileEdit, error) {
+	if inst.Err != nil {
+		return nil, inst.Err
+	}
+	v := cuecontext.New().BuildInstance(inst)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	t := newTrimmer(v, cfg)
+
+	var edits []FileEdit
+	for _, f := range inst.Files {
+		var removed []*ast.Field
+		ast.Walk(f, func(n ast.Node) bool {
+			if f, ok := n.(*ast.Field); ok && t.removed(f) {
+				removed = append(removed, f)
+				return false
+			}
+			return true
+		}, nil)
+		if len(removed) > 0 {
+			edits = append(edits, FileEdit{Filename: f.Filename, Removed: removed})
+		}
+	}
+	return edits, nil
+}
+
+// Apply removes the fields of e from f, which must be the file from which
+// the edit was computed.
+func (e FileEdit) Apply(f *ast.File) error {
+	remove := map[*ast.Field]bool{}
+	for _, x := range e.Removed {
+		remove[x] = true
+	}
+	astutil.Apply(f, func(c astutil.Cursor) bool {
+		if f, ok := c.Node().(*ast.Field); ok && remove[f] {
+			c.Delete()
+			return false
+		}
+		return true
+	}, nil)
+	return astutil.Sanitize(f)
+}
+
 type trimmer struct {
 	Config
 
@@ -116,6 +170,30 @@ type trimmer struct {
 
 var Debug bool = false
 
+// newTrimmer returns a trimmer that has determined the values of v that may
+// be removed.
+func newTrimmer(v cue.Value, cfg *Config) *trimmer {
+	r, x := value.ToInternal(v)
+
+	t := &trimmer{
+		Config:  *cfg,
+		ctx:     adt.NewContext(r, x),
+		remove:  map[ast.Node]bool{},
+		exclude: map[ast.Node]bool{},
+		debug:   Debug,
+		w:       os.Stderr,
+	}
+
+	d, _, _, pickedDefault := t.addDominators(nil, x, false)
+	t.findSubordinates(d, x, pickedDefault)
+	return t
+}
+
+// removed reports whether the field f is to be removed.
+func (t *trimmer) removed(f *ast.Field) bool {
+	return t.remove[f.Value] && !t.exclude[f.Value]
+}
+
 func (t *trimmer) markRemove(c adt.Conjunct) {
 	if src := c.Expr().Source(); src != nil {
 		t.remove[src] = true
@@ -276,6 +276,47 @@ foo: entry: {
 
 const trace = false
 
+func TestTrim(t *testing.T) {
+	in := `
+-- in.cue --
+foo: [string]: a: *1 | int
+foo: b: a: 1
+foo: c: a: 2
+-- other.cue --
+bar: 3
+`
+	a := txtar.Parse([]byte(in))
+	inst := cuetxtar.Load(a, "/tmp/test")[0]
+
+	edits, err := Trim(inst, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 1 {
+		t.Fatalf("got %d edits; want 1", len(edits))
+	}
+	e := edits[0]
+	if e.Filename != "/tmp/test/in.cue" || len(e.Removed) != 1 {
+		t.Fatalf("unexpected edit %+v", e)
+	}
+	if pos := e.Removed[0].Pos(); pos.Line() != 2 || pos.Column() != 9 {
+		t.Errorf("got removed field at %v; want line 2, column 9", pos)
+	}
+
+	f := inst.Files[0]
+	if err := e.Apply(f); err != nil {
+		t.Fatal(err)
+	}
+	got := string(formatNode(t, f))
+	want := `foo: [string]: a: *1 | int
+foo: b: {}
+foo: c: a: 2
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
 func TestData(t *testing.T) {
 	test := cuetxtar.TxTarTest{
 		Root:   "./testdata",